@@ -1,10 +1,11 @@
 const fs = require('fs');
+const path = require('path');
 
 /*****************************************************************************
  * Read puzzle input, this is such a goofy way, but this is what Google Gemini
  * gave me when I googled how to do this.
  *****************************************************************************/
-readInput('../input.txt')
+readInput(inputPath())
   .then(data => {
     let d = [];
     let left = [];
@@ -26,6 +27,26 @@ readInput('../input.txt')
     console.error('Error reading file:', err);
   });
 
+/*****************************************************************************
+ * Find the puzzle input: aoc passes its path in AOC_INPUT, and run by hand we
+ * look for 01.in under the repository root, the first directory up from here
+ * that holds AdventOfCode.
+ *****************************************************************************/
+function inputPath() {
+  if (process.env.AOC_INPUT) {
+    return process.env.AOC_INPUT;
+  }
+  let dir = process.cwd();
+  while (!fs.existsSync(path.join(dir, 'AdventOfCode'))) {
+    const parent = path.dirname(dir);
+    if (parent === dir) {
+      throw new Error('not inside the repository; set AOC_INPUT to the input file');
+    }
+    dir = parent;
+  }
+  return path.join(dir, 'AdventOfCode', '2024', '01.in');
+}
+
 function readInput(fin) {
   return new Promise((resolve, reject) => {
     fs.readFile(fin, 'utf8', (err, data) => {
@@ -1,73 +1,51 @@
+//go:build ignore
+
 package main
 
 import (
+  "encoding/json"
   "fmt"
   "os"
-  "regexp"
-  "strconv"
-  "strings"
+
+  "github.com/nixternal/CodingChallenges/AdventOfCode/2024/day03"
+  "github.com/nixternal/CodingChallenges/pkg/input"
 )
 
-// Read the input data from "input.txt" file. Parse the data into a string of
-// the "memory dump" and return it.
-func readPuzzleInput() string {
-  file, err := os.ReadFile("../input.txt")
+// Whether "aoc run -explain" asked for the steps to be traced.
+var explain = os.Getenv("AOC_EXPLAIN") != ""
+
+// Trace a step as a line on standard error for the runner to print. The
+// fields are JSON, so their keys come out sorted.
+func trace(event string, fields map[string]any) {
+  fields["event"] = event
+  line, err := json.Marshal(fields)
   if err != nil {
     panic(err)
   }
-
-  memory := string(file)
-  return memory
-}
-
-// Find all of the digits inside of a "mul()", ie mul(1,2) would give us [1,2].
-// Return a list of all matches
-func getMatches(memory string) [][]string {
-  re := regexp.MustCompile(`mul\((\d+),(\d+)\)`)
-  return re.FindAllStringSubmatch(memory, -1)
+  fmt.Fprintf(os.Stderr, "aoc-trace: %s\n", line)
 }
 
-// Get all of the "mul()" digits from "getMatches()" function, multiply each
-// pair of digits and then add their products together. Return the sum of
-// products.
-func partOne(memory string) int {
-  sum := 0
-  matches := getMatches(memory)
-  for _, match := range matches {
-    a, err1 := strconv.Atoi(match[1])
-    b, err2 := strconv.Atoi(match[2])
-    if err1 != nil || err2 != nil {
-      fmt.Println("Error converting to int:", err1, err2)
-      continue
-    }
-    sum += a * b
+// Solve the puzzle, on the input input.Path finds, with day03, which holds
+// the code so that other programs can import it, and print the answers.
+// Part two's steps are traced when "aoc run -explain" asks.
+func main() {
+  if explain {
+    day03.Trace = trace
   }
-  return sum
-}
-
-// In part 2 of the puzzle we only sum the values of our digits if they follow
-// a "do()" function in the corrupted memory dump.
-func partTwo(memory string, enabled bool) int {
-  if enabled {
-    if strings.Contains(memory, "don't()") {
-      i := strings.Index(memory, "don't")
-      return partOne(memory[:i]) + partTwo(memory[i:], false)
-    } else {
-      return partOne(memory)
-    }
-  } else {
-    if strings.Contains(memory, "do()") {
-      i := strings.Index(memory, "do()")
-      return partTwo(memory[i:], true)
-    } else {
-      return 0
-    }
+  path, err := input.Path("AdventOfCode/2024/03.in")
+  if err != nil {
+    panic(err)
   }
-}
+  file, err := os.Open(path)
+  if err != nil {
+    panic(err)
+  }
+  defer file.Close()
 
-// Orchestrate the program flow by calling the functions to print out the
-// solved Part 1 and Part 2 functions of the puzzle.
-func main() {
-  fmt.Println("Part 1:", partOne(readPuzzleInput()))        // 174960292
-  fmt.Println("Part 2:", partTwo(readPuzzleInput(), true))  // 56275602
+  partOne, partTwo, err := day03.Solve(file)
+  if err != nil {
+    panic(err)
+  }
+  fmt.Println("Part 1:", partOne)  // 174960292
+  fmt.Println("Part 2:", partTwo)  // 56275602
 }
@@ -0,0 +1,80 @@
+// Package day01 solves Advent of Code 2024 day 1, Historian Hysteria, for
+// 01.go and for anything else that wants the answers from an input of its
+// own.
+package day01
+
+import (
+  "fmt"
+  "io"
+  "math"
+  "sort"
+
+  "github.com/nixternal/CodingChallenges/pkg/input"
+)
+
+// Parse reads the two lists, one pair of integers to a line, and returns
+// them sorted. Lines with fewer than two fields, such as blank ones, are
+// skipped.
+func Parse(r io.Reader) (left, right []int, err error) {
+  // The fields are parsed as bytes; strconv.Atoi would need a string made
+  // for every one, which on a million-line input costs more than the
+  // puzzle.
+  var fields [][]byte
+  n := 0
+  for line, err := range input.Lines(r) {
+    if err != nil {
+      return nil, nil, err
+    }
+    n++
+    fields = input.Fields(fields[:0], []byte(line))
+    if len(fields) < 2 {
+      continue
+    }
+    l, err := input.ParseInt(fields[0])
+    if err != nil {
+      return nil, nil, fmt.Errorf("line %d: invalid input %q", n, fields[0])
+    }
+    r, err := input.ParseInt(fields[1])
+    if err != nil {
+      return nil, nil, fmt.Errorf("line %d: invalid input %q", n, fields[1])
+    }
+    left = append(left, l)
+    right = append(right, r)
+  }
+  sort.Ints(left)
+  sort.Ints(right)
+  return left, right, nil
+}
+
+// PartOne computes the sum of absolute differences between corresponding
+// elements in the sorted slices, left & right.
+func PartOne(left []int, right []int) int {
+  sum := 0
+  for i := 0; i < len(left); i++ {
+    sum += int(math.Abs(float64(left[i] - right[i])))
+  }
+  return sum
+}
+
+// PartTwo computes the weighted sum by multiplying each element in the left
+// by its frequency in the right, using a frequency map for efficiency.
+func PartTwo(left []int, right []int) int {
+  freq := make(map[int]int)
+  for _, val := range right {
+    freq[val]++
+  }
+  sum := 0
+  for _, l := range left {
+    sum += l * freq[l]
+  }
+  return sum
+}
+
+// Solve parses an input and returns the answers to both parts.
+func Solve(r io.Reader) (int, int, error) {
+  left, right, err := Parse(r)
+  if err != nil {
+    return 0, 0, err
+  }
+  return PartOne(left, right), PartTwo(left, right), nil
+}
@@ -0,0 +1,48 @@
+package day01
+
+import (
+  "errors"
+  "io"
+  "strings"
+  "testing"
+  "testing/iotest"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+const example = `3   4
+4   3
+2   5
+1   3
+3   9
+3   3
+`
+
+func TestParse(t *testing.T) {
+  left, right, err := Parse(strings.NewReader(example + "\n"))
+  testx.NoError(t, err)
+  testx.SlicesEqual(t, left, []int{1, 2, 3, 3, 3, 4})
+  testx.SlicesEqual(t, right, []int{3, 3, 3, 4, 5, 9})
+}
+
+func TestExample(t *testing.T) {
+  partOne, partTwo, err := Solve(strings.NewReader(example))
+  testx.NoError(t, err)
+  testx.Equal(t, partOne, 11)
+  testx.Equal(t, partTwo, 31)
+}
+
+func TestParseError(t *testing.T) {
+  _, _, err := Parse(strings.NewReader("1 2\n3 x\n"))
+  if err == nil || !strings.Contains(err.Error(), "line 2") {
+    t.Errorf("got %v, want an error on line 2", err)
+  }
+}
+
+func TestReadError(t *testing.T) {
+  disk := errors.New("disk on fire")
+  _, _, err := Solve(io.MultiReader(strings.NewReader(example), iotest.ErrReader(disk)))
+  if !errors.Is(err, disk) {
+    t.Errorf("got %v, want %v", err, disk)
+  }
+}
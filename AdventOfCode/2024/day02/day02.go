@@ -0,0 +1,130 @@
+// Package day02 solves Advent of Code 2024 day 2, Red-Nosed Reports, for
+// 02.go and for anything else that wants the answers from an input of its
+// own.
+package day02
+
+import (
+  "fmt"
+  "io"
+
+  "github.com/nixternal/CodingChallenges/pkg/input"
+  "github.com/nixternal/CodingChallenges/pkg/pool"
+)
+
+// Parse reads the reports, one to a line, each a list of integer levels
+// separated by whitespace. Blank lines are skipped.
+func Parse(r io.Reader) ([][]int, error) {
+  var reports [][]int  // Slice to hold the parsed data
+
+  // Read the input line-by-line, parsing the fields as bytes
+  var fields [][]byte
+  n := 0
+  for line, err := range input.Lines(r) {
+    if err != nil {
+      return nil, err
+    }
+    n++
+    report := make([]int, 0, 8)
+
+    // Convert each field to an integer
+    fields = input.Fields(fields[:0], []byte(line))
+    if len(fields) == 0 {
+      continue
+    }
+    for _, field := range fields {
+      value, err := input.ParseInt(field)
+      if err != nil {
+        return nil, fmt.Errorf("line %d: invalid level %q", n, field)
+      }
+      report = append(report, value)
+    }
+    reports = append(reports, report)  // Add the parsed line to the reports
+  }
+  return reports, nil
+}
+
+// IsSafe checks if report is "safe" based on specific criteria:
+//   - A report is considered "increasing" if differences between consecutive
+//     numbers are between 1 & 3 (inclusive)
+//   - A report is considered "decreasing" if differences between consecutive
+//     numbers are between -1 & -3 (inclusive)
+// Return "true" if the report is either increasing or decreasing
+func IsSafe(report []int) bool {
+  increasing := true
+  decreasing := true
+
+  for i := 1; i < len(report); i++ {
+    diff := report[i] - report[i-1]
+
+    if diff < 1 || diff > 3 {
+      increasing = false
+    }
+    if diff < -3 || diff > -1 {
+      decreasing = false
+    }
+  }
+
+  return increasing || decreasing
+}
+
+// CanBeSafe checks if a report can become "safe" by removing one element. For each
+// element in the report, the function builds the report without that
+// element in "scratch" and checks it with "IsSafe()". Returns "true" if
+// removing a single element results in a safe report. A safe report
+// stays safe without its first level, so it counts; the empty report has
+// no element to remove, so it does not.
+//
+// The scratch slice is reused for every element and every report, so a
+// caller passes the one it got back last time and nothing is allocated
+// once it is big enough; see BenchmarkPartTwo.
+func CanBeSafe(report []int, scratch []int) (bool, []int) {
+  for i := 0; i < len(report); i++ {
+    scratch = append(append(scratch[:0], report[:i]...), report[i+1:]...)
+    if IsSafe(scratch) {
+      return true, scratch
+    }
+  }
+  return false, scratch
+}
+
+// PartOne counts how many reports in the given 2D slice are "safe". Uses the
+// "IsSafe()" function to determine if a report is safe. Returns the number of
+// safe reports.
+func PartOne(reports [][]int) int {
+  num_safe := 0
+  for _, report := range reports {
+    if IsSafe(report) {
+      num_safe += 1
+    }
+  }
+  return num_safe
+}
+
+// PartTwo counts how many reports in the given 2D slice are "safe" and can be made
+// "safe" by removing a single element. It uses the "CanBeSafe()" function
+// for these checks.
+func PartTwo(reports [][]int) int {
+  num_safe := 0
+  scratch := candidates.Get(8)
+  for _, report := range reports {
+    var ok bool
+    if ok, scratch = CanBeSafe(report, scratch); ok {
+      num_safe += 1
+    }
+  }
+  candidates.Put(scratch)
+  return num_safe
+}
+
+// The scratch slices "PartTwo()" builds candidate reports in, pooled so
+// that calls one after another, or several at once, reuse them.
+var candidates pool.Slices[int]
+
+// Solve parses an input and returns the answers to both parts.
+func Solve(r io.Reader) (int, int, error) {
+  reports, err := Parse(r)
+  if err != nil {
+    return 0, 0, err
+  }
+  return PartOne(reports), PartTwo(reports), nil
+}
@@ -0,0 +1,218 @@
+package day02
+
+import (
+  "errors"
+  "io"
+  "math/rand"
+  "strings"
+  "testing"
+  "testing/iotest"
+
+  "github.com/nixternal/CodingChallenges/pkg/prop"
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+const example = `7 6 4 2 1
+1 2 7 8 9
+9 7 6 2 1
+1 3 2 4 5
+8 6 4 4 1
+1 3 6 7 9
+`
+
+func TestExample(t *testing.T) {
+  for _, c := range []struct {
+    name, input string
+  }{
+    {"example", example},
+    // A blank line is no report, not an empty one that counts as safe.
+    {"blank line", strings.Replace(example, "\n", "\n\n", 1)},
+    {"trailing blank lines", example + "\n\n"},
+  } {
+    t.Run(c.name, func(t *testing.T) {
+      partOne, partTwo, err := Solve(strings.NewReader(c.input))
+      testx.NoError(t, err)
+      testx.Equal(t, partOne, 2)
+      testx.Equal(t, partTwo, 4)
+    })
+  }
+}
+
+func TestCanBeSafeEmpty(t *testing.T) {
+  if ok, _ := CanBeSafe(nil, nil); ok {
+    t.Error("the empty report can be made safe")
+  }
+}
+
+// The first version of "CanBeSafe()", which allocates a new slice for each
+// element removed. Kept to benchmark against.
+func canBeSafeAlloc(report []int) bool {
+  for i := 0; i < len(report); i++ {
+    // Create a new slice excluding the element at index i
+    newReport := append([]int{}, report[:i]...)    // Copy elements before i
+    newReport = append(newReport, report[i+1:]...) // Append elements after i
+
+    if IsSafe(newReport) {
+      return true
+    }
+  }
+  return false
+}
+
+// Part two as it first was, allocating a new report for each level it
+// tries removing. Kept to benchmark against.
+func partTwoAlloc(reports [][]int) int {
+  num_safe := 0
+  for _, report := range reports {
+    if canBeSafeAlloc(report) {
+      num_safe += 1
+    }
+  }
+  return num_safe
+}
+
+// A thousand random reports the size of the puzzle's, most of them unsafe
+// so that every removal gets tried.
+func benchReports() [][]int {
+  rng := rand.New(rand.NewSource(1))
+  reports := make([][]int, 1000)
+  for i := range reports {
+    reports[i] = make([]int, 5+rng.Intn(4))
+    for j := range reports[i] {
+      reports[i][j] = 1 + rng.Intn(99)
+    }
+  }
+  return reports
+}
+
+// Part two with a new report allocated for each removal, and with the
+// pooled scratch slice, which allocates only the small header the pool
+// keeps it under.
+func BenchmarkPartTwo(b *testing.B) {
+  reports := benchReports()
+  for _, c := range []struct {
+    name string
+    f    func([][]int) int
+  }{{"allocating", partTwoAlloc}, {"scratch", PartTwo}} {
+    b.Run(c.name, func(b *testing.B) {
+      b.ReportAllocs()
+      for b.Loop() {
+        c.f(reports)
+      }
+    })
+  }
+}
+
+// "CanBeSafe()" without a scratch slice to keep, for the properties.
+func canBeSafeOnce(report []int) bool {
+  ok, _ := CanBeSafe(report, nil)
+  return ok
+}
+
+// Random safe reports: a start anywhere either side of zero, so negative
+// levels get exercised, then 0 to 7 steps of 1 to 3 in a random
+// direction. They shrink by dropping levels from the end, which keeps
+// them safe.
+var safeReports = prop.Gen[[]int]{
+  Generate: func(rng *rand.Rand, size int) []int {
+    sign := 1
+    if rng.Intn(2) == 0 {
+      sign = -1
+    }
+    report := []int{rng.Intn(41) - 20}
+    for n := rng.Intn(min(size, 7) + 1); n > 0; n-- {
+      report = append(report, report[len(report)-1]+sign*(1+rng.Intn(3)))
+    }
+    return report
+  },
+  Shrink: func(report []int) [][]int {
+    var out [][]int
+    for n := 1; n < len(report); n++ {
+      out = append(out, report[:n])
+    }
+    return out
+  },
+}
+
+// Any report at all, of up to 8 levels between -10 and 10. Small levels
+// make equal neighbours and steps of exactly 3 and 4 likely.
+var reports = prop.SliceOf(prop.Int(-10, 10), 8)
+
+// Map a report to another one level by level.
+func mapReport(report []int, f func(i int) int) []int {
+  out := make([]int, len(report))
+  for i := range report {
+    out[i] = f(i)
+  }
+  return out
+}
+
+// Insert level into report before index i, taken modulo the places there
+// are, without changing report.
+func insert(report []int, i, level int) []int {
+  i %= len(report) + 1
+  return append(append(append([]int{}, report[:i]...), level), report[i:]...)
+}
+
+// The properties that pin down "IsSafe()" and "CanBeSafe()", each checked
+// against a thousand random reports, shrunk to a small one on failure.
+func TestProperties(t *testing.T) {
+  var c prop.Config
+  t.Run("steps of 1 to 3 in one direction are safe", func(t *testing.T) {
+    prop.Check(t, c, safeReports, IsSafe)
+  })
+  t.Run("negating every level keeps safety", func(t *testing.T) {
+    prop.Check(t, c, reports, func(r []int) bool {
+      return IsSafe(r) == IsSafe(mapReport(r, func(i int) int { return -r[i] }))
+    })
+  })
+  t.Run("reversing keeps safety", func(t *testing.T) {
+    prop.Check(t, c, reports, func(r []int) bool {
+      return IsSafe(r) == IsSafe(mapReport(r, func(i int) int { return r[len(r)-1-i] }))
+    })
+  })
+  t.Run("shifting every level keeps safety", func(t *testing.T) {
+    prop.Check(t, c, prop.PairOf(reports, prop.Int(-100, 100)), func(p prop.Pair[[]int, int]) bool {
+      r, shift := p.A, p.B
+      return IsSafe(r) == IsSafe(mapReport(r, func(i int) int { return r[i] + shift }))
+    })
+  })
+  t.Run("a step of 0 or of 4 or more is unsafe", func(t *testing.T) {
+    steps := []int{0, 4, 5, -4, -5}
+    g := prop.PairOf(safeReports, prop.PairOf(prop.Int(0, 7), prop.Int(0, len(steps)-1)))
+    prop.Check(t, c, g, func(p prop.Pair[[]int, prop.Pair[int, int]]) bool {
+      r, i := p.A, p.B.A%len(p.A)
+      return !IsSafe(insert(r, i+1, r[i]+steps[p.B.B]))
+    })
+  })
+  t.Run("a safe report can be made safe", func(t *testing.T) {
+    prop.Check(t, c, reports, func(r []int) bool {
+      return len(r) == 0 || !IsSafe(r) || canBeSafeOnce(r)
+    })
+  })
+  t.Run("one level inserted anywhere can be removed again", func(t *testing.T) {
+    g := prop.PairOf(safeReports, prop.PairOf(prop.Int(0, 8), prop.Int(-20, 20)))
+    prop.Check(t, c, g, func(p prop.Pair[[]int, prop.Pair[int, int]]) bool {
+      return canBeSafeOnce(insert(p.A, p.B.A, p.B.B))
+    })
+  })
+  t.Run("reusing a scratch slice gives the same answers", func(t *testing.T) {
+    prop.Check(t, c, prop.PairOf(reports, prop.Int(0, 9)), func(p prop.Pair[[]int, int]) bool {
+      ok, _ := CanBeSafe(p.A, make([]int, p.B, 10))
+      return ok == canBeSafeAlloc(p.A)
+    })
+  })
+  t.Run("trimming either end keeps a report that can be made safe", func(t *testing.T) {
+    prop.Check(t, c, reports, func(r []int) bool {
+      return len(r) < 2 || !canBeSafeOnce(r) || canBeSafeOnce(r[1:]) && canBeSafeOnce(r[:len(r)-1])
+    })
+  })
+}
+
+func TestReadError(t *testing.T) {
+  disk := errors.New("disk on fire")
+  _, _, err := Solve(io.MultiReader(strings.NewReader(example), iotest.ErrReader(disk)))
+  if !errors.Is(err, disk) {
+    t.Errorf("got %v, want %v", err, disk)
+  }
+}
@@ -0,0 +1,113 @@
+// Package day03 solves Advent of Code 2024 day 3, Mull It Over, for 03.go
+// and for anything else that wants the answers from an input of its own.
+package day03
+
+import (
+  "io"
+  "iter"
+  "regexp"
+  "strconv"
+  "strings"
+
+  "github.com/nixternal/CodingChallenges/pkg/input"
+)
+
+var mulPattern = regexp.MustCompile(`mul\((\d+),(\d+)\)`)
+
+// Muls yields the digits inside of each "mul()" in turn, ie mul(1,2) would
+// give us 1 and 2. The next match is only looked for once the caller asks
+// for it, so nothing is collected up front. The pattern allows only digits,
+// so the one thing that stops a number converting is one too big for an
+// int, and such a "mul()" is skipped.
+func Muls(memory string) iter.Seq2[int, int] {
+  return func(yield func(int, int) bool) {
+    for rest := memory; ; {
+      m := mulPattern.FindStringSubmatchIndex(rest)
+      if m == nil {
+        return
+      }
+      a, err1 := strconv.Atoi(rest[m[2]:m[3]])
+      b, err2 := strconv.Atoi(rest[m[4]:m[5]])
+      rest = rest[m[1]:]
+      if err1 != nil || err2 != nil {
+        continue
+      }
+      if !yield(a, b) {
+        return
+      }
+    }
+  }
+}
+
+// PartOne gets all of the "mul()" digits from "Muls()", multiplies each pair
+// of digits and then adds their products together.
+func PartOne(memory string) int {
+  sum := 0
+  for a, b := range Muls(memory) {
+    sum += a * b
+  }
+  return sum
+}
+
+// Trace, if set, is called with each switch part two makes, for
+// "aoc run -explain".
+var Trace func(event string, fields map[string]any)
+
+func trace(event string, fields map[string]any) {
+  if Trace != nil {
+    Trace(event, fields)
+  }
+}
+
+// PartTwo only sums the values of our digits if they follow a "do()"
+// function in the corrupted memory dump; enabled says whether the memory
+// starts out that way, which the puzzle's does.
+func PartTwo(memory string, enabled bool) int {
+  sum, _ := partTwo(memory, enabled)
+  return sum
+}
+
+// partTwo is PartTwo that also says whether the memory ends enabled, for
+// carrying on into more of it.
+func partTwo(memory string, enabled bool) (int, bool) {
+  if enabled {
+    if strings.Contains(memory, "don't()") {
+      i := strings.Index(memory, "don't")
+      sum := PartOne(memory[:i])
+      trace("don't", map[string]any{"counted": memory[:i], "sum": sum})
+      rest, enabled := partTwo(memory[i:], false)
+      return sum + rest, enabled
+    } else {
+      sum := PartOne(memory)
+      trace("end", map[string]any{"counted": memory, "sum": sum})
+      return sum, true
+    }
+  } else {
+    if strings.Contains(memory, "do()") {
+      i := strings.Index(memory, "do()")
+      trace("do", map[string]any{"skipped": memory[:i]})
+      return partTwo(memory[i:], true)
+    } else {
+      trace("end", map[string]any{"skipped": memory})
+      return 0, false
+    }
+  }
+}
+
+// Solve reads the memory dump and returns the answers to both parts. No
+// "mul()", "do()" or "don't()" can run over a line break, so the dump is
+// read a line at a time, with whether it is enabled carried from each
+// line to the next, rather than held whole.
+func Solve(r io.Reader) (int, int, error) {
+  one, two, enabled := 0, 0, true
+  for line, err := range input.Lines(r) {
+    if err != nil {
+      return 0, 0, err
+    }
+    one += PartOne(line)
+    var sum int
+    sum, enabled = partTwo(line, enabled)
+    two += sum
+  }
+  return one, two, nil
+}
@@ -0,0 +1,63 @@
+package day03
+
+import (
+  "errors"
+  "fmt"
+  "io"
+  "maps"
+  "slices"
+  "strings"
+  "testing"
+  "testing/iotest"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+const example = "xmul(2,4)&mul[3,7]!^don't()_mul(5,5)+mul(32,64](mul(11,8)undo()?mul(8,5))"
+
+func TestMuls(t *testing.T) {
+  var got [][2]int
+  for a, b := range Muls(example) {
+    got = append(got, [2]int{a, b})
+  }
+  testx.SlicesEqual(t, got, [][2]int{{2, 4}, {5, 5}, {11, 8}, {8, 5}})
+}
+
+func TestExample(t *testing.T) {
+  partOne, partTwo, err := Solve(strings.NewReader(example))
+  testx.NoError(t, err)
+  testx.Equal(t, partOne, 161)
+  testx.Equal(t, partTwo, 48)
+}
+
+// A don't() on one line still holds on the next.
+func TestLines(t *testing.T) {
+  _, partTwo, err := Solve(strings.NewReader("mul(1,2)don't()\nmul(3,4)\ndo()mul(5,6)\n"))
+  testx.NoError(t, err)
+  testx.Equal(t, partTwo, 32)
+}
+
+// The switches part two makes on the example, as "aoc run -explain" shows
+// them.
+func TestTrace(t *testing.T) {
+  var b strings.Builder
+  Trace = func(event string, fields map[string]any) {
+    fmt.Fprint(&b, event)
+    for _, k := range slices.Sorted(maps.Keys(fields)) {
+      fmt.Fprintf(&b, " %s=%q", k, fmt.Sprint(fields[k]))
+    }
+    b.WriteByte('\n')
+  }
+  defer func() { Trace = nil }()
+  testx.Equal(t, PartTwo(example, true), 48)
+  testx.Snapshot(t, "trace", b.String())
+}
+
+// A read error is returned, not a sum of whatever was read before it.
+func TestReadError(t *testing.T) {
+  disk := errors.New("disk on fire")
+  partOne, partTwo, err := Solve(io.MultiReader(strings.NewReader(example+"\n"), iotest.ErrReader(disk)))
+  if !errors.Is(err, disk) || partOne != 0 || partTwo != 0 {
+    t.Errorf("got %d, %d, %v; want %v", partOne, partTwo, err, disk)
+  }
+}
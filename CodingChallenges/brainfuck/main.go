@@ -0,0 +1,276 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "time"
+)
+
+const tapeSize = 30000
+
+var errTape = errors.New("pointer moved off the tape")
+
+// Match brackets up front so compiled loops can jump in constant time and
+// unbalanced programs are reported before running anything.
+func matchBrackets(prog []byte) (map[int]int, error) {
+  jumps := map[int]int{}
+  var stack []int
+  for i, c := range prog {
+    switch c {
+    case '[':
+      stack = append(stack, i)
+    case ']':
+      if len(stack) == 0 {
+        return nil, fmt.Errorf("unmatched ']' at offset %d", i)
+      }
+      open := stack[len(stack)-1]
+      stack = stack[:len(stack)-1]
+      jumps[open], jumps[i] = i, open
+    }
+  }
+  if len(stack) > 0 {
+    return nil, fmt.Errorf("unmatched '[' at offset %d", stack[len(stack)-1])
+  }
+  return jumps, nil
+}
+
+// Run the program one character at a time, scanning for the matching
+// bracket on every jump. Cells are bytes that wrap; at end of input the
+// current cell is set to zero.
+func interpret(prog []byte, in *bufio.Reader, out *bufio.Writer) error {
+  if _, err := matchBrackets(prog); err != nil {
+    return err
+  }
+  tape := make([]byte, tapeSize)
+  ptr := 0
+  for pc := 0; pc < len(prog); pc++ {
+    switch prog[pc] {
+    case '+':
+      tape[ptr]++
+    case '-':
+      tape[ptr]--
+    case '>':
+      if ptr++; ptr >= tapeSize {
+        return errTape
+      }
+    case '<':
+      if ptr--; ptr < 0 {
+        return errTape
+      }
+    case '.':
+      out.WriteByte(tape[ptr])
+    case ',':
+      out.Flush()
+      c, _ := in.ReadByte()
+      tape[ptr] = c
+    case '[':
+      if tape[ptr] == 0 {
+        pc = scan(prog, pc, 1)
+      }
+    case ']':
+      if tape[ptr] != 0 {
+        pc = scan(prog, pc, -1)
+      }
+    }
+  }
+  return nil
+}
+
+// Find the bracket matching the one at pc, searching in direction dir.
+func scan(prog []byte, pc, dir int) int {
+  depth := 0
+  for ; ; pc += dir {
+    switch prog[pc] {
+    case '[':
+      depth += dir
+    case ']':
+      depth -= dir
+    }
+    if depth == 0 {
+      return pc
+    }
+  }
+}
+
+type opcode byte
+
+const (
+  opAdd   opcode = iota // add arg to the current cell
+  opMove                // move the pointer by arg
+  opOut                 // write the current cell
+  opIn                  // read into the current cell
+  opJz                  // jump to arg if the current cell is zero
+  opJnz                 // jump to arg if the current cell is not zero
+  opClear               // set the current cell to zero, from [-] or [+]
+)
+
+type instr struct {
+  op  opcode
+  arg int
+}
+
+// Compile the program into instructions: runs of +- and <> collapse into a
+// single add or move, [-] and [+] become a clear, and loop instructions
+// carry the index of their partner.
+func compile(prog []byte) ([]instr, error) {
+  if _, err := matchBrackets(prog); err != nil {
+    return nil, err
+  }
+  var code []instr
+  var stack []int
+  for i := 0; i < len(prog); i++ {
+    switch c := prog[i]; c {
+    case '+', '-', '>', '<':
+      delta := 0
+      op := opAdd
+      if c == '>' || c == '<' {
+        op = opMove
+      }
+      for ; i < len(prog); i++ {
+        switch {
+        case prog[i] == '+' && op == opAdd, prog[i] == '>' && op == opMove:
+          delta++
+        case prog[i] == '-' && op == opAdd, prog[i] == '<' && op == opMove:
+          delta--
+        case isCommand(prog[i]):
+          goto done
+        }
+      }
+    done:
+      i--
+      if delta != 0 {
+        code = append(code, instr{op, delta})
+      }
+    case '.':
+      code = append(code, instr{op: opOut})
+    case ',':
+      code = append(code, instr{op: opIn})
+    case '[':
+      if i+2 < len(prog) && (prog[i+1] == '-' || prog[i+1] == '+') && prog[i+2] == ']' {
+        code = append(code, instr{op: opClear})
+        i += 2
+        continue
+      }
+      stack = append(stack, len(code))
+      code = append(code, instr{op: opJz})
+    case ']':
+      open := stack[len(stack)-1]
+      stack = stack[:len(stack)-1]
+      code[open].arg = len(code)
+      code = append(code, instr{opJnz, open})
+    }
+  }
+  return code, nil
+}
+
+func isCommand(c byte) bool {
+  switch c {
+  case '+', '-', '>', '<', '.', ',', '[', ']':
+    return true
+  }
+  return false
+}
+
+// Execute compiled instructions.
+func execute(code []instr, in *bufio.Reader, out *bufio.Writer) error {
+  tape := make([]byte, tapeSize)
+  ptr := 0
+  for pc := 0; pc < len(code); pc++ {
+    ins := code[pc]
+    switch ins.op {
+    case opAdd:
+      tape[ptr] += byte(ins.arg)
+    case opMove:
+      if ptr += ins.arg; ptr < 0 || ptr >= tapeSize {
+        return errTape
+      }
+    case opOut:
+      out.WriteByte(tape[ptr])
+    case opIn:
+      out.Flush()
+      c, _ := in.ReadByte()
+      tape[ptr] = c
+    case opJz:
+      if tape[ptr] == 0 {
+        pc = ins.arg
+      }
+    case opJnz:
+      if tape[ptr] != 0 {
+        pc = ins.arg
+      }
+    case opClear:
+      tape[ptr] = 0
+    }
+  }
+  return nil
+}
+
+// Run a program file (or code given with -e). -naive uses the plain
+// interpreter and -compare runs both and reports how long each took.
+func main() {
+  code := flag.String("e", "", "program text to run instead of a file")
+  naive := flag.Bool("naive", false, "use the unoptimised interpreter")
+  compareMode := flag.Bool("compare", false, "time the naive and optimised interpreters on the program")
+  flag.Parse()
+
+  prog := []byte(*code)
+  if *code == "" {
+    if flag.NArg() != 1 {
+      fmt.Fprintln(os.Stderr, "usage: brainfuck [-naive | -compare] (-e code | file.bf)")
+      os.Exit(2)
+    }
+    var err error
+    if prog, err = os.ReadFile(flag.Arg(0)); err != nil {
+      fmt.Fprintln(os.Stderr, "brainfuck:", err)
+      os.Exit(2)
+    }
+  }
+
+  in := bufio.NewReader(os.Stdin)
+  out := bufio.NewWriter(os.Stdout)
+  defer out.Flush()
+
+  runNaive := func(in *bufio.Reader, w *bufio.Writer) error { return interpret(prog, in, w) }
+  runFast := func(in *bufio.Reader, w *bufio.Writer) error {
+    compiled, err := compile(prog)
+    if err != nil {
+      return err
+    }
+    return execute(compiled, in, w)
+  }
+
+  var err error
+  switch {
+  case *compareMode:
+    // Both runs read the same input, so it is read up front, and output is
+    // discarded so only interpretation is timed.
+    var input []byte
+    if input, err = io.ReadAll(os.Stdin); err != nil {
+      break
+    }
+    for _, r := range []struct {
+      name string
+      run  func(*bufio.Reader, *bufio.Writer) error
+    }{{"naive", runNaive}, {"optimised", runFast}} {
+      start := time.Now()
+      if err = r.run(bufio.NewReader(bytes.NewReader(input)), bufio.NewWriter(io.Discard)); err != nil {
+        break
+      }
+      fmt.Fprintf(out, "%-10s %v\n", r.name, time.Since(start).Round(time.Millisecond))
+    }
+  case *naive:
+    err = runNaive(in, out)
+  default:
+    err = runFast(in, out)
+  }
+  if err != nil {
+    out.Flush()
+    fmt.Fprintln(os.Stderr, "brainfuck:", err)
+    os.Exit(1)
+  }
+}
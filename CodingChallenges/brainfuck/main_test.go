@@ -0,0 +1,108 @@
+package main
+
+import (
+  "bufio"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+const hello = `++++++++[>++++[>++>+++>+++>+<<<<-]>+>+>->>+[<]<-]>>.>---.+++++++..+++.>>.<-.<.+++.------.--------.>>+.>++.`
+
+type interpreter struct {
+  name string
+  run  func(prog []byte, in *bufio.Reader, out *bufio.Writer) error
+}
+
+var interpreters = []interpreter{
+  {"naive", interpret},
+  {"optimised", func(prog []byte, in *bufio.Reader, out *bufio.Writer) error {
+    code, err := compile(prog)
+    if err != nil {
+      return err
+    }
+    return execute(code, in, out)
+  }},
+}
+
+func runString(t testing.TB, i interpreter, prog, input string) (string, error) {
+  t.Helper()
+  var out strings.Builder
+  w := bufio.NewWriter(&out)
+  err := i.run([]byte(prog), bufio.NewReader(strings.NewReader(input)), w)
+  w.Flush()
+  return out.String(), err
+}
+
+func TestPrograms(t *testing.T) {
+  for _, c := range []struct {
+    name, prog, input, want string
+    err                     string
+  }{
+    {name: "hello", prog: hello, want: "Hello World!\n"},
+    {name: "cat", prog: ",[.,]", input: "echo me", want: "echo me"},
+    {name: "wrap", prog: "-.+.[+]+.", want: "\xff\x00\x01"},
+    {name: "clear", prog: "+++[-].++[+]." + "+++>[-]<.", want: "\x00\x00\x03"},
+    {name: "comments", prog: "a+b+c. this is ignored", want: "\x02"},
+    {name: "left", prog: "<", err: errTape.Error()},
+    {name: "right", prog: "+[>+]", err: errTape.Error()},
+    {name: "unmatched open", prog: "+[", err: "unmatched '[' at offset 1"},
+    {name: "unmatched close", prog: "+]", err: "unmatched ']' at offset 1"},
+  } {
+    for _, i := range interpreters {
+      t.Run(c.name+"/"+i.name, func(t *testing.T) {
+        got, err := runString(t, i, c.prog, c.input)
+        if c.err != "" {
+          if err == nil || err.Error() != c.err {
+            t.Errorf("got error %v, want %s", err, c.err)
+          }
+          return
+        }
+        testx.NoError(t, err)
+        testx.Equal(t, got, c.want)
+      })
+    }
+  }
+}
+
+func mandelbrot(t testing.TB) string {
+  prog, err := os.ReadFile(filepath.Join("testdata", "mandelbrot.bf"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  return string(prog)
+}
+
+// testdata/mandelbrot.bf comes from testdata/mandelbrot.go, whose -ref
+// flag prints the picture it draws.
+func TestMandelbrot(t *testing.T) {
+  got, err := runString(t, interpreters[1], mandelbrot(t), "")
+  testx.NoError(t, err)
+  testx.Snapshot(t, "mandelbrot", got)
+}
+
+func BenchmarkMandelbrot(b *testing.B) {
+  prog := mandelbrot(b)
+  for _, i := range interpreters {
+    b.Run(i.name, func(b *testing.B) {
+      for b.Loop() {
+        if _, err := runString(b, i, prog, ""); err != nil {
+          b.Fatal(err)
+        }
+      }
+    })
+  }
+}
+
+func BenchmarkHello(b *testing.B) {
+  for _, i := range interpreters {
+    b.Run(i.name, func(b *testing.B) {
+      for b.Loop() {
+        runString(b, i, hello, "")
+      }
+    })
+  }
+}
@@ -0,0 +1,343 @@
+//go:build ignore
+
+// Generate mandelbrot.bf, a Mandelbrot set renderer for the benchmarks:
+//
+//   go run testdata/mandelbrot.go > testdata/mandelbrot.bf
+//
+// With -ref it prints what the program should print, worked out in Go
+// with the same arithmetic.
+//
+// The program works in fixed point with 4 fractional bits, so every value
+// it keeps fits a byte. Coordinates are biased by 128 so that adding and
+// subtracting are plain wrapping byte arithmetic; they are split into sign
+// and magnitude only to multiply. A point is tested at most 26 times and
+// printed as the letter of the test it escaped on, or a space if it never
+// did.
+package main
+
+import (
+  "flag"
+  "fmt"
+  "os"
+  "strings"
+)
+
+const (
+  rows, cols = 20, 40
+  x0, y0     = -32, -19 // the top left corner, in sixteenths
+  maxIter    = 26
+)
+
+// A program under construction, tracking where the pointer is so that
+// cells can be addressed by number.
+type program struct {
+  strings.Builder
+  ptr, cells int
+}
+
+// A fresh cell.
+func (p *program) cell() int {
+  p.cells++
+  return p.cells - 1
+}
+
+// A cell that can be tested: ifElse needs the two after it, zeroed.
+func (p *program) testable() int {
+  p.cells += 3
+  return p.cells - 3
+}
+
+func (p *program) at(c int) {
+  for ; p.ptr < c; p.ptr++ {
+    p.WriteByte('>')
+  }
+  for ; p.ptr > c; p.ptr-- {
+    p.WriteByte('<')
+  }
+}
+
+func (p *program) add(c, n int) {
+  p.at(c)
+  n = (n%256 + 256) % 256
+  if n <= 128 {
+    p.WriteString(strings.Repeat("+", n))
+  } else {
+    p.WriteString(strings.Repeat("-", 256-n))
+  }
+}
+
+func (p *program) clear(c int) {
+  p.at(c)
+  p.WriteString("[-]")
+}
+
+func (p *program) set(c, n int) {
+  p.clear(c)
+  p.add(c, n)
+}
+
+// Run body while cell c is not zero.
+func (p *program) loop(c int, body func()) {
+  p.at(c)
+  p.WriteByte('[')
+  body()
+  p.at(c)
+  p.WriteByte(']')
+}
+
+// Repeat body n times, counting down in a scratch cell.
+func (p *program) times(n int, body func()) {
+  k := p.cell()
+  p.set(k, n)
+  p.loop(k, func() {
+    p.add(k, -1)
+    body()
+  })
+}
+
+// Add cell src to each of dsts, leaving src zero.
+func (p *program) move(src int, dsts ...int) {
+  p.loop(src, func() {
+    p.add(src, -1)
+    for _, d := range dsts {
+      p.add(d, 1)
+    }
+  })
+}
+
+// Set dst to the value of src.
+func (p *program) copy(src, dst int) {
+  tmp := p.cell()
+  p.clear(dst)
+  p.move(src, dst, tmp)
+  p.move(tmp, src)
+}
+
+// Run then if testable cell x is not zero, and els if it is, with the
+// idiom x[then>-]>[<els>->]<< on the cells after x, which are left zero.
+func (p *program) ifElse(x int, then, els func()) {
+  p.add(x+1, 1)
+  p.at(x)
+  p.WriteByte('[')
+  if then != nil {
+    then()
+  }
+  p.at(x + 1)
+  p.WriteString("-]")
+  // The pointer is now at x+1 if x was zero, and x when it was not, until
+  // a step right puts it on x+1 only in the first case.
+  p.WriteString(">[")
+  p.ptr = x + 1
+  if els != nil {
+    els()
+  }
+  p.at(x + 1)
+  p.WriteByte('-')
+  p.at(x + 2)
+  p.WriteByte(']')
+  p.at(x)
+}
+
+// Decrement testable cell x unless it is zero.
+func (p *program) decSat(x int) {
+  p.ifElse(x, func() { p.add(x, -1) }, nil)
+}
+
+// Whether magnitude m exceeds n: flag is set to 1 or 0.
+func (p *program) greater(m, n, flag int) {
+  t := p.testable()
+  p.copy(m, t)
+  p.times(n, func() { p.decSat(t) })
+  p.clear(flag)
+  p.loop(t, func() {
+    p.clear(t)
+    p.add(flag, 1)
+  })
+}
+
+// Split biased value v into its magnitude and a sign of 1 if negative.
+func (p *program) split(v, mag, sign int) {
+  pos, neg := p.testable(), p.cell()
+  p.copy(v, pos)
+  p.clear(neg)
+  p.times(128, func() {
+    p.ifElse(pos, func() { p.add(pos, -1) }, func() { p.add(neg, 1) })
+  })
+  p.clear(mag)
+  p.clear(sign)
+  p.move(pos, mag)
+  p.loop(neg, func() {
+    p.move(neg, mag)
+    p.add(sign, 1)
+  })
+}
+
+// Set q to a*b/16 for magnitudes a and b, counting the product down in
+// sixteenths.
+func (p *program) mul(a, b, q int) {
+  r, ta, tb := p.testable(), p.cell(), p.cell()
+  p.clear(q)
+  p.set(r, 16)
+  p.copy(a, ta)
+  p.loop(ta, func() {
+    p.add(ta, -1)
+    p.copy(b, tb)
+    p.loop(tb, func() {
+      p.add(tb, -1)
+      p.add(r, -1)
+      p.ifElse(r, nil, func() {
+        p.add(r, 16)
+        p.add(q, 1)
+      })
+    })
+  })
+  p.clear(r)
+}
+
+func generate() string {
+  p := &program{}
+  row, col := p.cell(), p.cell()
+  cx, cy, x, y := p.cell(), p.cell(), p.cell(), p.cell()
+  going, used, tries := p.cell(), p.testable(), p.testable()
+  xs, sx, ys, sy := p.cell(), p.cell(), p.cell(), p.cell()
+  x2, y2, xy := p.cell(), p.cell(), p.cell()
+  big, over, sum := p.testable(), p.testable(), p.testable()
+  sign, both, ch := p.testable(), p.testable(), p.cell()
+
+  p.set(cy, 128+y0)
+  p.set(row, rows)
+  p.loop(row, func() {
+    p.add(row, -1)
+    p.set(cx, 128+x0)
+    p.set(col, cols)
+    p.loop(col, func() {
+      p.add(col, -1)
+      p.copy(cx, x)
+      p.copy(cy, y)
+      p.clear(used)
+      p.set(tries, maxIter)
+      p.set(going, 1)
+      p.loop(going, func() {
+        p.add(used, 1)
+        p.split(x, xs, sx)
+        p.split(y, ys, sy)
+        p.greater(xs, 32, big)
+        p.greater(ys, 32, sum)
+        p.move(sum, big)
+        p.ifElse(big, func() {
+          p.clear(big)
+          p.clear(going)
+        }, func() {
+          p.mul(xs, xs, x2)
+          p.mul(ys, ys, y2)
+          p.copy(x2, sum)
+          p.copy(y2, big)
+          p.move(big, sum)
+          p.greater(sum, 64, over)
+          p.clear(sum)
+          p.ifElse(over, func() {
+            p.clear(over)
+            p.clear(going)
+          }, func() {
+            // x = x² - y² + cx, and y = 2xy + cy.
+            p.mul(xs, ys, xy)
+            p.copy(cx, x)
+            p.move(x2, x)
+            p.loop(y2, func() {
+              p.add(y2, -1)
+              p.add(x, -1)
+            })
+            p.copy(cy, y)
+            p.copy(sx, sign)
+            p.move(sy, sign)
+            p.ifElse(sign, func() {
+              p.add(sign, -1)
+              p.move(sign, both)
+              p.ifElse(both, func() {
+                p.clear(both)
+                p.move(xy, y, y)
+              }, func() {
+                p.loop(xy, func() {
+                  p.add(xy, -1)
+                  p.add(y, -2)
+                })
+              })
+            }, func() {
+              p.move(xy, y, y)
+            })
+            p.add(tries, -1)
+            p.ifElse(tries, nil, func() {
+              p.clear(going)
+              p.clear(used)
+            })
+          })
+        })
+        p.clear(xs)
+        p.clear(sx)
+        p.clear(ys)
+        p.clear(sy)
+        p.clear(x2)
+        p.clear(y2)
+        p.clear(xy)
+      })
+      // A point still in the set has used cleared, and prints a space.
+      p.set(ch, ' ')
+      p.ifElse(used, func() {
+        p.set(ch, 'A'-1)
+        p.move(used, ch)
+      }, nil)
+      p.at(ch)
+      p.WriteString(".")
+      p.add(cx, 1)
+    })
+    p.set(ch, '\n')
+    p.at(ch)
+    p.WriteString(".")
+    p.add(cy, 2)
+  })
+  return p.String()
+}
+
+// The picture the program draws, computed directly.
+func reference() string {
+  var b strings.Builder
+  abs := func(v int) int { return max(v, -v) }
+  for r := range rows {
+    for c := range cols {
+      cx, cy := x0+c, y0+2*r
+      x, y := cx, cy
+      out := byte(' ')
+      for i := 1; i <= maxIter; i++ {
+        xs, ys := abs(x), abs(y)
+        if xs > 32 || ys > 32 || xs*xs/16+ys*ys/16 > 64 {
+          out = byte('A' - 1 + i)
+          break
+        }
+        xy := xs * ys / 16
+        if (x < 0) != (y < 0) {
+          xy = -xy
+        }
+        x, y = xs*xs/16-ys*ys/16+cx, 2*xy+cy
+      }
+      b.WriteByte(out)
+    }
+    b.WriteByte('\n')
+  }
+  return b.String()
+}
+
+func main() {
+  ref := flag.Bool("ref", false, "print what the program prints")
+  flag.Parse()
+  if *ref {
+    fmt.Print(reference())
+    return
+  }
+  // Wrap the code, which has no comment characters, at 80 columns.
+  code := generate()
+  for len(code) > 80 {
+    fmt.Fprintln(os.Stdout, code[:80])
+    code = code[80:]
+  }
+  fmt.Println(code)
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+  "bufio"
+  "fmt"
+  "math"
+  "os"
+  "strconv"
+  "strings"
+  "unicode"
+)
+
+// The kinds of token the tokenizer produces.
+const (
+  number = iota
+  operator
+  function
+  leftParen
+  rightParen
+  comma
+)
+
+type token struct {
+  kind  int
+  text  string
+  value float64
+}
+
+// Operator precedence and associativity. Unary minus is written as "neg" so
+// that it can bind tighter than multiplication but looser than "^", which
+// makes -2^2 evaluate to -4 like it does on paper.
+var precedence = map[string]int{
+  "+": 1, "-": 1,
+  "*": 2, "/": 2, "%": 2,
+  "neg": 3,
+  "^": 4,
+}
+
+var rightAssociative = map[string]bool{"^": true, "neg": true}
+
+// Functions known to the calculator and the number of arguments they take.
+var functions = map[string]int{
+  "sin": 1, "cos": 1, "tan": 1, "sqrt": 1, "abs": 1, "ln": 1, "log": 1,
+  "min": 2, "max": 2,
+}
+
+var constants = map[string]float64{"pi": math.Pi, "e": math.E}
+
+// Split an expression into tokens. A "-" is turned into unary negation when
+// it shows up where an operand is expected: at the start, after another
+// operator, after "(" or after ",".
+func tokenize(expr string) ([]token, error) {
+  var tokens []token
+  expectOperand := true
+  for i := 0; i < len(expr); {
+    c := rune(expr[i])
+    switch {
+    case unicode.IsSpace(c):
+      i++
+    case unicode.IsDigit(c) || c == '.':
+      j := i
+      for j < len(expr) && (unicode.IsDigit(rune(expr[j])) || expr[j] == '.') {
+        j++
+      }
+      // Allow scientific notation such as 1e-3.
+      if j < len(expr) && (expr[j] == 'e' || expr[j] == 'E') {
+        k := j + 1
+        if k < len(expr) && (expr[k] == '+' || expr[k] == '-') {
+          k++
+        }
+        if k < len(expr) && unicode.IsDigit(rune(expr[k])) {
+          for k < len(expr) && unicode.IsDigit(rune(expr[k])) {
+            k++
+          }
+          j = k
+        }
+      }
+      v, err := strconv.ParseFloat(expr[i:j], 64)
+      if err != nil {
+        return nil, fmt.Errorf("bad number %q", expr[i:j])
+      }
+      tokens = append(tokens, token{kind: number, text: expr[i:j], value: v})
+      i = j
+      expectOperand = false
+    case unicode.IsLetter(c):
+      j := i
+      for j < len(expr) && unicode.IsLetter(rune(expr[j])) {
+        j++
+      }
+      name := strings.ToLower(expr[i:j])
+      if v, ok := constants[name]; ok {
+        tokens = append(tokens, token{kind: number, text: name, value: v})
+        expectOperand = false
+      } else if _, ok := functions[name]; ok {
+        tokens = append(tokens, token{kind: function, text: name})
+        expectOperand = true
+      } else {
+        return nil, fmt.Errorf("unknown identifier %q", name)
+      }
+      i = j
+    case c == '(':
+      tokens = append(tokens, token{kind: leftParen, text: "("})
+      expectOperand = true
+      i++
+    case c == ')':
+      tokens = append(tokens, token{kind: rightParen, text: ")"})
+      expectOperand = false
+      i++
+    case c == ',':
+      tokens = append(tokens, token{kind: comma, text: ","})
+      expectOperand = true
+      i++
+    case strings.ContainsRune("+-*/%^", c):
+      op := string(c)
+      if expectOperand {
+        if op == "+" {
+          i++
+          continue
+        }
+        if op != "-" {
+          return nil, fmt.Errorf("unexpected operator %q", op)
+        }
+        op = "neg"
+      }
+      tokens = append(tokens, token{kind: operator, text: op})
+      expectOperand = true
+      i++
+    default:
+      return nil, fmt.Errorf("unexpected character %q", c)
+    }
+  }
+  return tokens, nil
+}
+
+// Convert infix tokens to reverse polish notation with Dijkstra's
+// shunting-yard algorithm. Functions are pushed on the operator stack and
+// popped to the output when their closing parenthesis is reached.
+func toRPN(tokens []token) ([]token, error) {
+  var output, stack []token
+  for _, t := range tokens {
+    switch t.kind {
+    case number:
+      output = append(output, t)
+    case function:
+      stack = append(stack, t)
+    case comma:
+      for len(stack) > 0 && stack[len(stack)-1].kind != leftParen {
+        output = append(output, stack[len(stack)-1])
+        stack = stack[:len(stack)-1]
+      }
+      if len(stack) == 0 {
+        return nil, fmt.Errorf("misplaced comma")
+      }
+    case operator:
+      // A prefix operator has no left operand yet, so nothing on the
+      // stack can be finished by it: 2^-1 must keep the ^ waiting for
+      // the negated 1.
+      for len(stack) > 0 && t.text != "neg" {
+        top := stack[len(stack)-1]
+        if top.kind != operator {
+          break
+        }
+        p1, p2 := precedence[t.text], precedence[top.text]
+        if p2 > p1 || (p2 == p1 && !rightAssociative[t.text]) {
+          output = append(output, top)
+          stack = stack[:len(stack)-1]
+          continue
+        }
+        break
+      }
+      stack = append(stack, t)
+    case leftParen:
+      stack = append(stack, t)
+    case rightParen:
+      for len(stack) > 0 && stack[len(stack)-1].kind != leftParen {
+        output = append(output, stack[len(stack)-1])
+        stack = stack[:len(stack)-1]
+      }
+      if len(stack) == 0 {
+        return nil, fmt.Errorf("mismatched parentheses")
+      }
+      stack = stack[:len(stack)-1]
+      if len(stack) > 0 && stack[len(stack)-1].kind == function {
+        output = append(output, stack[len(stack)-1])
+        stack = stack[:len(stack)-1]
+      }
+    }
+  }
+  for len(stack) > 0 {
+    top := stack[len(stack)-1]
+    if top.kind == leftParen {
+      return nil, fmt.Errorf("mismatched parentheses")
+    }
+    output = append(output, top)
+    stack = stack[:len(stack)-1]
+  }
+  return output, nil
+}
+
+// Apply a function to its arguments.
+func call(name string, args []float64) float64 {
+  switch name {
+  case "sin":
+    return math.Sin(args[0])
+  case "cos":
+    return math.Cos(args[0])
+  case "tan":
+    return math.Tan(args[0])
+  case "sqrt":
+    return math.Sqrt(args[0])
+  case "abs":
+    return math.Abs(args[0])
+  case "ln":
+    return math.Log(args[0])
+  case "log":
+    return math.Log10(args[0])
+  case "min":
+    return math.Min(args[0], args[1])
+  case "max":
+    return math.Max(args[0], args[1])
+  }
+  panic("unknown function " + name)
+}
+
+// Evaluate an RPN token list with a value stack.
+func evalRPN(rpn []token) (float64, error) {
+  var stack []float64
+  pop := func(n int) ([]float64, error) {
+    if len(stack) < n {
+      return nil, fmt.Errorf("missing operand")
+    }
+    args := append([]float64{}, stack[len(stack)-n:]...)
+    stack = stack[:len(stack)-n]
+    return args, nil
+  }
+
+  for _, t := range rpn {
+    switch t.kind {
+    case number:
+      stack = append(stack, t.value)
+    case function:
+      args, err := pop(functions[t.text])
+      if err != nil {
+        return 0, err
+      }
+      stack = append(stack, call(t.text, args))
+    case operator:
+      if t.text == "neg" {
+        args, err := pop(1)
+        if err != nil {
+          return 0, err
+        }
+        stack = append(stack, -args[0])
+        continue
+      }
+      args, err := pop(2)
+      if err != nil {
+        return 0, err
+      }
+      a, b := args[0], args[1]
+      switch t.text {
+      case "+":
+        stack = append(stack, a+b)
+      case "-":
+        stack = append(stack, a-b)
+      case "*":
+        stack = append(stack, a*b)
+      case "/":
+        if b == 0 {
+          return 0, fmt.Errorf("division by zero")
+        }
+        stack = append(stack, a/b)
+      case "%":
+        if b == 0 {
+          return 0, fmt.Errorf("division by zero")
+        }
+        stack = append(stack, math.Mod(a, b))
+      case "^":
+        stack = append(stack, math.Pow(a, b))
+      }
+    }
+  }
+  if len(stack) != 1 {
+    return 0, fmt.Errorf("malformed expression")
+  }
+  return stack[0], nil
+}
+
+// Tokenize, convert and evaluate a single expression.
+func evaluate(expr string) (float64, error) {
+  tokens, err := tokenize(expr)
+  if err != nil {
+    return 0, err
+  }
+  rpn, err := toRPN(tokens)
+  if err != nil {
+    return 0, err
+  }
+  return evalRPN(rpn)
+}
+
+// Evaluate the expression given on the command line, or read one expression
+// per line from stdin when no arguments are given.
+func main() {
+  if len(os.Args) > 1 {
+    result, err := evaluate(strings.Join(os.Args[1:], " "))
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "calc:", err)
+      os.Exit(1)
+    }
+    fmt.Println(strconv.FormatFloat(result, 'g', -1, 64))
+    return
+  }
+
+  scanner := bufio.NewScanner(os.Stdin)
+  for scanner.Scan() {
+    line := strings.TrimSpace(scanner.Text())
+    if line == "" {
+      continue
+    }
+    result, err := evaluate(line)
+    if err != nil {
+      fmt.Println("error:", err)
+      continue
+    }
+    fmt.Println(strconv.FormatFloat(result, 'g', -1, 64))
+  }
+}
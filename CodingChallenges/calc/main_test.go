@@ -0,0 +1,63 @@
+package main
+
+import (
+  "math"
+  "testing"
+)
+
+func TestEvaluate(t *testing.T) {
+  for _, c := range []struct {
+    expr string
+    want float64
+  }{
+    {"1 + 2", 3},
+    {"2 + 3 * 4", 14},
+    {"(2 + 3) * 4", 20},
+    {"10 - 4 - 3", 3},
+    {"100 / 10 / 5", 2},
+    {"7 % 4", 3},
+    {"2 ^ 3 ^ 2", 512},
+    {"-2 ^ 2", -4},
+    {"(-2) ^ 2", 4},
+    {"2 ^ -1", 0.5},
+    {"2 ^ -1 ^ 2", 0.5},
+    {"2 * -3", -6},
+    {"-3 * 2", -6},
+    {"--2", 2},
+    {"-(1 + 2)", -3},
+    {"+5 - -5", 10},
+    {"4 ^ -(1 / 2)", 0.5},
+    {"-2 ^ -2", -0.25},
+    {"sqrt(16) + abs(-3)", 7},
+    {"max(1, -2 ^ 2)", 1},
+    {"min(2 ^ -1, 3)", 0.5},
+    {"log(1000)", 3},
+    {"2 * pi", 2 * math.Pi},
+    {".5 + 1.25", 1.75},
+  } {
+    got, err := evaluate(c.expr)
+    if err != nil {
+      t.Errorf("%s: %v", c.expr, err)
+    } else if math.Abs(got-c.want) > 1e-12 {
+      t.Errorf("%s = %v, want %v", c.expr, got, c.want)
+    }
+  }
+}
+
+func TestErrors(t *testing.T) {
+  for _, c := range []struct{ expr, err string }{
+    {"1 / 0", "division by zero"},
+    {"5 % 0", "division by zero"},
+    {"(1 + 2", "mismatched parentheses"},
+    {"1 + 2)", "mismatched parentheses"},
+    {"1 +", "missing operand"},
+    {"* 2", `unexpected operator "*"`},
+    {"1 2", "malformed expression"},
+    {"2 $ 3", `unexpected character '$'`},
+  } {
+    _, err := evaluate(c.expr)
+    if err == nil || err.Error() != c.err {
+      t.Errorf("%s: got %v, want %q", c.expr, err, c.err)
+    }
+  }
+}
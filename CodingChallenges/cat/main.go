@@ -0,0 +1,113 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "strings"
+)
+
+// Options collected from the command line. Numbering continues across files,
+// as it does with GNU cat.
+type options struct {
+  number         bool
+  numberNonBlank bool
+  squeeze        bool
+}
+
+var opts options
+
+// State carried between files: the next line number, whether the last
+// line printed was blank (for -s squeezing across file boundaries), and
+// whether a file ended without a newline, so that the next one starts part
+// way through a line, which is neither numbered nor blank.
+type state struct {
+  line      int
+  lastBlank bool
+  midLine   bool
+}
+
+// Copy one input to the writer. Without any line options the bytes are
+// streamed through unchanged; otherwise lines are read one at a time so they
+// can be numbered or squeezed. A final line without a newline is kept as is.
+func cat(r io.Reader, w *bufio.Writer, st *state) error {
+  if !opts.number && !opts.numberNonBlank && !opts.squeeze {
+    _, err := io.Copy(w, r)
+    return err
+  }
+
+  reader := bufio.NewReaderSize(r, 1<<16)
+  for {
+    line, err := reader.ReadString('\n')
+    if line == "" {
+      if err == io.EOF {
+        return nil
+      }
+      return err
+    }
+    continued := st.midLine
+    st.midLine = !strings.HasSuffix(line, "\n")
+    blank := line == "\n" && !continued
+    if opts.squeeze && blank && st.lastBlank {
+      continue
+    }
+    st.lastBlank = blank
+    switch {
+    case continued:
+    case opts.numberNonBlank:
+      if !blank {
+        st.line++
+        fmt.Fprintf(w, "%6d\t", st.line)
+      }
+    case opts.number:
+      st.line++
+      fmt.Fprintf(w, "%6d\t", st.line)
+    }
+    w.WriteString(line)
+    if err != nil {
+      if err == io.EOF {
+        return nil
+      }
+      return err
+    }
+  }
+}
+
+// Concatenate the named files (stdin for "-" or when none are given) to
+// stdout. Missing files are reported and skipped with a non-zero exit status.
+func main() {
+  flag.BoolVar(&opts.number, "n", false, "number all output lines")
+  flag.BoolVar(&opts.numberNonBlank, "b", false, "number nonempty output lines, overrides -n")
+  flag.BoolVar(&opts.squeeze, "s", false, "suppress repeated empty output lines")
+  flag.Parse()
+
+  files := flag.Args()
+  if len(files) == 0 {
+    files = []string{"-"}
+  }
+
+  w := bufio.NewWriterSize(os.Stdout, 1<<16)
+  st := &state{}
+  status := 0
+  for _, name := range files {
+    var r io.Reader = os.Stdin
+    if name != "-" {
+      file, err := os.Open(name)
+      if err != nil {
+        fmt.Fprintln(os.Stderr, "cat:", err)
+        status = 1
+        continue
+      }
+      r = file
+      defer file.Close()
+    }
+    if err := cat(r, w, st); err != nil {
+      fmt.Fprintln(os.Stderr, "cat:", err)
+      status = 1
+    }
+  }
+  w.Flush()
+  os.Exit(status)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+  "bufio"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// Each case cats files from testdata with the given options, and wants
+// what GNU cat prints for them. Numbering, squeezing and whether a line
+// has started carry over from one file to the next.
+func TestCat(t *testing.T) {
+  defer func(o options) { opts = o }(opts)
+  for _, c := range []struct {
+    flags string
+    files []string
+    want  string
+  }{
+    {"", []string{"partial.txt", "line.txt"}, "onetwo\n"},
+    // A line begun at the end of one file is finished by the next, and
+    // numbered only once.
+    {"n", []string{"partial.txt", "line.txt"}, "     1\tonetwo\n"},
+    {"n", []string{"partial.txt", "partial.txt", "partial.txt"}, "     1\toneoneone"},
+    {"n", []string{"partial.txt", "blanks.txt"}, "     1\tone\n     2\t\n     3\t\n     4\tx\n     5\t\n"},
+    // The newline ending it makes no blank line.
+    {"s", []string{"partial.txt", "blanks.txt"}, "one\n\nx\n\n"},
+    {"b", []string{"partial.txt", "blanks.txt"}, "     1\tone\n\n\n     2\tx\n\n"},
+    {"n", []string{"text.txt", "text.txt"}, "     1\talpha\n     2\tbeta\n     3\t\n     4\tgamma\n     5\talpha\n     6\tbeta\n     7\t\n     8\tgamma\n"},
+    {"ns", []string{"text.txt", "blanks.txt"}, "     1\talpha\n     2\tbeta\n     3\t\n     4\tgamma\n     5\t\n     6\tx\n     7\t\n"},
+    {"bs", []string{"blanks.txt", "blanks.txt", "text.txt"}, "\n     1\tx\n\n     2\tx\n\n     3\talpha\n     4\tbeta\n\n     5\tgamma\n"},
+  } {
+    opts = options{
+      number:         strings.Contains(c.flags, "n"),
+      numberNonBlank: strings.Contains(c.flags, "b"),
+      squeeze:        strings.Contains(c.flags, "s"),
+    }
+    var out strings.Builder
+    w := bufio.NewWriter(&out)
+    st := &state{}
+    for _, name := range c.files {
+      f, err := os.Open(filepath.Join("testdata", name))
+      if err != nil {
+        t.Fatal(err)
+      }
+      if err := cat(f, w, st); err != nil {
+        t.Fatal(err)
+      }
+      f.Close()
+    }
+    w.Flush()
+    if out.String() != c.want {
+      t.Errorf("cat -%s %s:\ngot  %q\nwant %q", c.flags, strings.Join(c.files, " "), out.String(), c.want)
+    }
+  }
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+  "bufio"
+  "fmt"
+  "io"
+  "os"
+  "os/exec"
+  "os/signal"
+  "path/filepath"
+  "strconv"
+  "strings"
+)
+
+// Command history, kept in memory and persisted to ~/.ccsh_history.
+type history struct {
+  path    string
+  entries []string
+}
+
+func loadHistory() *history {
+  h := &history{}
+  home, err := os.UserHomeDir()
+  if err != nil {
+    return h
+  }
+  h.path = filepath.Join(home, ".ccsh_history")
+  data, err := os.ReadFile(h.path)
+  if err == nil {
+    for _, line := range strings.Split(string(data), "\n") {
+      if line != "" {
+        h.entries = append(h.entries, line)
+      }
+    }
+  }
+  return h
+}
+
+// Record a line in memory and append it to the history file.
+func (h *history) add(line string) {
+  h.entries = append(h.entries, line)
+  if h.path == "" {
+    return
+  }
+  file, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+  if err != nil {
+    return
+  }
+  defer file.Close()
+  fmt.Fprintln(file, line)
+}
+
+// The commands the shell runs itself rather than executing.
+var builtins = map[string]bool{"exit": true, "cd": true, "pwd": true, "history": true}
+
+// Run a builtin if the command is one, reporting whether it was. Builtins only
+// run in the shell process when they are the sole stage of a pipeline; "exit"
+// ends the shell.
+func builtin(cmd command, h *history, out io.Writer) (bool, error) {
+  switch cmd.args[0] {
+  case "exit":
+    code := 0
+    if len(cmd.args) > 1 {
+      code, _ = strconv.Atoi(cmd.args[1])
+    }
+    os.Exit(code)
+  case "cd":
+    dir := ""
+    if len(cmd.args) > 1 {
+      dir = cmd.args[1]
+    } else if home, err := os.UserHomeDir(); err == nil {
+      dir = home
+    }
+    return true, os.Chdir(dir)
+  case "pwd":
+    wd, err := os.Getwd()
+    if err != nil {
+      return true, err
+    }
+    fmt.Fprintln(out, wd)
+    return true, nil
+  case "history":
+    for i, entry := range h.entries {
+      fmt.Fprintf(out, "%5d  %s\n", i+1, entry)
+    }
+    return true, nil
+  }
+  return false, nil
+}
+
+// Open the redirection target for a command's output.
+func openOutput(cmd command) (*os.File, error) {
+  flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+  if cmd.append {
+    flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+  }
+  return os.OpenFile(cmd.stdout, flags, 0o644)
+}
+
+// Execute a pipeline. Each stage is started with its stdin wired to the
+// previous stage's stdout through an OS pipe, then all stages are waited on.
+// If a stage cannot be started, the ones already running are still waited
+// on, once the pipe ends are closed so that they see EOF.
+func execute(pipeline []command, h *history) error {
+  if len(pipeline) == 1 && builtins[pipeline[0].args[0]] {
+    cmd := pipeline[0]
+    var out io.Writer = os.Stdout
+    if cmd.stdout != "" {
+      file, err := openOutput(cmd)
+      if err != nil {
+        return err
+      }
+      defer file.Close()
+      out = file
+    }
+    _, err := builtin(cmd, h, out)
+    return err
+  }
+
+  var procs []*exec.Cmd
+  var closers []io.Closer
+  startErr := start(pipeline, &procs, &closers)
+
+  // Close our copies of the pipe ends so readers see EOF when writers exit.
+  for _, c := range closers {
+    c.Close()
+  }
+
+  var last error
+  for _, proc := range procs {
+    last = proc.Wait()
+  }
+  if startErr != nil {
+    return startErr
+  }
+  if _, ok := last.(*exec.ExitError); ok {
+    return nil
+  }
+  return last
+}
+
+// Start the stages of a pipeline in order, stopping at the first that
+// cannot be started. The processes started and the files opened for them
+// are added to procs and closers.
+func start(pipeline []command, procs *[]*exec.Cmd, closers *[]io.Closer) error {
+  var prev io.Reader = os.Stdin
+  for i, stage := range pipeline {
+    proc := exec.Command(stage.args[0], stage.args[1:]...)
+    proc.Stdin = prev
+    proc.Stderr = os.Stderr
+    if stage.stdin != "" {
+      file, err := os.Open(stage.stdin)
+      if err != nil {
+        return err
+      }
+      *closers = append(*closers, file)
+      proc.Stdin = file
+    }
+
+    if i < len(pipeline)-1 {
+      r, w, err := os.Pipe()
+      if err != nil {
+        return err
+      }
+      *closers = append(*closers, r, w)
+      proc.Stdout = w
+      prev = r
+    } else {
+      proc.Stdout = os.Stdout
+    }
+    if stage.stdout != "" {
+      file, err := openOutput(stage)
+      if err != nil {
+        return err
+      }
+      *closers = append(*closers, file)
+      proc.Stdout = file
+    }
+
+    if err := proc.Start(); err != nil {
+      if _, ok := err.(*exec.Error); ok {
+        return fmt.Errorf("%s: command not found", stage.args[0])
+      }
+      return err
+    }
+    *procs = append(*procs, proc)
+  }
+  return nil
+}
+
+// Read commands from stdin in a loop, showing a prompt when interactive.
+// Ctrl-C interrupts the running command but not the shell itself.
+func main() {
+  // Catch rather than ignore SIGINT: ignored signals are inherited across
+  // exec, which would make children immune to Ctrl-C as well.
+  signal.Notify(make(chan os.Signal, 1), os.Interrupt)
+  h := loadHistory()
+  interactive := false
+  if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+    interactive = true
+  }
+
+  reader := bufio.NewReader(os.Stdin)
+  for {
+    if interactive {
+      fmt.Print("ccsh> ")
+    }
+    line, err := reader.ReadString('\n')
+    if err != nil && line == "" {
+      if interactive {
+        fmt.Println()
+      }
+      return
+    }
+    line = strings.TrimSpace(line)
+    if line == "" {
+      continue
+    }
+    h.add(line)
+
+    tokens, err := tokenize(line)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "ccsh:", err)
+      continue
+    }
+    pipeline, err := parse(tokens)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "ccsh:", err)
+      continue
+    }
+    if len(pipeline) == 0 {
+      continue
+    }
+    if err := execute(pipeline, h); err != nil {
+      fmt.Fprintln(os.Stderr, "ccsh:", err)
+    }
+  }
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+  "os"
+  "path/filepath"
+  "testing"
+  "time"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+func TestRedirect(t *testing.T) {
+  dir := t.TempDir()
+  out := filepath.Join(dir, "out")
+  h := &history{entries: []string{"ls"}}
+  for _, p := range [][]command{
+    {{args: []string{"history"}, stdout: out}},
+    {{args: []string{"echo", "a"}, stdout: out, append: true}},
+    {{args: []string{"tr", "a", "b"}, stdin: out}, {args: []string{"sort"}, stdout: out, append: true}},
+  } {
+    if err := execute(p, h); err != nil {
+      t.Fatal(err)
+    }
+  }
+  data, err := os.ReadFile(out)
+  testx.NoError(t, err)
+  testx.Equal(t, string(data), "    1  ls\na\n    1  ls\nb\n")
+}
+
+// A stage that cannot be started fails the pipeline, but only once the
+// stages before it have finished.
+func TestStartFailureWaits(t *testing.T) {
+  done := filepath.Join(t.TempDir(), "done")
+  start := time.Now()
+  err := execute([]command{
+    {args: []string{"sh", "-c", "sleep 0.2; touch " + done}},
+    {args: []string{"ccsh-no-such-command"}},
+  }, &history{})
+  if err == nil || err.Error() != "ccsh-no-such-command: command not found" {
+    t.Errorf("got %v, want command not found", err)
+  }
+  if _, err := os.Stat(done); err != nil {
+    t.Errorf("returned after %v without waiting for the first stage", time.Since(start))
+  }
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+  "errors"
+  "strings"
+)
+
+// A single command in a pipeline with its arguments and redirections.
+type command struct {
+  args   []string
+  stdin  string
+  stdout string
+  append bool
+}
+
+// A lexical token. Operators are flagged so that a quoted "|" stays a plain
+// word.
+type token struct {
+  text string
+  op   bool
+}
+
+// Split a command line into words and operators. Single quotes keep text
+// literal, double quotes allow backslash escapes of " and \, and a bare
+// backslash escapes the next character. The operators |, < , > and >> are
+// returned as their own tokens when unquoted.
+func tokenize(line string) ([]token, error) {
+  var tokens []token
+  var word strings.Builder
+  inWord := false
+  flush := func() {
+    if inWord {
+      tokens = append(tokens, token{text: word.String()})
+      word.Reset()
+      inWord = false
+    }
+  }
+
+  for i := 0; i < len(line); i++ {
+    c := line[i]
+    switch {
+    case c == ' ' || c == '\t':
+      flush()
+    case c == '|' || c == '<':
+      flush()
+      tokens = append(tokens, token{text: string(c), op: true})
+    case c == '>':
+      flush()
+      if i+1 < len(line) && line[i+1] == '>' {
+        tokens = append(tokens, token{text: ">>", op: true})
+        i++
+      } else {
+        tokens = append(tokens, token{text: ">", op: true})
+      }
+    case c == '\'':
+      end := strings.IndexByte(line[i+1:], '\'')
+      if end < 0 {
+        return nil, errors.New("unterminated single quote")
+      }
+      word.WriteString(line[i+1 : i+1+end])
+      inWord = true
+      i += end + 1
+    case c == '"':
+      inWord = true
+      i++
+      for ; i < len(line) && line[i] != '"'; i++ {
+        if line[i] == '\\' && i+1 < len(line) && (line[i+1] == '"' || line[i+1] == '\\') {
+          i++
+        }
+        word.WriteByte(line[i])
+      }
+      if i >= len(line) {
+        return nil, errors.New("unterminated double quote")
+      }
+    case c == '\\':
+      if i+1 < len(line) {
+        i++
+        word.WriteByte(line[i])
+      }
+      inWord = true
+    default:
+      word.WriteByte(c)
+      inWord = true
+    }
+  }
+  flush()
+  return tokens, nil
+}
+
+// Turn tokens into a pipeline of commands. Redirections must be followed by a
+// file name, and every stage of a pipeline needs at least one word.
+func parse(tokens []token) ([]command, error) {
+  var pipeline []command
+  cur := command{}
+  for i := 0; i < len(tokens); i++ {
+    tok := tokens[i].text
+    if !tokens[i].op {
+      cur.args = append(cur.args, tok)
+      continue
+    }
+    switch tok {
+    case "|":
+      if len(cur.args) == 0 {
+        return nil, errors.New("syntax error near unexpected token `|'")
+      }
+      pipeline = append(pipeline, cur)
+      cur = command{}
+    case "<", ">", ">>":
+      if i+1 >= len(tokens) || tokens[i+1].op {
+        return nil, errors.New("syntax error: missing file name after " + tok)
+      }
+      i++
+      if tok == "<" {
+        cur.stdin = tokens[i].text
+      } else {
+        cur.stdout = tokens[i].text
+        cur.append = tok == ">>"
+      }
+    }
+  }
+  if len(cur.args) == 0 {
+    if len(pipeline) > 0 {
+      return nil, errors.New("syntax error: pipeline ends with `|'")
+    }
+    return nil, nil
+  }
+  return append(pipeline, cur), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+  "reflect"
+  "testing"
+)
+
+func words(ws ...string) []token {
+  ts := make([]token, len(ws))
+  for i, w := range ws {
+    ts[i] = token{text: w, op: w == "|" || w == "<" || w == ">" || w == ">>"}
+  }
+  return ts
+}
+
+func TestTokenize(t *testing.T) {
+  for _, c := range []struct {
+    line string
+    want []token
+  }{
+    {"", nil},
+    {"  ls   -l  ", words("ls", "-l")},
+    {"cat<in|wc -l>>out", words("cat", "<", "in", "|", "wc", "-l", ">>", "out")},
+    {"echo a>b", words("echo", "a", ">", "b")},
+    {`echo 'a | b' "c > d"`, []token{{text: "echo"}, {text: "a | b"}, {text: "c > d"}}},
+    {`echo "say \"hi\" \\ \n"`, []token{{text: "echo"}, {text: `say "hi" \ \n`}}},
+    {`echo a\ b \|`, []token{{text: "echo"}, {text: "a b"}, {text: "|"}}},
+    {`echo ''x"" ''`, []token{{text: "echo"}, {text: "x"}, {text: ""}}},
+    {`echo it'`, nil},
+    {`echo "it`, nil},
+  } {
+    got, err := tokenize(c.line)
+    if c.want == nil && c.line != "" {
+      if err == nil {
+        t.Errorf("tokenize(%q) = %v, want an error", c.line, got)
+      }
+      continue
+    }
+    if err != nil || !reflect.DeepEqual(got, c.want) {
+      t.Errorf("tokenize(%q) = %v, %v; want %v", c.line, got, err, c.want)
+    }
+  }
+}
+
+func TestParse(t *testing.T) {
+  for _, c := range []struct {
+    line string
+    want []command
+    err  bool
+  }{
+    {"", nil, false},
+    {"ls -l", []command{{args: []string{"ls", "-l"}}}, false},
+    {"sort < in | uniq -c > out", []command{
+      {args: []string{"sort"}, stdin: "in"},
+      {args: []string{"uniq", "-c"}, stdout: "out"},
+    }, false},
+    {"> out echo hi", []command{{args: []string{"echo", "hi"}, stdout: "out"}}, false},
+    {"echo hi >> log", []command{{args: []string{"echo", "hi"}, stdout: "log", append: true}}, false},
+    {"echo '|' x", []command{{args: []string{"echo", "|", "x"}}}, false},
+    {"| wc", nil, true},
+    {"ls |", nil, true},
+    {"ls | | wc", nil, true},
+    {"cat <", nil, true},
+    {"cat > | wc", nil, true},
+  } {
+    tokens, err := tokenize(c.line)
+    if err != nil {
+      t.Fatal(err)
+    }
+    got, err := parse(tokens)
+    if c.err {
+      if err == nil {
+        t.Errorf("parse(%q) = %v, want an error", c.line, got)
+      }
+      continue
+    }
+    if err != nil || !reflect.DeepEqual(got, c.want) {
+      t.Errorf("parse(%q) = %+v, %v; want %+v", c.line, got, err, c.want)
+    }
+  }
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+  "encoding/json"
+  "flag"
+  "fmt"
+  "net/http"
+  "sort"
+  "sync"
+  "time"
+)
+
+// Messages exchanged with clients as JSON text frames. Clients send "join",
+// "leave" and "message"; the server sends "message", "presence" and "error".
+type event struct {
+  Type  string   `json:"type"`
+  Room  string   `json:"room,omitempty"`
+  User  string   `json:"user,omitempty"`
+  Text  string   `json:"text,omitempty"`
+  Users []string `json:"users,omitempty"`
+  Time  string   `json:"time,omitempty"`
+}
+
+// How many outgoing messages a client may have waiting before it is judged
+// too slow to keep up and disconnected.
+const sendQueue = 64
+
+// A client's messages are queued and written by a goroutine of its own, so
+// one that stops reading holds up nobody else.
+type client struct {
+  conn  *wsConn
+  name  string
+  rooms map[string]bool
+  send  chan []byte
+  gone  chan struct{}
+  once  sync.Once
+}
+
+func newClient(conn *wsConn, name string) *client {
+  return &client{
+    conn: conn, name: name, rooms: map[string]bool{},
+    send: make(chan []byte, sendQueue), gone: make(chan struct{}),
+  }
+}
+
+// Queue a message for the client. If its queue is full it is dropped
+// rather than waited for.
+func (c *client) queue(data []byte) {
+  select {
+  case c.send <- data:
+  default:
+    c.drop()
+  }
+}
+
+// Disconnect the client. Closing the connection also ends its read loop,
+// which takes it out of its rooms.
+func (c *client) drop() {
+  c.once.Do(func() {
+    close(c.gone)
+    c.conn.conn.Close()
+  })
+}
+
+// Write the client's queued messages until it is dropped or a write fails.
+func (c *client) writeLoop() {
+  for {
+    select {
+    case data := <-c.send:
+      if err := c.conn.writeFrame(opText, data); err != nil {
+        c.drop()
+        return
+      }
+    case <-c.gone:
+      return
+    }
+  }
+}
+
+// The hub keeps room membership. Each room maps to its set of clients.
+type hub struct {
+  mu    sync.Mutex
+  rooms map[string]map[*client]bool
+}
+
+func newHub() *hub {
+  return &hub{rooms: map[string]map[*client]bool{}}
+}
+
+// Send an event to every member of a room. Nothing here waits on a
+// client: each message only joins the member's queue.
+func (h *hub) broadcast(room string, e event) {
+  h.mu.Lock()
+  members := make([]*client, 0, len(h.rooms[room]))
+  for c := range h.rooms[room] {
+    members = append(members, c)
+  }
+  h.mu.Unlock()
+
+  data, _ := json.Marshal(e)
+  for _, c := range members {
+    c.queue(data)
+  }
+}
+
+// Tell a room who is in it now.
+func (h *hub) presence(room string) {
+  h.mu.Lock()
+  var users []string
+  for c := range h.rooms[room] {
+    users = append(users, c.name)
+  }
+  h.mu.Unlock()
+  sort.Strings(users)
+  h.broadcast(room, event{Type: "presence", Room: room, Users: users})
+}
+
+func (h *hub) join(c *client, room string) {
+  h.mu.Lock()
+  if h.rooms[room] == nil {
+    h.rooms[room] = map[*client]bool{}
+  }
+  h.rooms[room][c] = true
+  c.rooms[room] = true
+  h.mu.Unlock()
+  h.presence(room)
+}
+
+func (h *hub) leave(c *client, room string) {
+  h.mu.Lock()
+  delete(h.rooms[room], c)
+  if len(h.rooms[room]) == 0 {
+    delete(h.rooms, room)
+  }
+  delete(c.rooms, room)
+  h.mu.Unlock()
+  h.presence(room)
+}
+
+func sendError(c *client, msg string) {
+  data, _ := json.Marshal(event{Type: "error", Text: msg})
+  c.queue(data)
+}
+
+// Handle one WebSocket client: upgrade, then run it.
+func (h *hub) serve(w http.ResponseWriter, r *http.Request) {
+  name := r.URL.Query().Get("name")
+  if name == "" {
+    name = "anonymous"
+  }
+  conn, err := upgrade(w, r)
+  if err != nil {
+    return
+  }
+  h.run(conn, name)
+}
+
+// Process a client's events until it disconnects or is dropped, at which
+// point it leaves every room it was in.
+func (h *hub) run(conn *wsConn, name string) {
+  c := newClient(conn, name)
+  go c.writeLoop()
+  defer func() {
+    for room := range c.rooms {
+      h.leave(c, room)
+    }
+    conn.close()
+    c.drop()
+  }()
+
+  for {
+    opcode, data, err := conn.readMessage()
+    if err != nil {
+      return
+    }
+    if opcode != opText {
+      sendError(c, "only text messages are supported")
+      continue
+    }
+    var e event
+    if err := json.Unmarshal(data, &e); err != nil {
+      sendError(c, "invalid JSON")
+      continue
+    }
+    switch e.Type {
+    case "join":
+      if e.Room == "" {
+        sendError(c, "room is required")
+        continue
+      }
+      h.join(c, e.Room)
+    case "leave":
+      if c.rooms[e.Room] {
+        h.leave(c, e.Room)
+      }
+    case "message":
+      if !c.rooms[e.Room] {
+        sendError(c, "not in room "+e.Room)
+        continue
+      }
+      h.broadcast(e.Room, event{
+        Type: "message", Room: e.Room, User: c.name, Text: e.Text,
+        Time: time.Now().UTC().Format(time.RFC3339),
+      })
+    default:
+      sendError(c, "unknown event type "+e.Type)
+    }
+  }
+}
+
+// A tiny browser client for trying the server by hand.
+const page = `<!doctype html>
+<title>Chat</title>
+<input id=name placeholder=name> <input id=room value=lobby> <button id=go>Join</button>
+<pre id=log></pre>
+<input id=text size=60 disabled>
+<script>
+let ws, room;
+go.onclick = () => {
+  room = document.getElementById('room').value;
+  ws = new WebSocket('ws://' + location.host + '/ws?name=' + encodeURIComponent(document.getElementById('name').value));
+  ws.onopen = () => { ws.send(JSON.stringify({type: 'join', room})); text.disabled = false; };
+  ws.onmessage = m => {
+    const e = JSON.parse(m.data);
+    log.textContent += e.type === 'message' ? '<' + e.user + '> ' + e.text + '\n'
+      : e.type === 'presence' ? '* in ' + e.room + ': ' + e.users.join(', ') + '\n'
+      : '! ' + e.text + '\n';
+  };
+};
+text.onkeydown = k => {
+  if (k.key === 'Enter') { ws.send(JSON.stringify({type: 'message', room, text: text.value})); text.value = ''; }
+};
+</script>
+`
+
+// Serve the WebSocket endpoint at /ws and the demo page at /.
+func main() {
+  addr := flag.String("addr", ":8080", "address to listen on")
+  flag.Parse()
+
+  h := newHub()
+  http.HandleFunc("/ws", h.serve)
+  http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    fmt.Fprint(w, page)
+  })
+  fmt.Println("Listening on", *addr)
+  if err := http.ListenAndServe(*addr, nil); err != nil {
+    panic(err)
+  }
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+  "bufio"
+  "encoding/binary"
+  "encoding/json"
+  "io"
+  "net"
+  "slices"
+  "strconv"
+  "testing"
+  "time"
+)
+
+// A client run by h on the far end of a pipe.
+func dial(t *testing.T, h *hub, name string) net.Conn {
+  client, server := net.Pipe()
+  go h.run(&wsConn{conn: server, r: bufio.NewReader(server)}, name)
+  t.Cleanup(func() { client.Close() })
+  return client
+}
+
+// Send an event as a masked text frame, as a browser would.
+func send(t *testing.T, conn net.Conn, e event) {
+  t.Helper()
+  payload, _ := json.Marshal(e)
+  frame := []byte{0x80 | opText}
+  if n := len(payload); n < 126 {
+    frame = append(frame, 0x80|byte(n))
+  } else {
+    frame = append(frame, 0x80|126)
+    frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+  }
+  mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+  frame = append(frame, mask[:]...)
+  for i, b := range payload {
+    frame = append(frame, b^mask[i%4])
+  }
+  conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+  if _, err := conn.Write(frame); err != nil {
+    t.Fatalf("sending %+v: %v", e, err)
+  }
+}
+
+// Read events from the server until the connection ends.
+func listen(conn net.Conn) <-chan event {
+  events := make(chan event, 1024)
+  go func() {
+    defer close(events)
+    r := bufio.NewReader(conn)
+    for {
+      var head [2]byte
+      if _, err := io.ReadFull(r, head[:]); err != nil {
+        return
+      }
+      length := int(head[1] & 0x7f)
+      if length == 126 {
+        var ext [2]byte
+        io.ReadFull(r, ext[:])
+        length = int(binary.BigEndian.Uint16(ext[:]))
+      }
+      payload := make([]byte, length)
+      if _, err := io.ReadFull(r, payload); err != nil || head[0]&0x0f != opText {
+        return
+      }
+      var e event
+      json.Unmarshal(payload, &e)
+      events <- e
+    }
+  }()
+  return events
+}
+
+// The next event of the given type, skipping any others, or of any type if
+// typ is empty.
+func next(t *testing.T, events <-chan event, typ string) event {
+  t.Helper()
+  timeout := time.After(5 * time.Second)
+  for {
+    select {
+    case e, ok := <-events:
+      if !ok {
+        t.Fatalf("connection closed waiting for %s", typ)
+      }
+      if e.Type == typ || typ == "" {
+        return e
+      }
+    case <-timeout:
+      t.Fatalf("no %s event", typ)
+    }
+  }
+}
+
+func TestRooms(t *testing.T) {
+  h := newHub()
+  alice, bob := dial(t, h, "alice"), dial(t, h, "bob")
+  fromAlice, fromBob := listen(alice), listen(bob)
+
+  send(t, alice, event{Type: "join", Room: "lobby"})
+  if e := next(t, fromAlice, "presence"); !slices.Equal(e.Users, []string{"alice"}) {
+    t.Errorf("presence %v", e.Users)
+  }
+  send(t, bob, event{Type: "message", Room: "lobby", Text: "hi"})
+  if e := next(t, fromBob, "error"); e.Text != "not in room lobby" {
+    t.Errorf("got error %q", e.Text)
+  }
+  send(t, bob, event{Type: "join", Room: "lobby"})
+  for _, events := range []<-chan event{fromAlice, fromBob} {
+    if e := next(t, events, "presence"); !slices.Equal(e.Users, []string{"alice", "bob"}) {
+      t.Errorf("presence %v", e.Users)
+    }
+  }
+  send(t, bob, event{Type: "message", Room: "lobby", Text: "hi"})
+  for _, events := range []<-chan event{fromAlice, fromBob} {
+    if e := next(t, events, "message"); e.User != "bob" || e.Text != "hi" || e.Room != "lobby" {
+      t.Errorf("got %+v", e)
+    }
+  }
+  send(t, bob, event{Type: "shout"})
+  if e := next(t, fromBob, "error"); e.Text != "unknown event type shout" {
+    t.Errorf("got error %q", e.Text)
+  }
+
+  // Leaving, or hanging up, is announced to those who stay.
+  bob.Close()
+  if e := next(t, fromAlice, "presence"); !slices.Equal(e.Users, []string{"alice"}) {
+    t.Errorf("presence %v after bob left", e.Users)
+  }
+}
+
+// A client that stops reading is dropped once its queue is full, and until
+// then holds up neither the sender nor the rest of the room.
+func TestStalledClient(t *testing.T) {
+  h := newHub()
+  mallory := dial(t, h, "mallory")
+  send(t, mallory, event{Type: "join", Room: "lobby"})
+  alice, bob := dial(t, h, "alice"), dial(t, h, "bob")
+  fromAlice, fromBob := listen(alice), listen(bob)
+  send(t, alice, event{Type: "join", Room: "lobby"})
+  send(t, bob, event{Type: "join", Room: "lobby"})
+  for len(next(t, fromAlice, "presence").Users) < 3 {
+  }
+
+  // Alice reads her own messages as they come back, as any client would,
+  // while mallory reads nothing at all.
+  const n = 4 * sendQueue
+  for i := range n {
+    send(t, alice, event{Type: "message", Room: "lobby", Text: strconv.Itoa(i)})
+    if e := next(t, fromAlice, "message"); e.Text != strconv.Itoa(i) {
+      t.Fatalf("alice got back %q, want %d", e.Text, i)
+    }
+  }
+  dropped := false
+  for i := 0; i < n; {
+    switch e := next(t, fromBob, ""); e.Type {
+    case "message":
+      if e.Text != strconv.Itoa(i) {
+        t.Fatalf("bob got %q, want %d", e.Text, i)
+      }
+      i++
+    case "presence":
+      dropped = slices.Equal(e.Users, []string{"alice", "bob"})
+    }
+  }
+  if !dropped {
+    t.Error("mallory was not dropped")
+  }
+
+  // Mallory's connection was closed, not left waiting.
+  mallory.SetReadDeadline(time.Now().Add(5 * time.Second))
+  if _, err := io.ReadAll(mallory); err != nil {
+    t.Errorf("mallory's connection is still open: %v", err)
+  }
+}
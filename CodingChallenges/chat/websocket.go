@@ -0,0 +1,217 @@
+package main
+
+import (
+  "bufio"
+  "crypto/sha1"
+  "encoding/base64"
+  "encoding/binary"
+  "errors"
+  "io"
+  "net"
+  "net/http"
+  "strings"
+  "sync"
+  "time"
+)
+
+// The GUID every WebSocket server appends to the client key (RFC 6455 1.3).
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Frame opcodes.
+const (
+  opContinuation = 0x0
+  opText         = 0x1
+  opBinary       = 0x2
+  opClose        = 0x8
+  opPing         = 0x9
+  opPong         = 0xa
+)
+
+const maxMessage = 1 << 20
+
+// How long writing one frame may take before the client is given up on.
+const writeTimeout = 10 * time.Second
+
+// A server side WebSocket connection. Writes are serialised with a mutex so
+// broadcasts from other goroutines cannot interleave frames.
+type wsConn struct {
+  conn net.Conn
+  r    *bufio.Reader
+  mu   sync.Mutex
+}
+
+// Compute the Sec-WebSocket-Accept value for a client key.
+func acceptKey(key string) string {
+  h := sha1.Sum([]byte(key + wsGUID))
+  return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func headerContains(h http.Header, name, token string) bool {
+  for _, v := range h.Values(name) {
+    for _, part := range strings.Split(v, ",") {
+      if strings.EqualFold(strings.TrimSpace(part), token) {
+        return true
+      }
+    }
+  }
+  return false
+}
+
+// Validate the opening handshake, hijack the HTTP connection and send the
+// 101 Switching Protocols response.
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+  if r.Method != http.MethodGet ||
+    !headerContains(r.Header, "Connection", "upgrade") ||
+    !headerContains(r.Header, "Upgrade", "websocket") ||
+    r.Header.Get("Sec-WebSocket-Version") != "13" {
+    http.Error(w, "not a websocket handshake", http.StatusBadRequest)
+    return nil, errors.New("bad handshake")
+  }
+  key := r.Header.Get("Sec-WebSocket-Key")
+  if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+    http.Error(w, "bad Sec-WebSocket-Key", http.StatusBadRequest)
+    return nil, errors.New("bad key")
+  }
+
+  hj, ok := w.(http.Hijacker)
+  if !ok {
+    http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+    return nil, errors.New("cannot hijack")
+  }
+  conn, rw, err := hj.Hijack()
+  if err != nil {
+    return nil, err
+  }
+  rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+  rw.WriteString("Upgrade: websocket\r\n")
+  rw.WriteString("Connection: Upgrade\r\n")
+  rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+  if err := rw.Flush(); err != nil {
+    conn.Close()
+    return nil, err
+  }
+  return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+// Read one frame. Client frames must be masked (RFC 6455 5.1).
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+  var head [2]byte
+  if _, err = io.ReadFull(c.r, head[:]); err != nil {
+    return
+  }
+  fin = head[0]&0x80 != 0
+  opcode = head[0] & 0x0f
+  if head[0]&0x70 != 0 {
+    return false, 0, nil, errors.New("reserved bits set")
+  }
+  masked := head[1]&0x80 != 0
+  if !masked {
+    return false, 0, nil, errors.New("unmasked client frame")
+  }
+  length := uint64(head[1] & 0x7f)
+  switch length {
+  case 126:
+    var ext [2]byte
+    if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+      return
+    }
+    length = uint64(binary.BigEndian.Uint16(ext[:]))
+  case 127:
+    var ext [8]byte
+    if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+      return
+    }
+    length = binary.BigEndian.Uint64(ext[:])
+  }
+  if length > maxMessage {
+    return false, 0, nil, errors.New("frame too large")
+  }
+  if opcode >= opClose && (length > 125 || !fin) {
+    return false, 0, nil, errors.New("invalid control frame")
+  }
+  var mask [4]byte
+  if _, err = io.ReadFull(c.r, mask[:]); err != nil {
+    return
+  }
+  payload = make([]byte, length)
+  if _, err = io.ReadFull(c.r, payload); err != nil {
+    return
+  }
+  for i := range payload {
+    payload[i] ^= mask[i%4]
+  }
+  return fin, opcode, payload, nil
+}
+
+// Write one unmasked frame (servers never mask).
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  frame := []byte{0x80 | opcode}
+  switch n := len(payload); {
+  case n < 126:
+    frame = append(frame, byte(n))
+  case n <= 0xffff:
+    frame = append(frame, 126)
+    frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+  default:
+    frame = append(frame, 127)
+    frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+  }
+  frame = append(frame, payload...)
+  c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+  _, err := c.conn.Write(frame)
+  return err
+}
+
+// Read the next complete data message, reassembling fragments and answering
+// pings along the way. A close frame is echoed and reported as io.EOF.
+func (c *wsConn) readMessage() (byte, []byte, error) {
+  var msgType byte
+  var msg []byte
+  for {
+    fin, opcode, payload, err := c.readFrame()
+    if err != nil {
+      return 0, nil, err
+    }
+    switch opcode {
+    case opPing:
+      if err := c.writeFrame(opPong, payload); err != nil {
+        return 0, nil, err
+      }
+      continue
+    case opPong:
+      continue
+    case opClose:
+      code := payload
+      if len(code) > 2 {
+        code = code[:2]
+      }
+      c.writeFrame(opClose, code)
+      return 0, nil, io.EOF
+    case opText, opBinary:
+      if msgType != 0 {
+        return 0, nil, errors.New("expected continuation frame")
+      }
+      msgType = opcode
+    case opContinuation:
+      if msgType == 0 {
+        return 0, nil, errors.New("unexpected continuation frame")
+      }
+    default:
+      return 0, nil, errors.New("unknown opcode")
+    }
+    msg = append(msg, payload...)
+    if len(msg) > maxMessage {
+      return 0, nil, errors.New("message too large")
+    }
+    if fin {
+      return msgType, msg, nil
+    }
+  }
+}
+
+func (c *wsConn) close() error {
+  c.writeFrame(opClose, []byte{0x03, 0xe8}) // 1000 normal closure
+  return c.conn.Close()
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+  "errors"
+  "fmt"
+  "io"
+  "net/http"
+  "net/url"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+// Options parsed from the command line, mirroring the curl flags supported.
+type options struct {
+  method       string
+  headers      []string
+  data         string
+  hasData      bool
+  verbose      bool
+  include      bool
+  follow       bool
+  maxRedirects int
+  url          string
+}
+
+// Parse curl style arguments. Flags may appear before or after the URL and
+// both "-X POST" and "-XPOST" forms are accepted for single-letter flags.
+func parseArgs(args []string) (*options, error) {
+  opts := &options{maxRedirects: 50}
+  value := func(i *int, name string) (string, error) {
+    arg := args[*i]
+    if len(arg) > 2 && !strings.HasPrefix(arg, "--") {
+      return arg[2:], nil
+    }
+    *i++
+    if *i >= len(args) {
+      return "", fmt.Errorf("option %s: requires parameter", name)
+    }
+    return args[*i], nil
+  }
+
+  for i := 0; i < len(args); i++ {
+    arg := args[i]
+    var err error
+    switch {
+    case arg == "-v" || arg == "--verbose":
+      opts.verbose = true
+    case arg == "-i" || arg == "--include":
+      opts.include = true
+    case arg == "-L" || arg == "--location":
+      opts.follow = true
+    case strings.HasPrefix(arg, "-X") || arg == "--request":
+      opts.method, err = value(&i, "-X")
+    case strings.HasPrefix(arg, "-H") || arg == "--header":
+      var h string
+      if h, err = value(&i, "-H"); err == nil {
+        if !strings.Contains(h, ":") {
+          return nil, fmt.Errorf("invalid header %q", h)
+        }
+        opts.headers = append(opts.headers, h)
+      }
+    case strings.HasPrefix(arg, "-d") || arg == "--data":
+      var d string
+      if d, err = value(&i, "-d"); err == nil {
+        if strings.HasPrefix(d, "@") {
+          var b []byte
+          if b, err = os.ReadFile(d[1:]); err == nil {
+            d = string(b)
+          }
+        }
+        if opts.hasData {
+          opts.data += "&" + d
+        } else {
+          opts.data = d
+        }
+        opts.hasData = true
+      }
+    case arg == "--max-redirs":
+      var n string
+      if n, err = value(&i, "--max-redirs"); err == nil {
+        opts.maxRedirects, err = strconv.Atoi(n)
+      }
+    case strings.HasPrefix(arg, "-") && arg != "-":
+      return nil, fmt.Errorf("unknown option %s", arg)
+    default:
+      if opts.url != "" {
+        return nil, errors.New("only one URL is supported")
+      }
+      opts.url = arg
+    }
+    if err != nil {
+      return nil, err
+    }
+  }
+
+  if opts.url == "" {
+    return nil, errors.New("no URL specified")
+  }
+  if !strings.Contains(opts.url, "://") {
+    opts.url = "http://" + opts.url
+  }
+  if opts.method == "" {
+    opts.method = "GET"
+    if opts.hasData {
+      opts.method = "POST"
+    }
+  }
+  opts.method = strings.ToUpper(opts.method)
+  return opts, nil
+}
+
+// Build the request described by the options. Like curl, -d sends a
+// form-encoded body unless a Content-Type header is given.
+func buildRequest(opts *options, target string) (*http.Request, error) {
+  var body io.Reader
+  if opts.hasData {
+    body = strings.NewReader(opts.data)
+  }
+  req, err := http.NewRequest(opts.method, target, body)
+  if err != nil {
+    return nil, err
+  }
+  req.Header.Set("User-Agent", "cccurl/1.0")
+  req.Header.Set("Accept", "*/*")
+  if opts.hasData {
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+  }
+  for _, h := range opts.headers {
+    name, val, _ := strings.Cut(h, ":")
+    val = strings.TrimSpace(val)
+    if val == "" {
+      // "-H 'Name:'" removes a default header, as in curl.
+      req.Header.Del(name)
+      continue
+    }
+    req.Header.Set(strings.TrimSpace(name), val)
+  }
+  return req, nil
+}
+
+// Print header lines in a stable order with the given prefix.
+func dumpHeaders(w io.Writer, prefix string, h http.Header) {
+  names := make([]string, 0, len(h))
+  for name := range h {
+    names = append(names, name)
+  }
+  sort.Strings(names)
+  for _, name := range names {
+    for _, v := range h[name] {
+      fmt.Fprintf(w, "%s%s: %s\r\n", prefix, name, v)
+    }
+  }
+}
+
+// Perform the request, following redirects by hand when -L is set so every
+// hop can be shown in verbose mode. 301/302/303 turn into GETs without a
+// body, matching curl and browsers; 307/308 replay the original request.
+func run(opts *options, stdout, stderr io.Writer) error {
+  client := &http.Client{
+    CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+  }
+  target := opts.url
+  for hops := 0; ; hops++ {
+    req, err := buildRequest(opts, target)
+    if err != nil {
+      return err
+    }
+    if opts.verbose {
+      fmt.Fprintf(stderr, "> %s %s %s\r\n", req.Method, req.URL.RequestURI(), "HTTP/1.1")
+      fmt.Fprintf(stderr, "> Host: %s\r\n", req.URL.Host)
+      dumpHeaders(stderr, "> ", req.Header)
+      fmt.Fprintf(stderr, ">\r\n")
+    }
+
+    resp, err := client.Do(req)
+    if err != nil {
+      return err
+    }
+    body, err := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if err != nil {
+      return err
+    }
+
+    if opts.verbose {
+      fmt.Fprintf(stderr, "< %s %s\r\n", resp.Proto, resp.Status)
+      dumpHeaders(stderr, "< ", resp.Header)
+      fmt.Fprintf(stderr, "<\r\n")
+    }
+
+    location := resp.Header.Get("Location")
+    redirect := resp.StatusCode >= 300 && resp.StatusCode < 400 && location != ""
+    if opts.follow && redirect {
+      if hops >= opts.maxRedirects {
+        return fmt.Errorf("maximum (%d) redirects followed", opts.maxRedirects)
+      }
+      next, err := req.URL.Parse(location)
+      if err != nil {
+        return err
+      }
+      if resp.StatusCode != http.StatusTemporaryRedirect && resp.StatusCode != http.StatusPermanentRedirect && opts.method != "HEAD" {
+        opts.method = "GET"
+        opts.hasData = false
+      }
+      if opts.include {
+        fmt.Fprintf(stdout, "%s %s\r\n", resp.Proto, resp.Status)
+        dumpHeaders(stdout, "", resp.Header)
+        fmt.Fprint(stdout, "\r\n")
+      }
+      target = next.String()
+      continue
+    }
+
+    if opts.include {
+      fmt.Fprintf(stdout, "%s %s\r\n", resp.Proto, resp.Status)
+      dumpHeaders(stdout, "", resp.Header)
+      fmt.Fprint(stdout, "\r\n")
+    }
+    _, err = stdout.Write(body)
+    return err
+  }
+}
+
+// Parse the arguments and run the request, exiting non-zero on failure.
+func main() {
+  opts, err := parseArgs(os.Args[1:])
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "curl:", err)
+    fmt.Fprintln(os.Stderr, "usage: curl [-v] [-i] [-L] [-X method] [-H header]... [-d data] url")
+    os.Exit(2)
+  }
+  if _, err := url.Parse(opts.url); err != nil {
+    fmt.Fprintln(os.Stderr, "curl:", err)
+    os.Exit(3)
+  }
+  if err := run(opts, os.Stdout, os.Stderr); err != nil {
+    fmt.Fprintln(os.Stderr, "curl:", err)
+    os.Exit(1)
+  }
+}
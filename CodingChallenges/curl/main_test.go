@@ -0,0 +1,202 @@
+package main
+
+import (
+  "fmt"
+  "io"
+  "net/http"
+  "net/http/httptest"
+  "os"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+func TestParseArgs(t *testing.T) {
+  file := filepath.Join(t.TempDir(), "body.json")
+  if err := os.WriteFile(file, []byte(`{"a":1}`), 0o644); err != nil {
+    t.Fatal(err)
+  }
+  get := func(o options) *options {
+    o.maxRedirects = 50
+    if o.method == "" {
+      o.method = "GET"
+    }
+    return &o
+  }
+  for _, c := range []struct {
+    args []string
+    want *options
+    err  string
+  }{
+    {[]string{"example.com"}, get(options{url: "http://example.com"}), ""},
+    {[]string{"https://example.com/x?y=1"}, get(options{url: "https://example.com/x?y=1"}), ""},
+    {[]string{"-v", "-i", "-L", "http://h"}, get(options{verbose: true, include: true, follow: true, url: "http://h"}), ""},
+    {[]string{"http://h", "--verbose", "--include", "--location"}, get(options{verbose: true, include: true, follow: true, url: "http://h"}), ""},
+    {[]string{"-X", "delete", "http://h"}, get(options{method: "DELETE", url: "http://h"}), ""},
+    {[]string{"-XPUT", "http://h"}, get(options{method: "PUT", url: "http://h"}), ""},
+    {[]string{"--request", "PATCH", "http://h"}, get(options{method: "PATCH", url: "http://h"}), ""},
+    {[]string{"-H", "A: 1", "-HB:2", "--header", "C:", "http://h"}, get(options{headers: []string{"A: 1", "B:2", "C:"}, url: "http://h"}), ""},
+    {[]string{"-d", "a=1", "http://h"}, get(options{method: "POST", data: "a=1", hasData: true, url: "http://h"}), ""},
+    {[]string{"-da=1", "--data", "b=2", "http://h"}, get(options{method: "POST", data: "a=1&b=2", hasData: true, url: "http://h"}), ""},
+    {[]string{"-d", "", "http://h"}, get(options{method: "POST", hasData: true, url: "http://h"}), ""},
+    {[]string{"-d", "@" + file, "-X", "PUT", "http://h"}, get(options{method: "PUT", data: `{"a":1}`, hasData: true, url: "http://h"}), ""},
+    {[]string{"--max-redirs", "3", "http://h"}, &options{method: "GET", maxRedirects: 3, url: "http://h"}, ""},
+    {nil, nil, "no URL"},
+    {[]string{"-v"}, nil, "no URL"},
+    {[]string{"http://a", "http://b"}, nil, "only one URL"},
+    {[]string{"--nope", "http://h"}, nil, "unknown option --nope"},
+    {[]string{"http://h", "-X"}, nil, "requires parameter"},
+    {[]string{"-H", "no colon", "http://h"}, nil, "invalid header"},
+    {[]string{"--max-redirs", "many", "http://h"}, nil, "invalid syntax"},
+    {[]string{"-d", "@" + file + ".missing", "http://h"}, nil, "no such file"},
+  } {
+    got, err := parseArgs(c.args)
+    if c.err != "" {
+      if err == nil || !strings.Contains(err.Error(), c.err) {
+        t.Errorf("%q: got error %v, want %q", c.args, err, c.err)
+      }
+      continue
+    }
+    if err != nil {
+      t.Errorf("%q: %v", c.args, err)
+    } else if !reflect.DeepEqual(got, c.want) {
+      t.Errorf("%q: got %+v, want %+v", c.args, *got, *c.want)
+    }
+  }
+}
+
+func TestBuildRequest(t *testing.T) {
+  for _, c := range []struct {
+    args   []string
+    method string
+    header http.Header
+    body   string
+  }{
+    {[]string{"http://h/p"}, "GET", http.Header{"User-Agent": {"cccurl/1.0"}, "Accept": {"*/*"}}, ""},
+    {[]string{"-d", "a=1", "http://h/p"}, "POST", http.Header{
+      "User-Agent": {"cccurl/1.0"}, "Accept": {"*/*"}, "Content-Type": {"application/x-www-form-urlencoded"},
+    }, "a=1"},
+    // A header given replaces a default, and an empty one removes it.
+    {[]string{"-d", "{}", "-H", "Content-Type: application/json", "-H", "Accept:", "-H", "X-Extra:  yes ", "http://h/p"}, "POST", http.Header{
+      "User-Agent": {"cccurl/1.0"}, "Content-Type": {"application/json"}, "X-Extra": {"yes"},
+    }, "{}"},
+    {[]string{"-X", "HEAD", "-H", "user-agent: other", "http://h/p"}, "HEAD", http.Header{"User-Agent": {"other"}, "Accept": {"*/*"}}, ""},
+  } {
+    opts, err := parseArgs(c.args)
+    if err != nil {
+      t.Fatal(err)
+    }
+    req, err := buildRequest(opts, opts.url)
+    if err != nil {
+      t.Fatalf("%q: %v", c.args, err)
+    }
+    if req.Method != c.method || req.URL.String() != "http://h/p" {
+      t.Errorf("%q: got %s %s", c.args, req.Method, req.URL)
+    }
+    if !reflect.DeepEqual(req.Header, c.header) {
+      t.Errorf("%q: got headers %v, want %v", c.args, req.Header, c.header)
+    }
+    var body []byte
+    if req.Body != nil {
+      body, _ = io.ReadAll(req.Body)
+    }
+    if string(body) != c.body {
+      t.Errorf("%q: got body %q, want %q", c.args, body, c.body)
+    }
+  }
+}
+
+// A server that answers every request with what it received, and
+// redirects from /redirect/CODE to /echo with that status.
+func echoServer(t *testing.T) *httptest.Server {
+  srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    var code int
+    if _, err := fmt.Sscanf(r.URL.Path, "/redirect/%d", &code); err == nil {
+      http.Redirect(w, r, "/echo", code)
+      return
+    }
+    if r.URL.Path == "/loop" {
+      http.Redirect(w, r, "/loop", http.StatusFound)
+      return
+    }
+    body, _ := io.ReadAll(r.Body)
+    w.Header().Set("X-Seen", "yes")
+    fmt.Fprintf(w, "%s %s type=%q body=%q", r.Method, r.URL.Path, r.Header.Get("Content-Type"), body)
+  }))
+  t.Cleanup(srv.Close)
+  return srv
+}
+
+func TestRun(t *testing.T) {
+  srv := echoServer(t)
+  for _, c := range []struct {
+    name string
+    args []string
+    out  string // what stdout must be, or contain with include
+    err  string
+  }{
+    {"get", []string{"/echo"}, `GET /echo type="" body=""`, ""},
+    {"post", []string{"-d", "a=1", "/echo"}, `POST /echo type="application/x-www-form-urlencoded" body="a=1"`, ""},
+    {"not followed", []string{"/redirect/302"}, "", ""},
+    {"302 turns into a get", []string{"-L", "-d", "a=1", "/redirect/302"}, `GET /echo type="" body=""`, ""},
+    {"303 turns into a get", []string{"-L", "-X", "PUT", "-d", "a=1", "/redirect/303"}, `GET /echo type="" body=""`, ""},
+    {"307 replays the post", []string{"-L", "-d", "a=1", "/redirect/307"}, `POST /echo type="application/x-www-form-urlencoded" body="a=1"`, ""},
+    {"308 replays the put", []string{"-L", "-X", "PUT", "-d", "b=2", "/redirect/308"}, `PUT /echo type="application/x-www-form-urlencoded" body="b=2"`, ""},
+    {"too many redirects", []string{"-L", "--max-redirs", "2", "/loop"}, "", "maximum (2) redirects"},
+  } {
+    args := append([]string{}, c.args...)
+    args[len(args)-1] = srv.URL + args[len(args)-1]
+    opts, err := parseArgs(args)
+    if err != nil {
+      t.Fatal(err)
+    }
+    var stdout, stderr strings.Builder
+    err = run(opts, &stdout, &stderr)
+    switch {
+    case c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)):
+      t.Errorf("%s: got error %v, want %q", c.name, err, c.err)
+    case c.err == "" && err != nil:
+      t.Errorf("%s: %v", c.name, err)
+    case c.err == "" && !strings.HasPrefix(stdout.String(), c.out):
+      t.Errorf("%s: got %q, want %q", c.name, stdout.String(), c.out)
+    }
+  }
+}
+
+// -i puts each response's headers on stdout, redirects included, and -v
+// puts the exchange on stderr.
+func TestRunIncludeVerbose(t *testing.T) {
+  srv := echoServer(t)
+  opts, err := parseArgs([]string{"-i", "-v", "-L", "-H", "X-Mine: 1", srv.URL + "/redirect/301"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  var stdout, stderr strings.Builder
+  if err := run(opts, &stdout, &stderr); err != nil {
+    t.Fatal(err)
+  }
+  out, log := stdout.String(), stderr.String()
+  for _, want := range []string{
+    "HTTP/1.1 301 Moved Permanently\r\n",
+    "Location: /echo\r\n",
+    "HTTP/1.1 200 OK\r\n",
+    "X-Seen: yes\r\n\r\nGET /echo",
+  } {
+    if !strings.Contains(out, want) {
+      t.Errorf("stdout has no %q:\n%s", want, out)
+    }
+  }
+  host := strings.TrimPrefix(srv.URL, "http://")
+  for _, want := range []string{
+    "> GET /redirect/301 HTTP/1.1\r\n> Host: " + host + "\r\n",
+    "> X-Mine: 1\r\n",
+    "< HTTP/1.1 301 Moved Permanently\r\n",
+    "> GET /echo HTTP/1.1\r\n",
+    "< X-Seen: yes\r\n",
+  } {
+    if !strings.Contains(log, want) {
+      t.Errorf("stderr has no %q:\n%s", want, log)
+    }
+  }
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "flag"
+  "fmt"
+  "os"
+  "strings"
+  "time"
+)
+
+// One step of an edit script.
+type opKind int
+
+const (
+  equal opKind = iota
+  insert
+  remove
+)
+
+type edit struct {
+  kind opKind
+  a, b int // line indexes into the old and new files
+}
+
+// Find the shortest edit script from a to b with Myers' O(ND) algorithm in
+// its linear space form. Rather than keep the frontier of every round to
+// trace the path back, find the middle snake of the shortest path by
+// searching from both ends at once, then solve the two halves either side
+// of it the same way. Space is O(N+M) for the frontiers and the path.
+func myers(a, b []string) []edit {
+  var path [][2]int
+  var find func(left, top, right, bottom int) bool
+  find = func(left, top, right, bottom int) bool {
+    start, finish, ok := middleSnake(a, b, left, top, right, bottom)
+    if !ok {
+      return false
+    }
+    if !find(left, top, start[0], start[1]) {
+      path = append(path, start)
+    }
+    if !find(finish[0], finish[1], right, bottom) {
+      path = append(path, finish)
+    }
+    return true
+  }
+  find(0, 0, len(a), len(b))
+
+  // Between consecutive points of the path there is at most one insertion
+  // or deletion, with a diagonal of equal lines on either side of it.
+  var edits []edit
+  x, y := 0, 0
+  diagonal := func(x2, y2 int) {
+    for x < x2 && y < y2 && a[x] == b[y] {
+      edits = append(edits, edit{equal, x, y})
+      x++
+      y++
+    }
+  }
+  for _, p := range path {
+    diagonal(p[0], p[1])
+    switch dx, dy := p[0]-x, p[1]-y; {
+    case dx < dy:
+      edits = append(edits, edit{insert, x, y})
+      y++
+    case dx > dy:
+      edits = append(edits, edit{remove, x, y})
+      x++
+    }
+    diagonal(p[0], p[1])
+  }
+  return edits
+}
+
+// Find the middle snake of a shortest path across the box of a[left:right]
+// and b[top:bottom], as its start and finish points. The forward search
+// keeps the furthest x on each diagonal k = x - y, counted from the box's
+// top left corner; the backward one keeps the furthest y on each diagonal
+// c = k - delta, counted from its bottom right. Where the two meet is the
+// middle of the path. An empty box has no snake.
+func middleSnake(a, b []string, left, top, right, bottom int) (start, finish [2]int, ok bool) {
+  width, height := right-left, bottom-top
+  if width+height == 0 {
+    return start, finish, false
+  }
+  delta := width - height
+  odd := delta%2 != 0
+  max := (width + height + 1) / 2
+  offset := max + 1
+  vf := make([]int, 2*max+3)
+  vb := make([]int, 2*max+3)
+  vf[offset+1] = left
+  vb[offset+1] = bottom
+
+  for d := 0; d <= max; d++ {
+    for k := d; k >= -d; k -= 2 {
+      c := k - delta
+      var x, px int
+      if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+        x = vf[offset+k+1]
+        px = x
+      } else {
+        px = vf[offset+k-1]
+        x = px + 1
+      }
+      y := top + (x - left) - k
+      py := y
+      if d > 0 && x == px {
+        py = y - 1
+      }
+      for x < right && y < bottom && a[x] == b[y] {
+        x++
+        y++
+      }
+      vf[offset+k] = x
+      if odd && c >= -(d-1) && c <= d-1 && y >= vb[offset+c] {
+        return [2]int{px, py}, [2]int{x, y}, true
+      }
+    }
+    for c := d; c >= -d; c -= 2 {
+      k := c + delta
+      var y, py int
+      if c == -d || (c != d && vb[offset+c-1] > vb[offset+c+1]) {
+        y = vb[offset+c+1]
+        py = y
+      } else {
+        py = vb[offset+c-1]
+        y = py - 1
+      }
+      x := left + (y - top) + k
+      px := x
+      if d > 0 && y == py {
+        px = x + 1
+      }
+      for x > left && y > top && a[x-1] == b[y-1] {
+        x--
+        y--
+      }
+      vb[offset+c] = y
+      if !odd && k >= -d && k <= d && x <= vf[offset+k] {
+        return [2]int{x, y}, [2]int{px, py}, true
+      }
+    }
+  }
+  return start, finish, false
+}
+
+// Split file contents into lines, each keeping its newline. A last line
+// without one is then a different line from the same text with one, so it
+// shows up as a change wherever it lands in the edit script.
+func splitLines(data []byte) []string {
+  lines := strings.SplitAfter(string(data), "\n")
+  if lines[len(lines)-1] == "" {
+    lines = lines[:len(lines)-1]
+  }
+  return lines
+}
+
+// Format a hunk range as "start,count" with the unified diff conventions: an
+// empty range is shown at the line before it and a count of 1 is omitted.
+func hunkRange(start, count int) string {
+  if count == 0 {
+    return fmt.Sprintf("%d,0", start)
+  }
+  if count == 1 {
+    return fmt.Sprintf("%d", start+1)
+  }
+  return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// Write the edit script as unified diff hunks with the given number of
+// context lines around each change. A line without a newline can only be
+// the last of its file, and is flagged the way GNU diff does.
+func unified(w *bufio.Writer, a, b []string, edits []edit, context int) {
+  line := func(prefix string, text string) {
+    w.WriteString(prefix + text)
+    if !strings.HasSuffix(text, "\n") {
+      w.WriteString("\n\\ No newline at end of file\n")
+    }
+  }
+
+  for i := 0; i < len(edits); {
+    if edits[i].kind == equal {
+      i++
+      continue
+    }
+    // Grow the hunk while the next change is within 2*context lines.
+    start := i - context
+    if start < 0 {
+      start = 0
+    }
+    end := i
+    for end < len(edits) {
+      if edits[end].kind != equal {
+        end++
+        continue
+      }
+      run := end
+      for run < len(edits) && edits[run].kind == equal {
+        run++
+      }
+      if run == len(edits) || run-end > 2*context {
+        end += context
+        if end > len(edits) {
+          end = len(edits)
+        }
+        break
+      }
+      end = run
+    }
+
+    aStart, bStart, aCount, bCount := edits[start].a, edits[start].b, 0, 0
+    for _, e := range edits[start:end] {
+      if e.kind != insert {
+        aCount++
+      }
+      if e.kind != remove {
+        bCount++
+      }
+    }
+    fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+    for _, e := range edits[start:end] {
+      switch e.kind {
+      case equal:
+        line(" ", a[e.a])
+      case remove:
+        line("-", a[e.a])
+      case insert:
+        line("+", b[e.b])
+      }
+    }
+    i = end
+  }
+}
+
+// Print a file header line with its modification time.
+func header(w *bufio.Writer, prefix, name string) {
+  stamp := ""
+  if info, err := os.Stat(name); err == nil {
+    stamp = "\t" + info.ModTime().Format("2006-01-02 15:04:05.000000000 -0700")
+  } else {
+    stamp = "\t" + time.Unix(0, 0).Format("2006-01-02 15:04:05.000000000 -0700")
+  }
+  fmt.Fprintf(w, "%s %s%s\n", prefix, name, stamp)
+}
+
+// Compare two files and print a unified diff. The exit status is 0 when the
+// files are identical, 1 when they differ and 2 on trouble.
+func main() {
+  context := flag.Int("U", 3, "number of context lines")
+  flag.Parse()
+  if flag.NArg() != 2 {
+    fmt.Fprintln(os.Stderr, "usage: diff [-U n] FILE1 FILE2")
+    os.Exit(2)
+  }
+  oldName, newName := flag.Arg(0), flag.Arg(1)
+  oldData, err := os.ReadFile(oldName)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "diff:", err)
+    os.Exit(2)
+  }
+  newData, err := os.ReadFile(newName)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "diff:", err)
+    os.Exit(2)
+  }
+
+  // Identical files need no edit script at all.
+  if bytes.Equal(oldData, newData) {
+    return
+  }
+
+  a, b := splitLines(oldData), splitLines(newData)
+  w := bufio.NewWriter(os.Stdout)
+  header(w, "---", oldName)
+  header(w, "+++", newName)
+  unified(w, a, b, myers(a, b), *context)
+  w.Flush()
+  os.Exit(1)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+  "bufio"
+  "math/rand"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/prop"
+)
+
+// The length of the longest common subsequence, by dynamic programming.
+func lcs(a, b []string) int {
+  prev := make([]int, len(b)+1)
+  for i := range a {
+    cur := make([]int, len(b)+1)
+    for j := range b {
+      if a[i] == b[j] {
+        cur[j+1] = prev[j] + 1
+      } else {
+        cur[j+1] = max(prev[j+1], cur[j])
+      }
+    }
+    prev = cur
+  }
+  return prev[len(b)]
+}
+
+// An edit script is valid if it walks both files in order, keeping only
+// equal lines, and shortest if it keeps a longest common subsequence.
+func TestMyers(t *testing.T) {
+  lines := prop.SliceOf(prop.Int(0, 3), 40)
+  prop.Check(t, prop.Config{}, prop.PairOf(lines, lines), func(p prop.Pair[[]int, []int]) bool {
+    a, b := toLines(p.A), toLines(p.B)
+    x, y, kept := 0, 0, 0
+    for _, e := range myers(a, b) {
+      if e.a != x || e.b != y {
+        return false
+      }
+      switch e.kind {
+      case equal:
+        if a[x] != b[y] {
+          return false
+        }
+        x, y, kept = x+1, y+1, kept+1
+      case remove:
+        x++
+      case insert:
+        y++
+      }
+    }
+    return x == len(a) && y == len(b) && kept == lcs(a, b)
+  })
+}
+
+func toLines(ns []int) []string {
+  s := make([]string, len(ns))
+  for i, n := range ns {
+    s[i] = strconv.Itoa(n)
+  }
+  return s
+}
+
+func TestUnified(t *testing.T) {
+  a := splitLines([]byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"))
+  b := splitLines([]byte("a\nb\nx\nd\ne\nf\ng\nh\ni\nj\nk\nl"))
+  var out strings.Builder
+  w := bufio.NewWriter(&out)
+  unified(w, a, b, myers(a, b), 1)
+  w.Flush()
+  want := `@@ -2,3 +2,3 @@
+ b
+-c
++x
+ d
+@@ -11 +11,2 @@
+ k
++l
+\ No newline at end of file
+`
+  if out.String() != want {
+    t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+  }
+}
+
+// A long file with changes spread through it, each found on its own.
+func TestMyersLarge(t *testing.T) {
+  a := make([]string, 20000)
+  for i := range a {
+    a[i] = strconv.Itoa(i)
+  }
+  b := append([]string(nil), a...)
+  for i := 0; i < len(b); i += 997 {
+    b[i] = "changed"
+  }
+  changes := 0
+  for _, e := range myers(a, b) {
+    if e.kind != equal {
+      changes++
+    }
+  }
+  if want := 2 * (len(a)/997 + 1); changes != want {
+    t.Errorf("%d changes, want %d", changes, want)
+  }
+}
+
+// The hunks diff prints between two files' contents.
+func hunks(old, new string) string {
+  a, b := splitLines([]byte(old)), splitLines([]byte(new))
+  var out strings.Builder
+  w := bufio.NewWriter(&out)
+  unified(w, a, b, myers(a, b), 3)
+  w.Flush()
+  return out.String()
+}
+
+// A last line without a newline differs from the same text with one
+// wherever the two land in the edit script.
+func TestNoNewline(t *testing.T) {
+  for _, c := range []struct{ old, new, want string }{
+    {"a\nb\n", "a\nb", "@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"},
+    {"a\nb", "a\nb\n", "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+    {"a\nb", "a\nb\nc", "@@ -1,2 +1,3 @@\n a\n-b\n\\ No newline at end of file\n+b\n+c\n\\ No newline at end of file\n"},
+    // The old file's "c" line is not the new file's last line.
+    {"a\nb\nc\nd\n", "c", "@@ -1,4 +1 @@\n-a\n-b\n-c\n-d\n+c\n\\ No newline at end of file\n"},
+    {"c", "c\nd\n", "@@ -1 +1,2 @@\n-c\n\\ No newline at end of file\n+c\n+d\n"},
+    {"", "a", "@@ -0,0 +1 @@\n+a\n\\ No newline at end of file\n"},
+    {"a", "", "@@ -1 +0,0 @@\n-a\n\\ No newline at end of file\n"},
+  } {
+    if got := hunks(c.old, c.new); got != c.want {
+      t.Errorf("%q to %q:\n%s\nwant:\n%s", c.old, c.new, got, c.want)
+    }
+  }
+}
+
+// Random files, with and without a final newline, give patches that
+// patch(1) applies to the old file to make the new one.
+func TestPatchApplies(t *testing.T) {
+  if _, err := exec.LookPath("patch"); err != nil {
+    t.Skip("patch is not installed")
+  }
+  dir := t.TempDir()
+  rng := rand.New(rand.NewSource(1))
+  file := func() string {
+    var s strings.Builder
+    for range rng.Intn(40) {
+      s.WriteString(string(rune('a'+rng.Intn(4))) + "\n")
+    }
+    if s.Len() > 0 && rng.Intn(2) == 0 {
+      return s.String()[:s.Len()-1]
+    }
+    return s.String()
+  }
+  cases := [][2]string{
+    {strings.Repeat("c\n", 30), "c"},
+    {"c", strings.Repeat("c\n", 30)},
+    {strings.Repeat("a\nc\n", 15), "a\nc"},
+  }
+  for range 200 {
+    cases = append(cases, [2]string{file(), file()})
+  }
+  for _, c := range cases {
+    if c[0] == c[1] {
+      continue
+    }
+    old, diff, out := filepath.Join(dir, "old"), filepath.Join(dir, "diff"), filepath.Join(dir, "out")
+    os.WriteFile(old, []byte(c[0]), 0o644)
+    os.WriteFile(diff, []byte("--- old\n+++ new\n"+hunks(c[0], c[1])), 0o644)
+    os.Remove(out)
+    if msg, err := exec.Command("patch", "-s", "-f", "-o", out, old, diff).CombinedOutput(); err != nil {
+      t.Errorf("%q to %q: patch failed: %v\n%s", c[0], c[1], err, msg)
+      continue
+    }
+    if got, _ := os.ReadFile(out); string(got) != c[1] {
+      t.Errorf("%q to %q: patch made %q", c[0], c[1], got)
+    }
+  }
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+  "errors"
+  "flag"
+  "fmt"
+  "math/rand"
+  "net"
+  "os"
+  "strings"
+  "time"
+)
+
+// A few of the root servers (a.root-servers.net and friends). Iterative
+// resolution starts here.
+var rootServers = []string{"198.41.0.4", "199.9.14.201", "192.33.4.12", "199.7.91.13"}
+
+var timeout = 3 * time.Second
+
+// Send a single query over UDP and parse the reply, checking that its ID
+// matches ours.
+func exchange(server, name string, qtype uint16, recursive bool) (*packet, error) {
+  id := uint16(rand.Intn(1 << 16))
+  query, err := buildQuery(id, name, qtype, recursive)
+  if err != nil {
+    return nil, err
+  }
+  if _, _, err := net.SplitHostPort(server); err != nil {
+    server = net.JoinHostPort(server, "53")
+  }
+  conn, err := net.DialTimeout("udp", server, timeout)
+  if err != nil {
+    return nil, err
+  }
+  defer conn.Close()
+  conn.SetDeadline(time.Now().Add(timeout))
+  if _, err := conn.Write(query); err != nil {
+    return nil, err
+  }
+  buf := make([]byte, 4096)
+  n, err := conn.Read(buf)
+  if err != nil {
+    return nil, err
+  }
+  p, err := parsePacket(buf[:n])
+  if err != nil {
+    return nil, err
+  }
+  if p.header.id != id || !p.header.qr {
+    return nil, errors.New("reply does not match query")
+  }
+  if p.header.rcode == 3 {
+    return nil, fmt.Errorf("%s: no such domain", name)
+  }
+  if p.header.rcode != 0 {
+    return nil, fmt.Errorf("server returned rcode %d", p.header.rcode)
+  }
+  return p, nil
+}
+
+// Resolve a name iteratively: ask a root server, follow the NS referrals
+// (using glue addresses from the additional section where present, or
+// resolving the name server's address ourselves) until a server answers, and
+// chase CNAMEs from the start again. depth limits referral and CNAME loops.
+func resolveIterative(name string, qtype uint16, depth int, trace bool) ([]record, error) {
+  if depth > 16 {
+    return nil, errors.New("too many referrals")
+  }
+  servers := rootServers
+  for {
+    var p *packet
+    var err error
+    for _, server := range servers {
+      if trace {
+        fmt.Printf("; querying %s for %s %s\n", server, name, typeNames[qtype])
+      }
+      if p, err = exchange(server, name, qtype, false); err == nil {
+        break
+      }
+    }
+    if err != nil {
+      return nil, err
+    }
+
+    var answers []record
+    for _, r := range p.answers {
+      if r.rtype == qtype {
+        answers = append(answers, r)
+      }
+    }
+    if len(answers) > 0 {
+      return p.answers, nil
+    }
+    for _, r := range p.answers {
+      if r.rtype == typeCNAME {
+        rest, err := resolveIterative(r.data, qtype, depth+1, trace)
+        return append([]record{r}, rest...), err
+      }
+    }
+
+    // Follow the referral.
+    glue := map[string]string{}
+    for _, r := range p.additional {
+      if r.rtype == typeA {
+        glue[r.name] = r.data
+      }
+    }
+    var next []string
+    for _, r := range p.authority {
+      if r.rtype != typeNS {
+        continue
+      }
+      if ip, ok := glue[r.data]; ok {
+        next = append(next, ip)
+      }
+    }
+    if len(next) == 0 {
+      for _, r := range p.authority {
+        if r.rtype != typeNS {
+          continue
+        }
+        addrs, err := resolveIterative(r.data, typeA, depth+1, trace)
+        if err != nil {
+          continue
+        }
+        for _, a := range addrs {
+          if a.rtype == typeA {
+            next = append(next, a.data)
+          }
+        }
+        if len(next) > 0 {
+          break
+        }
+      }
+    }
+    if len(next) == 0 {
+      return nil, fmt.Errorf("%s: no answer and no usable referral", name)
+    }
+    servers = next
+    depth++
+  }
+}
+
+// Resolve a name either by forwarding to a recursive server or iteratively
+// from the roots, and print the answer records.
+func main() {
+  server := flag.String("server", "", "recursive server to forward to (e.g. 8.8.8.8); iterative from the roots if empty")
+  qtypeName := flag.String("type", "A", "record type: A or AAAA")
+  trace := flag.Bool("trace", false, "show each server queried during iterative resolution")
+  flag.Parse()
+  if flag.NArg() != 1 {
+    fmt.Fprintln(os.Stderr, "usage: dns [-server addr] [-type A|AAAA] [-trace] name")
+    os.Exit(2)
+  }
+
+  qtype := uint16(typeA)
+  switch strings.ToUpper(*qtypeName) {
+  case "A":
+  case "AAAA":
+    qtype = typeAAAA
+  default:
+    fmt.Fprintln(os.Stderr, "dns: unsupported type", *qtypeName)
+    os.Exit(2)
+  }
+
+  name := flag.Arg(0)
+  var answers []record
+  var err error
+  if *server != "" {
+    var p *packet
+    if p, err = exchange(*server, name, qtype, true); err == nil {
+      answers = p.answers
+    }
+  } else {
+    answers, err = resolveIterative(name, qtype, 0, *trace)
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "dns:", err)
+    os.Exit(1)
+  }
+  for _, r := range answers {
+    fmt.Printf("%s\t%d\tIN\t%s\t%s\n", r.name, r.ttl, typeNames[r.rtype], r.data)
+  }
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+  "encoding/binary"
+  "errors"
+  "fmt"
+  "net"
+  "strings"
+)
+
+// Record types and the class this resolver deals with.
+const (
+  typeA     = 1
+  typeNS    = 2
+  typeCNAME = 5
+  typeAAAA  = 28
+  classIN   = 1
+)
+
+var typeNames = map[uint16]string{typeA: "A", typeNS: "NS", typeCNAME: "CNAME", typeAAAA: "AAAA"}
+
+// The fixed 12 byte DNS header (RFC 1035 4.1.1). The flags word is kept as
+// separate fields and packed/unpacked bit by bit.
+type header struct {
+  id      uint16
+  qr      bool
+  opcode  uint8
+  aa      bool
+  tc      bool
+  rd      bool
+  ra      bool
+  rcode   uint8
+  qdcount uint16
+  ancount uint16
+  nscount uint16
+  arcount uint16
+}
+
+type question struct {
+  name  string
+  qtype uint16
+  class uint16
+}
+
+// A resource record. Data holds the decoded rdata: an IP address for A and
+// AAAA, a domain name for NS and CNAME, or the raw bytes as hex otherwise.
+type record struct {
+  name  string
+  rtype uint16
+  class uint16
+  ttl   uint32
+  data  string
+}
+
+type packet struct {
+  header     header
+  questions  []question
+  answers    []record
+  authority  []record
+  additional []record
+}
+
+// Pack the header flags: QR(1) OPCODE(4) AA(1) TC(1) RD(1) RA(1) Z(3) RCODE(4).
+func (h *header) flags() uint16 {
+  var f uint16
+  if h.qr {
+    f |= 1 << 15
+  }
+  f |= uint16(h.opcode&0xf) << 11
+  if h.aa {
+    f |= 1 << 10
+  }
+  if h.tc {
+    f |= 1 << 9
+  }
+  if h.rd {
+    f |= 1 << 8
+  }
+  if h.ra {
+    f |= 1 << 7
+  }
+  f |= uint16(h.rcode & 0xf)
+  return f
+}
+
+func (h *header) setFlags(f uint16) {
+  h.qr = f&(1<<15) != 0
+  h.opcode = uint8(f>>11) & 0xf
+  h.aa = f&(1<<10) != 0
+  h.tc = f&(1<<9) != 0
+  h.rd = f&(1<<8) != 0
+  h.ra = f&(1<<7) != 0
+  h.rcode = uint8(f & 0xf)
+}
+
+// Encode a domain name as length-prefixed labels ending in a zero byte.
+// Queries are small, so names are never compressed when sending.
+func encodeName(name string) ([]byte, error) {
+  var out []byte
+  name = strings.TrimSuffix(name, ".")
+  if name != "" {
+    for _, label := range strings.Split(name, ".") {
+      if len(label) == 0 || len(label) > 63 {
+        return nil, fmt.Errorf("invalid label %q", label)
+      }
+      out = append(out, byte(len(label)))
+      out = append(out, label...)
+    }
+  }
+  if len(out)+1 > maxNameLength {
+    return nil, fmt.Errorf("name %q is too long", name)
+  }
+  return append(out, 0), nil
+}
+
+// Build a query packet for a single question.
+func buildQuery(id uint16, name string, qtype uint16, recursionDesired bool) ([]byte, error) {
+  h := header{id: id, rd: recursionDesired, qdcount: 1}
+  buf := make([]byte, 12)
+  binary.BigEndian.PutUint16(buf[0:], h.id)
+  binary.BigEndian.PutUint16(buf[2:], h.flags())
+  binary.BigEndian.PutUint16(buf[4:], h.qdcount)
+  qname, err := encodeName(name)
+  if err != nil {
+    return nil, err
+  }
+  buf = append(buf, qname...)
+  buf = binary.BigEndian.AppendUint16(buf, qtype)
+  buf = binary.BigEndian.AppendUint16(buf, classIN)
+  return buf, nil
+}
+
+var errTruncated = errors.New("truncated packet")
+
+// The longest a name may be on the wire, counting its length bytes.
+const maxNameLength = 255
+
+// Decode a possibly compressed name starting at off. Return the name and the
+// offset just past it in the original position (not past any pointer
+// target). Each pointer must point below everything read so far, which
+// rules out loops, and the name may be at most maxNameLength bytes.
+func decodeName(msg []byte, off int) (string, int, error) {
+  var labels []string
+  end := -1
+  lowest := off
+  length := 1 // the terminating zero
+  for hops := 0; ; {
+    if off >= len(msg) {
+      return "", 0, errTruncated
+    }
+    n := int(msg[off])
+    switch {
+    case n == 0:
+      if end < 0 {
+        end = off + 1
+      }
+      return strings.Join(labels, ".") + ".", end, nil
+    case n&0xc0 == 0xc0:
+      if off+1 >= len(msg) {
+        return "", 0, errTruncated
+      }
+      ptr := int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+      if ptr >= lowest {
+        return "", 0, errors.New("compression pointer does not point backwards")
+      }
+      if hops++; hops > maxNameLength/2 {
+        return "", 0, errors.New("too many compression pointers")
+      }
+      if end < 0 {
+        end = off + 2
+      }
+      off, lowest = ptr, ptr
+    case n&0xc0 != 0:
+      return "", 0, fmt.Errorf("unsupported label type %#x", n&0xc0)
+    default:
+      if off+1+n > len(msg) {
+        return "", 0, errTruncated
+      }
+      if length += 1 + n; length > maxNameLength {
+        return "", 0, errors.New("name too long")
+      }
+      labels = append(labels, string(msg[off+1:off+1+n]))
+      off += 1 + n
+    }
+  }
+}
+
+// Decode one resource record at off.
+func decodeRecord(msg []byte, off int) (record, int, error) {
+  var r record
+  name, off, err := decodeName(msg, off)
+  if err != nil {
+    return r, 0, err
+  }
+  if off+10 > len(msg) {
+    return r, 0, errTruncated
+  }
+  r.name = name
+  r.rtype = binary.BigEndian.Uint16(msg[off:])
+  r.class = binary.BigEndian.Uint16(msg[off+2:])
+  r.ttl = binary.BigEndian.Uint32(msg[off+4:])
+  rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+  off += 10
+  if off+rdlen > len(msg) {
+    return r, 0, errTruncated
+  }
+  rdata := msg[off : off+rdlen]
+  switch r.rtype {
+  case typeA, typeAAAA:
+    if (r.rtype == typeA && rdlen != 4) || (r.rtype == typeAAAA && rdlen != 16) {
+      return r, 0, fmt.Errorf("bad address length %d", rdlen)
+    }
+    r.data = net.IP(rdata).String()
+  case typeNS, typeCNAME:
+    // The target may itself be compressed, so decode against the whole message.
+    target, _, err := decodeName(msg, off)
+    if err != nil {
+      return r, 0, err
+    }
+    r.data = target
+  default:
+    r.data = fmt.Sprintf("%x", rdata)
+  }
+  return r, off + rdlen, nil
+}
+
+// Parse a complete DNS message.
+func parsePacket(msg []byte) (*packet, error) {
+  if len(msg) < 12 {
+    return nil, errTruncated
+  }
+  p := &packet{}
+  p.header.id = binary.BigEndian.Uint16(msg[0:])
+  p.header.setFlags(binary.BigEndian.Uint16(msg[2:]))
+  p.header.qdcount = binary.BigEndian.Uint16(msg[4:])
+  p.header.ancount = binary.BigEndian.Uint16(msg[6:])
+  p.header.nscount = binary.BigEndian.Uint16(msg[8:])
+  p.header.arcount = binary.BigEndian.Uint16(msg[10:])
+
+  off := 12
+  for i := 0; i < int(p.header.qdcount); i++ {
+    name, next, err := decodeName(msg, off)
+    if err != nil {
+      return nil, err
+    }
+    if next+4 > len(msg) {
+      return nil, errTruncated
+    }
+    p.questions = append(p.questions, question{
+      name:  name,
+      qtype: binary.BigEndian.Uint16(msg[next:]),
+      class: binary.BigEndian.Uint16(msg[next+2:]),
+    })
+    off = next + 4
+  }
+
+  sections := []struct {
+    count uint16
+    dest  *[]record
+  }{
+    {p.header.ancount, &p.answers},
+    {p.header.nscount, &p.authority},
+    {p.header.arcount, &p.additional},
+  }
+  for _, s := range sections {
+    for i := 0; i < int(s.count); i++ {
+      r, next, err := decodeRecord(msg, off)
+      if err != nil {
+        return nil, err
+      }
+      *s.dest = append(*s.dest, r)
+      off = next
+    }
+  }
+  return p, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+  "bytes"
+  "encoding/binary"
+  "strings"
+  "testing"
+  "time"
+)
+
+// A message made of a zeroed header followed by body, so that offsets in
+// the body start at 12.
+func message(body ...byte) []byte {
+  return append(make([]byte, 12), body...)
+}
+
+func TestDecodeName(t *testing.T) {
+  long := message()
+  for i := 0; i < 4; i++ {
+    long = append(long, 63)
+    long = append(long, strings.Repeat("x", 63)...)
+  }
+  long = append(long, 0)
+  // Three labels of 63 and one of 61 make exactly 255 bytes.
+  longest := append(append(append([]byte{}, long[:12+3*64]...), 61), strings.Repeat("y", 61)...)
+  longest = append(longest, 0)
+
+  for _, c := range []struct {
+    name string
+    msg  []byte
+    off  int
+    want string
+    end  int
+    err  string
+  }{
+    {"root", message(0), 12, ".", 13, ""},
+    {"labels", message(3, 'w', 'w', 'w', 1, 'a', 0), 12, "www.a.", 19, ""},
+    // "b" followed by a pointer back to "a." at 12.
+    {"pointer", message(1, 'a', 0, 1, 'b', 0xc0, 12), 15, "b.a.", 19, ""},
+    {"pointer to pointer", message(1, 'a', 0, 0xc0, 12, 1, 'b', 0xc0, 15), 17, "b.a.", 21, ""},
+    {"empty", message(), 12, "", 0, "truncated packet"},
+    {"truncated label", message(5, 'a', 'b'), 12, "", 0, "truncated packet"},
+    {"truncated pointer", message(1, 'a', 0xc0), 12, "", 0, "truncated packet"},
+    {"pointer past the end", message(0xc0, 0x3f), 12, "", 0, "compression pointer does not point backwards"},
+    {"pointer to itself", message(0xc0, 12), 12, "", 0, "compression pointer does not point backwards"},
+    {"forward pointer", message(0xc0, 14, 0), 12, "", 0, "compression pointer does not point backwards"},
+    // Jumping back to "a" walks forward onto the same pointer again.
+    {"loop", message(1, 'a', 0xc0, 12), 14, "", 0, "compression pointer does not point backwards"},
+    {"loop through two pointers", message(0xc0, 14, 0xc0, 12), 14, "", 0, "compression pointer does not point backwards"},
+    {"label type", message(0x40, 'a', 0), 12, "", 0, "unsupported label type 0x40"},
+    {"too long", long, 12, "", 0, "name too long"},
+    {"longest", longest, 12, strings.TrimPrefix(strings.Repeat("."+strings.Repeat("x", 63), 3), ".") + "." + strings.Repeat("y", 61) + ".", 12 + 255, ""},
+  } {
+    t.Run(c.name, func(t *testing.T) {
+      done := make(chan struct{})
+      var name string
+      var end int
+      var err error
+      go func() {
+        name, end, err = decodeName(c.msg, c.off)
+        close(done)
+      }()
+      select {
+      case <-done:
+      case <-time.After(time.Second):
+        t.Fatal("decodeName did not return")
+      }
+      switch {
+      case c.err != "":
+        if err == nil || err.Error() != c.err {
+          t.Errorf("got %q, %v, want error %q", name, err, c.err)
+        }
+      case err != nil:
+        t.Errorf("unexpected error %v", err)
+      case name != c.want || end != c.end:
+        t.Errorf("got %q ending at %d, want %q ending at %d", name, end, c.want, c.end)
+      }
+    })
+  }
+}
+
+func TestEncodeName(t *testing.T) {
+  for _, c := range []struct {
+    name string
+    want []byte
+    err  bool
+  }{
+    {".", []byte{0}, false},
+    {"", []byte{0}, false},
+    {"a.bc", []byte{1, 'a', 2, 'b', 'c', 0}, false},
+    {"a.bc.", []byte{1, 'a', 2, 'b', 'c', 0}, false},
+    {"a..b", nil, true},
+    {strings.Repeat("x", 63) + ".com", append(append([]byte{63}, strings.Repeat("x", 63)...), 3, 'c', 'o', 'm', 0), false},
+    {strings.Repeat("x", 64) + ".com", nil, true},
+    {strings.Repeat("x.", 127), nil, false},
+    {strings.Repeat("x.", 128), nil, true},
+  } {
+    got, err := encodeName(c.name)
+    if c.err {
+      if err == nil {
+        t.Errorf("%q: got %v, want an error", c.name, got)
+      }
+      continue
+    }
+    if err != nil {
+      t.Errorf("%q: %v", c.name, err)
+      continue
+    }
+    if c.want != nil && !bytes.Equal(got, c.want) {
+      t.Errorf("%q: got %v, want %v", c.name, got, c.want)
+    }
+    // Whatever is encoded decodes to the same name.
+    back, end, err := decodeName(message(got...), 12)
+    if err != nil || back != strings.TrimSuffix(c.name, ".")+"." && c.name != "" || end != 12+len(got) {
+      t.Errorf("%q: decoded as %q ending at %d, %v", c.name, back, end, err)
+    }
+  }
+}
+
+func TestBuildQuery(t *testing.T) {
+  got, err := buildQuery(0x1234, "dns.google.com", typeA, true)
+  if err != nil {
+    t.Fatal(err)
+  }
+  want := []byte{
+    0x12, 0x34, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0,
+    3, 'd', 'n', 's', 6, 'g', 'o', 'o', 'g', 'l', 'e', 3, 'c', 'o', 'm', 0,
+    0, 1, 0, 1,
+  }
+  if !bytes.Equal(got, want) {
+    t.Errorf("got  %x\nwant %x", got, want)
+  }
+  p, err := parsePacket(got)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if p.header.id != 0x1234 || !p.header.rd || p.header.qr || len(p.questions) != 1 ||
+    p.questions[0] != (question{"dns.google.com.", typeA, classIN}) {
+    t.Errorf("parsed back as %+v", p)
+  }
+}
+
+// A response with a compressed answer, authority and additional record.
+func TestParseResponse(t *testing.T) {
+  msg := []byte{
+    0xab, 0xcd, 0x81, 0x80, 0, 1, 0, 2, 0, 1, 0, 1,
+    // 12: question example.com A IN
+    7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0, 1, 0, 1,
+    // 29: www.example.com CNAME example.com
+    3, 'w', 'w', 'w', 0xc0, 12, 0, 5, 0, 1, 0, 0, 0x0e, 0x10, 0, 2, 0xc0, 12,
+    // 47: example.com A 93.184.216.34
+    0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 93, 184, 216, 34,
+    // 63: example.com NS a.iana-servers.net, then its AAAA
+    0xc0, 12, 0, 2, 0, 1, 0, 0, 0, 60, 0, 20,
+    1, 'a', 12, 'i', 'a', 'n', 'a', '-', 's', 'e', 'r', 'v', 'e', 'r', 's', 3, 'n', 'e', 't', 0,
+    0xc0, 75, 0, 28, 0, 1, 0, 0, 0, 60, 0, 16,
+    0x20, 0x01, 0x05, 0x00, 0x00, 0x8f, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x53,
+  }
+  p, err := parsePacket(msg)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if !p.header.qr || !p.header.rd || !p.header.ra || p.header.rcode != 0 {
+    t.Errorf("header %+v", p.header)
+  }
+  want := []record{
+    {"www.example.com.", typeCNAME, classIN, 3600, "example.com."},
+    {"example.com.", typeA, classIN, 60, "93.184.216.34"},
+    {"example.com.", typeNS, classIN, 60, "a.iana-servers.net."},
+    {"a.iana-servers.net.", typeAAAA, classIN, 60, "2001:500:8f::53"},
+  }
+  got := append(append(append([]record{}, p.answers...), p.authority...), p.additional...)
+  if len(got) != len(want) {
+    t.Fatalf("got %d records, want %d: %+v", len(got), len(want), got)
+  }
+  for i := range want {
+    if got[i] != want[i] {
+      t.Errorf("record %d: got %+v, want %+v", i, got[i], want[i])
+    }
+  }
+
+  // Cutting the message anywhere short is an error, never a panic.
+  for n := range msg {
+    if _, err := parsePacket(msg[:n]); err == nil {
+      t.Errorf("parsed the first %d bytes", n)
+    }
+  }
+  bad := append([]byte{}, msg...)
+  binary.BigEndian.PutUint16(bad[57:], 5)
+  if _, err := parsePacket(bad); err == nil {
+    t.Error("parsed an A record of 5 bytes")
+  }
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+  "path/filepath"
+  "strings"
+)
+
+const tabStop = 8
+
+// Highlight classes for rendered characters.
+const (
+  hlNormal byte = iota
+  hlComment
+  hlMultiComment
+  hlKeyword
+  hlType
+  hlString
+  hlNumber
+  hlMatch
+)
+
+// A syntax describes how to highlight one kind of file.
+type syntax struct {
+  name        string
+  extensions  []string
+  keywords    []string
+  types       []string
+  lineComment string
+  blockStart  string
+  blockEnd    string
+  quotes      string
+  numbers     bool
+}
+
+var syntaxes = []*syntax{
+  {
+    name:       "go",
+    extensions: []string{".go"},
+    keywords: []string{"break", "case", "chan", "const", "continue", "default", "defer", "else",
+      "fallthrough", "for", "func", "go", "goto", "if", "import", "interface", "map", "package",
+      "range", "return", "select", "struct", "switch", "type", "var"},
+    types: []string{"bool", "byte", "complex64", "complex128", "error", "float32", "float64",
+      "int", "int8", "int16", "int32", "int64", "rune", "string", "uint", "uint8", "uint16",
+      "uint32", "uint64", "uintptr", "any", "nil", "true", "false", "iota"},
+    lineComment: "//", blockStart: "/*", blockEnd: "*/", quotes: "\"'`", numbers: true,
+  },
+  {
+    name:       "c",
+    extensions: []string{".c", ".h", ".cpp", ".cc", ".hpp"},
+    keywords: []string{"switch", "if", "while", "for", "break", "continue", "return", "else",
+      "struct", "union", "typedef", "static", "enum", "class", "case", "default", "do", "goto",
+      "sizeof", "const", "extern", "volatile"},
+    types:       []string{"int", "long", "double", "float", "char", "unsigned", "signed", "void", "short", "bool"},
+    lineComment: "//", blockStart: "/*", blockEnd: "*/", quotes: "\"'", numbers: true,
+  },
+  {
+    name:       "python",
+    extensions: []string{".py"},
+    keywords: []string{"and", "as", "assert", "break", "class", "continue", "def", "del", "elif",
+      "else", "except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda",
+      "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield"},
+    types:       []string{"None", "True", "False", "int", "str", "float", "list", "dict", "set", "tuple", "self"},
+    lineComment: "#", quotes: "\"'", numbers: true,
+  },
+}
+
+// Pick a syntax from a file name's extension, or nil for plain text.
+func syntaxFor(name string) *syntax {
+  ext := filepath.Ext(name)
+  for _, s := range syntaxes {
+    for _, e := range s.extensions {
+      if e == ext {
+        return s
+      }
+    }
+  }
+  return nil
+}
+
+// A row holds a line's text, its rendered form with tabs expanded and the
+// highlight class of each rendered byte. openComment records whether the
+// row ends inside an unterminated block comment, which affects the next row.
+type row struct {
+  chars       []byte
+  render      []byte
+  hl          []byte
+  openComment bool
+}
+
+// A buffer is the editable document, independent of the terminal.
+type buffer struct {
+  rows   []*row
+  syntax *syntax
+  dirty  int
+}
+
+func newBuffer(text string, name string) *buffer {
+  b := &buffer{syntax: syntaxFor(name)}
+  if text == "" {
+    return b
+  }
+  text = strings.TrimSuffix(text, "\n")
+  for _, line := range strings.Split(text, "\n") {
+    b.insertRow(len(b.rows), []byte(strings.TrimSuffix(line, "\r")))
+  }
+  b.dirty = 0
+  return b
+}
+
+// The whole buffer as file contents, each line ending in a newline.
+func (b *buffer) String() string {
+  var sb strings.Builder
+  for _, r := range b.rows {
+    sb.Write(r.chars)
+    sb.WriteByte('\n')
+  }
+  return sb.String()
+}
+
+// Convert a cursor index into chars to a column in the rendered row.
+func (r *row) cxToRx(cx int) int {
+  rx := 0
+  for _, c := range r.chars[:cx] {
+    if c == '\t' {
+      rx += tabStop - 1 - rx%tabStop
+    }
+    rx++
+  }
+  return rx
+}
+
+// The inverse of cxToRx.
+func (r *row) rxToCx(rx int) int {
+  cur := 0
+  for cx, c := range r.chars {
+    if c == '\t' {
+      cur += tabStop - 1 - cur%tabStop
+    }
+    cur++
+    if cur > rx {
+      return cx
+    }
+  }
+  return len(r.chars)
+}
+
+// Rebuild a row's rendered text and highlighting, then rehighlight the
+// following rows for as long as the block comment state keeps changing.
+func (b *buffer) update(at int) {
+  r := b.rows[at]
+  r.render = r.render[:0]
+  for _, c := range r.chars {
+    if c == '\t' {
+      r.render = append(r.render, ' ')
+      for len(r.render)%tabStop != 0 {
+        r.render = append(r.render, ' ')
+      }
+    } else {
+      r.render = append(r.render, c)
+    }
+  }
+  for i := at; i < len(b.rows); i++ {
+    was := b.rows[i].openComment
+    b.highlight(i)
+    if b.rows[i].openComment == was {
+      break
+    }
+  }
+}
+
+func isSeparator(c byte) bool {
+  return c == ' ' || c == '\t' || c == 0 || strings.IndexByte(",.()+-/*=~%<>[];{}:&|!^", c) >= 0
+}
+
+func isDigit(c byte) bool {
+  return c >= '0' && c <= '9'
+}
+
+// Assign a highlight class to every rendered byte of row at.
+func (b *buffer) highlight(at int) {
+  r := b.rows[at]
+  r.hl = make([]byte, len(r.render))
+  s := b.syntax
+  if s == nil {
+    r.openComment = false
+    return
+  }
+  text := r.render
+  inComment := at > 0 && b.rows[at-1].openComment
+  var inString byte
+  prevSep := true
+
+  for i := 0; i < len(text); {
+    c := text[i]
+    prevHL := hlNormal
+    if i > 0 {
+      prevHL = r.hl[i-1]
+    }
+
+    if inString == 0 && !inComment && s.lineComment != "" && hasPrefixAt(text, i, s.lineComment) {
+      for j := i; j < len(text); j++ {
+        r.hl[j] = hlComment
+      }
+      break
+    }
+
+    if inString == 0 && s.blockStart != "" {
+      if inComment {
+        r.hl[i] = hlMultiComment
+        if hasPrefixAt(text, i, s.blockEnd) {
+          for j := 0; j < len(s.blockEnd); j++ {
+            r.hl[i+j] = hlMultiComment
+          }
+          i += len(s.blockEnd)
+          inComment = false
+          prevSep = true
+          continue
+        }
+        i++
+        continue
+      }
+      if hasPrefixAt(text, i, s.blockStart) {
+        for j := 0; j < len(s.blockStart); j++ {
+          r.hl[i+j] = hlMultiComment
+        }
+        i += len(s.blockStart)
+        inComment = true
+        continue
+      }
+    }
+
+    if inString != 0 {
+      r.hl[i] = hlString
+      if c == '\\' && inString != '`' && i+1 < len(text) {
+        r.hl[i+1] = hlString
+        i += 2
+        continue
+      }
+      if c == inString {
+        inString = 0
+      }
+      i++
+      prevSep = true
+      continue
+    }
+    if strings.IndexByte(s.quotes, c) >= 0 {
+      inString = c
+      r.hl[i] = hlString
+      i++
+      continue
+    }
+
+    if s.numbers && (isDigit(c) && (prevSep || prevHL == hlNumber) || c == '.' && prevHL == hlNumber) {
+      r.hl[i] = hlNumber
+      i++
+      prevSep = false
+      continue
+    }
+
+    if prevSep {
+      if n, class := b.keywordAt(text, i); n > 0 {
+        for j := 0; j < n; j++ {
+          r.hl[i+j] = class
+        }
+        i += n
+        prevSep = false
+        continue
+      }
+    }
+    prevSep = isSeparator(c)
+    i++
+  }
+  r.openComment = inComment
+}
+
+func hasPrefixAt(text []byte, i int, prefix string) bool {
+  return strings.HasPrefix(string(text[i:]), prefix)
+}
+
+// Report the length and class of a keyword starting at i, if it is followed
+// by a separator.
+func (b *buffer) keywordAt(text []byte, i int) (int, byte) {
+  for _, list := range []struct {
+    words []string
+    class byte
+  }{{b.syntax.keywords, hlKeyword}, {b.syntax.types, hlType}} {
+    for _, kw := range list.words {
+      end := i + len(kw)
+      if hasPrefixAt(text, i, kw) && (end == len(text) || isSeparator(text[end])) {
+        return len(kw), list.class
+      }
+    }
+  }
+  return 0, 0
+}
+
+func (b *buffer) insertRow(at int, chars []byte) {
+  if at < 0 || at > len(b.rows) {
+    return
+  }
+  // Until highlighted, a new row passes on the comment state it inherits,
+  // so update can tell whether the rows after it are affected.
+  r := &row{chars: append([]byte(nil), chars...), openComment: at > 0 && b.rows[at-1].openComment}
+  b.rows = append(b.rows, nil)
+  copy(b.rows[at+1:], b.rows[at:])
+  b.rows[at] = r
+  b.update(at)
+  b.dirty++
+}
+
+func (b *buffer) deleteRow(at int) {
+  if at < 0 || at >= len(b.rows) {
+    return
+  }
+  b.rows = append(b.rows[:at], b.rows[at+1:]...)
+  if at < len(b.rows) {
+    b.update(at)
+  }
+  b.dirty++
+}
+
+// Insert c at (x, y). Typing on the line after the last one adds a row.
+func (b *buffer) insertChar(x, y int, c byte) {
+  if y == len(b.rows) {
+    b.insertRow(y, nil)
+  }
+  r := b.rows[y]
+  x = min(max(x, 0), len(r.chars))
+  r.chars = append(r.chars, 0)
+  copy(r.chars[x+1:], r.chars[x:])
+  r.chars[x] = c
+  b.update(y)
+  b.dirty++
+}
+
+// Split the row at (x, y), returning the cursor position on the new line.
+func (b *buffer) insertNewline(x, y int) (int, int) {
+  if y == len(b.rows) {
+    b.insertRow(y, nil)
+    return 0, y + 1
+  }
+  r := b.rows[y]
+  x = min(x, len(r.chars))
+  b.insertRow(y+1, r.chars[x:])
+  r.chars = r.chars[:x]
+  b.update(y)
+  return 0, y + 1
+}
+
+// Delete the character before (x, y), joining with the previous line at the
+// start of a row. Returns the new cursor position.
+func (b *buffer) deleteChar(x, y int) (int, int) {
+  if y >= len(b.rows) || x == 0 && y == 0 {
+    return x, y
+  }
+  r := b.rows[y]
+  if x > 0 {
+    r.chars = append(r.chars[:x-1], r.chars[x:]...)
+    b.update(y)
+    b.dirty++
+    return x - 1, y
+  }
+  prev := b.rows[y-1]
+  nx := len(prev.chars)
+  prev.chars = append(prev.chars, r.chars...)
+  b.deleteRow(y)
+  b.update(y - 1)
+  return nx, y - 1
+}
+
+// Find the next match of query searching from (x, y) in direction dir (1 or
+// -1), wrapping around. Returns the row and char index, or -1, -1.
+func (b *buffer) find(query string, x, y, dir int) (int, int) {
+  n := len(b.rows)
+  if query == "" || n == 0 {
+    return -1, -1
+  }
+  for i := 0; i <= n; i++ {
+    line := string(b.rows[y].chars)
+    if dir > 0 {
+      start := min(x, len(line))
+      if k := strings.Index(line[start:], query); k >= 0 {
+        return start + k, y
+      }
+      y = (y + 1) % n
+      x = 0
+    } else {
+      end := min(max(x, 0), len(line))
+      if k := strings.LastIndex(line[:end], query); k >= 0 {
+        return k, y
+      }
+      y = (y - 1 + n) % n
+      x = len(b.rows[y].chars)
+    }
+  }
+  return -1, -1
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+  "bytes"
+  "fmt"
+  "os"
+  "strings"
+  "time"
+)
+
+const quitTimes = 3
+
+// The editor state: the buffer plus the view onto it.
+type editor struct {
+  buf           *buffer
+  cx, cy        int // cursor in chars and rows
+  rx            int // cursor column in the rendered row
+  rowOff        int
+  colOff        int
+  screenRows    int
+  screenCols    int
+  filename      string
+  status        string
+  statusTime    time.Time
+  quitRemaining int
+}
+
+func (e *editor) setStatus(format string, args ...any) {
+  e.status = fmt.Sprintf(format, args...)
+  e.statusTime = time.Now()
+}
+
+// Adjust the scroll offsets so the cursor is on screen.
+func (e *editor) scroll() {
+  e.rx = 0
+  if e.cy < len(e.buf.rows) {
+    e.rx = e.buf.rows[e.cy].cxToRx(e.cx)
+  }
+  if e.cy < e.rowOff {
+    e.rowOff = e.cy
+  }
+  if e.cy >= e.rowOff+e.screenRows {
+    e.rowOff = e.cy - e.screenRows + 1
+  }
+  if e.rx < e.colOff {
+    e.colOff = e.rx
+  }
+  if e.rx >= e.colOff+e.screenCols {
+    e.colOff = e.rx - e.screenCols + 1
+  }
+}
+
+func colorOf(hl byte) int {
+  switch hl {
+  case hlComment, hlMultiComment:
+    return 36
+  case hlKeyword:
+    return 33
+  case hlType:
+    return 32
+  case hlString:
+    return 35
+  case hlNumber:
+    return 31
+  case hlMatch:
+    return 34
+  }
+  return 39
+}
+
+func (e *editor) drawRows(b *bytes.Buffer) {
+  for y := 0; y < e.screenRows; y++ {
+    fileRow := y + e.rowOff
+    if fileRow >= len(e.buf.rows) {
+      if len(e.buf.rows) == 0 && y == e.screenRows/3 {
+        welcome := "ccedit -- Ctrl-S save | Ctrl-Q quit | Ctrl-F find"
+        if len(welcome) > e.screenCols {
+          welcome = welcome[:e.screenCols]
+        }
+        pad := (e.screenCols - len(welcome)) / 2
+        if pad > 0 {
+          b.WriteByte('~')
+          pad--
+        }
+        b.WriteString(strings.Repeat(" ", pad))
+        b.WriteString(welcome)
+      } else {
+        b.WriteByte('~')
+      }
+    } else {
+      r := e.buf.rows[fileRow]
+      start := min(e.colOff, len(r.render))
+      end := min(start+e.screenCols, len(r.render))
+      color := -1
+      for i := start; i < end; i++ {
+        c := r.render[i]
+        if c < 32 || c == 127 {
+          // Show control characters inverted, as @ to _ or ?.
+          sym := byte('?')
+          if c < 32 {
+            sym = '@' + c
+          }
+          fmt.Fprintf(b, "\x1b[7m%c\x1b[m", sym)
+          if color != -1 {
+            fmt.Fprintf(b, "\x1b[%dm", color)
+          }
+          continue
+        }
+        if want := colorOf(r.hl[i]); want != color {
+          color = want
+          fmt.Fprintf(b, "\x1b[%dm", color)
+        }
+        b.WriteByte(c)
+      }
+      b.WriteString("\x1b[39m")
+    }
+    b.WriteString("\x1b[K\r\n")
+  }
+}
+
+func (e *editor) drawStatusBar(b *bytes.Buffer) {
+  name := e.filename
+  if name == "" {
+    name = "[No Name]"
+  }
+  modified := ""
+  if e.buf.dirty > 0 {
+    modified = " (modified)"
+  }
+  left := fmt.Sprintf("%.20s - %d lines%s", name, len(e.buf.rows), modified)
+  kind := "no ft"
+  if e.buf.syntax != nil {
+    kind = e.buf.syntax.name
+  }
+  right := fmt.Sprintf("%s | %d/%d", kind, e.cy+1, len(e.buf.rows))
+  if len(left) > e.screenCols {
+    left = left[:e.screenCols]
+  }
+  b.WriteString("\x1b[7m")
+  b.WriteString(left)
+  if gap := e.screenCols - len(left) - len(right); gap >= 0 {
+    b.WriteString(strings.Repeat(" ", gap))
+    b.WriteString(right)
+  } else {
+    b.WriteString(strings.Repeat(" ", e.screenCols-len(left)))
+  }
+  b.WriteString("\x1b[m\r\n")
+}
+
+func (e *editor) drawMessageBar(b *bytes.Buffer) {
+  b.WriteString("\x1b[K")
+  if e.status != "" && time.Since(e.statusTime) < 5*time.Second {
+    msg := e.status
+    if len(msg) > e.screenCols {
+      msg = msg[:e.screenCols]
+    }
+    b.WriteString(msg)
+  }
+}
+
+// Redraw the whole screen in a single write, hiding the cursor meanwhile to
+// avoid flicker.
+func (e *editor) refresh() {
+  e.scroll()
+  var b bytes.Buffer
+  b.WriteString("\x1b[?25l\x1b[H")
+  e.drawRows(&b)
+  e.drawStatusBar(&b)
+  e.drawMessageBar(&b)
+  fmt.Fprintf(&b, "\x1b[%d;%dH", e.cy-e.rowOff+1, e.rx-e.colOff+1)
+  b.WriteString("\x1b[?25h")
+  os.Stdout.Write(b.Bytes())
+}
+
+// Ask for a line of input in the message bar. The callback, if any, sees
+// the input after every key so searches can be incremental. Escape cancels
+// and returns the empty string.
+func (e *editor) prompt(format string, callback func(string, int)) string {
+  var input []byte
+  for {
+    e.setStatus(format, input)
+    e.refresh()
+    key, err := readKey()
+    if err != nil {
+      return ""
+    }
+    switch {
+    case key == keyDelete || key == keyBackspace || key == ctrl('h'):
+      if len(input) > 0 {
+        input = input[:len(input)-1]
+      }
+    case key == '\x1b':
+      e.setStatus("")
+      if callback != nil {
+        callback(string(input), key)
+      }
+      return ""
+    case key == '\r':
+      if len(input) > 0 {
+        e.setStatus("")
+        if callback != nil {
+          callback(string(input), key)
+        }
+        return string(input)
+      }
+    case key < 128 && key >= 32:
+      input = append(input, byte(key))
+    }
+    if callback != nil {
+      callback(string(input), key)
+    }
+  }
+}
+
+// Incremental search: arrows move between matches, Escape restores the
+// cursor, Enter leaves it on the match.
+func (e *editor) find() {
+  savedX, savedY, savedCol, savedRow := e.cx, e.cy, e.colOff, e.rowOff
+  lastX, lastY := -1, -1
+  var savedHL []byte
+  hlRow := -1
+  restoreHL := func() {
+    if hlRow >= 0 && hlRow < len(e.buf.rows) {
+      copy(e.buf.rows[hlRow].hl, savedHL)
+    }
+    hlRow = -1
+  }
+
+  query := e.prompt("Search: %s (Use ESC/Arrows/Enter)", func(query string, key int) {
+    restoreHL()
+    dir := 1
+    switch key {
+    case '\r', '\x1b':
+      lastX, lastY = -1, -1
+      return
+    case keyRight, keyDown:
+    case keyLeft, keyUp:
+      dir = -1
+    default:
+      lastX, lastY = -1, -1
+    }
+    if len(e.buf.rows) == 0 {
+      return
+    }
+    x, y := 0, e.cy
+    if lastY >= 0 {
+      x, y = lastX+dir, lastY
+      if dir < 0 {
+        x = lastX
+      }
+    } else if y >= len(e.buf.rows) {
+      y = 0
+    }
+    fx, fy := e.buf.find(query, x, y, dir)
+    if fy < 0 {
+      return
+    }
+    lastX, lastY = fx, fy
+    e.cx, e.cy = fx, fy
+    e.rowOff = len(e.buf.rows) // scroll so the match is at the top
+    r := e.buf.rows[fy]
+    hlRow = fy
+    savedHL = append(savedHL[:0], r.hl...)
+    start := r.cxToRx(fx)
+    for i := start; i < start+len(query) && i < len(r.hl); i++ {
+      r.hl[i] = hlMatch
+    }
+  })
+  if query == "" {
+    e.cx, e.cy, e.colOff, e.rowOff = savedX, savedY, savedCol, savedRow
+  }
+}
+
+func (e *editor) moveCursor(key int) {
+  var r *row
+  if e.cy < len(e.buf.rows) {
+    r = e.buf.rows[e.cy]
+  }
+  switch key {
+  case keyLeft:
+    if e.cx > 0 {
+      e.cx--
+    } else if e.cy > 0 {
+      e.cy--
+      e.cx = len(e.buf.rows[e.cy].chars)
+    }
+  case keyRight:
+    if r != nil && e.cx < len(r.chars) {
+      e.cx++
+    } else if r != nil {
+      e.cy++
+      e.cx = 0
+    }
+  case keyUp:
+    if e.cy > 0 {
+      e.cy--
+    }
+  case keyDown:
+    if e.cy < len(e.buf.rows) {
+      e.cy++
+    }
+  }
+  // Snap to the end of a shorter line.
+  length := 0
+  if e.cy < len(e.buf.rows) {
+    length = len(e.buf.rows[e.cy].chars)
+  }
+  e.cx = min(e.cx, length)
+}
+
+func (e *editor) save() {
+  if e.filename == "" {
+    e.filename = e.prompt("Save as: %s (ESC to cancel)", nil)
+    if e.filename == "" {
+      e.setStatus("Save aborted")
+      return
+    }
+    e.buf.syntax = syntaxFor(e.filename)
+    for i := range e.buf.rows {
+      e.buf.update(i)
+    }
+  }
+  text := e.buf.String()
+  if err := os.WriteFile(e.filename, []byte(text), 0o644); err != nil {
+    e.setStatus("Can't save! I/O error: %v", err)
+    return
+  }
+  e.buf.dirty = 0
+  e.setStatus("%d bytes written to disk", len(text))
+}
+
+// Handle one key press. Returns false when the editor should exit.
+func (e *editor) processKey() (bool, error) {
+  key, err := readKey()
+  if err != nil {
+    return false, err
+  }
+  switch key {
+  case '\r':
+    e.cx, e.cy = e.buf.insertNewline(e.cx, e.cy)
+
+  case ctrl('q'):
+    if e.buf.dirty > 0 && e.quitRemaining > 0 {
+      e.setStatus("WARNING!!! File has unsaved changes. Press Ctrl-Q %d more times to quit.", e.quitRemaining)
+      e.quitRemaining--
+      return true, nil
+    }
+    return false, nil
+
+  case ctrl('s'):
+    e.save()
+
+  case ctrl('f'):
+    e.find()
+
+  case keyHome:
+    e.cx = 0
+  case keyEnd:
+    if e.cy < len(e.buf.rows) {
+      e.cx = len(e.buf.rows[e.cy].chars)
+    }
+
+  case keyBackspace, ctrl('h'), keyDelete:
+    if key == keyDelete {
+      e.moveCursor(keyRight)
+    }
+    e.cx, e.cy = e.buf.deleteChar(e.cx, e.cy)
+
+  case keyPageUp, keyPageDown:
+    dir := keyUp
+    if key == keyPageUp {
+      e.cy = e.rowOff
+    } else {
+      dir = keyDown
+      e.cy = min(e.rowOff+e.screenRows-1, len(e.buf.rows))
+    }
+    for i := 0; i < e.screenRows; i++ {
+      e.moveCursor(dir)
+    }
+
+  case keyUp, keyDown, keyLeft, keyRight:
+    e.moveCursor(key)
+
+  case ctrl('l'), '\x1b':
+    // Nothing to do; the screen is redrawn after every key.
+
+  default:
+    if key < 256 {
+      e.buf.insertChar(e.cx, e.cy, byte(key))
+      e.cx++
+    }
+  }
+  e.quitRemaining = quitTimes
+  return true, nil
+}
+
+func main() {
+  e := &editor{quitRemaining: quitTimes}
+  text := ""
+  if len(os.Args) > 2 {
+    fmt.Fprintln(os.Stderr, "usage: editor [file]")
+    os.Exit(2)
+  }
+  if len(os.Args) == 2 {
+    e.filename = os.Args[1]
+    data, err := os.ReadFile(e.filename)
+    if err != nil && !os.IsNotExist(err) {
+      fmt.Fprintln(os.Stderr, "editor:", err)
+      os.Exit(1)
+    }
+    text = string(data)
+  }
+  e.buf = newBuffer(text, e.filename)
+
+  restore, err := enableRawMode()
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "editor:", err)
+    os.Exit(1)
+  }
+  rows, cols, err := windowSize()
+  if err != nil {
+    restore()
+    fmt.Fprintln(os.Stderr, "editor:", err)
+    os.Exit(1)
+  }
+  e.screenRows, e.screenCols = rows-2, cols // room for the status and message bars
+  e.setStatus("HELP: Ctrl-S = save | Ctrl-Q = quit | Ctrl-F = find")
+
+  for {
+    e.refresh()
+    more, err := e.processKey()
+    if err != nil || !more {
+      os.Stdout.WriteString("\x1b[2J\x1b[H")
+      restore()
+      if err != nil {
+        fmt.Fprintln(os.Stderr, "editor:", err)
+        os.Exit(1)
+      }
+      return
+    }
+  }
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+  "bufio"
+  "errors"
+  "fmt"
+  "io"
+  "os"
+  "os/exec"
+  "strings"
+)
+
+// Keys beyond the byte range, decoded from escape sequences.
+const (
+  keyBackspace = 127
+  keyLeft      = 1000 + iota
+  keyRight
+  keyUp
+  keyDown
+  keyDelete
+  keyHome
+  keyEnd
+  keyPageUp
+  keyPageDown
+)
+
+func ctrl(c byte) int {
+  return int(c & 0x1f)
+}
+
+// The terminal settings are saved and restored with stty, which keeps the
+// editor free of platform-specific ioctls.
+func stty(args ...string) (string, error) {
+  cmd := exec.Command("stty", args...)
+  cmd.Stdin = os.Stdin
+  out, err := cmd.Output()
+  return strings.TrimSpace(string(out)), err
+}
+
+// Put the terminal in raw mode: no echo, no line buffering, no signals or
+// output processing. Returns a function restoring the previous state.
+func enableRawMode() (func(), error) {
+  saved, err := stty("-g")
+  if err != nil {
+    return nil, errors.New("stdin is not a terminal")
+  }
+  // min 0 time 1: reads return after at most a tenth of a second, which is
+  // how a lone Escape is told apart from the start of a sequence.
+  if _, err := stty("raw", "-echo", "-icanon", "-isig", "-iexten", "-ixon", "-icrnl", "-opost", "min", "0", "time", "1"); err != nil {
+    return nil, err
+  }
+  return func() { stty(saved) }, nil
+}
+
+func windowSize() (rows, cols int, err error) {
+  out, err := stty("size")
+  if err != nil {
+    return 0, 0, err
+  }
+  if _, err := fmt.Sscan(out, &rows, &cols); err != nil || rows == 0 || cols == 0 {
+    return 0, 0, errors.New("cannot get window size")
+  }
+  return rows, cols, nil
+}
+
+var input = bufio.NewReader(os.Stdin)
+
+// Block until a byte arrives; reads time out empty in raw mode.
+func readByte() (byte, error) {
+  for {
+    c, err := input.ReadByte()
+    if err == nil {
+      return c, nil
+    }
+    if err != io.EOF {
+      return 0, err
+    }
+  }
+}
+
+// Read a byte only if one arrives within the read timeout.
+func peekByte() (byte, bool) {
+  c, err := input.ReadByte()
+  return c, err == nil
+}
+
+// Read a key press, decoding the VT100 and xterm sequences for arrows,
+// Home, End, Delete and Page Up/Down.
+func readKey() (int, error) {
+  c, err := readByte()
+  if err != nil {
+    return 0, err
+  }
+  if c != '\x1b' {
+    return int(c), nil
+  }
+  s0, ok := peekByte()
+  if !ok {
+    return '\x1b', nil
+  }
+  s1, ok := peekByte()
+  if !ok {
+    return '\x1b', nil
+  }
+  if s0 == '[' {
+    if s1 >= '0' && s1 <= '9' {
+      s2, ok := peekByte()
+      if !ok || s2 != '~' {
+        return '\x1b', nil
+      }
+      switch s1 {
+      case '1', '7':
+        return keyHome, nil
+      case '3':
+        return keyDelete, nil
+      case '4', '8':
+        return keyEnd, nil
+      case '5':
+        return keyPageUp, nil
+      case '6':
+        return keyPageDown, nil
+      }
+      return '\x1b', nil
+    }
+    switch s1 {
+    case 'A':
+      return keyUp, nil
+    case 'B':
+      return keyDown, nil
+    case 'C':
+      return keyRight, nil
+    case 'D':
+      return keyLeft, nil
+    case 'H':
+      return keyHome, nil
+    case 'F':
+      return keyEnd, nil
+    }
+  } else if s0 == 'O' {
+    switch s1 {
+    case 'H':
+      return keyHome, nil
+    case 'F':
+      return keyEnd, nil
+    }
+  }
+  return '\x1b', nil
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+  "bytes"
+  "compress/zlib"
+  "crypto/sha1"
+  "encoding/hex"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "path/filepath"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Locate the .git directory by walking up from the working directory, the
+// same way git does.
+func gitDir() (string, error) {
+  dir, err := os.Getwd()
+  if err != nil {
+    return "", err
+  }
+  for {
+    candidate := filepath.Join(dir, ".git")
+    if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+      return candidate, nil
+    }
+    parent := filepath.Dir(dir)
+    if parent == dir {
+      return "", errors.New("not a git repository (or any of the parent directories): .git")
+    }
+    dir = parent
+  }
+}
+
+// Create the minimal layout git needs to recognise a repository.
+func initRepo(dir string) error {
+  root := filepath.Join(dir, ".git")
+  for _, d := range []string{"objects", "refs/heads", "refs/tags"} {
+    if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+      return err
+    }
+  }
+  if err := os.WriteFile(filepath.Join(root, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644); err != nil {
+    return err
+  }
+  config := "[core]\n\trepositoryformatversion = 0\n\tfilemode = true\n\tbare = false\n"
+  if err := os.WriteFile(filepath.Join(root, "config"), []byte(config), 0o644); err != nil {
+    return err
+  }
+  abs, _ := filepath.Abs(root)
+  fmt.Printf("Initialized empty Git repository in %s/\n", abs)
+  return nil
+}
+
+// Frame content as a loose object ("<type> <size>\0<content>") and return
+// the framed bytes with their SHA-1 id.
+func frame(kind string, content []byte) ([]byte, string) {
+  data := append([]byte(fmt.Sprintf("%s %d\x00", kind, len(content))), content...)
+  sum := sha1.Sum(data)
+  return data, hex.EncodeToString(sum[:])
+}
+
+// Write an object to .git/objects/xx/yyyy... zlib compressed, unless it is
+// already there. Objects are written to a temp file and renamed into place so
+// a crash never leaves a truncated object behind.
+func writeObject(dir, kind string, content []byte) (string, error) {
+  data, id := frame(kind, content)
+  path := filepath.Join(dir, "objects", id[:2], id[2:])
+  if _, err := os.Stat(path); err == nil {
+    return id, nil
+  }
+  if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+    return "", err
+  }
+  var buf bytes.Buffer
+  zw := zlib.NewWriter(&buf)
+  zw.Write(data)
+  if err := zw.Close(); err != nil {
+    return "", err
+  }
+  tmp, err := os.CreateTemp(filepath.Dir(path), "tmp_obj_")
+  if err != nil {
+    return "", err
+  }
+  if _, err := tmp.Write(buf.Bytes()); err != nil {
+    tmp.Close()
+    os.Remove(tmp.Name())
+    return "", err
+  }
+  tmp.Close()
+  os.Chmod(tmp.Name(), 0o444)
+  return id, os.Rename(tmp.Name(), path)
+}
+
+// Read and inflate a loose object, checking its header against its content.
+// Abbreviated ids of at least four characters are expanded when unique.
+func readObject(dir, id string) (string, []byte, error) {
+  id, err := expandID(dir, id)
+  if err != nil {
+    return "", nil, err
+  }
+  file, err := os.Open(filepath.Join(dir, "objects", id[:2], id[2:]))
+  if err != nil {
+    return "", nil, fmt.Errorf("object %s not found", id)
+  }
+  defer file.Close()
+  zr, err := zlib.NewReader(file)
+  if err != nil {
+    return "", nil, err
+  }
+  data, err := io.ReadAll(zr)
+  if err != nil {
+    return "", nil, err
+  }
+  nul := bytes.IndexByte(data, 0)
+  if nul < 0 {
+    return "", nil, errors.New("corrupt object header")
+  }
+  kind, sizeStr, ok := strings.Cut(string(data[:nul]), " ")
+  size, err := strconv.Atoi(sizeStr)
+  if !ok || err != nil || size != len(data)-nul-1 {
+    return "", nil, errors.New("corrupt object header")
+  }
+  return kind, data[nul+1:], nil
+}
+
+func expandID(dir, id string) (string, error) {
+  if len(id) == 40 {
+    return id, nil
+  }
+  if len(id) < 4 {
+    return "", fmt.Errorf("ambiguous argument '%s'", id)
+  }
+  entries, _ := os.ReadDir(filepath.Join(dir, "objects", id[:2]))
+  var matches []string
+  for _, e := range entries {
+    if strings.HasPrefix(e.Name(), id[2:]) {
+      matches = append(matches, id[:2]+e.Name())
+    }
+  }
+  if len(matches) != 1 {
+    return "", fmt.Errorf("ambiguous or unknown object '%s'", id)
+  }
+  return matches[0], nil
+}
+
+// Pretty-print a tree object as "mode type id\tname" lines like
+// "git cat-file -p".
+func printTree(dir string, content []byte) error {
+  for len(content) > 0 {
+    sp := bytes.IndexByte(content, ' ')
+    nul := bytes.IndexByte(content, 0)
+    if sp < 0 || nul < sp || nul+21 > len(content) {
+      return errors.New("corrupt tree object")
+    }
+    mode := string(content[:sp])
+    name := string(content[sp+1 : nul])
+    id := hex.EncodeToString(content[nul+1 : nul+21])
+    kind := "blob"
+    if mode == "40000" {
+      kind = "tree"
+    } else if mode == "160000" {
+      kind = "commit"
+    }
+    fmt.Printf("%06s %s %s\t%s\n", mode, kind, id, name)
+    content = content[nul+21:]
+  }
+  return nil
+}
+
+// Build tree objects for a directory recursively, skipping .git. Entries are
+// sorted the way git sorts them: by name, with directories compared as if
+// their name ended in "/".
+func writeTree(dir, path string) (string, error) {
+  entries, err := os.ReadDir(path)
+  if err != nil {
+    return "", err
+  }
+  type treeEntry struct {
+    mode, name, sortKey string
+    id                  []byte
+  }
+  var items []treeEntry
+  for _, e := range entries {
+    if e.Name() == ".git" {
+      continue
+    }
+    full := filepath.Join(path, e.Name())
+    info, err := os.Lstat(full)
+    if err != nil {
+      return "", err
+    }
+    var id, mode string
+    sortKey := e.Name()
+    switch {
+    case info.IsDir():
+      if id, err = writeTree(dir, full); err != nil {
+        return "", err
+      }
+      if id == "" {
+        continue // git does not store empty directories
+      }
+      mode, sortKey = "40000", e.Name()+"/"
+    case info.Mode()&os.ModeSymlink != 0:
+      target, err := os.Readlink(full)
+      if err != nil {
+        return "", err
+      }
+      if id, err = writeObject(dir, "blob", []byte(target)); err != nil {
+        return "", err
+      }
+      mode = "120000"
+    default:
+      data, err := os.ReadFile(full)
+      if err != nil {
+        return "", err
+      }
+      if id, err = writeObject(dir, "blob", data); err != nil {
+        return "", err
+      }
+      mode = "100644"
+      if info.Mode()&0o111 != 0 {
+        mode = "100755"
+      }
+    }
+    raw, _ := hex.DecodeString(id)
+    items = append(items, treeEntry{mode, e.Name(), sortKey, raw})
+  }
+  if len(items) == 0 && path != "." {
+    return "", nil
+  }
+  sort.Slice(items, func(i, j int) bool { return items[i].sortKey < items[j].sortKey })
+  var buf bytes.Buffer
+  for _, it := range items {
+    fmt.Fprintf(&buf, "%s %s\x00", it.mode, it.name)
+    buf.Write(it.id)
+  }
+  return writeObject(dir, "tree", buf.Bytes())
+}
+
+// Identity and timestamp for commits, taken from the same environment
+// variables git uses.
+func signature(kind string) string {
+  name := os.Getenv("GIT_" + kind + "_NAME")
+  if name == "" {
+    name = "Coding Challenges"
+  }
+  email := os.Getenv("GIT_" + kind + "_EMAIL")
+  if email == "" {
+    email = "cc@example.com"
+  }
+  now := time.Now()
+  return fmt.Sprintf("%s <%s> %d %s", name, email, now.Unix(), now.Format("-0700"))
+}
+
+// Create a commit object for a tree with optional parents.
+func commitTree(dir, tree string, parents []string, message string) (string, error) {
+  if kind, _, err := readObject(dir, tree); err != nil || kind != "tree" {
+    return "", fmt.Errorf("%s is not a valid tree object", tree)
+  }
+  var buf strings.Builder
+  fmt.Fprintf(&buf, "tree %s\n", tree)
+  for _, p := range parents {
+    fmt.Fprintf(&buf, "parent %s\n", p)
+  }
+  fmt.Fprintf(&buf, "author %s\n", signature("AUTHOR"))
+  fmt.Fprintf(&buf, "committer %s\n\n", signature("COMMITTER"))
+  buf.WriteString(message)
+  if !strings.HasSuffix(message, "\n") {
+    buf.WriteString("\n")
+  }
+  return writeObject(dir, "commit", []byte(buf.String()))
+}
+
+// A repeatable string flag for -p parents.
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, ",") }
+func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
+
+func fail(err error) {
+  fmt.Fprintln(os.Stderr, "fatal:", err)
+  os.Exit(128)
+}
+
+// Dispatch the subcommand.
+func main() {
+  if len(os.Args) < 2 {
+    fmt.Fprintln(os.Stderr, "usage: git <init|hash-object|cat-file|write-tree|commit-tree> [args]")
+    os.Exit(1)
+  }
+  cmd, args := os.Args[1], os.Args[2:]
+  if cmd == "init" {
+    dir := "."
+    if len(args) > 0 {
+      dir = args[0]
+    }
+    if err := initRepo(dir); err != nil {
+      fail(err)
+    }
+    return
+  }
+
+  dir, err := gitDir()
+  if err != nil && !(cmd == "hash-object") {
+    fail(err)
+  }
+
+  switch cmd {
+  case "hash-object":
+    fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+    write := fs.Bool("w", false, "write the object into the object database")
+    kind := fs.String("t", "blob", "object type")
+    fs.Parse(args)
+    for _, name := range fs.Args() {
+      data, err := os.ReadFile(name)
+      if err != nil {
+        fail(err)
+      }
+      var id string
+      if *write {
+        if dir == "" {
+          fail(errors.New("not a git repository"))
+        }
+        id, err = writeObject(dir, *kind, data)
+        if err != nil {
+          fail(err)
+        }
+      } else {
+        _, id = frame(*kind, data)
+      }
+      fmt.Println(id)
+    }
+  case "cat-file":
+    if len(args) != 2 {
+      fail(errors.New("usage: git cat-file (-t | -s | -p | <type>) <object>"))
+    }
+    kind, content, err := readObject(dir, args[1])
+    if err != nil {
+      fail(err)
+    }
+    switch args[0] {
+    case "-t":
+      fmt.Println(kind)
+    case "-s":
+      fmt.Println(len(content))
+    case "-p":
+      if kind == "tree" {
+        if err := printTree(dir, content); err != nil {
+          fail(err)
+        }
+      } else {
+        os.Stdout.Write(content)
+      }
+    default:
+      if args[0] != kind {
+        fail(fmt.Errorf("git cat-file %s: bad file", args[1]))
+      }
+      os.Stdout.Write(content)
+    }
+  case "write-tree":
+    // Without an index, the tree is built from the working directory.
+    root := filepath.Dir(dir)
+    if err := os.Chdir(root); err != nil {
+      fail(err)
+    }
+    id, err := writeTree(dir, ".")
+    if err != nil {
+      fail(err)
+    }
+    fmt.Println(id)
+  case "commit-tree":
+    fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+    var parents multiFlag
+    fs.Var(&parents, "p", "parent commit (repeatable)")
+    message := fs.String("m", "", "commit message")
+    if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+      // git accepts the tree before the flags.
+      args = append(args[1:], args[0])
+    }
+    fs.Parse(args)
+    if fs.NArg() != 1 {
+      fail(errors.New("usage: git commit-tree <tree> [-p <parent>]... -m <message>"))
+    }
+    msg := *message
+    if msg == "" {
+      data, _ := io.ReadAll(os.Stdin)
+      msg = string(data)
+    }
+    id, err := commitTree(dir, fs.Arg(0), parents, msg)
+    if err != nil {
+      fail(err)
+    }
+    fmt.Println(id)
+  default:
+    fail(fmt.Errorf("'%s' is not a git command", cmd))
+  }
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+  "os"
+  "os/exec"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// Run real git in dir, with no user or system configuration to change its
+// answers.
+func realGit(t *testing.T, dir string, args ...string) string {
+  t.Helper()
+  cmd := exec.Command("git", args...)
+  cmd.Dir = dir
+  cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull, "HOME="+dir)
+  out, err := cmd.CombinedOutput()
+  if err != nil {
+    t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+  }
+  return strings.TrimSpace(string(out))
+}
+
+// The trees written for a working directory are the ones git writes for it
+// once everything is added, down to the object ids, and git can read every
+// object written.
+func TestWriteTreeMatchesGit(t *testing.T) {
+  if _, err := exec.LookPath("git"); err != nil {
+    t.Skip("git is not installed")
+  }
+  for _, c := range []struct {
+    name  string
+    files map[string]string // path to content, "->target" for a symlink
+    exec  []string
+    dirs  []string // made empty
+  }{
+    {"empty", nil, nil, []string{"nothing"}},
+    {"flat", map[string]string{"README": "hello\n", "empty": ""}, nil, nil},
+    {"nested", map[string]string{
+      "a/b/c/deep.txt": "deep\n",
+      "a/top.txt":      "top\n",
+      "src/main.go":    "package main\n",
+    }, nil, []string{"a/b/empty", "unused"}},
+    // Directories sort as if their names ended in "/", so "a" comes after
+    // "a-b" and "a.txt" but before "a0".
+    {"sort order", map[string]string{
+      "a/x": "1", "a-b": "2", "a.txt": "3", "a0": "4", "B": "5", "b": "6",
+    }, nil, nil},
+    {"modes", map[string]string{
+      "run.sh": "#!/bin/sh\necho hi\n",
+      "plain":  "plain\n",
+      "link":   "->plain",
+      "dir/up": "->../run.sh",
+    }, []string{"run.sh"}, nil},
+  } {
+    t.Run(c.name, func(t *testing.T) {
+      root := t.TempDir()
+      for path, content := range c.files {
+        full := filepath.Join(root, path)
+        if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+          t.Fatal(err)
+        }
+        if target, ok := strings.CutPrefix(content, "->"); ok {
+          if err := os.Symlink(target, full); err != nil {
+            t.Skip("no symlinks:", err)
+          }
+        } else if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+          t.Fatal(err)
+        }
+      }
+      for _, path := range c.exec {
+        os.Chmod(filepath.Join(root, path), 0o755)
+      }
+      for _, path := range c.dirs {
+        os.MkdirAll(filepath.Join(root, path), 0o755)
+      }
+
+      realGit(t, root, "init", "-q")
+      t.Chdir(root)
+      got, err := writeTree(filepath.Join(root, ".git"), ".")
+      if err != nil {
+        t.Fatal(err)
+      }
+      // Check our objects before git adds its own copies of them.
+      realGit(t, root, "fsck", "--strict", "--no-dangling")
+      realGit(t, root, "add", "-A")
+      if want := realGit(t, root, "write-tree"); got != want {
+        t.Errorf("got tree %s, git wrote %s:\n%s", got, want, realGit(t, root, "ls-tree", "-r", "-t", want))
+      }
+    })
+  }
+}
+
+// A blob written and read back is the one git would hash.
+func TestObjects(t *testing.T) {
+  dir := t.TempDir()
+  id, err := writeObject(dir, "blob", []byte("what is up, doc?"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if want := "bd9dbf5aae1a3862dd1526723246b20206e5fc37"; id != want {
+    t.Errorf("got id %s, want %s", id, want)
+  }
+  for _, short := range []string{id, id[:7], id[:4]} {
+    kind, content, err := readObject(dir, short)
+    if err != nil || kind != "blob" || string(content) != "what is up, doc?" {
+      t.Errorf("reading %s gave %s %q, %v", short, kind, content, err)
+    }
+  }
+  if _, _, err := readObject(dir, id[:3]); err == nil {
+    t.Error("a 3-character id was accepted")
+  }
+  if _, _, err := readObject(dir, strings.Repeat("0", 40)); err == nil {
+    t.Error("a missing object was read")
+  }
+}
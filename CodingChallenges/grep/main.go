@@ -0,0 +1,135 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "io/fs"
+  "os"
+  "path/filepath"
+)
+
+// Options collected from the command line.
+type options struct {
+  recursive   bool
+  invert      bool
+  ignoreCase  bool
+  lineNumbers bool
+}
+
+var opts options
+
+// Scan a reader line-by-line and print the lines that match (or, with -v,
+// do not match) the regex. The file name is prefixed when more than one file
+// is being searched. Return whether anything was printed.
+func grepReader(re *regex, r io.Reader, name string, showName bool, w *bufio.Writer) (bool, error) {
+  found := false
+  scanner := bufio.NewScanner(r)
+  scanner.Buffer(make([]byte, 64*1024), 1<<30)
+  for n := 1; scanner.Scan(); n++ {
+    line := scanner.Text()
+    if re.matchString(line) == opts.invert {
+      continue
+    }
+    found = true
+    if showName {
+      fmt.Fprintf(w, "%s:", name)
+    }
+    if opts.lineNumbers {
+      fmt.Fprintf(w, "%d:", n)
+    }
+    fmt.Fprintln(w, line)
+  }
+  return found, scanner.Err()
+}
+
+// Search a single named file (or stdin for "-").
+func grepFile(re *regex, name string, showName bool, w *bufio.Writer) (bool, error) {
+  if name == "-" {
+    return grepReader(re, os.Stdin, "(standard input)", showName, w)
+  }
+  file, err := os.Open(name)
+  if err != nil {
+    return false, err
+  }
+  defer file.Close()
+  return grepReader(re, file, name, showName, w)
+}
+
+// Expand the command line paths into the list of files to search. With -r
+// directories are walked recursively, otherwise they are reported and
+// skipped like GNU grep does.
+func collectFiles(paths []string) []string {
+  var files []string
+  for _, path := range paths {
+    info, err := os.Stat(path)
+    if path == "-" || err != nil || !info.IsDir() {
+      files = append(files, path)
+      continue
+    }
+    if !opts.recursive {
+      fmt.Fprintf(os.Stderr, "grep: %s: Is a directory\n", path)
+      continue
+    }
+    filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+      if err != nil {
+        fmt.Fprintln(os.Stderr, "grep:", err)
+        return nil
+      }
+      if d.Type().IsRegular() {
+        files = append(files, p)
+      }
+      return nil
+    })
+  }
+  return files
+}
+
+// Parse the command line, compile the pattern and search every file. The
+// exit status follows grep: 0 when a line was selected, 1 when none were, 2
+// on errors.
+func main() {
+  flag.BoolVar(&opts.recursive, "r", false, "read all files under each directory, recursively")
+  flag.BoolVar(&opts.invert, "v", false, "select non-matching lines")
+  flag.BoolVar(&opts.ignoreCase, "i", false, "ignore case distinctions")
+  flag.BoolVar(&opts.lineNumbers, "n", false, "print line number with output lines")
+  flag.Parse()
+
+  args := flag.Args()
+  if len(args) == 0 {
+    fmt.Fprintln(os.Stderr, "usage: grep [-rvin] PATTERN [FILE...]")
+    os.Exit(2)
+  }
+  re, err := compile(args[0], opts.ignoreCase)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "grep:", err)
+    os.Exit(2)
+  }
+
+  paths := args[1:]
+  if len(paths) == 0 {
+    if opts.recursive {
+      paths = []string{"."}
+    } else {
+      paths = []string{"-"}
+    }
+  }
+  files := collectFiles(paths)
+  showName := len(files) > 1 || opts.recursive
+
+  w := bufio.NewWriter(os.Stdout)
+  status := 1
+  for _, name := range files {
+    found, err := grepFile(re, name, showName, w)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "grep:", err)
+      status = 2
+    }
+    if found && status != 2 {
+      status = 0
+    }
+  }
+  w.Flush()
+  os.Exit(status)
+}
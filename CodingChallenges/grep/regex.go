@@ -0,0 +1,309 @@
+package main
+
+import (
+  "fmt"
+  "unicode"
+)
+
+// The regex engine is a small backtracking matcher. Patterns are parsed into
+// a tree of alternations, sequences and quantified atoms, and matched with
+// continuation passing so that "a*ab" backtracks into the star correctly.
+
+type nodeKind int
+
+const (
+  charClass nodeKind = iota
+  group
+  lineStart
+  lineEnd
+)
+
+// A single atom: a set of accepted characters, a parenthesised group, or an
+// anchor. Quantifiers are recorded as min/max repetitions (max -1 = no limit).
+type node struct {
+  kind    nodeKind
+  matches func(rune) bool
+  alts    [][]*node
+  min     int
+  max     int
+}
+
+// A compiled pattern. The top level is always an alternation of sequences.
+type regex struct {
+  alts [][]*node
+}
+
+type parser struct {
+  pattern    []rune
+  pos        int
+  ignoreCase bool
+}
+
+// Compile a pattern string into a regex, reporting syntax errors such as
+// unbalanced parentheses or a dangling quantifier.
+func compile(pattern string, ignoreCase bool) (*regex, error) {
+  p := &parser{pattern: []rune(pattern), ignoreCase: ignoreCase}
+  alts, err := p.parseAlternation()
+  if err != nil {
+    return nil, err
+  }
+  if p.pos < len(p.pattern) {
+    return nil, fmt.Errorf("unmatched ) at offset %d", p.pos)
+  }
+  return &regex{alts: alts}, nil
+}
+
+// Parse sequences separated by "|" until the end of the pattern or a ")".
+func (p *parser) parseAlternation() ([][]*node, error) {
+  var alts [][]*node
+  for {
+    seq, err := p.parseSequence()
+    if err != nil {
+      return nil, err
+    }
+    alts = append(alts, seq)
+    if p.pos < len(p.pattern) && p.pattern[p.pos] == '|' {
+      p.pos++
+      continue
+    }
+    return alts, nil
+  }
+}
+
+// Parse quantified atoms until "|", ")" or the end of the pattern.
+func (p *parser) parseSequence() ([]*node, error) {
+  var seq []*node
+  for p.pos < len(p.pattern) {
+    c := p.pattern[p.pos]
+    if c == '|' || c == ')' {
+      break
+    }
+    n, err := p.parseAtom()
+    if err != nil {
+      return nil, err
+    }
+    if p.pos < len(p.pattern) {
+      switch p.pattern[p.pos] {
+      case '*':
+        n.min, n.max = 0, -1
+        p.pos++
+      case '+':
+        n.min, n.max = 1, -1
+        p.pos++
+      case '?':
+        n.min, n.max = 0, 1
+        p.pos++
+      }
+    }
+    seq = append(seq, n)
+  }
+  return seq, nil
+}
+
+// Parse a single atom and return it with a default quantifier of exactly one.
+func (p *parser) parseAtom() (*node, error) {
+  c := p.pattern[p.pos]
+  p.pos++
+  n := &node{kind: charClass, min: 1, max: 1}
+  switch c {
+  case '*', '+', '?':
+    return nil, fmt.Errorf("nothing to repeat at offset %d", p.pos-1)
+  case '^':
+    n.kind = lineStart
+  case '$':
+    n.kind = lineEnd
+  case '.':
+    n.matches = func(rune) bool { return true }
+  case '(':
+    alts, err := p.parseAlternation()
+    if err != nil {
+      return nil, err
+    }
+    if p.pos >= len(p.pattern) || p.pattern[p.pos] != ')' {
+      return nil, fmt.Errorf("missing )")
+    }
+    p.pos++
+    n.kind = group
+    n.alts = alts
+  case '[':
+    return p.parseBracket()
+  case '\\':
+    if p.pos >= len(p.pattern) {
+      return nil, fmt.Errorf("trailing backslash")
+    }
+    n.matches = p.fold(escape(p.pattern[p.pos]))
+    p.pos++
+  default:
+    n.matches = p.fold(func(r rune) bool { return r == c })
+  }
+  return n, nil
+}
+
+// With -i, make a matcher also accept every other case of what it accepts.
+// Bracket expressions are folded before they are negated, so that [^a]
+// turns away "A" as well as "a".
+func (p *parser) fold(m func(rune) bool) func(rune) bool {
+  if !p.ignoreCase {
+    return m
+  }
+  return func(r rune) bool {
+    if m(r) {
+      return true
+    }
+    for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+      if m(f) {
+        return true
+      }
+    }
+    return false
+  }
+}
+
+// Return the matcher for a backslash escape. \d, \w and \s (and their
+// negated upper-case forms) are classes, anything else is a literal.
+func escape(c rune) func(rune) bool {
+  switch c {
+  case 'd':
+    return unicode.IsDigit
+  case 'D':
+    return func(r rune) bool { return !unicode.IsDigit(r) }
+  case 'w':
+    return isWord
+  case 'W':
+    return func(r rune) bool { return !isWord(r) }
+  case 's':
+    return unicode.IsSpace
+  case 'S':
+    return func(r rune) bool { return !unicode.IsSpace(r) }
+  }
+  return func(r rune) bool { return r == c }
+}
+
+func isWord(r rune) bool {
+  return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Parse a bracket expression such as [a-z0-9_] or [^abc]. A "]" straight
+// after the opening bracket (or "^") is taken literally.
+func (p *parser) parseBracket() (*node, error) {
+  negate := false
+  if p.pos < len(p.pattern) && p.pattern[p.pos] == '^' {
+    negate = true
+    p.pos++
+  }
+  var ranges [][2]rune
+  var classes []func(rune) bool
+  first := true
+  for {
+    if p.pos >= len(p.pattern) {
+      return nil, fmt.Errorf("missing ]")
+    }
+    c := p.pattern[p.pos]
+    if c == ']' && !first {
+      p.pos++
+      break
+    }
+    first = false
+    p.pos++
+    if c == '\\' && p.pos < len(p.pattern) {
+      classes = append(classes, escape(p.pattern[p.pos]))
+      p.pos++
+      continue
+    }
+    lo, hi := c, c
+    if p.pos+1 < len(p.pattern) && p.pattern[p.pos] == '-' && p.pattern[p.pos+1] != ']' {
+      hi = p.pattern[p.pos+1]
+      p.pos += 2
+      if hi < lo {
+        return nil, fmt.Errorf("invalid range %c-%c", lo, hi)
+      }
+    }
+    ranges = append(ranges, [2]rune{lo, hi})
+  }
+  in := p.fold(func(r rune) bool {
+    for _, rg := range ranges {
+      if r >= rg[0] && r <= rg[1] {
+        return true
+      }
+    }
+    for _, class := range classes {
+      if class(r) {
+        return true
+      }
+    }
+    return false
+  })
+  match := func(r rune) bool { return in(r) != negate }
+  return &node{kind: charClass, matches: match, min: 1, max: 1}, nil
+}
+
+// Report whether the regex matches anywhere in the line.
+func (re *regex) matchString(line string) bool {
+  text := []rune(line)
+  for start := 0; start <= len(text); start++ {
+    if re.matchAlts(re.alts, text, start, func(int) bool { return true }) {
+      return true
+    }
+  }
+  return false
+}
+
+// Try each alternative in turn, succeeding if any of them can be followed by
+// the continuation.
+func (re *regex) matchAlts(alts [][]*node, text []rune, pos int, k func(int) bool) bool {
+  for _, seq := range alts {
+    if re.matchSeq(seq, text, pos, k) {
+      return true
+    }
+  }
+  return false
+}
+
+// Match a sequence of nodes starting at pos, then hand the end position to
+// the continuation.
+func (re *regex) matchSeq(seq []*node, text []rune, pos int, k func(int) bool) bool {
+  if len(seq) == 0 {
+    return k(pos)
+  }
+  return re.matchRepeat(seq[0], 0, text, pos, func(next int) bool {
+    return re.matchSeq(seq[1:], text, next, k)
+  })
+}
+
+// Match node n greedily: try one more repetition first and fall back to
+// stopping here once at least n.min repetitions have been seen. Zero-width
+// repetitions are cut off to avoid looping forever on patterns like (a*)*.
+func (re *regex) matchRepeat(n *node, count int, text []rune, pos int, k func(int) bool) bool {
+  if n.max < 0 || count < n.max {
+    matched := re.matchOne(n, text, pos, func(next int) bool {
+      if next == pos && count >= n.min {
+        return false
+      }
+      return re.matchRepeat(n, count+1, text, next, k)
+    })
+    if matched {
+      return true
+    }
+  }
+  return count >= n.min && k(pos)
+}
+
+// Match exactly one occurrence of node n.
+func (re *regex) matchOne(n *node, text []rune, pos int, k func(int) bool) bool {
+  switch n.kind {
+  case lineStart:
+    return pos == 0 && k(pos)
+  case lineEnd:
+    return pos == len(text) && k(pos)
+  case group:
+    return re.matchAlts(n.alts, text, pos, k)
+  }
+  if pos >= len(text) {
+    return false
+  }
+  r := text[pos]
+  if n.matches(r) {
+    return k(pos + 1)
+  }
+  return false
+}
@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+// Whether each pattern matches each line, as GNU grep -E (with -i where
+// ignoreCase is set) decides.
+func TestMatch(t *testing.T) {
+  for _, c := range []struct {
+    pattern    string
+    ignoreCase bool
+    line       string
+    want       bool
+  }{
+    {"hello", false, "say hello", true},
+    {"HELLO", false, "say hello", false},
+    {"HELLO", true, "say hello", true},
+    {"hello", true, "SAY HELLO", true},
+    {"[A-C]+x", true, "abX", true},
+    // A negated class is folded before it is negated, so it turns away
+    // every case of what it lists.
+    {"[^a]", true, "A", false},
+    {"[^a]", true, "aA", false},
+    {"[^a]", true, "ab", true},
+    {"[^a-z]", true, "ABC", false},
+    {"[^a-z]", true, "AB1", true},
+    {"[^A-Z]", false, "abc", true},
+    {`\W`, true, "aB_", false},
+    // Anchors.
+    {"^ab", false, "xab", false},
+    {"^ab", false, "abx", true},
+    {"ab$", false, "abx", false},
+    {"ab$", false, "xab", true},
+    {"^$", false, "", true},
+    {"^$", false, " ", false},
+    // Classes.
+    {"[]a]", false, "]", true},
+    {"[a-]", false, "-", true},
+    {`[\d]x`, false, "7x", true},
+    {`\d\s\w`, false, "1 a", true},
+    {`\d\s\w`, false, "1a ", false},
+    {"x.z", false, "xyz", true},
+    {"x.z", false, "xz", false},
+    // Extended syntax, and backtracking into what a star took.
+    {"a(b|c)+d", false, "abcbd", true},
+    {"a(b|c)+d", false, "ad", false},
+    {"colou?r", false, "color", true},
+    {"colou?r", false, "colouur", false},
+    {"a|b|c", false, "zzc", true},
+    {"a*ab", false, "aaab", true},
+    {"(a|ab)(c|bcd)(d*)", false, "abcd", true},
+    {"(ab)*$", false, "abab", true},
+    {"(a*)*b", false, "aaac", false},
+  } {
+    re, err := compile(c.pattern, c.ignoreCase)
+    if err != nil {
+      t.Errorf("%s: %v", c.pattern, err)
+      continue
+    }
+    if got := re.matchString(c.line); got != c.want {
+      t.Errorf("%s (ignore case %v) on %q: got %v, want %v", c.pattern, c.ignoreCase, c.line, got, c.want)
+    }
+  }
+}
+
+func TestCompileErrors(t *testing.T) {
+  for _, pattern := range []string{"*a", "a|+", "(ab", "ab)", "[ab", "[z-a]", `ab\`} {
+    if _, err := compile(pattern, false); err == nil {
+      t.Errorf("%s compiled", pattern)
+    }
+  }
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+)
+
+// Copy the first n lines (or n bytes when bytes is set) of r to w.
+func head(r io.Reader, w *bufio.Writer, n int64, bytes bool) error {
+  if bytes {
+    _, err := io.CopyN(w, r, n)
+    if err == io.EOF {
+      err = nil
+    }
+    return err
+  }
+  br := bufio.NewReader(r)
+  for i := int64(0); i < n; i++ {
+    line, err := br.ReadSlice('\n')
+    w.Write(line)
+    if err == bufio.ErrBufferFull {
+      // A line longer than the buffer; keep copying until its newline.
+      i--
+      continue
+    }
+    if err == io.EOF {
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// Print the head of each file, with "==> name <==" banners when there is more
+// than one, like head(1).
+func main() {
+  lines := flag.Int64("n", 10, "print the first `num` lines")
+  count := flag.Int64("c", -1, "print the first `num` bytes")
+  quiet := flag.Bool("q", false, "never print file name headers")
+  flag.Parse()
+
+  files := flag.Args()
+  if len(files) == 0 {
+    files = []string{"-"}
+  }
+  n, bytes := *lines, false
+  if *count >= 0 {
+    n, bytes = *count, true
+  }
+
+  w := bufio.NewWriter(os.Stdout)
+  defer w.Flush()
+  status := 0
+  for i, name := range files {
+    var r io.Reader = os.Stdin
+    if name != "-" {
+      file, err := os.Open(name)
+      if err != nil {
+        fmt.Fprintln(os.Stderr, "head:", err)
+        status = 1
+        continue
+      }
+      defer file.Close()
+      r = file
+    }
+    if len(files) > 1 && !*quiet {
+      if i > 0 {
+        w.WriteString("\n")
+      }
+      if name == "-" {
+        name = "standard input"
+      }
+      fmt.Fprintf(w, "==> %s <==\n", name)
+    }
+    if err := head(r, w, n, bytes); err != nil {
+      fmt.Fprintln(os.Stderr, "head:", err)
+      status = 1
+    }
+  }
+  w.Flush()
+  os.Exit(status)
+}
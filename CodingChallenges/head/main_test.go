@@ -0,0 +1,41 @@
+package main
+
+import (
+  "bufio"
+  "strings"
+  "testing"
+  "testing/iotest"
+)
+
+func TestHead(t *testing.T) {
+  long := strings.Repeat("x", 10000) // longer than bufio's buffer
+  for _, c := range []struct {
+    in    string
+    n     int64
+    bytes bool
+    want  string
+  }{
+    {"1\n2\n3\n", 2, false, "1\n2\n"},
+    {"1\n2\n3\n", 0, false, ""},
+    {"1\n2\n3\n", 5, false, "1\n2\n3\n"},
+    {"1\n2\n3", 3, false, "1\n2\n3"},
+    {"", 3, false, ""},
+    {"\n\n\n", 2, false, "\n\n"},
+    {long + "\n" + long + "\nend\n", 1, false, long + "\n"},
+    {long + "\n" + long + "\nend\n", 3, false, long + "\n" + long + "\nend\n"},
+    {"1\n2\n3\n", 3, true, "1\n2"},
+    {"1\n2\n3\n", 0, true, ""},
+    {"1\n2\n3\n", 99, true, "1\n2\n3\n"},
+  } {
+    var out strings.Builder
+    w := bufio.NewWriter(&out)
+    // A byte at a time, as a slow pipe would give it.
+    if err := head(iotest.OneByteReader(strings.NewReader(c.in)), w, c.n, c.bytes); err != nil {
+      t.Fatal(err)
+    }
+    w.Flush()
+    if out.String() != c.want {
+      t.Errorf("%.20q, %d (bytes %v): got %.40q, want %.40q", c.in, c.n, c.bytes, out.String(), c.want)
+    }
+  }
+}
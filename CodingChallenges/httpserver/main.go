@@ -0,0 +1,293 @@
+package main
+
+import (
+  "bufio"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "mime"
+  "net"
+  "net/url"
+  "os"
+  "path"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// A parsed HTTP/1.1 request. Only what the static file server needs is kept.
+type request struct {
+  method  string
+  target  string
+  version string
+  headers map[string]string
+  body    []byte
+}
+
+// Errors that map straight onto an HTTP status code.
+type httpError struct {
+  status int
+  msg    string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+var statusText = map[int]string{
+  200: "OK",
+  400: "Bad Request",
+  403: "Forbidden",
+  404: "Not Found",
+  405: "Method Not Allowed",
+  413: "Payload Too Large",
+  431: "Request Header Fields Too Large",
+  500: "Internal Server Error",
+  501: "Not Implemented",
+  505: "HTTP Version Not Supported",
+}
+
+const (
+  maxHeaderLine = 8 * 1024
+  maxHeaders    = 100
+  maxBody       = 1 << 20
+)
+
+// Read a CRLF (or bare LF) terminated line, refusing lines longer than the
+// header limit so a client cannot make us buffer forever.
+func readLine(r *bufio.Reader) (string, error) {
+  var line []byte
+  for {
+    chunk, isPrefix, err := r.ReadLine()
+    if err != nil {
+      return "", err
+    }
+    line = append(line, chunk...)
+    if len(line) > maxHeaderLine {
+      return "", &httpError{431, "header line too long"}
+    }
+    if !isPrefix {
+      return string(line), nil
+    }
+  }
+}
+
+// Parse a request line, headers and (when Content-Length is given) the body.
+// Malformed input is reported as an httpError carrying the status to send.
+func readRequest(r *bufio.Reader) (*request, error) {
+  line, err := readLine(r)
+  if err != nil {
+    return nil, err
+  }
+  // Tolerate stray empty lines between pipelined requests (RFC 9112 2.2).
+  for line == "" {
+    if line, err = readLine(r); err != nil {
+      return nil, err
+    }
+  }
+
+  parts := strings.Split(line, " ")
+  if len(parts) != 3 || parts[0] == "" || !strings.HasPrefix(parts[1], "/") {
+    return nil, &httpError{400, "malformed request line"}
+  }
+  req := &request{method: parts[0], target: parts[1], version: parts[2], headers: map[string]string{}}
+  if !strings.HasPrefix(req.version, "HTTP/1.") {
+    if strings.HasPrefix(req.version, "HTTP/") {
+      return nil, &httpError{505, "unsupported version"}
+    }
+    return nil, &httpError{400, "malformed version"}
+  }
+
+  for i := 0; ; i++ {
+    if i > maxHeaders {
+      return nil, &httpError{431, "too many headers"}
+    }
+    line, err := readLine(r)
+    if err != nil {
+      if err == io.EOF {
+        return nil, &httpError{400, "unexpected end of headers"}
+      }
+      return nil, err
+    }
+    if line == "" {
+      break
+    }
+    colon := strings.IndexByte(line, ':')
+    if colon <= 0 || strings.ContainsAny(line[:colon], " \t") {
+      return nil, &httpError{400, "malformed header"}
+    }
+    name := strings.ToLower(line[:colon])
+    value := strings.TrimSpace(line[colon+1:])
+    // A repeated header is the same as one listing its values, except
+    // that two different lengths leave the body's end in doubt
+    // (RFC 9112 6.3).
+    if prev, ok := req.headers[name]; ok {
+      if name == "content-length" {
+        if prev != value {
+          return nil, &httpError{400, "conflicting Content-Length"}
+        }
+      } else {
+        value = prev + ", " + value
+      }
+    }
+    req.headers[name] = value
+  }
+
+  if req.version == "HTTP/1.1" && req.headers["host"] == "" {
+    return nil, &httpError{400, "missing Host header"}
+  }
+  // No transfer coding is supported, so where the body ends is unknown
+  // and the connection cannot be used for another request.
+  if _, ok := req.headers["transfer-encoding"]; ok {
+    return nil, &httpError{501, "Transfer-Encoding not supported"}
+  }
+  if cl, ok := req.headers["content-length"]; ok {
+    n, err := strconv.Atoi(cl)
+    if err != nil || n < 0 {
+      return nil, &httpError{400, "bad Content-Length"}
+    }
+    if n > maxBody {
+      return nil, &httpError{413, "body too large"}
+    }
+    req.body = make([]byte, n)
+    if _, err := io.ReadFull(r, req.body); err != nil {
+      return nil, &httpError{400, "short body"}
+    }
+  }
+  return req, nil
+}
+
+// Decide whether the connection should stay open after this request, using
+// the HTTP/1.1 default of keep-alive and the HTTP/1.0 default of close.
+func keepAlive(req *request) bool {
+  conn := strings.ToLower(req.headers["connection"])
+  if req.version == "HTTP/1.0" {
+    return conn == "keep-alive"
+  }
+  return conn != "close"
+}
+
+// Write a complete response with the standard headers plus any extra header
+// lines given.
+func writeResponse(w *bufio.Writer, status int, contentType string, body []byte, headOnly, keep bool, extra ...string) error {
+  fmt.Fprintf(w, "HTTP/1.1 %d %s\r\n", status, statusText[status])
+  fmt.Fprintf(w, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123[:len(time.RFC1123)-3]+"GMT"))
+  fmt.Fprintf(w, "Server: ccserver\r\n")
+  fmt.Fprintf(w, "Content-Type: %s\r\n", contentType)
+  fmt.Fprintf(w, "Content-Length: %d\r\n", len(body))
+  if keep {
+    fmt.Fprintf(w, "Connection: keep-alive\r\n")
+  } else {
+    fmt.Fprintf(w, "Connection: close\r\n")
+  }
+  for _, header := range extra {
+    w.WriteString(header + "\r\n")
+  }
+  w.WriteString("\r\n")
+  if !headOnly {
+    w.Write(body)
+  }
+  return w.Flush()
+}
+
+// Write an error page for the given status.
+func writeError(w *bufio.Writer, status int, headOnly, keep bool, extra ...string) error {
+  body := []byte(fmt.Sprintf("<h1>%d %s</h1>\n", status, statusText[status]))
+  return writeResponse(w, status, "text/html; charset=utf-8", body, headOnly, keep, extra...)
+}
+
+// Map a request target onto a file under root. The path is cleaned before
+// joining so "/../" segments can never escape the document root.
+func resolve(root, target string) (string, error) {
+  if i := strings.IndexAny(target, "?#"); i >= 0 {
+    target = target[:i]
+  }
+  p, err := url.PathUnescape(target)
+  if err != nil {
+    return "", &httpError{400, "bad escape"}
+  }
+  p = path.Clean("/" + p)
+  full := filepath.Join(root, filepath.FromSlash(p))
+  info, err := os.Stat(full)
+  if err != nil {
+    if errors.Is(err, os.ErrNotExist) {
+      return "", &httpError{404, "not found"}
+    }
+    return "", &httpError{403, "forbidden"}
+  }
+  if info.IsDir() {
+    full = filepath.Join(full, "index.html")
+  }
+  return full, nil
+}
+
+// Pick a content type from the file extension, falling back to octet-stream.
+func contentType(name string) string {
+  if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+    return t
+  }
+  return "application/octet-stream"
+}
+
+// Serve requests on one connection until the client closes it, asks for
+// "Connection: close", sends garbage, or stays idle past the timeout.
+func handle(conn net.Conn, root string, idle time.Duration) {
+  defer conn.Close()
+  r := bufio.NewReader(conn)
+  w := bufio.NewWriter(conn)
+  for {
+    conn.SetReadDeadline(time.Now().Add(idle))
+    req, err := readRequest(r)
+    if err != nil {
+      var he *httpError
+      if errors.As(err, &he) {
+        writeError(w, he.status, false, false)
+      }
+      return
+    }
+
+    keep := keepAlive(req)
+    headOnly := req.method == "HEAD"
+    if req.method != "GET" && req.method != "HEAD" {
+      writeError(w, 405, false, keep, "Allow: GET, HEAD")
+    } else if file, err := resolve(root, req.target); err != nil {
+      status := 500
+      var he *httpError
+      if errors.As(err, &he) {
+        status = he.status
+      }
+      writeError(w, status, headOnly, keep)
+    } else if body, err := os.ReadFile(file); err != nil {
+      writeError(w, 404, headOnly, keep)
+    } else {
+      writeResponse(w, 200, contentType(file), body, headOnly, keep)
+    }
+
+    if !keep {
+      return
+    }
+  }
+}
+
+// Listen on the configured address and serve each connection in its own
+// goroutine.
+func main() {
+  addr := flag.String("addr", ":8080", "address to listen on")
+  root := flag.String("root", "www", "directory to serve files from")
+  idle := flag.Duration("idle", 30*time.Second, "keep-alive idle timeout")
+  flag.Parse()
+
+  listener, err := net.Listen("tcp", *addr)
+  if err != nil {
+    panic(err)
+  }
+  fmt.Println("Serving", *root, "on", listener.Addr())
+  for {
+    conn, err := listener.Accept()
+    if err != nil {
+      fmt.Println("accept:", err)
+      continue
+    }
+    go handle(conn, *root, *idle)
+  }
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+  "io"
+  "net"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+  "time"
+)
+
+// Send raw bytes to a server for root, half-close the connection, and
+// return everything it sent back before closing its end.
+func exchange(t *testing.T, root, send string) string {
+  t.Helper()
+  l, err := net.Listen("tcp", "127.0.0.1:0")
+  if err != nil {
+    t.Skip("no loopback:", err)
+  }
+  defer l.Close()
+  go func() {
+    if conn, err := l.Accept(); err == nil {
+      handle(conn, root, 5*time.Second)
+    }
+  }()
+  conn, err := net.Dial("tcp", l.Addr().String())
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer conn.Close()
+  // The server may stop reading and answer before it has everything.
+  go func() {
+    io.WriteString(conn, send)
+    conn.(*net.TCPConn).CloseWrite()
+  }()
+  conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+  got, err := io.ReadAll(conn)
+  if err != nil && !strings.Contains(err.Error(), "reset") {
+    t.Fatalf("reading the response: %v", err)
+  }
+  return string(got)
+}
+
+func TestRequests(t *testing.T) {
+  root := t.TempDir()
+  if err := os.WriteFile(filepath.Join(root, "index.html"), []byte("<p>hi</p>\n"), 0o644); err != nil {
+    t.Fatal(err)
+  }
+  get := "GET / HTTP/1.1\r\nHost: x\r\n"
+  for _, c := range []struct {
+    name, send string
+    status     string // the start of the only response, or "" for none
+  }{
+    {"ok", get + "Connection: close\r\n\r\n", "HTTP/1.1 200 OK\r\n"},
+    {"head", "HEAD / HTTP/1.0\r\n\r\n", "HTTP/1.1 200 OK\r\n"},
+    {"nothing", "", ""},
+    {"bad request line", "GARBAGE\r\n\r\n", "HTTP/1.1 400 "},
+    {"relative target", "GET index.html HTTP/1.1\r\nHost: x\r\n\r\n", "HTTP/1.1 400 "},
+    {"bad version", "GET / HTTP/2.0\r\n\r\n", "HTTP/1.1 505 "},
+    {"no host", "GET / HTTP/1.1\r\n\r\n", "HTTP/1.1 400 "},
+    {"bad header", get + "No colon\r\n\r\n", "HTTP/1.1 400 "},
+    {"oversized header", get + "X: " + strings.Repeat("a", maxHeaderLine) + "\r\n\r\n", "HTTP/1.1 431 "},
+    {"too many headers", get + strings.Repeat("X: y\r\n", maxHeaders+1) + "\r\n", "HTTP/1.1 431 "},
+    {"same Content-Length twice", get + "Content-Length: 3\r\nContent-Length: 3\r\nConnection: close\r\n\r\nabc", "HTTP/1.1 200 OK\r\n"},
+    {"conflicting Content-Length", get + "Content-Length: 3\r\nContent-Length: 4\r\n\r\nabcd", "HTTP/1.1 400 "},
+    {"bad Content-Length", get + "Content-Length: -1\r\n\r\n", "HTTP/1.1 400 "},
+    {"too large", get + "Content-Length: 2000000\r\n\r\n", "HTTP/1.1 413 "},
+    {"chunked", get + "Transfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n", "HTTP/1.1 501 "},
+    {"chunked and length", get + "Content-Length: 3\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n", "HTTP/1.1 501 "},
+    {"eof in request line", "GET / HT", "HTTP/1.1 400 "},
+    {"eof in headers", get + "Accept: */*", "HTTP/1.1 400 "},
+    {"eof in body", get + "Content-Length: 10\r\n\r\nabc", "HTTP/1.1 400 "},
+    {"post", "POST / HTTP/1.1\r\nHost: x\r\nConnection: close\r\nContent-Length: 1\r\n\r\na", "HTTP/1.1 405 "},
+  } {
+    t.Run(c.name, func(t *testing.T) {
+      got := exchange(t, root, c.send)
+      if c.status == "" {
+        if got != "" {
+          t.Errorf("got %q, want no response", got)
+        }
+        return
+      }
+      if !strings.HasPrefix(got, c.status) {
+        t.Fatalf("got %q, want a %q response", got, c.status)
+      }
+      if n := strings.Count(got, "HTTP/1.1 "); n != 1 {
+        t.Errorf("got %d responses: %q", n, got)
+      }
+      if !strings.Contains(got, "\r\nConnection: close\r\n") {
+        t.Errorf("the connection was not marked closed: %q", got)
+      }
+    })
+  }
+}
+
+// Requests on one connection are answered in turn until one asks to close.
+func TestKeepAlive(t *testing.T) {
+  root := t.TempDir()
+  os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0o644)
+  got := exchange(t, root, "GET /a.txt HTTP/1.1\r\nHost: x\r\n\r\n"+
+    "\r\nGET /missing HTTP/1.1\r\nHost: x\r\n\r\n"+
+    "GET /a.txt HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n"+
+    "GET /a.txt HTTP/1.1\r\nHost: x\r\n\r\n")
+  var statuses []string
+  for _, resp := range strings.Split(got, "HTTP/1.1 ")[1:] {
+    status, _, _ := strings.Cut(resp, "\r\n")
+    statuses = append(statuses, status)
+  }
+  want := []string{"200 OK", "404 Not Found", "200 OK"}
+  if strings.Join(statuses, "|") != strings.Join(want, "|") {
+    t.Errorf("got %q, want %q", statuses, want)
+  }
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "net"
+  "os"
+  "strings"
+  "sync"
+  "time"
+)
+
+// The client keeps a scrollback buffer per channel (and per private
+// conversation) and tracks which one typed text is sent to. The reading
+// goroutine and the input loop share it, so mu guards everything but conn.
+type client struct {
+  conn    net.Conn
+  mu      sync.Mutex
+  nick    string
+  buffers map[string][]string
+  current string
+}
+
+// How long to wait for the server to close the connection after QUIT.
+const quitWait = 5 * time.Second
+
+const scrollback = 500
+
+// Send a message to the server.
+func (c *client) send(command string, params ...string) error {
+  m := &message{command: command, params: params}
+  _, err := c.conn.Write([]byte(m.String()))
+  return err
+}
+
+// The nickname the client currently has.
+func (c *client) nickname() string {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  return c.nick
+}
+
+// Append a line to a buffer and print it, prefixed with the buffer name when
+// it is not the one currently in focus.
+func (c *client) show(buffer, line string) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  if buffer == "" {
+    buffer = "*server*"
+  }
+  stamped := time.Now().Format("15:04") + " " + line
+  buf := append(c.buffers[buffer], stamped)
+  if len(buf) > scrollback {
+    buf = buf[len(buf)-scrollback:]
+  }
+  c.buffers[buffer] = buf
+  if buffer == c.current || buffer == "*server*" {
+    fmt.Println(stamped)
+  } else {
+    fmt.Printf("[%s] %s\n", buffer, stamped)
+  }
+}
+
+// Handle one message from the server.
+func (c *client) handle(m *message) {
+  switch m.command {
+  case "PING":
+    c.send("PONG", m.params...)
+  case "001":
+    c.show("", "Registered as "+c.nickname())
+  case "433":
+    // Nickname in use: retry with an underscore appended.
+    c.mu.Lock()
+    c.nick += "_"
+    nick := c.nick
+    c.mu.Unlock()
+    c.send("NICK", nick)
+  case "JOIN":
+    if len(m.params) > 0 {
+      ch := m.params[0]
+      c.mu.Lock()
+      if m.nick() == c.nick {
+        c.current = ch
+      }
+      c.mu.Unlock()
+      c.show(ch, fmt.Sprintf("* %s has joined %s", m.nick(), ch))
+    }
+  case "PART":
+    if len(m.params) > 0 {
+      c.show(m.params[0], fmt.Sprintf("* %s has left %s", m.nick(), m.params[0]))
+    }
+  case "QUIT":
+    reason := ""
+    if len(m.params) > 0 {
+      reason = " (" + m.params[0] + ")"
+    }
+    c.show("", fmt.Sprintf("* %s has quit%s", m.nick(), reason))
+  case "NICK":
+    if len(m.params) > 0 {
+      c.mu.Lock()
+      if m.nick() == c.nick {
+        c.nick = m.params[0]
+      }
+      c.mu.Unlock()
+      c.show("", fmt.Sprintf("* %s is now known as %s", m.nick(), m.params[0]))
+    }
+  case "PRIVMSG", "NOTICE":
+    if len(m.params) < 2 {
+      return
+    }
+    target := m.params[0]
+    if target == c.nickname() {
+      target = m.nick()
+    }
+    c.show(target, fmt.Sprintf("<%s> %s", m.nick(), m.params[1]))
+  default:
+    // Numeric replies and anything else go to the server buffer, without the
+    // leading target nick.
+    params := m.params
+    if len(params) > 0 && params[0] == c.nickname() {
+      params = params[1:]
+    }
+    c.show("", strings.Join(params, " "))
+  }
+}
+
+// Read server messages until the connection drops.
+func (c *client) readLoop(done chan<- struct{}) {
+  defer close(done)
+  scanner := bufio.NewScanner(c.conn)
+  for scanner.Scan() {
+    m, err := parseMessage(scanner.Text())
+    if err != nil {
+      continue
+    }
+    c.handle(m)
+  }
+}
+
+// Interpret a line typed by the user. Lines starting with "/" are commands,
+// anything else is sent to the current channel.
+func (c *client) input(line string) bool {
+  if !strings.HasPrefix(line, "/") {
+    c.mu.Lock()
+    target := c.current
+    c.mu.Unlock()
+    if target == "" {
+      fmt.Println("Not in a channel; use /join #channel")
+      return true
+    }
+    c.send("PRIVMSG", target, line)
+    c.show(target, fmt.Sprintf("<%s> %s", c.nickname(), line))
+    return true
+  }
+
+  fields := strings.SplitN(line[1:], " ", 3)
+  switch strings.ToLower(fields[0]) {
+  case "join":
+    if len(fields) > 1 {
+      c.send("JOIN", fields[1])
+    }
+  case "part":
+    c.mu.Lock()
+    target := c.current
+    c.mu.Unlock()
+    if len(fields) > 1 {
+      target = fields[1]
+    }
+    c.send("PART", target)
+  case "msg":
+    if len(fields) > 2 {
+      c.send("PRIVMSG", fields[1], fields[2])
+      c.show(fields[1], fmt.Sprintf("<%s> %s", c.nickname(), fields[2]))
+    }
+  case "nick":
+    if len(fields) > 1 {
+      c.send("NICK", fields[1])
+    }
+  case "switch":
+    if len(fields) > 1 {
+      c.mu.Lock()
+      c.current = fields[1]
+      lines := append([]string(nil), c.buffers[fields[1]]...)
+      c.mu.Unlock()
+      for _, l := range lines {
+        fmt.Println(l)
+      }
+    }
+  case "quit":
+    msg := "Leaving"
+    if len(fields) > 1 {
+      msg = strings.Join(fields[1:], " ")
+    }
+    c.send("QUIT", msg)
+    return false
+  default:
+    fmt.Println("Commands: /join #chan, /part [#chan], /msg nick text, /nick name, /switch buffer, /quit [msg]")
+  }
+  return true
+}
+
+// Connect, register with NICK/USER, then read user input until /quit or the
+// server closes the connection.
+func main() {
+  server := flag.String("server", "irc.libera.chat:6667", "IRC server address")
+  nick := flag.String("nick", "ccirc", "nickname")
+  name := flag.String("name", "Coding Challenges Client", "real name")
+  flag.Parse()
+
+  conn, err := net.Dial("tcp", *server)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "irc:", err)
+    os.Exit(1)
+  }
+  defer conn.Close()
+
+  c := &client{conn: conn, nick: *nick, buffers: map[string][]string{}}
+  c.send("NICK", c.nick)
+  c.send("USER", c.nick, "0", "*", *name)
+
+  done := make(chan struct{})
+  go c.readLoop(done)
+
+  lines := make(chan string)
+  go func() {
+    scanner := bufio.NewScanner(os.Stdin)
+    for scanner.Scan() {
+      lines <- scanner.Text()
+    }
+    close(lines)
+  }()
+  c.interact(lines, done)
+}
+
+// Pass typed lines to the client until /quit, the end of input or the
+// server closing the connection. Leaving either way sends QUIT, then gives
+// the server a while to close the connection.
+func (c *client) interact(lines <-chan string, done <-chan struct{}) {
+  for {
+    select {
+    case <-done:
+      fmt.Println("Disconnected")
+      return
+    case line, ok := <-lines:
+      if !ok {
+        c.send("QUIT", "Leaving")
+      } else if c.input(line) {
+        continue
+      }
+      select {
+      case <-done:
+      case <-time.After(quitWait):
+      }
+      return
+    }
+  }
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+  "bufio"
+  "net"
+  "strings"
+  "testing"
+  "time"
+)
+
+// A client wired to a fake server through a pipe, with its reading
+// goroutine started.
+func connect(t *testing.T) (*client, *bufio.Reader, net.Conn, chan struct{}) {
+  t.Helper()
+  local, server := net.Pipe()
+  t.Cleanup(func() { local.Close(); server.Close() })
+  c := &client{conn: local, nick: "cc", buffers: map[string][]string{}}
+  done := make(chan struct{})
+  go c.readLoop(done)
+  return c, bufio.NewReader(server), server, done
+}
+
+func expectLine(t *testing.T, r *bufio.Reader, want string) {
+  t.Helper()
+  line, err := r.ReadString('\n')
+  if err != nil || strings.TrimRight(line, "\r\n") != want {
+    t.Fatalf("server got %q, %v; want %q", line, err, want)
+  }
+}
+
+// The nick changes on the reading goroutine while typed lines use it;
+// run with -race.
+func TestNickChanges(t *testing.T) {
+  c, r, server, _ := connect(t)
+  go func() {
+    server.Write([]byte(":irc 433 * cc :Nickname is already in use\r\n"))
+    server.Write([]byte(":cc_!u@h JOIN #go\r\n"))
+  }()
+  expectLine(t, r, "NICK cc_")
+
+  lines := make(chan string)
+  go c.interact(lines, make(chan struct{}))
+  go func() {
+    for range 20 {
+      lines <- "/msg friend hi"
+    }
+  }()
+  for range 20 {
+    expectLine(t, r, "PRIVMSG friend hi")
+  }
+  if got := c.nickname(); got != "cc_" {
+    t.Errorf("nick is %q, want cc_", got)
+  }
+}
+
+// The end of input leaves like /quit, and returns once the server closes
+// the connection.
+func TestEndOfInput(t *testing.T) {
+  c, r, server, done := connect(t)
+  lines := make(chan string)
+  left := make(chan struct{})
+  go func() {
+    c.interact(lines, done)
+    close(left)
+  }()
+  close(lines)
+  expectLine(t, r, "QUIT Leaving")
+  server.Close()
+  select {
+  case <-left:
+  case <-time.After(quitWait / 2):
+    t.Fatal("still waiting after the server closed the connection")
+  }
+}
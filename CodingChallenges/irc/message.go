@@ -0,0 +1,77 @@
+package main
+
+import (
+  "errors"
+  "strings"
+)
+
+// An IRC message as described in RFC 2812 section 2.3.1:
+//   [":" prefix SPACE] command [params] [SPACE ":" trailing] CRLF
+// The trailing parameter, when present, is stored as the last param.
+type message struct {
+  prefix  string
+  command string
+  params  []string
+}
+
+// The nickname part of a "nick!user@host" prefix.
+func (m *message) nick() string {
+  if i := strings.IndexByte(m.prefix, '!'); i >= 0 {
+    return m.prefix[:i]
+  }
+  return m.prefix
+}
+
+// Parse a single line received from the server. The line may or may not
+// still carry its CRLF terminator.
+func parseMessage(line string) (*message, error) {
+  line = strings.TrimRight(line, "\r\n")
+  if line == "" {
+    return nil, errors.New("empty message")
+  }
+  m := &message{}
+  if line[0] == ':' {
+    end := strings.IndexByte(line, ' ')
+    if end < 0 {
+      return nil, errors.New("message has a prefix but no command")
+    }
+    m.prefix = line[1:end]
+    line = strings.TrimLeft(line[end:], " ")
+  }
+
+  var trailing *string
+  if i := strings.Index(line, " :"); i >= 0 {
+    t := line[i+2:]
+    trailing = &t
+    line = line[:i]
+  }
+  fields := strings.Fields(line)
+  if len(fields) == 0 {
+    return nil, errors.New("missing command")
+  }
+  m.command = strings.ToUpper(fields[0])
+  m.params = fields[1:]
+  if trailing != nil {
+    m.params = append(m.params, *trailing)
+  }
+  return m, nil
+}
+
+// Format a message for sending. The last parameter is always sent as a
+// trailing parameter when it contains spaces, starts with ":" or is empty.
+func (m *message) String() string {
+  var b strings.Builder
+  if m.prefix != "" {
+    b.WriteString(":" + m.prefix + " ")
+  }
+  b.WriteString(m.command)
+  for i, p := range m.params {
+    b.WriteByte(' ')
+    if i == len(m.params)-1 && (p == "" || strings.ContainsRune(p, ' ') || p[0] == ':') {
+      b.WriteByte(':')
+    }
+    b.WriteString(p)
+  }
+  b.WriteString("\r\n")
+  return b.String()
+}
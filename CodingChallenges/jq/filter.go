@@ -0,0 +1,1326 @@
+package main
+
+import (
+  "errors"
+  "fmt"
+  "math"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+// A compiled filter maps one input to zero or more outputs.
+type filter func(input any) ([]any, error)
+
+type tokenKind int
+
+const (
+  tokEOF tokenKind = iota
+  tokNum
+  tokStr
+  tokIdent
+  tokField // ".name"
+  tokOp
+)
+
+type token struct {
+  kind tokenKind
+  text string
+  num  float64
+}
+
+func isIdentStart(c byte) bool {
+  return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+  return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// Split a filter expression into tokens. ".name" is a single token so that
+// ". name" (identity followed by a call) stays distinct from field access.
+func lex(src string) ([]token, error) {
+  var toks []token
+  for i := 0; i < len(src); {
+    c := src[i]
+    switch {
+    case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+      i++
+    case c == '#':
+      for i < len(src) && src[i] != '\n' {
+        i++
+      }
+    case c == '.' && i+1 < len(src) && isIdentStart(src[i+1]):
+      j := i + 1
+      for j < len(src) && isIdentChar(src[j]) {
+        j++
+      }
+      toks = append(toks, token{kind: tokField, text: src[i+1 : j]})
+      i = j
+    case c >= '0' && c <= '9' || (c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9'):
+      j := i
+      for j < len(src) && strings.IndexByte("0123456789.eE", src[j]) >= 0 {
+        if (src[j] == 'e' || src[j] == 'E') && j+1 < len(src) && (src[j+1] == '+' || src[j+1] == '-') {
+          j++
+        }
+        j++
+      }
+      // Out of range is infinity, printed as the largest double, as jq
+      // does for input.
+      n, err := strconv.ParseFloat(src[i:j], 64)
+      if err != nil && !errors.Is(err, strconv.ErrRange) {
+        return nil, fmt.Errorf("invalid number %q", src[i:j])
+      }
+      toks = append(toks, token{kind: tokNum, num: n})
+      i = j
+    case c == '"':
+      p := &parser{data: []byte(src), pos: i}
+      s, err := p.string()
+      if err != nil {
+        return nil, errors.New("invalid string literal in filter")
+      }
+      toks = append(toks, token{kind: tokStr, text: s.(string)})
+      i = p.pos
+    case isIdentStart(c):
+      j := i
+      for j < len(src) && isIdentChar(src[j]) {
+        j++
+      }
+      toks = append(toks, token{kind: tokIdent, text: src[i:j]})
+      i = j
+    default:
+      op := ""
+      for _, candidate := range []string{"..", "//", "==", "!=", "<=", ">=", "|", ",", ".", "[", "]", "(", ")", "{", "}", ":", ";", "?", "+", "-", "*", "/", "%", "<", ">"} {
+        if strings.HasPrefix(src[i:], candidate) {
+          op = candidate
+          break
+        }
+      }
+      if op == "" {
+        return nil, fmt.Errorf("unexpected character %q", c)
+      }
+      toks = append(toks, token{kind: tokOp, text: op})
+      i += len(op)
+    }
+  }
+  return append(toks, token{kind: tokEOF}), nil
+}
+
+// A recursive descent parser over the tokens. Precedence from lowest to
+// highest: "|", ",", "//", "or", "and", comparisons, "+ -", "* / %", then
+// postfix indexing.
+type filterParser struct {
+  toks []token
+  pos  int
+}
+
+func (p *filterParser) peek() token { return p.toks[p.pos] }
+
+func (p *filterParser) next() token {
+  t := p.toks[p.pos]
+  if t.kind != tokEOF {
+    p.pos++
+  }
+  return t
+}
+
+func (p *filterParser) isOp(op string) bool {
+  t := p.peek()
+  return t.kind == tokOp && t.text == op
+}
+
+func (p *filterParser) isKeyword(word string) bool {
+  t := p.peek()
+  return t.kind == tokIdent && t.text == word
+}
+
+func (p *filterParser) expect(op string) error {
+  if !p.isOp(op) {
+    return fmt.Errorf("syntax error: expected %q", op)
+  }
+  p.pos++
+  return nil
+}
+
+// Compile a filter expression.
+func compile(src string) (filter, error) {
+  toks, err := lex(src)
+  if err != nil {
+    return nil, err
+  }
+  p := &filterParser{toks: toks}
+  if p.peek().kind == tokEOF {
+    return identity, nil
+  }
+  f, err := p.pipe()
+  if err != nil {
+    return nil, err
+  }
+  if p.peek().kind != tokEOF {
+    return nil, fmt.Errorf("syntax error: unexpected %q", p.peek().text)
+  }
+  return f, nil
+}
+
+func identity(v any) ([]any, error) { return []any{v}, nil }
+
+func (p *filterParser) pipe() (filter, error) {
+  left, err := p.comma()
+  if err != nil || !p.isOp("|") {
+    return left, err
+  }
+  p.next()
+  right, err := p.pipe()
+  if err != nil {
+    return nil, err
+  }
+  return func(v any) ([]any, error) {
+    vs, err := left(v)
+    if err != nil {
+      return nil, err
+    }
+    var out []any
+    for _, x := range vs {
+      r, err := right(x)
+      out = append(out, r...)
+      if err != nil {
+        return out, err
+      }
+    }
+    return out, nil
+  }, nil
+}
+
+func (p *filterParser) comma() (filter, error) {
+  left, err := p.alternative()
+  for err == nil && p.isOp(",") {
+    p.next()
+    var right filter
+    if right, err = p.alternative(); err == nil {
+      l := left
+      left = func(v any) ([]any, error) {
+        a, err := l(v)
+        if err != nil {
+          return a, err
+        }
+        b, err := right(v)
+        return append(a, b...), err
+      }
+    }
+  }
+  return left, err
+}
+
+// "a // b" yields the truthy outputs of a, or the outputs of b if there are
+// none. Errors from a count as no output.
+func (p *filterParser) alternative() (filter, error) {
+  left, err := p.or()
+  if err != nil || !p.isOp("//") {
+    return left, err
+  }
+  p.next()
+  right, err := p.alternative()
+  if err != nil {
+    return nil, err
+  }
+  return func(v any) ([]any, error) {
+    a, _ := left(v)
+    var out []any
+    for _, x := range a {
+      if truthy(x) {
+        out = append(out, x)
+      }
+    }
+    if len(out) > 0 {
+      return out, nil
+    }
+    return right(v)
+  }, nil
+}
+
+func truthy(v any) bool {
+  return v != nil && v != false
+}
+
+// "and" and "or" short-circuit per left output, as in jq.
+func (p *filterParser) or() (filter, error) {
+  left, err := p.and()
+  for err == nil && p.isKeyword("or") {
+    p.next()
+    var right filter
+    if right, err = p.and(); err == nil {
+      left = logical(left, right, true)
+    }
+  }
+  return left, err
+}
+
+func (p *filterParser) and() (filter, error) {
+  left, err := p.comparison()
+  for err == nil && p.isKeyword("and") {
+    p.next()
+    var right filter
+    if right, err = p.comparison(); err == nil {
+      left = logical(left, right, false)
+    }
+  }
+  return left, err
+}
+
+func logical(left, right filter, isOr bool) filter {
+  return func(v any) ([]any, error) {
+    a, err := left(v)
+    if err != nil {
+      return nil, err
+    }
+    var out []any
+    for _, x := range a {
+      if truthy(x) == isOr {
+        out = append(out, isOr)
+        continue
+      }
+      b, err := right(v)
+      if err != nil {
+        return out, err
+      }
+      for _, y := range b {
+        out = append(out, truthy(y))
+      }
+    }
+    return out, nil
+  }
+}
+
+func (p *filterParser) comparison() (filter, error) {
+  left, err := p.additive()
+  if err != nil {
+    return nil, err
+  }
+  for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+    if p.isOp(op) {
+      p.next()
+      right, err := p.additive()
+      if err != nil {
+        return nil, err
+      }
+      return binary(left, right, func(a, b any) (any, error) {
+        c := compare(a, b)
+        switch op {
+        case "==":
+          return c == 0, nil
+        case "!=":
+          return c != 0, nil
+        case "<=":
+          return c <= 0, nil
+        case ">=":
+          return c >= 0, nil
+        case "<":
+          return c < 0, nil
+        }
+        return c > 0, nil
+      }), nil
+    }
+  }
+  return left, nil
+}
+
+func (p *filterParser) additive() (filter, error) {
+  left, err := p.multiplicative()
+  for err == nil && (p.isOp("+") || p.isOp("-")) {
+    op := p.next().text
+    var right filter
+    if right, err = p.multiplicative(); err == nil {
+      left = binary(left, right, func(a, b any) (any, error) { return arith(op, a, b) })
+    }
+  }
+  return left, err
+}
+
+func (p *filterParser) multiplicative() (filter, error) {
+  left, err := p.unary()
+  for err == nil && (p.isOp("*") || p.isOp("/") || p.isOp("%")) {
+    op := p.next().text
+    var right filter
+    if right, err = p.unary(); err == nil {
+      left = binary(left, right, func(a, b any) (any, error) { return arith(op, a, b) })
+    }
+  }
+  return left, err
+}
+
+func (p *filterParser) unary() (filter, error) {
+  if !p.isOp("-") {
+    return p.postfix()
+  }
+  p.next()
+  operand, err := p.postfix()
+  if err != nil {
+    return nil, err
+  }
+  return func(v any) ([]any, error) {
+    vs, err := operand(v)
+    if err != nil {
+      return nil, err
+    }
+    out := make([]any, len(vs))
+    for i, x := range vs {
+      n, ok := x.(float64)
+      if !ok {
+        return nil, fmt.Errorf("%s (%s) cannot be negated", typeName(x), toJSON(x, 0))
+      }
+      out[i] = -n
+    }
+    return out, nil
+  }, nil
+}
+
+// Apply a binary operator to every combination of outputs. Like jq, the
+// right-hand side is the outer loop.
+func binary(left, right filter, op func(a, b any) (any, error)) filter {
+  return func(v any) ([]any, error) {
+    bs, err := right(v)
+    if err != nil {
+      return nil, err
+    }
+    as, err := left(v)
+    if err != nil {
+      return nil, err
+    }
+    var out []any
+    for _, b := range bs {
+      for _, a := range as {
+        r, err := op(a, b)
+        if err != nil {
+          return out, err
+        }
+        out = append(out, r)
+      }
+    }
+    return out, nil
+  }
+}
+
+func arith(op string, a, b any) (any, error) {
+  fail := func() (any, error) {
+    return nil, fmt.Errorf("%s (%s) and %s (%s) cannot be %s", typeName(a), toJSON(a, 0),
+      typeName(b), toJSON(b, 0), map[string]string{"+": "added", "-": "subtracted",
+        "*": "multiplied", "/": "divided", "%": "divided"}[op])
+  }
+  x, xnum := a.(float64)
+  y, ynum := b.(float64)
+  if xnum && ynum {
+    switch op {
+    case "+":
+      return x + y, nil
+    case "-":
+      return x - y, nil
+    case "*":
+      return x * y, nil
+    case "/":
+      if y == 0 {
+        return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", toJSON(a, 0), toJSON(b, 0))
+      }
+      return x / y, nil
+    case "%":
+      if int64(y) == 0 {
+        return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", toJSON(a, 0), toJSON(b, 0))
+      }
+      return float64(int64(x) % int64(y)), nil
+    }
+  }
+  switch op {
+  case "+":
+    if a == nil {
+      return b, nil
+    }
+    if b == nil {
+      return a, nil
+    }
+    switch x := a.(type) {
+    case string:
+      if y, ok := b.(string); ok {
+        return x + y, nil
+      }
+    case []any:
+      if y, ok := b.([]any); ok {
+        return append(append([]any{}, x...), y...), nil
+      }
+    case *object:
+      if y, ok := b.(*object); ok {
+        merged := x.copy()
+        for _, k := range y.keys {
+          merged.set(k, y.vals[k])
+        }
+        return merged, nil
+      }
+    }
+  case "-":
+    if x, ok := a.([]any); ok {
+      if y, ok := b.([]any); ok {
+        out := []any{}
+        for _, e := range x {
+          keep := true
+          for _, r := range y {
+            if compare(e, r) == 0 {
+              keep = false
+              break
+            }
+          }
+          if keep {
+            out = append(out, e)
+          }
+        }
+        return out, nil
+      }
+    }
+  case "*":
+    if x, ok := a.(*object); ok {
+      if y, ok := b.(*object); ok {
+        return deepMerge(x, y), nil
+      }
+    }
+  case "/":
+    if x, ok := a.(string); ok {
+      if y, ok := b.(string); ok {
+        return splitString(x, y), nil
+      }
+    }
+  }
+  return fail()
+}
+
+func deepMerge(a, b *object) *object {
+  out := a.copy()
+  for _, k := range b.keys {
+    bv := b.vals[k]
+    if ao, ok := out.vals[k].(*object); ok {
+      if bo, ok := bv.(*object); ok {
+        out.set(k, deepMerge(ao, bo))
+        continue
+      }
+    }
+    out.set(k, bv)
+  }
+  return out
+}
+
+func splitString(s, sep string) []any {
+  out := []any{}
+  if s == "" {
+    return out
+  }
+  for _, part := range strings.Split(s, sep) {
+    out = append(out, part)
+  }
+  return out
+}
+
+// Parse a primary term followed by any number of suffixes: ".name",
+// ."name", "[...]" and "?".
+func (p *filterParser) postfix() (filter, error) {
+  f, err := p.primary()
+  for err == nil {
+    switch t := p.peek(); {
+    case t.kind == tokField:
+      p.next()
+      f = index(f, constant(t.text))
+    case t.kind == tokOp && t.text == "." && p.toks[p.pos+1].kind == tokStr:
+      p.next()
+      f = index(f, constant(p.next().text))
+    case t.kind == tokOp && t.text == "." && p.toks[p.pos+1].kind == tokOp && p.toks[p.pos+1].text == "[":
+      p.next()
+    case t.kind == tokOp && t.text == "[":
+      f, err = p.bracket(f)
+    case t.kind == tokOp && t.text == "?":
+      p.next()
+      f = try(f)
+    default:
+      return f, nil
+    }
+  }
+  return nil, err
+}
+
+// Parse "[]", "[e]", "[e:e]", "[:e]" or "[e:]" applied to term.
+func (p *filterParser) bracket(term filter) (filter, error) {
+  p.next()
+  if p.isOp("]") {
+    p.next()
+    return iterate(term), nil
+  }
+  var from, to filter
+  var err error
+  if !p.isOp(":") {
+    if from, err = p.pipe(); err != nil {
+      return nil, err
+    }
+  }
+  if p.isOp(":") {
+    p.next()
+    if !p.isOp("]") {
+      if to, err = p.pipe(); err != nil {
+        return nil, err
+      }
+    }
+    if err := p.expect("]"); err != nil {
+      return nil, err
+    }
+    if from == nil {
+      from = constant(nil)
+    }
+    if to == nil {
+      to = constant(nil)
+    }
+    return slice(term, from, to), nil
+  }
+  if err := p.expect("]"); err != nil {
+    return nil, err
+  }
+  return index(term, from), nil
+}
+
+func constant(v any) filter {
+  return func(any) ([]any, error) { return []any{v}, nil }
+}
+
+// "f?" turns errors from f into empty output, keeping what came before.
+func try(f filter) filter {
+  return func(v any) ([]any, error) {
+    out, _ := f(v)
+    return out, nil
+  }
+}
+
+// Index each output of term by each output of key. The key is evaluated
+// against the original input, so ".[.i]" works as in jq.
+func index(term, key filter) filter {
+  return func(v any) ([]any, error) {
+    keys, err := key(v)
+    if err != nil {
+      return nil, err
+    }
+    targets, err := term(v)
+    if err != nil {
+      return nil, err
+    }
+    var out []any
+    for _, t := range targets {
+      for _, k := range keys {
+        r, err := indexValue(t, k)
+        if err != nil {
+          return out, err
+        }
+        out = append(out, r)
+      }
+    }
+    return out, nil
+  }
+}
+
+func indexValue(t, k any) (any, error) {
+  switch t := t.(type) {
+  case nil:
+    switch k.(type) {
+    case string, float64, nil:
+      return nil, nil
+    }
+  case *object:
+    if k, ok := k.(string); ok {
+      v, _ := t.get(k)
+      return v, nil
+    }
+  case []any:
+    if n, ok := k.(float64); ok {
+      i := int(math.Floor(n))
+      if i < 0 {
+        i += len(t)
+      }
+      if i < 0 || i >= len(t) {
+        return nil, nil
+      }
+      return t[i], nil
+    }
+  }
+  if s, ok := k.(string); ok {
+    return nil, fmt.Errorf("Cannot index %s with %q", typeName(t), s)
+  }
+  return nil, fmt.Errorf("Cannot index %s with %s", typeName(t), typeName(k))
+}
+
+// Resolve slice bounds the way jq does: nil means the end, negative counts
+// from the end, and everything is clamped to the length.
+func sliceBounds(n int, from, to any) (int, int, error) {
+  bound := func(v any, def int) (int, error) {
+    if v == nil {
+      return def, nil
+    }
+    f, ok := v.(float64)
+    if !ok {
+      return 0, errors.New("Start and end indices of an array slice must be numbers")
+    }
+    i := int(math.Floor(f))
+    if i < 0 {
+      i += n
+    }
+    return max(0, min(i, n)), nil
+  }
+  start, err := bound(from, 0)
+  if err != nil {
+    return 0, 0, err
+  }
+  end, err := bound(to, n)
+  if err != nil {
+    return 0, 0, err
+  }
+  return start, max(start, end), nil
+}
+
+func slice(term, from, to filter) filter {
+  return func(v any) ([]any, error) {
+    tos, err := to(v)
+    if err != nil {
+      return nil, err
+    }
+    froms, err := from(v)
+    if err != nil {
+      return nil, err
+    }
+    targets, err := term(v)
+    if err != nil {
+      return nil, err
+    }
+    var out []any
+    for _, t := range targets {
+      for _, e := range tos {
+        for _, s := range froms {
+          switch t := t.(type) {
+          case nil:
+            out = append(out, nil)
+          case []any:
+            i, j, err := sliceBounds(len(t), s, e)
+            if err != nil {
+              return out, err
+            }
+            out = append(out, append([]any{}, t[i:j]...))
+          case string:
+            r := []rune(t)
+            i, j, err := sliceBounds(len(r), s, e)
+            if err != nil {
+              return out, err
+            }
+            out = append(out, string(r[i:j]))
+          default:
+            return out, fmt.Errorf("Cannot index %s with object", typeName(t))
+          }
+        }
+      }
+    }
+    return out, nil
+  }
+}
+
+func iterate(term filter) filter {
+  return func(v any) ([]any, error) {
+    targets, err := term(v)
+    if err != nil {
+      return nil, err
+    }
+    var out []any
+    for _, t := range targets {
+      switch t := t.(type) {
+      case []any:
+        out = append(out, t...)
+      case *object:
+        for _, k := range t.keys {
+          out = append(out, t.vals[k])
+        }
+      default:
+        return out, fmt.Errorf("Cannot iterate over %s", describe(t))
+      }
+    }
+    return out, nil
+  }
+}
+
+func describe(v any) string {
+  if v == nil {
+    return "null"
+  }
+  return fmt.Sprintf("%s (%s)", typeName(v), toJSON(v, 0))
+}
+
+func (p *filterParser) primary() (filter, error) {
+  t := p.next()
+  switch t.kind {
+  case tokNum:
+    return constant(t.num), nil
+  case tokStr:
+    return constant(t.text), nil
+  case tokField:
+    return index(identity, constant(t.text)), nil
+  case tokIdent:
+    switch t.text {
+    case "true":
+      return constant(true), nil
+    case "false":
+      return constant(false), nil
+    case "null":
+      return constant(nil), nil
+    }
+    var args []filter
+    if p.isOp("(") {
+      p.next()
+      for {
+        arg, err := p.pipe()
+        if err != nil {
+          return nil, err
+        }
+        args = append(args, arg)
+        if !p.isOp(";") {
+          break
+        }
+        p.next()
+      }
+      if err := p.expect(")"); err != nil {
+        return nil, err
+      }
+    }
+    return builtin(t.text, args)
+  case tokOp:
+    switch t.text {
+    case ".":
+      if p.peek().kind == tokStr {
+        return index(identity, constant(p.next().text)), nil
+      }
+      return identity, nil
+    case "..":
+      return recurse, nil
+    case "(":
+      f, err := p.pipe()
+      if err != nil {
+        return nil, err
+      }
+      return f, p.expect(")")
+    case "[":
+      if p.isOp("]") {
+        p.next()
+        return constant([]any{}), nil
+      }
+      f, err := p.pipe()
+      if err != nil {
+        return nil, err
+      }
+      if err := p.expect("]"); err != nil {
+        return nil, err
+      }
+      return func(v any) ([]any, error) {
+        out, err := f(v)
+        if out == nil {
+          out = []any{}
+        }
+        return []any{out}, err
+      }, nil
+    case "{":
+      return p.objectConstruction()
+    }
+  }
+  if t.kind == tokEOF {
+    return nil, errors.New("syntax error: unexpected end of filter")
+  }
+  return nil, fmt.Errorf("syntax error: unexpected %q", t.text)
+}
+
+// "..": the input followed by all of its descendants, depth first.
+func recurse(v any) ([]any, error) {
+  out := []any{v}
+  switch v := v.(type) {
+  case []any:
+    for _, e := range v {
+      r, _ := recurse(e)
+      out = append(out, r...)
+    }
+  case *object:
+    for _, k := range v.keys {
+      r, _ := recurse(v.vals[k])
+      out = append(out, r...)
+    }
+  }
+  return out, nil
+}
+
+// Parse "{key: value, ...}". Keys may be identifiers, strings or
+// parenthesised expressions; "{a}" is short for "{a: .a}". Multiple outputs
+// from keys or values produce every combination.
+func (p *filterParser) objectConstruction() (filter, error) {
+  type entry struct{ key, value filter }
+  var entries []entry
+  for !p.isOp("}") {
+    var key filter
+    var name string
+    switch t := p.next(); {
+    case t.kind == tokIdent || t.kind == tokStr:
+      name = t.text
+      key = constant(name)
+    case t.kind == tokOp && t.text == "(":
+      var err error
+      if key, err = p.pipe(); err != nil {
+        return nil, err
+      }
+      if err := p.expect(")"); err != nil {
+        return nil, err
+      }
+    default:
+      return nil, errors.New("syntax error: invalid object key")
+    }
+    value := index(identity, constant(name))
+    if p.isOp(":") {
+      p.next()
+      var err error
+      if value, err = p.alternative(); err != nil {
+        return nil, err
+      }
+    } else if name == "" {
+      return nil, errors.New("syntax error: expected ':' after object key")
+    }
+    entries = append(entries, entry{key, value})
+    if !p.isOp(",") {
+      break
+    }
+    p.next()
+  }
+  if err := p.expect("}"); err != nil {
+    return nil, err
+  }
+  return func(v any) ([]any, error) {
+    results := []*object{newObject()}
+    for _, e := range entries {
+      keys, err := e.key(v)
+      if err != nil {
+        return nil, err
+      }
+      values, err := e.value(v)
+      if err != nil {
+        return nil, err
+      }
+      var next []*object
+      for _, o := range results {
+        for _, k := range keys {
+          ks, ok := k.(string)
+          if !ok {
+            return nil, fmt.Errorf("Object keys must be strings")
+          }
+          for _, val := range values {
+            c := o.copy()
+            c.set(ks, val)
+            next = append(next, c)
+          }
+        }
+      }
+      results = next
+    }
+    out := make([]any, len(results))
+    for i, o := range results {
+      out[i] = o
+    }
+    return out, nil
+  }, nil
+}
+
+// Look up a builtin by name and arity.
+func builtin(name string, args []filter) (filter, error) {
+  simple := map[string]func(v any) (any, error){
+    "length":         length,
+    "keys":           func(v any) (any, error) { return keys(v, true) },
+    "keys_unsorted":  func(v any) (any, error) { return keys(v, false) },
+    "type":           func(v any) (any, error) { return typeName(v), nil },
+    "not":            func(v any) (any, error) { return !truthy(v), nil },
+    "add":            add,
+    "sort":           func(v any) (any, error) { return sortBy(v, nil) },
+    "reverse":        reverse,
+    "unique":         unique,
+    "min":            func(v any) (any, error) { return extreme(v, -1) },
+    "max":            func(v any) (any, error) { return extreme(v, 1) },
+    "tostring":       tostring,
+    "tonumber":       tonumber,
+    "to_entries":     toEntries,
+    "from_entries":   fromEntries,
+    "ascii_downcase": func(v any) (any, error) { return mapString(v, strings.ToLower) },
+    "ascii_upcase":   func(v any) (any, error) { return mapString(v, strings.ToUpper) },
+    "floor":          func(v any) (any, error) { return mapNumber(v, math.Floor) },
+    "sqrt":           func(v any) (any, error) { return mapNumber(v, math.Sqrt) },
+  }
+  if fn, ok := simple[name]; ok && len(args) == 0 {
+    return func(v any) ([]any, error) {
+      r, err := fn(v)
+      if err != nil {
+        return nil, err
+      }
+      return []any{r}, nil
+    }, nil
+  }
+
+  switch {
+  case name == "empty" && len(args) == 0:
+    return func(any) ([]any, error) { return nil, nil }, nil
+  case name == "error" && len(args) == 0:
+    return func(v any) ([]any, error) { return nil, errors.New(errorText(v)) }, nil
+  case name == "error" && len(args) == 1:
+    return func(v any) ([]any, error) {
+      msgs, err := args[0](v)
+      if err != nil || len(msgs) == 0 {
+        return nil, err
+      }
+      return nil, errors.New(errorText(msgs[0]))
+    }, nil
+  case (name == "first" || name == "last") && len(args) == 0:
+    i := 0
+    if name == "last" {
+      i = -1
+    }
+    return index(identity, constant(float64(i))), nil
+  case name == "select" && len(args) == 1:
+    return func(v any) ([]any, error) {
+      conds, err := args[0](v)
+      var out []any
+      for _, c := range conds {
+        if truthy(c) {
+          out = append(out, v)
+        }
+      }
+      return out, err
+    }, nil
+  case name == "map" && len(args) == 1:
+    return func(v any) ([]any, error) {
+      items, err := iterate(identity)(v)
+      if err != nil {
+        return nil, err
+      }
+      out := []any{}
+      for _, item := range items {
+        r, err := args[0](item)
+        if err != nil {
+          return nil, err
+        }
+        out = append(out, r...)
+      }
+      return []any{out}, nil
+    }, nil
+  case name == "has" && len(args) == 1:
+    return binary(identity, args[0], has), nil
+  case name == "join" && len(args) == 1:
+    return binary(identity, args[0], join), nil
+  case name == "split" && len(args) == 1:
+    return binary(identity, args[0], func(s, sep any) (any, error) {
+      a, ok1 := s.(string)
+      b, ok2 := sep.(string)
+      if !ok1 || !ok2 {
+        return nil, errors.New("split input and separator must be strings")
+      }
+      return splitString(a, b), nil
+    }), nil
+  case name == "sort_by" && len(args) == 1:
+    return func(v any) ([]any, error) {
+      r, err := sortBy(v, args[0])
+      if err != nil {
+        return nil, err
+      }
+      return []any{r}, nil
+    }, nil
+  case name == "range" && (len(args) == 1 || len(args) == 2):
+    from, upto := constant(0.0), args[0]
+    if len(args) == 2 {
+      from, upto = args[0], args[1]
+    }
+    return func(v any) ([]any, error) {
+      starts, err := from(v)
+      if err != nil {
+        return nil, err
+      }
+      ends, err := upto(v)
+      if err != nil {
+        return nil, err
+      }
+      var out []any
+      for _, s := range starts {
+        for _, e := range ends {
+          a, ok1 := s.(float64)
+          b, ok2 := e.(float64)
+          if !ok1 || !ok2 {
+            return out, errors.New("Range bounds must be numeric")
+          }
+          for x := a; x < b; x++ {
+            out = append(out, x)
+          }
+        }
+      }
+      return out, nil
+    }, nil
+  }
+  return nil, fmt.Errorf("%s/%d is not defined", name, len(args))
+}
+
+func errorText(v any) string {
+  if s, ok := v.(string); ok {
+    return s
+  }
+  return toJSON(v, 0) + " (not a string)"
+}
+
+func length(v any) (any, error) {
+  switch v := v.(type) {
+  case nil:
+    return 0.0, nil
+  case bool:
+    return nil, fmt.Errorf("boolean (%v) has no length", v)
+  case float64:
+    return math.Abs(v), nil
+  case string:
+    return float64(len([]rune(v))), nil
+  case []any:
+    return float64(len(v)), nil
+  case *object:
+    return float64(len(v.keys)), nil
+  }
+  return nil, nil
+}
+
+func keys(v any, sorted bool) (any, error) {
+  switch v := v.(type) {
+  case *object:
+    ks := v.keys
+    if sorted {
+      ks = sortedKeys(v)
+    }
+    out := make([]any, len(ks))
+    for i, k := range ks {
+      out[i] = k
+    }
+    return out, nil
+  case []any:
+    out := make([]any, len(v))
+    for i := range v {
+      out[i] = float64(i)
+    }
+    return out, nil
+  }
+  return nil, fmt.Errorf("%s has no keys", describe(v))
+}
+
+func has(v, k any) (any, error) {
+  switch v := v.(type) {
+  case *object:
+    if k, ok := k.(string); ok {
+      _, found := v.get(k)
+      return found, nil
+    }
+  case []any:
+    if n, ok := k.(float64); ok {
+      return n >= 0 && int(n) < len(v), nil
+    }
+  }
+  return nil, fmt.Errorf("Cannot check whether %s has a %s key", typeName(v), typeName(k))
+}
+
+func add(v any) (any, error) {
+  items, err := iterate(identity)(v)
+  if err != nil {
+    return nil, err
+  }
+  var sum any
+  for _, item := range items {
+    if sum, err = arith("+", sum, item); err != nil {
+      return nil, err
+    }
+  }
+  return sum, nil
+}
+
+// Sort an array, optionally by the output of a key filter. The sort is
+// stable, so equal keys keep their input order.
+func sortBy(v any, key filter) (any, error) {
+  arr, ok := v.([]any)
+  if !ok {
+    return nil, fmt.Errorf("%s cannot be sorted, as it is not an array", describe(v))
+  }
+  type item struct {
+    key, value any
+  }
+  items := make([]item, len(arr))
+  for i, e := range arr {
+    items[i] = item{e, e}
+    if key != nil {
+      ks, err := key(e)
+      if err != nil {
+        return nil, err
+      }
+      if ks == nil {
+        ks = []any{}
+      }
+      items[i].key = ks
+    }
+  }
+  sort.SliceStable(items, func(i, j int) bool { return compare(items[i].key, items[j].key) < 0 })
+  out := make([]any, len(items))
+  for i, it := range items {
+    out[i] = it.value
+  }
+  return out, nil
+}
+
+func reverse(v any) (any, error) {
+  switch v := v.(type) {
+  case nil:
+    return []any{}, nil
+  case string:
+    r := []rune(v)
+    for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+      r[i], r[j] = r[j], r[i]
+    }
+    return string(r), nil
+  case []any:
+    out := make([]any, len(v))
+    for i, e := range v {
+      out[len(v)-1-i] = e
+    }
+    return out, nil
+  }
+  return nil, fmt.Errorf("Cannot reverse %s", describe(v))
+}
+
+func unique(v any) (any, error) {
+  sorted, err := sortBy(v, nil)
+  if err != nil {
+    return nil, err
+  }
+  out := []any{}
+  for _, e := range sorted.([]any) {
+    if len(out) == 0 || compare(out[len(out)-1], e) != 0 {
+      out = append(out, e)
+    }
+  }
+  return out, nil
+}
+
+func extreme(v any, sign int) (any, error) {
+  arr, ok := v.([]any)
+  if !ok {
+    return nil, fmt.Errorf("%s cannot be searched, as it is not an array", describe(v))
+  }
+  var best any
+  for i, e := range arr {
+    if i == 0 || compare(e, best)*sign >= 0 {
+      best = e
+    }
+  }
+  return best, nil
+}
+
+func tostring(v any) (any, error) {
+  if s, ok := v.(string); ok {
+    return s, nil
+  }
+  return toJSON(v, 0), nil
+}
+
+func tonumber(v any) (any, error) {
+  switch v := v.(type) {
+  case float64:
+    return v, nil
+  case string:
+    if n, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+      return n, nil
+    }
+  }
+  return nil, fmt.Errorf("Cannot parse %s as a number", toJSON(v, 0))
+}
+
+func toEntries(v any) (any, error) {
+  o, ok := v.(*object)
+  if !ok {
+    return nil, fmt.Errorf("%s has no keys", describe(v))
+  }
+  out := make([]any, len(o.keys))
+  for i, k := range o.keys {
+    e := newObject()
+    e.set("key", k)
+    e.set("value", o.vals[k])
+    out[i] = e
+  }
+  return out, nil
+}
+
+// Build an object from {key, value} entries. "k", "name" and "Key" style
+// variants are accepted as jq does.
+func fromEntries(v any) (any, error) {
+  arr, ok := v.([]any)
+  if !ok {
+    return nil, fmt.Errorf("Cannot iterate over %s", describe(v))
+  }
+  out := newObject()
+  for _, e := range arr {
+    o, ok := e.(*object)
+    if !ok {
+      return nil, fmt.Errorf("Cannot index %s with \"key\"", typeName(e))
+    }
+    var key any
+    for _, name := range []string{"key", "k", "name", "Name", "Key", "K"} {
+      if k, ok := o.get(name); ok && truthy(k) {
+        key = k
+        break
+      }
+    }
+    var value any
+    for _, name := range []string{"value", "v", "Value", "V"} {
+      if val, ok := o.get(name); ok {
+        value = val
+        break
+      }
+    }
+    switch k := key.(type) {
+    case string:
+      out.set(k, value)
+    case float64, bool:
+      out.set(toJSON(k, 0), value)
+    default:
+      return nil, fmt.Errorf("Cannot use %s as object key", describe(key))
+    }
+  }
+  return out, nil
+}
+
+func join(v, sep any) (any, error) {
+  arr, ok := v.([]any)
+  s, ok2 := sep.(string)
+  if !ok || !ok2 {
+    return nil, fmt.Errorf("Cannot join %s with %s", describe(v), describe(sep))
+  }
+  parts := make([]string, len(arr))
+  for i, e := range arr {
+    switch e := e.(type) {
+    case nil:
+    case string:
+      parts[i] = e
+    case float64, bool:
+      parts[i] = toJSON(e, 0)
+    default:
+      return nil, fmt.Errorf("Cannot join with %s", typeName(e))
+    }
+  }
+  return strings.Join(parts, s), nil
+}
+
+func mapString(v any, fn func(string) string) (any, error) {
+  s, ok := v.(string)
+  if !ok {
+    return nil, fmt.Errorf("%s cannot be case-converted", describe(v))
+  }
+  return fn(s), nil
+}
+
+func mapNumber(v any, fn func(float64) float64) (any, error) {
+  n, ok := v.(float64)
+  if !ok {
+    return nil, fmt.Errorf("%s number required", describe(v))
+  }
+  return fn(n), nil
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+  "errors"
+  "fmt"
+  "math"
+  "sort"
+  "strconv"
+  "strings"
+  "unicode/utf16"
+)
+
+// JSON values are represented as nil, bool, float64, string, []any and
+// *object. Objects keep their keys in insertion order so output matches the
+// input, as jq does.
+type object struct {
+  keys []string
+  vals map[string]any
+}
+
+func newObject() *object {
+  return &object{vals: map[string]any{}}
+}
+
+func (o *object) set(k string, v any) {
+  if _, ok := o.vals[k]; !ok {
+    o.keys = append(o.keys, k)
+  }
+  o.vals[k] = v
+}
+
+func (o *object) get(k string) (any, bool) {
+  v, ok := o.vals[k]
+  return v, ok
+}
+
+func (o *object) copy() *object {
+  c := &object{keys: append([]string(nil), o.keys...), vals: make(map[string]any, len(o.vals))}
+  for k, v := range o.vals {
+    c.vals[k] = v
+  }
+  return c
+}
+
+// A parser reads a sequence of whitespace separated JSON values.
+type parser struct {
+  data []byte
+  pos  int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+  line := 1 + strings.Count(string(p.data[:p.pos]), "\n")
+  return fmt.Errorf("%s at line %d", fmt.Sprintf(format, args...), line)
+}
+
+func (p *parser) skipSpace() {
+  for p.pos < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+    p.pos++
+  }
+}
+
+// Report whether there is another value to read.
+func (p *parser) more() bool {
+  p.skipSpace()
+  return p.pos < len(p.data)
+}
+
+func (p *parser) value() (any, error) {
+  p.skipSpace()
+  if p.pos >= len(p.data) {
+    return nil, p.errorf("unexpected end of input")
+  }
+  switch c := p.data[p.pos]; {
+  case c == '{':
+    return p.object()
+  case c == '[':
+    return p.array()
+  case c == '"':
+    return p.string()
+  case c == '-' || (c >= '0' && c <= '9'):
+    return p.number()
+  }
+  for lit, v := range map[string]any{"true": true, "false": false, "null": nil} {
+    if strings.HasPrefix(string(p.data[p.pos:]), lit) {
+      p.pos += len(lit)
+      return v, nil
+    }
+  }
+  return nil, p.errorf("invalid literal")
+}
+
+func (p *parser) object() (any, error) {
+  p.pos++
+  obj := newObject()
+  p.skipSpace()
+  if p.pos < len(p.data) && p.data[p.pos] == '}' {
+    p.pos++
+    return obj, nil
+  }
+  for {
+    p.skipSpace()
+    if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+      return nil, p.errorf("object keys must be strings")
+    }
+    key, err := p.string()
+    if err != nil {
+      return nil, err
+    }
+    p.skipSpace()
+    if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+      return nil, p.errorf("expected ':' after object key")
+    }
+    p.pos++
+    v, err := p.value()
+    if err != nil {
+      return nil, err
+    }
+    obj.set(key.(string), v)
+    p.skipSpace()
+    if p.pos < len(p.data) && p.data[p.pos] == ',' {
+      p.pos++
+      continue
+    }
+    if p.pos < len(p.data) && p.data[p.pos] == '}' {
+      p.pos++
+      return obj, nil
+    }
+    return nil, p.errorf("expected ',' or '}' in object")
+  }
+}
+
+func (p *parser) array() (any, error) {
+  p.pos++
+  arr := []any{}
+  p.skipSpace()
+  if p.pos < len(p.data) && p.data[p.pos] == ']' {
+    p.pos++
+    return arr, nil
+  }
+  for {
+    v, err := p.value()
+    if err != nil {
+      return nil, err
+    }
+    arr = append(arr, v)
+    p.skipSpace()
+    if p.pos < len(p.data) && p.data[p.pos] == ',' {
+      p.pos++
+      continue
+    }
+    if p.pos < len(p.data) && p.data[p.pos] == ']' {
+      p.pos++
+      return arr, nil
+    }
+    return nil, p.errorf("expected ',' or ']' in array")
+  }
+}
+
+func (p *parser) number() (any, error) {
+  start := p.pos
+  if p.data[p.pos] == '-' {
+    p.pos++
+  }
+  for p.pos < len(p.data) && strings.IndexByte("0123456789.eE+-", p.data[p.pos]) >= 0 {
+    p.pos++
+  }
+  f, err := strconv.ParseFloat(string(p.data[start:p.pos]), 64)
+  if err != nil && !errors.Is(err, strconv.ErrRange) {
+    p.pos = start
+    return nil, p.errorf("invalid number")
+  }
+  return f, nil
+}
+
+// Parse a string literal, decoding escapes including UTF-16 surrogate pairs.
+func (p *parser) string() (any, error) {
+  p.pos++
+  var b strings.Builder
+  for p.pos < len(p.data) {
+    c := p.data[p.pos]
+    switch {
+    case c == '"':
+      p.pos++
+      return b.String(), nil
+    case c < 0x20:
+      return nil, p.errorf("control character in string")
+    case c != '\\':
+      b.WriteByte(c)
+      p.pos++
+      continue
+    }
+    p.pos++
+    if p.pos >= len(p.data) {
+      break
+    }
+    esc := p.data[p.pos]
+    p.pos++
+    switch esc {
+    case '"', '\\', '/':
+      b.WriteByte(esc)
+    case 'b':
+      b.WriteByte('\b')
+    case 'f':
+      b.WriteByte('\f')
+    case 'n':
+      b.WriteByte('\n')
+    case 'r':
+      b.WriteByte('\r')
+    case 't':
+      b.WriteByte('\t')
+    case 'u':
+      r, err := p.hex4()
+      if err != nil {
+        return nil, err
+      }
+      if utf16.IsSurrogate(r) && strings.HasPrefix(string(p.data[p.pos:]), "\\u") {
+        p.pos += 2
+        r2, err := p.hex4()
+        if err != nil {
+          return nil, err
+        }
+        r = utf16.DecodeRune(r, r2)
+      }
+      b.WriteRune(r)
+    default:
+      return nil, p.errorf("invalid escape '\\%c'", esc)
+    }
+  }
+  return nil, p.errorf("unterminated string")
+}
+
+func (p *parser) hex4() (rune, error) {
+  if p.pos+4 > len(p.data) {
+    return 0, p.errorf("invalid \\u escape")
+  }
+  n, err := strconv.ParseUint(string(p.data[p.pos:p.pos+4]), 16, 32)
+  if err != nil {
+    return 0, p.errorf("invalid \\u escape")
+  }
+  p.pos += 4
+  return rune(n), nil
+}
+
+// Format a number the way jq does: the shortest digits that round-trip,
+// written out in full unless that takes more than 15 zeros after them or 3
+// before them, when they are written with an exponent instead.
+func formatNumber(f float64) string {
+  switch {
+  case math.IsNaN(f):
+    return "null"
+  case math.IsInf(f, 1):
+    return "1.7976931348623157e+308"
+  case math.IsInf(f, -1):
+    return "-1.7976931348623157e+308"
+  }
+  e := strconv.FormatFloat(f, 'e', -1, 64)
+  mantissa, exp, _ := strings.Cut(e, "e")
+  point, _ := strconv.Atoi(exp)
+  point++ // where the decimal point falls after the first digit
+  digits := len(strings.Trim(mantissa, "-.")) - strings.Count(mantissa, ".")
+  if point <= -4 || point > digits+15 {
+    return e
+  }
+  return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func quote(s string) string {
+  var b strings.Builder
+  b.WriteByte('"')
+  for _, r := range s {
+    switch r {
+    case '"':
+      b.WriteString(`\"`)
+    case '\\':
+      b.WriteString(`\\`)
+    case '\n':
+      b.WriteString(`\n`)
+    case '\t':
+      b.WriteString(`\t`)
+    case '\r':
+      b.WriteString(`\r`)
+    case '\b':
+      b.WriteString(`\b`)
+    case '\f':
+      b.WriteString(`\f`)
+    default:
+      if r < 0x20 || r == 0x7f {
+        fmt.Fprintf(&b, `\u%04x`, r)
+      } else {
+        b.WriteRune(r)
+      }
+    }
+  }
+  b.WriteByte('"')
+  return b.String()
+}
+
+// Encode a value. With indent > 0 the output is pretty printed with that
+// many spaces per level; otherwise it is compact.
+func encode(b *strings.Builder, v any, indent, depth int) {
+  newline := func(d int) {
+    if indent > 0 {
+      b.WriteByte('\n')
+      b.WriteString(strings.Repeat(" ", indent*d))
+    }
+  }
+  switch v := v.(type) {
+  case nil:
+    b.WriteString("null")
+  case bool:
+    b.WriteString(strconv.FormatBool(v))
+  case float64:
+    b.WriteString(formatNumber(v))
+  case string:
+    b.WriteString(quote(v))
+  case []any:
+    if len(v) == 0 {
+      b.WriteString("[]")
+      return
+    }
+    b.WriteByte('[')
+    for i, e := range v {
+      if i > 0 {
+        b.WriteByte(',')
+      }
+      newline(depth + 1)
+      encode(b, e, indent, depth+1)
+    }
+    newline(depth)
+    b.WriteByte(']')
+  case *object:
+    if len(v.keys) == 0 {
+      b.WriteString("{}")
+      return
+    }
+    b.WriteByte('{')
+    for i, k := range v.keys {
+      if i > 0 {
+        b.WriteByte(',')
+      }
+      newline(depth + 1)
+      b.WriteString(quote(k))
+      b.WriteByte(':')
+      if indent > 0 {
+        b.WriteByte(' ')
+      }
+      encode(b, v.vals[k], indent, depth+1)
+    }
+    newline(depth)
+    b.WriteByte('}')
+  }
+}
+
+func toJSON(v any, indent int) string {
+  var b strings.Builder
+  encode(&b, v, indent, 0)
+  return b.String()
+}
+
+func typeName(v any) string {
+  switch v.(type) {
+  case nil:
+    return "null"
+  case bool:
+    return "boolean"
+  case float64:
+    return "number"
+  case string:
+    return "string"
+  case []any:
+    return "array"
+  }
+  return "object"
+}
+
+// Order values the way jq sorts them: null, false, true, numbers, strings,
+// arrays, objects. Objects compare by their sorted key sets first, then by
+// values in key order.
+func compare(a, b any) int {
+  rank := func(v any) int {
+    switch v := v.(type) {
+    case nil:
+      return 0
+    case bool:
+      if v {
+        return 2
+      }
+      return 1
+    case float64:
+      return 3
+    case string:
+      return 4
+    case []any:
+      return 5
+    }
+    return 6
+  }
+  if ra, rb := rank(a), rank(b); ra != rb {
+    return ra - rb
+  }
+  switch a := a.(type) {
+  case float64:
+    b := b.(float64)
+    switch {
+    case a < b:
+      return -1
+    case a > b:
+      return 1
+    }
+    return 0
+  case string:
+    return strings.Compare(a, b.(string))
+  case []any:
+    b := b.([]any)
+    for i := 0; i < len(a) && i < len(b); i++ {
+      if c := compare(a[i], b[i]); c != 0 {
+        return c
+      }
+    }
+    return len(a) - len(b)
+  case *object:
+    b := b.(*object)
+    ka, kb := sortedKeys(a), sortedKeys(b)
+    ka2, kb2 := make([]any, len(ka)), make([]any, len(kb))
+    for i, k := range ka {
+      ka2[i] = k
+    }
+    for i, k := range kb {
+      kb2[i] = k
+    }
+    if c := compare(ka2, kb2); c != 0 {
+      return c
+    }
+    for _, k := range ka {
+      if c := compare(a.vals[k], b.vals[k]); c != 0 {
+        return c
+      }
+    }
+  }
+  return 0
+}
+
+func sortedKeys(o *object) []string {
+  keys := append([]string(nil), o.keys...)
+  sort.Strings(keys)
+  return keys
+}
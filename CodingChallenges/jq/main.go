@@ -0,0 +1,94 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+)
+
+// Run the filter over every JSON value in the inputs, printing each output.
+// Exit status follows jq: 2 for usage, I/O or parse errors, 3 for a bad
+// filter and 5 when the filter failed at runtime.
+func main() {
+  compact := flag.Bool("c", false, "compact output instead of pretty printing")
+  raw := flag.Bool("r", false, "write strings without quotes")
+  nullInput := flag.Bool("n", false, "use null as the single input instead of reading any")
+  indent := flag.Int("indent", 2, "spaces per indentation level")
+  flag.Parse()
+
+  if flag.NArg() == 0 {
+    fmt.Fprintln(os.Stderr, "usage: jq [-c] [-r] [-n] filter [file...]")
+    os.Exit(2)
+  }
+  f, err := compile(flag.Arg(0))
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "jq: error: %v\njq: 1 compile error\n", err)
+    os.Exit(3)
+  }
+
+  w := bufio.NewWriter(os.Stdout)
+  defer w.Flush()
+  width := *indent
+  if *compact {
+    width = 0
+  }
+  emit := func(v any) {
+    if s, ok := v.(string); ok && *raw {
+      w.WriteString(s)
+    } else {
+      w.WriteString(toJSON(v, width))
+    }
+    w.WriteByte('\n')
+  }
+  status := 0
+  run := func(v any) {
+    outs, err := f(v)
+    for _, o := range outs {
+      emit(o)
+    }
+    if err != nil {
+      w.Flush()
+      fmt.Fprintln(os.Stderr, "jq: error:", err)
+      status = 5
+    }
+  }
+
+  if *nullInput {
+    run(nil)
+    w.Flush()
+    os.Exit(status)
+  }
+
+  var data []byte
+  files := flag.Args()[1:]
+  if len(files) == 0 {
+    data, err = io.ReadAll(os.Stdin)
+  }
+  for _, name := range files {
+    var b []byte
+    if b, err = os.ReadFile(name); err != nil {
+      break
+    }
+    data = append(append(data, b...), '\n')
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "jq: error:", err)
+    os.Exit(2)
+  }
+
+  p := &parser{data: data}
+  for p.more() {
+    v, err := p.value()
+    if err != nil {
+      w.Flush()
+      fmt.Fprintln(os.Stderr, "jq: error (at <stdin>): cannot parse input:", err)
+      os.Exit(2)
+    }
+    run(v)
+  }
+  w.Flush()
+  os.Exit(status)
+}
+
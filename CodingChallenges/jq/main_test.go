@@ -0,0 +1,172 @@
+package main
+
+import (
+  "slices"
+  "strings"
+  "testing"
+)
+
+// Run a filter over every value in input, returning each output as
+// compact JSON.
+func jq(t *testing.T, src, input string) ([]string, error) {
+  t.Helper()
+  f, err := compile(src)
+  if err != nil {
+    return nil, err
+  }
+  var outs []string
+  p := &parser{data: []byte(input)}
+  for p.more() {
+    v, err := p.value()
+    if err != nil {
+      t.Fatalf("%s: bad input: %v", input, err)
+    }
+    results, err := f(v)
+    for _, r := range results {
+      outs = append(outs, toJSON(r, 0))
+    }
+    if err != nil {
+      return outs, err
+    }
+  }
+  return outs, nil
+}
+
+// Each filter on its input gives what jq 1.6 -c prints for it.
+func TestFilters(t *testing.T) {
+  for _, c := range []struct {
+    filter, input string
+    want          []string
+  }{
+    {`.`, `{"a":1,"b":[1,2]}`, []string{`{"a":1,"b":[1,2]}`}},
+    {`.a`, `{"a":1,"b":[1,2]}`, []string{`1`}},
+    {`.b[1]`, `{"a":1,"b":[1,2]}`, []string{`2`}},
+    {`.b[-1]`, `{"a":1,"b":[1,2]}`, []string{`2`}},
+    {`.missing`, `{"a":1}`, []string{`null`}},
+    {`.a.b.c`, `{"a":null}`, []string{`null`}},
+    {`.["a"]`, `{"a":"x"}`, []string{`"x"`}},
+    {`.[]`, `[1,"two",null,true]`, []string{`1`, `"two"`, `null`, `true`}},
+    {`.[]`, `{"x":1,"y":2}`, []string{`1`, `2`}},
+    {`.[]?`, `3`, nil},
+    {`.[1:3]`, `[0,1,2,3,4]`, []string{`[1,2]`}},
+    {`.[:-1]`, `"hello"`, []string{`"hell"`}},
+    {`.[2:]`, `[0,1,2,3]`, []string{`[2,3]`}},
+    {`.a?`, `[1]`, nil},
+    {`.a, .b`, `{"a":1,"b":2}`, []string{`1`, `2`}},
+    {`.[] | .name`, `[{"name":"x"},{"name":"y"}]`, []string{`"x"`, `"y"`}},
+    {`[.[] | . * 2]`, `[1,2,3]`, []string{`[2,4,6]`}},
+    {`{a: .x, "b": 2, c}`, `{"x":1,"c":3}`, []string{`{"a":1,"b":2,"c":3}`}},
+    {`{(.k): .v}`, `{"k":"key","v":42}`, []string{`{"key":42}`}},
+    {`.a + .b`, `{"a":1,"b":2}`, []string{`3`}},
+    {`.a + .b`, `{"a":"x","b":"y"}`, []string{`"xy"`}},
+    {`.a + .b`, `{"a":[1],"b":[2]}`, []string{`[1,2]`}},
+    {`.a + .b`, `{"a":{"x":1},"b":{"y":2}}`, []string{`{"x":1,"y":2}`}},
+    {`.a + null`, `{"a":1}`, []string{`1`}},
+    {`. - [2]`, `[1,2,3,2]`, []string{`[1,3]`}},
+    {`10 / 4`, `null`, []string{`2.5`}},
+    {`7 % 3`, `null`, []string{`1`}},
+    {`"a,b,c" / ","`, `null`, []string{`["a","b","c"]`}},
+    {`{"a":{"b":1}} * {"a":{"c":2}}`, `null`, []string{`{"a":{"b":1,"c":2}}`}},
+    {`.a == .b, .a != .b, .a < .b`, `{"a":1,"b":"1"}`, []string{`false`, `true`, `true`}},
+    {`[.[] | select(. > 1)]`, `[0,1,2,3]`, []string{`[2,3]`}},
+    {`.a // "default"`, `{"a":null}`, []string{`"default"`}},
+    {`.a // "default"`, `{"a":false}`, []string{`"default"`}},
+    {`.a // "default"`, `{"a":0}`, []string{`0`}},
+    {`.a and .b, .a or .b`, `{"a":true,"b":false}`, []string{`false`, `true`}},
+    {`not`, `null`, []string{`true`}},
+    {`length`, `[1,2,3]`, []string{`3`}},
+    {`length`, `"héllo"`, []string{`5`}},
+    {`length`, `{"a":1}`, []string{`1`}},
+    {`length`, `null`, []string{`0`}},
+    {`length`, `-5`, []string{`5`}},
+    {`keys`, `{"b":1,"a":2}`, []string{`["a","b"]`}},
+    {`keys_unsorted`, `{"b":1,"a":2}`, []string{`["b","a"]`}},
+    {`has("a")`, `{"a":null}`, []string{`true`}},
+    {`has(1)`, `[0]`, []string{`false`}},
+    {`map(. + 1)`, `[1,2]`, []string{`[2,3]`}},
+    {`add`, `[1,2,3]`, []string{`6`}},
+    {`add`, `["a","b"]`, []string{`"ab"`}},
+    {`add`, `[]`, []string{`null`}},
+    {`sort`, `[3,"a",null,true,false,[1],{"a":1},1]`, []string{`[null,false,true,1,3,"a",[1],{"a":1}]`}},
+    {`sort_by(.n)`, `[{"n":2},{"n":1},{"n":3}]`, []string{`[{"n":1},{"n":2},{"n":3}]`}},
+    {`unique`, `[3,1,3,2,1]`, []string{`[1,2,3]`}},
+    {`min, max`, `[5,2,9]`, []string{`2`, `9`}},
+    {`min`, `[]`, []string{`null`}},
+    {`reverse`, `[1,2,3]`, []string{`[3,2,1]`}},
+    {`tostring`, `{"a":[1,2]}`, []string{`"{\"a\":[1,2]}"`}},
+    {`tonumber`, `"3.5"`, []string{`3.5`}},
+    {`to_entries`, `{"a":1,"b":2}`, []string{`[{"key":"a","value":1},{"key":"b","value":2}]`}},
+    {`from_entries`, `[{"key":"a","value":1},{"name":"b","value":2}]`, []string{`{"a":1,"b":2}`}},
+    {`join("-")`, `["a","b","c"]`, []string{`"a-b-c"`}},
+    {`split(", ")`, `"a, b, c"`, []string{`["a","b","c"]`}},
+    {`ascii_downcase, ascii_upcase`, `"MiXeD"`, []string{`"mixed"`, `"MIXED"`}},
+    {`floor, sqrt`, `16.7`, []string{`16`, `4.08656334834051`}},
+    {`[range(3)]`, `null`, []string{`[0,1,2]`}},
+    {`[range(2;5)]`, `null`, []string{`[2,3,4]`}},
+    {`first, last`, `[1,2,3]`, []string{`1`, `3`}},
+    {`[..]`, `[1,[2]]`, []string{`[[1,[2]],1,[2],2]`}},
+    {`type`, `[null,true,1,"s",[],{}]`, []string{`"array"`}},
+    {`[.[] | type]`, `[null,true,1,"s",[],{}]`, []string{`["null","boolean","number","string","array","object"]`}},
+    {`.[0] | . * 3`, `[0.1]`, []string{`0.30000000000000004`}},
+    {`100000000000000000000`, `null`, []string{`1e+20`}},
+    {`3.0`, `null`, []string{`3`}},
+    {`"é\n\t\"\\"`, `null`, []string{`"é\n\t\"\\"`}},
+    {`empty`, `1`, nil},
+    {`[empty]`, `1`, []string{`[]`}},
+  } {
+    got, err := jq(t, c.filter, c.input)
+    if err != nil {
+      t.Errorf("%s on %s: %v", c.filter, c.input, err)
+    } else if !slices.Equal(got, c.want) {
+      t.Errorf("%s on %s: got %q, want %q", c.filter, c.input, got, c.want)
+    }
+  }
+}
+
+// Numbers print in jq's shortest form, with an exponent only for many
+// leading or trailing zeros, and out of range ones as the largest double.
+func TestNumbers(t *testing.T) {
+  in := "1e16 1e15 1234567.5 12345678901234567890123 0.0001 0.00001 0.000123 1.5e-5 1e100 3e5 -2.5e-9 1e-300 5e-324 -0 100000000000000000 123456789012345678 1e1000 -1e1000 0.1 3.0"
+  want := "1e+16 1000000000000000 1234567.5 12345678901234568000000 0.0001 1e-05 0.000123 1.5e-05 1e+100 300000 -2.5e-09 1e-300 5e-324 -0 1e+17 123456789012345680 1.7976931348623157e+308 -1.7976931348623157e+308 0.1 3"
+  got, err := jq(t, ".", in)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if strings.Join(got, " ") != want {
+    t.Errorf("got  %s\nwant %s", strings.Join(got, " "), want)
+  }
+  if got, err := jq(t, "1e1000, 2.50", "null"); err != nil || strings.Join(got, " ") != "1.7976931348623157e+308 2.5" {
+    t.Errorf("number literals gave %q, %v", got, err)
+  }
+}
+
+// Filters that do not compile, and filters that fail on their input after
+// giving whatever outputs came before the failure.
+func TestErrors(t *testing.T) {
+  for _, src := range []string{"1 +", ".[", "{a", "nosuch", "map", "(1", `"abc`} {
+    if _, err := compile(src); err == nil {
+      t.Errorf("%s compiled", src)
+    }
+  }
+  for _, c := range []struct {
+    filter, input string
+    want          []string
+  }{
+    {`.a`, `[1]`, nil},
+    {`.[0]`, `{"a":1}`, nil},
+    {`.a + 1`, `{"a":"s"}`, nil},
+    {`[1] - 1`, `null`, nil},
+    {`"abc" | .[0]`, `null`, nil},
+    {`error("x")`, `null`, nil},
+    {`1, error("x"), 2`, `null`, []string{"1"}},
+    {`.[] | keys`, `[{"a":1}, 3]`, []string{`["a"]`}},
+  } {
+    got, err := jq(t, c.filter, c.input)
+    if err == nil {
+      t.Errorf("%s on %s: no error", c.filter, c.input)
+    }
+    if !slices.Equal(got, c.want) {
+      t.Errorf("%s on %s: got %q before the error, want %q", c.filter, c.input, got, c.want)
+    }
+  }
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "os"
+  "strings"
+  "time"
+)
+
+func usage() {
+  fmt.Fprintln(os.Stderr, `usage: kvstore [-d dir] [-sync] [-segment bytes] [command args...]
+  put key value     set a key
+  get key           print a key's value
+  del key           delete a key
+  scan [from [to]]  list keys in [from, to) with their values
+  compact           merge old segments, dropping dead data
+  stats             show key count and disk usage
+With no command, commands are read one per line from stdin and old segments
+are compacted in the background.`)
+}
+
+// Run one command, writing its output to w.
+func run(s *store, w *bufio.Writer, args []string) error {
+  need := func(n int) error {
+    if len(args) != n+1 {
+      return fmt.Errorf("%s takes %d argument(s)", args[0], n)
+    }
+    return nil
+  }
+  switch args[0] {
+  case "put", "set":
+    if err := need(2); err != nil {
+      return err
+    }
+    return s.put(args[1], []byte(args[2]))
+  case "get":
+    if err := need(1); err != nil {
+      return err
+    }
+    v, err := s.get(args[1])
+    if err != nil {
+      return err
+    }
+    fmt.Fprintf(w, "%s\n", v)
+  case "del", "delete":
+    if err := need(1); err != nil {
+      return err
+    }
+    return s.delete(args[1])
+  case "scan":
+    if len(args) > 3 {
+      return fmt.Errorf("scan takes at most 2 arguments")
+    }
+    var from, to string
+    if len(args) > 1 {
+      from = args[1]
+    }
+    if len(args) > 2 {
+      to = args[2]
+    }
+    it := s.iterate(from, to)
+    for it.next() {
+      fmt.Fprintf(w, "%s\t%s\n", it.key(), it.value())
+    }
+    return it.err()
+  case "compact":
+    return s.compact()
+  case "stats":
+    keys, segments, total, stale := s.stats()
+    fmt.Fprintf(w, "keys: %d\nsegments: %d\nbytes: %d\ngarbage: %d\n", keys, segments, total, stale)
+  default:
+    return fmt.Errorf("unknown command %q", args[0])
+  }
+  return nil
+}
+
+func main() {
+  dir := flag.String("d", "kvdata", "data directory")
+  syncWrites := flag.Bool("sync", false, "fsync after every write")
+  segmentSize := flag.Int64("segment", 4<<20, "roll over to a new segment after this many bytes")
+  flag.Usage = usage
+  flag.Parse()
+
+  s, err := openStore(*dir, *segmentSize, *syncWrites)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "kvstore:", err)
+    os.Exit(1)
+  }
+  w := bufio.NewWriter(os.Stdout)
+
+  if flag.NArg() > 0 {
+    err := run(s, w, flag.Args())
+    w.Flush()
+    s.close()
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "kvstore:", err)
+      os.Exit(1)
+    }
+    return
+  }
+
+  s.autoCompact(time.Second, 0.5, func(format string, args ...any) {
+    fmt.Fprintf(os.Stderr, "kvstore: "+format+"\n", args...)
+  })
+  scanner := bufio.NewScanner(os.Stdin)
+  for scanner.Scan() {
+    // A value may contain spaces: everything after the key is the value.
+    line := strings.TrimSpace(scanner.Text())
+    if line == "" {
+      continue
+    }
+    args := strings.Fields(line)
+    if (args[0] == "put" || args[0] == "set") && len(args) > 3 {
+      rest := strings.TrimSpace(line[len(args[0]):])
+      value := strings.TrimSpace(rest[len(args[1]):])
+      args = []string{args[0], args[1], value}
+    }
+    if err := run(s, w, args); err != nil {
+      fmt.Fprintln(w, "error:", err)
+    }
+    w.Flush()
+  }
+  if err := s.close(); err != nil {
+    fmt.Fprintln(os.Stderr, "kvstore:", err)
+    os.Exit(1)
+  }
+}
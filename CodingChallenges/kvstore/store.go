@@ -0,0 +1,520 @@
+package main
+
+import (
+  "encoding/binary"
+  "errors"
+  "fmt"
+  "hash/crc32"
+  "io"
+  "os"
+  "path/filepath"
+  "sort"
+  "strconv"
+  "strings"
+  "sync"
+  "time"
+)
+
+// Each segment file is a sequence of records:
+//
+//   crc u32 | kind u8 | keyLen u32 | valueLen u32 | key | value
+//
+// The CRC covers everything after it. A segment written by compaction starts
+// with a marker record saying it replaces every segment with a lower id, so a
+// crash between writing it and deleting those segments is harmless.
+const (
+  recordHeader = 4 + 1 + 4 + 4
+
+  kindPut       = 1
+  kindDelete    = 2
+  kindCompacted = 3
+)
+
+var (
+  errNotFound = errors.New("key not found")
+  errCorrupt  = errors.New("corrupt record")
+)
+
+// Where the latest value for a key lives.
+type location struct {
+  segment int
+  offset  int64 // offset of the value bytes
+  size    uint32
+}
+
+type segment struct {
+  id   int
+  file *os.File
+  size int64
+}
+
+// A store is a log-structured hash table: writes append to the active
+// segment and the in-memory index maps every live key to its latest value.
+type store struct {
+  mu       sync.RWMutex
+  dir      string
+  segments map[int]*segment
+  active   *segment
+  index    map[string]location
+  stale    map[int]int64 // per segment, bytes of overwritten or deleted data
+  syncs    bool
+
+  maxSegment int64
+  compactMu  sync.Mutex // only one compaction at a time
+  done       chan struct{}
+  wg         sync.WaitGroup
+}
+
+func segmentPath(dir string, id int) string {
+  return filepath.Join(dir, fmt.Sprintf("%09d.wal", id))
+}
+
+// Open a store, replaying every segment in order to rebuild the index. A
+// torn record at the end of the newest segment (from a crash mid-write) is
+// truncated away; corruption anywhere else is an error.
+func openStore(dir string, maxSegment int64, syncWrites bool) (*store, error) {
+  if err := os.MkdirAll(dir, 0o755); err != nil {
+    return nil, err
+  }
+  s := &store{dir: dir, segments: map[int]*segment{}, index: map[string]location{}, stale: map[int]int64{},
+    maxSegment: maxSegment, syncs: syncWrites, done: make(chan struct{})}
+
+  names, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+  if err != nil {
+    return nil, err
+  }
+  var ids []int
+  for _, name := range names {
+    id, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(name), ".wal"))
+    if err == nil {
+      ids = append(ids, id)
+    }
+  }
+  sort.Ints(ids)
+
+  // A compacted segment supersedes all older ones; drop leftovers first.
+  for i := len(ids) - 1; i >= 0; i-- {
+    if compacted, _ := isCompacted(segmentPath(dir, ids[i])); compacted {
+      for _, old := range ids[:i] {
+        os.Remove(segmentPath(dir, old))
+      }
+      ids = ids[i:]
+      break
+    }
+  }
+
+  for i, id := range ids {
+    last := i == len(ids)-1
+    if err := s.replay(id, last); err != nil {
+      s.close()
+      return nil, err
+    }
+  }
+  next := 1
+  if len(ids) > 0 {
+    next = ids[len(ids)-1]
+    if s.segments[next].size >= maxSegment {
+      next++
+    }
+  }
+  if err := s.useSegment(next); err != nil {
+    s.close()
+    return nil, err
+  }
+  return s, nil
+}
+
+func isCompacted(path string) (bool, error) {
+  f, err := os.Open(path)
+  if err != nil {
+    return false, err
+  }
+  defer f.Close()
+  hdr := make([]byte, recordHeader)
+  if _, err := io.ReadFull(f, hdr); err != nil {
+    return false, nil
+  }
+  return hdr[4] == kindCompacted && crc32.ChecksumIEEE(hdr[4:]) == binary.BigEndian.Uint32(hdr), nil
+}
+
+// Read a segment's records into the index.
+func (s *store) replay(id int, last bool) error {
+  f, err := os.OpenFile(segmentPath(s.dir, id), os.O_RDWR, 0)
+  if err != nil {
+    return err
+  }
+  seg := &segment{id: id, file: f}
+  s.segments[id] = seg
+
+  var offset int64
+  for {
+    kind, key, valueLen, n, err := readRecord(f, offset)
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      if !last {
+        return fmt.Errorf("%s at offset %d: %w", segmentPath(s.dir, id), offset, err)
+      }
+      // A partial write at the tail: discard it.
+      if err := f.Truncate(offset); err != nil {
+        return err
+      }
+      break
+    }
+    switch kind {
+    case kindPut:
+      s.discard(key)
+      s.index[key] = location{id, offset + recordHeader + int64(len(key)), valueLen}
+    case kindDelete:
+      s.discard(key)
+      delete(s.index, key)
+      s.stale[id] += n
+    }
+    offset += n
+  }
+  seg.size = offset
+  return nil
+}
+
+// Read the record at offset, returning its kind, key, value length and
+// total size. The value itself is read and checked but not returned.
+func readRecord(f *os.File, offset int64) (byte, string, uint32, int64, error) {
+  hdr := make([]byte, recordHeader)
+  n, err := f.ReadAt(hdr, offset)
+  if n == 0 && err == io.EOF {
+    return 0, "", 0, 0, io.EOF
+  }
+  if n < recordHeader {
+    return 0, "", 0, 0, errCorrupt
+  }
+  kind := hdr[4]
+  keyLen := binary.BigEndian.Uint32(hdr[5:])
+  valueLen := binary.BigEndian.Uint32(hdr[9:])
+  if keyLen > 1<<20 || valueLen > 1<<30 {
+    return 0, "", 0, 0, errCorrupt
+  }
+  body := make([]byte, int(keyLen)+int(valueLen))
+  if _, err := f.ReadAt(body, offset+recordHeader); err != nil {
+    return 0, "", 0, 0, errCorrupt
+  }
+  crc := crc32.NewIEEE()
+  crc.Write(hdr[4:])
+  crc.Write(body)
+  if crc.Sum32() != binary.BigEndian.Uint32(hdr) {
+    return 0, "", 0, 0, errCorrupt
+  }
+  return kind, string(body[:keyLen]), valueLen, recordHeader + int64(len(body)), nil
+}
+
+func encodeRecord(kind byte, key string, value []byte) []byte {
+  rec := make([]byte, recordHeader+len(key)+len(value))
+  rec[4] = kind
+  binary.BigEndian.PutUint32(rec[5:], uint32(len(key)))
+  binary.BigEndian.PutUint32(rec[9:], uint32(len(value)))
+  copy(rec[recordHeader:], key)
+  copy(rec[recordHeader+len(key):], value)
+  binary.BigEndian.PutUint32(rec, crc32.ChecksumIEEE(rec[4:]))
+  return rec
+}
+
+// Make segment id the active one, creating it if needed.
+func (s *store) useSegment(id int) error {
+  if seg, ok := s.segments[id]; ok {
+    s.active = seg
+    return nil
+  }
+  f, err := os.OpenFile(segmentPath(s.dir, id), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+  if err != nil {
+    return err
+  }
+  seg := &segment{id: id, file: f}
+  s.segments[id] = seg
+  s.active = seg
+  return nil
+}
+
+// Append a record to the active segment, rolling over to a new segment when
+// it is full. Must be called with mu held.
+func (s *store) append(kind byte, key string, value []byte) (location, error) {
+  if s.active.size >= s.maxSegment {
+    if err := s.useSegment(s.active.id + 1); err != nil {
+      return location{}, err
+    }
+  }
+  rec := encodeRecord(kind, key, value)
+  seg := s.active
+  if _, err := seg.file.WriteAt(rec, seg.size); err != nil {
+    return location{}, err
+  }
+  if s.syncs {
+    if err := seg.file.Sync(); err != nil {
+      return location{}, err
+    }
+  }
+  loc := location{seg.id, seg.size + recordHeader + int64(len(key)), uint32(len(value))}
+  seg.size += int64(len(rec))
+  return loc, nil
+}
+
+func (s *store) put(key string, value []byte) error {
+  if key == "" {
+    return errors.New("empty key")
+  }
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  loc, err := s.append(kindPut, key, value)
+  if err != nil {
+    return err
+  }
+  s.discard(key)
+  s.index[key] = loc
+  return nil
+}
+
+func (s *store) delete(key string) error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  if _, ok := s.index[key]; !ok {
+    return errNotFound
+  }
+  loc, err := s.append(kindDelete, key, nil)
+  if err != nil {
+    return err
+  }
+  s.discard(key)
+  delete(s.index, key)
+  // The tombstone is only needed until the older record is compacted away.
+  s.stale[loc.segment] += recordHeader + int64(len(key))
+  return nil
+}
+
+// Account for the current record of key becoming garbage.
+func (s *store) discard(key string) {
+  if old, ok := s.index[key]; ok {
+    s.stale[old.segment] += recordHeader + int64(len(key)) + int64(old.size)
+  }
+}
+
+func (s *store) get(key string) ([]byte, error) {
+  s.mu.RLock()
+  defer s.mu.RUnlock()
+  loc, ok := s.index[key]
+  if !ok {
+    return nil, errNotFound
+  }
+  return s.read(loc)
+}
+
+func (s *store) read(loc location) ([]byte, error) {
+  value := make([]byte, loc.size)
+  if _, err := s.segments[loc.segment].file.ReadAt(value, loc.offset); err != nil {
+    return nil, err
+  }
+  return value, nil
+}
+
+// An iterator walks keys in sorted order over a snapshot of the key set
+// taken when it was created. Values are read lazily, so a key deleted since
+// then is skipped.
+type iterator struct {
+  s    *store
+  keys []string
+  pos  int
+  k    string
+  v    []byte
+  fail error
+}
+
+// Iterate over keys in [start, end); an empty end means no upper bound.
+func (s *store) iterate(start, end string) *iterator {
+  s.mu.RLock()
+  keys := make([]string, 0, len(s.index))
+  for k := range s.index {
+    if k >= start && (end == "" || k < end) {
+      keys = append(keys, k)
+    }
+  }
+  s.mu.RUnlock()
+  sort.Strings(keys)
+  return &iterator{s: s, keys: keys}
+}
+
+func (it *iterator) next() bool {
+  for it.pos < len(it.keys) {
+    it.k = it.keys[it.pos]
+    it.pos++
+    it.v, it.fail = it.s.get(it.k)
+    if it.fail == errNotFound {
+      it.fail = nil
+      continue
+    }
+    return it.fail == nil
+  }
+  return false
+}
+
+func (it *iterator) key() string   { return it.k }
+func (it *iterator) value() []byte { return it.v }
+
+// The error that stopped iteration early, if any.
+func (it *iterator) err() error { return it.fail }
+
+// Compact every segment except the active one into a single new segment
+// holding only live values. The copy runs without blocking writers; the
+// index is then switched over for keys that were not rewritten meanwhile,
+// and the old segments are deleted.
+func (s *store) compact() error {
+  s.compactMu.Lock()
+  defer s.compactMu.Unlock()
+
+  s.mu.Lock()
+  var old []int
+  for id := range s.segments {
+    if id != s.active.id {
+      old = append(old, id)
+    }
+  }
+  if len(old) == 0 {
+    // Everything is in the active segment; seal it so it can be compacted.
+    if err := s.useSegment(s.active.id + 1); err != nil {
+      s.mu.Unlock()
+      return err
+    }
+    old = []int{s.active.id - 1}
+  }
+  sort.Ints(old)
+  target := old[len(old)-1]
+  live := map[string]location{}
+  for k, loc := range s.index {
+    if loc.segment <= target {
+      live[k] = loc
+    }
+  }
+  s.mu.Unlock()
+
+  tmpPath := segmentPath(s.dir, target) + ".compact"
+  tmp, err := os.Create(tmpPath)
+  if err != nil {
+    return err
+  }
+  defer os.Remove(tmpPath)
+  keys := make([]string, 0, len(live))
+  for k := range live {
+    keys = append(keys, k)
+  }
+  sort.Strings(keys)
+
+  marker := encodeRecord(kindCompacted, "", nil)
+  offset := int64(len(marker))
+  moved := map[string]location{}
+  if _, err := tmp.Write(marker); err != nil {
+    tmp.Close()
+    return err
+  }
+  for _, k := range keys {
+    s.mu.RLock()
+    value, err := s.read(live[k])
+    s.mu.RUnlock()
+    if err != nil {
+      tmp.Close()
+      return err
+    }
+    rec := encodeRecord(kindPut, k, value)
+    if _, err := tmp.Write(rec); err != nil {
+      tmp.Close()
+      return err
+    }
+    moved[k] = location{target, offset + recordHeader + int64(len(k)), uint32(len(value))}
+    offset += int64(len(rec))
+  }
+  if err := tmp.Sync(); err != nil {
+    tmp.Close()
+    return err
+  }
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  if err := os.Rename(tmpPath, segmentPath(s.dir, target)); err != nil {
+    tmp.Close()
+    return err
+  }
+  for _, id := range old {
+    s.segments[id].file.Close()
+    delete(s.segments, id)
+  }
+  s.segments[target] = &segment{id: target, file: tmp, size: offset}
+  for k, loc := range moved {
+    if cur, ok := s.index[k]; ok && cur == live[k] {
+      s.index[k] = loc
+    }
+  }
+  for _, id := range old[:len(old)-1] {
+    os.Remove(segmentPath(s.dir, id))
+  }
+  for _, id := range old {
+    delete(s.stale, id)
+  }
+  // Values overwritten while the copy ran are already garbage.
+  for k, loc := range moved {
+    if s.index[k] != loc {
+      s.stale[target] += recordHeader + int64(len(k)) + int64(loc.size)
+    }
+  }
+  return nil
+}
+
+// Compact in the background whenever at least ratio of the bytes on disk
+// are garbage, checking every interval.
+func (s *store) autoCompact(interval time.Duration, ratio float64, logf func(string, ...any)) {
+  s.wg.Add(1)
+  go func() {
+    defer s.wg.Done()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+      select {
+      case <-s.done:
+        return
+      case <-ticker.C:
+      }
+      _, _, total, stale := s.stats()
+      if total == 0 || float64(stale)/float64(total) < ratio {
+        continue
+      }
+      if err := s.compact(); err != nil {
+        logf("compaction failed: %v", err)
+      } else {
+        _, _, after, _ := s.stats()
+        logf("compacted %d bytes to %d", total, after)
+      }
+    }
+  }()
+}
+
+// Stats for deciding when to compact.
+func (s *store) stats() (keys, segments int, total, stale int64) {
+  s.mu.RLock()
+  defer s.mu.RUnlock()
+  for _, seg := range s.segments {
+    total += seg.size
+  }
+  for _, n := range s.stale {
+    stale += n
+  }
+  return len(s.index), len(s.segments), total, stale
+}
+
+func (s *store) close() error {
+  close(s.done)
+  s.wg.Wait()
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  var first error
+  for _, seg := range s.segments {
+    if err := seg.file.Close(); err != nil && first == nil {
+      first = err
+    }
+  }
+  return first
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+  "sort"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+// Segments this small roll over every couple of records.
+const testSegment = 64
+
+func open(t *testing.T, dir string) *store {
+  t.Helper()
+  s, err := openStore(dir, testSegment, false)
+  testx.NoError(t, err)
+  return s
+}
+
+// Every key and value in the store.
+func contents(t *testing.T, s *store) map[string]string {
+  t.Helper()
+  got := map[string]string{}
+  it := s.iterate("", "")
+  for it.next() {
+    got[it.key()] = string(it.value())
+  }
+  testx.NoError(t, it.err())
+  return got
+}
+
+func check(t *testing.T, s *store, want map[string]string) {
+  t.Helper()
+  got := contents(t, s)
+  if len(got) != len(want) {
+    t.Errorf("store holds %v, want %v", got, want)
+    return
+  }
+  for k, v := range want {
+    if got[k] != v {
+      t.Errorf("%s = %q, want %q", k, got[k], v)
+    }
+  }
+}
+
+// The segment ids on disk.
+func segmentIDs(t *testing.T, dir string) []int {
+  t.Helper()
+  names, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+  testx.NoError(t, err)
+  var ids []int
+  for _, name := range names {
+    var id int
+    fmt.Sscanf(filepath.Base(name), "%d.wal", &id)
+    ids = append(ids, id)
+  }
+  sort.Ints(ids)
+  return ids
+}
+
+// Fill a store with overwrites and deletes spread over several segments,
+// returning what it should hold.
+func fill(t *testing.T, s *store) map[string]string {
+  t.Helper()
+  want := map[string]string{}
+  for i := range 20 {
+    k, v := fmt.Sprintf("k%d", i%7), fmt.Sprintf("v%d", i)
+    testx.NoError(t, s.put(k, []byte(v)))
+    want[k] = v
+  }
+  for _, k := range []string{"k1", "k4"} {
+    testx.NoError(t, s.delete(k))
+    delete(want, k)
+  }
+  return want
+}
+
+func TestReopen(t *testing.T) {
+  dir := t.TempDir()
+  s := open(t, dir)
+  want := fill(t, s)
+  if len(segmentIDs(t, dir)) < 3 {
+    t.Fatalf("only segments %v were written", segmentIDs(t, dir))
+  }
+  testx.NoError(t, s.close())
+
+  s = open(t, dir)
+  check(t, s, want)
+  if _, err := s.get("k1"); err != errNotFound {
+    t.Errorf("deleted key: got error %v, want %v", err, errNotFound)
+  }
+  // Writing after a reopen carries on in the same files.
+  testx.NoError(t, s.put("new", []byte("value")))
+  want["new"] = "value"
+  testx.NoError(t, s.close())
+
+  s = open(t, dir)
+  defer s.close()
+  check(t, s, want)
+}
+
+func TestTornTail(t *testing.T) {
+  dir := t.TempDir()
+  s := open(t, dir)
+  want := fill(t, s)
+  testx.NoError(t, s.put("torn", []byte("half written")))
+  last := s.active.id
+  good := s.active.size - int64(len(encodeRecord(kindPut, "torn", []byte("half written"))))
+  testx.NoError(t, s.close())
+
+  // Cut the last record short, as a crash in the middle of writing it
+  // would.
+  path := segmentPath(dir, last)
+  info, err := os.Stat(path)
+  testx.NoError(t, err)
+  testx.NoError(t, os.Truncate(path, info.Size()-5))
+
+  s = open(t, dir)
+  check(t, s, want)
+  // The partial record is gone from the file, so new records follow the
+  // good ones and survive another reopen.
+  info, err = os.Stat(path)
+  testx.NoError(t, err)
+  testx.Equal(t, info.Size(), good)
+  testx.NoError(t, s.put("after", []byte("crash")))
+  want["after"] = "crash"
+  testx.NoError(t, s.close())
+
+  s = open(t, dir)
+  defer s.close()
+  check(t, s, want)
+}
+
+func TestCorruptOlderSegment(t *testing.T) {
+  dir := t.TempDir()
+  s := open(t, dir)
+  fill(t, s)
+  first := segmentIDs(t, dir)[0]
+  testx.NoError(t, s.close())
+
+  // Only the newest segment can have been cut short by a crash; damage
+  // anywhere else is reported rather than silently dropped.
+  path := segmentPath(dir, first)
+  info, err := os.Stat(path)
+  testx.NoError(t, err)
+  testx.NoError(t, os.Truncate(path, info.Size()-5))
+  if s, err := openStore(dir, testSegment, false); err == nil {
+    s.close()
+    t.Fatal("opened a store with a damaged older segment")
+  }
+}
+
+func TestCompact(t *testing.T) {
+  dir := t.TempDir()
+  s := open(t, dir)
+  want := fill(t, s)
+  _, before, total, _ := s.stats()
+  testx.NoError(t, s.compact())
+  _, after, compacted, _ := s.stats()
+  if after >= before || compacted >= total {
+    t.Errorf("compaction left %d segments of %d and %d bytes of %d", after, before, compacted, total)
+  }
+  // Only garbage in the active segment, which is not compacted, is left.
+  for id, n := range s.stale {
+    if id != s.active.id && n != 0 {
+      t.Errorf("segment %d still has %d stale bytes", id, n)
+    }
+  }
+  check(t, s, want)
+  testx.NoError(t, s.close())
+
+  s = open(t, dir)
+  defer s.close()
+  check(t, s, want)
+}
+
+// A crash after the compacted segment is renamed into place but before the
+// segments it replaces are deleted leaves those on disk. They hold values
+// since overwritten and keys since deleted, none of which may come back.
+func TestCrashAfterCompactionRename(t *testing.T) {
+  dir := t.TempDir()
+  s := open(t, dir)
+  want := fill(t, s)
+  saved := map[string][]byte{}
+  for _, id := range segmentIDs(t, dir) {
+    data, err := os.ReadFile(segmentPath(dir, id))
+    testx.NoError(t, err)
+    saved[segmentPath(dir, id)] = data
+  }
+  testx.NoError(t, s.compact())
+  testx.NoError(t, s.close())
+
+  restored := 0
+  for path, data := range saved {
+    if _, err := os.Stat(path); os.IsNotExist(err) {
+      testx.NoError(t, os.WriteFile(path, data, 0o644))
+      restored++
+    }
+  }
+  if restored == 0 {
+    t.Fatal("compaction deleted no segments")
+  }
+
+  s = open(t, dir)
+  check(t, s, want)
+  testx.NoError(t, s.close())
+  // The leftovers are cleaned up on open.
+  if n := len(segmentIDs(t, dir)); n >= len(saved) {
+    t.Errorf("%d segments left after reopening, had %d before compacting", n, len(saved))
+  }
+}
+
+// A crash before the rename leaves only the half-built copy, which is not
+// a segment.
+func TestCrashBeforeCompactionRename(t *testing.T) {
+  dir := t.TempDir()
+  s := open(t, dir)
+  want := fill(t, s)
+  testx.NoError(t, s.close())
+  junk := segmentPath(dir, segmentIDs(t, dir)[0]) + ".compact"
+  testx.NoError(t, os.WriteFile(junk, encodeRecord(kindCompacted, "", nil), 0o644))
+
+  s = open(t, dir)
+  defer s.close()
+  check(t, s, want)
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "image"
+  "image/color"
+  "image/gif"
+  "math/rand"
+  "os"
+  "sort"
+  "strings"
+  "time"
+
+  "github.com/nixternal/CodingChallenges/pkg/automaton"
+)
+
+// Characters and colours for each state when drawing.
+var (
+  glyphs  = []string{" ", "█", "▓", "▒", "░", "#", "+", "*"}
+  palette = color.Palette{
+    color.RGBA{0x10, 0x10, 0x18, 0xff},
+    color.RGBA{0x60, 0xd0, 0x60, 0xff},
+    color.RGBA{0xb0, 0x70, 0x30, 0xff},
+    color.RGBA{0x40, 0x80, 0xe0, 0xff},
+    color.RGBA{0xe0, 0xe0, 0x40, 0xff},
+    color.RGBA{0xe0, 0x40, 0x40, 0xff},
+    color.RGBA{0xc0, 0x40, 0xe0, 0xff},
+    color.RGBA{0xf0, 0xf0, 0xf0, 0xff},
+  }
+)
+
+// The region drawn for each frame.
+type viewport struct {
+  x, y, w, h int
+}
+
+// Draw a frame for the terminal. Home the cursor and overwrite rather than
+// clearing, so the animation does not flicker.
+func drawTerminal(w *bufio.Writer, g automaton.Grid, v viewport, gen int) {
+  w.WriteString("\x1b[H")
+  for y := v.y; y < v.y+v.h; y++ {
+    for x := v.x; x < v.x+v.w; x++ {
+      w.WriteString(glyphs[g.Get(x, y)])
+    }
+    w.WriteString("\x1b[K\n")
+  }
+  fmt.Fprintf(w, "generation %d, population %d\x1b[K\n", gen, g.Population())
+  w.Flush()
+}
+
+// Render a frame as a paletted image, scale pixels per cell.
+func drawImage(g automaton.Grid, v viewport, scale int) *image.Paletted {
+  img := image.NewPaletted(image.Rect(0, 0, v.w*scale, v.h*scale), palette)
+  for y := 0; y < v.h; y++ {
+    for x := 0; x < v.w; x++ {
+      s := g.Get(v.x+x, v.y+y)
+      if s == 0 {
+        continue
+      }
+      for dy := 0; dy < scale; dy++ {
+        for dx := 0; dx < scale; dx++ {
+          img.SetColorIndex(x*scale+dx, y*scale+dy, s)
+        }
+      }
+    }
+  }
+  return img
+}
+
+// A grid's contents as text, for printing the final generation.
+func dump(g automaton.Grid, v viewport, states string) string {
+  var b strings.Builder
+  for y := v.y; y < v.y+v.h; y++ {
+    for x := v.x; x < v.x+v.w; x++ {
+      b.WriteByte(states[g.Get(x, y)])
+    }
+    b.WriteByte('\n')
+  }
+  return b.String()
+}
+
+func main() {
+  ruleFlag := flag.String("rule", "B3/S23", "Life-like rule in B/S notation, or \"lumber\" for AoC 2018 day 18")
+  nbFlag := flag.String("neighborhood", "moore", "moore or vonneumann, with an optional :radius")
+  backend := flag.String("backend", "dense", "dense or sparse grid storage")
+  edgeFlag := flag.String("edges", "toroidal", "toroidal, bounded or (sparse only) unbounded")
+  size := flag.String("size", "", "grid size WxH (default: fits the pattern, or 80x40)")
+  patternFlag := flag.String("pattern", "", "built-in pattern name or a .rle/plaintext file")
+  states := flag.String("states", "", "characters for each state in plaintext files and -print (default .O, or .|# for lumber)")
+  density := flag.Float64("random", 0.3, "fill density when no pattern is given")
+  seed := flag.Int64("seed", 0, "random seed (default: time based)")
+  gens := flag.Int("n", 0, "number of generations (default: run until interrupted, or 100 for -gif and -print)")
+  delay := flag.Duration("delay", 100*time.Millisecond, "time between frames")
+  gifOut := flag.String("gif", "", "write an animated GIF to this file instead of animating")
+  scale := flag.Int("scale", 4, "GIF pixels per cell")
+  printFinal := flag.Bool("print", false, "print only the final generation and its population")
+  list := flag.Bool("list", false, "list built-in patterns")
+  flag.Parse()
+
+  fail := func(err error) {
+    fmt.Fprintln(os.Stderr, "life:", err)
+    os.Exit(1)
+  }
+  if *list {
+    var names []string
+    for name := range builtins {
+      names = append(names, name)
+    }
+    sort.Strings(names)
+    fmt.Println(strings.Join(names, "\n"))
+    return
+  }
+
+  var r automaton.Rule
+  if *ruleFlag == "lumber" {
+    r = automaton.Lumber{}
+    if *states == "" {
+      *states = ".|#"
+    }
+  } else {
+    lr, err := automaton.ParseLifeRule(*ruleFlag)
+    if err != nil {
+      fail(err)
+    }
+    r = lr
+  }
+  if *states == "" {
+    *states = ".O"
+  }
+  if len(*states) < r.States() {
+    fail(fmt.Errorf("-states needs a character for each of %d states", r.States()))
+  }
+  nb, err := automaton.ParseNeighborhood(*nbFlag)
+  if err != nil {
+    fail(err)
+  }
+
+  var p *pattern
+  if *patternFlag != "" {
+    if p, err = loadPattern(*patternFlag, *states); err != nil {
+      fail(err)
+    }
+  }
+
+  w, h := 80, 40
+  if *size != "" {
+    if _, err := fmt.Sscanf(*size, "%dx%d", &w, &h); err != nil || w < 1 || h < 1 {
+      fail(fmt.Errorf("bad -size %q", *size))
+    }
+  } else if p != nil && p.exact {
+    w, h = p.width, p.height
+  } else if p != nil {
+    // Leave room around small patterns so they have space to evolve.
+    w, h = max(p.width+20, 80), max(p.height+20, 40)
+  }
+
+  var e automaton.Edges
+  switch *edgeFlag {
+  case "toroidal":
+    e = automaton.Toroidal
+  case "bounded":
+    e = automaton.Bounded
+  case "unbounded":
+    e = automaton.Unbounded
+    if *backend != "sparse" {
+      fail(fmt.Errorf("unbounded edges need -backend sparse"))
+    }
+  default:
+    fail(fmt.Errorf("unknown edges %q", *edgeFlag))
+  }
+
+  var g automaton.Grid
+  switch *backend {
+  case "dense":
+    g = automaton.NewDense(w, h, e)
+  case "sparse":
+    if err := automaton.CheckSparse(r, nb); err != nil {
+      fail(err)
+    }
+    g = automaton.NewSparse(w, h, e)
+  default:
+    fail(fmt.Errorf("unknown backend %q", *backend))
+  }
+
+  // Centre the pattern; a picture of the whole grid lands at the origin.
+  if p != nil {
+    ox, oy := (w-p.width)/2, (h-p.height)/2
+    for c, s := range p.cells {
+      if int(s) >= r.States() {
+        fail(fmt.Errorf("pattern uses state %d but the rule has %d", s, r.States()))
+      }
+      g.Set(c.X+ox, c.Y+oy, s)
+    }
+  } else {
+    if *seed == 0 {
+      *seed = time.Now().UnixNano()
+    }
+    rng := rand.New(rand.NewSource(*seed))
+    for y := 0; y < h; y++ {
+      for x := 0; x < w; x++ {
+        if rng.Float64() < *density {
+          g.Set(x, y, uint8(1+rng.Intn(r.States()-1)))
+        }
+      }
+    }
+  }
+  view := viewport{0, 0, w, h}
+
+  if *gifOut != "" || *printFinal {
+    if *gens == 0 {
+      *gens = 100
+    }
+    anim := &gif.GIF{}
+    for gen := 0; gen <= *gens; gen++ {
+      if *gifOut != "" {
+        anim.Image = append(anim.Image, drawImage(g, view, *scale))
+        anim.Delay = append(anim.Delay, int(*delay/(10*time.Millisecond)))
+      }
+      if gen < *gens {
+        g = g.Step(r, nb)
+      }
+    }
+    if *printFinal {
+      if e != automaton.Unbounded {
+        fmt.Print(dump(g, view, *states))
+      }
+      fmt.Println("population", g.Population())
+    }
+    if *gifOut != "" {
+      f, err := os.Create(*gifOut)
+      if err != nil {
+        fail(err)
+      }
+      if err := gif.EncodeAll(f, anim); err != nil {
+        fail(err)
+      }
+      if err := f.Close(); err != nil {
+        fail(err)
+      }
+    }
+    return
+  }
+
+  out := bufio.NewWriter(os.Stdout)
+  out.WriteString("\x1b[2J")
+  for gen := 0; *gens == 0 || gen <= *gens; gen++ {
+    drawTerminal(out, g, view, gen)
+    g = g.Step(r, nb)
+    time.Sleep(*delay)
+  }
+}
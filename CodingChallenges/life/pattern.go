@@ -0,0 +1,128 @@
+package main
+
+import (
+  "fmt"
+  "os"
+  "strconv"
+  "strings"
+  "unicode"
+
+  "github.com/nixternal/CodingChallenges/pkg/grid"
+)
+
+// A pattern is a list of cells relative to its top-left corner.
+// Plaintext pictures describe a whole grid, so exact is set for them.
+type pattern struct {
+  cells         map[grid.Point]uint8
+  width, height int
+  exact         bool
+}
+
+func (p *pattern) add(x, y int, s uint8) {
+  if s == 0 {
+    return
+  }
+  p.cells[grid.Point{X: x, Y: y}] = s
+  p.width = max(p.width, x+1)
+  p.height = max(p.height, y+1)
+}
+
+// A few well-known patterns, in RLE.
+var builtins = map[string]string{
+  "glider":     "bo$2bo$3o!",
+  "blinker":    "3o!",
+  "lwss":       "bo2bo$o4b$o3bo$4o!",
+  "rpentomino": "b2o$2ob$bo!",
+  "acorn":      "bo5b$3bo3b$2o2b3o!",
+  "pulsar":     "2b3o3b3o2b2$o4bobo4bo$o4bobo4bo$o4bobo4bo$2b3o3b3o2b2$2b3o3b3o2b$o4bobo4bo$o4bobo4bo$o4bobo4bo2$2b3o3b3o!",
+  "gosper":     "24bo11b$22bobo11b$12b2o6b2o12b2o$11bo3bo4b2o12b2o$2o8bo5bo3b2o14b$2o8bo3bob2o4bobo11b$10bo5bo7bo11b$11bo3bo20b$12b2o!",
+}
+
+// Parse run-length encoded cells: "b" or "." is dead, "o" alive, "$" ends a
+// row and "!" the pattern, each optionally preceded by a repeat count.
+// Multi-state letters A-X are states 1-24 as in Golly.
+func parseRLE(data string) (*pattern, error) {
+  p := &pattern{cells: map[grid.Point]uint8{}}
+  x, y := 0, 0
+  count := ""
+  for _, line := range strings.Split(data, "\n") {
+    line = strings.TrimSpace(line)
+    if line == "" || line[0] == '#' || strings.HasPrefix(line, "x ") || strings.HasPrefix(line, "x=") {
+      continue
+    }
+    for _, c := range line {
+      n := 1
+      if unicode.IsDigit(c) {
+        count += string(c)
+        continue
+      }
+      if count != "" {
+        n, _ = strconv.Atoi(count)
+        count = ""
+      }
+      switch {
+      case c == 'b' || c == '.':
+        x += n
+      case c == 'o' || c >= 'A' && c <= 'X':
+        s := uint8(1)
+        if c != 'o' {
+          s = uint8(c-'A') + 1
+        }
+        for i := 0; i < n; i++ {
+          p.add(x, y, s)
+          x++
+        }
+      case c == '$':
+        y += n
+        x = 0
+      case c == '!':
+        return p, nil
+      case unicode.IsSpace(c):
+      default:
+        return nil, fmt.Errorf("unexpected %q in RLE", c)
+      }
+    }
+  }
+  return p, nil
+}
+
+// Parse a picture of the grid, one row per line, where each character in
+// states stands for the state at that index. Lines starting with "!" are
+// comments, as in the plaintext .cells format.
+func parsePlain(data, states string) (*pattern, error) {
+  p := &pattern{cells: map[grid.Point]uint8{}, exact: true}
+  y := 0
+  for _, line := range strings.Split(strings.TrimRight(data, "\n"), "\n") {
+    line = strings.TrimRight(line, "\r")
+    if strings.HasPrefix(line, "!") {
+      continue
+    }
+    p.width = max(p.width, len([]rune(line)))
+    for x, c := range []rune(line) {
+      s := strings.IndexRune(states, c)
+      if s < 0 {
+        return nil, fmt.Errorf("line %d: unexpected %q", y+1, c)
+      }
+      p.add(x, y, uint8(s))
+    }
+    y++
+  }
+  p.height = max(p.height, y)
+  return p, nil
+}
+
+// Load a built-in pattern by name or a pattern file. Files ending in .rle
+// are run-length encoded; anything else is a plaintext picture.
+func loadPattern(name, states string) (*pattern, error) {
+  if rle, ok := builtins[name]; ok {
+    return parseRLE(rle)
+  }
+  data, err := os.ReadFile(name)
+  if err != nil {
+    return nil, err
+  }
+  if strings.HasSuffix(name, ".rle") {
+    return parseRLE(string(data))
+  }
+  return parsePlain(string(data), states)
+}
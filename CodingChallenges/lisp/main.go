@@ -0,0 +1,659 @@
+package main
+
+import (
+  "bufio"
+  "errors"
+  "fmt"
+  "os"
+  "strconv"
+  "strings"
+)
+
+// Values are represented with plain Go types: float64 numbers, symbol
+// strings, bool, *cell pairs (nil is the empty list), and callables.
+type symbol string
+
+type cell struct {
+  car interface{}
+  cdr interface{}
+}
+
+type builtin func(args []interface{}) (interface{}, error)
+
+// A user defined procedure closes over the environment it was created in.
+type lambda struct {
+  params []symbol
+  rest   symbol
+  body   []interface{}
+  env    *env
+}
+
+// Lexical environments chain to their enclosing scope.
+type env struct {
+  vars  map[symbol]interface{}
+  outer *env
+}
+
+func newEnv(outer *env) *env {
+  return &env{vars: map[symbol]interface{}{}, outer: outer}
+}
+
+func (e *env) lookup(s symbol) (interface{}, error) {
+  for cur := e; cur != nil; cur = cur.outer {
+    if v, ok := cur.vars[s]; ok {
+      return v, nil
+    }
+  }
+  return nil, fmt.Errorf("unbound symbol: %s", s)
+}
+
+func (e *env) set(s symbol, v interface{}) error {
+  for cur := e; cur != nil; cur = cur.outer {
+    if _, ok := cur.vars[s]; ok {
+      cur.vars[s] = v
+      return nil
+    }
+  }
+  return fmt.Errorf("unbound symbol: %s", s)
+}
+
+// Split source text into tokens: parentheses, quote marks and atoms.
+// Comments run from ";" to the end of the line.
+func tokenize(src string) []string {
+  var tokens []string
+  for i := 0; i < len(src); {
+    c := src[i]
+    switch {
+    case c == ';':
+      for i < len(src) && src[i] != '\n' {
+        i++
+      }
+    case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+      i++
+    case c == '(' || c == ')' || c == '\'':
+      tokens = append(tokens, string(c))
+      i++
+    default:
+      j := i
+      for j < len(src) && !strings.ContainsRune(" \t\r\n()';", rune(src[j])) {
+        j++
+      }
+      tokens = append(tokens, src[i:j])
+      i = j
+    }
+  }
+  return tokens
+}
+
+var errIncomplete = errors.New("incomplete expression")
+
+// Read one expression from the token stream, returning it and the remaining
+// tokens. 'x is read as (quote x).
+func read(tokens []string) (interface{}, []string, error) {
+  if len(tokens) == 0 {
+    return nil, nil, errIncomplete
+  }
+  tok, rest := tokens[0], tokens[1:]
+  switch tok {
+  case "(":
+    var items []interface{}
+    for {
+      if len(rest) == 0 {
+        return nil, nil, errIncomplete
+      }
+      if rest[0] == ")" {
+        return list(items...), rest[1:], nil
+      }
+      item, r, err := read(rest)
+      if err != nil {
+        return nil, nil, err
+      }
+      items = append(items, item)
+      rest = r
+    }
+  case ")":
+    return nil, nil, errors.New("unexpected )")
+  case "'":
+    item, r, err := read(rest)
+    if err != nil {
+      return nil, nil, err
+    }
+    return list(symbol("quote"), item), r, nil
+  }
+  if n, err := strconv.ParseFloat(tok, 64); err == nil {
+    return n, rest, nil
+  }
+  switch tok {
+  case "#t":
+    return true, rest, nil
+  case "#f":
+    return false, rest, nil
+  }
+  return symbol(tok), rest, nil
+}
+
+func list(items ...interface{}) interface{} {
+  var out interface{}
+  for i := len(items) - 1; i >= 0; i-- {
+    out = &cell{items[i], out}
+  }
+  return out
+}
+
+// Convert a proper list into a slice.
+func toSlice(v interface{}) ([]interface{}, error) {
+  var out []interface{}
+  for v != nil {
+    c, ok := v.(*cell)
+    if !ok {
+      return nil, errors.New("not a proper list")
+    }
+    out = append(out, c.car)
+    v = c.cdr
+  }
+  return out, nil
+}
+
+func truthy(v interface{}) bool {
+  b, ok := v.(bool)
+  return !ok || b
+}
+
+// Evaluate an expression. Tail calls in if, begin, let bodies and procedure
+// bodies loop instead of recursing so deep recursion in Lisp code does not
+// grow the Go stack.
+func eval(x interface{}, e *env) (interface{}, error) {
+  for {
+    switch v := x.(type) {
+    case symbol:
+      return e.lookup(v)
+    case *cell:
+    default:
+      return x, nil
+    }
+
+    form, err := toSlice(x)
+    if err != nil {
+      return nil, err
+    }
+    if s, ok := form[0].(symbol); ok {
+      switch s {
+      case "quote":
+        if len(form) != 2 {
+          return nil, errors.New("quote: expected 1 argument")
+        }
+        return form[1], nil
+      case "if":
+        if len(form) < 3 || len(form) > 4 {
+          return nil, errors.New("if: expected (if test then [else])")
+        }
+        test, err := eval(form[1], e)
+        if err != nil {
+          return nil, err
+        }
+        if truthy(test) {
+          x = form[2]
+        } else if len(form) == 4 {
+          x = form[3]
+        } else {
+          return false, nil
+        }
+        continue
+      case "define":
+        if len(form) < 3 {
+          return nil, errors.New("define: expected a name and a value")
+        }
+        // (define (name args...) body...) is sugar for a lambda.
+        if sig, ok := form[1].(*cell); ok {
+          name, ok := sig.car.(symbol)
+          if !ok {
+            return nil, errors.New("define: bad procedure name")
+          }
+          fn, err := makeLambda(sig.cdr, form[2:], e)
+          if err != nil {
+            return nil, err
+          }
+          e.vars[name] = fn
+          return name, nil
+        }
+        name, ok := form[1].(symbol)
+        if !ok {
+          return nil, errors.New("define: expected a symbol")
+        }
+        val, err := eval(form[2], e)
+        if err != nil {
+          return nil, err
+        }
+        e.vars[name] = val
+        return name, nil
+      case "set!":
+        if len(form) != 3 {
+          return nil, errors.New("set!: expected a name and a value")
+        }
+        name, ok := form[1].(symbol)
+        if !ok {
+          return nil, errors.New("set!: expected a symbol")
+        }
+        val, err := eval(form[2], e)
+        if err != nil {
+          return nil, err
+        }
+        return val, e.set(name, val)
+      case "lambda":
+        if len(form) < 3 {
+          return nil, errors.New("lambda: expected parameters and a body")
+        }
+        return makeLambda(form[1], form[2:], e)
+      case "begin":
+        if len(form) == 1 {
+          return nil, nil
+        }
+        for _, expr := range form[1 : len(form)-1] {
+          if _, err := eval(expr, e); err != nil {
+            return nil, err
+          }
+        }
+        x = form[len(form)-1]
+        continue
+      case "let":
+        if len(form) < 3 {
+          return nil, errors.New("let: expected bindings and a body")
+        }
+        bindings, err := toSlice(form[1])
+        if err != nil {
+          return nil, err
+        }
+        inner := newEnv(e)
+        for _, b := range bindings {
+          pair, err := toSlice(b)
+          if err != nil || len(pair) != 2 {
+            return nil, errors.New("let: bad binding")
+          }
+          name, ok := pair[0].(symbol)
+          if !ok {
+            return nil, errors.New("let: bad binding name")
+          }
+          if inner.vars[name], err = eval(pair[1], e); err != nil {
+            return nil, err
+          }
+        }
+        for _, expr := range form[2 : len(form)-1] {
+          if _, err := eval(expr, inner); err != nil {
+            return nil, err
+          }
+        }
+        x, e = form[len(form)-1], inner
+        continue
+      case "and", "or":
+        var result interface{} = s == "and"
+        for _, expr := range form[1:] {
+          if result, err = eval(expr, e); err != nil {
+            return nil, err
+          }
+          if truthy(result) != (s == "and") {
+            break
+          }
+        }
+        return result, nil
+      }
+    }
+
+    fn, err := eval(form[0], e)
+    if err != nil {
+      return nil, err
+    }
+    args := make([]interface{}, len(form)-1)
+    for i, a := range form[1:] {
+      if args[i], err = eval(a, e); err != nil {
+        return nil, err
+      }
+    }
+    switch f := fn.(type) {
+    case builtin:
+      return f(args)
+    case *lambda:
+      inner, err := f.bind(args)
+      if err != nil {
+        return nil, err
+      }
+      for _, expr := range f.body[:len(f.body)-1] {
+        if _, err := eval(expr, inner); err != nil {
+          return nil, err
+        }
+      }
+      x, e = f.body[len(f.body)-1], inner
+    default:
+      return nil, fmt.Errorf("not a procedure: %s", show(fn))
+    }
+  }
+}
+
+// Build a lambda from a parameter list, which may be improper to collect the
+// remaining arguments: (lambda (a . rest) ...) or (lambda args ...).
+func makeLambda(params interface{}, body []interface{}, e *env) (*lambda, error) {
+  fn := &lambda{body: body, env: e}
+  for params != nil {
+    switch p := params.(type) {
+    case symbol:
+      fn.rest = p
+      return fn, nil
+    case *cell:
+      name, ok := p.car.(symbol)
+      if !ok {
+        return nil, errors.New("lambda: parameters must be symbols")
+      }
+      fn.params = append(fn.params, name)
+      params = p.cdr
+    default:
+      return nil, errors.New("lambda: bad parameter list")
+    }
+  }
+  return fn, nil
+}
+
+func (f *lambda) bind(args []interface{}) (*env, error) {
+  if len(args) < len(f.params) || (f.rest == "" && len(args) != len(f.params)) {
+    return nil, fmt.Errorf("expected %d arguments, got %d", len(f.params), len(args))
+  }
+  inner := newEnv(f.env)
+  for i, p := range f.params {
+    inner.vars[p] = args[i]
+  }
+  if f.rest != "" {
+    inner.vars[f.rest] = list(args[len(f.params):]...)
+  }
+  return inner, nil
+}
+
+// Apply any procedure to already evaluated arguments, used by builtins such
+// as map and apply that call back into Lisp code.
+func apply(fn interface{}, args []interface{}) (interface{}, error) {
+  switch f := fn.(type) {
+  case builtin:
+    return f(args)
+  case *lambda:
+    inner, err := f.bind(args)
+    if err != nil {
+      return nil, err
+    }
+    var result interface{}
+    for _, expr := range f.body {
+      if result, err = eval(expr, inner); err != nil {
+        return nil, err
+      }
+    }
+    return result, nil
+  }
+  return nil, fmt.Errorf("not a procedure: %s", show(fn))
+}
+
+// Render a value the way the REPL prints it.
+func show(v interface{}) string {
+  switch x := v.(type) {
+  case nil:
+    return "()"
+  case float64:
+    if x == float64(int64(x)) && x < 1e15 && x > -1e15 {
+      return strconv.FormatInt(int64(x), 10)
+    }
+    return strconv.FormatFloat(x, 'g', -1, 64)
+  case bool:
+    if x {
+      return "#t"
+    }
+    return "#f"
+  case symbol:
+    return string(x)
+  case *cell:
+    var parts []string
+    var cur interface{} = x
+    for {
+      c, ok := cur.(*cell)
+      if !ok {
+        break
+      }
+      parts = append(parts, show(c.car))
+      cur = c.cdr
+    }
+    if cur != nil {
+      parts = append(parts, ".", show(cur))
+    }
+    return "(" + strings.Join(parts, " ") + ")"
+  case builtin:
+    return "#<builtin>"
+  case *lambda:
+    return "#<lambda>"
+  }
+  return fmt.Sprint(v)
+}
+
+func numbers(name string, args []interface{}) ([]float64, error) {
+  out := make([]float64, len(args))
+  for i, a := range args {
+    n, ok := a.(float64)
+    if !ok {
+      return nil, fmt.Errorf("%s: not a number: %s", name, show(a))
+    }
+    out[i] = n
+  }
+  return out, nil
+}
+
+// Arithmetic fold such as (+ 1 2 3) or (- 10 1 2).
+func arith(name string, identity float64, op func(a, b float64) float64) builtin {
+  return func(args []interface{}) (interface{}, error) {
+    ns, err := numbers(name, args)
+    if err != nil {
+      return nil, err
+    }
+    if len(ns) == 0 {
+      return identity, nil
+    }
+    if len(ns) == 1 {
+      return op(identity, ns[0]), nil
+    }
+    acc := ns[0]
+    for _, n := range ns[1:] {
+      acc = op(acc, n)
+    }
+    return acc, nil
+  }
+}
+
+// Chained comparison such as (< 1 2 3).
+func compare(name string, ok func(a, b float64) bool) builtin {
+  return func(args []interface{}) (interface{}, error) {
+    ns, err := numbers(name, args)
+    if err != nil {
+      return nil, err
+    }
+    for i := 1; i < len(ns); i++ {
+      if !ok(ns[i-1], ns[i]) {
+        return false, nil
+      }
+    }
+    return true, nil
+  }
+}
+
+// The global environment with the numeric and list builtins.
+func globals() *env {
+  e := newEnv(nil)
+  e.vars["+"] = arith("+", 0, func(a, b float64) float64 { return a + b })
+  e.vars["-"] = arith("-", 0, func(a, b float64) float64 { return a - b })
+  e.vars["*"] = arith("*", 1, func(a, b float64) float64 { return a * b })
+  e.vars["/"] = arith("/", 1, func(a, b float64) float64 { return a / b })
+  e.vars["<"] = compare("<", func(a, b float64) bool { return a < b })
+  e.vars[">"] = compare(">", func(a, b float64) bool { return a > b })
+  e.vars["<="] = compare("<=", func(a, b float64) bool { return a <= b })
+  e.vars[">="] = compare(">=", func(a, b float64) bool { return a >= b })
+  e.vars["="] = compare("=", func(a, b float64) bool { return a == b })
+  e.vars["modulo"] = builtin(func(args []interface{}) (interface{}, error) {
+    ns, err := numbers("modulo", args)
+    if err != nil || len(ns) != 2 {
+      return nil, errors.New("modulo: expected 2 numbers")
+    }
+    if int64(ns[1]) == 0 {
+      return nil, errors.New("modulo: division by zero")
+    }
+    m := float64(int64(ns[0]) % int64(ns[1]))
+    if m != 0 && (m < 0) != (ns[1] < 0) {
+      m += ns[1]
+    }
+    return m, nil
+  })
+  e.vars["cons"] = builtin(func(args []interface{}) (interface{}, error) {
+    if len(args) != 2 {
+      return nil, errors.New("cons: expected 2 arguments")
+    }
+    return &cell{args[0], args[1]}, nil
+  })
+  e.vars["car"] = builtin(func(args []interface{}) (interface{}, error) {
+    if len(args) != 1 {
+      return nil, errors.New("car: expected 1 argument")
+    }
+    c, ok := args[0].(*cell)
+    if !ok {
+      return nil, errors.New("car: not a pair")
+    }
+    return c.car, nil
+  })
+  e.vars["cdr"] = builtin(func(args []interface{}) (interface{}, error) {
+    if len(args) != 1 {
+      return nil, errors.New("cdr: expected 1 argument")
+    }
+    c, ok := args[0].(*cell)
+    if !ok {
+      return nil, errors.New("cdr: not a pair")
+    }
+    return c.cdr, nil
+  })
+  e.vars["list"] = builtin(func(args []interface{}) (interface{}, error) {
+    return list(args...), nil
+  })
+  e.vars["null?"] = builtin(func(args []interface{}) (interface{}, error) {
+    return len(args) == 1 && args[0] == nil, nil
+  })
+  e.vars["pair?"] = builtin(func(args []interface{}) (interface{}, error) {
+    if len(args) != 1 {
+      return nil, errors.New("pair?: expected 1 argument")
+    }
+    _, ok := args[0].(*cell)
+    return ok, nil
+  })
+  e.vars["eq?"] = builtin(func(args []interface{}) (interface{}, error) {
+    if len(args) != 2 {
+      return nil, errors.New("eq?: expected 2 arguments")
+    }
+    // A builtin is a Go func, and comparing funcs panics, so no builtin
+    // is eq? to anything, itself included.
+    _, fn1 := args[0].(builtin)
+    _, fn2 := args[1].(builtin)
+    return !fn1 && !fn2 && args[0] == args[1], nil
+  })
+  e.vars["not"] = builtin(func(args []interface{}) (interface{}, error) {
+    return len(args) == 1 && !truthy(args[0]), nil
+  })
+  e.vars["length"] = builtin(func(args []interface{}) (interface{}, error) {
+    if len(args) != 1 {
+      return nil, errors.New("length: expected 1 argument")
+    }
+    items, err := toSlice(args[0])
+    return float64(len(items)), err
+  })
+  e.vars["map"] = builtin(func(args []interface{}) (interface{}, error) {
+    if len(args) != 2 {
+      return nil, errors.New("map: expected a procedure and a list")
+    }
+    items, err := toSlice(args[1])
+    if err != nil {
+      return nil, err
+    }
+    out := make([]interface{}, len(items))
+    for i, item := range items {
+      if out[i], err = apply(args[0], []interface{}{item}); err != nil {
+        return nil, err
+      }
+    }
+    return list(out...), nil
+  })
+  e.vars["apply"] = builtin(func(args []interface{}) (interface{}, error) {
+    if len(args) != 2 {
+      return nil, errors.New("apply: expected a procedure and a list")
+    }
+    items, err := toSlice(args[1])
+    if err != nil {
+      return nil, err
+    }
+    return apply(args[0], items)
+  })
+  e.vars["display"] = builtin(func(args []interface{}) (interface{}, error) {
+    for _, a := range args {
+      fmt.Print(show(a))
+    }
+    return nil, nil
+  })
+  e.vars["newline"] = builtin(func(args []interface{}) (interface{}, error) {
+    fmt.Println()
+    return nil, nil
+  })
+  return e
+}
+
+// Evaluate every expression in a source string, returning the last value.
+func run(src string, e *env) (interface{}, error) {
+  tokens := tokenize(src)
+  var result interface{}
+  for len(tokens) > 0 {
+    expr, rest, err := read(tokens)
+    if err != nil {
+      return nil, err
+    }
+    if result, err = eval(expr, e); err != nil {
+      return nil, err
+    }
+    tokens = rest
+  }
+  return result, nil
+}
+
+// Run the files given as arguments, or start a REPL. The REPL keeps reading
+// lines until the parentheses balance so definitions can span lines.
+func main() {
+  e := globals()
+  if len(os.Args) > 1 {
+    for _, name := range os.Args[1:] {
+      src, err := os.ReadFile(name)
+      if err != nil {
+        fmt.Fprintln(os.Stderr, "lisp:", err)
+        os.Exit(1)
+      }
+      if _, err := run(string(src), e); err != nil {
+        fmt.Fprintln(os.Stderr, "lisp:", err)
+        os.Exit(1)
+      }
+    }
+    return
+  }
+
+  scanner := bufio.NewScanner(os.Stdin)
+  pending := ""
+  fmt.Print("lisp> ")
+  for scanner.Scan() {
+    pending += scanner.Text() + "\n"
+    tokens := tokenize(pending)
+    if _, _, err := read(tokens); err == errIncomplete && len(tokens) > 0 {
+      fmt.Print("...   ")
+      continue
+    }
+    result, err := run(pending, e)
+    pending = ""
+    if err != nil {
+      fmt.Println("error:", err)
+    } else {
+      fmt.Println(show(result))
+    }
+    fmt.Print("lisp> ")
+  }
+  fmt.Println()
+}
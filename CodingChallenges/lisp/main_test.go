@@ -0,0 +1,59 @@
+package main
+
+import (
+  "strings"
+  "testing"
+)
+
+func TestRun(t *testing.T) {
+  for _, c := range []struct{ src, want string }{
+    {"(+ 1 2 3)", "6"},
+    {"(- 10 4 1)", "5"},
+    {"(/ 1 4)", "0.25"},
+    {"(modulo 7 3)", "1"},
+    {"(modulo -7 3)", "2"},
+    {"(modulo 7 -3)", "-2"},
+    {"(< 1 2 3)", "#t"},
+    {"(cons 1 (list 2 3))", "(1 2 3)"},
+    {"(cons 1 2)", "(1 . 2)"},
+    {"(car '(a b))", "a"},
+    {"(cdr '(a b))", "(b)"},
+    {"(eq? 'a 'a)", "#t"},
+    {"(eq? '() '())", "#t"},
+    {"(eq? car car)", "#f"},
+    {"(eq? car cdr)", "#f"},
+    {"(eq? car 1)", "#f"},
+    {"(define f (lambda (x) x)) (eq? f f)", "#t"},
+    {"(define (fact n) (if (= n 0) 1 (* n (fact (- n 1))))) (fact 10)", "3628800"},
+    {"(let ((x 2) (y 3)) (* x y))", "6"},
+    {"(map (lambda (x) (* x x)) '(1 2 3))", "(1 4 9)"},
+    {"(apply + '(1 2 3))", "6"},
+    {"(and 1 #f 3)", "#f"},
+    {"(or #f 2)", "2"},
+    {"(length '(1 2 3))", "3"},
+    {"(define n 1) (set! n (+ n 1)) n", "2"},
+  } {
+    got, err := run(c.src, globals())
+    if err != nil {
+      t.Errorf("%s: %v", c.src, err)
+    } else if show(got) != c.want {
+      t.Errorf("%s = %s, want %s", c.src, show(got), c.want)
+    }
+  }
+}
+
+func TestErrors(t *testing.T) {
+  for _, c := range []struct{ src, err string }{
+    {"(modulo 5 0)", "modulo: division by zero"},
+    {"(modulo 5 0.5)", "modulo: division by zero"},
+    {"(modulo 5)", "modulo: expected 2 numbers"},
+    {"(car 1)", "car: not a pair"},
+    {"(eq? 1)", "eq?: expected 2 arguments"},
+    {"(undefined)", "undefined"},
+  } {
+    _, err := run(c.src, globals())
+    if err == nil || !strings.Contains(err.Error(), c.err) {
+      t.Errorf("%s: got %v, want %q", c.src, err, c.err)
+    }
+  }
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+  "regexp"
+  "strconv"
+  "strings"
+)
+
+// The block phase splits the document into a tree of blocks. Text inside
+// headings and paragraphs is kept raw and handled by the inline phase when
+// rendering.
+type blockKind int
+
+const (
+  paragraph blockKind = iota
+  heading
+  codeBlock
+  quote
+  list
+  thematicBreak
+)
+
+type block struct {
+  kind     blockKind
+  level    int      // heading level
+  text     string   // raw inline text, or code
+  info     string   // fenced code info string
+  children []*block // quote contents
+  items    [][]*block
+  ordered  bool
+  start    int
+  tight    bool
+}
+
+var (
+  atxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+  hrLine     = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+  fenceOpen  = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+  setextLine = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+  bulletItem = regexp.MustCompile(`^( {0,3})([-+*])( {1,4}|\t|$)`)
+  orderItem  = regexp.MustCompile(`^( {0,3})(\d{1,9})([.)])( {1,4}|\t|$)`)
+  quoteLine  = regexp.MustCompile(`^ {0,3}> ?`)
+)
+
+func isBlank(line string) bool {
+  return strings.TrimSpace(line) == ""
+}
+
+// Expand tabs to spaces using four-column tab stops, which keeps all the
+// indentation arithmetic below in plain columns.
+func expandTabs(line string) string {
+  if !strings.Contains(line, "\t") {
+    return line
+  }
+  var b strings.Builder
+  col := 0
+  for _, r := range line {
+    if r == '\t' {
+      n := 4 - col%4
+      b.WriteString(strings.Repeat(" ", n))
+      col += n
+      continue
+    }
+    b.WriteRune(r)
+    col++
+  }
+  return b.String()
+}
+
+func indentOf(line string) int {
+  return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// Remove up to n columns of leading spaces.
+func unindent(line string, n int) string {
+  i := 0
+  for i < n && i < len(line) && line[i] == ' ' {
+    i++
+  }
+  return line[i:]
+}
+
+// A list marker found at the start of a line.
+type marker struct {
+  ordered bool
+  char    byte // '-', '+', '*', '.' or ')'
+  start   int
+  width   int // columns up to where the item content starts
+}
+
+func listMarker(line string) (marker, bool) {
+  if m := bulletItem.FindStringSubmatch(line); m != nil {
+    if hrLine.MatchString(line) {
+      return marker{}, false
+    }
+    return markerWidth(marker{char: m[2][0]}, line, len(m[1])+1, m[3]), true
+  }
+  if m := orderItem.FindStringSubmatch(line); m != nil {
+    n, _ := strconv.Atoi(m[2])
+    mk := marker{ordered: true, char: m[3][0], start: n}
+    return markerWidth(mk, line, len(m[1])+len(m[2])+1, m[4]), true
+  }
+  return marker{}, false
+}
+
+// Content starts after the marker and its following spaces, except that an
+// item starting with indented code or a blank line keeps just one space.
+func markerWidth(mk marker, line string, markerEnd int, spaces string) marker {
+  if len(spaces) >= 5 || isBlank(line[markerEnd:]) {
+    mk.width = markerEnd + 1
+  } else {
+    mk.width = markerEnd + len(spaces)
+  }
+  return mk
+}
+
+// Report whether a line would start a block other than a paragraph, which
+// ends a paragraph without a blank line.
+func interruptsParagraph(line string) bool {
+  if atxHeading.MatchString(line) || hrLine.MatchString(line) || fenceOpen.MatchString(line) || quoteLine.MatchString(line) {
+    return true
+  }
+  // Only non-empty bullets or ordered lists starting at 1 may interrupt.
+  if mk, ok := listMarker(line); ok && !isBlank(line[min(mk.width, len(line)):]) {
+    return !mk.ordered || mk.start == 1
+  }
+  return false
+}
+
+// Parse a sequence of lines into blocks.
+func parseBlocks(lines []string) []*block {
+  var blocks []*block
+  for i := 0; i < len(lines); {
+    line := lines[i]
+    switch {
+    case isBlank(line):
+      i++
+
+    case indentOf(line) >= 4:
+      var code []string
+      for i < len(lines) && (isBlank(lines[i]) || indentOf(lines[i]) >= 4) {
+        code = append(code, unindent(lines[i], 4))
+        i++
+      }
+      for len(code) > 0 && isBlank(code[len(code)-1]) {
+        code = code[:len(code)-1]
+      }
+      blocks = append(blocks, &block{kind: codeBlock, text: strings.Join(code, "\n") + "\n"})
+
+    case fenceOpen.MatchString(line):
+      m := fenceOpen.FindStringSubmatch(line)
+      indent, fence, info := len(m[1]), m[2], m[3]
+      var code []string
+      for i++; i < len(lines); i++ {
+        trimmed := strings.TrimSpace(lines[i])
+        if indentOf(lines[i]) < 4 && strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+          i++
+          break
+        }
+        code = append(code, unindent(lines[i], indent))
+      }
+      text := strings.Join(code, "\n")
+      if len(code) > 0 {
+        text += "\n"
+      }
+      lang := ""
+      if f := strings.Fields(info); len(f) > 0 {
+        lang = f[0]
+      }
+      blocks = append(blocks, &block{kind: codeBlock, text: text, info: lang})
+
+    case atxHeading.MatchString(line):
+      m := atxHeading.FindStringSubmatch(line)
+      blocks = append(blocks, &block{kind: heading, level: len(m[1]), text: strings.TrimSpace(m[2])})
+      i++
+
+    case hrLine.MatchString(line):
+      blocks = append(blocks, &block{kind: thematicBreak})
+      i++
+
+    case quoteLine.MatchString(line):
+      var inner []string
+      for i < len(lines) && !isBlank(lines[i]) {
+        if loc := quoteLine.FindStringIndex(lines[i]); loc != nil {
+          inner = append(inner, lines[i][loc[1]:])
+        } else if len(inner) > 0 && !interruptsParagraph(lines[i]) && !isBlank(inner[len(inner)-1]) {
+          inner = append(inner, lines[i]) // lazy continuation
+        } else {
+          break
+        }
+        i++
+      }
+      blocks = append(blocks, &block{kind: quote, children: parseBlocks(inner)})
+
+    default:
+      if mk, ok := listMarker(line); ok {
+        var b *block
+        b, i = parseList(lines, i, mk)
+        blocks = append(blocks, b)
+        continue
+      }
+      var para []string
+      for i < len(lines) && !isBlank(lines[i]) {
+        if len(para) > 0 {
+          if m := setextLine.FindStringSubmatch(lines[i]); m != nil {
+            level := 1
+            if m[1][0] == '-' {
+              level = 2
+            }
+            blocks = append(blocks, &block{kind: heading, level: level, text: strings.TrimSpace(strings.Join(para, "\n"))})
+            para = nil
+            i++
+            break
+          }
+          if interruptsParagraph(lines[i]) {
+            break
+          }
+        }
+        para = append(para, strings.TrimLeft(lines[i], " "))
+        i++
+      }
+      if para != nil {
+        blocks = append(blocks, &block{kind: paragraph, text: strings.TrimRight(strings.Join(para, "\n"), " \t")})
+      }
+    }
+  }
+  return blocks
+}
+
+// Parse a list starting at lines[i]. Each item takes the lines indented at
+// least as far as its content column, plus lazy paragraph continuations.
+// The list continues while items use the same kind of marker. It is loose if
+// any blank line separates items or blocks within an item.
+func parseList(lines []string, i int, first marker) (*block, int) {
+  b := &block{kind: list, ordered: first.ordered, start: first.start, tight: true}
+  for i < len(lines) && sameList(lines[i], first) {
+    mk, _ := listMarker(lines[i])
+    item := []string{contentAfter(lines[i], mk.width)}
+    i++
+    sawBlank := false
+    for i < len(lines) {
+      line := lines[i]
+      switch {
+      case isBlank(line):
+        item = append(item, "")
+        sawBlank = true
+        i++
+        continue
+      case indentOf(line) >= mk.width:
+        item = append(item, unindent(line, mk.width))
+      case isListItem(line):
+        // The next item, of this list or a new one, looked for before a
+        // lazy continuation: an ordered item not starting at 1 cannot
+        // interrupt a paragraph, but it does end an item.
+        goto done
+      case !sawBlank && !interruptsParagraph(line) && !isBlank(item[len(item)-1]):
+        item = append(item, line) // lazy continuation
+      default:
+        goto done
+      }
+      sawBlank = false
+      i++
+    }
+  done:
+    // Trailing blank lines belong between items, not inside this one.
+    trailing := 0
+    for len(item) > 0 && isBlank(item[len(item)-1]) {
+      item = item[:len(item)-1]
+      trailing++
+    }
+    for _, l := range item {
+      if isBlank(l) {
+        b.tight = false
+      }
+    }
+    if trailing > 0 && i < len(lines) && sameList(lines[i], first) {
+      b.tight = false
+    }
+    b.items = append(b.items, parseBlocks(item))
+  }
+  return b, i
+}
+
+func isListItem(line string) bool {
+  _, ok := listMarker(line)
+  return ok
+}
+
+// Whether line starts an item of the same list as first: the same kind
+// of marker, and for ordered lists the same delimiter.
+func sameList(line string, first marker) bool {
+  mk, ok := listMarker(line)
+  return ok && mk.ordered == first.ordered && mk.char == first.char
+}
+
+// Return the text after a list marker whose content starts at column width.
+func contentAfter(line string, width int) string {
+  if width >= len(line) {
+    return ""
+  }
+  return line[width:]
+}
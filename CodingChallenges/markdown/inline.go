@@ -0,0 +1,401 @@
+package main
+
+import (
+  "html"
+  "strings"
+  "unicode"
+  "unicode/utf8"
+)
+
+// The inline phase turns raw text into a list of pieces: literal HTML that
+// is already rendered, and runs of * or _ that may become emphasis. Emphasis
+// is resolved afterwards with the CommonMark delimiter rules.
+type piece struct {
+  html      string
+  delim     byte // '*' or '_' for a delimiter run, 0 otherwise
+  count     int  // delimiters left in the run
+  origCount int
+  canOpen   bool
+  canClose  bool
+}
+
+func isPunct(r rune) bool {
+  return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isASCIIPunct(c byte) bool {
+  return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// Classify a delimiter run by the characters around it. A run is left
+// flanking when it is not followed by whitespace and, if followed by
+// punctuation, is preceded by whitespace or punctuation; right flanking is
+// the mirror image. Underscores are stricter so snake_case stays literal.
+func flanking(text string, start, end int) (canOpen, canClose bool) {
+  before, after := ' ', ' '
+  if start > 0 {
+    before, _ = utf8.DecodeLastRuneInString(text[:start])
+  }
+  if end < len(text) {
+    after, _ = utf8.DecodeRuneInString(text[end:])
+  }
+  left := !unicode.IsSpace(after) && (!isPunct(after) || unicode.IsSpace(before) || isPunct(before))
+  right := !unicode.IsSpace(before) && (!isPunct(before) || unicode.IsSpace(after) || isPunct(after))
+  if text[start] == '_' {
+    return left && (!right || isPunct(before)), right && (!left || isPunct(after))
+  }
+  return left, right
+}
+
+// Find the end of a code span starting with a run of n backticks at i.
+// Returns -1 if there is no closing run of exactly n backticks.
+func codeSpanEnd(text string, i, n int) int {
+  for j := i + n; j < len(text); {
+    if text[j] != '`' {
+      j++
+      continue
+    }
+    k := j
+    for k < len(text) && text[k] == '`' {
+      k++
+    }
+    if k-j == n {
+      return j
+    }
+    j = k
+  }
+  return -1
+}
+
+// Find the "]" that closes the "[" at i, skipping escapes, code spans and
+// nested brackets.
+func closingBracket(text string, i int) int {
+  depth := 0
+  for j := i; j < len(text); j++ {
+    switch text[j] {
+    case '\\':
+      j++
+    case '`':
+      n := 1
+      for j+n < len(text) && text[j+n] == '`' {
+        n++
+      }
+      if end := codeSpanEnd(text, j, n); end >= 0 {
+        j = end + n - 1
+      } else {
+        j += n - 1
+      }
+    case '[':
+      depth++
+    case ']':
+      depth--
+      if depth == 0 {
+        return j
+      }
+    }
+  }
+  return -1
+}
+
+// Parse "(destination "title")" at i, returning the parts and the index
+// after the closing parenthesis.
+func linkTarget(text string, i int) (dest, title string, end int, ok bool) {
+  if i >= len(text) || text[i] != '(' {
+    return "", "", 0, false
+  }
+  j := i + 1
+  skipSpace := func() {
+    for j < len(text) && (text[j] == ' ' || text[j] == '\n') {
+      j++
+    }
+  }
+  skipSpace()
+  if j < len(text) && text[j] == '<' {
+    close := strings.IndexByte(text[j:], '>')
+    if close < 0 {
+      return "", "", 0, false
+    }
+    dest = text[j+1 : j+close]
+    j += close + 1
+  } else {
+    start, depth := j, 0
+    for ; j < len(text) && text[j] != ' ' && text[j] != '\n'; j++ {
+      if text[j] == '\\' && j+1 < len(text) {
+        j++
+      } else if text[j] == '(' {
+        depth++
+      } else if text[j] == ')' {
+        if depth == 0 {
+          break
+        }
+        depth--
+      }
+    }
+    dest = text[start:j]
+  }
+  skipSpace()
+  if j < len(text) && (text[j] == '"' || text[j] == '\'' || text[j] == '(') {
+    closer := text[j]
+    if closer == '(' {
+      closer = ')'
+    }
+    close := strings.IndexByte(text[j+1:], closer)
+    if close < 0 {
+      return "", "", 0, false
+    }
+    title = text[j+1 : j+1+close]
+    j += close + 2
+    skipSpace()
+  }
+  if j >= len(text) || text[j] != ')' {
+    return "", "", 0, false
+  }
+  return unescape(dest), unescape(title), j + 1, true
+}
+
+// Remove backslash escapes from link destinations and titles.
+func unescape(s string) string {
+  var b strings.Builder
+  for i := 0; i < len(s); i++ {
+    if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+      i++
+    }
+    b.WriteByte(s[i])
+  }
+  return b.String()
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// Escape text for HTML the way CommonMark renderers do.
+func escape(s string) string {
+  return htmlEscaper.Replace(s)
+}
+
+// Only safe schemes are allowed in links, so "javascript:" URLs from
+// untrusted documents are neutralised.
+func safeURL(u string) string {
+  lower := strings.ToLower(strings.TrimSpace(u))
+  for _, bad := range []string{"javascript:", "vbscript:", "data:"} {
+    if strings.HasPrefix(lower, bad) && !strings.HasPrefix(lower, "data:image/") {
+      return "#"
+    }
+  }
+  return u
+}
+
+// Render inline markup to HTML.
+func renderInline(text string) string {
+  var pieces []piece
+  var buf strings.Builder
+  flush := func() {
+    if buf.Len() > 0 {
+      pieces = append(pieces, piece{html: buf.String()})
+      buf.Reset()
+    }
+  }
+
+  for i := 0; i < len(text); {
+    c := text[i]
+    switch {
+    case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+      flush()
+      pieces = append(pieces, piece{html: "<br />\n"})
+      i += 2
+
+    case c == '\\' && i+1 < len(text) && isASCIIPunct(text[i+1]):
+      buf.WriteString(escape(text[i+1 : i+2]))
+      i += 2
+
+    case c == '`':
+      n := 1
+      for i+n < len(text) && text[i+n] == '`' {
+        n++
+      }
+      end := codeSpanEnd(text, i, n)
+      if end < 0 {
+        buf.WriteString(text[i : i+n])
+        i += n
+        continue
+      }
+      code := strings.ReplaceAll(text[i+n:end], "\n", " ")
+      if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+        code = code[1 : len(code)-1]
+      }
+      flush()
+      pieces = append(pieces, piece{html: "<code>" + escape(code) + "</code>"})
+      i = end + n
+
+    case c == '*' || c == '_':
+      j := i
+      for j < len(text) && text[j] == c {
+        j++
+      }
+      open, close := flanking(text, i, j)
+      flush()
+      pieces = append(pieces, piece{html: text[i:j], delim: c, count: j - i, origCount: j - i, canOpen: open, canClose: close})
+      i = j
+
+    case c == '!' && i+1 < len(text) && text[i+1] == '[', c == '[':
+      image := c == '!'
+      open := i
+      if image {
+        open++
+      }
+      close := closingBracket(text, open)
+      if close >= 0 {
+        if dest, title, end, ok := linkTarget(text, close+1); ok {
+          flush()
+          label := text[open+1 : close]
+          var h string
+          if image {
+            h = `<img src="` + escape(safeURL(dest)) + `" alt="` + escape(plainText(label)) + `"`
+            if title != "" {
+              h += ` title="` + escape(title) + `"`
+            }
+            h += " />"
+          } else {
+            h = `<a href="` + escape(safeURL(dest)) + `"`
+            if title != "" {
+              h += ` title="` + escape(title) + `"`
+            }
+            h += ">" + renderInline(label) + "</a>"
+          }
+          pieces = append(pieces, piece{html: h})
+          i = end
+          continue
+        }
+      }
+      buf.WriteString(escape(text[i : i+1]))
+      i++
+
+    case c == '<':
+      // Autolinks: <scheme:...> or <user@host>.
+      end := strings.IndexByte(text[i:], '>')
+      if end > 0 {
+        inner := text[i+1 : i+end]
+        scheme, _, hasScheme := strings.Cut(inner, ":")
+        switch {
+        case !strings.ContainsAny(inner, " <\n") && hasScheme && len(scheme) >= 2 && isScheme(scheme):
+          flush()
+          pieces = append(pieces, piece{html: `<a href="` + escape(safeURL(inner)) + `">` + escape(inner) + "</a>"})
+          i += end + 1
+          continue
+        case !strings.ContainsAny(inner, " <\n") && strings.Count(inner, "@") == 1 && !strings.HasPrefix(inner, "@"):
+          flush()
+          pieces = append(pieces, piece{html: `<a href="mailto:` + escape(inner) + `">` + escape(inner) + "</a>"})
+          i += end + 1
+          continue
+        }
+      }
+      buf.WriteString("&lt;")
+      i++
+
+    case c == '\n':
+      // Two or more trailing spaces make a hard line break.
+      s := buf.String()
+      trimmed := strings.TrimRight(s, " ")
+      buf.Reset()
+      buf.WriteString(trimmed)
+      if len(s)-len(trimmed) >= 2 {
+        buf.WriteString("<br />")
+      }
+      buf.WriteByte('\n')
+      i++
+      for i < len(text) && text[i] == ' ' {
+        i++
+      }
+
+    default:
+      r, size := utf8.DecodeRuneInString(text[i:])
+      buf.WriteString(escape(string(r)))
+      i += size
+    }
+  }
+  flush()
+  return resolveEmphasis(pieces)
+}
+
+func isScheme(s string) bool {
+  for i := 0; i < len(s); i++ {
+    c := s[i]
+    if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '.' || c == '-')) {
+      return false
+    }
+  }
+  return true
+}
+
+// Strip markup from a label for use as alt text.
+func plainText(s string) string {
+  h := renderInline(s)
+  var b strings.Builder
+  inTag := false
+  for _, r := range h {
+    switch {
+    case r == '<':
+      inTag = true
+    case r == '>':
+      inTag = false
+    case !inTag:
+      b.WriteRune(r)
+    }
+  }
+  return html.UnescapeString(b.String())
+}
+
+// Match closers with the nearest eligible opener, innermost first. Each
+// match consumes two delimiters from both runs when possible (<strong>),
+// otherwise one (<em>), and everything between becomes rendered HTML.
+// Unmatched delimiters are left as literal text.
+func resolveEmphasis(pieces []piece) string {
+  for c := 0; c < len(pieces); c++ {
+    closer := &pieces[c]
+    if closer.delim == 0 || !closer.canClose || closer.count == 0 {
+      continue
+    }
+    o := c - 1
+    for ; o >= 0; o-- {
+      opener := &pieces[o]
+      if opener.delim != closer.delim || !opener.canOpen || opener.count == 0 {
+        continue
+      }
+      // The "rule of 3": a run that can both open and close only matches
+      // when the combined lengths are not a multiple of three.
+      if (opener.canClose || closer.canOpen) && (opener.origCount+closer.origCount)%3 == 0 &&
+        !(opener.origCount%3 == 0 && closer.origCount%3 == 0) {
+        continue
+      }
+      break
+    }
+    if o < 0 {
+      continue
+    }
+
+    n := 1
+    tag := "em"
+    if pieces[o].count >= 2 && closer.count >= 2 {
+      n, tag = 2, "strong"
+    }
+    inner := render(pieces[o+1 : c])
+    pieces[o].count -= n
+    pieces[c].count -= n
+    wrapped := piece{html: "<" + tag + ">" + inner + "</" + tag + ">"}
+    pieces = append(pieces[:o+1], append([]piece{wrapped}, pieces[c:]...)...)
+    // The closer is now at o+2; revisit it as it may have delimiters left.
+    c = o + 1
+  }
+  return render(pieces)
+}
+
+func render(pieces []piece) string {
+  var b strings.Builder
+  for _, p := range pieces {
+    if p.delim != 0 {
+      b.WriteString(strings.Repeat(string(p.delim), p.count))
+    } else {
+      b.WriteString(p.html)
+    }
+  }
+  return b.String()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "strconv"
+  "strings"
+)
+
+// Render blocks to HTML. In tight lists paragraphs are written without <p>
+// tags, as CommonMark does.
+func renderBlocks(b *strings.Builder, blocks []*block, tight bool) {
+  for _, bl := range blocks {
+    switch bl.kind {
+    case paragraph:
+      if tight {
+        b.WriteString(renderInline(bl.text))
+        b.WriteString("\n")
+      } else {
+        fmt.Fprintf(b, "<p>%s</p>\n", renderInline(bl.text))
+      }
+    case heading:
+      fmt.Fprintf(b, "<h%d>%s</h%d>\n", bl.level, renderInline(bl.text), bl.level)
+    case codeBlock:
+      if bl.info != "" {
+        fmt.Fprintf(b, "<pre><code class=\"language-%s\">", escape(unescape(bl.info)))
+      } else {
+        b.WriteString("<pre><code>")
+      }
+      b.WriteString(escape(bl.text))
+      b.WriteString("</code></pre>\n")
+    case thematicBreak:
+      b.WriteString("<hr />\n")
+    case quote:
+      b.WriteString("<blockquote>\n")
+      renderBlocks(b, bl.children, false)
+      b.WriteString("</blockquote>\n")
+    case list:
+      tag := "ul"
+      if bl.ordered {
+        tag = "ol"
+      }
+      b.WriteString("<" + tag)
+      if bl.ordered && bl.start != 1 {
+        b.WriteString(` start="` + strconv.Itoa(bl.start) + `"`)
+      }
+      b.WriteString(">\n")
+      for _, item := range bl.items {
+        b.WriteString("<li>")
+        if len(item) > 0 && !(bl.tight && item[0].kind == paragraph) {
+          b.WriteString("\n")
+        }
+        var inner strings.Builder
+        renderBlocks(&inner, item, bl.tight)
+        s := inner.String()
+        if bl.tight && len(item) > 0 && item[len(item)-1].kind == paragraph {
+          s = strings.TrimSuffix(s, "\n")
+        }
+        b.WriteString(s)
+        b.WriteString("</li>\n")
+      }
+      b.WriteString("</" + tag + ">\n")
+    }
+  }
+}
+
+// Convert a Markdown document to an HTML fragment.
+func toHTML(doc string) string {
+  lines := strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n")
+  if len(lines) > 0 && lines[len(lines)-1] == "" {
+    lines = lines[:len(lines)-1]
+  }
+  for i, l := range lines {
+    lines[i] = expandTabs(l)
+  }
+  var b strings.Builder
+  renderBlocks(&b, parseBlocks(lines), false)
+  return b.String()
+}
+
+const pageTemplate = `<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+</head>
+<body>
+%s</body>
+</html>
+`
+
+// Convert the named files (or stdin) and write HTML to stdout. With -page a
+// complete document is written instead of a fragment.
+func main() {
+  page := flag.Bool("page", false, "wrap the output in a complete HTML document")
+  title := flag.String("title", "", "document title for -page")
+  flag.Parse()
+
+  var doc strings.Builder
+  if flag.NArg() == 0 {
+    data, err := io.ReadAll(os.Stdin)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "markdown:", err)
+      os.Exit(1)
+    }
+    doc.Write(data)
+  }
+  for _, name := range flag.Args() {
+    data, err := os.ReadFile(name)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "markdown:", err)
+      os.Exit(1)
+    }
+    doc.Write(data)
+    if len(data) > 0 && data[len(data)-1] != '\n' {
+      doc.WriteString("\n")
+    }
+  }
+
+  out := toHTML(doc.String())
+  if *page {
+    out = fmt.Sprintf(pageTemplate, escape(*title), out)
+  }
+  fmt.Print(out)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+// Each testdata/NAME.md renders to testdata/NAME.txt; run with
+// AOC_UPDATE=1 to accept a change, and read the diff before committing.
+func TestGolden(t *testing.T) {
+  docs, _ := filepath.Glob(filepath.Join("testdata", "*.md"))
+  if len(docs) == 0 {
+    t.Fatal("no documents in testdata")
+  }
+  for _, doc := range docs {
+    name := strings.TrimSuffix(filepath.Base(doc), ".md")
+    t.Run(name, func(t *testing.T) {
+      src, err := os.ReadFile(doc)
+      if err != nil {
+        t.Fatal(err)
+      }
+      testx.Snapshot(t, name, toHTML(string(src)))
+    })
+  }
+}
+
+func TestOrderedListItems(t *testing.T) {
+  for _, c := range []struct{ doc, want string }{
+    {"1. one\n2. two\n3. three\n", "<ol>\n<li>one</li>\n<li>two</li>\n<li>three</li>\n</ol>\n"},
+    {"3) c\n4) d\n", "<ol start=\"3\">\n<li>c</li>\n<li>d</li>\n</ol>\n"},
+    {"1. a\n2) b\n", "<ol>\n<li>a</li>\n</ol>\n<ol start=\"2\">\n<li>b</li>\n</ol>\n"},
+    {"text\n2. not a list\n", "<p>text\n2. not a list</p>\n"},
+  } {
+    if got := toHTML(c.doc); got != c.want {
+      t.Errorf("%q:\n%s\nwant:\n%s", c.doc, got, c.want)
+    }
+  }
+}
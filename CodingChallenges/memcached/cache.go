@@ -0,0 +1,145 @@
+package main
+
+import (
+  "container/list"
+  "sync"
+  "time"
+)
+
+// A cached item. Size is what the item is charged against the memory cap:
+// its key, data and a fixed per-item overhead. Cas is the unique value
+// "gets" reports, new with every change to the item, which a "cas"
+// command must quote to store over it.
+type item struct {
+  key     string
+  flags   uint32
+  data    []byte
+  expires time.Time
+  cas     uint64
+}
+
+const itemOverhead = 48
+
+func (it *item) size() int {
+  return len(it.key) + len(it.data) + itemOverhead
+}
+
+// An LRU cache bounded by total item size. The list front holds the most
+// recently used item; when a store pushes usage over the cap, items are
+// evicted from the back.
+type cache struct {
+  mu       sync.Mutex
+  maxBytes int
+  used     int
+  items    map[string]*list.Element
+  lru      *list.List
+  lastCas  uint64
+}
+
+func newCache(maxBytes int) *cache {
+  return &cache{maxBytes: maxBytes, items: map[string]*list.Element{}, lru: list.New()}
+}
+
+// Look up a live item and mark it as recently used. Expired items are
+// removed lazily here. The caller must hold the lock.
+func (c *cache) lookup(key string, now time.Time) *item {
+  el, ok := c.items[key]
+  if !ok {
+    return nil
+  }
+  it := el.Value.(*item)
+  if !it.expires.IsZero() && !now.Before(it.expires) {
+    c.removeElement(el)
+    return nil
+  }
+  c.lru.MoveToFront(el)
+  return it
+}
+
+func (c *cache) removeElement(el *list.Element) {
+  it := c.lru.Remove(el).(*item)
+  delete(c.items, it.key)
+  c.used -= it.size()
+}
+
+// Insert or replace an item, giving it a new cas value, and evict least
+// recently used items until the cache fits under its cap again. The
+// caller must hold the lock.
+func (c *cache) put(it *item) bool {
+  if it.size() > c.maxBytes {
+    return false
+  }
+  c.lastCas++
+  it.cas = c.lastCas
+  if el, ok := c.items[it.key]; ok {
+    c.removeElement(el)
+  }
+  c.items[it.key] = c.lru.PushFront(it)
+  c.used += it.size()
+  for c.used > c.maxBytes {
+    c.removeElement(c.lru.Back())
+  }
+  return true
+}
+
+// The storage commands share one entry point. Return the protocol reply:
+// STORED, NOT_STORED, or SERVER_ERROR when the item can never fit, and for
+// cas, EXISTS when the item has changed since the client's gets and
+// NOT_FOUND when it is gone. A cas command's item carries the client's
+// cas value until it is stored.
+func (c *cache) store(cmd string, it *item, now time.Time) string {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  existing := c.lookup(it.key, now)
+  switch cmd {
+  case "add":
+    if existing != nil {
+      return "NOT_STORED"
+    }
+  case "replace":
+    if existing == nil {
+      return "NOT_STORED"
+    }
+  case "cas":
+    if existing == nil {
+      return "NOT_FOUND"
+    }
+    if existing.cas != it.cas {
+      return "EXISTS"
+    }
+  case "append", "prepend":
+    if existing == nil {
+      return "NOT_STORED"
+    }
+    // append/prepend ignore the new flags and exptime.
+    data := make([]byte, 0, len(existing.data)+len(it.data))
+    if cmd == "append" {
+      data = append(append(data, existing.data...), it.data...)
+    } else {
+      data = append(append(data, it.data...), existing.data...)
+    }
+    it = &item{key: it.key, flags: existing.flags, data: data, expires: existing.expires}
+  }
+  if !c.put(it) {
+    return "SERVER_ERROR object too large for cache"
+  }
+  return "STORED"
+}
+
+// Fetch an item for a get command.
+func (c *cache) get(key string, now time.Time) *item {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  return c.lookup(key, now)
+}
+
+// Delete an item, reporting whether it existed.
+func (c *cache) remove(key string, now time.Time) bool {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  if c.lookup(key, now) == nil {
+    return false
+  }
+  c.removeElement(c.items[key])
+  return true
+}
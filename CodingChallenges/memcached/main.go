@@ -0,0 +1,180 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "net"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Exptime values larger than 30 days are absolute Unix timestamps, smaller
+// ones are relative seconds, as in the memcached protocol.
+const relativeExpiryLimit = 60 * 60 * 24 * 30
+
+// Convert a protocol exptime into an absolute expiry. Zero means never; a
+// negative value means the item is already expired.
+func expiry(exptime int64, now time.Time) time.Time {
+  switch {
+  case exptime == 0:
+    return time.Time{}
+  case exptime < 0:
+    return now
+  case exptime > relativeExpiryLimit:
+    return time.Unix(exptime, 0)
+  }
+  return now.Add(time.Duration(exptime) * time.Second)
+}
+
+// The largest item accepted, memcached's default: a bigger one is refused
+// and its data read and thrown away, never held.
+const maxItemSize = 1 << 20
+
+// Handle a storage command line:
+//   <cmd> <key> <flags> <exptime> <bytes> [noreply]\r\n<data>\r\n
+//   cas <key> <flags> <exptime> <bytes> <cas unique> [noreply]\r\n<data>\r\n
+// Return the reply (empty with noreply) and whether the connection should be
+// dropped because the data block could not be read.
+func handleStorage(c *cache, fields []string, r *bufio.Reader) (string, bool) {
+  n := 5
+  if fields[0] == "cas" {
+    n = 6
+  }
+  if len(fields) != n && len(fields) != n+1 {
+    return "ERROR", false
+  }
+  noreply := len(fields) == n+1 && fields[n] == "noreply"
+  flags, err1 := strconv.ParseUint(fields[2], 10, 32)
+  exptime, err2 := strconv.ParseInt(fields[3], 10, 64)
+  size, err3 := strconv.Atoi(fields[4])
+  var cas uint64
+  var err4 error
+  if fields[0] == "cas" {
+    cas, err4 = strconv.ParseUint(fields[5], 10, 64)
+  }
+  if err1 != nil || err2 != nil || err3 != nil || err4 != nil || size < 0 || len(fields[1]) > 250 {
+    return "CLIENT_ERROR bad command line format", false
+  }
+  if size > maxItemSize {
+    if _, err := io.CopyN(io.Discard, r, int64(size)+2); err != nil {
+      return "", true
+    }
+    return "SERVER_ERROR object too large for cache", false
+  }
+
+  data := make([]byte, size+2)
+  if _, err := io.ReadFull(r, data); err != nil {
+    return "", true
+  }
+  if string(data[size:]) != "\r\n" {
+    return "CLIENT_ERROR bad data chunk", false
+  }
+
+  now := time.Now()
+  it := &item{key: fields[1], flags: uint32(flags), data: data[:size], expires: expiry(exptime, now), cas: cas}
+  reply := c.store(fields[0], it, now)
+  if noreply {
+    return "", false
+  }
+  return reply, false
+}
+
+// Serve one client connection until it disconnects or sends "quit".
+func handle(c *cache, conn net.Conn) {
+  defer conn.Close()
+  r := bufio.NewReader(conn)
+  w := bufio.NewWriter(conn)
+  for {
+    line, err := r.ReadString('\n')
+    if err != nil {
+      return
+    }
+    fields := strings.Fields(line)
+    if len(fields) == 0 {
+      continue
+    }
+
+    reply := ""
+    switch fields[0] {
+    case "set", "add", "replace", "append", "prepend", "cas":
+      var drop bool
+      reply, drop = handleStorage(c, fields, r)
+      if drop {
+        return
+      }
+    case "get", "gets":
+      if len(fields) < 2 {
+        reply = "ERROR"
+        break
+      }
+      now := time.Now()
+      for _, key := range fields[1:] {
+        if it := c.get(key, now); it != nil {
+          fmt.Fprintf(w, "VALUE %s %d %d", it.key, it.flags, len(it.data))
+          if fields[0] == "gets" {
+            fmt.Fprintf(w, " %d", it.cas)
+          }
+          w.WriteString("\r\n")
+          w.Write(it.data)
+          w.WriteString("\r\n")
+        }
+      }
+      reply = "END"
+    case "delete":
+      if len(fields) < 2 {
+        reply = "ERROR"
+        break
+      }
+      if c.remove(fields[1], time.Now()) {
+        reply = "DELETED"
+      } else {
+        reply = "NOT_FOUND"
+      }
+      if fields[len(fields)-1] == "noreply" {
+        reply = ""
+      }
+    case "version":
+      reply = "VERSION 1.6.0-cc"
+    case "quit":
+      w.Flush()
+      return
+    default:
+      reply = "ERROR"
+    }
+
+    if reply != "" {
+      w.WriteString(reply + "\r\n")
+    }
+    if r.Buffered() == 0 {
+      if err := w.Flush(); err != nil {
+        return
+      }
+    }
+  }
+}
+
+// Listen on the configured port and serve each client in its own goroutine.
+func main() {
+  port := flag.Int("p", 11211, "TCP port to listen on")
+  memory := flag.Int("m", 64, "memory cap in megabytes")
+  flag.Parse()
+
+  listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
+  if err != nil {
+    panic(err)
+  }
+  fmt.Println("Listening on", listener.Addr())
+
+  c := newCache(*memory << 20)
+  for {
+    conn, err := listener.Accept()
+    if err != nil {
+      fmt.Println("accept:", err)
+      continue
+    }
+    go handle(c, conn)
+  }
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+  "bufio"
+  "fmt"
+  "io"
+  "net"
+  "strconv"
+  "strings"
+  "testing"
+  "time"
+)
+
+// client is the small part of a memcached client library the tests need,
+// speaking the text protocol to a real listener.
+type client struct {
+  conn net.Conn
+  r    *bufio.Reader
+}
+
+type value struct {
+  flags uint32
+  data  string
+  cas   uint64
+}
+
+func dial(t *testing.T, c *cache) *client {
+  t.Helper()
+  ln, err := net.Listen("tcp", "127.0.0.1:0")
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { ln.Close() })
+  go func() {
+    for {
+      conn, err := ln.Accept()
+      if err != nil {
+        return
+      }
+      go handle(c, conn)
+    }
+  }()
+  conn, err := net.Dial("tcp", ln.Addr().String())
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { conn.Close() })
+  conn.SetDeadline(time.Now().Add(5 * time.Second))
+  return &client{conn, bufio.NewReader(conn)}
+}
+
+func (c *client) line() (string, error) {
+  s, err := c.r.ReadString('\n')
+  return strings.TrimSuffix(s, "\r\n"), err
+}
+
+// store sends a storage command and returns the server's reply.
+func (c *client) store(cmd, key string, flags uint32, exptime int, data string, cas ...uint64) (string, error) {
+  line := fmt.Sprintf("%s %s %d %d %d", cmd, key, flags, exptime, len(data))
+  for _, v := range cas {
+    line += " " + strconv.FormatUint(v, 10)
+  }
+  if _, err := fmt.Fprintf(c.conn, "%s\r\n%s\r\n", line, data); err != nil {
+    return "", err
+  }
+  return c.line()
+}
+
+// get fetches keys with get or gets, returning the values found.
+func (c *client) get(cmd string, keys ...string) (map[string]value, error) {
+  if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", cmd, strings.Join(keys, " ")); err != nil {
+    return nil, err
+  }
+  found := map[string]value{}
+  for {
+    line, err := c.line()
+    if err != nil {
+      return nil, err
+    }
+    if line == "END" {
+      return found, nil
+    }
+    f := strings.Fields(line)
+    if f[0] != "VALUE" || len(f) != 4+btoi(cmd == "gets") {
+      return nil, fmt.Errorf("unexpected %q", line)
+    }
+    flags, _ := strconv.ParseUint(f[2], 10, 32)
+    size, _ := strconv.Atoi(f[3])
+    v := value{flags: uint32(flags)}
+    if cmd == "gets" {
+      v.cas, _ = strconv.ParseUint(f[4], 10, 64)
+    }
+    data := make([]byte, size+2)
+    if _, err := io.ReadFull(c.r, data); err != nil {
+      return nil, err
+    }
+    v.data = string(data[:size])
+    found[f[1]] = v
+  }
+}
+
+func btoi(b bool) int {
+  if b {
+    return 1
+  }
+  return 0
+}
+
+func (c *client) command(line string) (string, error) {
+  if _, err := fmt.Fprintf(c.conn, "%s\r\n", line); err != nil {
+    return "", err
+  }
+  return c.line()
+}
+
+func expect(t *testing.T, what, got string, err error, want string) {
+  t.Helper()
+  if err != nil || got != want {
+    t.Errorf("%s: got %q, %v; want %q", what, got, err, want)
+  }
+}
+
+func TestStorage(t *testing.T) {
+  c := dial(t, newCache(1<<20))
+  r, err := c.store("set", "k", 42, 0, "hello")
+  expect(t, "set", r, err, "STORED")
+  r, err = c.store("add", "k", 0, 0, "x")
+  expect(t, "add existing", r, err, "NOT_STORED")
+  r, err = c.store("replace", "nope", 0, 0, "x")
+  expect(t, "replace missing", r, err, "NOT_STORED")
+  r, err = c.store("append", "k", 0, 0, " world")
+  expect(t, "append", r, err, "STORED")
+  r, err = c.store("prepend", "k", 0, 0, ">")
+  expect(t, "prepend", r, err, "STORED")
+
+  got, err := c.get("get", "k", "nope")
+  if err != nil || len(got) != 1 || got["k"] != (value{flags: 42, data: ">hello world"}) {
+    t.Errorf("get: %v, %v", got, err)
+  }
+  r, err = c.command("delete k")
+  expect(t, "delete", r, err, "DELETED")
+  r, err = c.command("delete k")
+  expect(t, "delete again", r, err, "NOT_FOUND")
+  r, err = c.command("version")
+  expect(t, "version", r, err, "VERSION 1.6.0-cc")
+  r, err = c.command("bogus")
+  expect(t, "unknown", r, err, "ERROR")
+}
+
+// gets reports a cas value that changes with the item, and cas stores
+// only over the value it was read with.
+func TestCas(t *testing.T) {
+  c := dial(t, newCache(1<<20))
+  c.store("set", "k", 0, 0, "1")
+  got, err := c.get("gets", "k")
+  if err != nil || got["k"].cas == 0 {
+    t.Fatalf("gets: %v, %v", got, err)
+  }
+  first := got["k"].cas
+
+  r, err := c.store("cas", "k", 0, 0, "2", first)
+  expect(t, "cas with the current value", r, err, "STORED")
+  r, err = c.store("cas", "k", 0, 0, "3", first)
+  expect(t, "cas with a stale value", r, err, "EXISTS")
+  r, err = c.store("cas", "gone", 0, 0, "3", first)
+  expect(t, "cas on a missing key", r, err, "NOT_FOUND")
+
+  got, _ = c.get("gets", "k")
+  if got["k"].data != "2" || got["k"].cas == first {
+    t.Errorf("after cas: %+v", got["k"])
+  }
+  second := got["k"].cas
+  c.store("append", "k", 0, 0, "!")
+  got, _ = c.get("gets", "k")
+  if got["k"].cas == second {
+    t.Error("append left the cas value as it was")
+  }
+  r, err = c.command("cas k 0 0 1\r\nx")
+  expect(t, "cas without its value", r, err, "ERROR")
+}
+
+// An item over the size limit is refused without being held, and the
+// connection carries on after its data.
+func TestTooLarge(t *testing.T) {
+  c := dial(t, newCache(64<<20))
+  r, err := c.store("set", "big", 0, 0, strings.Repeat("x", maxItemSize+1))
+  expect(t, "too large", r, err, "SERVER_ERROR object too large for cache")
+  r, err = c.store("set", "small", 0, 0, "ok")
+  expect(t, "then a small one", r, err, "STORED")
+  r, err = c.command("set k 0 0 -1")
+  expect(t, "negative size", r, err, "CLIENT_ERROR bad command line format")
+}
+
+func TestEvictionAndExpiry(t *testing.T) {
+  c := newCache(3 * (itemOverhead + 2))
+  now := time.Now()
+  for _, k := range []string{"a", "b", "c"} {
+    c.store("set", &item{key: k, data: []byte("1")}, now)
+  }
+  c.get("a", now)
+  c.store("set", &item{key: "d", data: []byte("1")}, now)
+  if c.get("b", now) != nil || c.get("a", now) == nil {
+    t.Error("evicted the wrong item")
+  }
+  c.store("set", &item{key: "e", data: []byte("1"), expires: now.Add(time.Second)}, now)
+  if c.get("e", now.Add(2*time.Second)) != nil {
+    t.Error("an expired item was returned")
+  }
+}
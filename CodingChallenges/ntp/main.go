@@ -0,0 +1,166 @@
+package main
+
+import (
+  "encoding/binary"
+  "errors"
+  "flag"
+  "fmt"
+  "net"
+  "os"
+  "time"
+)
+
+// Seconds between the NTP epoch (1900-01-01) and the Unix epoch (1970-01-01).
+const ntpEpochOffset = 2208988800
+
+// The 48 byte NTPv4 packet header from RFC 5905. Extension fields and the
+// MAC are not used by a simple client.
+type packet struct {
+  leap           uint8
+  version        uint8
+  mode           uint8
+  stratum        uint8
+  poll           int8
+  precision      int8
+  rootDelay      uint32
+  rootDispersion uint32
+  referenceID    uint32
+  referenceTime  uint64
+  originTime     uint64
+  receiveTime    uint64
+  transmitTime   uint64
+}
+
+// Convert a time to the 64-bit NTP timestamp format: 32 bits of seconds since
+// 1900 and 32 bits of binary fraction.
+func toNTP(t time.Time) uint64 {
+  secs := uint64(t.Unix() + ntpEpochOffset)
+  frac := (uint64(t.Nanosecond()) << 32) / 1e9
+  return secs<<32 | frac
+}
+
+// Convert a 64-bit NTP timestamp back to a time.
+func fromNTP(ts uint64) time.Time {
+  secs := int64(ts>>32) - ntpEpochOffset
+  nanos := (int64(ts&0xffffffff) * 1e9) >> 32
+  return time.Unix(secs, nanos)
+}
+
+// Serialize the packet into its wire format.
+func (p *packet) marshal() []byte {
+  b := make([]byte, 48)
+  b[0] = p.leap<<6 | (p.version&0x7)<<3 | p.mode&0x7
+  b[1] = p.stratum
+  b[2] = byte(p.poll)
+  b[3] = byte(p.precision)
+  binary.BigEndian.PutUint32(b[4:], p.rootDelay)
+  binary.BigEndian.PutUint32(b[8:], p.rootDispersion)
+  binary.BigEndian.PutUint32(b[12:], p.referenceID)
+  binary.BigEndian.PutUint64(b[16:], p.referenceTime)
+  binary.BigEndian.PutUint64(b[24:], p.originTime)
+  binary.BigEndian.PutUint64(b[32:], p.receiveTime)
+  binary.BigEndian.PutUint64(b[40:], p.transmitTime)
+  return b
+}
+
+// Parse a packet from its wire format.
+func unmarshal(b []byte) (*packet, error) {
+  if len(b) < 48 {
+    return nil, fmt.Errorf("short packet: %d bytes", len(b))
+  }
+  return &packet{
+    leap:           b[0] >> 6,
+    version:        (b[0] >> 3) & 0x7,
+    mode:           b[0] & 0x7,
+    stratum:        b[1],
+    poll:           int8(b[2]),
+    precision:      int8(b[3]),
+    rootDelay:      binary.BigEndian.Uint32(b[4:]),
+    rootDispersion: binary.BigEndian.Uint32(b[8:]),
+    referenceID:    binary.BigEndian.Uint32(b[12:]),
+    referenceTime:  binary.BigEndian.Uint64(b[16:]),
+    originTime:     binary.BigEndian.Uint64(b[24:]),
+    receiveTime:    binary.BigEndian.Uint64(b[32:]),
+    transmitTime:   binary.BigEndian.Uint64(b[40:]),
+  }, nil
+}
+
+// Compute the clock offset and round-trip delay from the four timestamps:
+// t1 client transmit, t2 server receive, t3 server transmit, t4 client
+// receive.
+//   offset = ((t2 - t1) + (t3 - t4)) / 2
+//   delay  = (t4 - t1) - (t3 - t2)
+func offsetAndDelay(t1, t2, t3, t4 time.Time) (time.Duration, time.Duration) {
+  offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+  delay := t4.Sub(t1) - t3.Sub(t2)
+  return offset, delay
+}
+
+// Validate a server reply against the request we sent, rejecting replies that
+// are not for us or come from an unsynchronised server.
+func validate(req, resp *packet) error {
+  switch {
+  case resp.mode != 4:
+    return fmt.Errorf("unexpected mode %d", resp.mode)
+  case resp.originTime != req.transmitTime:
+    return errors.New("reply does not match our request")
+  case resp.leap == 3 || resp.stratum == 0:
+    return errors.New("server clock is not synchronised (kiss-o'-death)")
+  case resp.transmitTime == 0:
+    return errors.New("server sent zero transmit timestamp")
+  }
+  return nil
+}
+
+// Send one client request to the server and return the reply packet with the
+// local send and receive times.
+func query(server string, timeout time.Duration) (*packet, *packet, time.Time, time.Time, error) {
+  conn, err := net.DialTimeout("udp", server, timeout)
+  if err != nil {
+    return nil, nil, time.Time{}, time.Time{}, err
+  }
+  defer conn.Close()
+  conn.SetDeadline(time.Now().Add(timeout))
+
+  t1 := time.Now()
+  req := &packet{version: 4, mode: 3, transmitTime: toNTP(t1)}
+  if _, err := conn.Write(req.marshal()); err != nil {
+    return nil, nil, t1, time.Time{}, err
+  }
+  buf := make([]byte, 512)
+  n, err := conn.Read(buf)
+  t4 := time.Now()
+  if err != nil {
+    return nil, nil, t1, t4, err
+  }
+  resp, err := unmarshal(buf[:n])
+  if err != nil {
+    return nil, nil, t1, t4, err
+  }
+  return req, resp, t1, t4, validate(req, resp)
+}
+
+// Query the server and print the corrected time with the measured offset and
+// round-trip delay.
+func main() {
+  server := flag.String("server", "pool.ntp.org:123", "NTP server address")
+  timeout := flag.Duration("timeout", 5*time.Second, "request timeout")
+  flag.Parse()
+
+  _, resp, t1, t4, err := query(*server, *timeout)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "ntp:", err)
+    os.Exit(1)
+  }
+  // Use the transmitted origin timestamp rather than t1 so rounding in the
+  // NTP format does not leak into the offset.
+  t1 = fromNTP(resp.originTime)
+  t2, t3 := fromNTP(resp.receiveTime), fromNTP(resp.transmitTime)
+  offset, delay := offsetAndDelay(t1, t2, t3, t4)
+
+  fmt.Println("Server:    ", *server, "stratum", resp.stratum)
+  fmt.Println("Local time:", t4.Format(time.RFC3339Nano))
+  fmt.Println("NTP time:  ", t4.Add(offset).Format(time.RFC3339Nano))
+  fmt.Println("Offset:    ", offset)
+  fmt.Println("Delay:     ", delay)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+  "bytes"
+  "net"
+  "strings"
+  "testing"
+  "time"
+)
+
+// A server's reply to a request sent at 2024-01-01 00:00:00 UTC: version
+// 4, server mode, stratum 2 synchronised to GPS, received 5.125s and sent
+// 5.25s after it by the server's clock.
+var reply = []byte{
+  0x24, 0x02, 0x03, 0xe9,
+  0x00, 0x00, 0x01, 0x2c,
+  0x00, 0x00, 0x00, 0x5a,
+  'G', 'P', 'S', 0,
+  0xe9, 0x3c, 0x7e, 0xf0, 0x00, 0x00, 0x00, 0x00,
+  0xe9, 0x3c, 0x7f, 0x00, 0x00, 0x00, 0x00, 0x00,
+  0xe9, 0x3c, 0x7f, 0x05, 0x20, 0x00, 0x00, 0x00,
+  0xe9, 0x3c, 0x7f, 0x05, 0x40, 0x00, 0x00, 0x00,
+}
+
+var newYear = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestUnmarshal(t *testing.T) {
+  p, err := unmarshal(reply)
+  if err != nil {
+    t.Fatal(err)
+  }
+  want := packet{
+    leap: 0, version: 4, mode: 4, stratum: 2, poll: 3, precision: -23,
+    rootDelay: 300, rootDispersion: 90, referenceID: 0x47505300,
+    referenceTime: 0xe93c7ef0_00000000,
+    originTime:    0xe93c7f00_00000000,
+    receiveTime:   0xe93c7f05_20000000,
+    transmitTime:  0xe93c7f05_40000000,
+  }
+  if *p != want {
+    t.Errorf("got %+v, want %+v", *p, want)
+  }
+  if got := p.marshal(); !bytes.Equal(got, reply) {
+    t.Errorf("marshalled back to % x", got)
+  }
+  if _, err := unmarshal(reply[:47]); err == nil {
+    t.Error("a 47-byte packet parsed")
+  }
+}
+
+func TestMarshal(t *testing.T) {
+  req := &packet{leap: 3, version: 4, mode: 3, poll: -6, transmitTime: toNTP(newYear)}
+  b := req.marshal()
+  if len(b) != 48 || b[0] != 0xe3 || b[2] != 0xfa {
+    t.Errorf("got % x", b)
+  }
+  if got, _ := unmarshal(b); *got != *req {
+    t.Errorf("round trip gave %+v, want %+v", *got, *req)
+  }
+}
+
+func TestTimestamps(t *testing.T) {
+  for _, c := range []struct {
+    t  time.Time
+    ts uint64
+  }{
+    {time.Unix(0, 0), ntpEpochOffset << 32},
+    {newYear, 0xe93c7f00_00000000},
+    {newYear.Add(500 * time.Millisecond), 0xe93c7f00_80000000},
+    {newYear.Add(time.Second + 250*time.Millisecond), 0xe93c7f01_40000000},
+  } {
+    if got := toNTP(c.t); got != c.ts {
+      t.Errorf("toNTP(%v) = %#x, want %#x", c.t, got, c.ts)
+    }
+    if got := fromNTP(c.ts); !got.Equal(c.t) {
+      t.Errorf("fromNTP(%#x) = %v, want %v", c.ts, got, c.t)
+    }
+  }
+  // A fraction in 2^-32 seconds truncates to within a nanosecond.
+  now := newYear.Add(123456789 * time.Nanosecond)
+  if d := now.Sub(fromNTP(toNTP(now))); d < 0 || d > time.Nanosecond {
+    t.Errorf("round trip is off by %v", d)
+  }
+}
+
+func TestOffsetAndDelay(t *testing.T) {
+  resp, _ := unmarshal(reply)
+  t1, t4 := fromNTP(resp.originTime), newYear.Add(500*time.Millisecond)
+  offset, delay := offsetAndDelay(t1, fromNTP(resp.receiveTime), fromNTP(resp.transmitTime), t4)
+  // ((5.125 - 0) + (5.25 - 0.5)) / 2, and 0.5 - (5.25 - 5.125).
+  if offset != 4937500*time.Microsecond || delay != 375*time.Millisecond {
+    t.Errorf("got offset %v and delay %v, want 4.9375s and 375ms", offset, delay)
+  }
+}
+
+func TestValidate(t *testing.T) {
+  req := &packet{version: 4, mode: 3, transmitTime: 0xe93c7f00_00000000}
+  for _, c := range []struct {
+    name   string
+    change func(*packet)
+    err    string
+  }{
+    {"good", func(*packet) {}, ""},
+    {"client mode", func(p *packet) { p.mode = 3 }, "unexpected mode"},
+    {"other request", func(p *packet) { p.originTime++ }, "does not match"},
+    {"alarm", func(p *packet) { p.leap = 3 }, "not synchronised"},
+    {"kiss-o'-death", func(p *packet) { p.stratum = 0 }, "not synchronised"},
+    {"no transmit time", func(p *packet) { p.transmitTime = 0 }, "zero transmit"},
+  } {
+    resp, _ := unmarshal(reply)
+    c.change(resp)
+    err := validate(req, resp)
+    if c.err == "" && err != nil || c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+      t.Errorf("%s: got %v, want %q", c.name, err, c.err)
+    }
+  }
+}
+
+// A query against a local server whose clock is an hour ahead.
+func TestQuery(t *testing.T) {
+  conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+  if err != nil {
+    t.Skip("no loopback:", err)
+  }
+  defer conn.Close()
+  go func() {
+    buf := make([]byte, 512)
+    n, addr, err := conn.ReadFrom(buf)
+    if err != nil {
+      return
+    }
+    req, err := unmarshal(buf[:n])
+    if err != nil || req.mode != 3 || req.version != 4 {
+      return
+    }
+    now := time.Now().Add(time.Hour)
+    resp := &packet{version: 4, mode: 4, stratum: 1, originTime: req.transmitTime, receiveTime: toNTP(now), transmitTime: toNTP(now)}
+    conn.WriteTo(resp.marshal(), addr)
+  }()
+
+  _, resp, _, t4, err := query(conn.LocalAddr().String(), 5*time.Second)
+  if err != nil {
+    t.Fatal(err)
+  }
+  offset, delay := offsetAndDelay(fromNTP(resp.originTime), fromNTP(resp.receiveTime), fromNTP(resp.transmitTime), t4)
+  if offset < 59*time.Minute || offset > 61*time.Minute || delay < 0 || delay > time.Second {
+    t.Errorf("got offset %v and delay %v, want about an hour and next to nothing", offset, delay)
+  }
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "net"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+  "sync"
+  "time"
+)
+
+// Parse a port list such as "22,80,8000-8100" into sorted unique ports.
+func parsePorts(spec string) ([]int, error) {
+  seen := map[int]bool{}
+  for _, part := range strings.Split(spec, ",") {
+    part = strings.TrimSpace(part)
+    lo, hi, isRange := strings.Cut(part, "-")
+    start, err := strconv.Atoi(lo)
+    if err != nil {
+      return nil, fmt.Errorf("invalid port %q", part)
+    }
+    end := start
+    if isRange {
+      if end, err = strconv.Atoi(hi); err != nil {
+        return nil, fmt.Errorf("invalid port range %q", part)
+      }
+    }
+    if start < 1 || end > 65535 || start > end {
+      return nil, fmt.Errorf("invalid port range %q", part)
+    }
+    for p := start; p <= end; p++ {
+      seen[p] = true
+    }
+  }
+  ports := make([]int, 0, len(seen))
+  for p := range seen {
+    ports = append(ports, p)
+  }
+  sort.Ints(ports)
+  return ports, nil
+}
+
+// Expand a host argument. CIDR blocks become every address in the block
+// except the network and broadcast addresses for IPv4 blocks larger than /31.
+func expandHost(arg string) ([]string, error) {
+  ip, network, err := net.ParseCIDR(arg)
+  if err != nil {
+    return []string{arg}, nil
+  }
+  ones, bits := network.Mask.Size()
+  if bits-ones > 16 {
+    return nil, fmt.Errorf("%s: refusing to scan more than 65536 addresses", arg)
+  }
+  var hosts []string
+  for cur := ip.Mask(network.Mask); network.Contains(cur); cur = nextIP(cur) {
+    hosts = append(hosts, cur.String())
+  }
+  if ip.To4() != nil && bits-ones > 1 {
+    hosts = hosts[1 : len(hosts)-1]
+  }
+  return hosts, nil
+}
+
+func nextIP(ip net.IP) net.IP {
+  next := append(net.IP(nil), ip...)
+  for i := len(next) - 1; i >= 0; i-- {
+    next[i]++
+    if next[i] != 0 {
+      break
+    }
+  }
+  return next
+}
+
+type target struct {
+  host string
+  port int
+}
+
+type result struct {
+  target
+  banner string
+}
+
+// Attempt a full TCP connection (a "vanilla" connect scan, which needs no
+// privileges). With grab set, wait briefly for the service to announce
+// itself, which is how SSH, SMTP and FTP servers identify themselves.
+func probe(t target, timeout time.Duration, grab bool) (result, bool) {
+  conn, err := net.DialTimeout("tcp", net.JoinHostPort(t.host, strconv.Itoa(t.port)), timeout)
+  if err != nil {
+    return result{}, false
+  }
+  defer conn.Close()
+  r := result{target: t}
+  if grab {
+    conn.SetReadDeadline(time.Now().Add(timeout))
+    line, _ := bufio.NewReader(conn).ReadString('\n')
+    r.banner = strings.TrimSpace(line)
+  }
+  return r, true
+}
+
+// Scan every target with a fixed pool of workers so the number of sockets in
+// flight never exceeds the limit.
+func scan(targets []target, workers int, timeout time.Duration, grab bool) []result {
+  jobs := make(chan target)
+  results := make(chan result)
+  var wg sync.WaitGroup
+  for i := 0; i < workers; i++ {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      for t := range jobs {
+        if r, open := probe(t, timeout, grab); open {
+          results <- r
+        }
+      }
+    }()
+  }
+  go func() {
+    for _, t := range targets {
+      jobs <- t
+    }
+    close(jobs)
+    wg.Wait()
+    close(results)
+  }()
+
+  var open []result
+  for r := range results {
+    open = append(open, r)
+  }
+  sort.Slice(open, func(i, j int) bool {
+    if open[i].host != open[j].host {
+      return open[i].host < open[j].host
+    }
+    return open[i].port < open[j].port
+  })
+  return open
+}
+
+// Scan the given hosts and print the open ports, grouped by host.
+func main() {
+  portSpec := flag.String("p", "1-1024", "ports to scan, e.g. 22,80,8000-8100")
+  workers := flag.Int("w", 100, "maximum number of concurrent connection attempts")
+  timeout := flag.Duration("t", 500*time.Millisecond, "connect (and banner read) timeout")
+  grab := flag.Bool("b", false, "grab the first line the service sends")
+  flag.Parse()
+
+  if flag.NArg() == 0 || *workers < 1 {
+    fmt.Fprintln(os.Stderr, "usage: portscan [-p ports] [-w workers] [-t timeout] [-b] host|cidr...")
+    os.Exit(2)
+  }
+  ports, err := parsePorts(*portSpec)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "portscan:", err)
+    os.Exit(2)
+  }
+  var targets []target
+  for _, arg := range flag.Args() {
+    hosts, err := expandHost(arg)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "portscan:", err)
+      os.Exit(2)
+    }
+    for _, h := range hosts {
+      for _, p := range ports {
+        targets = append(targets, target{h, p})
+      }
+    }
+  }
+
+  start := time.Now()
+  open := scan(targets, *workers, *timeout, *grab)
+  last := ""
+  for _, r := range open {
+    if r.host != last {
+      fmt.Printf("%s:\n", r.host)
+      last = r.host
+    }
+    line := fmt.Sprintf("  %d/tcp open", r.port)
+    if name, ok := services[r.port]; ok {
+      line += "  " + name
+    }
+    if r.banner != "" {
+      line += "  " + strconv.Quote(r.banner)
+    }
+    fmt.Println(line)
+  }
+  fmt.Printf("Scanned %d ports in %s, %d open\n", len(targets), time.Since(start).Round(time.Millisecond), len(open))
+}
+
+// Well known services for labelling results.
+var services = map[int]string{
+  21: "ftp", 22: "ssh", 23: "telnet", 25: "smtp", 53: "domain", 80: "http",
+  110: "pop3", 143: "imap", 443: "https", 465: "smtps", 587: "submission",
+  993: "imaps", 995: "pop3s", 3306: "mysql", 5432: "postgresql",
+  6379: "redis", 8080: "http-alt", 11211: "memcached",
+}
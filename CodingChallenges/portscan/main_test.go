@@ -0,0 +1,121 @@
+package main
+
+import (
+  "net"
+  "slices"
+  "strings"
+  "testing"
+  "time"
+)
+
+func TestParsePorts(t *testing.T) {
+  for _, c := range []struct {
+    spec string
+    want []int
+  }{
+    {"80", []int{80}},
+    {"443,22, 80", []int{22, 80, 443}},
+    {"8000-8003,8002,1", []int{1, 8000, 8001, 8002, 8003}},
+    {"65535", []int{65535}},
+  } {
+    if got, err := parsePorts(c.spec); err != nil || !slices.Equal(got, c.want) {
+      t.Errorf("%s: got %v, %v, want %v", c.spec, got, err, c.want)
+    }
+  }
+  for _, spec := range []string{"", "0", "65536", "http", "10-", "-10", "20-10", "1,,2"} {
+    if _, err := parsePorts(spec); err == nil {
+      t.Errorf("%q parsed", spec)
+    }
+  }
+}
+
+func TestExpandHost(t *testing.T) {
+  for _, c := range []struct {
+    arg  string
+    want []string
+  }{
+    {"example.com", []string{"example.com"}},
+    {"10.0.0.7", []string{"10.0.0.7"}},
+    {"10.0.0.7/32", []string{"10.0.0.7"}},
+    {"10.0.0.6/31", []string{"10.0.0.6", "10.0.0.7"}},
+    // Larger IPv4 blocks lose their network and broadcast addresses.
+    {"10.0.0.5/30", []string{"10.0.0.5", "10.0.0.6"}},
+    {"10.0.0.255/29", []string{"10.0.0.249", "10.0.0.250", "10.0.0.251", "10.0.0.252", "10.0.0.253", "10.0.0.254"}},
+    {"fe80::1/126", []string{"fe80::", "fe80::1", "fe80::2", "fe80::3"}},
+  } {
+    if got, err := expandHost(c.arg); err != nil || !slices.Equal(got, c.want) {
+      t.Errorf("%s: got %v, %v, want %v", c.arg, got, err, c.want)
+    }
+  }
+  if _, err := expandHost("10.0.0.0/8"); err == nil {
+    t.Error("a /8 was expanded")
+  }
+}
+
+// A listener on a free loopback port that greets each connection with
+// banner, or says nothing if it is empty.
+func listen(t *testing.T, banner string) int {
+  t.Helper()
+  l, err := net.Listen("tcp", "127.0.0.1:0")
+  if err != nil {
+    t.Skip("no loopback:", err)
+  }
+  t.Cleanup(func() { l.Close() })
+  go func() {
+    for {
+      conn, err := l.Accept()
+      if err != nil {
+        return
+      }
+      if banner != "" {
+        conn.Write([]byte(banner))
+      }
+      // Hold the connection open until the scanner hangs up.
+      go func() {
+        conn.Read(make([]byte, 1))
+        conn.Close()
+      }()
+    }
+  }()
+  return l.Addr().(*net.TCPAddr).Port
+}
+
+// A loopback port with nothing listening on it.
+func closedPort(t *testing.T) int {
+  t.Helper()
+  l, err := net.Listen("tcp", "127.0.0.1:0")
+  if err != nil {
+    t.Skip("no loopback:", err)
+  }
+  port := l.Addr().(*net.TCPAddr).Port
+  l.Close()
+  return port
+}
+
+func TestScan(t *testing.T) {
+  ssh := listen(t, "SSH-2.0-OpenSSH_9.6\r\n")
+  quiet := listen(t, "")
+  closed := closedPort(t)
+  targets := []target{{"127.0.0.1", closed}, {"127.0.0.1", quiet}, {"127.0.0.1", ssh}}
+
+  for _, grab := range []bool{false, true} {
+    for _, workers := range []int{1, 3} {
+      got := scan(targets, workers, 200*time.Millisecond, grab)
+      want := []result{{target{"127.0.0.1", ssh}, "SSH-2.0-OpenSSH_9.6"}, {target{"127.0.0.1", quiet}, ""}}
+      if !grab {
+        want[0].banner = ""
+      }
+      slices.SortFunc(want, func(a, b result) int { return a.port - b.port })
+      if !slices.Equal(got, want) {
+        t.Errorf("grab %v, %d workers: got %v, want %v", grab, workers, got, want)
+      }
+    }
+  }
+
+  if _, open := probe(target{"127.0.0.1", closed}, 200*time.Millisecond, false); open {
+    t.Errorf("port %d is open", closed)
+  }
+  if r, open := probe(target{"127.0.0.1", ssh}, 200*time.Millisecond, true); !open || !strings.HasPrefix(r.banner, "SSH-2.0") {
+    t.Errorf("port %d: got %+v, %v", ssh, r, open)
+  }
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+  "sync"
+  "time"
+)
+
+// A limiter decides whether the next request from a client (identified by
+// key, typically its IP address) may go ahead at the given moment. Passing
+// the time in rather than reading the clock keeps every algorithm
+// deterministic, which is what the burst demo relies on.
+type limiter interface {
+  allow(key string, now time.Time) bool
+}
+
+// Every algorithm keeps state per client, which would grow without bound
+// as new clients come and go. State that has gone idle long enough to be
+// no different from a new client's is swept out. Sweeps run from allow, at
+// most once an interval, so they need no goroutine and follow the
+// caller's clock like everything else.
+type sweeper struct {
+  interval time.Duration
+  next     time.Time
+}
+
+// Whether a sweep is due, starting the next interval if it is.
+func (s *sweeper) due(now time.Time) bool {
+  if now.Before(s.next) {
+    return false
+  }
+  s.next = now.Add(s.interval)
+  return true
+}
+
+// Token bucket: each client gets a bucket holding up to capacity tokens,
+// refilled at rate tokens per second. A request spends one token. Bursts up
+// to the bucket size are allowed, after which traffic is smoothed to rate.
+type tokenBucket struct {
+  mu       sync.Mutex
+  capacity float64
+  rate     float64
+  buckets  map[string]*bucket
+  sweep    sweeper
+}
+
+type bucket struct {
+  tokens float64
+  last   time.Time
+}
+
+func newTokenBucket(capacity int, rate float64) *tokenBucket {
+  // An empty bucket is full again, as a new client's is, after this long.
+  refill := time.Duration(float64(capacity) / rate * float64(time.Second))
+  return &tokenBucket{capacity: float64(capacity), rate: rate, buckets: map[string]*bucket{}, sweep: sweeper{interval: refill}}
+}
+
+func (tb *tokenBucket) allow(key string, now time.Time) bool {
+  tb.mu.Lock()
+  defer tb.mu.Unlock()
+  if tb.sweep.due(now) {
+    for k, b := range tb.buckets {
+      if b.tokens+now.Sub(b.last).Seconds()*tb.rate >= tb.capacity {
+        delete(tb.buckets, k)
+      }
+    }
+  }
+  b, ok := tb.buckets[key]
+  if !ok {
+    b = &bucket{tokens: tb.capacity, last: now}
+    tb.buckets[key] = b
+  }
+  b.tokens += now.Sub(b.last).Seconds() * tb.rate
+  if b.tokens > tb.capacity {
+    b.tokens = tb.capacity
+  }
+  b.last = now
+  if b.tokens < 1 {
+    return false
+  }
+  b.tokens--
+  return true
+}
+
+// Fixed window counter: time is cut into windows of a fixed size and each
+// client may make limit requests per window. Cheap, but a client can fire
+// 2*limit requests across a window boundary.
+type fixedWindow struct {
+  mu      sync.Mutex
+  limit   int
+  window  time.Duration
+  windows map[string]*windowCount
+  sweep   sweeper
+}
+
+type windowCount struct {
+  start time.Time
+  count int
+}
+
+func newFixedWindow(limit int, window time.Duration) *fixedWindow {
+  return &fixedWindow{limit: limit, window: window, windows: map[string]*windowCount{}, sweep: sweeper{interval: window}}
+}
+
+func (fw *fixedWindow) allow(key string, now time.Time) bool {
+  fw.mu.Lock()
+  defer fw.mu.Unlock()
+  start := now.Truncate(fw.window)
+  if fw.sweep.due(now) {
+    for k, w := range fw.windows {
+      if w.start.Before(start) {
+        delete(fw.windows, k)
+      }
+    }
+  }
+  w, ok := fw.windows[key]
+  if !ok || !w.start.Equal(start) {
+    w = &windowCount{start: start}
+    fw.windows[key] = w
+  }
+  if w.count >= fw.limit {
+    return false
+  }
+  w.count++
+  return true
+}
+
+// Sliding window log: remember the timestamp of every accepted request and
+// allow a new one only if fewer than limit fall inside the trailing window.
+// Exact, but memory grows with the limit.
+type slidingLog struct {
+  mu     sync.Mutex
+  limit  int
+  window time.Duration
+  logs   map[string][]time.Time
+  sweep  sweeper
+}
+
+func newSlidingLog(limit int, window time.Duration) *slidingLog {
+  return &slidingLog{limit: limit, window: window, logs: map[string][]time.Time{}, sweep: sweeper{interval: window}}
+}
+
+func (sl *slidingLog) allow(key string, now time.Time) bool {
+  sl.mu.Lock()
+  defer sl.mu.Unlock()
+  cutoff := now.Add(-sl.window)
+  if sl.sweep.due(now) {
+    for k, log := range sl.logs {
+      if len(log) == 0 || !log[len(log)-1].After(cutoff) {
+        delete(sl.logs, k)
+      }
+    }
+  }
+  log := sl.logs[key]
+  i := 0
+  for i < len(log) && !log[i].After(cutoff) {
+    i++
+  }
+  log = log[i:]
+  if len(log) >= sl.limit {
+    sl.logs[key] = log
+    return false
+  }
+  sl.logs[key] = append(log, now)
+  return true
+}
+
+// Sliding window counter: a fixed window counter that also weighs the
+// previous window's count by how much of it still overlaps the trailing
+// window. Nearly as smooth as the log with the memory cost of two counters.
+type slidingCounter struct {
+  mu       sync.Mutex
+  limit    int
+  window   time.Duration
+  counters map[string]*slidingCount
+  sweep    sweeper
+}
+
+type slidingCount struct {
+  start    time.Time
+  current  int
+  previous int
+}
+
+func newSlidingCounter(limit int, window time.Duration) *slidingCounter {
+  return &slidingCounter{limit: limit, window: window, counters: map[string]*slidingCount{}, sweep: sweeper{interval: window}}
+}
+
+func (sc *slidingCounter) allow(key string, now time.Time) bool {
+  sc.mu.Lock()
+  defer sc.mu.Unlock()
+  start := now.Truncate(sc.window)
+  if sc.sweep.due(now) {
+    // Counts from before the previous window no longer weigh at all.
+    for k, c := range sc.counters {
+      if c.start.Add(sc.window).Before(start) {
+        delete(sc.counters, k)
+      }
+    }
+  }
+  c, ok := sc.counters[key]
+  if !ok {
+    c = &slidingCount{start: start}
+    sc.counters[key] = c
+  }
+  switch {
+  case start.Equal(c.start):
+  case start.Equal(c.start.Add(sc.window)):
+    c.start, c.previous, c.current = start, c.current, 0
+  default:
+    c.start, c.previous, c.current = start, 0, 0
+  }
+  overlap := 1 - float64(now.Sub(start))/float64(sc.window)
+  estimate := float64(c.previous)*overlap + float64(c.current)
+  if estimate >= float64(sc.limit) {
+    return false
+  }
+  c.current++
+  return true
+}
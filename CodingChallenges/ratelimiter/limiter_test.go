@@ -0,0 +1,119 @@
+package main
+
+import (
+  "fmt"
+  "math/rand"
+  "testing"
+  "time"
+)
+
+var base = time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+var algorithms = []string{"token-bucket", "fixed-window", "sliding-log", "sliding-counter"}
+
+// The number of clients a limiter holds state for.
+func clients(l limiter) int {
+  switch l := l.(type) {
+  case *tokenBucket:
+    return len(l.buckets)
+  case *fixedWindow:
+    return len(l.windows)
+  case *slidingLog:
+    return len(l.logs)
+  case *slidingCounter:
+    return len(l.counters)
+  }
+  panic("unknown limiter")
+}
+
+// Turn a limiter's sweeps off.
+func neverSweep(l limiter) {
+  never := sweeper{next: base.Add(1 << 62)}
+  switch l := l.(type) {
+  case *tokenBucket:
+    l.sweep = never
+  case *fixedWindow:
+    l.sweep = never
+  case *slidingLog:
+    l.sweep = never
+  case *slidingCounter:
+    l.sweep = never
+  }
+}
+
+func TestLimits(t *testing.T) {
+  for _, name := range algorithms {
+    l, _ := newLimiter(name, 5, time.Second)
+    for i := range 5 {
+      if !l.allow("a", base.Add(time.Duration(i)*time.Millisecond)) {
+        t.Errorf("%s: request %d refused", name, i+1)
+      }
+    }
+    if l.allow("a", base.Add(10*time.Millisecond)) {
+      t.Errorf("%s: a sixth request in a second was allowed", name)
+    }
+    if !l.allow("b", base.Add(10*time.Millisecond)) {
+      t.Errorf("%s: another client was limited too", name)
+    }
+    if !l.allow("a", base.Add(3*time.Second)) {
+      t.Errorf("%s: still limited seconds later", name)
+    }
+  }
+}
+
+// Clients that have gone quiet are forgotten once a later request comes
+// in, but not one still being limited.
+func TestIdleClientsSwept(t *testing.T) {
+  for _, name := range algorithms {
+    l, _ := newLimiter(name, 5, time.Second)
+    for i := range 1000 {
+      l.allow(fmt.Sprint("client", i), base)
+    }
+    for range 10 {
+      l.allow("busy", base.Add(2*time.Second))
+    }
+    if l.allow("newcomer", base.Add(2050*time.Millisecond)); clients(l) != 2 {
+      t.Errorf("%s: holds %d clients, want busy and newcomer", name, clients(l))
+    }
+    if l.allow("busy", base.Add(2050*time.Millisecond)) {
+      t.Errorf("%s: the busy client's limit was swept away", name)
+    }
+  }
+}
+
+// Sweeping only drops state no different from a new client's, so the
+// decisions are the same as when nothing is ever swept.
+func TestSweepingChangesNothing(t *testing.T) {
+  for _, name := range algorithms {
+    r := rand.New(rand.NewSource(1))
+    swept, kept := mustLimiter(t, name), mustLimiter(t, name)
+    neverSweep(kept)
+    now := base
+    for i := range 20000 {
+      // Mostly bursts over the limit, now and then a quiet spell.
+      gap := time.Duration(r.Intn(15)) * time.Millisecond
+      if r.Intn(100) == 0 {
+        gap = time.Duration(r.Intn(1500)) * time.Millisecond
+      }
+      now = now.Add(gap)
+      key := fmt.Sprint("regular", r.Intn(8))
+      if r.Intn(10) == 0 {
+        key = fmt.Sprint("passing", r.Intn(200))
+      }
+      if a, b := swept.allow(key, now), kept.allow(key, now); a != b {
+        t.Fatalf("%s: request %d from %s at %v: %v with sweeps, %v without", name, i, key, now.Sub(base), a, b)
+      }
+    }
+    if clients(swept) >= clients(kept) {
+      t.Errorf("%s: nothing was swept", name)
+    }
+  }
+}
+
+func mustLimiter(t *testing.T, name string) limiter {
+  l, err := newLimiter(name, 4, 500*time.Millisecond)
+  if err != nil {
+    t.Fatal(err)
+  }
+  return l
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+  "flag"
+  "fmt"
+  "net"
+  "net/http"
+  "os"
+  "time"
+)
+
+// Build the limiter selected on the command line. Every algorithm is
+// configured to allow roughly limit requests per window.
+func newLimiter(algorithm string, limit int, window time.Duration) (limiter, error) {
+  switch algorithm {
+  case "token-bucket":
+    return newTokenBucket(limit, float64(limit)/window.Seconds()), nil
+  case "fixed-window":
+    return newFixedWindow(limit, window), nil
+  case "sliding-log":
+    return newSlidingLog(limit, window), nil
+  case "sliding-counter":
+    return newSlidingCounter(limit, window), nil
+  }
+  return nil, fmt.Errorf("unknown algorithm %q", algorithm)
+}
+
+// Wrap a handler so requests over the client's limit get a 429 response.
+// Clients are keyed by their remote IP address.
+func middleware(l limiter, next http.Handler) http.Handler {
+  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    ip, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+      ip = r.RemoteAddr
+    }
+    if !l.allow(ip, time.Now()) {
+      http.Error(w, "Too many requests", http.StatusTooManyRequests)
+      return
+    }
+    next.ServeHTTP(w, r)
+  })
+}
+
+// Replay the same synthetic traffic against every algorithm and print how
+// many requests each accepts. The traffic is quiet, then a burst straddling
+// a window boundary, then a steady trickle, which is where the algorithms
+// disagree the most.
+func demo(limit int, window time.Duration) {
+  var times []time.Duration
+  for i := 0; i < limit/2; i++ {
+    times = append(times, time.Duration(i)*window/time.Duration(limit))
+  }
+  burst := window - window/10
+  for i := 0; i < 2*limit; i++ {
+    times = append(times, burst+time.Duration(i)*window/time.Duration(10*limit))
+  }
+  for i := 0; i < 2*limit; i++ {
+    times = append(times, 2*window+time.Duration(i)*window/time.Duration(limit))
+  }
+
+  base := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+  fmt.Printf("%d requests, limit %d per %v\n", len(times), limit, window)
+  for _, name := range []string{"token-bucket", "fixed-window", "sliding-log", "sliding-counter"} {
+    l, _ := newLimiter(name, limit, window)
+    accepted, burstAccepted := 0, 0
+    for _, t := range times {
+      if l.allow("client", base.Add(t)) {
+        accepted++
+        if t >= burst && t < burst+window/5 {
+          burstAccepted++
+        }
+      }
+    }
+    fmt.Printf("%-16s accepted %4d (%d during the boundary burst)\n", name, accepted, burstAccepted)
+  }
+}
+
+// Serve a rate limited endpoint and an unlimited one, or run the offline
+// burst comparison with -demo.
+func main() {
+  addr := flag.String("addr", ":8080", "address to listen on")
+  algorithm := flag.String("algo", "token-bucket", "token-bucket, fixed-window, sliding-log or sliding-counter")
+  limit := flag.Int("limit", 10, "requests allowed per window")
+  window := flag.Duration("window", time.Second, "window size")
+  runDemo := flag.Bool("demo", false, "compare the algorithms on synthetic burst traffic and exit")
+  flag.Parse()
+
+  if *limit <= 0 || *window <= 0 {
+    fmt.Fprintln(os.Stderr, "limit and window must be positive")
+    os.Exit(2)
+  }
+  if *runDemo {
+    demo(*limit, *window)
+    return
+  }
+
+  l, err := newLimiter(*algorithm, *limit, *window)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    os.Exit(2)
+  }
+  mux := http.NewServeMux()
+  mux.Handle("/limited", middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    fmt.Fprintln(w, "Limited, don't over use me!")
+  })))
+  mux.HandleFunc("/unlimited", func(w http.ResponseWriter, r *http.Request) {
+    fmt.Fprintln(w, "Unlimited! Let's Go!")
+  })
+  fmt.Println("Listening on", *addr, "using", *algorithm)
+  if err := http.ListenAndServe(*addr, mux); err != nil {
+    panic(err)
+  }
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+  "bufio"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "math"
+  "net"
+  "strconv"
+  "strings"
+  "sync"
+  "time"
+)
+
+// A single stored value together with its optional expiry time. A zero
+// expiry means the key lives forever.
+type entry struct {
+  value   string
+  expires time.Time
+}
+
+// The key space shared by every client connection, guarded by a mutex.
+type store struct {
+  mu   sync.Mutex
+  data map[string]entry
+}
+
+func newStore() *store {
+  return &store{data: make(map[string]entry)}
+}
+
+// Look up a key, lazily deleting it when it has already expired. The caller
+// must hold the lock.
+func (s *store) lookup(key string) (entry, bool) {
+  e, ok := s.data[key]
+  if ok && !e.expires.IsZero() && time.Now().After(e.expires) {
+    delete(s.data, key)
+    return entry{}, false
+  }
+  return e, ok
+}
+
+// Periodically sample keys and drop expired ones so that keys which are never
+// read again do not leak memory, the same idea Redis uses for active expiry.
+func (s *store) expireLoop(interval time.Duration) {
+  for range time.Tick(interval) {
+    s.mu.Lock()
+    checked := 0
+    for key := range s.data {
+      s.lookup(key)
+      checked++
+      if checked >= 100 {
+        break
+      }
+    }
+    s.mu.Unlock()
+  }
+}
+
+// Limits on what one command may ask the server to hold, Redis's own: the
+// elements of a multibulk, the bytes of a bulk string, and the length of
+// any line, inline commands included. A client asking for more is sent a
+// protocol error and disconnected, rather than trusted with the memory.
+const (
+  maxArgs   = 1024 * 1024
+  maxBulk   = 512 * 1024 * 1024
+  maxInline = 64 * 1024
+)
+
+// Read one RESP value from the reader. Clients send commands as arrays of
+// bulk strings, but inline commands (plain text lines, as typed in telnet)
+// are accepted too.
+func readCommand(r *bufio.Reader) ([]string, error) {
+  line, err := readLine(r)
+  if err != nil {
+    return nil, err
+  }
+  if len(line) == 0 {
+    return nil, nil
+  }
+  if line[0] != '*' {
+    return strings.Fields(line), nil
+  }
+
+  n, err := strconv.Atoi(line[1:])
+  if err != nil || n < 0 || n > maxArgs {
+    return nil, errors.New("invalid multibulk length")
+  }
+  // The count is only the client's word, so the slice grows as the
+  // arguments actually arrive.
+  args := make([]string, 0, min(n, 16))
+  for i := 0; i < n; i++ {
+    header, err := readLine(r)
+    if err != nil {
+      return nil, err
+    }
+    if len(header) == 0 || header[0] != '$' {
+      return nil, fmt.Errorf("expected '$', got '%s'", header)
+    }
+    size, err := strconv.Atoi(header[1:])
+    if err != nil || size < 0 || size > maxBulk {
+      return nil, errors.New("invalid bulk length")
+    }
+    // Copy the payload as it arrives rather than allocate the declared
+    // size up front, so a length alone cannot take the memory.
+    var buf strings.Builder
+    if _, err := io.CopyN(&buf, r, int64(size)); err != nil {
+      if err == io.EOF {
+        err = io.ErrUnexpectedEOF
+      }
+      return nil, err
+    }
+    var crlf [2]byte
+    if _, err := io.ReadFull(r, crlf[:]); err != nil {
+      return nil, err
+    }
+    if crlf != [2]byte{'\r', '\n'} {
+      return nil, errors.New("expected CRLF after bulk string")
+    }
+    args = append(args, buf.String())
+  }
+  return args, nil
+}
+
+// Read a CRLF terminated line, returning it without the terminator. A
+// line longer than maxInline is an error.
+func readLine(r *bufio.Reader) (string, error) {
+  var line []byte
+  for {
+    chunk, err := r.ReadSlice('\n')
+    if len(line)+len(chunk) > maxInline+2 {
+      return "", errors.New("too big inline request")
+    }
+    line = append(line, chunk...)
+    if err == nil {
+      break
+    }
+    if err != bufio.ErrBufferFull {
+      return "", err
+    }
+  }
+  return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// RESP encoders for the reply types used by the supported commands.
+func simpleString(s string) string { return "+" + s + "\r\n" }
+func errorReply(s string) string    { return "-ERR " + s + "\r\n" }
+func integer(n int64) string        { return ":" + strconv.FormatInt(n, 10) + "\r\n" }
+func bulkString(s string) string    { return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n" }
+
+const nullBulk = "$-1\r\n"
+
+// Run a single command against the store and return the encoded reply.
+func (s *store) execute(args []string) string {
+  if len(args) == 0 {
+    return errorReply("empty command")
+  }
+  name := strings.ToUpper(args[0])
+  // Arities count the command name, as Redis's do; a negative one is a
+  // minimum, and any other must be met exactly.
+  arity := map[string]int{
+    "PING": -1, "ECHO": 2, "GET": 2, "SET": -3, "DEL": -2, "EXISTS": -2,
+    "EXPIRE": 3, "TTL": 2, "INCR": 2, "DECR": 2,
+  }
+  n, ok := arity[name]
+  if !ok {
+    return errorReply(fmt.Sprintf("unknown command '%s'", args[0]))
+  }
+  if n > 0 && len(args) != n || n < 0 && len(args) < -n || name == "PING" && len(args) > 2 {
+    return errorReply(fmt.Sprintf("wrong number of arguments for '%s' command", strings.ToLower(name)))
+  }
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  switch name {
+  case "PING":
+    if len(args) > 1 {
+      return bulkString(args[1])
+    }
+    return simpleString("PONG")
+  case "ECHO":
+    return bulkString(args[1])
+  case "GET":
+    e, ok := s.lookup(args[1])
+    if !ok {
+      return nullBulk
+    }
+    return bulkString(e.value)
+  case "SET":
+    e := entry{value: args[2]}
+    for i := 3; i < len(args); i++ {
+      opt := strings.ToUpper(args[i])
+      if (opt == "EX" || opt == "PX") && i+1 < len(args) {
+        n, err := strconv.ParseInt(args[i+1], 10, 64)
+        if err != nil || n <= 0 {
+          return errorReply("invalid expire time in 'set' command")
+        }
+        unit := time.Second
+        if opt == "PX" {
+          unit = time.Millisecond
+        }
+        e.expires = time.Now().Add(time.Duration(n) * unit)
+        i++
+      } else {
+        return errorReply("syntax error")
+      }
+    }
+    s.data[args[1]] = e
+    return simpleString("OK")
+  case "DEL", "EXISTS":
+    var n int64
+    for _, key := range args[1:] {
+      if _, ok := s.lookup(key); ok {
+        n++
+        if name == "DEL" {
+          delete(s.data, key)
+        }
+      }
+    }
+    return integer(n)
+  case "EXPIRE":
+    secs, err := strconv.ParseInt(args[2], 10, 64)
+    if err != nil {
+      return errorReply("value is not an integer or out of range")
+    }
+    e, ok := s.lookup(args[1])
+    if !ok {
+      return integer(0)
+    }
+    if secs <= 0 {
+      delete(s.data, args[1])
+      return integer(1)
+    }
+    e.expires = time.Now().Add(time.Duration(secs) * time.Second)
+    s.data[args[1]] = e
+    return integer(1)
+  case "TTL":
+    e, ok := s.lookup(args[1])
+    if !ok {
+      return integer(-2)
+    }
+    if e.expires.IsZero() {
+      return integer(-1)
+    }
+    return integer(int64(time.Until(e.expires).Round(time.Second) / time.Second))
+  case "INCR", "DECR":
+    e, _ := s.lookup(args[1])
+    n := int64(0)
+    if e.value != "" {
+      v, err := strconv.ParseInt(e.value, 10, 64)
+      if err != nil {
+        return errorReply("value is not an integer or out of range")
+      }
+      n = v
+    }
+    if name == "INCR" && n == math.MaxInt64 || name == "DECR" && n == math.MinInt64 {
+      return errorReply("increment or decrement would overflow")
+    }
+    if name == "INCR" {
+      n++
+    } else {
+      n--
+    }
+    e.value = strconv.FormatInt(n, 10)
+    s.data[args[1]] = e
+    return integer(n)
+  }
+  return errorReply("unreachable")
+}
+
+// Serve one client connection until it disconnects, answering each command
+// in order. Replies are buffered and flushed once the client has no more
+// pipelined commands waiting.
+func (s *store) handle(conn net.Conn) {
+  defer conn.Close()
+  r := bufio.NewReader(conn)
+  w := bufio.NewWriter(conn)
+  for {
+    args, err := readCommand(r)
+    if err != nil {
+      if err != io.EOF {
+        w.WriteString(errorReply("Protocol error: " + err.Error()))
+        w.Flush()
+      }
+      return
+    }
+    if args == nil {
+      continue
+    }
+    if strings.ToUpper(args[0]) == "QUIT" {
+      w.WriteString(simpleString("OK"))
+      w.Flush()
+      return
+    }
+    w.WriteString(s.execute(args))
+    if r.Buffered() == 0 {
+      if err := w.Flush(); err != nil {
+        return
+      }
+    }
+  }
+}
+
+// Listen on the configured address and serve every client in its own
+// goroutine.
+func main() {
+  addr := flag.String("addr", ":6379", "address to listen on")
+  flag.Parse()
+
+  listener, err := net.Listen("tcp", *addr)
+  if err != nil {
+    panic(err)
+  }
+  fmt.Println("Listening on", listener.Addr())
+
+  s := newStore()
+  go s.expireLoop(100 * time.Millisecond)
+  for {
+    conn, err := listener.Accept()
+    if err != nil {
+      fmt.Println("accept:", err)
+      continue
+    }
+    go s.handle(conn)
+  }
+}
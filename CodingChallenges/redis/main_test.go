@@ -0,0 +1,124 @@
+package main
+
+import (
+  "bufio"
+  "io"
+  "net"
+  "runtime"
+  "slices"
+  "strings"
+  "testing"
+)
+
+func TestReadCommand(t *testing.T) {
+  for _, c := range []struct {
+    name, in string
+    want     []string
+    err      string
+  }{
+    {"multibulk", "*2\r\n$4\r\nECHO\r\n$5\r\nhi\r\nx\r\n", []string{"ECHO", "hi\r\nx"}, ""},
+    {"inline", "SET a 1\r\n", []string{"SET", "a", "1"}, ""},
+    {"empty line", "\r\n", nil, ""},
+    {"empty multibulk", "*0\r\n", []string{}, ""},
+    {"null multibulk", "*-1\r\n", nil, "invalid multibulk length"},
+    {"negative multibulk", "*-5\r\n", nil, "invalid multibulk length"},
+    {"huge multibulk", "*99999999999\r\n", nil, "invalid multibulk length"},
+    {"bad multibulk", "*x\r\n", nil, "invalid multibulk length"},
+    {"negative bulk", "*1\r\n$-1\r\n", nil, "invalid bulk length"},
+    {"huge bulk", "*1\r\n$1073741824\r\n", nil, "invalid bulk length"},
+    {"not a bulk", "*1\r\n:1\r\n", nil, "expected '$', got ':1'"},
+    {"long inline", strings.Repeat("a", maxInline+1) + "\r\n", nil, "too big inline request"},
+    {"short bulk", "*1\r\n$10\r\nabc\r\n", nil, io.ErrUnexpectedEOF.Error()},
+    {"missing CRLF", "*1\r\n$3\r\nabc", nil, io.EOF.Error()},
+    {"bad CRLF", "*1\r\n$3\r\nabcd\r\n", nil, "expected CRLF after bulk string"},
+  } {
+    got, err := readCommand(bufio.NewReader(strings.NewReader(c.in)))
+    switch {
+    case c.err != "" && (err == nil || err.Error() != c.err):
+      t.Errorf("%s: got error %v, want %q", c.name, err, c.err)
+    case c.err == "" && err != nil:
+      t.Errorf("%s: unexpected error %v", c.name, err)
+    case c.err == "" && (!slices.Equal(got, c.want) || (got == nil) != (c.want == nil)):
+      t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+    }
+  }
+}
+
+// A client on one end of a pipe, served by handle on the other.
+func dial(t *testing.T, s *store) (net.Conn, *bufio.Reader) {
+  client, server := net.Pipe()
+  go s.handle(server)
+  t.Cleanup(func() { client.Close() })
+  return client, bufio.NewReader(client)
+}
+
+func TestSession(t *testing.T) {
+  conn, r := dial(t, newStore())
+  for _, c := range []struct{ send, want string }{
+    {"PING\r\n", "+PONG\r\n"},
+    {"*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$2\r\n41\r\n", "+OK\r\n"},
+    {"*2\r\n$4\r\nINCR\r\n$1\r\nk\r\n", ":42\r\n"},
+    {"GET k\r\n", "$2\r\n42\r\n"},
+    {"GET missing\r\n", "$-1\r\n"},
+    {"EXISTS k missing\r\n", ":1\r\n"},
+    {"TTL k\r\n", ":-1\r\n"},
+    {"SET k v EX 0\r\n", "-ERR invalid expire time in 'set' command\r\n"},
+    {"NOPE\r\n", "-ERR unknown command 'NOPE'\r\n"},
+    {"GET\r\n", "-ERR wrong number of arguments for 'get' command\r\n"},
+    {"GET a b\r\n", "-ERR wrong number of arguments for 'get' command\r\n"},
+    {"INCR a b\r\n", "-ERR wrong number of arguments for 'incr' command\r\n"},
+    {"PING a b\r\n", "-ERR wrong number of arguments for 'ping' command\r\n"},
+    {"PING hi\r\n", "$2\r\nhi\r\n"},
+    {"SET n 9223372036854775806 EX 100\r\n", "+OK\r\n"},
+    {"INCR n\r\n", ":9223372036854775807\r\n"},
+    {"INCR n\r\n", "-ERR increment or decrement would overflow\r\n"},
+    {"GET n\r\n", "$19\r\n9223372036854775807\r\n"},
+    {"SET n -9223372036854775808\r\n", "+OK\r\n"},
+    {"DECR n\r\n", "-ERR increment or decrement would overflow\r\n"},
+    {"DEL k n missing\r\n", ":2\r\n"},
+    {"QUIT\r\n", "+OK\r\n"},
+  } {
+    if _, err := io.WriteString(conn, c.send); err != nil {
+      t.Fatalf("%q: %v", c.send, err)
+    }
+    got, err := r.ReadString('\n')
+    if strings.HasPrefix(got, "$") && got != "$-1\r\n" {
+      rest, _ := r.ReadString('\n')
+      got += rest
+    }
+    if err != nil || got != c.want {
+      t.Errorf("%q: got %q, %v; want %q", c.send, got, err, c.want)
+    }
+  }
+}
+
+// A bad length gets a protocol error and the connection closed, before
+// the server makes room for anything.
+func TestProtocolError(t *testing.T) {
+  for _, send := range []string{"*-1\r\n", "*2147483648\r\n", "*1\r\n$9999999999\r\n"} {
+    conn, r := dial(t, newStore())
+    io.WriteString(conn, send)
+    got, _ := r.ReadString('\n')
+    if !strings.HasPrefix(got, "-ERR Protocol error: invalid") {
+      t.Errorf("%q: got %q, want a protocol error", send, got)
+    }
+    if _, err := r.ReadByte(); err != io.EOF {
+      t.Errorf("%q: connection left open (%v)", send, err)
+    }
+  }
+}
+
+// A bulk length is only the client's word: declaring the largest allowed
+// and then sending a few bytes costs the server what was sent, not 512 MB.
+func TestBulkLengthNotTrusted(t *testing.T) {
+  var before, after runtime.MemStats
+  runtime.ReadMemStats(&before)
+  _, err := readCommand(bufio.NewReader(strings.NewReader("*1\r\n$536870912\r\nabc")))
+  runtime.ReadMemStats(&after)
+  if err != io.ErrUnexpectedEOF {
+    t.Errorf("got error %v, want %v", err, io.ErrUnexpectedEOF)
+  }
+  if grew := after.TotalAlloc - before.TotalAlloc; grew > 1<<20 {
+    t.Errorf("reading the command allocated %d bytes", grew)
+  }
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+)
+
+func main() {
+  ignoreCase := flag.Bool("i", false, "ignore case distinctions")
+  onlyMatching := flag.Bool("o", false, "print only the matched part of each line")
+  invert := flag.Bool("v", false, "select non-matching lines")
+  flag.Parse()
+
+  if flag.NArg() == 0 {
+    fmt.Fprintln(os.Stderr, "usage: regex [-i] [-o] [-v] pattern [file...]")
+    os.Exit(2)
+  }
+  re, err := compile(flag.Arg(0), *ignoreCase)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "regex:", err)
+    os.Exit(2)
+  }
+
+  w := bufio.NewWriter(os.Stdout)
+  defer w.Flush()
+  status := 1
+  search := func(r io.Reader) error {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 64*1024), 1<<30)
+    for scanner.Scan() {
+      line := []rune(scanner.Text())
+      start, end := re.find(line, 0)
+      if (start >= 0) == *invert {
+        continue
+      }
+      status = 0
+      switch {
+      case *onlyMatching && !*invert:
+        // Print every non-empty match on the line.
+        for start >= 0 {
+          if end > start {
+            fmt.Fprintln(w, string(line[start:end]))
+          }
+          next := max(end, start+1)
+          if next > len(line) {
+            break
+          }
+          start, end = re.find(line, next)
+        }
+      default:
+        fmt.Fprintln(w, string(line))
+      }
+    }
+    return scanner.Err()
+  }
+
+  files := flag.Args()[1:]
+  if len(files) == 0 {
+    files = []string{"-"}
+  }
+  for _, name := range files {
+    var err error
+    if name == "-" {
+      err = search(os.Stdin)
+    } else if f, openErr := os.Open(name); openErr != nil {
+      err = openErr
+    } else {
+      err = search(f)
+      f.Close()
+    }
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "regex:", err)
+      status = 2
+    }
+  }
+  w.Flush()
+  os.Exit(status)
+}
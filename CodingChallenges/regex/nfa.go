@@ -0,0 +1,389 @@
+package main
+
+import (
+  "fmt"
+  "unicode"
+)
+
+// Patterns compile to a Thompson NFA: each state either consumes one
+// character from a class, splits into two epsilon transitions, checks an
+// anchor, or accepts. Matching simulates every path at once, so the running
+// time is O(len(pattern) * len(text)) whatever the pattern.
+
+type stateKind int
+
+const (
+  consume stateKind = iota
+  split
+  assertBegin
+  assertEnd
+  accept
+)
+
+type runeRange struct {
+  lo, hi rune
+}
+
+// A character class. "." is a negated empty class; newlines are not special.
+type class struct {
+  ranges []runeRange
+  negate bool
+}
+
+func (c *class) matches(r rune, fold bool) bool {
+  found := c.contains(r)
+  if !found && fold {
+    for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+      if c.contains(f) {
+        found = true
+        break
+      }
+    }
+  }
+  return found != c.negate
+}
+
+func (c *class) contains(r rune) bool {
+  for _, rr := range c.ranges {
+    if r >= rr.lo && r <= rr.hi {
+      return true
+    }
+  }
+  return false
+}
+
+type state struct {
+  kind stateKind
+  cls  *class
+  out  *state
+  out1 *state // second branch of a split
+  id   int
+}
+
+// A fragment under construction: its start state and the dangling
+// transitions still to be connected to whatever comes next.
+type fragment struct {
+  start *state
+  outs  []**state
+}
+
+func (f fragment) patch(s *state) {
+  for _, p := range f.outs {
+    *p = s
+  }
+}
+
+type regex struct {
+  start  *state
+  states int
+  fold   bool
+}
+
+type parser struct {
+  pattern []rune
+  pos     int
+  states  int
+}
+
+func (p *parser) newState(kind stateKind) *state {
+  p.states++
+  return &state{kind: kind, id: p.states - 1}
+}
+
+// Compile a pattern, reporting syntax errors with their offset.
+func compile(pattern string, fold bool) (*regex, error) {
+  p := &parser{pattern: []rune(pattern)}
+  f, err := p.alternation()
+  if err != nil {
+    return nil, err
+  }
+  if p.pos < len(p.pattern) {
+    return nil, fmt.Errorf("unmatched ) at offset %d", p.pos)
+  }
+  f.patch(p.newState(accept))
+  return &regex{start: f.start, states: p.states, fold: fold}, nil
+}
+
+func (p *parser) more() bool {
+  return p.pos < len(p.pattern)
+}
+
+func (p *parser) peek() rune {
+  return p.pattern[p.pos]
+}
+
+// alternation := concat ("|" concat)*
+func (p *parser) alternation() (fragment, error) {
+  left, err := p.concat()
+  if err != nil {
+    return fragment{}, err
+  }
+  for p.more() && p.peek() == '|' {
+    p.pos++
+    right, err := p.concat()
+    if err != nil {
+      return fragment{}, err
+    }
+    s := p.newState(split)
+    s.out, s.out1 = left.start, right.start
+    left = fragment{s, append(left.outs, right.outs...)}
+  }
+  return left, nil
+}
+
+// concat := repeat*, where an empty sequence matches the empty string.
+func (p *parser) concat() (fragment, error) {
+  var f *fragment
+  for p.more() && p.peek() != '|' && p.peek() != ')' {
+    next, err := p.repeat()
+    if err != nil {
+      return fragment{}, err
+    }
+    if f == nil {
+      f = &next
+    } else {
+      f.patch(next.start)
+      f.outs = next.outs
+    }
+  }
+  if f == nil {
+    // An epsilon: a split whose branches both lead onwards.
+    s := p.newState(split)
+    return fragment{s, []**state{&s.out, &s.out1}}, nil
+  }
+  return *f, nil
+}
+
+// repeat := atom ("*" | "+" | "?")*
+func (p *parser) repeat() (fragment, error) {
+  atom, err := p.atom()
+  if err != nil {
+    return fragment{}, err
+  }
+  for p.more() && (p.peek() == '*' || p.peek() == '+' || p.peek() == '?') {
+    s := p.newState(split)
+    switch p.peek() {
+    case '*':
+      s.out = atom.start
+      atom.patch(s)
+      atom = fragment{s, []**state{&s.out1}}
+    case '+':
+      s.out = atom.start
+      atom.patch(s)
+      atom = fragment{atom.start, []**state{&s.out1}}
+    case '?':
+      s.out = atom.start
+      atom = fragment{s, append(atom.outs, &s.out1)}
+    }
+    p.pos++
+  }
+  return atom, nil
+}
+
+func (p *parser) atom() (fragment, error) {
+  start := p.pos
+  c := p.peek()
+  p.pos++
+  switch c {
+  case '(':
+    f, err := p.alternation()
+    if err != nil {
+      return fragment{}, err
+    }
+    if !p.more() || p.peek() != ')' {
+      return fragment{}, fmt.Errorf("missing ) for ( at offset %d", start)
+    }
+    p.pos++
+    return f, nil
+  case '*', '+', '?':
+    return fragment{}, fmt.Errorf("nothing to repeat at offset %d", start)
+  case '^', '$':
+    kind := assertBegin
+    if c == '$' {
+      kind = assertEnd
+    }
+    s := p.newState(kind)
+    return fragment{s, []**state{&s.out}}, nil
+  case '.':
+    return p.single(&class{negate: true}), nil
+  case '[':
+    cls, err := p.bracket(start)
+    if err != nil {
+      return fragment{}, err
+    }
+    return p.single(cls), nil
+  case '\\':
+    if !p.more() {
+      return fragment{}, fmt.Errorf("trailing backslash at offset %d", start)
+    }
+    cls := escapeClass(p.peek())
+    p.pos++
+    return p.single(cls), nil
+  }
+  return p.single(&class{ranges: []runeRange{{c, c}}}), nil
+}
+
+func (p *parser) single(cls *class) fragment {
+  s := p.newState(consume)
+  s.cls = cls
+  return fragment{s, []**state{&s.out}}
+}
+
+var (
+  digitRanges = []runeRange{{'0', '9'}}
+  wordRanges  = []runeRange{{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}}
+  spaceRanges = []runeRange{{'\t', '\r'}, {' ', ' '}}
+)
+
+// The class for an escape: \d \w \s and their negations, the usual control
+// character escapes, or the character itself.
+func escapeClass(c rune) *class {
+  switch c {
+  case 'd', 'D':
+    return &class{ranges: digitRanges, negate: c == 'D'}
+  case 'w', 'W':
+    return &class{ranges: wordRanges, negate: c == 'W'}
+  case 's', 'S':
+    return &class{ranges: spaceRanges, negate: c == 'S'}
+  case 'n':
+    c = '\n'
+  case 't':
+    c = '\t'
+  case 'r':
+    c = '\r'
+  }
+  return &class{ranges: []runeRange{{c, c}}}
+}
+
+// Parse a bracket expression after its "[". A "]" first in the list and a
+// "-" first or last are literal.
+func (p *parser) bracket(start int) (*class, error) {
+  cls := &class{}
+  if p.more() && p.peek() == '^' {
+    cls.negate = true
+    p.pos++
+  }
+  first := true
+  for {
+    if !p.more() {
+      return nil, fmt.Errorf("missing ] for [ at offset %d", start)
+    }
+    c := p.peek()
+    p.pos++
+    if c == ']' && !first {
+      return cls, nil
+    }
+    first = false
+    if c == '\\' && p.more() {
+      esc := escapeClass(p.peek())
+      p.pos++
+      if len(esc.ranges) != 1 || esc.negate || esc.ranges[0].lo != esc.ranges[0].hi {
+        if esc.negate {
+          return nil, fmt.Errorf("negated escape in [] at offset %d", p.pos-2)
+        }
+        cls.ranges = append(cls.ranges, esc.ranges...)
+        continue
+      }
+      c = esc.ranges[0].lo
+    }
+    lo, hi := c, c
+    if p.pos+1 < len(p.pattern) && p.peek() == '-' && p.pattern[p.pos+1] != ']' {
+      hi = p.pattern[p.pos+1]
+      p.pos += 2
+      if hi == '\\' && p.more() {
+        hi = escapeClass(p.peek()).ranges[0].lo
+        p.pos++
+      }
+      if hi < lo {
+        return nil, fmt.Errorf("invalid range %c-%c in [] at offset %d", lo, hi, start)
+      }
+    }
+    cls.ranges = append(cls.ranges, runeRange{lo, hi})
+  }
+}
+
+// A thread in the simulation: an NFA state and where its match began.
+type thread struct {
+  s     *state
+  start int
+}
+
+// The set of threads for one text position, in priority order.
+type threadList struct {
+  threads []thread
+}
+
+type matcher struct {
+  re   *regex
+  text []rune
+  seen []int // step at which each state was last added, so it is added once
+}
+
+// Follow epsilon transitions from s, adding every state reached to l.
+// Anchors are checked against position pos here, as they consume nothing.
+func (m *matcher) add(l *threadList, s *state, start, pos, step int) {
+  if s == nil || m.seen[s.id] == step {
+    return
+  }
+  m.seen[s.id] = step
+  switch s.kind {
+  case split:
+    m.add(l, s.out, start, pos, step)
+    m.add(l, s.out1, start, pos, step)
+  case assertBegin:
+    if pos == 0 {
+      m.add(l, s.out, start, pos, step)
+    }
+  case assertEnd:
+    if pos == len(m.text) {
+      m.add(l, s.out, start, pos, step)
+    }
+  default:
+    l.threads = append(l.threads, thread{s, start})
+  }
+}
+
+// Find the leftmost-longest match in text at or after from, returning its
+// rune offsets or -1, -1. Threads that started earlier take priority, so
+// once a match is found no new threads need to start.
+func (re *regex) find(text []rune, from int) (int, int) {
+  m := &matcher{re: re, text: text, seen: make([]int, re.states)}
+  for i := range m.seen {
+    m.seen[i] = -1
+  }
+  clist, nlist := &threadList{}, &threadList{}
+  bestStart, bestEnd := -1, -1
+
+  for pos := from; pos <= len(text); pos++ {
+    if bestStart < 0 {
+      m.add(clist, re.start, pos, pos, pos)
+    }
+    if len(clist.threads) == 0 {
+      break
+    }
+    nlist.threads = nlist.threads[:0]
+    for _, t := range clist.threads {
+      switch t.s.kind {
+      case accept:
+        if bestStart < 0 || t.start < bestStart || t.start == bestStart && pos > bestEnd {
+          bestStart, bestEnd = t.start, pos
+        }
+      case consume:
+        if bestStart >= 0 && t.start > bestStart {
+          continue
+        }
+        if pos < len(text) && t.s.cls.matches(text[pos], re.fold) {
+          m.add(nlist, t.s.out, t.start, pos+1, pos+1)
+        }
+      }
+    }
+    clist, nlist = nlist, clist
+  }
+  return bestStart, bestEnd
+}
+
+// Report whether the pattern matches anywhere in s.
+func (re *regex) matchString(s string) bool {
+  start, _ := re.find([]rune(s), 0)
+  return start >= 0
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+  "fmt"
+  "strings"
+  "testing"
+)
+
+// Match by exploring one path through the NFA at a time, retrying the
+// alternatives of each split on failure, as backtracking engines do. It
+// serves as a reference for the simulation and shows what the simulation
+// avoids. Patterns that repeat something able to match the empty string
+// send it round a loop forever.
+func (re *regex) backtrack(s *state, text []rune, pos int) bool {
+  switch s.kind {
+  case accept:
+    return true
+  case split:
+    return re.backtrack(s.out, text, pos) || re.backtrack(s.out1, text, pos)
+  case assertBegin:
+    return pos == 0 && re.backtrack(s.out, text, pos)
+  case assertEnd:
+    return pos == len(text) && re.backtrack(s.out, text, pos)
+  }
+  return pos < len(text) && s.cls.matches(text[pos], re.fold) && re.backtrack(s.out, text, pos+1)
+}
+
+// (a?){n}a{n} against a{n} makes a backtracking matcher try 2^n paths
+// before it finds the one that skips every optional a.
+func pathological(n int) (*regex, []rune) {
+  re, err := compile("^"+strings.Repeat("a?", n)+strings.Repeat("a", n)+"$", false)
+  if err != nil {
+    panic(err)
+  }
+  return re, []rune(strings.Repeat("a", n))
+}
+
+func TestFind(t *testing.T) {
+  for _, c := range []struct {
+    pattern, text string
+    fold          bool
+    start, end    int
+  }{
+    {"abc", "xxabcxx", false, 2, 5},
+    {"a*", "baaa", false, 0, 0},
+    {"a+", "baaa", false, 1, 4},
+    {"a|ab|abc", "abcd", false, 0, 3},
+    {"(a|b)*c", "abababc", false, 0, 7},
+    {"^b", "ab", false, -1, -1},
+    {"b$", "ab", false, 1, 2},
+    {"[^a-c]+", "abcdefa", false, 3, 6},
+    {`\d+`, "abc123def", false, 3, 6},
+    {"HELLO", "say hello", true, 4, 9},
+    {"x", "", false, -1, -1},
+  } {
+    re, err := compile(c.pattern, c.fold)
+    if err != nil {
+      t.Errorf("%s: %v", c.pattern, err)
+      continue
+    }
+    start, end := re.find([]rune(c.text), 0)
+    if start != c.start || end != c.end {
+      t.Errorf("%s in %q: got %d, %d, want %d, %d", c.pattern, c.text, start, end, c.start, c.end)
+    }
+  }
+}
+
+// The simulation agrees with backtracking on patterns small enough for
+// backtracking to finish.
+func TestPathologicalAgrees(t *testing.T) {
+  for n := 1; n <= 16; n++ {
+    re, text := pathological(n)
+    start, end := re.find(text, 0)
+    if start != 0 || end != n {
+      t.Errorf("n = %d: got %d, %d, want 0, %d", n, start, end, n)
+    }
+    if !re.backtrack(re.start, text, 0) {
+      t.Errorf("n = %d: backtracking found no match", n)
+    }
+    // One a short, neither matches.
+    if start, _ := re.find(text[1:], 0); start >= 0 {
+      t.Errorf("n = %d: matched %d a's", n, n-1)
+    }
+    if re.backtrack(re.start, text[1:], 0) {
+      t.Errorf("n = %d: backtracking matched %d a's", n, n-1)
+    }
+  }
+}
+
+// Sizes far beyond what backtracking could finish, and patterns that would
+// send it round an empty loop, match in time proportional to the text.
+func TestPathologicalLarge(t *testing.T) {
+  re, text := pathological(500)
+  if start, end := re.find(text, 0); start != 0 || end != 500 {
+    t.Errorf("(a?){500}a{500}: got %d, %d", start, end)
+  }
+  long := strings.Repeat("a", 10000)
+  for _, c := range []struct {
+    pattern, text string
+    start, end    int
+  }{
+    {"(a*)*b", long, -1, -1},
+    {"(a*)*b", long + "b", 0, 10001},
+    {"(a|aa)*c", long, -1, -1},
+    {"(x+x+)+y", strings.Repeat("x", 10000), -1, -1},
+    {"(a|a)*$", long, 0, 10000},
+  } {
+    re, err := compile(c.pattern, false)
+    if err != nil {
+      t.Fatal(err)
+    }
+    start, end := re.find([]rune(c.text), 0)
+    if start != c.start || end != c.end {
+      t.Errorf("%s: got %d, %d, want %d, %d", c.pattern, start, end, c.start, c.end)
+    }
+  }
+}
+
+func BenchmarkPathological(b *testing.B) {
+  for _, n := range []int{10, 20, 100} {
+    re, text := pathological(n)
+    b.Run(fmt.Sprintf("nfa/%d", n), func(b *testing.B) {
+      for b.Loop() {
+        re.find(text, 0)
+      }
+    })
+    if n > 20 {
+      continue
+    }
+    b.Run(fmt.Sprintf("backtrack/%d", n), func(b *testing.B) {
+      for b.Loop() {
+        re.backtrack(re.start, text, 0)
+      }
+    })
+  }
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+  "bufio"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "regexp"
+  "strconv"
+  "strings"
+)
+
+// An address selects lines by number, "$" for the last line, or a regex.
+type address struct {
+  line int
+  last bool
+  re   *regexp.Regexp
+}
+
+// A parsed command with up to two addresses. For ranges, active records
+// whether we are currently inside the range.
+type command struct {
+  addr1, addr2 *address
+  negate       bool
+  name         byte
+
+  // s command
+  re          *regexp.Regexp
+  replacement string
+  global      bool
+  occurrence  int
+  print       bool
+
+  active bool
+}
+
+// The regular expressions use Go's RE2 syntax, which is close to POSIX ERE
+// (sed -E). Without -E, patterns are BREs and are translated first.
+func compileRegex(pattern string, ignoreCase, extended bool) (*regexp.Regexp, error) {
+  if !extended {
+    pattern = translateBRE(pattern)
+  }
+  if ignoreCase {
+    pattern = "(?i)" + pattern
+  }
+  return regexp.Compile(pattern)
+}
+
+// Translate a POSIX basic regular expression to the extended syntax. In a
+// BRE "\(", "\)", "\{", "\}" and, as GNU extensions, "\+", "\?" and "\|"
+// are the operators and the bare characters are literals, the other way
+// round from an ERE. A "*" is literal at the start of the expression, a
+// group or an alternative, as "^" is anywhere but there and "$" anywhere
+// but at the end of one. In a bracket expression a backslash is literal.
+func translateBRE(bre string) string {
+  var b strings.Builder
+  start := true
+  for i := 0; i < len(bre); i++ {
+    c := bre[i]
+    switch {
+    case c == '\\' && i+1 < len(bre):
+      i++
+      switch d := bre[i]; d {
+      case '(', '|':
+        b.WriteByte(d)
+        start = true
+        continue
+      case ')', '{', '}', '+', '?':
+        b.WriteByte(d)
+      default:
+        b.WriteByte(c)
+        b.WriteByte(d)
+      }
+    case c == '[':
+      j := bracketEnd(bre, i)
+      b.WriteString(strings.ReplaceAll(bre[i:j], `\`, `\\`))
+      i = j - 1
+    case strings.IndexByte("(){}+?|", c) >= 0, c == '*' && start, c == '^' && !start:
+      b.WriteByte('\\')
+      b.WriteByte(c)
+    case c == '^':
+      b.WriteByte(c)
+      continue
+    case c == '$' && !endsGroup(bre[i+1:]):
+      b.WriteString(`\$`)
+    default:
+      b.WriteByte(c)
+    }
+    start = false
+  }
+  return b.String()
+}
+
+// Whether a BRE ends here, or the group or alternative it is in does.
+func endsGroup(rest string) bool {
+  return rest == "" || strings.HasPrefix(rest, `\)`) || strings.HasPrefix(rest, `\|`)
+}
+
+// The index just past the bracket expression starting at re[i], or the end
+// of re if it is not closed. A "]" first in the list is part of it, as
+// are the ones ending "[:alpha:]", "[.x.]" and "[=x=]".
+func bracketEnd(re string, i int) int {
+  j := i + 1
+  if j < len(re) && re[j] == '^' {
+    j++
+  }
+  if j < len(re) && re[j] == ']' {
+    j++
+  }
+  for j < len(re) {
+    switch {
+    case re[j] == ']':
+      return j + 1
+    case re[j] == '[' && j+1 < len(re) && strings.IndexByte(":.=", re[j+1]) >= 0:
+      if k := strings.Index(re[j+2:], string(re[j+1])+"]"); k >= 0 {
+        j += k + 4
+        continue
+      }
+    }
+    j++
+  }
+  return len(re)
+}
+
+// Read text up to an unescaped delimiter, unescaping "\<delim>". Other
+// escapes are kept for the regex engine or the replacement.
+func readDelimited(script string, i int, delim byte) (string, int, error) {
+  var b strings.Builder
+  for i < len(script) {
+    c := script[i]
+    if c == '\\' && i+1 < len(script) {
+      if script[i+1] == delim {
+        b.WriteByte(delim)
+      } else if script[i+1] == 'n' {
+        b.WriteByte('\n')
+      } else {
+        b.WriteByte(c)
+        b.WriteByte(script[i+1])
+      }
+      i += 2
+      continue
+    }
+    if c == delim {
+      return b.String(), i + 1, nil
+    }
+    b.WriteByte(c)
+    i++
+  }
+  return "", i, fmt.Errorf("unterminated address or command, expected %q", delim)
+}
+
+func skipSpace(script string, i int) int {
+  for i < len(script) && (script[i] == ' ' || script[i] == '\t') {
+    i++
+  }
+  return i
+}
+
+// Parse one address at script[i], returning nil when there is none.
+func parseAddress(script string, i int, extended bool) (*address, int, error) {
+  if i >= len(script) {
+    return nil, i, nil
+  }
+  switch c := script[i]; {
+  case c >= '0' && c <= '9':
+    j := i
+    for j < len(script) && script[j] >= '0' && script[j] <= '9' {
+      j++
+    }
+    n, _ := strconv.Atoi(script[i:j])
+    return &address{line: n}, j, nil
+  case c == '$':
+    return &address{last: true}, i + 1, nil
+  case c == '/' || c == '\\':
+    delim := byte('/')
+    if c == '\\' {
+      if i+1 >= len(script) {
+        return nil, i, errors.New("unexpected end of script")
+      }
+      delim = script[i+1]
+      i++
+    }
+    pattern, j, err := readDelimited(script, i+1, delim)
+    if err != nil {
+      return nil, j, err
+    }
+    ignoreCase := false
+    if j < len(script) && script[j] == 'I' {
+      ignoreCase = true
+      j++
+    }
+    re, err := compileRegex(pattern, ignoreCase, extended)
+    if err != nil {
+      return nil, j, err
+    }
+    return &address{re: re}, j, nil
+  }
+  return nil, i, nil
+}
+
+// Parse a whole script of commands separated by ";" or newlines, with its
+// patterns extended regular expressions or, as sed's are by default, basic
+// ones.
+func parseScript(script string, extended bool) ([]*command, error) {
+  var cmds []*command
+  i := 0
+  for {
+    i = skipSpace(script, i)
+    for i < len(script) && (script[i] == ';' || script[i] == '\n') {
+      i = skipSpace(script, i+1)
+    }
+    if i >= len(script) {
+      return cmds, nil
+    }
+
+    cmd := &command{}
+    var err error
+    if cmd.addr1, i, err = parseAddress(script, i, extended); err != nil {
+      return nil, err
+    }
+    if cmd.addr1 != nil && i < len(script) && script[i] == ',' {
+      if cmd.addr2, i, err = parseAddress(script, i+1, extended); err != nil {
+        return nil, err
+      }
+      if cmd.addr2 == nil {
+        return nil, errors.New("unexpected `,'")
+      }
+    }
+    i = skipSpace(script, i)
+    if i < len(script) && script[i] == '!' {
+      cmd.negate = true
+      i = skipSpace(script, i+1)
+    }
+    if i >= len(script) {
+      return nil, errors.New("missing command")
+    }
+
+    cmd.name = script[i]
+    i++
+    switch cmd.name {
+    case 'p', 'd', 'q', '=':
+    case 's':
+      if i >= len(script) {
+        return nil, errors.New("unterminated `s' command")
+      }
+      delim := script[i]
+      var pattern string
+      if pattern, i, err = readDelimited(script, i+1, delim); err != nil {
+        return nil, errors.New("unterminated `s' command")
+      }
+      if cmd.replacement, i, err = readDelimited(script, i, delim); err != nil {
+        return nil, errors.New("unterminated `s' command")
+      }
+      ignoreCase := false
+      for i < len(script) && !strings.ContainsRune("; \t\n}", rune(script[i])) {
+        switch c := script[i]; {
+        case c == 'g':
+          cmd.global = true
+        case c == 'p':
+          cmd.print = true
+        case c == 'i' || c == 'I':
+          ignoreCase = true
+        case c >= '0' && c <= '9':
+          j := i
+          for j < len(script) && script[j] >= '0' && script[j] <= '9' {
+            j++
+          }
+          cmd.occurrence, _ = strconv.Atoi(script[i:j])
+          i = j - 1
+        default:
+          return nil, fmt.Errorf("unknown option to `s': %c", c)
+        }
+        i++
+      }
+      if cmd.re, err = compileRegex(pattern, ignoreCase, extended); err != nil {
+        return nil, err
+      }
+    default:
+      return nil, fmt.Errorf("unknown command: `%c'", cmd.name)
+    }
+    cmds = append(cmds, cmd)
+  }
+}
+
+func (a *address) matches(line string, n int, last bool) bool {
+  switch {
+  case a.re != nil:
+    return a.re.MatchString(line)
+  case a.last:
+    return last
+  }
+  return a.line == n
+}
+
+// Decide whether a command applies to the current line. A range starts when
+// addr1 matches and ends on the first later line matching addr2 (or at once
+// when addr2 is a line number not greater than the current line).
+func (c *command) selects(line string, n int, last bool) bool {
+  sel := false
+  switch {
+  case c.addr1 == nil:
+    sel = true
+  case c.addr2 == nil:
+    sel = c.addr1.matches(line, n, last)
+  case c.active:
+    sel = true
+    if c.addr2.matches(line, n, last) || (c.addr2.re == nil && !c.addr2.last && c.addr2.line <= n) {
+      c.active = false
+    }
+  case c.addr1.matches(line, n, last):
+    sel = true
+    c.active = true
+    if c.addr2.re == nil && !c.addr2.last && c.addr2.line <= n {
+      c.active = false
+    }
+    if c.addr2.last && last {
+      c.active = false
+    }
+  }
+  return sel != c.negate
+}
+
+// Expand a replacement for one match: "&" is the whole match, "\1".."\9" are
+// groups and "\&" or "\\" are literals.
+func expand(repl string, src string, m []int) string {
+  var b strings.Builder
+  for i := 0; i < len(repl); i++ {
+    c := repl[i]
+    switch {
+    case c == '&':
+      b.WriteString(src[m[0]:m[1]])
+    case c == '\\' && i+1 < len(repl):
+      i++
+      d := repl[i]
+      if d >= '0' && d <= '9' {
+        g := int(d - '0')
+        if 2*g+1 < len(m) && m[2*g] >= 0 {
+          b.WriteString(src[m[2*g]:m[2*g+1]])
+        }
+      } else {
+        b.WriteByte(d)
+      }
+    default:
+      b.WriteByte(c)
+    }
+  }
+  return b.String()
+}
+
+// Apply an s command, replacing the requested occurrence(s). Return the new
+// line and whether a substitution happened.
+func (c *command) substitute(line string) (string, bool) {
+  matches := c.re.FindAllStringSubmatchIndex(line, -1)
+  if len(matches) == 0 {
+    return line, false
+  }
+  var b strings.Builder
+  prev := 0
+  done := false
+  for k, m := range matches {
+    nth := k + 1
+    want := (c.occurrence == 0 && (c.global || nth == 1)) ||
+      (c.occurrence > 0 && (nth == c.occurrence || (c.global && nth > c.occurrence)))
+    if !want {
+      continue
+    }
+    b.WriteString(line[prev:m[0]])
+    b.WriteString(expand(c.replacement, line, m))
+    prev = m[1]
+    done = true
+  }
+  b.WriteString(line[prev:])
+  return b.String(), done
+}
+
+// The input files, or stdin when there are none, read one after another as
+// a single stream of lines, as sed numbers them. Each file is scanned on
+// its own, so a last line without a newline does not run into the next
+// file's first. A file that cannot be read is reported and skipped.
+type input struct {
+  names   []string
+  stdin   io.Reader
+  stderr  io.Writer
+  scanner *bufio.Scanner
+  file    *os.File
+  failed  bool // some file could not be read
+}
+
+// The next line of the stream, or false at its end.
+func (in *input) next() (string, bool) {
+  for {
+    if in.scanner != nil {
+      if in.scanner.Scan() {
+        return in.scanner.Text(), true
+      }
+      if err := in.scanner.Err(); err != nil {
+        fmt.Fprintln(in.stderr, "sed:", err)
+        in.failed = true
+      }
+      if in.file != nil {
+        in.file.Close()
+        in.file = nil
+      }
+      in.scanner = nil
+    }
+    if len(in.names) == 0 {
+      return "", false
+    }
+    name := in.names[0]
+    in.names = in.names[1:]
+    var r io.Reader = in.stdin
+    if name != "-" {
+      file, err := os.Open(name)
+      if err != nil {
+        fmt.Fprintln(in.stderr, "sed:", err)
+        in.failed = true
+        continue
+      }
+      in.file, r = file, file
+    }
+    in.scanner = bufio.NewScanner(r)
+    in.scanner.Buffer(make([]byte, 64*1024), 1<<30)
+  }
+}
+
+// Run the script over the input. The pattern space is printed at the end of
+// each cycle unless -n was given or the line was deleted.
+func run(cmds []*command, in *input, w *bufio.Writer, quiet bool) {
+  line, hasNext := in.next()
+  for n := 1; hasNext; n++ {
+    var next string
+    next, hasNext = in.next()
+    last := !hasNext
+
+    deleted, quit := false, false
+    for _, c := range cmds {
+      if !c.selects(line, n, last) {
+        continue
+      }
+      switch c.name {
+      case 'p':
+        fmt.Fprintln(w, line)
+      case '=':
+        fmt.Fprintln(w, n)
+      case 'd':
+        deleted = true
+      case 'q':
+        quit = true
+      case 's':
+        var ok bool
+        line, ok = c.substitute(line)
+        if ok && c.print {
+          fmt.Fprintln(w, line)
+        }
+      }
+      if deleted || quit {
+        break
+      }
+    }
+    if !deleted && !quiet {
+      fmt.Fprintln(w, line)
+    }
+    if quit {
+      return
+    }
+    line = next
+  }
+}
+
+// A repeatable -e flag.
+type scripts []string
+
+func (s *scripts) String() string     { return strings.Join(*s, "\n") }
+func (s *scripts) Set(v string) error { *s = append(*s, v); return nil }
+
+// Parse the options, compile the script and process each input file (or
+// stdin) as one continuous stream, as sed does. Files that cannot be read
+// are reported and skipped, and make the exit status 2.
+func main() {
+  quiet := flag.Bool("n", false, "suppress automatic printing of pattern space")
+  var extended bool
+  flag.BoolVar(&extended, "E", false, "use extended regular expressions")
+  flag.BoolVar(&extended, "r", false, "use extended regular expressions (same as -E)")
+  var exprs scripts
+  flag.Var(&exprs, "e", "add the script to the commands to be executed")
+  flag.Parse()
+
+  args := flag.Args()
+  if len(exprs) == 0 {
+    if len(args) == 0 {
+      fmt.Fprintln(os.Stderr, "usage: sed [-n] [-E] [-e script]... [script] [file...]")
+      os.Exit(1)
+    }
+    exprs = append(exprs, args[0])
+    args = args[1:]
+  }
+  cmds, err := parseScript(exprs.String(), extended)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "sed: -e expression:", err)
+    os.Exit(1)
+  }
+
+  if len(args) == 0 {
+    args = []string{"-"}
+  }
+  in := &input{names: args, stdin: os.Stdin, stderr: os.Stderr}
+  w := bufio.NewWriter(os.Stdout)
+  run(cmds, in, w, *quiet)
+  if in.file != nil {
+    in.file.Close()
+  }
+  if err := w.Flush(); err != nil {
+    fmt.Fprintln(os.Stderr, "sed:", err)
+    os.Exit(4)
+  }
+  if in.failed {
+    os.Exit(2)
+  }
+}
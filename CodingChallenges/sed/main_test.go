@@ -0,0 +1,123 @@
+package main
+
+import (
+  "bufio"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+// Run a script over the named files, returning what it printed and what it
+// reported.
+func sed(t *testing.T, script string, quiet bool, names ...string) (string, string, bool) {
+  t.Helper()
+  cmds, err := parseScript(script, true)
+  if err != nil {
+    t.Fatal(err)
+  }
+  var out, errs strings.Builder
+  in := &input{names: names, stdin: strings.NewReader(""), stderr: &errs}
+  w := bufio.NewWriter(&out)
+  run(cmds, in, w, quiet)
+  w.Flush()
+  return out.String(), errs.String(), in.failed
+}
+
+// Each testdata/NAME.sed run over testdata/input.in prints testdata/NAME.txt,
+// which was made by GNU sed -E -f NAME.sed input.in; a script starting with
+// a "#n" line runs with -n, as there. Run with AOC_UPDATE=1 to accept a
+// change, and check it against GNU sed before committing.
+func TestGolden(t *testing.T) {
+  scripts, _ := filepath.Glob(filepath.Join("testdata", "*.sed"))
+  if len(scripts) == 0 {
+    t.Fatal("no scripts in testdata")
+  }
+  for _, path := range scripts {
+    name := strings.TrimSuffix(filepath.Base(path), ".sed")
+    t.Run(name, func(t *testing.T) {
+      data, err := os.ReadFile(path)
+      if err != nil {
+        t.Fatal(err)
+      }
+      script, quiet := strings.CutPrefix(string(data), "#n\n")
+      out, _, _ := sed(t, script, quiet, filepath.Join("testdata", "input.in"))
+      testx.Snapshot(t, name, out)
+    })
+  }
+}
+
+// Files are one stream of lines, but a file whose last line has no newline
+// does not run into the next; one that cannot be read is skipped.
+func TestFiles(t *testing.T) {
+  dir := t.TempDir()
+  write := func(name, data string) string {
+    path := filepath.Join(dir, name)
+    if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+      t.Fatal(err)
+    }
+    return path
+  }
+  a := write("a", "one\ntwo")
+  b := write("b", "three\n")
+  out, errs, failed := sed(t, "=;$s/$/ (last)/", false, a, filepath.Join(dir, "missing"), b)
+  testx.Equal(t, out, "1\none\n2\ntwo\n3\nthree (last)\n")
+  testx.Equal(t, failed, true)
+  if !strings.Contains(errs, "missing") {
+    t.Errorf("the missing file was not reported: %q", errs)
+  }
+}
+
+// Without -E patterns are BREs, where the escaped characters are the
+// operators. Each output is what GNU sed 4.9 prints for the line.
+func TestBRE(t *testing.T) {
+  for _, c := range []struct{ script, line, want string }{
+    {`s/\(.\)\(.\)/\2\1/`, "ab cd", "ba cd"},
+    {`s/a\{2\}/X/g`, "aaaaa", "XXa"},
+    {`s/a\{1,2\}b/X/`, "caaab", "caX"},
+    {`s/x\+/X/`, "axxxb", "aXb"},
+    {`s/x\?y/Y/g`, "xy y", "Y Y"},
+    {`s/cat\|dog/pet/g`, "cat dog cow", "pet pet cow"},
+    {`s/\(a\|b\)\{2\}/X/`, "cabd", "cXd"},
+    {`s/^\(.*\)\.\(.*\)$/\2.\1/`, "one.two.three", "three.one.two"},
+    {`/b\+/s/^/>/`, "abbb", ">abbb"},
+    // The bare characters are literals.
+    {`s/a+b/X/`, "aab a+b", "aab X"},
+    {`s/(a)/X/`, "a (a)", "a X"},
+    {`s/{1}/X/`, "a{1}", "aX"},
+    {`s/a|b/X/`, "a a|b", "a X"},
+    {`s/a.c/X/`, "abc a.c", "X a.c"},
+    {`s/\./D/g`, "a.b.c", "aDbDc"},
+    // So is "*" where there is nothing to repeat, and "^" and "$" away
+    // from the ends.
+    {`s/*/S/`, "a*b*", "aSb*"},
+    {`s/\(*\)/S/`, "a*b", "aSb"},
+    {`s/^*/S/`, "*a", "Sa"},
+    {`s/a^b/X/`, "a^b ab", "X ab"},
+    {`s/b$c/X/`, "ab$c", "aX"},
+    {`s/c$/X/`, "abc", "abX"},
+    {`s/\(b$\)/X/`, "ab", "aX"},
+    {`s/x*/-/g`, "abc", "-a-b-c-"},
+    // Bracket expressions are copied, with a backslash literal in them.
+    {`s/[\]/S/`, `a\b`, "aSb"},
+    {`s/[]x]/S/g`, "a]x", "aSS"},
+    {`s/[[:digit:]]\+/N/`, "ab12c3", "abNc3"},
+    {`s/[^[:alpha:]]/_/g`, "a1 b2", "a__b_"},
+    {`s/\//|/g`, "a/b/c", "a|b|c"},
+  } {
+    cmds, err := parseScript(c.script, false)
+    if err != nil {
+      t.Errorf("%s: %v", c.script, err)
+      continue
+    }
+    var out strings.Builder
+    w := bufio.NewWriter(&out)
+    run(cmds, &input{names: []string{"-"}, stdin: strings.NewReader(c.line + "\n")}, w, false)
+    w.Flush()
+    if got := strings.TrimSuffix(out.String(), "\n"); got != c.want {
+      t.Errorf("%s on %q: got %q, want %q", c.script, c.line, got, c.want)
+    }
+  }
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+  "bufio"
+  "container/heap"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+// Options collected from the command line that control how lines are
+// compared and how much memory we are allowed to use before spilling to disk.
+type options struct {
+  numeric   bool
+  unique    bool
+  reverse   bool
+  key       int
+  separator string
+  chunkSize int
+  tmpDir    string
+}
+
+var opts options
+
+// Pull the sort key out of a line. When no key field is requested the whole
+// line is the key, otherwise fields are split by the separator (or by runs of
+// whitespace when no separator is given) and the 1-based field is returned.
+// Lines with too few fields sort as an empty key.
+func keyOf(line string) string {
+  if opts.key <= 0 {
+    return line
+  }
+  var fields []string
+  if opts.separator == "" {
+    fields = strings.Fields(line)
+  } else {
+    fields = strings.Split(line, opts.separator)
+  }
+  if opts.key > len(fields) {
+    return ""
+  }
+  return fields[opts.key-1]
+}
+
+// Parse the leading number of a key the way "sort -n" does: leading blanks
+// are skipped and anything that does not parse as a number counts as zero.
+func numberOf(key string) float64 {
+  key = strings.TrimSpace(key)
+  end := 0
+  for end < len(key) && (key[end] == '-' || key[end] == '.' ||
+    (key[end] >= '0' && key[end] <= '9')) {
+    end++
+  }
+  n, err := strconv.ParseFloat(key[:end], 64)
+  if err != nil {
+    return 0
+  }
+  return n
+}
+
+// Compare two lines using the active options. Return a negative number when a
+// sorts before b, positive when after, and zero when they are equal keys.
+func compare(a, b string) int {
+  ka, kb := keyOf(a), keyOf(b)
+  c := 0
+  if opts.numeric {
+    na, nb := numberOf(ka), numberOf(kb)
+    switch {
+    case na < nb:
+      c = -1
+    case na > nb:
+      c = 1
+    }
+  } else {
+    c = strings.Compare(ka, kb)
+  }
+  if opts.reverse {
+    c = -c
+  }
+  return c
+}
+
+// Sort a slice of lines in place. A stable sort keeps the input order of
+// lines with equal keys, matching what users expect from "sort -s".
+func sortLines(lines []string) {
+  sort.SliceStable(lines, func(i, j int) bool {
+    return compare(lines[i], lines[j]) < 0
+  })
+}
+
+// A buffered line writer that drops lines whose keys compare equal to the
+// previously written line when unique mode is enabled.
+type lineWriter struct {
+  w       *bufio.Writer
+  last    string
+  written bool
+}
+
+// Write a single line followed by a newline, skipping duplicates in unique
+// mode.
+func (lw *lineWriter) write(line string) error {
+  if opts.unique && lw.written && compare(lw.last, line) == 0 {
+    return nil
+  }
+  lw.last, lw.written = line, true
+  if _, err := lw.w.WriteString(line); err != nil {
+    return err
+  }
+  return lw.w.WriteByte('\n')
+}
+
+// Sort lines and write them to a temporary run file, returning its path.
+func spill(lines []string) (string, error) {
+  sortLines(lines)
+  file, err := os.CreateTemp(opts.tmpDir, "ccsort-run-*")
+  if err != nil {
+    return "", err
+  }
+  defer file.Close()
+  lw := &lineWriter{w: bufio.NewWriterSize(file, 1<<20)}
+  for _, line := range lines {
+    if err := lw.write(line); err != nil {
+      return file.Name(), err
+    }
+  }
+  return file.Name(), lw.w.Flush()
+}
+
+// Split the inputs into sorted runs of at most chunkSize bytes each, writing
+// every run to its own temporary file. Return the paths of the run files so
+// they can be merged afterwards, and the lines after the last full run,
+// which are still in memory: all of them when the input is smaller than a
+// run.
+func makeRuns(readers []io.Reader) ([]string, []string, error) {
+  var runs []string
+  var lines []string
+  size := 0
+
+  // Each input is scanned separately so a file without a trailing newline
+  // does not get glued to the first line of the next one.
+  for _, r := range readers {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 1<<20), 1<<30)
+    for scanner.Scan() {
+      line := scanner.Text()
+      // A run is only spilled once the next line does not fit, so input
+      // of exactly one run stays in memory.
+      if size > 0 && size+len(line)+1 > opts.chunkSize {
+        path, err := spill(lines)
+        if path != "" {
+          runs = append(runs, path)
+        }
+        if err != nil {
+          return runs, nil, err
+        }
+        lines, size = lines[:0], 0
+      }
+      lines = append(lines, line)
+      size += len(line) + 1
+    }
+    if err := scanner.Err(); err != nil {
+      return runs, nil, err
+    }
+  }
+  return runs, lines, nil
+}
+
+// One open run file during the merge phase, holding the next unread line.
+type run struct {
+  scanner *bufio.Scanner
+  file    *os.File
+  line    string
+  index   int
+}
+
+// A min-heap of runs ordered by their current line. Ties are broken by run
+// index so that the merge is stable across runs.
+type runHeap []*run
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+  if c := compare(h[i].line, h[j].line); c != 0 {
+    return c < 0
+  }
+  return h[i].index < h[j].index
+}
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*run)) }
+func (h *runHeap) Pop() interface{} {
+  old := *h
+  n := len(old)
+  item := old[n-1]
+  *h = old[:n-1]
+  return item
+}
+
+// Merge the sorted run files into the writer with a k-way heap merge, only
+// ever holding one line per run in memory.
+func mergeRuns(paths []string, lw *lineWriter) error {
+  h := &runHeap{}
+  defer func() {
+    for _, r := range *h {
+      r.file.Close()
+    }
+  }()
+
+  for i, path := range paths {
+    file, err := os.Open(path)
+    if err != nil {
+      return err
+    }
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 64*1024), 1<<30)
+    if scanner.Scan() {
+      heap.Push(h, &run{scanner: scanner, file: file, line: scanner.Text(), index: i})
+    } else {
+      file.Close()
+    }
+  }
+
+  for h.Len() > 0 {
+    top := (*h)[0]
+    if err := lw.write(top.line); err != nil {
+      return err
+    }
+    if top.scanner.Scan() {
+      top.line = top.scanner.Text()
+      heap.Fix(h, 0)
+    } else {
+      if err := top.scanner.Err(); err != nil {
+        return err
+      }
+      top.file.Close()
+      heap.Pop(h)
+    }
+  }
+  return nil
+}
+
+// Sort everything from the readers into the writer. Input that fits in
+// chunkSize bytes is sorted in memory; bigger input is cut into sorted runs
+// of at most chunkSize bytes that are spilled to disk and merged, so huge
+// inputs never sit in memory at once.
+func sortStream(readers []io.Reader, out io.Writer) error {
+  lw := &lineWriter{w: bufio.NewWriterSize(out, 1<<20)}
+
+  runs, rest, err := makeRuns(readers)
+  defer func() {
+    for _, path := range runs {
+      os.Remove(path)
+    }
+  }()
+  if err != nil {
+    return err
+  }
+  if len(runs) == 0 {
+    sortLines(rest)
+    for _, line := range rest {
+      if err := lw.write(line); err != nil {
+        return err
+      }
+    }
+    return lw.w.Flush()
+  }
+  if len(rest) > 0 {
+    path, err := spill(rest)
+    if path != "" {
+      runs = append(runs, path)
+    }
+    if err != nil {
+      return err
+    }
+  }
+  if err := mergeRuns(runs, lw); err != nil {
+    return err
+  }
+  return lw.w.Flush()
+}
+
+// Parse the command line, open the inputs (stdin when no files or "-" are
+// given) and sort them together.
+func main() {
+  flag.BoolVar(&opts.numeric, "n", false, "compare according to string numerical value")
+  flag.BoolVar(&opts.unique, "u", false, "output only the first of an equal run")
+  flag.BoolVar(&opts.reverse, "r", false, "reverse the result of comparisons")
+  flag.IntVar(&opts.key, "k", 0, "sort via the given 1-based field")
+  flag.StringVar(&opts.separator, "t", "", "use the given field separator")
+  flag.IntVar(&opts.chunkSize, "S", 64<<20, "bytes of input to sort in memory per run")
+  flag.StringVar(&opts.tmpDir, "T", os.TempDir(), "directory for temporary run files")
+  flag.Parse()
+
+  if opts.chunkSize <= 0 {
+    fmt.Fprintln(os.Stderr, "sort: chunk size must be positive")
+    os.Exit(2)
+  }
+
+  var readers []io.Reader
+  files := flag.Args()
+  if len(files) == 0 {
+    files = []string{"-"}
+  }
+  for _, name := range files {
+    if name == "-" {
+      readers = append(readers, os.Stdin)
+      continue
+    }
+    file, err := os.Open(name)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "sort:", err)
+      os.Exit(2)
+    }
+    defer file.Close()
+    readers = append(readers, file)
+  }
+
+  if err := sortStream(readers, os.Stdout); err != nil {
+    fmt.Fprintln(os.Stderr, "sort:", err)
+    os.Exit(2)
+  }
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+  "fmt"
+  "io"
+  "math/rand"
+  "os"
+  "path/filepath"
+  "slices"
+  "strings"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+// Sort the inputs with the given options, checking no run file is left.
+func sortWith(t *testing.T, o options, inputs ...string) string {
+  t.Helper()
+  defer func(saved options) { opts = saved }(opts)
+  if o.tmpDir == "" {
+    o.tmpDir = t.TempDir()
+  }
+  opts = o
+  var readers []io.Reader
+  for _, in := range inputs {
+    readers = append(readers, strings.NewReader(in))
+  }
+  var out strings.Builder
+  testx.NoError(t, sortStream(readers, &out))
+  if entries, _ := os.ReadDir(o.tmpDir); len(entries) != 0 {
+    t.Errorf("%d run files left behind", len(entries))
+  }
+  return out.String()
+}
+
+// Input that fits in a run never touches the disk: the run directory here
+// does not exist.
+func TestInMemory(t *testing.T) {
+  missing := filepath.Join(t.TempDir(), "missing")
+  in := "pear\napple\nfig\n"
+  for _, size := range []int{len(in), 1 << 20} {
+    got := sortWith(t, options{chunkSize: size, tmpDir: missing}, in)
+    testx.Equal(t, got, "apple\nfig\npear\n")
+  }
+  defer func(saved options) { opts = saved }(opts)
+  opts = options{chunkSize: len(in) - 1, tmpDir: missing}
+  if err := sortStream([]io.Reader{strings.NewReader(in)}, io.Discard); err == nil {
+    t.Error("input bigger than a run was sorted without spilling it")
+  }
+}
+
+func TestMakeRuns(t *testing.T) {
+  defer func(saved options) { opts = saved }(opts)
+  opts = options{chunkSize: 10, tmpDir: t.TempDir()}
+  runs, rest, err := makeRuns([]io.Reader{strings.NewReader("e\nd\nc\nb\na\n9\n8\n7\n6\n5\n4\n")})
+  testx.NoError(t, err)
+  testx.Equal(t, len(runs), 2)
+  testx.SlicesEqual(t, rest, []string{"4"})
+  for i, want := range []string{"a\nb\nc\nd\ne\n", "5\n6\n7\n8\n9\n"} {
+    data, err := os.ReadFile(runs[i])
+    testx.NoError(t, err)
+    testx.Equal(t, string(data), want)
+  }
+}
+
+// A file that needs hundreds of runs sorts the same as it does in memory,
+// under each of the options that change the comparison.
+func TestLarge(t *testing.T) {
+  r := rand.New(rand.NewSource(1))
+  var b strings.Builder
+  var lines []string
+  for i := range 50000 {
+    line := fmt.Sprintf("%d,%s,%d", r.Intn(1000)-500, strings.Repeat("x", r.Intn(8)), i)
+    lines = append(lines, line)
+    b.WriteString(line + "\n")
+  }
+  in := b.String()
+  half := len(in)/2 + strings.IndexByte(in[len(in)/2:], '\n') + 1
+
+  slices.Sort(lines)
+  testx.Equal(t, sortWith(t, options{chunkSize: 4096}, in), strings.Join(lines, "\n")+"\n")
+
+  for _, o := range []options{
+    {numeric: true},
+    {numeric: true, reverse: true},
+    {numeric: true, unique: true},
+    {key: 2, separator: ","},
+    {key: 2, separator: ",", unique: true, reverse: true},
+  } {
+    t.Run(fmt.Sprintf("%+v", o), func(t *testing.T) {
+      small, big := o, o
+      small.chunkSize, big.chunkSize = 4096, len(in)
+      testx.Equal(t, sortWith(t, small, in[:half], in[half:]), sortWith(t, big, in))
+    })
+  }
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+  "encoding/binary"
+  "errors"
+  "fmt"
+  "io"
+  "math"
+)
+
+// A Bloom filter over strings. Membership answers are either "definitely
+// not present" or "probably present", with the false-positive rate chosen
+// when the filter is sized.
+type bloom struct {
+  bits []uint64
+  m    uint64
+  k    uint32
+}
+
+// Size a filter for n items at false-positive rate p using the standard
+// formulas m = -n*ln(p)/ln(2)^2 and k = m/n*ln(2).
+func newBloom(n int, p float64) *bloom {
+  if n < 1 {
+    n = 1
+  }
+  m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+  if m < 64 {
+    m = 64
+  }
+  k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+  if k < 1 {
+    k = 1
+  }
+  return &bloom{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// 64-bit FNV-1a, seeded by mixing the seed into the offset basis. Two
+// differently seeded hashes are combined with double hashing below.
+func fnv1a(s string, seed uint64) uint64 {
+  h := uint64(14695981039346656037) ^ seed
+  for i := 0; i < len(s); i++ {
+    h ^= uint64(s[i])
+    h *= 1099511628211
+  }
+  return h
+}
+
+// Derive the k bit positions for a word. Kirsch and Mitzenmacher showed that
+// h1 + i*h2 is as good as k independent hash functions for a Bloom filter.
+func (b *bloom) positions(word string, visit func(uint64)) {
+  h1 := fnv1a(word, 0)
+  h2 := fnv1a(word, 0x9e3779b97f4a7c15) | 1
+  for i := uint64(0); i < uint64(b.k); i++ {
+    visit((h1 + i*h2) % b.m)
+  }
+}
+
+func (b *bloom) add(word string) {
+  b.positions(word, func(pos uint64) {
+    b.bits[pos/64] |= 1 << (pos % 64)
+  })
+}
+
+func (b *bloom) contains(word string) bool {
+  found := true
+  b.positions(word, func(pos uint64) {
+    if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+      found = false
+    }
+  })
+  return found
+}
+
+// The on-disk format is a 4 byte magic "CCBF", a uint16 version, a uint16
+// hash count, a uint64 bit count, then the bit array as little-endian
+// uint64 words. All header fields are big-endian.
+var magic = [4]byte{'C', 'C', 'B', 'F'}
+
+const formatVersion = 1
+
+func (b *bloom) writeTo(w io.Writer) error {
+  header := make([]byte, 16)
+  copy(header, magic[:])
+  binary.BigEndian.PutUint16(header[4:], formatVersion)
+  binary.BigEndian.PutUint16(header[6:], uint16(b.k))
+  binary.BigEndian.PutUint64(header[8:], b.m)
+  if _, err := w.Write(header); err != nil {
+    return err
+  }
+  return binary.Write(w, binary.LittleEndian, b.bits)
+}
+
+func readBloom(r io.Reader) (*bloom, error) {
+  header := make([]byte, 16)
+  if _, err := io.ReadFull(r, header); err != nil {
+    return nil, err
+  }
+  if [4]byte(header[:4]) != magic {
+    return nil, errors.New("not a bloom filter file")
+  }
+  if v := binary.BigEndian.Uint16(header[4:]); v != formatVersion {
+    return nil, fmt.Errorf("unsupported bloom filter version %d", v)
+  }
+  b := &bloom{k: uint32(binary.BigEndian.Uint16(header[6:])), m: binary.BigEndian.Uint64(header[8:])}
+  if b.k == 0 || b.m == 0 {
+    return nil, errors.New("corrupt bloom filter header")
+  }
+  b.bits = make([]uint64, (b.m+63)/64)
+  if err := binary.Read(r, binary.LittleEndian, b.bits); err != nil {
+    return nil, err
+  }
+  return b, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+  "bytes"
+  "math"
+  "math/rand"
+  "testing"
+)
+
+// Random lower-case words of 5 to 12 letters.
+func randomWord(rng *rand.Rand) string {
+  buf := make([]byte, 5+rng.Intn(8))
+  for i := range buf {
+    buf[i] = byte('a' + rng.Intn(26))
+  }
+  return string(buf)
+}
+
+// A filter sized for a dictionary holds all of it, and answers yes for
+// words outside it at no more than the rate it was sized for, give or take
+// sampling: a rate measured over n probes wanders by sqrt(p(1-p)/n), so
+// four times that is allowed.
+func TestFalsePositiveRate(t *testing.T) {
+  rng := rand.New(rand.NewSource(1))
+  words := 20000
+  probes := 200000
+  if testing.Short() {
+    words, probes = 2000, 20000
+  }
+  known := map[string]bool{}
+  for len(known) < words {
+    known[randomWord(rng)] = true
+  }
+  for _, p := range []float64{0.1, 0.01, 0.001} {
+    b := newBloom(len(known), p)
+    for word := range known {
+      b.add(word)
+    }
+    for word := range known {
+      if !b.contains(word) {
+        t.Fatalf("p=%v: %q was added but is missing", p, word)
+      }
+    }
+    positives, tested := 0, 0
+    for tested < probes {
+      word := randomWord(rng)
+      if known[word] {
+        continue
+      }
+      tested++
+      if b.contains(word) {
+        positives++
+      }
+    }
+    bound := p + 4*math.Sqrt(p*(1-p)/float64(tested))
+    if rate := float64(positives) / float64(tested); rate > bound {
+      t.Errorf("p=%v: %d false positives in %d, a rate of %.5f, over %.5f", p, positives, tested, rate, bound)
+    }
+  }
+}
+
+func TestFile(t *testing.T) {
+  b := newBloom(3, 0.01)
+  for _, word := range []string{"apple", "banana", "cherry"} {
+    b.add(word)
+  }
+  var buf bytes.Buffer
+  if err := b.writeTo(&buf); err != nil {
+    t.Fatal(err)
+  }
+  data := buf.Bytes()
+  if len(data) != 16+len(b.bits)*8 {
+    t.Errorf("wrote %d bytes for %d words of bits", len(data), len(b.bits))
+  }
+  got, err := readBloom(bytes.NewReader(data))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if got.m != b.m || got.k != b.k {
+    t.Errorf("read back m=%d k=%d, want m=%d k=%d", got.m, got.k, b.m, b.k)
+  }
+  for _, word := range []string{"apple", "banana", "cherry"} {
+    if !got.contains(word) {
+      t.Errorf("%q is missing after reading back", word)
+    }
+  }
+
+  for _, c := range []struct {
+    name string
+    data []byte
+  }{
+    {"empty", nil},
+    {"short header", data[:10]},
+    {"bad magic", append([]byte("XXXX"), data[4:]...)},
+    {"bad version", append(append([]byte{}, data[:5]...), append([]byte{9}, data[6:]...)...)},
+    {"no hashes", append(append([]byte{}, data[:6]...), append([]byte{0, 0}, data[8:]...)...)},
+    {"short bits", data[:len(data)-1]},
+  } {
+    if _, err := readBloom(bytes.NewReader(c.data)); err == nil {
+      t.Errorf("%s: read without an error", c.name)
+    }
+  }
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "strings"
+)
+
+// Read the dictionary, one word per line, lower-cased and de-duplicated.
+func readWords(r io.Reader) ([]string, error) {
+  seen := map[string]bool{}
+  var words []string
+  scanner := bufio.NewScanner(r)
+  for scanner.Scan() {
+    word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+    if word != "" && !seen[word] {
+      seen[word] = true
+      words = append(words, word)
+    }
+  }
+  return words, scanner.Err()
+}
+
+// Build a filter from the dictionary file and save it to out.
+func build(dictPath, out string, p float64) error {
+  file, err := os.Open(dictPath)
+  if err != nil {
+    return err
+  }
+  defer file.Close()
+  words, err := readWords(file)
+  if err != nil {
+    return err
+  }
+
+  b := newBloom(len(words), p)
+  for _, word := range words {
+    b.add(word)
+  }
+
+  f, err := os.Create(out)
+  if err != nil {
+    return err
+  }
+  w := bufio.NewWriter(f)
+  if err := b.writeTo(w); err != nil {
+    f.Close()
+    return err
+  }
+  if err := w.Flush(); err != nil {
+    f.Close()
+    return err
+  }
+  fmt.Printf("Stored %d words in %d bits with %d hash functions (%d bytes)\n",
+    len(words), b.m, b.k, 16+len(b.bits)*8)
+  return f.Close()
+}
+
+// Build a filter with -build, or otherwise check the words given as
+// arguments (or read from stdin) and print the ones that are probably
+// misspelt.
+func main() {
+  dict := flag.String("build", "", "dictionary file to build the filter from")
+  filter := flag.String("f", "words.bf", "bloom filter file")
+  rate := flag.Float64("p", 0.01, "target false-positive rate when building")
+  flag.Parse()
+
+  if *dict != "" {
+    if *rate <= 0 || *rate >= 1 {
+      fmt.Fprintln(os.Stderr, "spellcheck: -p must be between 0 and 1")
+      os.Exit(2)
+    }
+    if err := build(*dict, *filter, *rate); err != nil {
+      fmt.Fprintln(os.Stderr, "spellcheck:", err)
+      os.Exit(1)
+    }
+    return
+  }
+
+  file, err := os.Open(*filter)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "spellcheck:", err)
+    os.Exit(1)
+  }
+  b, err := readBloom(bufio.NewReader(file))
+  file.Close()
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "spellcheck:", err)
+    os.Exit(1)
+  }
+
+  words := flag.Args()
+  if len(words) == 0 {
+    scanner := bufio.NewScanner(os.Stdin)
+    scanner.Split(bufio.ScanWords)
+    for scanner.Scan() {
+      words = append(words, scanner.Text())
+    }
+  }
+  var misspelt []string
+  for _, word := range words {
+    w := strings.ToLower(strings.Trim(word, ".,;:!?\"'()"))
+    if w != "" && !b.contains(w) {
+      misspelt = append(misspelt, word)
+    }
+  }
+  if len(misspelt) > 0 {
+    fmt.Println("These words are spelt wrong:")
+    for _, word := range misspelt {
+      fmt.Println(" ", word)
+    }
+  }
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+  "encoding/binary"
+  "errors"
+  "fmt"
+  "math"
+  "os"
+  "strings"
+  "unicode/utf16"
+)
+
+// B-tree page types from the page header.
+const (
+  interiorIndex = 0x02
+  interiorTable = 0x05
+  leafIndex     = 0x0a
+  leafTable     = 0x0d
+)
+
+// A database opened read-only. Pages are read on demand.
+type database struct {
+  file       *os.File
+  pageSize   int
+  usableSize int
+  pageCount  int
+  encoding   uint32
+}
+
+// Open a database and validate its 100-byte header.
+func open(path string) (*database, error) {
+  file, err := os.Open(path)
+  if err != nil {
+    return nil, err
+  }
+  header := make([]byte, 100)
+  if _, err := file.ReadAt(header, 0); err != nil {
+    file.Close()
+    return nil, errors.New("file is too short to be a database")
+  }
+  if string(header[:16]) != "SQLite format 3\x00" {
+    file.Close()
+    return nil, errors.New("file is not a database")
+  }
+  db := &database{file: file}
+  db.pageSize = int(binary.BigEndian.Uint16(header[16:]))
+  if db.pageSize == 1 {
+    db.pageSize = 65536
+  }
+  db.usableSize = db.pageSize - int(header[20])
+  db.pageCount = int(binary.BigEndian.Uint32(header[28:]))
+  db.encoding = binary.BigEndian.Uint32(header[56:])
+  if db.pageCount == 0 {
+    // Older writers leave the in-header page count invalid.
+    info, _ := file.Stat()
+    db.pageCount = int(info.Size() / int64(db.pageSize))
+  }
+  return db, nil
+}
+
+func (db *database) close() error {
+  return db.file.Close()
+}
+
+// Read page n (numbered from 1).
+func (db *database) page(n int) ([]byte, error) {
+  if n < 1 || n > db.pageCount {
+    return nil, fmt.Errorf("page %d out of range", n)
+  }
+  buf := make([]byte, db.pageSize)
+  if _, err := db.file.ReadAt(buf, int64(n-1)*int64(db.pageSize)); err != nil {
+    return nil, err
+  }
+  return buf, nil
+}
+
+// Decode a big-endian varint of up to nine bytes; the ninth byte contributes
+// all eight bits.
+func varint(b []byte) (int64, int) {
+  var v uint64
+  for i := 0; i < 8 && i < len(b); i++ {
+    v = v<<7 | uint64(b[i]&0x7f)
+    if b[i]&0x80 == 0 {
+      return int64(v), i + 1
+    }
+  }
+  if len(b) < 9 {
+    return int64(v), len(b)
+  }
+  return int64(v<<8 | uint64(b[8])), 9
+}
+
+// A row from a table b-tree: its rowid and the raw record payload.
+type row struct {
+  rowid   int64
+  payload []byte
+}
+
+// Walk a table b-tree in rowid order, calling fn for each row until it
+// returns false.
+func (db *database) scanTable(root int, fn func(row) (bool, error)) error {
+  _, err := db.walk(root, fn, 0)
+  return err
+}
+
+func (db *database) walk(n int, fn func(row) (bool, error), depth int) (bool, error) {
+  if depth > 64 {
+    return false, errors.New("b-tree is too deep; database may be corrupt")
+  }
+  data, err := db.page(n)
+  if err != nil {
+    return false, err
+  }
+  offset := 0
+  if n == 1 {
+    offset = 100
+  }
+  hdr := data[offset:]
+  kind := hdr[0]
+  cells := int(binary.BigEndian.Uint16(hdr[3:]))
+  headerSize := 8
+  if kind == interiorTable || kind == interiorIndex {
+    headerSize = 12
+  }
+  pointers := hdr[headerSize:]
+
+  switch kind {
+  case leafTable:
+    for i := 0; i < cells; i++ {
+      cell := data[binary.BigEndian.Uint16(pointers[2*i:]):]
+      size, n1 := varint(cell)
+      rowid, n2 := varint(cell[n1:])
+      payload, err := db.payload(cell[n1+n2:], int(size))
+      if err != nil {
+        return false, err
+      }
+      if more, err := fn(row{rowid, payload}); !more || err != nil {
+        return false, err
+      }
+    }
+    return true, nil
+  case interiorTable:
+    for i := 0; i < cells; i++ {
+      cell := data[binary.BigEndian.Uint16(pointers[2*i:]):]
+      child := int(binary.BigEndian.Uint32(cell))
+      if more, err := db.walk(child, fn, depth+1); !more || err != nil {
+        return false, err
+      }
+    }
+    right := int(binary.BigEndian.Uint32(hdr[8:]))
+    return db.walk(right, fn, depth+1)
+  }
+  return false, fmt.Errorf("page %d: expected a table b-tree page, found type %#x", n, kind)
+}
+
+// Assemble a cell payload, following overflow pages when it does not fit on
+// the b-tree page. The local/overflow split uses the formula from the file
+// format documentation for table leaf cells.
+func (db *database) payload(cell []byte, size int) ([]byte, error) {
+  u := db.usableSize
+  maxLocal := u - 35
+  if size <= maxLocal {
+    return cell[:size], nil
+  }
+  minLocal := (u-12)*32/255 - 23
+  local := minLocal + (size-minLocal)%(u-4)
+  if local > maxLocal {
+    local = minLocal
+  }
+  out := make([]byte, 0, size)
+  out = append(out, cell[:local]...)
+  next := int(binary.BigEndian.Uint32(cell[local:]))
+  for len(out) < size {
+    if next == 0 {
+      return nil, errors.New("overflow chain ended early")
+    }
+    page, err := db.page(next)
+    if err != nil {
+      return nil, err
+    }
+    next = int(binary.BigEndian.Uint32(page))
+    chunk := page[4:u]
+    if remaining := size - len(out); len(chunk) > remaining {
+      chunk = chunk[:remaining]
+    }
+    out = append(out, chunk...)
+  }
+  return out, nil
+}
+
+// Decode a record into values: nil, int64, float64, string or []byte.
+func (db *database) decodeRecord(payload []byte) ([]any, error) {
+  headerSize, n := varint(payload)
+  if int(headerSize) > len(payload) {
+    return nil, errors.New("corrupt record header")
+  }
+  var types []int64
+  for pos := n; pos < int(headerSize); {
+    t, k := varint(payload[pos:])
+    types = append(types, t)
+    pos += k
+  }
+  body := payload[headerSize:]
+  values := make([]any, len(types))
+  for i, t := range types {
+    size := serialSize(t)
+    if size > len(body) {
+      return nil, errors.New("corrupt record body")
+    }
+    field := body[:size]
+    body = body[size:]
+    switch {
+    case t == 0:
+      values[i] = nil
+    case t >= 1 && t <= 6:
+      // Big-endian two's complement integers of 1, 2, 3, 4, 6 or 8 bytes.
+      v := int64(int8(field[0]))
+      for _, b := range field[1:] {
+        v = v<<8 | int64(b)
+      }
+      values[i] = v
+    case t == 7:
+      values[i] = math.Float64frombits(binary.BigEndian.Uint64(field))
+    case t == 8:
+      values[i] = int64(0)
+    case t == 9:
+      values[i] = int64(1)
+    case t >= 12 && t%2 == 0:
+      values[i] = append([]byte(nil), field...)
+    case t >= 13:
+      values[i] = db.text(field)
+    default:
+      return nil, fmt.Errorf("invalid serial type %d", t)
+    }
+  }
+  return values, nil
+}
+
+func serialSize(t int64) int {
+  switch {
+  case t <= 4:
+    return []int{0, 1, 2, 3, 4}[t]
+  case t == 5:
+    return 6
+  case t == 6 || t == 7:
+    return 8
+  case t < 12:
+    return 0
+  case t%2 == 0:
+    return int(t-12) / 2
+  }
+  return int(t-13) / 2
+}
+
+// Decode text in the database encoding: 1 is UTF-8, 2 UTF-16le, 3 UTF-16be.
+func (db *database) text(b []byte) string {
+  if db.encoding != 2 && db.encoding != 3 {
+    return string(b)
+  }
+  units := make([]uint16, len(b)/2)
+  for i := range units {
+    if db.encoding == 2 {
+      units[i] = binary.LittleEndian.Uint16(b[2*i:])
+    } else {
+      units[i] = binary.BigEndian.Uint16(b[2*i:])
+    }
+  }
+  return string(utf16.Decode(units))
+}
+
+// An entry from the sqlite_schema table, with the column names parsed from
+// its CREATE statement.
+type schemaEntry struct {
+  kind, name, table string
+  rootPage          int
+  sql               string
+  columns           []string
+  realColumns       []bool // columns with REAL affinity
+  rowidColumn       int    // index of an INTEGER PRIMARY KEY column, or -1
+}
+
+// Read the schema table, which always lives in the b-tree rooted at page 1.
+func (db *database) schema() ([]schemaEntry, error) {
+  var entries []schemaEntry
+  err := db.scanTable(1, func(r row) (bool, error) {
+    values, err := db.decodeRecord(r.payload)
+    if err != nil {
+      return false, err
+    }
+    if len(values) < 5 {
+      return false, errors.New("corrupt schema record")
+    }
+    e := schemaEntry{rowidColumn: -1}
+    e.kind, _ = values[0].(string)
+    e.name, _ = values[1].(string)
+    e.table, _ = values[2].(string)
+    if root, ok := values[3].(int64); ok {
+      e.rootPage = int(root)
+    }
+    e.sql, _ = values[4].(string)
+    if e.kind == "table" {
+      e.columns, e.realColumns, e.rowidColumn = parseColumns(e.sql)
+    }
+    entries = append(entries, e)
+    return true, nil
+  })
+  return entries, err
+}
+
+// Split s on commas that are not nested inside parentheses or quotes.
+func splitTopLevel(s string) []string {
+  var parts []string
+  depth := 0
+  var quote byte
+  start := 0
+  for i := 0; i < len(s); i++ {
+    c := s[i]
+    switch {
+    case quote != 0:
+      if c == quote {
+        quote = 0
+      }
+    case c == '\'' || c == '"' || c == '`':
+      quote = c
+    case c == '[':
+      quote = ']'
+    case c == '(':
+      depth++
+    case c == ')':
+      depth--
+    case c == ',' && depth == 0:
+      parts = append(parts, s[start:i])
+      start = i + 1
+    }
+  }
+  return append(parts, s[start:])
+}
+
+func unquoteIdent(s string) string {
+  if len(s) >= 2 {
+    switch {
+    case s[0] == '"' && s[len(s)-1] == '"', s[0] == '`' && s[len(s)-1] == '`', s[0] == '[' && s[len(s)-1] == ']':
+      return s[1 : len(s)-1]
+    }
+  }
+  return s
+}
+
+// Pull column names out of a CREATE TABLE statement, skipping table
+// constraints. A column declared "INTEGER PRIMARY KEY" is an alias for the
+// rowid and is stored as NULL in the record, so its index is reported.
+// Columns with REAL affinity are flagged because SQLite stores whole-number
+// reals as integers on disk.
+func parseColumns(sql string) ([]string, []bool, int) {
+  open, close := strings.IndexByte(sql, '('), strings.LastIndexByte(sql, ')')
+  if open < 0 || close < open {
+    return nil, nil, -1
+  }
+  var columns []string
+  var reals []bool
+  rowid := -1
+  for _, def := range splitTopLevel(sql[open+1 : close]) {
+    def = strings.TrimSpace(def)
+    fields := strings.Fields(def)
+    if len(fields) == 0 {
+      continue
+    }
+    switch strings.ToUpper(fields[0]) {
+    case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+      continue
+    }
+    name := fields[0]
+    if def[0] == '"' || def[0] == '`' || def[0] == '[' {
+      closer := map[byte]byte{'"': '"', '`': '`', '[': ']'}[def[0]]
+      if end := strings.IndexByte(def[1:], closer); end >= 0 {
+        name = def[:end+2]
+      }
+    }
+    upper := strings.ToUpper(strings.Join(strings.Fields(def[len(name):]), " "))
+    if strings.HasPrefix(upper, "INTEGER PRIMARY KEY") && !strings.Contains(upper, "DESC") {
+      rowid = len(columns)
+    }
+    columns = append(columns, unquoteIdent(name))
+    reals = append(reals, !strings.Contains(upper, "INT") &&
+      (strings.Contains(upper, "REAL") || strings.Contains(upper, "FLOA") || strings.Contains(upper, "DOUB")))
+  }
+  return columns, reals, rowid
+}
+
+// Format a value the way the sqlite3 shell does in its default list mode.
+func formatValue(v any) string {
+  switch v := v.(type) {
+  case nil:
+    return ""
+  case int64:
+    return fmt.Sprint(v)
+  case float64:
+    // The shell always shows a decimal point, even in an exponent form
+    // such as 1.0e+20, and writes infinities as Inf.
+    if math.IsInf(v, 0) {
+      return strings.TrimPrefix(fmt.Sprint(v), "+")
+    }
+    s := fmt.Sprintf("%.15g", v)
+    mantissa, exp, _ := strings.Cut(s, "e")
+    if !strings.ContainsAny(mantissa, ".N") {
+      mantissa += ".0"
+    }
+    if exp != "" {
+      return mantissa + "e" + exp
+    }
+    return mantissa
+  case []byte:
+    return string(v)
+  }
+  return fmt.Sprint(v)
+}
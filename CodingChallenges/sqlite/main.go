@@ -0,0 +1,374 @@
+package main
+
+import (
+  "bufio"
+  "errors"
+  "fmt"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+// A parsed "SELECT cols FROM table [WHERE col = value [AND ...]] [LIMIT n]".
+type query struct {
+  columns []string // "*" selects every column
+  count   bool     // SELECT COUNT(*)
+  table   string
+  where   []condition
+  limit   int
+}
+
+type condition struct {
+  column string
+  value  any
+}
+
+// Split SQL into identifiers, string literals, numbers and punctuation.
+func tokenizeSQL(sql string) ([]string, error) {
+  var toks []string
+  for i := 0; i < len(sql); {
+    c := sql[i]
+    switch {
+    case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ';':
+      i++
+    case c == '\'' || c == '"' || c == '`' || c == '[':
+      closer := c
+      if c == '[' {
+        closer = ']'
+      }
+      j := i + 1
+      for ; j < len(sql); j++ {
+        if sql[j] == closer {
+          if j+1 < len(sql) && sql[j+1] == closer && closer != ']' {
+            j++ // doubled quote
+            continue
+          }
+          break
+        }
+      }
+      if j >= len(sql) {
+        return nil, errors.New("unterminated quoted string")
+      }
+      toks = append(toks, sql[i:j+1])
+      i = j + 1
+    case strings.IndexByte("(),*=", c) >= 0:
+      toks = append(toks, string(c))
+      i++
+    default:
+      j := i
+      for j < len(sql) && strings.IndexByte(" \t\n\r;(),*=", sql[j]) < 0 {
+        j++
+      }
+      toks = append(toks, sql[i:j])
+      i = j
+    }
+  }
+  return toks, nil
+}
+
+// Turn a literal token into a value: 'text', integers and reals.
+func literal(tok string) (any, error) {
+  if strings.HasPrefix(tok, "'") {
+    return strings.ReplaceAll(tok[1:len(tok)-1], "''", "'"), nil
+  }
+  if n, err := strconv.ParseInt(tok, 10, 64); err == nil {
+    return n, nil
+  }
+  if f, err := strconv.ParseFloat(tok, 64); err == nil {
+    return f, nil
+  }
+  if strings.EqualFold(tok, "NULL") {
+    return nil, nil
+  }
+  return nil, fmt.Errorf("unsupported literal %s", tok)
+}
+
+func parseQuery(sql string) (*query, error) {
+  toks, err := tokenizeSQL(sql)
+  if err != nil {
+    return nil, err
+  }
+  pos := 0
+  peek := func() string {
+    if pos < len(toks) {
+      return toks[pos]
+    }
+    return ""
+  }
+  next := func() string { t := peek(); pos++; return t }
+  keyword := func(k string) bool {
+    if strings.EqualFold(peek(), k) {
+      pos++
+      return true
+    }
+    return false
+  }
+
+  q := &query{limit: -1}
+  if !keyword("SELECT") {
+    return nil, errors.New("only SELECT statements are supported")
+  }
+  if strings.EqualFold(peek(), "COUNT") && pos+3 < len(toks) && toks[pos+1] == "(" && toks[pos+2] == "*" && toks[pos+3] == ")" {
+    q.count = true
+    pos += 4
+  } else {
+    for {
+      col := next()
+      if col == "" || strings.EqualFold(col, "FROM") {
+        return nil, errors.New("expected a column list")
+      }
+      q.columns = append(q.columns, unquoteIdent(col))
+      if peek() != "," {
+        break
+      }
+      pos++
+    }
+  }
+  if !keyword("FROM") {
+    return nil, errors.New("expected FROM")
+  }
+  if q.table = unquoteIdent(next()); q.table == "" {
+    return nil, errors.New("expected a table name")
+  }
+  if keyword("WHERE") {
+    for {
+      col := unquoteIdent(next())
+      if next() != "=" {
+        return nil, errors.New("only equality conditions are supported")
+      }
+      v, err := literal(next())
+      if err != nil {
+        return nil, err
+      }
+      q.where = append(q.where, condition{col, v})
+      if !keyword("AND") {
+        break
+      }
+    }
+  }
+  if keyword("LIMIT") {
+    if q.limit, err = strconv.Atoi(next()); err != nil || q.limit < 0 {
+      return nil, errors.New("invalid LIMIT")
+    }
+  }
+  if pos < len(toks) {
+    return nil, fmt.Errorf("near %q: syntax error", peek())
+  }
+  return q, nil
+}
+
+// Compare a stored value with a literal using SQLite's loose numeric
+// equality between integers and reals.
+func equal(a, b any) bool {
+  switch a := a.(type) {
+  case int64:
+    switch b := b.(type) {
+    case int64:
+      return a == b
+    case float64:
+      return float64(a) == b
+    }
+  case float64:
+    switch b := b.(type) {
+    case int64:
+      return a == float64(b)
+    case float64:
+      return a == b
+    }
+  case string:
+    b, ok := b.(string)
+    return ok && a == b
+  case []byte:
+    b, ok := b.(string)
+    return ok && string(a) == b
+  }
+  return false
+}
+
+func columnIndex(t *schemaEntry, name string) (int, error) {
+  if strings.EqualFold(name, "rowid") {
+    return -1, nil
+  }
+  for i, c := range t.columns {
+    if strings.EqualFold(c, name) {
+      return i, nil
+    }
+  }
+  return 0, fmt.Errorf("no such column: %s", name)
+}
+
+// Run a query with a full scan of the table's b-tree, printing rows in the
+// sqlite3 shell's default "|" separated format.
+func run(db *database, q *query, w *bufio.Writer) error {
+  entries, err := db.schema()
+  if err != nil {
+    return err
+  }
+  var table *schemaEntry
+  for i := range entries {
+    if entries[i].kind == "table" && strings.EqualFold(entries[i].name, q.table) {
+      table = &entries[i]
+    }
+  }
+  if table == nil {
+    return fmt.Errorf("no such table: %s", q.table)
+  }
+
+  var selected []int
+  for _, c := range q.columns {
+    if c == "*" {
+      for i := range table.columns {
+        selected = append(selected, i)
+      }
+      continue
+    }
+    i, err := columnIndex(table, c)
+    if err != nil {
+      return err
+    }
+    selected = append(selected, i)
+  }
+  type filter struct {
+    index int
+    value any
+  }
+  var filters []filter
+  for _, cond := range q.where {
+    i, err := columnIndex(table, cond.column)
+    if err != nil {
+      return err
+    }
+    filters = append(filters, filter{i, cond.value})
+  }
+
+  count := 0
+  err = db.scanTable(table.rootPage, func(r row) (bool, error) {
+    values, err := db.decodeRecord(r.payload)
+    if err != nil {
+      return false, err
+    }
+    // Columns added by ALTER TABLE may be missing from older records.
+    for len(values) < len(table.columns) {
+      values = append(values, nil)
+    }
+    get := func(i int) any {
+      if i == -1 || i == table.rowidColumn {
+        return r.rowid
+      }
+      if n, ok := values[i].(int64); ok && table.realColumns[i] {
+        return float64(n)
+      }
+      return values[i]
+    }
+    for _, f := range filters {
+      if !equal(get(f.index), f.value) {
+        return true, nil
+      }
+    }
+    count++
+    if !q.count {
+      fields := make([]string, len(selected))
+      for j, i := range selected {
+        fields[j] = formatValue(get(i))
+      }
+      fmt.Fprintln(w, strings.Join(fields, "|"))
+    }
+    return q.limit < 0 || count < q.limit, nil
+  })
+  if q.count && err == nil {
+    fmt.Fprintln(w, count)
+  }
+  return err
+}
+
+// Handle the dot commands supported by the sqlite3 shell subset.
+func dotCommand(db *database, cmd string, w *bufio.Writer) error {
+  entries, err := db.schema()
+  if err != nil {
+    return err
+  }
+  switch strings.TrimSpace(cmd) {
+  case ".tables":
+    var names []string
+    for _, e := range entries {
+      if e.kind == "table" && !strings.HasPrefix(e.name, "sqlite_") {
+        names = append(names, e.name)
+      }
+    }
+    sort.Strings(names)
+    fmt.Fprintln(w, strings.Join(names, " "))
+  case ".schema":
+    for _, e := range entries {
+      if e.sql != "" {
+        fmt.Fprintf(w, "%s;\n", e.sql)
+      }
+    }
+  case ".dbinfo":
+    tables := 0
+    for _, e := range entries {
+      if e.kind == "table" {
+        tables++
+      }
+    }
+    encodings := map[uint32]string{1: "utf8", 2: "utf16le", 3: "utf16be"}
+    fmt.Fprintf(w, "database page size:  %d\n", db.pageSize)
+    fmt.Fprintf(w, "database page count: %d\n", db.pageCount)
+    fmt.Fprintf(w, "text encoding:       %s\n", encodings[db.encoding])
+    fmt.Fprintf(w, "number of tables:    %d\n", tables)
+  default:
+    return fmt.Errorf("unknown command: %s", cmd)
+  }
+  return nil
+}
+
+// Usage: sqlite file.db [command]. With no command, commands are read from
+// stdin one per line.
+func main() {
+  if len(os.Args) < 2 {
+    fmt.Fprintln(os.Stderr, "usage: sqlite file.db [.tables | .schema | .dbinfo | \"SELECT ...\"]")
+    os.Exit(2)
+  }
+  db, err := open(os.Args[1])
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "sqlite:", err)
+    os.Exit(1)
+  }
+  defer db.close()
+
+  w := bufio.NewWriter(os.Stdout)
+  defer w.Flush()
+  exec := func(cmd string) error {
+    cmd = strings.TrimSpace(cmd)
+    if cmd == "" {
+      return nil
+    }
+    if strings.HasPrefix(cmd, ".") {
+      return dotCommand(db, cmd, w)
+    }
+    q, err := parseQuery(cmd)
+    if err != nil {
+      return err
+    }
+    return run(db, q, w)
+  }
+
+  status := 0
+  report := func(err error) {
+    if err != nil {
+      w.Flush()
+      fmt.Fprintln(os.Stderr, "Error:", err)
+      status = 1
+    }
+  }
+  if len(os.Args) > 2 {
+    report(exec(strings.Join(os.Args[2:], " ")))
+  } else {
+    scanner := bufio.NewScanner(os.Stdin)
+    for scanner.Scan() {
+      report(exec(scanner.Text()))
+    }
+  }
+  w.Flush()
+  os.Exit(status)
+}
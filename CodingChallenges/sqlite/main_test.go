@@ -0,0 +1,110 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "math"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+// The databases in testdata are written by the real sqlite3 shell with
+// testdata/make.sh, and the expected output is what it prints for the same
+// command.
+func exec(t *testing.T, name, cmd string) (string, error) {
+  t.Helper()
+  db, err := open(filepath.Join("testdata", name))
+  testx.NoError(t, err)
+  defer db.close()
+  var out bytes.Buffer
+  w := bufio.NewWriter(&out)
+  if strings.HasPrefix(cmd, ".") {
+    err = dotCommand(db, cmd, w)
+  } else {
+    var q *query
+    if q, err = parseQuery(cmd); err == nil {
+      err = run(db, q, w)
+    }
+  }
+  w.Flush()
+  return out.String(), err
+}
+
+func TestQueries(t *testing.T) {
+  for _, c := range []struct{ db, cmd, want string }{
+    // A table with an INTEGER PRIMARY KEY, and a column added later that
+    // older rows do not store.
+    {"basic.db", "SELECT * FROM fruit",
+      "1|apple|red|1.5|\n2|banana|yellow|0.25|\n3|cherry|red|4.0|\n4|grape's|green||\n5|kiwi|brown|-3.75|\n6|lemon|yellow|0.5|12\n"},
+    {"basic.db", "SELECT name, price FROM fruit WHERE color = 'red'", "apple|1.5\ncherry|4.0\n"},
+    {"basic.db", "SELECT name FROM fruit WHERE price = 4", "cherry\n"},
+    {"basic.db", "SELECT rowid, name FROM fruit WHERE id = 4", "4|grape's\n"},
+    {"basic.db", "SELECT name FROM fruit WHERE name = 'grape''s'", "grape's\n"},
+    {"basic.db", "SELECT name, stock FROM fruit WHERE stock = 12", "lemon|12\n"},
+    {"basic.db", "SELECT COUNT(*) FROM fruit", "6\n"},
+    // Quoted identifiers, a table constraint and a blob.
+    {"basic.db", `SELECT * FROM "odd names"`, "a|1|hi\n\nb|2|\n"},
+    {"basic.db", `SELECT [first col], "third, with comma" FROM "odd names" WHERE second = 2`, "b|\n"},
+    // Enough rows for interior pages, and integers of every width.
+    {"basic.db", "SELECT COUNT(*) FROM numbers", "302\n"},
+    {"basic.db", "SELECT * FROM numbers LIMIT 3", "1|1|0.5|n001\n2|4|1.0|n002\n3|9|1.5|n003\n"},
+    {"basic.db", "SELECT * FROM numbers WHERE half = 150", "300|90000|150.0|n300\n"},
+    {"basic.db", "SELECT word FROM numbers WHERE square = 62500", "n250\n"},
+    {"basic.db", "SELECT * FROM numbers WHERE n = -129", "-129|4294967296|1.0e+20|\n"},
+    {"basic.db", "SELECT * FROM numbers WHERE n = 32767", "32767|-8388608|-0.5|\n"},
+    // Rows that spill onto overflow pages.
+    {"basic.db", "SELECT body FROM big WHERE id = 1", "short\n"},
+    {"basic.db", "SELECT body FROM big WHERE id = 2", strings.Repeat("x", 2000) + "\n"},
+    {"basic.db", "SELECT body FROM big WHERE id = 3", strings.Repeat("y", 500) + "\n"},
+    {"utf16.db", "SELECT * FROM words", "hello|en\nhéllo wörld|xx\n日本語|ja\n😀|emoji\n"},
+    {"utf16.db", "SELECT lang FROM words WHERE word = '日本語'", "ja\n"},
+    {"basic.db", ".tables", "big fruit numbers odd names\n"},
+    {"utf16.db", ".dbinfo",
+      "database page size:  512\ndatabase page count: 2\ntext encoding:       utf16le\nnumber of tables:    1\n"},
+  } {
+    got, err := exec(t, c.db, c.cmd)
+    if err != nil {
+      t.Errorf("%s: %v", c.cmd, err)
+    } else if got != c.want {
+      t.Errorf("%s:\n%s\nwant:\n%s", c.cmd, got, c.want)
+    }
+  }
+}
+
+func TestQueryErrors(t *testing.T) {
+  for _, c := range []struct{ cmd, want string }{
+    {"SELECT * FROM nope", "no such table: nope"},
+    {"SELECT nope FROM fruit", "no such column: nope"},
+    {"SELECT * FROM fruit WHERE nope = 1", "no such column: nope"},
+    {"SELECT * FROM fruit WHERE price > 1", "only equality conditions are supported"},
+    {"DELETE FROM fruit", "only SELECT statements are supported"},
+    {"SELECT * FROM fruit LIMIT x", "invalid LIMIT"},
+  } {
+    _, err := exec(t, "basic.db", c.cmd)
+    if err == nil || err.Error() != c.want {
+      t.Errorf("%s: got error %v, want %s", c.cmd, err, c.want)
+    }
+  }
+}
+
+// Reals as the sqlite3 shell prints them.
+func TestFormatReal(t *testing.T) {
+  for _, c := range []struct {
+    v    float64
+    want string
+  }{
+    {100, "100.0"},
+    {0.25, "0.25"},
+    {1e-5, "1.0e-05"},
+    {1.25e-7, "1.25e-07"},
+    {1e15, "1.0e+15"},
+    {1.5e300, "1.5e+300"},
+    {math.Inf(1), "Inf"},
+    {math.Inf(-1), "-Inf"},
+  } {
+    testx.Equal(t, formatValue(c.v), c.want)
+  }
+}
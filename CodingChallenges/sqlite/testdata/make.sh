@@ -0,0 +1,35 @@
+#!/bin/sh
+# Rebuild the test databases with the real sqlite3 shell:
+#
+#   cd testdata && ./make.sh
+#
+# Small pages give the larger tables interior pages and the long rows
+# overflow pages while keeping the files a few kilobytes.
+set -e
+rm -f basic.db utf16.db
+
+sqlite3 basic.db <<'SQL'
+PRAGMA page_size = 512;
+CREATE TABLE fruit (id INTEGER PRIMARY KEY, name TEXT, color TEXT, price REAL);
+INSERT INTO fruit (name, color, price) VALUES
+  ('apple', 'red', 1.5), ('banana', 'yellow', 0.25), ('cherry', 'red', 4),
+  ('grape''s', 'green', NULL), ('kiwi', 'brown', -3.75);
+CREATE TABLE "odd names" ([first col] TEXT, `second` INT, "third, with comma" BLOB, CHECK (second > 0));
+INSERT INTO "odd names" VALUES ('a', 1, x'68690a'), ('b', 2, NULL);
+CREATE TABLE numbers (n INTEGER, square INTEGER, half REAL, word TEXT);
+WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < 300)
+INSERT INTO numbers SELECT n, n * n, n / 2.0, printf('n%03d', n) FROM seq;
+INSERT INTO numbers VALUES (-129, 4294967296, 1e20, NULL), (32767, -8388608, -0.5, '');
+CREATE TABLE big (id INTEGER PRIMARY KEY, body TEXT);
+INSERT INTO big VALUES (1, 'short'), (2, printf('%.*c', 2000, 'x')), (3, printf('%.*c', 500, 'y'));
+ALTER TABLE fruit ADD COLUMN stock INTEGER;
+INSERT INTO fruit (name, color, price, stock) VALUES ('lemon', 'yellow', 0.5, 12);
+CREATE INDEX fruit_color ON fruit (color);
+SQL
+
+sqlite3 utf16.db <<'SQL'
+PRAGMA page_size = 512;
+PRAGMA encoding = 'UTF-16le';
+CREATE TABLE words (word TEXT, lang TEXT);
+INSERT INTO words VALUES ('hello', 'en'), ('héllo wörld', 'xx'), ('日本語', 'ja'), ('😀', 'emoji');
+SQL
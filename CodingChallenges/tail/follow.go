@@ -0,0 +1,93 @@
+package main
+
+import (
+  "fmt"
+  "io"
+  "os"
+  "time"
+)
+
+// A file being followed. file is nil while the name does not exist.
+type followed struct {
+  name   string
+  file   *os.File
+  offset int64
+}
+
+// Copy anything appended since the last read. A file that shrank below our
+// offset was truncated in place, so start again from the beginning.
+func (fl *followed) drain(w io.Writer, banner func()) error {
+  info, err := fl.file.Stat()
+  if err != nil {
+    return err
+  }
+  if info.Size() < fl.offset {
+    fmt.Fprintf(os.Stderr, "tail: %s: file truncated\n", fl.name)
+    fl.offset = 0
+  }
+  if info.Size() == fl.offset {
+    return nil
+  }
+  banner()
+  n, err := io.Copy(w, io.NewSectionReader(fl.file, fl.offset, info.Size()-fl.offset))
+  fl.offset += n
+  return err
+}
+
+// Check whether the name now refers to a different file than the one we have
+// open, which is what log rotation (rename and recreate) looks like. The old
+// file is drained before switching so no lines written just before the
+// rotation are lost.
+func (fl *followed) checkRotation(w io.Writer, banner func()) {
+  current, err := os.Stat(fl.name)
+  if err != nil {
+    return // removed; keep reading the old file until a new one appears
+  }
+  event := "appeared"
+  if fl.file != nil {
+    event = "been replaced"
+    opened, err := fl.file.Stat()
+    if err == nil && os.SameFile(opened, current) {
+      return
+    }
+    fl.drain(w, banner)
+    fl.file.Close()
+    fl.file = nil
+  }
+  file, err := os.Open(fl.name)
+  if err != nil {
+    return
+  }
+  fmt.Fprintf(os.Stderr, "tail: '%s' has %s; following new file\n", fl.name, event)
+  fl.file, fl.offset = file, 0
+}
+
+// Check each file once for rotation and new data, and return the name of
+// the file output last came from. Banners are shown when output switches to
+// a different file, like tail -f with several files.
+func poll(files []*followed, w io.Writer, banners bool, last string) string {
+  for _, fl := range files {
+    banner := func() {
+      if banners && last != fl.name {
+        fmt.Fprintf(w, "\n==> %s <==\n", fl.name)
+      }
+      last = fl.name
+    }
+    fl.checkRotation(w, banner)
+    if fl.file == nil {
+      continue
+    }
+    if err := fl.drain(w, banner); err != nil {
+      fmt.Fprintln(os.Stderr, "tail:", err)
+    }
+  }
+  return last
+}
+
+// Poll the files forever, printing new data as it arrives.
+func followLoop(files []*followed, w io.Writer, interval time.Duration, banners bool, last string) {
+  for {
+    last = poll(files, w, banners, last)
+    time.Sleep(interval)
+  }
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+func write(t *testing.T, path, data string) {
+  t.Helper()
+  if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+    t.Fatal(err)
+  }
+}
+
+func appendTo(t *testing.T, path, data string) {
+  t.Helper()
+  f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer f.Close()
+  if _, err := f.WriteString(data); err != nil {
+    t.Fatal(err)
+  }
+}
+
+// Start following path as tail -n 1 -f does, checking what is printed
+// first.
+func follow(t *testing.T, path, want string) *followed {
+  t.Helper()
+  f, err := os.Open(path)
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { f.Close() })
+  var out strings.Builder
+  offset, err := tail(f, &out, spec{n: 1})
+  if err != nil || out.String() != want {
+    t.Fatalf("tail printed %q, %v, want %q", out.String(), err, want)
+  }
+  return &followed{name: path, file: f, offset: offset}
+}
+
+// Poll once and check what was printed.
+func step(t *testing.T, files []*followed, banners bool, last *string, want string) {
+  t.Helper()
+  var out strings.Builder
+  *last = poll(files, &out, banners, *last)
+  if out.String() != want {
+    t.Errorf("got %q, want %q", out.String(), want)
+  }
+}
+
+func TestFollowGrowing(t *testing.T) {
+  path := filepath.Join(t.TempDir(), "log")
+  write(t, path, "a\nb\n")
+  files := []*followed{follow(t, path, "b\n")}
+  last := path
+  step(t, files, false, &last, "")
+  appendTo(t, path, "c\n")
+  step(t, files, false, &last, "c\n")
+  // A partial line is printed as it comes.
+  appendTo(t, path, "d")
+  step(t, files, false, &last, "d")
+  appendTo(t, path, "e\nf\n")
+  step(t, files, false, &last, "e\nf\n")
+  step(t, files, false, &last, "")
+  // Truncated in place: start again from the beginning.
+  write(t, path, "x\n")
+  step(t, files, false, &last, "x\n")
+}
+
+// Rotation by rename and recreate: what was written to the old file before
+// the switch is printed, then the new file from its start.
+func TestFollowRotated(t *testing.T) {
+  dir := t.TempDir()
+  path := filepath.Join(dir, "log")
+  write(t, path, "old 1\nold 2\n")
+  files := []*followed{follow(t, path, "old 2\n")}
+  last := path
+
+  if err := os.Rename(path, path+".1"); err != nil {
+    t.Fatal(err)
+  }
+  // Gone, but still open: writes to the old file keep coming.
+  appendTo(t, path+".1", "old 3\n")
+  step(t, files, false, &last, "old 3\n")
+  appendTo(t, path+".1", "old 4\n")
+  write(t, path, "new 1\n")
+  step(t, files, false, &last, "old 4\nnew 1\n")
+  appendTo(t, path+".1", "old 5\n")
+  appendTo(t, path, "new 2\n")
+  step(t, files, false, &last, "new 2\n")
+
+  // Removed and created again.
+  if err := os.Remove(path); err != nil {
+    t.Fatal(err)
+  }
+  step(t, files, false, &last, "")
+  write(t, path, "newer\n")
+  step(t, files, false, &last, "newer\n")
+}
+
+// A file missing at the start is picked up when it appears, and banners
+// mark each switch between files.
+func TestFollowMissing(t *testing.T) {
+  dir := t.TempDir()
+  a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+  write(t, a, "a1\n")
+  files := []*followed{follow(t, a, "a1\n"), {name: b}}
+  last := a
+  step(t, files, true, &last, "")
+  write(t, b, "b1\n")
+  step(t, files, true, &last, "\n==> "+b+" <==\nb1\n")
+  appendTo(t, b, "b2\n")
+  step(t, files, true, &last, "b2\n")
+  appendTo(t, a, "a2\n")
+  appendTo(t, b, "b3\n")
+  step(t, files, true, &last, "\n==> "+a+" <==\na2\n\n==> "+b+" <==\nb3\n")
+}
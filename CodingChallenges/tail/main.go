@@ -0,0 +1,260 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "strconv"
+  "strings"
+  "time"
+)
+
+const blockSize = 4096
+
+// Find the offset where the last n lines of a seekable file start by reading
+// blocks backwards from the end, so only the tail is ever read. A final line
+// without a trailing newline still counts as a line.
+func lastLinesOffset(f *os.File, size int64, n int64) (int64, error) {
+  if n <= 0 {
+    return size, nil
+  }
+  buf := make([]byte, blockSize)
+  pos := size
+  newlines := int64(0)
+  first := true
+  for pos > 0 {
+    chunk := int64(blockSize)
+    if pos < chunk {
+      chunk = pos
+    }
+    pos -= chunk
+    if _, err := f.ReadAt(buf[:chunk], pos); err != nil && err != io.EOF {
+      return 0, err
+    }
+    block := buf[:chunk]
+    if first && block[len(block)-1] == '\n' {
+      // The newline ending the last line does not start a new one.
+      block = block[:len(block)-1]
+    }
+    first = false
+    for i := len(block) - 1; i >= 0; i-- {
+      if block[i] == '\n' {
+        newlines++
+        if newlines == n {
+          return pos + int64(i) + 1, nil
+        }
+      }
+    }
+  }
+  return 0, nil
+}
+
+// Keep the last n lines of a stream that cannot seek, such as a pipe.
+func lastLinesStream(r io.Reader, w io.Writer, n int64) error {
+  if n <= 0 {
+    _, err := io.Copy(io.Discard, r)
+    return err
+  }
+  ring := make([][]byte, n)
+  count := int64(0)
+  br := bufio.NewReader(r)
+  for {
+    line, err := br.ReadBytes('\n')
+    if len(line) > 0 {
+      ring[count%n] = line
+      count++
+    }
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return err
+    }
+  }
+  start := int64(0)
+  if count > n {
+    start = count - n
+  }
+  for i := start; i < count; i++ {
+    if _, err := w.Write(ring[i%n]); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// Skip the first n-1 lines of r (for "-n +n") and copy the rest.
+func fromLine(r io.Reader, w io.Writer, n int64) error {
+  br := bufio.NewReader(r)
+  for i := int64(1); i < n; i++ {
+    if _, err := br.ReadSlice('\n'); err == bufio.ErrBufferFull {
+      i--
+    } else if err == io.EOF {
+      return nil
+    } else if err != nil {
+      return err
+    }
+  }
+  _, err := io.Copy(w, br)
+  return err
+}
+
+// Which part of the input to print, parsed from -n or -c. A leading "+"
+// means "starting at" instead of "the last".
+type spec struct {
+  n         int64
+  fromStart bool
+  bytes     bool
+}
+
+func parseSpec(s string, bytes bool) (spec, error) {
+  sp := spec{bytes: bytes}
+  if strings.HasPrefix(s, "+") {
+    sp.fromStart = true
+    s = s[1:]
+  }
+  n, err := strconv.ParseInt(strings.TrimPrefix(s, "-"), 10, 64)
+  if err != nil || n < 0 {
+    return sp, fmt.Errorf("invalid number: %q", s)
+  }
+  sp.n = n
+  return sp, nil
+}
+
+// Print the requested tail of a file and return the offset reached, which is
+// where following resumes. Seekable files are read from the end; other
+// input falls back to streaming.
+func tail(f *os.File, w io.Writer, sp spec) (int64, error) {
+  info, err := f.Stat()
+  if err != nil {
+    return 0, err
+  }
+  if !info.Mode().IsRegular() {
+    switch {
+    case sp.fromStart && sp.bytes:
+      if _, err := io.CopyN(io.Discard, f, max(sp.n-1, 0)); err != nil && err != io.EOF {
+        return 0, err
+      }
+      _, err = io.Copy(w, f)
+    case sp.fromStart:
+      err = fromLine(f, w, sp.n)
+    case sp.bytes:
+      var data []byte
+      if data, err = io.ReadAll(f); err == nil {
+        _, err = w.Write(data[max(int64(len(data))-sp.n, 0):])
+      }
+    default:
+      err = lastLinesStream(f, w, sp.n)
+    }
+    return 0, err
+  }
+
+  size := info.Size()
+  var start int64
+  switch {
+  case sp.fromStart && sp.bytes:
+    start = min(max(sp.n-1, 0), size)
+  case sp.fromStart:
+    if _, err := f.Seek(0, io.SeekStart); err != nil {
+      return 0, err
+    }
+    return size, fromLine(io.LimitReader(f, size), w, sp.n)
+  case sp.bytes:
+    start = max(size-sp.n, 0)
+  default:
+    if start, err = lastLinesOffset(f, size, sp.n); err != nil {
+      return 0, err
+    }
+  }
+  if _, err := f.Seek(start, io.SeekStart); err != nil {
+    return 0, err
+  }
+  _, err = io.CopyN(w, f, size-start)
+  return size, err
+}
+
+// Numbers like "tail -5" and "tail +5" are accepted as the old obsolete
+// syntax by rewriting them to -n before flag parsing.
+func rewriteObsolete(args []string) []string {
+  if len(args) > 0 && len(args[0]) > 1 && (args[0][0] == '-' || args[0][0] == '+') {
+    if _, err := strconv.Atoi(args[0][1:]); err == nil {
+      return append([]string{"-n", args[0]}, args[1:]...)
+    }
+  }
+  return args
+}
+
+// Print the tail of each file, then keep following them with -f.
+func main() {
+  fs := flag.NewFlagSet("tail", flag.ExitOnError)
+  lines := fs.String("n", "10", "output the last `num` lines, or use +num to start at line num")
+  count := fs.String("c", "", "output the last `num` bytes, or use +num to start at byte num")
+  follow := fs.Bool("f", false, "output appended data as the file grows, reopening it if it is rotated")
+  interval := fs.Duration("s", time.Second, "with -f, how often to check the files")
+  quiet := fs.Bool("q", false, "never print file name headers")
+  fs.Parse(rewriteObsolete(os.Args[1:]))
+
+  sp, err := parseSpec(*lines, false)
+  if *count != "" {
+    sp, err = parseSpec(*count, true)
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "tail:", err)
+    os.Exit(1)
+  }
+
+  names := fs.Args()
+  if len(names) == 0 {
+    names = []string{"-"}
+  }
+  w := bufio.NewWriter(os.Stdout)
+  banners := len(names) > 1 && !*quiet
+  status, shown := 0, ""
+  var watched []*followed
+  for i, name := range names {
+    f := os.Stdin
+    if name != "-" {
+      if f, err = os.Open(name); err != nil {
+        fmt.Fprintln(os.Stderr, "tail:", err)
+        status = 1
+        if *follow {
+          // Keep watching for the file to appear.
+          watched = append(watched, &followed{name: name})
+        }
+        continue
+      }
+    }
+    if banners {
+      if i > 0 {
+        w.WriteString("\n")
+      }
+      fmt.Fprintf(w, "==> %s <==\n", displayName(name))
+    }
+    shown = name
+    offset, err := tail(f, w, sp)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "tail:", err)
+      status = 1
+    }
+    if *follow && name != "-" {
+      watched = append(watched, &followed{name: name, file: f, offset: offset})
+    } else if name != "-" {
+      f.Close()
+    }
+  }
+  w.Flush()
+
+  if *follow && len(watched) > 0 {
+    followLoop(watched, os.Stdout, *interval, banners, shown)
+  }
+  os.Exit(status)
+}
+
+func displayName(name string) string {
+  if name == "-" {
+    return "standard input"
+  }
+  return name
+}
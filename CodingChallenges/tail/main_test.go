@@ -0,0 +1,123 @@
+package main
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// Run tail on content as a regular file and as a pipe, which it cannot
+// seek, and return both outputs.
+func runTail(t *testing.T, content string, sp spec) (file, pipe string) {
+  t.Helper()
+  path := filepath.Join(t.TempDir(), "in")
+  if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+    t.Fatal(err)
+  }
+  f, err := os.Open(path)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer f.Close()
+  var out strings.Builder
+  offset, err := tail(f, &out, sp)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if offset != int64(len(content)) {
+    t.Errorf("following would resume at %d of %d", offset, len(content))
+  }
+  file = out.String()
+
+  r, w, err := os.Pipe()
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer r.Close()
+  go func() {
+    w.WriteString(content)
+    w.Close()
+  }()
+  out.Reset()
+  if _, err := tail(r, &out, sp); err != nil {
+    t.Fatal(err)
+  }
+  return file, out.String()
+}
+
+// Each spec on a few inputs, checked against GNU tail.
+func TestTail(t *testing.T) {
+  for _, c := range []struct {
+    in, spec string
+    bytes    bool
+    want     string
+  }{
+    {"1\n2\n3\n4\n5", "2", false, "4\n5"},
+    {"1\n2\n3\n4\n5", "0", false, ""},
+    {"1\n2\n3\n4\n5", "9", false, "1\n2\n3\n4\n5"},
+    {"1\n2\n3\n4\n5", "+2", false, "2\n3\n4\n5"},
+    {"1\n2\n3\n4\n5", "+9", false, ""},
+    {"1\n2\n3\n4\n5", "3", true, "4\n5"},
+    {"1\n2\n3\n4\n5", "+3", true, "2\n3\n4\n5"},
+    {"", "2", false, ""},
+    {"", "+2", false, ""},
+    {"", "3", true, ""},
+    {"\n\n\n", "2", false, "\n\n"},
+    {"\n\n\n", "+2", false, "\n\n"},
+    {"\n\n\n", "+3", true, "\n"},
+  } {
+    sp, err := parseSpec(c.spec, c.bytes)
+    if err != nil {
+      t.Fatal(err)
+    }
+    file, pipe := runTail(t, c.in, sp)
+    if file != c.want || pipe != c.want {
+      t.Errorf("%q with %s (bytes %v): got %q from a file and %q from a pipe, want %q", c.in, c.spec, c.bytes, file, pipe, c.want)
+    }
+  }
+}
+
+// Lines are found across the blocks a file is read backwards in.
+func TestTailBlocks(t *testing.T) {
+  var b strings.Builder
+  for i := 1; b.Len() < 3*blockSize; i++ {
+    fmt.Fprintf(&b, "line %d %s\n", i, strings.Repeat("x", i%97))
+  }
+  lines := strings.SplitAfter(b.String(), "\n")
+  lines = lines[:len(lines)-1]
+  for _, n := range []int{1, 10, 200, len(lines), len(lines) + 5} {
+    want := strings.Join(lines[max(len(lines)-n, 0):], "")
+    file, pipe := runTail(t, b.String(), spec{n: int64(n)})
+    if file != want || pipe != want {
+      t.Errorf("last %d lines: got %d bytes from a file and %d from a pipe, want %d", n, len(file), len(pipe), len(want))
+    }
+  }
+}
+
+func TestParseSpec(t *testing.T) {
+  for _, c := range []struct {
+    in   string
+    want spec
+  }{
+    {"10", spec{n: 10}},
+    {"-10", spec{n: 10}},
+    {"+10", spec{n: 10, fromStart: true}},
+  } {
+    if got, err := parseSpec(c.in, false); err != nil || got != c.want {
+      t.Errorf("%s: got %+v, %v, want %+v", c.in, got, err, c.want)
+    }
+  }
+  for _, in := range []string{"", "x", "+", "--1", "1k"} {
+    if _, err := parseSpec(in, false); err == nil {
+      t.Errorf("%q parsed", in)
+    }
+  }
+  if got := rewriteObsolete([]string{"-5", "f"}); strings.Join(got, " ") != "-n -5 f" {
+    t.Errorf("obsolete -5 became %q", got)
+  }
+  if got := rewriteObsolete([]string{"-f", "f"}); strings.Join(got, " ") != "-f f" {
+    t.Errorf("-f became %q", got)
+  }
+}
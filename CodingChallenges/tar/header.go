@@ -0,0 +1,217 @@
+package main
+
+import (
+  "bytes"
+  "errors"
+  "fmt"
+  "strconv"
+  "strings"
+  "time"
+)
+
+const blockSize = 512
+
+// Type flags used in the ustar typeflag byte. GNU tar also writes 'L' for
+// long names and pax writers use 'x'/'g' for extended headers.
+const (
+  typeReg     = '0'
+  typeRegA    = '\x00'
+  typeLink    = '1'
+  typeSymlink = '2'
+  typeDir     = '5'
+  typeGNULong = 'L'
+  typeGNULink = 'K'
+  typePax     = 'x'
+  typePaxGlob = 'g'
+)
+
+// A decoded header. Only the fields this tool uses are kept.
+type header struct {
+  name     string
+  mode     int64
+  uid, gid int64
+  size     int64
+  mtime    time.Time
+  typeflag byte
+  linkname string
+  uname    string
+  gname    string
+}
+
+// Field offsets and lengths within a 512-byte ustar header block.
+var (
+  fName     = [2]int{0, 100}
+  fMode     = [2]int{100, 8}
+  fUID      = [2]int{108, 8}
+  fGID      = [2]int{116, 8}
+  fSize     = [2]int{124, 12}
+  fMtime    = [2]int{136, 12}
+  fChksum   = [2]int{148, 8}
+  fType     = [2]int{156, 1}
+  fLinkname = [2]int{157, 100}
+  fMagic    = [2]int{257, 6}
+  fVersion  = [2]int{263, 2}
+  fUname    = [2]int{265, 32}
+  fGname    = [2]int{297, 32}
+  fPrefix   = [2]int{345, 155}
+)
+
+func field(block []byte, f [2]int) []byte {
+  return block[f[0] : f[0]+f[1]]
+}
+
+// Strings are NUL padded; a field may use every byte with no terminator.
+func parseString(b []byte) string {
+  if i := bytes.IndexByte(b, 0); i >= 0 {
+    b = b[:i]
+  }
+  return string(b)
+}
+
+// Numbers are octal ASCII, NUL or space terminated. GNU tar stores values too
+// large for octal as big-endian binary with the high bit of the first byte set.
+func parseNumber(b []byte) (int64, error) {
+  if len(b) > 0 && b[0]&0x80 != 0 {
+    var n int64
+    for i, c := range b {
+      if i == 0 {
+        c &= 0x7f
+      }
+      n = n<<8 | int64(c)
+    }
+    return n, nil
+  }
+  s := strings.Trim(string(b), " \x00")
+  if s == "" {
+    return 0, nil
+  }
+  return strconv.ParseInt(s, 8, 64)
+}
+
+// Write an octal number right-aligned with leading zeros and a trailing NUL.
+func formatNumber(b []byte, n int64) error {
+  s := strconv.FormatInt(n, 8)
+  if len(s) > len(b)-1 {
+    return fmt.Errorf("value %d does not fit in a %d byte header field", n, len(b))
+  }
+  copy(b, strings.Repeat("0", len(b)-1-len(s))+s)
+  b[len(b)-1] = 0
+  return nil
+}
+
+// The checksum is the sum of all header bytes with the checksum field itself
+// treated as eight spaces.
+func checksum(block []byte) int64 {
+  var sum int64
+  for i, c := range block {
+    if i >= fChksum[0] && i < fChksum[0]+fChksum[1] {
+      c = ' '
+    }
+    sum += int64(c)
+  }
+  return sum
+}
+
+// Split a path into ustar prefix and name at a "/" so both parts fit.
+func splitName(name string) (prefix, base string, err error) {
+  if len(name) <= fName[1] {
+    return "", name, nil
+  }
+  for i := len(name) - 1; i > 0; i-- {
+    if name[i] == '/' && i <= fPrefix[1] && len(name)-i-1 <= fName[1] && len(name)-i-1 > 0 {
+      return name[:i], name[i+1:], nil
+    }
+  }
+  return "", "", fmt.Errorf("%s: file name is too long for ustar", name)
+}
+
+// Encode a header as a POSIX ustar block.
+func (h *header) marshal() ([]byte, error) {
+  block := make([]byte, blockSize)
+  prefix, name, err := splitName(h.name)
+  if err != nil {
+    return nil, err
+  }
+  if len(h.linkname) > fLinkname[1] {
+    return nil, fmt.Errorf("%s: link target is too long for ustar", h.name)
+  }
+  copy(field(block, fName), name)
+  copy(field(block, fPrefix), prefix)
+  copy(field(block, fLinkname), h.linkname)
+  copy(field(block, fUname), h.uname)
+  copy(field(block, fGname), h.gname)
+  copy(field(block, fMagic), "ustar\x00")
+  copy(field(block, fVersion), "00")
+  block[fType[0]] = h.typeflag
+  for _, f := range []struct {
+    f [2]int
+    n int64
+  }{{fMode, h.mode}, {fUID, h.uid}, {fGID, h.gid}, {fSize, h.size}, {fMtime, h.mtime.Unix()}} {
+    if err := formatNumber(field(block, f.f), f.n); err != nil {
+      return nil, fmt.Errorf("%s: %w", h.name, err)
+    }
+  }
+  // The checksum is six octal digits, a NUL and a space.
+  sum := field(block, fChksum)
+  formatNumber(sum[:7], checksum(block))
+  sum[7] = ' '
+  return block, nil
+}
+
+// Decode a header block, verifying its checksum. Both POSIX ("ustar\0" "00")
+// and old GNU ("ustar  \0") magic are accepted.
+func unmarshal(block []byte) (*header, error) {
+  want, err := parseNumber(field(block, fChksum))
+  if err != nil || want != checksum(block) {
+    return nil, errors.New("header checksum mismatch")
+  }
+  h := &header{
+    name:     parseString(field(block, fName)),
+    typeflag: block[fType[0]],
+    linkname: parseString(field(block, fLinkname)),
+  }
+  magic := string(field(block, fMagic))
+  if magic == "ustar\x00" || magic == "ustar " {
+    h.uname = parseString(field(block, fUname))
+    h.gname = parseString(field(block, fGname))
+    if magic == "ustar\x00" {
+      if prefix := parseString(field(block, fPrefix)); prefix != "" {
+        h.name = prefix + "/" + h.name
+      }
+    }
+  }
+  var mtime int64
+  for _, f := range []struct {
+    f [2]int
+    n *int64
+  }{{fMode, &h.mode}, {fUID, &h.uid}, {fGID, &h.gid}, {fSize, &h.size}, {fMtime, &mtime}} {
+    if *f.n, err = parseNumber(field(block, f.f)); err != nil {
+      return nil, fmt.Errorf("invalid header field: %w", err)
+    }
+  }
+  h.mtime = time.Unix(mtime, 0)
+  return h, nil
+}
+
+// Parse pax extended header records ("<len> <key>=<value>\n") and return the
+// ones we understand.
+func parsePax(data []byte) (map[string]string, error) {
+  records := map[string]string{}
+  for len(data) > 0 {
+    sp := bytes.IndexByte(data, ' ')
+    if sp < 0 {
+      return nil, errors.New("invalid pax record")
+    }
+    n, err := strconv.Atoi(string(data[:sp]))
+    if err != nil || n <= sp || n > len(data) || data[n-1] != '\n' {
+      return nil, errors.New("invalid pax record")
+    }
+    key, value, ok := strings.Cut(string(data[sp+1:n-1]), "=")
+    if !ok {
+      return nil, errors.New("invalid pax record")
+    }
+    records[key] = value
+    data = data[n:]
+  }
+  return records, nil
+}
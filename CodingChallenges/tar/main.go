@@ -0,0 +1,570 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "errors"
+  "fmt"
+  "io"
+  "io/fs"
+  "os"
+  "os/user"
+  "path/filepath"
+  "sort"
+  "strconv"
+  "strings"
+  "syscall"
+  "time"
+)
+
+// Archives end with two zero blocks and are padded to a record of twenty
+// blocks, as GNU tar does by default.
+const recordSize = 20 * blockSize
+
+// A reader yields one entry at a time. GNU long name/link entries and pax
+// extended headers are folded into the header of the entry that follows.
+type reader struct {
+  r         io.Reader
+  remaining int64
+  pad       int64
+}
+
+func (tr *reader) skip() error {
+  _, err := io.CopyN(io.Discard, tr.r, tr.remaining+tr.pad)
+  tr.remaining, tr.pad = 0, 0
+  return err
+}
+
+func (tr *reader) Read(p []byte) (int, error) {
+  if tr.remaining == 0 {
+    return 0, io.EOF
+  }
+  if int64(len(p)) > tr.remaining {
+    p = p[:tr.remaining]
+  }
+  n, err := tr.r.Read(p)
+  tr.remaining -= int64(n)
+  if err == io.EOF {
+    err = io.ErrUnexpectedEOF
+  }
+  return n, err
+}
+
+// Return the next entry, or io.EOF at the end-of-archive marker.
+func (tr *reader) next() (*header, error) {
+  var longName, longLink string
+  var pax map[string]string
+  for {
+    if err := tr.skip(); err != nil {
+      return nil, err
+    }
+    block := make([]byte, blockSize)
+    if _, err := io.ReadFull(tr.r, block); err != nil {
+      if err == io.EOF {
+        return nil, errors.New("unexpected end of archive")
+      }
+      return nil, err
+    }
+    if bytes.Equal(block, make([]byte, blockSize)) {
+      return nil, io.EOF
+    }
+    h, err := unmarshal(block)
+    if err != nil {
+      return nil, err
+    }
+    tr.remaining = h.size
+    if h.typeflag == typeDir || h.typeflag == typeLink || h.typeflag == typeSymlink {
+      tr.remaining = 0
+    }
+    tr.pad = (blockSize - tr.remaining%blockSize) % blockSize
+
+    switch h.typeflag {
+    case typeGNULong, typeGNULink, typePax, typePaxGlob:
+      data, err := io.ReadAll(tr)
+      if err != nil {
+        return nil, err
+      }
+      switch h.typeflag {
+      case typeGNULong:
+        longName = parseString(data)
+      case typeGNULink:
+        longLink = parseString(data)
+      case typePax:
+        if pax, err = parsePax(data); err != nil {
+          return nil, err
+        }
+      }
+      continue
+    }
+
+    if longName != "" {
+      h.name = longName
+    }
+    if longLink != "" {
+      h.linkname = longLink
+    }
+    if v, ok := pax["path"]; ok {
+      h.name = v
+    }
+    if v, ok := pax["linkpath"]; ok {
+      h.linkname = v
+    }
+    if v, ok := pax["size"]; ok {
+      if h.size, err = strconv.ParseInt(v, 10, 64); err != nil {
+        return nil, errors.New("invalid pax size")
+      }
+      tr.remaining = h.size
+      tr.pad = (blockSize - h.size%blockSize) % blockSize
+    }
+    if v, ok := pax["mtime"]; ok {
+      secs, _, _ := strings.Cut(v, ".")
+      if n, err := strconv.ParseInt(secs, 10, 64); err == nil {
+        h.mtime = time.Unix(n, 0)
+      }
+    }
+    return h, nil
+  }
+}
+
+// Look up user and group names once per id.
+var userNames, groupNames = map[int64]string{}, map[int64]string{}
+
+func ownerNames(uid, gid int64) (string, string) {
+  if _, ok := userNames[uid]; !ok {
+    if u, err := user.LookupId(strconv.FormatInt(uid, 10)); err == nil {
+      userNames[uid] = u.Username
+    } else {
+      userNames[uid] = ""
+    }
+  }
+  if _, ok := groupNames[gid]; !ok {
+    if g, err := user.LookupGroupId(strconv.FormatInt(gid, 10)); err == nil {
+      groupNames[gid] = g.Name
+    } else {
+      groupNames[gid] = ""
+    }
+  }
+  return userNames[uid], groupNames[gid]
+}
+
+// Build a header for a file on disk. Hard links to a file already in the
+// archive are stored as link entries so their content is written only once.
+func fileHeader(path, name string, info fs.FileInfo, seen map[[2]uint64]string) (*header, error) {
+  h := &header{name: name, mode: int64(info.Mode().Perm()), mtime: info.ModTime()}
+  if st, ok := info.Sys().(*syscall.Stat_t); ok {
+    h.uid, h.gid = int64(st.Uid), int64(st.Gid)
+    h.uname, h.gname = ownerNames(h.uid, h.gid)
+    if info.Mode()&fs.ModeSetuid != 0 {
+      h.mode |= 0o4000
+    }
+    if info.Mode()&fs.ModeSetgid != 0 {
+      h.mode |= 0o2000
+    }
+    if info.Mode()&fs.ModeSticky != 0 {
+      h.mode |= 0o1000
+    }
+    if info.Mode().IsRegular() && st.Nlink > 1 {
+      key := [2]uint64{uint64(st.Dev), uint64(st.Ino)}
+      if target, ok := seen[key]; ok {
+        h.typeflag, h.linkname = typeLink, target
+        return h, nil
+      }
+      seen[key] = name
+    }
+  }
+  switch {
+  case info.IsDir():
+    h.typeflag = typeDir
+    if !strings.HasSuffix(h.name, "/") {
+      h.name += "/"
+    }
+  case info.Mode()&fs.ModeSymlink != 0:
+    target, err := os.Readlink(path)
+    if err != nil {
+      return nil, err
+    }
+    h.typeflag, h.linkname = typeSymlink, target
+  case info.Mode().IsRegular():
+    h.typeflag, h.size = typeReg, info.Size()
+  default:
+    return nil, fmt.Errorf("%s: unsupported file type", path)
+  }
+  return h, nil
+}
+
+// Archive each path, recursing into directories in sorted order. Leading
+// "/" is stripped from member names, as GNU tar does.
+func create(w io.Writer, paths []string, verbose bool) error {
+  bw := bufio.NewWriter(w)
+  written := int64(0)
+  write := func(b []byte) error {
+    n, err := bw.Write(b)
+    written += int64(n)
+    return err
+  }
+  seen := map[[2]uint64]string{}
+
+  for _, root := range paths {
+    err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+      if err != nil {
+        return err
+      }
+      info, err := os.Lstat(path)
+      if err != nil {
+        return err
+      }
+      name := strings.TrimLeft(filepath.ToSlash(path), "/")
+      if name == "" {
+        return nil
+      }
+      h, err := fileHeader(path, name, info, seen)
+      if err != nil {
+        return err
+      }
+      block, err := h.marshal()
+      if err != nil {
+        return err
+      }
+      if verbose {
+        fmt.Fprintln(os.Stderr, h.name)
+      }
+      if err := write(block); err != nil {
+        return err
+      }
+      if h.typeflag != typeReg || h.size == 0 {
+        return nil
+      }
+      file, err := os.Open(path)
+      if err != nil {
+        return err
+      }
+      defer file.Close()
+      // Copy exactly the size recorded in the header; a file that changes
+      // while being read would otherwise corrupt the archive.
+      n, err := io.Copy(bw, io.LimitReader(file, h.size))
+      written += n
+      if err != nil {
+        return err
+      }
+      if n != h.size {
+        return fmt.Errorf("%s: file shrank while being archived", path)
+      }
+      if pad := (blockSize - h.size%blockSize) % blockSize; pad > 0 {
+        return write(make([]byte, pad))
+      }
+      return nil
+    })
+    if err != nil {
+      return err
+    }
+  }
+
+  if err := write(make([]byte, 2*blockSize)); err != nil {
+    return err
+  }
+  if pad := (recordSize - written%recordSize) % recordSize; pad > 0 {
+    if err := write(make([]byte, pad)); err != nil {
+      return err
+    }
+  }
+  return bw.Flush()
+}
+
+// Render a mode like "ls -l" for verbose listings.
+func modeString(h *header) string {
+  kind := "-"
+  switch h.typeflag {
+  case typeDir:
+    kind = "d"
+  case typeSymlink:
+    kind = "l"
+  case typeLink:
+    kind = "h"
+  }
+  const rwx = "rwxrwxrwx"
+  b := []byte(kind + "---------")
+  for i := 0; i < 9; i++ {
+    if h.mode&(1<<uint(8-i)) != 0 {
+      b[i+1] = rwx[i]
+    }
+  }
+  return string(b)
+}
+
+func list(r io.Reader, verbose bool) error {
+  tr := &reader{r: bufio.NewReader(r)}
+  for {
+    h, err := tr.next()
+    if err == io.EOF {
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+    if !verbose {
+      fmt.Println(h.name)
+      continue
+    }
+    owner := h.uname
+    if owner == "" {
+      owner = strconv.FormatInt(h.uid, 10)
+    }
+    group := h.gname
+    if group == "" {
+      group = strconv.FormatInt(h.gid, 10)
+    }
+    line := fmt.Sprintf("%s %s/%s %8d %s %s", modeString(h), owner, group, h.size,
+      h.mtime.Format("2006-01-02 15:04"), h.name)
+    switch h.typeflag {
+    case typeSymlink:
+      line += " -> " + h.linkname
+    case typeLink:
+      line += " link to " + h.linkname
+    }
+    fmt.Println(line)
+  }
+}
+
+// Resolve a member name under dir, refusing absolute names and names that
+// climb out of it with "..".
+func safePath(dir, name string) (string, error) {
+  clean := filepath.Clean(filepath.FromSlash(strings.TrimLeft(name, "/")))
+  if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+    return "", fmt.Errorf("%s: member name contains '..'", name)
+  }
+  return filepath.Join(dir, clean), nil
+}
+
+// Refuse a path under dir that goes through a symlink: an archive that
+// holds "link -> /etc" and then "link/passwd" would otherwise write
+// outside dir without ever naming "..". The directories from dir down to
+// path's parent are checked, and path itself too when whole is set;
+// anything not there yet is made by us as a plain directory.
+func noSymlinks(dir, path string, whole bool) error {
+  rel, err := filepath.Rel(dir, path)
+  if err != nil || rel == "." {
+    return err
+  }
+  parts := strings.Split(rel, string(filepath.Separator))
+  if !whole {
+    parts = parts[:len(parts)-1]
+  }
+  at := dir
+  for _, part := range parts {
+    at = filepath.Join(at, part)
+    info, err := os.Lstat(at)
+    if errors.Is(err, fs.ErrNotExist) {
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+    if info.Mode()&fs.ModeSymlink != 0 {
+      return fmt.Errorf("%s: refusing to extract through symlink %s", path, at)
+    }
+  }
+  return nil
+}
+
+// Extract every entry under dir. Directory permissions and times are applied
+// last, deepest first, so a read-only directory can still be filled.
+func extract(r io.Reader, dir string, verbose bool) error {
+  tr := &reader{r: bufio.NewReader(r)}
+  type dirEntry struct {
+    path  string
+    mode  fs.FileMode
+    mtime time.Time
+  }
+  var dirs []dirEntry
+  umask := syscall.Umask(0)
+  syscall.Umask(umask)
+  perm := func(mode int64) fs.FileMode {
+    m := fs.FileMode(mode) & fs.ModePerm &^ fs.FileMode(umask)
+    if mode&0o4000 != 0 {
+      m |= fs.ModeSetuid
+    }
+    if mode&0o2000 != 0 {
+      m |= fs.ModeSetgid
+    }
+    if mode&0o1000 != 0 {
+      m |= fs.ModeSticky
+    }
+    return m
+  }
+
+  for {
+    h, err := tr.next()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return err
+    }
+    path, err := safePath(dir, h.name)
+    if err != nil {
+      return err
+    }
+    // A directory is made, and later chmodded, through its own name, so
+    // it must not be a symlink either; anything else replaces whatever
+    // is at its name.
+    if err := noSymlinks(dir, path, h.typeflag == typeDir); err != nil {
+      return err
+    }
+    if verbose {
+      fmt.Println(h.name)
+    }
+    if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+      return err
+    }
+
+    switch h.typeflag {
+    case typeDir:
+      if err := os.MkdirAll(path, 0o700); err != nil {
+        return err
+      }
+      dirs = append(dirs, dirEntry{path, perm(h.mode), h.mtime})
+      continue
+    case typeSymlink:
+      os.Remove(path)
+      if err := os.Symlink(h.linkname, path); err != nil {
+        return err
+      }
+      continue
+    case typeLink:
+      target, err := safePath(dir, h.linkname)
+      if err != nil {
+        return err
+      }
+      if err := noSymlinks(dir, target, false); err != nil {
+        return err
+      }
+      os.Remove(path)
+      if err := os.Link(target, path); err != nil {
+        return err
+      }
+      continue
+    case typeReg, typeRegA:
+    default:
+      fmt.Fprintf(os.Stderr, "tar: %s: skipping unsupported entry type %q\n", h.name, h.typeflag)
+      continue
+    }
+
+    os.Remove(path)
+    file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+    if err != nil {
+      return err
+    }
+    if _, err := io.Copy(file, tr); err != nil {
+      file.Close()
+      return err
+    }
+    if err := file.Close(); err != nil {
+      return err
+    }
+    if err := os.Chmod(path, perm(h.mode)); err != nil {
+      return err
+    }
+    os.Chtimes(path, h.mtime, h.mtime)
+  }
+
+  sort.Slice(dirs, func(i, j int) bool { return len(dirs[i].path) > len(dirs[j].path) })
+  for _, d := range dirs {
+    // A later member may have put a symlink where the directory was.
+    if info, err := os.Lstat(d.path); err != nil || !info.IsDir() {
+      continue
+    }
+    if err := os.Chmod(d.path, d.mode); err != nil {
+      return err
+    }
+    os.Chtimes(d.path, d.mtime, d.mtime)
+  }
+  return nil
+}
+
+func usage() {
+  fmt.Fprintln(os.Stderr, "usage: tar {-c|-t|-x} [-v] [-f archive] [-C dir] [file...]")
+  os.Exit(2)
+}
+
+// Parse tar style options. The first argument may bundle letters without a
+// dash ("cvf out.tar"); "f" and "C" take the following argument.
+func main() {
+  args := os.Args[1:]
+  if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+    args[0] = "-" + args[0]
+  }
+  var mode byte
+  verbose := false
+  archive, dir := "-", "."
+  var paths []string
+  for i := 0; i < len(args); i++ {
+    arg := args[i]
+    if !strings.HasPrefix(arg, "-") || arg == "-" {
+      paths = append(paths, arg)
+      continue
+    }
+    for _, c := range arg[1:] {
+      switch c {
+      case 'c', 't', 'x':
+        if mode != 0 && mode != byte(c) {
+          fmt.Fprintln(os.Stderr, "tar: you may not specify more than one of -c, -t, -x")
+          os.Exit(2)
+        }
+        mode = byte(c)
+      case 'v':
+        verbose = true
+      case 'f', 'C':
+        i++
+        if i >= len(args) {
+          usage()
+        }
+        if c == 'f' {
+          archive = args[i]
+        } else {
+          dir = args[i]
+        }
+      default:
+        fmt.Fprintf(os.Stderr, "tar: unknown option -%c\n", c)
+        usage()
+      }
+    }
+  }
+
+  var err error
+  switch mode {
+  case 'c':
+    if len(paths) == 0 {
+      fmt.Fprintln(os.Stderr, "tar: refusing to create an empty archive")
+      os.Exit(2)
+    }
+    out := os.Stdout
+    if archive != "-" {
+      if out, err = os.Create(archive); err != nil {
+        break
+      }
+      defer out.Close()
+    }
+    if err = os.Chdir(dir); err == nil {
+      err = create(out, paths, verbose)
+    }
+  case 't', 'x':
+    in := os.Stdin
+    if archive != "-" {
+      if in, err = os.Open(archive); err != nil {
+        break
+      }
+      defer in.Close()
+    }
+    if mode == 't' {
+      err = list(in, verbose)
+    } else {
+      err = extract(in, dir, verbose)
+    }
+  default:
+    usage()
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "tar:", err)
+    os.Exit(2)
+  }
+}
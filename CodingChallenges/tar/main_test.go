@@ -0,0 +1,149 @@
+package main
+
+import (
+  "archive/tar"
+  "bytes"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// member is one entry of an archive made for a test.
+type member struct {
+  name, link, body string
+  typeflag         byte
+}
+
+// archive writes members with the standard library's writer, so extract
+// is tested against archives it did not make itself.
+func archive(t *testing.T, members ...member) *bytes.Buffer {
+  t.Helper()
+  var b bytes.Buffer
+  w := tar.NewWriter(&b)
+  for _, m := range members {
+    h := &tar.Header{Name: m.name, Linkname: m.link, Typeflag: m.typeflag, Mode: 0o644, Size: int64(len(m.body))}
+    if m.typeflag == tar.TypeDir {
+      h.Mode = 0o755
+    }
+    if err := w.WriteHeader(h); err != nil {
+      t.Fatal(err)
+    }
+    if _, err := w.Write([]byte(m.body)); err != nil {
+      t.Fatal(err)
+    }
+  }
+  if err := w.Close(); err != nil {
+    t.Fatal(err)
+  }
+  return &b
+}
+
+// An archive must not put anything outside the directory it is extracted
+// into, whether by "..", by writing through a symlink it made earlier, or
+// by linking to a file reached through one.
+func TestTraversal(t *testing.T) {
+  for _, c := range []struct {
+    name    string
+    members []member
+    err     string
+  }{
+    {"dot dot", []member{{name: "../evil", body: "x", typeflag: tar.TypeReg}}, "contains '..'"},
+    {"nested dot dot", []member{{name: "a/../../evil", body: "x", typeflag: tar.TypeReg}}, "contains '..'"},
+    {"file through symlink", []member{
+      {name: "link", link: "OUTSIDE", typeflag: tar.TypeSymlink},
+      {name: "link/evil", body: "x", typeflag: tar.TypeReg},
+    }, "refusing to extract through symlink"},
+    {"deep file through symlink", []member{
+      {name: "a/", typeflag: tar.TypeDir},
+      {name: "a/link", link: "OUTSIDE", typeflag: tar.TypeSymlink},
+      {name: "a/link/b/evil", body: "x", typeflag: tar.TypeReg},
+    }, "refusing to extract through symlink"},
+    {"directory through symlink", []member{
+      {name: "link", link: "OUTSIDE", typeflag: tar.TypeSymlink},
+      {name: "link/", typeflag: tar.TypeDir},
+    }, "refusing to extract through symlink"},
+    {"hard link through symlink", []member{
+      {name: "link", link: "OUTSIDE", typeflag: tar.TypeSymlink},
+      {name: "stolen", link: "link/secret", typeflag: tar.TypeLink},
+    }, "refusing to extract through symlink"},
+  } {
+    t.Run(c.name, func(t *testing.T) {
+      root := t.TempDir()
+      outside, dir := filepath.Join(root, "outside"), filepath.Join(root, "dir")
+      for _, d := range []string{outside, dir} {
+        if err := os.Mkdir(d, 0o755); err != nil {
+          t.Fatal(err)
+        }
+      }
+      os.WriteFile(filepath.Join(outside, "secret"), []byte("s"), 0o600)
+      for i := range c.members {
+        c.members[i].link = strings.ReplaceAll(c.members[i].link, "OUTSIDE", outside)
+      }
+      err := extract(archive(t, c.members...), dir, false)
+      if err == nil || !strings.Contains(err.Error(), c.err) {
+        t.Errorf("got %v, want an error containing %q", err, c.err)
+      }
+      for _, name := range []string{"evil", "link/evil", "b/evil"} {
+        if _, err := os.Lstat(filepath.Join(root, name)); err == nil {
+          t.Errorf("%s was written outside the directory", name)
+        }
+      }
+      entries, _ := os.ReadDir(outside)
+      if len(entries) != 1 {
+        t.Errorf("outside holds %d entries, want only the secret", len(entries))
+      }
+      if _, err := os.Lstat(filepath.Join(dir, "stolen")); err == nil {
+        t.Error("a file outside was linked in")
+      }
+    })
+  }
+}
+
+// A symlink of its own is fine, and an absolute name lands under dir.
+func TestExtract(t *testing.T) {
+  dir := t.TempDir()
+  err := extract(archive(t,
+    member{name: "a/", typeflag: tar.TypeDir},
+    member{name: "a/f", body: "hello", typeflag: tar.TypeReg},
+    member{name: "a/l", link: "f", typeflag: tar.TypeSymlink},
+    member{name: "a/h", link: "a/f", typeflag: tar.TypeLink},
+    member{name: "/abs", body: "abs", typeflag: tar.TypeReg},
+  ), dir, false)
+  if err != nil {
+    t.Fatal(err)
+  }
+  for name, want := range map[string]string{"a/f": "hello", "a/l": "hello", "a/h": "hello", "abs": "abs"} {
+    if b, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(b) != want {
+      t.Errorf("%s: got %q, %v; want %q", name, b, err, want)
+    }
+  }
+}
+
+// What create writes, extract reads back.
+func TestRoundTrip(t *testing.T) {
+  src := t.TempDir()
+  t.Chdir(src)
+  os.MkdirAll("tree/sub", 0o755)
+  os.WriteFile("tree/sub/f", []byte("data"), 0o640)
+  os.WriteFile("tree/big", bytes.Repeat([]byte("x"), 3*blockSize+7), 0o644)
+  os.Symlink("sub/f", "tree/l")
+  var b bytes.Buffer
+  if err := create(&b, []string{"tree"}, false); err != nil {
+    t.Fatal(err)
+  }
+  dst := t.TempDir()
+  if err := extract(&b, dst, false); err != nil {
+    t.Fatal(err)
+  }
+  for _, name := range []string{"tree/sub/f", "tree/big", "tree/l"} {
+    want, _ := os.ReadFile(filepath.Join(src, name))
+    got, err := os.ReadFile(filepath.Join(dst, name))
+    if err != nil || !bytes.Equal(got, want) {
+      t.Errorf("%s: got %d bytes, %v; want %d", name, len(got), err, len(want))
+    }
+  }
+  if info, err := os.Stat(filepath.Join(dst, "tree/sub/f")); err != nil || info.Mode().Perm() != 0o640 {
+    t.Errorf("mode not kept: %v, %v", info, err)
+  }
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+  "encoding/binary"
+  "errors"
+  "net"
+)
+
+// ICMP message types a traceroute cares about.
+const (
+  icmpEchoReply       = 0
+  icmpDestUnreachable = 3
+  icmpEchoRequest     = 8
+  icmpTimeExceeded    = 11
+)
+
+// What a reply tells us about one of our probes.
+type reply struct {
+  icmpType uint8
+  icmpCode uint8
+  // Identifies the probe the reply is for: the UDP destination port for
+  // UDP probes, or the echo sequence number for ICMP probes.
+  probe uint16
+}
+
+// The Internet checksum from RFC 1071: the ones' complement of the ones'
+// complement sum of the data taken as 16-bit words.
+func checksum(b []byte) uint16 {
+  var sum uint32
+  for i := 0; i+1 < len(b); i += 2 {
+    sum += uint32(binary.BigEndian.Uint16(b[i:]))
+  }
+  if len(b)%2 == 1 {
+    sum += uint32(b[len(b)-1]) << 8
+  }
+  for sum>>16 != 0 {
+    sum = sum&0xffff + sum>>16
+  }
+  return ^uint16(sum)
+}
+
+// Build an ICMP echo request with the given identifier, sequence number and
+// payload.
+func echoRequest(id, seq uint16, payload []byte) []byte {
+  b := make([]byte, 8+len(payload))
+  b[0] = icmpEchoRequest
+  binary.BigEndian.PutUint16(b[4:], id)
+  binary.BigEndian.PutUint16(b[6:], seq)
+  copy(b[8:], payload)
+  binary.BigEndian.PutUint16(b[2:], checksum(b))
+  return b
+}
+
+var errNotOurs = errors.New("not a reply to our probes")
+
+// Parse an ICMP message (without the outer IP header). Time Exceeded and
+// Destination Unreachable quote the original IP header plus at least 8 bytes
+// of the original datagram, which is enough to recover the UDP ports or the
+// echo id/sequence of our probe. Echo replies carry the id/sequence directly.
+func parseReply(b []byte, udp bool, id uint16) (reply, error) {
+  if len(b) < 8 {
+    return reply{}, errors.New("short ICMP message")
+  }
+  r := reply{icmpType: b[0], icmpCode: b[1]}
+  switch r.icmpType {
+  case icmpEchoReply:
+    if udp || binary.BigEndian.Uint16(b[4:]) != id {
+      return reply{}, errNotOurs
+    }
+    r.probe = binary.BigEndian.Uint16(b[6:])
+    return r, nil
+  case icmpTimeExceeded, icmpDestUnreachable:
+  default:
+    return reply{}, errNotOurs
+  }
+
+  inner := b[8:]
+  if len(inner) < 20 {
+    return reply{}, errors.New("short quoted IP header")
+  }
+  ihl := int(inner[0]&0x0f) * 4
+  proto := inner[9]
+  if len(inner) < ihl+8 {
+    return reply{}, errors.New("short quoted datagram")
+  }
+  quoted := inner[ihl:]
+  switch {
+  case udp && proto == 17:
+    r.probe = binary.BigEndian.Uint16(quoted[2:])
+  case !udp && proto == 1 && quoted[0] == icmpEchoRequest && binary.BigEndian.Uint16(quoted[4:]) == id:
+    r.probe = binary.BigEndian.Uint16(quoted[6:])
+  default:
+    return reply{}, errNotOurs
+  }
+  return r, nil
+}
+
+// Parse the struct sock_extended_err { u32 errno; u8 origin, type, code,
+// pad; u32 info, data; } that Linux queues for an ICMP error on a socket
+// with IP_RECVERR set, followed by the offender's sockaddr_in. Errors that
+// did not come from ICMP are not ours.
+func parseExtendedErr(d []byte, port int) (net.IP, reply, bool) {
+  const originICMP = 2 // SO_EE_ORIGIN_ICMP
+  if len(d) < 16+8 || d[4] != originICMP {
+    return nil, reply{}, false
+  }
+  r := reply{icmpType: d[5], icmpCode: d[6], probe: uint16(port)}
+  return net.IPv4(d[20], d[21], d[22], d[23]), r, true
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+  "encoding/hex"
+  "net"
+  "strings"
+  "testing"
+)
+
+// Messages captured from a raw ICMP socket on Linux, after the kernel has
+// stripped the outer IP header, and two Time Exceeded messages as a router
+// sends them, quoting only the first 8 bytes of the probe.
+const (
+  // A UDP probe of 32 bytes to 127.0.0.1 port 33434.
+  portUnreachable = "03 03 3b c1 00 00 00 00" +
+    " 45 00 00 3c a8 dd 40 00 40 11 93 d1 7f 00 00 01 7f 00 00 01" +
+    " e1 ce 82 9a 00 28 fe 3b" +
+    " 30 31 32 33 34 35 36 37 38 39 61 62 63 64 65 66 30 31 32 33 34 35 36 37 38 39 61 62 63 64 65 66"
+  // Echo id 0x1234, sequence 7, payload "abcdefgh".
+  echoReq   = "08 00 54 2f 12 34 00 07 61 62 63 64 65 66 67 68"
+  echoReply = "00 00 5c 2f 12 34 00 07 61 62 63 64 65 66 67 68"
+  // A UDP probe to 8.8.8.8 port 33434 whose TTL ran out.
+  timeExceededUDP = "0b 00 b3 32 00 00 00 00" +
+    " 45 00 00 3c a8 dd 40 00 01 11 fe b7 c0 a8 01 64 08 08 08 08" +
+    " c0 ce 82 9a 00 28 fe 3b"
+  // The echo request above, sent to 8.8.8.8, whose TTL ran out.
+  timeExceededEcho = "0b 00 86 95 00 00 00 00" +
+    " 45 00 00 24 a8 dd 40 00 01 01 fe df c0 a8 01 64 08 08 08 08" +
+    " 08 00 54 2f 12 34 00 07"
+  // The sock_extended_err and sockaddr_in Linux queued on an unprivileged
+  // UDP socket for the port unreachable from 127.0.0.1.
+  extendedErr = "6f 00 00 00 02 03 03 00 00 00 00 00 00 00 00 00" +
+    " 02 00 00 00 7f 00 00 01 00 00 00 00 00 00 00 00"
+)
+
+func unhex(t *testing.T, s string) []byte {
+  t.Helper()
+  b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+  if err != nil {
+    t.Fatal(err)
+  }
+  return b
+}
+
+func TestChecksum(t *testing.T) {
+  // The example from RFC 1071, and the same with an odd byte over.
+  if got := checksum([]byte{0x00, 0x01, 0xf2, 0x03, 0xf4, 0xf5, 0xf6, 0xf7}); got != 0x220d {
+    t.Errorf("RFC 1071 example: got %#04x, want 0x220d", got)
+  }
+  if got := checksum([]byte{0x00, 0x01, 0xf2, 0x03, 0xf4, 0xf5, 0xf6, 0xf7, 0x01}); got != 0x210d {
+    t.Errorf("odd length: got %#04x, want 0x210d", got)
+  }
+  // A message with its checksum in place sums to zero.
+  for _, msg := range []string{portUnreachable, echoReq, echoReply, timeExceededUDP, timeExceededEcho} {
+    if got := checksum(unhex(t, msg)); got != 0 {
+      t.Errorf("%s...: checksum %#04x, want 0", msg[:23], got)
+    }
+  }
+}
+
+func TestEchoRequest(t *testing.T) {
+  got := echoRequest(0x1234, 7, []byte("abcdefgh"))
+  if want := unhex(t, echoReq); string(got) != string(want) {
+    t.Errorf("got % x, want % x", got, want)
+  }
+}
+
+func TestParseReply(t *testing.T) {
+  for _, c := range []struct {
+    name string
+    msg  string
+    udp  bool
+    id   uint16
+    want reply
+    err  string // part of the error, or "" for none
+  }{
+    {"port unreachable", portUnreachable, true, 0, reply{icmpDestUnreachable, 3, 33434}, ""},
+    {"time exceeded for udp", timeExceededUDP, true, 0, reply{icmpTimeExceeded, 0, 33434}, ""},
+    {"time exceeded for echo", timeExceededEcho, false, 0x1234, reply{icmpTimeExceeded, 0, 7}, ""},
+    {"echo reply", echoReply, false, 0x1234, reply{icmpEchoReply, 0, 7}, ""},
+    {"echo reply in udp mode", echoReply, true, 0x1234, reply{}, "not a reply"},
+    {"someone else's echo reply", echoReply, false, 0x4321, reply{}, "not a reply"},
+    {"someone else's time exceeded", timeExceededEcho, false, 0x4321, reply{}, "not a reply"},
+    {"udp quoted in echo mode", timeExceededUDP, false, 0x1234, reply{}, "not a reply"},
+    {"echo quoted in udp mode", timeExceededEcho, true, 0, reply{}, "not a reply"},
+    {"our own echo request", echoReq, false, 0x1234, reply{}, "not a reply"},
+    {"short", echoReply[:20], false, 0x1234, reply{}, "short ICMP"},
+    {"short quoted header", timeExceededUDP[:20*3], true, 0, reply{}, "short quoted IP"},
+    {"short quoted datagram", timeExceededUDP[:34*3], true, 0, reply{}, "short quoted datagram"},
+  } {
+    got, err := parseReply(unhex(t, c.msg), c.udp, c.id)
+    switch {
+    case c.err == "" && err != nil:
+      t.Errorf("%s: %v", c.name, err)
+    case c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)):
+      t.Errorf("%s: got error %v, want %q", c.name, err, c.err)
+    case got != c.want:
+      t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+    }
+  }
+}
+
+func TestParseExtendedErr(t *testing.T) {
+  d := unhex(t, extendedErr)
+  from, r, ok := parseExtendedErr(d, 33435)
+  if !ok || !from.Equal(net.IPv4(127, 0, 0, 1)) || r != (reply{icmpDestUnreachable, 3, 33435}) {
+    t.Errorf("got %v, %+v, %v", from, r, ok)
+  }
+  if _, _, ok := parseExtendedErr(d[:23], 33435); ok {
+    t.Error("a short error parsed")
+  }
+  local := append([]byte{}, d...)
+  local[4] = 1 // SO_EE_ORIGIN_LOCAL
+  if _, _, ok := parseExtendedErr(local, 33435); ok {
+    t.Error("a local error parsed as ICMP")
+  }
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+  "errors"
+  "flag"
+  "fmt"
+  "net"
+  "os"
+  "strings"
+  "syscall"
+  "time"
+)
+
+const basePort = 33434
+
+// Set the IP TTL on a connection's underlying socket.
+func setTTL(conn syscall.Conn, ttl int) error {
+  raw, err := conn.SyscallConn()
+  if err != nil {
+    return err
+  }
+  var serr error
+  err = raw.Control(func(fd uintptr) {
+    serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+  })
+  if err != nil {
+    return err
+  }
+  return serr
+}
+
+// A raw-socket prober: probes go out as UDP datagrams (or ICMP echo requests
+// with -I) and replies are read from a raw ICMP socket, which needs root or
+// CAP_NET_RAW.
+type rawProber struct {
+  icmp *net.IPConn
+  udp  bool
+  id   uint16
+}
+
+func newRawProber(udp bool) (*rawProber, error) {
+  conn, err := net.ListenIP("ip4:icmp", &net.IPAddr{IP: net.IPv4zero})
+  if err != nil {
+    return nil, err
+  }
+  return &rawProber{icmp: conn, udp: udp, id: uint16(os.Getpid())}, nil
+}
+
+// Send probe number seq with the given TTL and wait for the matching reply.
+func (p *rawProber) probe(dst net.IP, seq, ttl int, timeout time.Duration) (net.IP, reply, time.Duration, error) {
+  start := time.Now()
+  if p.udp {
+    conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: dst, Port: basePort + seq})
+    if err != nil {
+      return nil, reply{}, 0, err
+    }
+    defer conn.Close()
+    if err := setTTL(conn, ttl); err != nil {
+      return nil, reply{}, 0, err
+    }
+    start = time.Now()
+    if _, err := conn.Write(make([]byte, 32)); err != nil {
+      return nil, reply{}, 0, err
+    }
+  } else {
+    if err := setTTL(p.icmp, ttl); err != nil {
+      return nil, reply{}, 0, err
+    }
+    start = time.Now()
+    if _, err := p.icmp.WriteToIP(echoRequest(p.id, uint16(seq), make([]byte, 32)), &net.IPAddr{IP: dst}); err != nil {
+      return nil, reply{}, 0, err
+    }
+  }
+
+  want := uint16(seq)
+  if p.udp {
+    want = uint16(basePort + seq)
+  }
+  buf := make([]byte, 1500)
+  deadline := start.Add(timeout)
+  for {
+    p.icmp.SetReadDeadline(deadline)
+    n, from, err := p.icmp.ReadFromIP(buf)
+    if err != nil {
+      return nil, reply{}, 0, errors.New("timeout")
+    }
+    r, err := parseReply(buf[:n], p.udp, p.id)
+    if err != nil || r.probe != want {
+      continue
+    }
+    return from.IP, r, time.Since(start), nil
+  }
+}
+
+// Trace the route to the host, printing one line per hop with the RTT of
+// each probe. The trace stops once the destination itself answers.
+func main() {
+  maxHops := flag.Int("m", 30, "maximum number of hops")
+  queries := flag.Int("q", 3, "probes per hop")
+  wait := flag.Duration("w", 3*time.Second, "time to wait for each reply")
+  icmpMode := flag.Bool("I", false, "use ICMP echo probes instead of UDP (raw mode only)")
+  unprivileged := flag.Bool("U", false, "use unprivileged UDP probes via the Linux error queue")
+  flag.Parse()
+  if flag.NArg() != 1 {
+    fmt.Fprintln(os.Stderr, "usage: traceroute [-I|-U] [-m hops] [-q n] [-w wait] host")
+    os.Exit(2)
+  }
+
+  host := flag.Arg(0)
+  addrs, err := net.LookupIP(host)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "traceroute:", err)
+    os.Exit(1)
+  }
+  var dst net.IP
+  for _, a := range addrs {
+    if a.To4() != nil {
+      dst = a.To4()
+      break
+    }
+  }
+  if dst == nil {
+    fmt.Fprintln(os.Stderr, "traceroute: no IPv4 address for", host)
+    os.Exit(1)
+  }
+
+  probe := func(seq, ttl int) (net.IP, reply, time.Duration, error) {
+    return probeUnprivileged(dst, basePort+seq, ttl, *wait)
+  }
+  if !*unprivileged {
+    p, err := newRawProber(!*icmpMode)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "traceroute: raw socket unavailable, falling back to -U:", err)
+    } else {
+      defer p.icmp.Close()
+      probe = func(seq, ttl int) (net.IP, reply, time.Duration, error) {
+        return p.probe(dst, seq, ttl, *wait)
+      }
+    }
+  }
+
+  fmt.Printf("traceroute to %s (%s), %d hops max\n", host, dst, *maxHops)
+  seq := 0
+  for ttl := 1; ttl <= *maxHops; ttl++ {
+    var parts []string
+    var last net.IP
+    reached := false
+    for q := 0; q < *queries; q++ {
+      from, r, rtt, err := probe(seq, ttl)
+      seq++
+      if err != nil {
+        parts = append(parts, "*")
+        continue
+      }
+      if !from.Equal(last) {
+        name := from.String()
+        if names, err := net.LookupAddr(name); err == nil && len(names) > 0 {
+          name = fmt.Sprintf("%s (%s)", strings.TrimSuffix(names[0], "."), from)
+        }
+        parts = append(parts, name)
+        last = from
+      }
+      parts = append(parts, fmt.Sprintf("%.3f ms", float64(rtt.Microseconds())/1000))
+      if from.Equal(dst) || r.icmpType == icmpEchoReply || r.icmpType == icmpDestUnreachable {
+        reached = true
+      }
+    }
+    fmt.Printf("%2d  %s\n", ttl, strings.Join(parts, "  "))
+    if reached {
+      return
+    }
+  }
+}
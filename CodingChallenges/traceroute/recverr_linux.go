@@ -0,0 +1,60 @@
+package main
+
+import (
+  "errors"
+  "net"
+  "syscall"
+  "time"
+)
+
+// Send one UDP probe from an unprivileged socket and wait for the ICMP error
+// it triggers. Linux queues ICMP errors for a UDP socket with IP_RECVERR set
+// on the socket's error queue, together with the address of the router that
+// sent them, so no raw socket is needed (this is how tracepath works).
+func probeUnprivileged(dst net.IP, port, ttl int, timeout time.Duration) (net.IP, reply, time.Duration, error) {
+  fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+  if err != nil {
+    return nil, reply{}, 0, err
+  }
+  defer syscall.Close(fd)
+  if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_TTL, ttl); err != nil {
+    return nil, reply{}, 0, err
+  }
+  if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_IP, syscall.IP_RECVERR, 1); err != nil {
+    return nil, reply{}, 0, err
+  }
+
+  addr := &syscall.SockaddrInet4{Port: port}
+  copy(addr.Addr[:], dst.To4())
+  start := time.Now()
+  if err := syscall.Sendto(fd, make([]byte, 32), 0, addr); err != nil {
+    return nil, reply{}, 0, err
+  }
+
+  buf := make([]byte, 512)
+  oob := make([]byte, 512)
+  for time.Since(start) < timeout {
+    _, oobn, _, _, err := syscall.Recvmsg(fd, buf, oob, syscall.MSG_ERRQUEUE|syscall.MSG_DONTWAIT)
+    if err == syscall.EAGAIN {
+      time.Sleep(2 * time.Millisecond)
+      continue
+    }
+    if err != nil {
+      return nil, reply{}, 0, err
+    }
+    rtt := time.Since(start)
+    msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+    if err != nil {
+      return nil, reply{}, 0, err
+    }
+    for _, m := range msgs {
+      if m.Header.Level != syscall.IPPROTO_IP || m.Header.Type != syscall.IP_RECVERR {
+        continue
+      }
+      if from, r, ok := parseExtendedErr(m.Data, port); ok {
+        return from, r, rtt, nil
+      }
+    }
+  }
+  return nil, reply{}, 0, errors.New("timeout")
+}
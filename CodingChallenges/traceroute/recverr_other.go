@@ -0,0 +1,15 @@
+//go:build !linux
+
+package main
+
+import (
+  "errors"
+  "net"
+  "time"
+)
+
+// Unprivileged probing relies on Linux's IP_RECVERR error queue; elsewhere
+// the raw socket mode is the only option.
+func probeUnprivileged(dst net.IP, port, ttl int, timeout time.Duration) (net.IP, reply, time.Duration, error) {
+  return nil, reply{}, 0, errors.New("unprivileged mode is only supported on Linux")
+}
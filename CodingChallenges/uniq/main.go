@@ -0,0 +1,132 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "strings"
+)
+
+// Options collected from the command line. Like the real uniq only adjacent
+// lines are ever compared, so unsorted input keeps its later duplicates.
+type options struct {
+  count      bool
+  repeated   bool
+  unique     bool
+  skipFields int
+  skipChars  int
+  ignoreCase bool
+}
+
+var opts options
+
+// Return the part of a line that takes part in the comparison. The first
+// skipFields blank-separated fields are skipped (along with the blanks in
+// front of them), then skipChars characters, matching "uniq -f" and "uniq -s".
+func compareKey(line string) string {
+  i := 0
+  for f := 0; f < opts.skipFields; f++ {
+    for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+      i++
+    }
+    for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+      i++
+    }
+  }
+  key := []rune(line[i:])
+  if opts.skipChars < len(key) {
+    key = key[opts.skipChars:]
+  } else {
+    key = nil
+  }
+  if opts.ignoreCase {
+    return strings.ToLower(string(key))
+  }
+  return string(key)
+}
+
+// Write a finished group of identical adjacent lines, honouring the -c, -d
+// and -u filters. The first line of the group is the one that gets printed.
+func emit(w *bufio.Writer, line string, n int) {
+  if opts.repeated && n < 2 {
+    return
+  }
+  if opts.unique && n > 1 {
+    return
+  }
+  if opts.count {
+    fmt.Fprintf(w, "%7d %s\n", n, line)
+  } else {
+    fmt.Fprintln(w, line)
+  }
+}
+
+// Stream the reader line-by-line, collapsing runs of adjacent lines whose
+// comparison keys are equal into a single output line.
+func uniq(r io.Reader, out io.Writer) error {
+  w := bufio.NewWriter(out)
+  defer w.Flush()
+
+  scanner := bufio.NewScanner(r)
+  scanner.Buffer(make([]byte, 64*1024), 1<<30)
+
+  var first, key string
+  n := 0
+  for scanner.Scan() {
+    line := scanner.Text()
+    k := compareKey(line)
+    if n > 0 && k == key {
+      n++
+      continue
+    }
+    if n > 0 {
+      emit(w, first, n)
+    }
+    first, key, n = line, k, 1
+  }
+  if n > 0 {
+    emit(w, first, n)
+  }
+  return scanner.Err()
+}
+
+// Parse the command line and run uniq from the input file (or stdin when it
+// is missing or "-") to the output file (or stdout).
+func main() {
+  flag.BoolVar(&opts.count, "c", false, "prefix lines by the number of occurrences")
+  flag.BoolVar(&opts.repeated, "d", false, "only print duplicate lines, one for each group")
+  flag.BoolVar(&opts.unique, "u", false, "only print unique lines")
+  flag.IntVar(&opts.skipFields, "f", 0, "avoid comparing the first N fields")
+  flag.IntVar(&opts.skipChars, "s", 0, "avoid comparing the first N characters")
+  flag.BoolVar(&opts.ignoreCase, "i", false, "ignore differences in case when comparing")
+  flag.Parse()
+
+  var in io.Reader = os.Stdin
+  var out io.Writer = os.Stdout
+  args := flag.Args()
+  if len(args) > 0 && args[0] != "-" {
+    file, err := os.Open(args[0])
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "uniq:", err)
+      os.Exit(1)
+    }
+    defer file.Close()
+    in = file
+  }
+  if len(args) > 1 && args[1] != "-" {
+    file, err := os.Create(args[1])
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "uniq:", err)
+      os.Exit(1)
+    }
+    defer file.Close()
+    out = file
+  }
+
+  if err := uniq(in, out); err != nil {
+    fmt.Fprintln(os.Stderr, "uniq:", err)
+    os.Exit(1)
+  }
+}
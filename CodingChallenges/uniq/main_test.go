@@ -0,0 +1,42 @@
+package main
+
+import (
+  "strings"
+  "testing"
+)
+
+// Each set of options on one input, checked against GNU uniq.
+func TestUniq(t *testing.T) {
+  in := "apple\napple\nApple\nbanana\n1 x apple\n2 x apple\n2 y apple\ncherry\ncherry\ncherry\nab1\nac1\nab2\n\n\nend"
+  for _, c := range []struct {
+    name string
+    opts options
+    want string
+  }{
+    {"plain", options{}, "apple\nApple\nbanana\n1 x apple\n2 x apple\n2 y apple\ncherry\nab1\nac1\nab2\n\nend\n"},
+    {"-c", options{count: true}, "      2 apple\n      1 Apple\n      1 banana\n      1 1 x apple\n      1 2 x apple\n      1 2 y apple\n      3 cherry\n      1 ab1\n      1 ac1\n      1 ab2\n      2 \n      1 end\n"},
+    {"-d", options{repeated: true}, "apple\ncherry\n\n"},
+    {"-u", options{unique: true}, "Apple\nbanana\n1 x apple\n2 x apple\n2 y apple\nab1\nac1\nab2\nend\n"},
+    {"-cd", options{count: true, repeated: true}, "      2 apple\n      3 cherry\n      2 \n"},
+    {"-du", options{repeated: true, unique: true}, ""},
+    {"-i", options{ignoreCase: true}, "apple\nbanana\n1 x apple\n2 x apple\n2 y apple\ncherry\nab1\nac1\nab2\n\nend\n"},
+    {"-ic", options{ignoreCase: true, count: true}, "      3 apple\n      1 banana\n      1 1 x apple\n      1 2 x apple\n      1 2 y apple\n      3 cherry\n      1 ab1\n      1 ac1\n      1 ab2\n      2 \n      1 end\n"},
+    {"-di", options{repeated: true, ignoreCase: true}, "apple\ncherry\n\n"},
+    {"-f 1", options{skipFields: 1}, "apple\n1 x apple\n2 y apple\ncherry\n"},
+    {"-f 2", options{skipFields: 2}, "apple\n1 x apple\ncherry\n"},
+    {"-f 5", options{skipFields: 5}, "apple\n"},
+    {"-s 1", options{skipChars: 1}, "apple\nbanana\n1 x apple\n2 y apple\ncherry\nab1\nac1\nab2\n\nend\n"},
+    {"-s 99", options{skipChars: 99}, "apple\n"},
+    {"-f 1 -s 2", options{skipFields: 1, skipChars: 2}, "apple\n1 x apple\ncherry\n"},
+  } {
+    opts = c.opts
+    var out strings.Builder
+    if err := uniq(strings.NewReader(in), &out); err != nil {
+      t.Fatalf("%s: %v", c.name, err)
+    }
+    if out.String() != c.want {
+      t.Errorf("%s: got %q, want %q", c.name, out.String(), c.want)
+    }
+  }
+  opts = options{}
+}
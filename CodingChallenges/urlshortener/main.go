@@ -0,0 +1,286 @@
+package main
+
+import (
+  "bufio"
+  "encoding/json"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "net/http"
+  "net/url"
+  "os"
+  "strings"
+  "sync"
+)
+
+const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Encode a non-negative id in base62 using the alphabet above.
+func encode(id uint64) string {
+  if id == 0 {
+    return string(alphabet[0])
+  }
+  var out []byte
+  for id > 0 {
+    out = append(out, alphabet[id%62])
+    id /= 62
+  }
+  for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+    out[i], out[j] = out[j], out[i]
+  }
+  return string(out)
+}
+
+// Decode a base62 key back into its id.
+func decode(key string) (uint64, error) {
+  var id uint64
+  for _, c := range key {
+    i := strings.IndexRune(alphabet, c)
+    if i < 0 {
+      return 0, fmt.Errorf("invalid character %q in key", c)
+    }
+    id = id*62 + uint64(i)
+  }
+  return id, nil
+}
+
+// One shortened link.
+type link struct {
+  Key  string `json:"key"`
+  URL  string `json:"url"`
+  Hits uint64 `json:"hits"`
+}
+
+// A logged change to the store. Every create, hit and delete is appended to
+// the log file as one JSON line and replayed on startup, so the store survives
+// restarts without needing a database.
+type event struct {
+  Op  string `json:"op"`
+  Key string `json:"key"`
+  URL string `json:"url,omitempty"`
+}
+
+// The link store: an in-memory map backed by an append-only JSON lines file.
+type store struct {
+  mu     sync.Mutex
+  links  map[string]*link
+  byURL  map[string]string
+  nextID uint64
+  log    *os.File
+}
+
+var errNotFound = errors.New("not found")
+
+// Open (or create) the log file and replay it into memory. A crash can
+// leave the last line torn; the file is cut back to the end of the last
+// whole event, so the events that follow are not appended after garbage
+// that a later replay would stop at.
+func openStore(path string) (*store, error) {
+  s := &store{links: map[string]*link{}, byURL: map[string]string{}, nextID: 1}
+  file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+  if err != nil {
+    return nil, err
+  }
+  r := bufio.NewReader(file)
+  good := int64(0)
+  for {
+    line, err := r.ReadBytes('\n')
+    if err == io.EOF {
+      // A line without its newline was never finished, whether or not
+      // what made it out parses.
+      break
+    }
+    if err != nil {
+      file.Close()
+      return nil, err
+    }
+    var e event
+    if err := json.Unmarshal(line, &e); err != nil {
+      break
+    }
+    s.apply(e)
+    good += int64(len(line))
+  }
+  if err := file.Truncate(good); err != nil {
+    file.Close()
+    return nil, err
+  }
+  s.log = file
+  return s, nil
+}
+
+// Apply an event to the in-memory state. The caller must hold the lock.
+func (s *store) apply(e event) {
+  switch e.Op {
+  case "create":
+    s.links[e.Key] = &link{Key: e.Key, URL: e.URL}
+    s.byURL[e.URL] = e.Key
+    if id, err := decode(e.Key); err == nil && id >= s.nextID {
+      s.nextID = id + 1
+    }
+  case "hit":
+    if l, ok := s.links[e.Key]; ok {
+      l.Hits++
+    }
+  case "delete":
+    if l, ok := s.links[e.Key]; ok {
+      delete(s.byURL, l.URL)
+      delete(s.links, e.Key)
+    }
+  }
+}
+
+// Append an event to the log and apply it. The caller must hold the lock.
+func (s *store) record(e event) error {
+  line, err := json.Marshal(e)
+  if err != nil {
+    return err
+  }
+  if _, err := s.log.Write(append(line, '\n')); err != nil {
+    return err
+  }
+  s.apply(e)
+  return nil
+}
+
+// Shorten a URL, returning the existing link when it was already shortened.
+func (s *store) shorten(u string) (*link, error) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  if key, ok := s.byURL[u]; ok {
+    return s.links[key], nil
+  }
+  key := encode(s.nextID)
+  if err := s.record(event{Op: "create", Key: key, URL: u}); err != nil {
+    return nil, err
+  }
+  return s.links[key], nil
+}
+
+// Resolve a key to its URL and count the visit.
+func (s *store) visit(key string) (string, error) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  l, ok := s.links[key]
+  if !ok {
+    return "", errNotFound
+  }
+  if err := s.record(event{Op: "hit", Key: key}); err != nil {
+    return "", err
+  }
+  return l.URL, nil
+}
+
+// Return a copy of a link's stats.
+func (s *store) stats(key string) (link, error) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  l, ok := s.links[key]
+  if !ok {
+    return link{}, errNotFound
+  }
+  return *l, nil
+}
+
+// Delete a link.
+func (s *store) remove(key string) error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  if _, ok := s.links[key]; !ok {
+    return errNotFound
+  }
+  return s.record(event{Op: "delete", Key: key})
+}
+
+// The HTTP API in front of the store.
+type server struct {
+  store   *store
+  baseURL string
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(status)
+  json.NewEncoder(w).Encode(v)
+}
+
+func writeErr(w http.ResponseWriter, status int, msg string) {
+  writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// Route the API:
+//   POST   /           {"url": "..."} -> 201 {"key", "url", "short_url"}
+//   GET    /{key}      302 redirect to the long URL
+//   GET    /stats/{key} hit counts
+//   DELETE /{key}      remove the link
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+  path := strings.TrimPrefix(r.URL.Path, "/")
+  switch {
+  case r.Method == http.MethodPost && path == "":
+    var body struct {
+      URL string `json:"url"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+      writeErr(w, http.StatusBadRequest, "invalid JSON body")
+      return
+    }
+    u, err := url.Parse(body.URL)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+      writeErr(w, http.StatusBadRequest, "url must be an absolute http(s) URL")
+      return
+    }
+    l, err := srv.store.shorten(body.URL)
+    if err != nil {
+      writeErr(w, http.StatusInternalServerError, err.Error())
+      return
+    }
+    writeJSON(w, http.StatusCreated, map[string]string{
+      "key": l.Key, "url": l.URL, "short_url": srv.baseURL + "/" + l.Key,
+    })
+  case r.Method == http.MethodGet && strings.HasPrefix(path, "stats/"):
+    l, err := srv.store.stats(strings.TrimPrefix(path, "stats/"))
+    if err != nil {
+      writeErr(w, http.StatusNotFound, err.Error())
+      return
+    }
+    writeJSON(w, http.StatusOK, l)
+  case r.Method == http.MethodGet && path != "":
+    target, err := srv.store.visit(path)
+    if err == errNotFound {
+      writeErr(w, http.StatusNotFound, err.Error())
+      return
+    } else if err != nil {
+      writeErr(w, http.StatusInternalServerError, err.Error())
+      return
+    }
+    http.Redirect(w, r, target, http.StatusFound)
+  case r.Method == http.MethodDelete && path != "":
+    if err := srv.store.remove(path); err == errNotFound {
+      writeErr(w, http.StatusNotFound, err.Error())
+    } else if err != nil {
+      writeErr(w, http.StatusInternalServerError, err.Error())
+    } else {
+      w.WriteHeader(http.StatusNoContent)
+    }
+  default:
+    writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+  }
+}
+
+// Open the store and start the HTTP server.
+func main() {
+  addr := flag.String("addr", ":8000", "address to listen on")
+  data := flag.String("data", "links.jsonl", "path of the persistent link log")
+  base := flag.String("base", "http://localhost:8000", "base URL used in short links")
+  flag.Parse()
+
+  s, err := openStore(*data)
+  if err != nil {
+    panic(err)
+  }
+  fmt.Println("Listening on", *addr)
+  if err := http.ListenAndServe(*addr, &server{store: s, baseURL: strings.TrimSuffix(*base, "/")}); err != nil {
+    panic(err)
+  }
+}
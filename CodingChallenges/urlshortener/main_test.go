@@ -0,0 +1,134 @@
+package main
+
+import (
+  "encoding/json"
+  "net/http"
+  "net/http/httptest"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// A server on a fresh log in a temporary directory, and a client that
+// shows redirects instead of following them.
+func start(t *testing.T, path string) (*httptest.Server, *http.Client) {
+  t.Helper()
+  s, err := openStore(path)
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { s.log.Close() })
+  ts := httptest.NewServer(&server{store: s, baseURL: "http://sho.rt"})
+  t.Cleanup(ts.Close)
+  client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+    return http.ErrUseLastResponse
+  }}
+  return ts, client
+}
+
+func do(t *testing.T, client *http.Client, method, url, body string) (*http.Response, map[string]any) {
+  t.Helper()
+  req, _ := http.NewRequest(method, url, strings.NewReader(body))
+  resp, err := client.Do(req)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer resp.Body.Close()
+  var v map[string]any
+  json.NewDecoder(resp.Body).Decode(&v)
+  return resp, v
+}
+
+func TestAPI(t *testing.T) {
+  ts, client := start(t, filepath.Join(t.TempDir(), "links.jsonl"))
+
+  resp, v := do(t, client, "POST", ts.URL+"/", `{"url": "https://example.com/a"}`)
+  if resp.StatusCode != http.StatusCreated || v["key"] != "1" || v["short_url"] != "http://sho.rt/1" {
+    t.Fatalf("create: %d %v", resp.StatusCode, v)
+  }
+  if _, v := do(t, client, "POST", ts.URL+"/", `{"url": "https://example.com/a"}`); v["key"] != "1" {
+    t.Errorf("the same URL got a new key: %v", v)
+  }
+  if _, v := do(t, client, "POST", ts.URL+"/", `{"url": "https://example.com/b"}`); v["key"] != "2" {
+    t.Errorf("a second URL got key %v, want 2", v["key"])
+  }
+
+  for range 3 {
+    resp, _ := do(t, client, "GET", ts.URL+"/1", "")
+    if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "https://example.com/a" {
+      t.Fatalf("redirect: %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+    }
+  }
+  if _, v := do(t, client, "GET", ts.URL+"/stats/1", ""); v["hits"] != 3.0 {
+    t.Errorf("stats: %v, want 3 hits", v)
+  }
+
+  for _, c := range []struct {
+    method, path, body string
+    status             int
+  }{
+    {"POST", "/", `not json`, http.StatusBadRequest},
+    {"POST", "/", `{"url": "ftp://example.com"}`, http.StatusBadRequest},
+    {"POST", "/", `{"url": "/relative"}`, http.StatusBadRequest},
+    {"GET", "/zz", "", http.StatusNotFound},
+    {"GET", "/stats/zz", "", http.StatusNotFound},
+    {"PUT", "/1", "", http.StatusMethodNotAllowed},
+    {"DELETE", "/1", "", http.StatusNoContent},
+    {"DELETE", "/1", "", http.StatusNotFound},
+    {"GET", "/1", "", http.StatusNotFound},
+  } {
+    if resp, v := do(t, client, c.method, ts.URL+c.path, c.body); resp.StatusCode != c.status {
+      t.Errorf("%s %s: got %d %v, want %d", c.method, c.path, resp.StatusCode, v, c.status)
+    }
+  }
+}
+
+// Links, hits and deletes survive a restart, and keys are not reused.
+func TestReopen(t *testing.T) {
+  path := filepath.Join(t.TempDir(), "links.jsonl")
+  ts, client := start(t, path)
+  do(t, client, "POST", ts.URL+"/", `{"url": "https://example.com/a"}`)
+  do(t, client, "POST", ts.URL+"/", `{"url": "https://example.com/b"}`)
+  do(t, client, "GET", ts.URL+"/1", "")
+  do(t, client, "DELETE", ts.URL+"/2", "")
+
+  ts, client = start(t, path)
+  if _, v := do(t, client, "GET", ts.URL+"/stats/1", ""); v["hits"] != 1.0 {
+    t.Errorf("stats after reopen: %v", v)
+  }
+  if resp, _ := do(t, client, "GET", ts.URL+"/2", ""); resp.StatusCode != http.StatusNotFound {
+    t.Errorf("deleted link came back: %d", resp.StatusCode)
+  }
+  if _, v := do(t, client, "POST", ts.URL+"/", `{"url": "https://example.com/c"}`); v["key"] != "3" {
+    t.Errorf("new key after reopen: %v, want 3", v["key"])
+  }
+}
+
+// A torn last line is cut off on open, so what is written after it is
+// found by the next replay.
+func TestTornTail(t *testing.T) {
+  for _, tail := range []string{`{"op":"hit","ke`, `{"op":"hit","key":"1"}`, "garbage\n"} {
+    path := filepath.Join(t.TempDir(), "links.jsonl")
+    os.WriteFile(path, []byte(`{"op":"create","key":"1","url":"https://example.com/a"}`+"\n"+tail), 0o644)
+
+    ts, client := start(t, path)
+    do(t, client, "GET", ts.URL+"/1", "")
+    do(t, client, "POST", ts.URL+"/", `{"url": "https://example.com/b"}`)
+
+    want := `{"op":"create","key":"1","url":"https://example.com/a"}
+{"op":"hit","key":"1"}
+{"op":"create","key":"2","url":"https://example.com/b"}
+`
+    if b, _ := os.ReadFile(path); string(b) != want {
+      t.Errorf("%q: log after the tear:\n%s\nwant:\n%s", tail, b, want)
+    }
+    ts, client = start(t, path)
+    if _, v := do(t, client, "GET", ts.URL+"/stats/1", ""); v["hits"] != 1.0 {
+      t.Errorf("%q: hits after the tear: %v, want 1", tail, v)
+    }
+    if resp, _ := do(t, client, "GET", ts.URL+"/2", ""); resp.StatusCode != http.StatusFound {
+      t.Errorf("%q: the link made after the tear was lost: %d", tail, resp.StatusCode)
+    }
+  }
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "crypto/rand"
+  "errors"
+  "flag"
+  "fmt"
+  "math/big"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "sort"
+  "strings"
+  "time"
+)
+
+var stdin = bufio.NewReader(os.Stdin)
+
+func isTerminal() bool {
+  info, err := os.Stdin.Stat()
+  return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Read a secret. On a terminal, echo is turned off with stty while typing;
+// otherwise a line is read from stdin so the tool can be scripted. The
+// VAULT_PASSWORD environment variable supplies the master password.
+func readSecret(prompt string, master bool) ([]byte, error) {
+  if env := os.Getenv("VAULT_PASSWORD"); master && env != "" {
+    return []byte(env), nil
+  }
+  if isTerminal() {
+    fmt.Fprint(os.Stderr, prompt)
+    stty := func(arg string) {
+      cmd := exec.Command("stty", arg)
+      cmd.Stdin = os.Stdin
+      cmd.Run()
+    }
+    stty("-echo")
+    defer func() {
+      stty("echo")
+      fmt.Fprintln(os.Stderr)
+    }()
+  }
+  line, err := stdin.ReadString('\n')
+  if err != nil && line == "" {
+    return nil, errors.New("no password given")
+  }
+  return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+// Read a new master password, typed twice so a slip is not locked into
+// the vault.
+func newMasterPassword() ([]byte, error) {
+  password, err := readSecret("New master password: ", true)
+  if err != nil {
+    return nil, err
+  }
+  if len(password) < 8 {
+    return nil, errors.New("master password must be at least 8 characters")
+  }
+  again, err := readSecret("Repeat the new master password: ", true)
+  if err != nil {
+    return nil, err
+  }
+  if !bytes.Equal(password, again) {
+    return nil, errors.New("the passwords do not match")
+  }
+  return password, nil
+}
+
+// Generate a random password from letters, digits and symbols.
+func generate(length int) (string, error) {
+  const alphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789!@#$%^&*-_=+"
+  b := make([]byte, length)
+  for i := range b {
+    n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+    if err != nil {
+      return "", err
+    }
+    b[i] = alphabet[n.Int64()]
+  }
+  return string(b), nil
+}
+
+func defaultPath() string {
+  home, err := os.UserHomeDir()
+  if err != nil {
+    return ".vault"
+  }
+  return filepath.Join(home, ".vault")
+}
+
+func usage() {
+  fmt.Fprintln(os.Stderr, `usage: vault [-f file] <command> [args]
+  init                          create an empty vault
+  add [-u user] [-n notes] [-g len] name
+                                add or replace an entry (-g generates the password)
+  get name                      print an entry's password
+  show name                     print all of an entry's fields
+  list                          list entry names
+  delete name                   remove an entry
+  passwd                        change the master password`)
+  os.Exit(2)
+}
+
+func fail(err error) {
+  fmt.Fprintln(os.Stderr, "vault:", err)
+  os.Exit(1)
+}
+
+// Load the vault, run the command and save it again if it changed.
+func main() {
+  path := flag.String("f", defaultPath(), "vault file")
+  flag.Usage = usage
+  flag.Parse()
+  if flag.NArg() == 0 {
+    usage()
+  }
+  cmd, args := flag.Arg(0), flag.Args()[1:]
+
+  if cmd == "init" {
+    if _, err := os.Stat(*path); err == nil {
+      fail(fmt.Errorf("%s already exists", *path))
+    }
+    password, err := newMasterPassword()
+    if err != nil {
+      fail(err)
+    }
+    data, err := seal(&vault{Entries: map[string]entry{}}, password, kdfParams{defaultLogN, defaultR, defaultP})
+    if err == nil {
+      err = writeVault(*path, data)
+    }
+    if err != nil {
+      fail(err)
+    }
+    fmt.Fprintln(os.Stderr, "Created", *path)
+    return
+  }
+
+  data, err := os.ReadFile(*path)
+  if err != nil {
+    fail(err)
+  }
+  password, err := readSecret("Master password: ", true)
+  if err != nil {
+    fail(err)
+  }
+  v, kp, err := open(data, password)
+  if err != nil {
+    fail(err)
+  }
+
+  changed := false
+  switch cmd {
+  case "add":
+    fs := flag.NewFlagSet("add", flag.ExitOnError)
+    user := fs.String("u", "", "username")
+    notes := fs.String("n", "", "notes")
+    gen := fs.Int("g", 0, "generate a random password of this length")
+    fs.Parse(args)
+    if fs.NArg() != 1 {
+      usage()
+    }
+    e := entry{Username: *user, Notes: *notes, Updated: time.Now().UTC()}
+    if *gen > 0 {
+      e.Password, err = generate(*gen)
+    } else {
+      var secret []byte
+      secret, err = readSecret("Password for "+fs.Arg(0)+": ", false)
+      e.Password = string(secret)
+    }
+    if err != nil {
+      fail(err)
+    }
+    v.Entries[fs.Arg(0)] = e
+    changed = true
+    if *gen > 0 {
+      fmt.Println(e.Password)
+    }
+  case "get", "show":
+    if len(args) != 1 {
+      usage()
+    }
+    e, ok := v.Entries[args[0]]
+    if !ok {
+      fail(fmt.Errorf("no entry named %q", args[0]))
+    }
+    if cmd == "get" {
+      fmt.Println(e.Password)
+      break
+    }
+    fmt.Printf("name:     %s\nusername: %s\npassword: %s\nnotes:    %s\nupdated:  %s\n",
+      args[0], e.Username, e.Password, e.Notes, e.Updated.Format(time.RFC3339))
+  case "list":
+    names := make([]string, 0, len(v.Entries))
+    for name := range v.Entries {
+      names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+      if u := v.Entries[name].Username; u != "" {
+        fmt.Printf("%s\t%s\n", name, u)
+      } else {
+        fmt.Println(name)
+      }
+    }
+  case "delete":
+    if len(args) != 1 {
+      usage()
+    }
+    if _, ok := v.Entries[args[0]]; !ok {
+      fail(fmt.Errorf("no entry named %q", args[0]))
+    }
+    delete(v.Entries, args[0])
+    changed = true
+  case "passwd":
+    os.Unsetenv("VAULT_PASSWORD")
+    if password, err = newMasterPassword(); err != nil {
+      fail(err)
+    }
+    changed = true
+  default:
+    usage()
+  }
+
+  if changed {
+    data, err := seal(v, password, kp)
+    if err == nil {
+      err = writeVault(*path, data)
+    }
+    if err != nil {
+      fail(err)
+    }
+  }
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+  "crypto/pbkdf2"
+  "crypto/sha256"
+  "encoding/binary"
+  "errors"
+  "math/bits"
+)
+
+// scrypt (RFC 7914). The password is stretched with PBKDF2-HMAC-SHA256 into
+// p blocks of 128*r bytes, each block is mixed by the memory-hard ROMix
+// function, and a final PBKDF2 pass over the mixed blocks yields the key.
+func scrypt(password, salt []byte, n, r, p, keyLen int) ([]byte, error) {
+  if n <= 1 || n&(n-1) != 0 {
+    return nil, errors.New("scrypt: N must be a power of two greater than 1")
+  }
+  if r <= 0 || p <= 0 || uint64(r)*uint64(p) >= 1<<30 || r > (1<<31-1)/128/p || n > (1<<31-1)/128/r {
+    return nil, errors.New("scrypt: parameters are too large")
+  }
+  b, err := pbkdf2.Key(sha256.New, string(password), salt, 1, p*128*r)
+  if err != nil {
+    return nil, err
+  }
+  x := make([]uint32, 32*r)
+  v := make([]uint32, 32*r*n)
+  for i := 0; i < p; i++ {
+    block := b[i*128*r : (i+1)*128*r]
+    for j := range x {
+      x[j] = binary.LittleEndian.Uint32(block[4*j:])
+    }
+    roMix(x, v, n, r)
+    for j, w := range x {
+      binary.LittleEndian.PutUint32(block[4*j:], w)
+    }
+  }
+  return pbkdf2.Key(sha256.New, string(password), b, 1, keyLen)
+}
+
+// ROMix fills v with n successive BlockMix outputs, then reads them back in
+// an order that depends on the data, so the whole table must stay in memory.
+func roMix(x, v []uint32, n, r int) {
+  size := 32 * r
+  tmp := make([]uint32, size)
+  for i := 0; i < n; i++ {
+    copy(v[i*size:], x)
+    blockMix(x, tmp, r)
+  }
+  for i := 0; i < n; i++ {
+    j := int(x[(2*r-1)*16] & uint32(n-1))
+    for k := range x {
+      x[k] ^= v[j*size+k]
+    }
+    blockMix(x, tmp, r)
+  }
+}
+
+// BlockMix applies Salsa20/8 across the 2r 64-byte sub-blocks of b and
+// interleaves the outputs: even results first, then odd ones.
+func blockMix(b, y []uint32, r int) {
+  var x [16]uint32
+  copy(x[:], b[(2*r-1)*16:])
+  for i := 0; i < 2*r; i++ {
+    for k := 0; k < 16; k++ {
+      x[k] ^= b[i*16+k]
+    }
+    salsa208(&x)
+    dst := (i/2)*16 + (i%2)*r*16
+    copy(y[dst:dst+16], x[:])
+  }
+  copy(b, y)
+}
+
+// Salsa20/8 core: four double rounds, then add the input back in.
+func salsa208(b *[16]uint32) {
+  x := *b
+  for i := 0; i < 8; i += 2 {
+    // Columns.
+    x[4] ^= bits.RotateLeft32(x[0]+x[12], 7)
+    x[8] ^= bits.RotateLeft32(x[4]+x[0], 9)
+    x[12] ^= bits.RotateLeft32(x[8]+x[4], 13)
+    x[0] ^= bits.RotateLeft32(x[12]+x[8], 18)
+    x[9] ^= bits.RotateLeft32(x[5]+x[1], 7)
+    x[13] ^= bits.RotateLeft32(x[9]+x[5], 9)
+    x[1] ^= bits.RotateLeft32(x[13]+x[9], 13)
+    x[5] ^= bits.RotateLeft32(x[1]+x[13], 18)
+    x[14] ^= bits.RotateLeft32(x[10]+x[6], 7)
+    x[2] ^= bits.RotateLeft32(x[14]+x[10], 9)
+    x[6] ^= bits.RotateLeft32(x[2]+x[14], 13)
+    x[10] ^= bits.RotateLeft32(x[6]+x[2], 18)
+    x[3] ^= bits.RotateLeft32(x[15]+x[11], 7)
+    x[7] ^= bits.RotateLeft32(x[3]+x[15], 9)
+    x[11] ^= bits.RotateLeft32(x[7]+x[3], 13)
+    x[15] ^= bits.RotateLeft32(x[11]+x[7], 18)
+    // Rows.
+    x[1] ^= bits.RotateLeft32(x[0]+x[3], 7)
+    x[2] ^= bits.RotateLeft32(x[1]+x[0], 9)
+    x[3] ^= bits.RotateLeft32(x[2]+x[1], 13)
+    x[0] ^= bits.RotateLeft32(x[3]+x[2], 18)
+    x[6] ^= bits.RotateLeft32(x[5]+x[4], 7)
+    x[7] ^= bits.RotateLeft32(x[6]+x[5], 9)
+    x[4] ^= bits.RotateLeft32(x[7]+x[6], 13)
+    x[5] ^= bits.RotateLeft32(x[4]+x[7], 18)
+    x[11] ^= bits.RotateLeft32(x[10]+x[9], 7)
+    x[8] ^= bits.RotateLeft32(x[11]+x[10], 9)
+    x[9] ^= bits.RotateLeft32(x[8]+x[11], 13)
+    x[10] ^= bits.RotateLeft32(x[9]+x[8], 18)
+    x[12] ^= bits.RotateLeft32(x[15]+x[14], 7)
+    x[13] ^= bits.RotateLeft32(x[12]+x[15], 9)
+    x[14] ^= bits.RotateLeft32(x[13]+x[12], 13)
+    x[15] ^= bits.RotateLeft32(x[14]+x[13], 18)
+  }
+  for i := range b {
+    b[i] += x[i]
+  }
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+  "bytes"
+  "crypto/aes"
+  "crypto/cipher"
+  "crypto/rand"
+  "encoding/binary"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "os"
+  "path/filepath"
+  "time"
+)
+
+// On-disk layout, all integers big-endian:
+//
+//   magic "CCPV" | version u8 | log2(N) u8 | r u32 | p u32 | salt [16] |
+//   nonce [12] | AES-256-GCM ciphertext of the JSON entries
+//
+// Everything before the ciphertext is passed to GCM as additional data, so
+// changing the version or KDF parameters is detected just like changing the
+// ciphertext. A fresh salt and nonce are generated on every save.
+const (
+  magic      = "CCPV"
+  version    = 1
+  saltSize   = 16
+  nonceSize  = 12
+  headerSize = len(magic) + 1 + 1 + 4 + 4 + saltSize + nonceSize
+)
+
+// Default scrypt cost: N=2^15, r=8, p=1 uses 32 MiB and takes a fraction
+// of a second, the parameters recommended for interactive logins.
+const (
+  defaultLogN = 15
+  defaultR    = 8
+  defaultP    = 1
+)
+
+var errBadPassword = errors.New("wrong master password or the vault has been tampered with")
+
+type entry struct {
+  Username string    `json:"username,omitempty"`
+  Password string    `json:"password"`
+  Notes    string    `json:"notes,omitempty"`
+  Updated  time.Time `json:"updated"`
+}
+
+type vault struct {
+  Entries map[string]entry `json:"entries"`
+}
+
+type kdfParams struct {
+  logN uint8
+  r, p uint32
+}
+
+func deriveKey(password []byte, salt []byte, kp kdfParams) ([]byte, error) {
+  if kp.logN < 1 || kp.logN > 24 || kp.r > 64 || kp.p > 16 {
+    return nil, errors.New("unsupported key derivation parameters")
+  }
+  return scrypt(password, salt, 1<<kp.logN, int(kp.r), int(kp.p), 32)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+  block, err := aes.NewCipher(key)
+  if err != nil {
+    return nil, err
+  }
+  return cipher.NewGCM(block)
+}
+
+// Encrypt the vault into its file representation.
+func seal(v *vault, password []byte, kp kdfParams) ([]byte, error) {
+  plaintext, err := json.Marshal(v)
+  if err != nil {
+    return nil, err
+  }
+  header := make([]byte, 0, headerSize)
+  header = append(header, magic...)
+  header = append(header, version, kp.logN)
+  header = binary.BigEndian.AppendUint32(header, kp.r)
+  header = binary.BigEndian.AppendUint32(header, kp.p)
+  random := make([]byte, saltSize+nonceSize)
+  if _, err := rand.Read(random); err != nil {
+    return nil, err
+  }
+  header = append(header, random...)
+  salt, nonce := random[:saltSize], random[saltSize:]
+
+  key, err := deriveKey(password, salt, kp)
+  if err != nil {
+    return nil, err
+  }
+  gcm, err := newGCM(key)
+  if err != nil {
+    return nil, err
+  }
+  return gcm.Seal(header, nonce, plaintext, header), nil
+}
+
+// Decrypt a vault file, returning the KDF parameters so a save can keep them.
+func open(data, password []byte) (*vault, kdfParams, error) {
+  var kp kdfParams
+  if len(data) < headerSize || !bytes.HasPrefix(data, []byte(magic)) {
+    return nil, kp, errors.New("not a vault file")
+  }
+  if data[4] != version {
+    return nil, kp, fmt.Errorf("unsupported vault version %d", data[4])
+  }
+  kp.logN = data[5]
+  kp.r = binary.BigEndian.Uint32(data[6:])
+  kp.p = binary.BigEndian.Uint32(data[10:])
+  salt := data[14 : 14+saltSize]
+  nonce := data[14+saltSize : headerSize]
+
+  key, err := deriveKey(password, salt, kp)
+  if err != nil {
+    return nil, kp, err
+  }
+  gcm, err := newGCM(key)
+  if err != nil {
+    return nil, kp, err
+  }
+  plaintext, err := gcm.Open(nil, nonce, data[headerSize:], data[:headerSize])
+  if err != nil {
+    return nil, kp, errBadPassword
+  }
+  v := &vault{}
+  if err := json.Unmarshal(plaintext, v); err != nil {
+    return nil, kp, err
+  }
+  if v.Entries == nil {
+    v.Entries = map[string]entry{}
+  }
+  return v, kp, nil
+}
+
+// Write the file atomically with owner-only permissions, so a crash never
+// leaves a half-written vault in place of the old one.
+func writeVault(path string, data []byte) error {
+  tmp, err := os.CreateTemp(filepath.Dir(path), ".vault-*")
+  if err != nil {
+    return err
+  }
+  defer os.Remove(tmp.Name())
+  if err := tmp.Chmod(0o600); err != nil {
+    tmp.Close()
+    return err
+  }
+  if _, err := tmp.Write(data); err != nil {
+    tmp.Close()
+    return err
+  }
+  if err := tmp.Sync(); err != nil {
+    tmp.Close()
+    return err
+  }
+  if err := tmp.Close(); err != nil {
+    return err
+  }
+  return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+  "bufio"
+  "encoding/hex"
+  "errors"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+// Cheap parameters: the tests check the format, not the cost.
+var testParams = kdfParams{logN: 4, r: 1, p: 1}
+
+// The test vectors of RFC 7914 section 12.
+func TestScrypt(t *testing.T) {
+  for _, c := range []struct {
+    password, salt string
+    n, r, p        int
+    want           string
+  }{
+    {"", "", 16, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+    {"password", "NaCl", 1024, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+  } {
+    key, err := scrypt([]byte(c.password), []byte(c.salt), c.n, c.r, c.p, 64)
+    testx.NoError(t, err)
+    testx.Equal(t, hex.EncodeToString(key), c.want)
+  }
+}
+
+func TestRoundTrip(t *testing.T) {
+  v := &vault{Entries: map[string]entry{
+    "mail": {Username: "me", Password: "hunter2", Notes: "the old one", Updated: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)},
+    "bank": {Password: "s3cret!"},
+  }}
+  data, err := seal(v, []byte("correct horse"), testParams)
+  testx.NoError(t, err)
+  path := filepath.Join(t.TempDir(), "vault")
+  testx.NoError(t, writeVault(path, data))
+  info, err := os.Stat(path)
+  testx.NoError(t, err)
+  testx.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+
+  data, err = os.ReadFile(path)
+  testx.NoError(t, err)
+  got, kp, err := open(data, []byte("correct horse"))
+  testx.NoError(t, err)
+  testx.Equal(t, kp, testParams)
+  testx.Equal(t, len(got.Entries), 2)
+  for name, e := range v.Entries {
+    if g := got.Entries[name]; g != e {
+      t.Errorf("%s: got %+v, want %+v", name, g, e)
+    }
+  }
+
+  // Every save has a fresh salt and nonce.
+  again, err := seal(v, []byte("correct horse"), testParams)
+  testx.NoError(t, err)
+  if string(again[14:headerSize]) == string(data[14:headerSize]) {
+    t.Error("a second save reused the salt and nonce")
+  }
+}
+
+// Changing any byte of the file, header included, or cutting it short
+// fails to open it rather than giving altered entries.
+func TestTamper(t *testing.T) {
+  password := []byte("correct horse")
+  data, err := seal(&vault{Entries: map[string]entry{"a": {Password: "b"}}}, password, testParams)
+  testx.NoError(t, err)
+  if _, _, err := open(data, []byte("wrong horse")); !errors.Is(err, errBadPassword) {
+    t.Errorf("wrong password: got %v", err)
+  }
+  for i := range data {
+    bad := []byte(string(data))
+    bad[i] ^= 0x01
+    if v, _, err := open(bad, password); err == nil {
+      t.Errorf("flipping byte %d opened the vault: %+v", i, v)
+    }
+  }
+  for _, n := range []int{0, 3, headerSize - 1, headerSize, len(data) - 1} {
+    if _, _, err := open(data[:n], password); err == nil {
+      t.Errorf("a vault cut to %d bytes opened", n)
+    }
+  }
+}
+
+func TestNewMasterPassword(t *testing.T) {
+  t.Setenv("VAULT_PASSWORD", "")
+  defer func(r *bufio.Reader) { stdin = r }(stdin)
+  for _, c := range []struct{ input, want, err string }{
+    {"long enough\nlong enough\n", "long enough", ""},
+    {"long enough\nlong enuogh\n", "", "the passwords do not match"},
+    {"short\nshort\n", "", "master password must be at least 8 characters"},
+    {"long enough\n", "", "no password given"},
+  } {
+    stdin = bufio.NewReader(strings.NewReader(c.input))
+    got, err := newMasterPassword()
+    if c.err != "" {
+      if err == nil || err.Error() != c.err {
+        t.Errorf("%q: got %q, %v; want %s", c.input, got, err, c.err)
+      }
+      continue
+    }
+    testx.NoError(t, err)
+    testx.Equal(t, string(got), c.want)
+  }
+}
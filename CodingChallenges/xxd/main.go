@@ -0,0 +1,273 @@
+package main
+
+import (
+  "bufio"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "strconv"
+  "strings"
+)
+
+// Write a canonical dump: offset, hex bytes in groups, then the printable
+// ASCII rendering with "." for everything else.
+func dump(r io.Reader, w *bufio.Writer, offset int64, cols, group int, upper bool) error {
+  hexDigits := "0123456789abcdef"
+  if upper {
+    hexDigits = "0123456789ABCDEF"
+  }
+  if group <= 0 || group > cols {
+    group = cols // -g0 means one group per line
+  }
+  groups := (cols + group - 1) / group
+  width := cols*2 + groups
+
+  buf := make([]byte, cols)
+  line := make([]byte, 0, width+cols+16)
+  for {
+    n, err := io.ReadFull(r, buf)
+    if n > 0 {
+      line = append(line[:0], fmt.Sprintf("%08x: ", offset)...)
+      start := len(line)
+      for i, b := range buf[:n] {
+        line = append(line, hexDigits[b>>4], hexDigits[b&0xf])
+        if (i+1)%group == 0 {
+          line = append(line, ' ')
+        }
+      }
+      if n%group != 0 {
+        line = append(line, ' ')
+      }
+      for len(line)-start < width {
+        line = append(line, ' ')
+      }
+      line = append(line, ' ')
+      for _, b := range buf[:n] {
+        if b < 0x20 || b > 0x7e {
+          b = '.'
+        }
+        line = append(line, b)
+      }
+      line = append(line, '\n')
+      if _, err := w.Write(line); err != nil {
+        return err
+      }
+      offset += int64(n)
+    }
+    if err == io.EOF || err == io.ErrUnexpectedEOF {
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+  }
+}
+
+// Write a plain dump: continuous hex digits, cols bytes per line.
+func plain(r io.Reader, w *bufio.Writer, cols int) error {
+  buf := make([]byte, cols)
+  for {
+    n, err := io.ReadFull(r, buf)
+    if n > 0 {
+      fmt.Fprintf(w, "%x\n", buf[:n])
+    }
+    if err == io.EOF || err == io.ErrUnexpectedEOF {
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+  }
+}
+
+func hexValue(c byte) (byte, bool) {
+  switch {
+  case c >= '0' && c <= '9':
+    return c - '0', true
+  case c >= 'a' && c <= 'f':
+    return c - 'a' + 10, true
+  case c >= 'A' && c <= 'F':
+    return c - 'A' + 10, true
+  }
+  return 0, false
+}
+
+// Append the bytes spelled by pairs of hex digits in s, ignoring anything
+// else. Parsing stops at the first non-hex, non-space character so the
+// ASCII column of a dump is never read as data.
+func decodeHex(s string, out []byte, stopAtText bool) []byte {
+  var hi byte
+  half := false
+  for i := 0; i < len(s); i++ {
+    v, ok := hexValue(s[i])
+    if !ok {
+      if stopAtText && s[i] != ' ' && s[i] != '\t' {
+        break
+      }
+      // Two spaces in a row separate the hex from the ASCII column.
+      if stopAtText && s[i] == ' ' && i+1 < len(s) && s[i+1] == ' ' {
+        break
+      }
+      continue
+    }
+    if half {
+      out = append(out, hi<<4|v)
+    } else {
+      hi = v
+    }
+    half = !half
+  }
+  return out
+}
+
+// Turn a dump back into binary. In canonical mode each line carries its own
+// offset; gaps are filled with zero bytes. Lines that go backwards are an
+// error because stdout cannot seek.
+func reverse(r io.Reader, w *bufio.Writer, isPlain bool) error {
+  scanner := bufio.NewScanner(r)
+  scanner.Buffer(make([]byte, 64*1024), 1<<20)
+  var written int64
+  var data []byte
+  for scanner.Scan() {
+    line := scanner.Text()
+    if isPlain {
+      data = decodeHex(line, data[:0], false)
+      w.Write(data)
+      continue
+    }
+    addr, rest, ok := strings.Cut(line, ":")
+    if !ok {
+      continue
+    }
+    offset, err := strconv.ParseInt(strings.TrimSpace(addr), 16, 64)
+    if err != nil {
+      continue
+    }
+    if offset < written {
+      return errors.New("sorry, cannot seek backwards on a stream")
+    }
+    for ; written < offset; written++ {
+      w.WriteByte(0)
+    }
+    data = decodeHex(strings.TrimLeft(rest, " "), data[:0], true)
+    w.Write(data)
+    written += int64(len(data))
+  }
+  return scanner.Err()
+}
+
+// Skip the first n bytes of r, seeking when possible. A negative seek counts
+// back from the end of a seekable file.
+func skip(r io.Reader, n int64) (int64, error) {
+  if seeker, ok := r.(io.Seeker); ok {
+    whence := io.SeekStart
+    if n < 0 {
+      whence = io.SeekEnd
+    }
+    if pos, err := seeker.Seek(n, whence); err == nil {
+      return pos, nil
+    }
+  }
+  if n < 0 {
+    return 0, errors.New("cannot seek from the end of a stream")
+  }
+  copied, err := io.CopyN(io.Discard, r, n)
+  if err == io.EOF {
+    err = nil
+  }
+  return copied, err
+}
+
+// xxd accepts values glued to their option ("-c8", "-g1"); split them so the
+// flag package understands them.
+func splitGlued(args []string) []string {
+  var out []string
+  for _, arg := range args {
+    if len(arg) > 2 && arg[0] == '-' && strings.IndexByte("cgls", arg[1]) >= 0 {
+      if _, err := strconv.ParseInt(arg[2:], 0, 64); err == nil {
+        out = append(out, arg[:2], arg[2:])
+        continue
+      }
+    }
+    out = append(out, arg)
+  }
+  return out
+}
+
+// Dump a file (or stdin) to stdout, or reverse a dump with -r.
+func main() {
+  cols := flag.Int("c", 16, "bytes per line")
+  group := flag.Int("g", 2, "bytes per group; 0 for no grouping")
+  seek := flag.Int64("s", 0, "start at this offset (negative counts from the end)")
+  length := flag.Int64("l", -1, "stop after this many bytes")
+  isPlain := flag.Bool("p", false, "plain hex dump with no offsets or ASCII")
+  rev := flag.Bool("r", false, "reverse: convert a hex dump to binary")
+  upper := flag.Bool("u", false, "use upper case hex letters")
+  flag.CommandLine.Parse(splitGlued(os.Args[1:]))
+
+  var in io.Reader = os.Stdin
+  if flag.NArg() > 0 && flag.Arg(0) != "-" {
+    file, err := os.Open(flag.Arg(0))
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "xxd:", err)
+      os.Exit(2)
+    }
+    defer file.Close()
+    in = file
+  }
+  var out io.Writer = os.Stdout
+  if flag.NArg() > 1 {
+    file, err := os.Create(flag.Arg(1))
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "xxd:", err)
+      os.Exit(2)
+    }
+    defer file.Close()
+    out = file
+  }
+  w := bufio.NewWriter(out)
+
+  var err error
+  if *rev {
+    err = reverse(in, w, *isPlain)
+  } else {
+    if *cols < 1 || *cols > 256 {
+      fmt.Fprintln(os.Stderr, "xxd: invalid number of columns (max. 256)")
+      os.Exit(2)
+    }
+    if *isPlain && !isSet("c") {
+      *cols = 30
+    }
+    var offset int64
+    if *seek != 0 {
+      offset, err = skip(in, *seek)
+    }
+    if *length >= 0 {
+      in = io.LimitReader(in, *length)
+    }
+    if err == nil && *isPlain {
+      err = plain(in, w, *cols)
+    } else if err == nil {
+      err = dump(in, w, offset, *cols, *group, *upper)
+    }
+  }
+  if ferr := w.Flush(); err == nil {
+    err = ferr
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "xxd:", err)
+    os.Exit(2)
+  }
+}
+
+func isSet(name string) bool {
+  set := false
+  flag.Visit(func(f *flag.Flag) {
+    if f.Name == name {
+      set = true
+    }
+  })
+  return set
+}
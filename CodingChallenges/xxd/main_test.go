@@ -0,0 +1,163 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "strings"
+  "testing"
+)
+
+// Text with bytes outside ASCII, and an ASCII column that itself looks
+// like hex, so reversing must know where the hex stops.
+const sample = "Hello, xxd!\n\x00\x01\x7f\x80\xff  ab cd: 0a\n"
+
+func dumped(t *testing.T, data string, offset int64, cols, group int, upper bool) string {
+  t.Helper()
+  var out strings.Builder
+  w := bufio.NewWriter(&out)
+  if err := dump(strings.NewReader(data), w, offset, cols, group, upper); err != nil {
+    t.Fatal(err)
+  }
+  w.Flush()
+  return out.String()
+}
+
+func plained(t *testing.T, data string, cols int) string {
+  t.Helper()
+  var out strings.Builder
+  w := bufio.NewWriter(&out)
+  if err := plain(strings.NewReader(data), w, cols); err != nil {
+    t.Fatal(err)
+  }
+  w.Flush()
+  return out.String()
+}
+
+func reversed(t *testing.T, dump string, isPlain bool) (string, error) {
+  t.Helper()
+  var out strings.Builder
+  w := bufio.NewWriter(&out)
+  err := reverse(strings.NewReader(dump), w, isPlain)
+  w.Flush()
+  return out.String(), err
+}
+
+// Dumps of the sample with each layout, as xxd prints them.
+var dumps = []struct {
+  name         string
+  cols, group  int
+  upper, plain bool
+  want         string
+}{
+  {"default", 16, 2, false, false, "00000000: 4865 6c6c 6f2c 2078 7864 210a 0001 7f80  Hello, xxd!.....\n00000010: ff20 2061 6220 6364 3a20 3061 0a         .  ab cd: 0a.\n"},
+  {"-c 8", 8, 2, false, false, "00000000: 4865 6c6c 6f2c 2078  Hello, x\n00000008: 7864 210a 0001 7f80  xd!.....\n00000010: ff20 2061 6220 6364  .  ab cd\n00000018: 3a20 3061 0a         : 0a.\n"},
+  {"-g 1", 16, 1, false, false, "00000000: 48 65 6c 6c 6f 2c 20 78 78 64 21 0a 00 01 7f 80  Hello, xxd!.....\n00000010: ff 20 20 61 62 20 63 64 3a 20 30 61 0a           .  ab cd: 0a.\n"},
+  {"-g 0", 16, 0, false, false, "00000000: 48656c6c6f2c20787864210a00017f80  Hello, xxd!.....\n00000010: ff202061622063643a2030610a        .  ab cd: 0a.\n"},
+  {"-g 4", 16, 4, false, false, "00000000: 48656c6c 6f2c2078 7864210a 00017f80  Hello, xxd!.....\n00000010: ff202061 62206364 3a203061 0a        .  ab cd: 0a.\n"},
+  {"-u", 16, 2, true, false, "00000000: 4865 6C6C 6F2C 2078 7864 210A 0001 7F80  Hello, xxd!.....\n00000010: FF20 2061 6220 6364 3A20 3061 0A         .  ab cd: 0a.\n"},
+  {"-c 5 -g 3", 5, 3, false, false, "00000000: 48656c 6c6f  Hello\n00000005: 2c2078 7864  , xxd\n0000000a: 210a00 017f  !....\n0000000f: 80ff20 2061  ..  a\n00000014: 622063 643a  b cd:\n00000019: 203061 0a     0a.\n"},
+  {"-p", 30, 0, false, true, "48656c6c6f2c20787864210a00017f80ff202061622063643a2030610a\n"},
+  {"-p -c 4", 4, 0, false, true, "48656c6c\n6f2c2078\n7864210a\n00017f80\nff202061\n62206364\n3a203061\n0a\n"},
+}
+
+func TestDump(t *testing.T) {
+  for _, c := range dumps {
+    var got string
+    if c.plain {
+      got = plained(t, sample, c.cols)
+    } else {
+      got = dumped(t, sample, 0, c.cols, c.group, c.upper)
+    }
+    if got != c.want {
+      t.Errorf("%s: got\n%s\nwant\n%s", c.name, got, c.want)
+    }
+  }
+}
+
+// Every dump xxd prints reverses to the sample, and so does every dump of
+// every byte value.
+func TestReverse(t *testing.T) {
+  for _, c := range dumps {
+    if got, err := reversed(t, c.want, c.plain); err != nil || got != sample {
+      t.Errorf("%s: got %q, %v, want the sample", c.name, got, err)
+    }
+  }
+  var all bytes.Buffer
+  for i := range 512 {
+    all.WriteByte(byte(i * 7))
+  }
+  for _, cols := range []int{1, 3, 16, 33, 256} {
+    for _, group := range []int{0, 1, 2, 4, 8} {
+      for _, upper := range []bool{false, true} {
+        if got, err := reversed(t, dumped(t, all.String(), 0, cols, group, upper), false); err != nil || got != all.String() {
+          t.Errorf("-c %d -g %d (upper %v): round trip gave %d bytes, %v", cols, group, upper, len(got), err)
+        }
+      }
+    }
+    if got, err := reversed(t, plained(t, all.String(), cols), true); err != nil || got != all.String() {
+      t.Errorf("-p -c %d: round trip gave %d bytes, %v", cols, len(got), err)
+    }
+  }
+}
+
+func TestReverseOffsets(t *testing.T) {
+  // A gap between lines is filled with zeros; lines may start anywhere.
+  got, err := reversed(t, "00000002: 4142  AB\n00000006: 43  C\nnot a dump line\n", false)
+  if err != nil || got != "\x00\x00AB\x00\x00C" {
+    t.Errorf("got %q, %v", got, err)
+  }
+  if _, err := reversed(t, "00000010: 4142  AB\n00000000: 43  C\n", false); err == nil {
+    t.Error("going backwards did not fail")
+  }
+}
+
+func TestSkip(t *testing.T) {
+  for _, c := range []struct {
+    n    int64
+    pos  int64
+    rest string
+  }{
+    {4, 4, sample[4:]},
+    {-3, int64(len(sample)) - 3, sample[len(sample)-3:]},
+    {100, 100, ""}, // past the end, as a seek goes
+  } {
+    r := strings.NewReader(sample)
+    pos, err := skip(r, c.n)
+    if err != nil || pos != c.pos {
+      t.Errorf("skip %d: got %d, %v, want %d", c.n, pos, err, c.pos)
+    }
+    if rest := sample[len(sample)-r.Len():]; rest != c.rest {
+      t.Errorf("skip %d: %q left, want %q", c.n, rest, c.rest)
+    }
+  }
+  // A stream cannot seek, so it is read through, and cannot start from
+  // its end at all.
+  stream := bufio.NewReader(strings.NewReader(sample))
+  if pos, err := skip(stream, 4); err != nil || pos != 4 {
+    t.Errorf("skipping a stream: got %d, %v", pos, err)
+  }
+  if pos, err := skip(stream, 100); err != nil || pos != int64(len(sample))-4 {
+    t.Errorf("skipping past the end of a stream: got %d, %v", pos, err)
+  }
+  if _, err := skip(stream, -3); err == nil {
+    t.Error("skipping back from the end of a stream did not fail")
+  }
+  // As xxd -s 4 -l 6 prints it.
+  r := strings.NewReader(sample)
+  pos, _ := skip(r, 4)
+  var out strings.Builder
+  w := bufio.NewWriter(&out)
+  dump(strings.NewReader(sample[4:10]), w, pos, 16, 2, false)
+  w.Flush()
+  if want := "00000004: 6f2c 2078 7864                           o, xxd\n"; out.String() != want {
+    t.Errorf("got %q, want %q", out.String(), want)
+  }
+}
+
+func TestSplitGlued(t *testing.T) {
+  got := splitGlued([]string{"-c8", "-g1", "-s0x10", "-p", "-rfile", "-l", "5", "-"})
+  want := "-c 8 -g 1 -s 0x10 -p -rfile -l 5 -"
+  if strings.Join(got, " ") != want {
+    t.Errorf("got %q, want %q", strings.Join(got, " "), want)
+  }
+}
@@ -0,0 +1,12 @@
+#!/usr/bin/env python
+"""Set 1, challenge 1: convert hex to base64."""
+
+from cryptopals import b64_encode, expect, hex_decode
+
+HEX = ("49276d206b696c6c696e6720796f757220627261696e206c696b65206120706f"
+       "69736f6e6f7573206d757368726f6f6d")
+WANT = "SSdtIGtpbGxpbmcgeW91ciBicmFpbiBsaWtlIGEgcG9pc29ub3VzIG11c2hyb29t"
+
+
+if __name__ == "__main__":
+    expect("hex to base64", b64_encode(hex_decode(HEX)), WANT)
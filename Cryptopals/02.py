@@ -0,0 +1,11 @@
+#!/usr/bin/env python
+"""Set 1, challenge 2: fixed XOR."""
+
+from cryptopals import expect, hex_decode, hex_encode, xor
+
+
+if __name__ == "__main__":
+    a = hex_decode("1c0111001f010100061a024b53535009181c")
+    b = hex_decode("686974207468652062756c6c277320657965")
+    expect("fixed xor", hex_encode(xor(a, b)),
+           "746865206b696420646f6e277420706c6179")
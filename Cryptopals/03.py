@@ -0,0 +1,13 @@
+#!/usr/bin/env python
+"""Set 1, challenge 3: single-byte XOR cipher."""
+
+from cryptopals import break_single_xor, expect, hex_decode
+
+CIPHERTEXT = ("1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b"
+              "3736")
+
+
+if __name__ == "__main__":
+    _, key, plaintext = break_single_xor(hex_decode(CIPHERTEXT))
+    print(f"key {chr(key)!r}: {plaintext.decode()}")
+    expect("single-byte xor", plaintext, b"Cooking MC's like a pound of bacon")
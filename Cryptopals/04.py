@@ -0,0 +1,22 @@
+#!/usr/bin/env python
+"""Set 1, challenge 4: detect single-character XOR among 60-character
+strings. The data file is 4.txt from the challenge page, saved as 04.in."""
+
+from cryptopals import break_single_xor, expect, hex_decode, read_input
+
+
+def find_encrypted(lines: list[str]) -> tuple[int, bytes]:
+    """The line whose best single-byte decryption scores highest."""
+    best = max((break_single_xor(hex_decode(line)), n)
+               for n, line in enumerate(lines, 1))
+    (_, _, plaintext), n = best
+    return n, plaintext
+
+
+if __name__ == "__main__":
+    data = read_input("04.in")
+    if data is not None:
+        n, plaintext = find_encrypted(data.split())
+        print(f"line {n}: {plaintext!r}")
+        expect("detect single-byte xor", plaintext,
+               b"Now that the party is jumping\n")
@@ -0,0 +1,15 @@
+#!/usr/bin/env python
+"""Set 1, challenge 5: implement repeating-key XOR."""
+
+from cryptopals import expect, hex_encode, repeating_xor
+
+PLAINTEXT = (b"Burning 'em, if you ain't quick and nimble\n"
+             b"I go crazy when I hear a cymbal")
+WANT = ("0b3637272a2b2e63622c2e69692a23693a2a3c6324202d623d63343c2a262263"
+        "24272765272a282b2f20430a652e2c652a3124333a653e2b2027630c692b2028"
+        "3165286326302e27282f")
+
+
+if __name__ == "__main__":
+    expect("repeating-key xor", hex_encode(repeating_xor(PLAINTEXT, b"ICE")),
+           WANT)
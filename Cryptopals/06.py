@@ -0,0 +1,41 @@
+#!/usr/bin/env python
+"""Set 1, challenge 6: break repeating-key XOR. The data file is 6.txt
+from the challenge page, base64 encoded, saved as 06.in."""
+
+from cryptopals import (b64_decode, break_single_xor, english_score, expect,
+                        hamming, read_input, repeating_xor)
+
+
+def guess_key_sizes(data: bytes, count: int = 3) -> list[int]:
+    """Key sizes whose consecutive blocks differ least, normalised by size.
+    Averaging over many block pairs makes the right size stand out."""
+    scores = []
+    for size in range(2, 41):
+        chunks = [data[i:i + size] for i in range(0, len(data) - size, size)][:16]
+        pairs = list(zip(chunks, chunks[1:]))
+        distance = sum(hamming(a, b) for a, b in pairs) / len(pairs) / size
+        scores.append((distance, size))
+    return [size for _, size in sorted(scores)[:count]]
+
+
+def break_repeating_xor(data: bytes) -> tuple[bytes, bytes]:
+    """Solve each key position as single-byte XOR and keep the key size
+    whose decryption looks most like English."""
+    best = None
+    for size in guess_key_sizes(data):
+        key = bytes(break_single_xor(data[i::size])[1] for i in range(size))
+        plaintext = repeating_xor(data, key)
+        candidate = (english_score(plaintext), key, plaintext)
+        if best is None or candidate > best:
+            best = candidate
+    return best[1], best[2]
+
+
+if __name__ == "__main__":
+    expect("hamming distance", hamming(b"this is a test", b"wokka wokka!!!"), 37)
+    data = read_input("06.in")
+    if data is not None:
+        key, plaintext = break_repeating_xor(b64_decode(data))
+        print(f"key {key.decode()!r}")
+        print(plaintext.decode().splitlines()[0])
+        expect("break repeating-key xor", key, b"Terminator X: Bring the noise")
@@ -0,0 +1,18 @@
+#!/usr/bin/env python
+"""Set 1, challenge 7: AES in ECB mode. The data file is 7.txt from the
+challenge page, base64 encoded, saved as 07.in."""
+
+from cryptopals import AES, b64_decode, ecb_decrypt, expect, hex_decode, hex_encode, read_input
+
+
+if __name__ == "__main__":
+    # The FIPS-197 appendix C.1 vector checks the block cipher itself.
+    aes = AES(bytes(range(16)))
+    block = aes.encrypt_block(hex_decode("00112233445566778899aabbccddeeff"))
+    expect("AES-128 known answer", hex_encode(block),
+           "69c4e0d86a7b0430d8cdb78070b4c55a")
+    data = read_input("07.in")
+    if data is not None:
+        plaintext = ecb_decrypt(b64_decode(data), b"YELLOW SUBMARINE")
+        expect("AES-ECB decrypt", plaintext.splitlines()[0],
+               b"I'm back and I'm ringin' the bell ")
@@ -0,0 +1,15 @@
+#!/usr/bin/env python
+"""Set 1, challenge 8: detect AES in ECB mode among hex-encoded
+ciphertexts. The data file is 8.txt from the challenge page, saved as
+08.in."""
+
+from cryptopals import expect, has_repeated_block, hex_decode, read_input
+
+
+if __name__ == "__main__":
+    data = read_input("08.in")
+    if data is not None:
+        found = [n for n, line in enumerate(data.split(), 1)
+                 if has_repeated_block(hex_decode(line))]
+        print("ECB lines:", found)
+        expect("detect ECB", found, [133])
@@ -0,0 +1,11 @@
+#!/usr/bin/env python
+"""Set 2, challenge 9: implement PKCS#7 padding."""
+
+from cryptopals import expect, pkcs7_pad
+
+
+if __name__ == "__main__":
+    expect("pad to 20", pkcs7_pad(b"YELLOW SUBMARINE", 20),
+           b"YELLOW SUBMARINE\x04\x04\x04\x04")
+    expect("full block of padding", pkcs7_pad(b"YELLOW SUBMARINE", 16),
+           b"YELLOW SUBMARINE" + b"\x10" * 16)
@@ -0,0 +1,19 @@
+#!/usr/bin/env python
+"""Set 2, challenge 10: implement CBC mode on top of the ECB block cipher.
+The data file is 10.txt from the challenge page, base64 encoded, saved as
+10.in."""
+
+from cryptopals import b64_decode, cbc_decrypt, cbc_encrypt, expect, read_input
+
+KEY = b"YELLOW SUBMARINE"
+
+
+if __name__ == "__main__":
+    message = b"CBC chains each block into the next, so equal blocks differ."
+    expect("CBC round trip", cbc_decrypt(cbc_encrypt(message, KEY, bytes(16)),
+                                         KEY, bytes(16)), message)
+    data = read_input("10.in")
+    if data is not None:
+        plaintext = cbc_decrypt(b64_decode(data), KEY, bytes(16))
+        expect("CBC decrypt", plaintext.splitlines()[0],
+               b"I'm back and I'm ringin' the bell ")
@@ -0,0 +1,30 @@
+#!/usr/bin/env python
+"""Set 2, challenge 11: an ECB/CBC detection oracle."""
+
+import random
+
+from cryptopals import (cbc_encrypt, ecb_encrypt, expect, has_repeated_block,
+                        random_bytes)
+
+
+def encryption_oracle(data: bytes) -> tuple[bytes, str]:
+    """Encrypt under a random key with 5-10 random bytes either side,
+    choosing ECB or CBC at random. The mode is returned to score guesses."""
+    data = random_bytes(random.randint(5, 10)) + data + random_bytes(random.randint(5, 10))
+    key = random_bytes(16)
+    if random.random() < 0.5:
+        return ecb_encrypt(data, key), "ECB"
+    return cbc_encrypt(data, key, random_bytes(16)), "CBC"
+
+
+def detect_mode(oracle) -> tuple[str, str]:
+    """Enough identical bytes guarantee two identical aligned blocks, which
+    only ECB turns into identical ciphertext blocks."""
+    ciphertext, mode = oracle(b"A" * 48)
+    return ("ECB" if has_repeated_block(ciphertext) else "CBC"), mode
+
+
+if __name__ == "__main__":
+    results = [detect_mode(encryption_oracle) for _ in range(50)]
+    correct = sum(guess == mode for guess, mode in results)
+    expect("detected the mode 50 times out of 50", correct, 50)
@@ -0,0 +1,66 @@
+#!/usr/bin/env python
+"""Set 2, challenge 12: byte-at-a-time ECB decryption (simple).
+
+The secret suffix is the base64 text from the challenge page, saved as
+12.in; without it a stand-in secret is used. Either way the attack only
+sees the oracle, and the check compares what it recovered with the secret.
+"""
+
+import os
+
+from cryptopals import b64_decode, ecb_encrypt, expect, has_repeated_block, random_bytes
+
+FALLBACK = b"Rollin' in my stand-in secret\nWith my rag-top down so my hair can blow\n"
+
+
+def load_secret() -> bytes:
+    if os.path.exists("12.in"):
+        with open("12.in", "r") as file:
+            return b64_decode(file.read())
+    return FALLBACK
+
+
+def make_oracle(secret: bytes):
+    key = random_bytes(16)
+    return lambda data: ecb_encrypt(data + secret, key)
+
+
+def block_size(oracle) -> int:
+    """The ciphertext grows by a whole block once the input pushes the
+    padding over a block boundary."""
+    base = len(oracle(b""))
+    n = 1
+    while len(oracle(b"A" * n)) == base:
+        n += 1
+    return len(oracle(b"A" * n)) - base
+
+
+def recover(oracle) -> bytes:
+    """Line each unknown byte up as the last byte of a block whose other
+    bytes are known, then match that block against all 256 guesses."""
+    size = block_size(oracle)
+    if not has_repeated_block(oracle(b"A" * 2 * size)):
+        raise ValueError("oracle is not using ECB")
+    known = b""
+    total = len(oracle(b""))
+    while len(known) < total:
+        pad = b"A" * (size - 1 - len(known) % size)
+        index = len(known) // size * size
+        target = oracle(pad)[index:index + size]
+        prefix = (pad + known)[-(size - 1):]
+        for b in range(256):
+            if oracle(prefix + bytes([b]))[:size] == target:
+                known += bytes([b])
+                break
+        else:
+            # No match means we reached the padding: the last byte found
+            # was the 0x01 pad byte.
+            return known[:-1]
+    return known
+
+
+if __name__ == "__main__":
+    secret = load_secret()
+    recovered = recover(make_oracle(secret))
+    print(recovered.decode(errors="replace"))
+    expect("recovered the secret", recovered, secret)
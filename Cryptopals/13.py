@@ -0,0 +1,44 @@
+#!/usr/bin/env python
+"""Set 2, challenge 13: ECB cut-and-paste."""
+
+from cryptopals import ecb_decrypt, ecb_encrypt, expect, pkcs7_pad, random_bytes
+
+KEY = random_bytes(16)
+
+
+def parse(s: str) -> dict[str, str]:
+    """Parse k=v&k=v structured cookies."""
+    return dict(pair.split("=", 1) for pair in s.split("&") if "=" in pair)
+
+
+def profile_for(email: str) -> str:
+    email = email.replace("&", "").replace("=", "")
+    return f"email={email}&uid=10&role=user"
+
+
+def encrypted_profile(email: str) -> bytes:
+    return ecb_encrypt(profile_for(email).encode(), KEY)
+
+
+def decrypt_profile(ciphertext: bytes) -> dict[str, str]:
+    return parse(ecb_decrypt(ciphertext, KEY).decode())
+
+
+def forge_admin() -> bytes:
+    """Build "admin" plus valid padding as a block of its own, then paste it
+    over the final block of a profile sized so that "role=" ends a block."""
+    # "email=" is 6 bytes, so 10 bytes of email finish the first block.
+    admin = encrypted_profile("A" * 10 + pkcs7_pad(b"admin").decode())[16:32]
+    # "email=" + 13 + "&uid=10&role=" is 32 bytes: two whole blocks.
+    base = encrypted_profile("foooo@bar.com")
+    return base[:32] + admin
+
+
+if __name__ == "__main__":
+    expect("parse", parse("foo=bar&baz=qux&zap=zazzle"),
+           {"foo": "bar", "baz": "qux", "zap": "zazzle"})
+    expect("metacharacters stripped", profile_for("foo@bar.com&role=admin"),
+           "email=foo@bar.comroleadmin&uid=10&role=user")
+    profile = decrypt_profile(forge_admin())
+    print(profile)
+    expect("forged admin role", profile.get("role"), "admin")
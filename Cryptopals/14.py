@@ -0,0 +1,47 @@
+#!/usr/bin/env python
+"""Set 2, challenge 14: byte-at-a-time ECB decryption (harder), where the
+oracle also prepends a random-length random prefix that stays fixed."""
+
+import importlib
+import random
+
+from cryptopals import ecb_encrypt, expect, random_bytes
+
+simple = importlib.import_module("12")
+
+
+def make_oracle(secret: bytes):
+    key = random_bytes(16)
+    prefix = random_bytes(random.randint(0, 40))
+    return lambda data: ecb_encrypt(prefix + data + secret, key)
+
+
+def prefix_length(oracle) -> int:
+    """Grow a filler before two blocks of a marker until they encrypt to
+    equal adjacent blocks: the filler has then aligned the end of the prefix
+    to a block boundary. Repeating with a second marker rules out prefix
+    bytes that happen to match the first."""
+    for fill in range(16):
+        found = []
+        for marker in (b"A", b"B"):
+            chunks = oracle(b"\x00" * fill + marker * 32)
+            found.append(next((i for i in range(0, len(chunks) - 16, 16)
+                               if chunks[i:i + 16] == chunks[i + 16:i + 32]), None))
+        if found[0] is not None and found[0] == found[1]:
+            return found[0] - fill
+    raise ValueError("could not align the prefix")
+
+
+def strip_prefix(oracle):
+    """Wrap the oracle so it looks like the simple one: pad the prefix out
+    to a block boundary and drop those leading blocks."""
+    n = prefix_length(oracle)
+    fill = -n % 16
+    skip = n + fill
+    return lambda data: oracle(b"\x00" * fill + data)[skip:]
+
+
+if __name__ == "__main__":
+    secret = simple.load_secret()
+    recovered = simple.recover(strip_prefix(make_oracle(secret)))
+    expect("recovered the secret past a random prefix", recovered, secret)
@@ -0,0 +1,20 @@
+#!/usr/bin/env python
+"""Set 2, challenge 15: PKCS#7 padding validation."""
+
+from cryptopals import PaddingError, expect, pkcs7_unpad
+
+
+def rejects(data: bytes) -> bool:
+    try:
+        pkcs7_unpad(data)
+    except PaddingError:
+        return True
+    return False
+
+
+if __name__ == "__main__":
+    expect("valid padding", pkcs7_unpad(b"ICE ICE BABY\x04\x04\x04\x04"),
+           b"ICE ICE BABY")
+    expect("wrong pad byte", rejects(b"ICE ICE BABY\x05\x05\x05\x05"), True)
+    expect("mixed pad bytes", rejects(b"ICE ICE BABY\x01\x02\x03\x04"), True)
+    expect("zero pad byte", rejects(b"ICE ICE BABY\x00\x00\x00\x00"), True)
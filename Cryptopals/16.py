@@ -0,0 +1,37 @@
+#!/usr/bin/env python
+"""Set 2, challenge 16: CBC bit-flipping attacks."""
+
+from cryptopals import cbc_decrypt, cbc_encrypt, expect, random_bytes, xor
+
+KEY = random_bytes(16)
+IV = random_bytes(16)
+PREFIX = b"comment1=cooking%20MCs;userdata="
+SUFFIX = b";comment2=%20like%20a%20pound%20of%20bacon"
+
+
+def encrypt_userdata(data: bytes) -> bytes:
+    data = data.replace(b";", b"%3B").replace(b"=", b"%3D")
+    return cbc_encrypt(PREFIX + data + SUFFIX, KEY, IV)
+
+
+def is_admin(ciphertext: bytes) -> bool:
+    plaintext = cbc_decrypt(ciphertext, KEY, IV)
+    return b";admin=true;" in plaintext
+
+
+def forge() -> bytes:
+    """Flipping a bit in one ciphertext block scrambles that block but flips
+    the same bit in the next plaintext block. The prefix is exactly two
+    blocks, so send a sacrificial block and then a block to rewrite, and
+    edit the sacrificial block's ciphertext."""
+    want = b";admin=true;" + b"A" * 4
+    have = b"A" * 16
+    ciphertext = bytearray(encrypt_userdata(b"A" * 32))
+    ciphertext[32:48] = xor(xor(ciphertext[32:48], have), want)
+    return bytes(ciphertext)
+
+
+if __name__ == "__main__":
+    expect("quoting stops the direct route",
+           is_admin(encrypt_userdata(b";admin=true;")), False)
+    expect("bit-flipped to admin", is_admin(forge()), True)
@@ -0,0 +1,288 @@
+"""
+Building blocks for the Cryptopals challenges, written from scratch rather
+than taken from a library: hex and base64 codecs, XOR, English scoring,
+PKCS#7 padding, and AES-128 with ECB and CBC modes.
+"""
+
+import os
+
+# --- Encodings -------------------------------------------------------------
+
+HEX_DIGITS = "0123456789abcdef"
+B64_ALPHABET = ("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+                "0123456789+/")
+
+
+def hex_decode(s: str) -> bytes:
+    s = s.strip().lower()
+    if len(s) % 2:
+        raise ValueError("odd-length hex string")
+    return bytes(HEX_DIGITS.index(s[i]) << 4 | HEX_DIGITS.index(s[i + 1])
+                 for i in range(0, len(s), 2))
+
+
+def hex_encode(data: bytes) -> str:
+    return "".join(HEX_DIGITS[b >> 4] + HEX_DIGITS[b & 15] for b in data)
+
+
+def b64_encode(data: bytes) -> str:
+    """Every 3 bytes become 4 six-bit characters, padded with '='."""
+    out = []
+    for i in range(0, len(data), 3):
+        chunk = data[i:i + 3]
+        n = int.from_bytes(chunk + bytes(3 - len(chunk)), "big")
+        chars = [B64_ALPHABET[n >> shift & 63] for shift in (18, 12, 6, 0)]
+        keep = len(chunk) + 1
+        out.append("".join(chars[:keep]) + "=" * (4 - keep))
+    return "".join(out)
+
+
+def b64_decode(s: str) -> bytes:
+    """Decode base64, ignoring whitespace such as the line breaks in the
+    challenge files."""
+    s = "".join(s.split())
+    if len(s) % 4:
+        raise ValueError("base64 length is not a multiple of 4")
+    out = bytearray()
+    for i in range(0, len(s), 4):
+        quad = s[i:i + 4]
+        pad = quad.count("=")
+        n = 0
+        for c in quad.rstrip("="):
+            n = n << 6 | B64_ALPHABET.index(c)
+        n <<= 6 * pad
+        out += n.to_bytes(3, "big")[:3 - pad]
+    return bytes(out)
+
+
+# --- XOR and English scoring -----------------------------------------------
+
+def xor(a: bytes, b: bytes) -> bytes:
+    """XOR two equal-length buffers."""
+    if len(a) != len(b):
+        raise ValueError("xor of unequal lengths")
+    return bytes(x ^ y for x, y in zip(a, b))
+
+
+def repeating_xor(data: bytes, key: bytes) -> bytes:
+    """XOR with a key repeated to the data's length; a one-byte key is
+    single-byte XOR."""
+    return bytes(b ^ key[i % len(key)] for i, b in enumerate(data))
+
+
+# Relative letter frequencies in English text, space included, per 1000.
+FREQUENCIES = {
+    " ": 183, "e": 102, "t": 75, "a": 65, "o": 62, "n": 57, "i": 57,
+    "s": 53, "r": 50, "h": 50, "l": 33, "d": 33, "u": 23, "c": 22, "m": 20,
+    "f": 18, "w": 17, "g": 16, "p": 15, "y": 14, "b": 13, "v": 8, "k": 6,
+    "x": 1, "j": 1, "q": 1, "z": 1,
+}
+
+
+def english_score(data: bytes) -> float:
+    """Higher for text that looks like English: letter frequencies add,
+    unprintable bytes cost heavily."""
+    score = 0.0
+    for b in data:
+        c = chr(b).lower()
+        if c in FREQUENCIES:
+            score += FREQUENCIES[c]
+        elif b < 32 and b not in (9, 10, 13) or b > 126:
+            score -= 500
+    return score / max(len(data), 1)
+
+
+def break_single_xor(data: bytes) -> tuple[float, int, bytes]:
+    """Try every key byte, returning (score, key, plaintext) for the most
+    English-looking result."""
+    return max((english_score(p), key, p)
+               for key in range(256)
+               for p in [repeating_xor(data, bytes([key]))])
+
+
+def hamming(a: bytes, b: bytes) -> int:
+    """Number of differing bits."""
+    return sum(bin(x ^ y).count("1") for x, y in zip(a, b))
+
+
+# --- Padding ---------------------------------------------------------------
+
+class PaddingError(ValueError):
+    pass
+
+
+def pkcs7_pad(data: bytes, block: int = 16) -> bytes:
+    n = block - len(data) % block
+    return data + bytes([n]) * n
+
+
+def pkcs7_unpad(data: bytes, block: int = 16) -> bytes:
+    """Strip PKCS#7 padding, raising PaddingError if it is invalid."""
+    if not data or len(data) % block:
+        raise PaddingError("data is not a whole number of blocks")
+    n = data[-1]
+    if not 1 <= n <= block or data[-n:] != bytes([n]) * n:
+        raise PaddingError("bad padding")
+    return data[:-n]
+
+
+# --- AES-128 (FIPS-197) ----------------------------------------------------
+
+def _xtime(b: int) -> int:
+    b <<= 1
+    return (b ^ 0x1B) & 0xFF if b & 0x100 else b
+
+
+def _mul(a: int, b: int) -> int:
+    """Multiply in GF(2^8) modulo x^8 + x^4 + x^3 + x + 1."""
+    result = 0
+    while b:
+        if b & 1:
+            result ^= a
+        a = _xtime(a)
+        b >>= 1
+    return result
+
+
+def _make_sbox() -> tuple[list[int], list[int]]:
+    """The S-box is the multiplicative inverse followed by an affine map."""
+    sbox = [0] * 256
+    for x in range(256):
+        inv = 0
+        if x:
+            inv = next(y for y in range(1, 256) if _mul(x, y) == 1)
+        s = inv
+        for shift in range(1, 5):
+            s ^= (inv << shift | inv >> (8 - shift)) & 0xFF
+        sbox[x] = s ^ 0x63
+    inverse = [0] * 256
+    for x, s in enumerate(sbox):
+        inverse[s] = x
+    return sbox, inverse
+
+
+SBOX, INV_SBOX = _make_sbox()
+
+
+def _expand_key(key: bytes) -> list[list[int]]:
+    """Eleven 16-byte round keys from a 16-byte key."""
+    if len(key) != 16:
+        raise ValueError("AES-128 needs a 16-byte key")
+    words = [list(key[i:i + 4]) for i in range(0, 16, 4)]
+    rcon = 1
+    for i in range(4, 44):
+        temp = words[i - 1][:]
+        if i % 4 == 0:
+            temp = [SBOX[b] for b in temp[1:] + temp[:1]]
+            temp[0] ^= rcon
+            rcon = _xtime(rcon)
+        words.append([a ^ b for a, b in zip(words[i - 4], temp)])
+    return [sum(words[r * 4:r * 4 + 4], []) for r in range(11)]
+
+
+# The state is 16 bytes in column order: byte r + 4c is row r, column c.
+def _shift_rows(s: list[int], inverse: bool = False) -> list[int]:
+    sign = -1 if inverse else 1
+    return [s[r + 4 * ((c + sign * r) % 4)] for c in range(4) for r in range(4)]
+
+
+def _mix_columns(s: list[int], inverse: bool = False) -> list[int]:
+    m = (14, 11, 13, 9) if inverse else (2, 3, 1, 1)
+    out = []
+    for c in range(4):
+        col = s[4 * c:4 * c + 4]
+        for r in range(4):
+            out.append(_mul(col[0], m[(0 - r) % 4]) ^ _mul(col[1], m[(1 - r) % 4])
+                       ^ _mul(col[2], m[(2 - r) % 4]) ^ _mul(col[3], m[(3 - r) % 4]))
+    return out
+
+
+class AES:
+    """AES-128 on single blocks."""
+
+    def __init__(self, key: bytes):
+        self.round_keys = _expand_key(key)
+
+    def encrypt_block(self, block: bytes) -> bytes:
+        s = [b ^ k for b, k in zip(block, self.round_keys[0])]
+        for rnd in range(1, 11):
+            s = _shift_rows([SBOX[b] for b in s])
+            if rnd < 10:
+                s = _mix_columns(s)
+            s = [b ^ k for b, k in zip(s, self.round_keys[rnd])]
+        return bytes(s)
+
+    def decrypt_block(self, block: bytes) -> bytes:
+        s = [b ^ k for b, k in zip(block, self.round_keys[10])]
+        for rnd in range(9, -1, -1):
+            s = [INV_SBOX[b] for b in _shift_rows(s, inverse=True)]
+            s = [b ^ k for b, k in zip(s, self.round_keys[rnd])]
+            if rnd > 0:
+                s = _mix_columns(s, inverse=True)
+        return bytes(s)
+
+
+def blocks(data: bytes, size: int = 16) -> list[bytes]:
+    return [data[i:i + size] for i in range(0, len(data), size)]
+
+
+def ecb_encrypt(data: bytes, key: bytes) -> bytes:
+    aes = AES(key)
+    return b"".join(aes.encrypt_block(b) for b in blocks(pkcs7_pad(data)))
+
+
+def ecb_decrypt(data: bytes, key: bytes) -> bytes:
+    aes = AES(key)
+    return pkcs7_unpad(b"".join(aes.decrypt_block(b) for b in blocks(data)))
+
+
+def cbc_encrypt(data: bytes, key: bytes, iv: bytes) -> bytes:
+    """CBC built on the block cipher: each plaintext block is XORed with
+    the previous ciphertext block before encryption."""
+    aes = AES(key)
+    out, prev = [], iv
+    for block in blocks(pkcs7_pad(data)):
+        prev = aes.encrypt_block(xor(block, prev))
+        out.append(prev)
+    return b"".join(out)
+
+
+def cbc_decrypt(data: bytes, key: bytes, iv: bytes) -> bytes:
+    aes = AES(key)
+    out, prev = [], iv
+    for block in blocks(data):
+        out.append(xor(aes.decrypt_block(block), prev))
+        prev = block
+    return pkcs7_unpad(b"".join(out))
+
+
+def has_repeated_block(data: bytes) -> bool:
+    """ECB encrypts equal blocks equally, which gives it away."""
+    chunks = blocks(data)
+    return len(set(chunks)) < len(chunks)
+
+
+def random_bytes(n: int) -> bytes:
+    return os.urandom(n)
+
+
+# --- Running challenges ----------------------------------------------------
+
+def expect(name: str, got, want) -> bool:
+    """Print a known-answer check and return whether it passed."""
+    ok = got == want
+    print(f"{'ok  ' if ok else 'FAIL'} {name}")
+    if not ok:
+        print(f"     got:  {got!r}\n     want: {want!r}")
+    return ok
+
+
+def read_input(name: str) -> str | None:
+    """Read a challenge's data file, saved from the Cryptopals site as
+    NN.in next to the script. Returns None if it is missing."""
+    try:
+        with open(name, "r") as file:
+            return file.read()
+    except FileNotFoundError:
+        print(f"skip {name} not found; save the challenge's data file there")
+        return None
@@ -0,0 +1,137 @@
+package main
+
+import (
+  "encoding/json"
+  "sort"
+  "sync"
+  "time"
+)
+
+// Challenge 3: broadcast. Every node must eventually hold every value
+// broadcast to any node, through partitions and with few messages.
+//
+// Values are gossiped to the node's neighbors in batches. For each
+// neighbor the node tracks the values it has not yet confirmed; a ticker
+// resends those until a gossip_ok arrives, which makes delivery survive
+// dropped messages and partitions, and batching keeps the message count
+// per broadcast well below one per neighbor.
+type broadcaster struct {
+  mu        sync.Mutex
+  values    map[int]bool
+  neighbors []string
+  pending   map[string]map[int]bool
+}
+
+func registerBroadcast(n *node, interval time.Duration) {
+  b := &broadcaster{values: map[int]bool{}, pending: map[string]map[int]bool{}}
+  // Until a topology arrives, gossip with every other node.
+  n.onInit = append(n.onInit, func(n *node) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    for _, id := range n.nodeIDs {
+      if id != n.id {
+        b.neighbors = append(b.neighbors, id)
+      }
+    }
+    n.every(interval, func() { b.gossip(n) })
+  })
+
+  n.handle("topology", func(n *node, m message) error {
+    var req struct {
+      Topology map[string][]string `json:"topology"`
+    }
+    if err := json.Unmarshal(m.Body, &req); err != nil {
+      return &rpcError{errMalformed, err.Error()}
+    }
+    b.mu.Lock()
+    b.neighbors = req.Topology[n.id]
+    b.mu.Unlock()
+    n.reply(m, map[string]any{"type": "topology_ok"})
+    return nil
+  })
+
+  n.handle("broadcast", func(n *node, m message) error {
+    var req struct {
+      Message int `json:"message"`
+    }
+    if err := json.Unmarshal(m.Body, &req); err != nil {
+      return &rpcError{errMalformed, err.Error()}
+    }
+    b.add(m.Src, []int{req.Message})
+    n.reply(m, map[string]any{"type": "broadcast_ok"})
+    return nil
+  })
+
+  n.handle("gossip", func(n *node, m message) error {
+    var req struct {
+      Messages []int `json:"messages"`
+    }
+    if err := json.Unmarshal(m.Body, &req); err != nil {
+      return &rpcError{errMalformed, err.Error()}
+    }
+    b.add(m.Src, req.Messages)
+    n.reply(m, map[string]any{"type": "gossip_ok"})
+    return nil
+  })
+
+  n.handle("read", func(n *node, m message) error {
+    b.mu.Lock()
+    values := make([]int, 0, len(b.values))
+    for v := range b.values {
+      values = append(values, v)
+    }
+    b.mu.Unlock()
+    sort.Ints(values)
+    n.reply(m, map[string]any{"type": "read_ok", "messages": values})
+    return nil
+  })
+}
+
+// Record values heard from src and queue the new ones for every neighbor
+// except src, which already has them.
+func (b *broadcaster) add(src string, values []int) {
+  b.mu.Lock()
+  defer b.mu.Unlock()
+  for _, v := range values {
+    if b.values[v] {
+      continue
+    }
+    b.values[v] = true
+    for _, nb := range b.neighbors {
+      if nb == src {
+        continue
+      }
+      if b.pending[nb] == nil {
+        b.pending[nb] = map[int]bool{}
+      }
+      b.pending[nb][v] = true
+    }
+  }
+}
+
+// Send each neighbor its unconfirmed values and clear the ones it confirms.
+// A batch that times out stays pending and goes again on a later tick.
+func (b *broadcaster) gossip(n *node) {
+  b.mu.Lock()
+  batches := map[string][]int{}
+  for nb, set := range b.pending {
+    for v := range set {
+      batches[nb] = append(batches[nb], v)
+    }
+  }
+  b.mu.Unlock()
+
+  for nb, batch := range batches {
+    go func() {
+      m, err := n.rpc(nb, map[string]any{"type": "gossip", "messages": batch}, time.Second)
+      if err != nil || m.header().Type != "gossip_ok" {
+        return
+      }
+      b.mu.Lock()
+      defer b.mu.Unlock()
+      for _, v := range batch {
+        delete(b.pending[nb], v)
+      }
+    }()
+  }
+}
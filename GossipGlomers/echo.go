@@ -0,0 +1,17 @@
+package main
+
+import "encoding/json"
+
+// Challenge 1: reply to each echo with the same text.
+func registerEcho(n *node) {
+  n.handle("echo", func(n *node, m message) error {
+    var req struct {
+      Echo json.RawMessage `json:"echo"`
+    }
+    if err := json.Unmarshal(m.Body, &req); err != nil {
+      return &rpcError{errMalformed, err.Error()}
+    }
+    n.reply(m, map[string]any{"type": "echo_ok", "echo": req.Echo})
+    return nil
+  })
+}
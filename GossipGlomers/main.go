@@ -0,0 +1,29 @@
+package main
+
+import (
+  "flag"
+  "fmt"
+  "os"
+  "time"
+)
+
+// One binary serves every workload: Maelstrom only sends a node the
+// message types of the workload under test, so the handlers do not clash.
+//
+//   maelstrom test -w echo --bin gossip --node-count 1 --time-limit 10
+//   maelstrom test -w broadcast --bin gossip --node-count 25 --nemesis partition
+//
+// "go test" checks the workloads against an in-process network instead.
+func main() {
+  interval := flag.Duration("interval", 100*time.Millisecond, "broadcast gossip interval")
+  flag.Parse()
+
+  n := newNode(nil)
+  registerEcho(n)
+  registerUniqueIDs(n)
+  registerBroadcast(n, *interval)
+  if err := n.run(os.Stdin, os.Stdout); err != nil {
+    fmt.Fprintln(os.Stderr, "gossip:", err)
+    os.Exit(1)
+  }
+}
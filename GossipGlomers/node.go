@@ -0,0 +1,230 @@
+package main
+
+import (
+  "bufio"
+  "encoding/json"
+  "fmt"
+  "io"
+  "os"
+  "sync"
+  "time"
+)
+
+// A message in the Maelstrom protocol. The body stays raw so each handler
+// can decode the fields of its own message type.
+type message struct {
+  Src  string          `json:"src"`
+  Dest string          `json:"dest"`
+  Body json.RawMessage `json:"body"`
+}
+
+// The fields every body has.
+type header struct {
+  Type      string `json:"type"`
+  MsgID     int    `json:"msg_id,omitempty"`
+  InReplyTo int    `json:"in_reply_to,omitempty"`
+}
+
+func (m message) header() header {
+  var h header
+  json.Unmarshal(m.Body, &h)
+  return h
+}
+
+// Error codes from the Maelstrom protocol that this runtime uses.
+const (
+  errTimeout      = 0
+  errNotSupported = 10
+  errMalformed    = 12
+  errCrash        = 13
+)
+
+// An rpcError returned by a handler is sent back as an error reply.
+type rpcError struct {
+  code int
+  text string
+}
+
+func (e *rpcError) Error() string {
+  return fmt.Sprintf("error %d: %s", e.code, e.text)
+}
+
+type handler func(n *node, m message) error
+
+// A node is the runtime shared by every workload: it answers init, routes
+// requests to handlers, matches replies to the callbacks of outstanding
+// RPCs, and writes messages through its transport. The transport is
+// stdout under Maelstrom and an in-process network in simulations.
+type node struct {
+  id      string
+  nodeIDs []string
+
+  handlers map[string]handler
+  onInit   []func(n *node)
+  out      func(message)
+
+  mu        sync.Mutex
+  nextMsgID int
+  callbacks map[int]func(message)
+
+  done chan struct{}
+  wg   sync.WaitGroup
+}
+
+func newNode(out func(message)) *node {
+  return &node{
+    handlers:  map[string]handler{},
+    out:       out,
+    callbacks: map[int]func(message){},
+    done:      make(chan struct{}),
+  }
+}
+
+// Register the handler for a request type.
+func (n *node) handle(typ string, h handler) {
+  n.handlers[typ] = h
+}
+
+// Write a message with the given msg_id. Body fields are given as a map,
+// with "type" among them.
+func (n *node) write(dest string, id int, body map[string]any) {
+  body["msg_id"] = id
+  data, _ := json.Marshal(body)
+  n.out(message{Src: n.id, Dest: dest, Body: data})
+}
+
+// Allocate a msg_id, registering callback for its reply if there is one.
+// Registering before sending means a fast reply cannot miss the callback.
+func (n *node) newMsgID(callback func(message)) int {
+  n.mu.Lock()
+  defer n.mu.Unlock()
+  n.nextMsgID++
+  if callback != nil {
+    n.callbacks[n.nextMsgID] = callback
+  }
+  return n.nextMsgID
+}
+
+func (n *node) send(dest string, body map[string]any) {
+  n.write(dest, n.newMsgID(nil), body)
+}
+
+func (n *node) reply(req message, body map[string]any) {
+  body["in_reply_to"] = req.header().MsgID
+  n.send(req.Src, body)
+}
+
+// Send a request and wait for its reply, giving up after timeout.
+func (n *node) rpc(dest string, body map[string]any, timeout time.Duration) (message, error) {
+  ch := make(chan message, 1)
+  id := n.newMsgID(func(m message) { ch <- m })
+  n.write(dest, id, body)
+  select {
+  case m := <-ch:
+    return m, nil
+  case <-time.After(timeout):
+    n.mu.Lock()
+    delete(n.callbacks, id)
+    n.mu.Unlock()
+    return message{}, &rpcError{errTimeout, "timed out waiting for " + dest}
+  }
+}
+
+// Run fn every interval until the node stops.
+func (n *node) every(interval time.Duration, fn func()) {
+  n.wg.Add(1)
+  go func() {
+    defer n.wg.Done()
+    t := time.NewTicker(interval)
+    defer t.Stop()
+    for {
+      select {
+      case <-n.done:
+        return
+      case <-t.C:
+        fn()
+      }
+    }
+  }()
+}
+
+// Handle one incoming message. Replies go to their callbacks; requests run
+// in their own goroutine so a handler waiting on an RPC does not block the
+// messages that would answer it.
+func (n *node) deliver(m message) {
+  h := m.header()
+  if h.InReplyTo != 0 {
+    n.mu.Lock()
+    cb := n.callbacks[h.InReplyTo]
+    delete(n.callbacks, h.InReplyTo)
+    n.mu.Unlock()
+    if cb != nil {
+      cb(m)
+    }
+    return
+  }
+  if h.Type == "init" {
+    var init struct {
+      NodeID  string   `json:"node_id"`
+      NodeIDs []string `json:"node_ids"`
+    }
+    json.Unmarshal(m.Body, &init)
+    n.id, n.nodeIDs = init.NodeID, init.NodeIDs
+    for _, fn := range n.onInit {
+      fn(n)
+    }
+    n.reply(m, map[string]any{"type": "init_ok"})
+    return
+  }
+  hd, ok := n.handlers[h.Type]
+  if !ok {
+    n.replyError(m, &rpcError{errNotSupported, "unsupported message type " + h.Type})
+    return
+  }
+  n.wg.Add(1)
+  go func() {
+    defer n.wg.Done()
+    if err := hd(n, m); err != nil {
+      n.replyError(m, err)
+    }
+  }()
+}
+
+func (n *node) replyError(req message, err error) {
+  e, ok := err.(*rpcError)
+  if !ok {
+    e = &rpcError{errCrash, err.Error()}
+  }
+  n.reply(req, map[string]any{"type": "error", "code": e.code, "text": e.text})
+}
+
+// Stop background work and wait for running handlers.
+func (n *node) stop() {
+  close(n.done)
+  n.wg.Wait()
+}
+
+// Serve Maelstrom: one JSON message per line on r, replies as lines on w.
+func (n *node) run(r io.Reader, w io.Writer) error {
+  var mu sync.Mutex
+  enc := json.NewEncoder(w)
+  n.out = func(m message) {
+    mu.Lock()
+    defer mu.Unlock()
+    if err := enc.Encode(m); err != nil {
+      fmt.Fprintln(os.Stderr, "gossip:", err)
+    }
+  }
+  s := bufio.NewScanner(r)
+  s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+  for s.Scan() {
+    var m message
+    if err := json.Unmarshal(s.Bytes(), &m); err != nil {
+      fmt.Fprintln(os.Stderr, "gossip: bad message:", err)
+      continue
+    }
+    n.deliver(m)
+  }
+  n.stop()
+  return s.Err()
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+  "encoding/json"
+  "flag"
+  "fmt"
+  "math/rand"
+  "sync"
+  "testing"
+  "time"
+)
+
+var seed = flag.Int64("seed", 1, "random seed for the simulated network")
+
+// An in-process network for checking workloads without Maelstrom. Nodes
+// and clients are both node runtimes whose transport is the network, which
+// delivers each message after a random latency and drops messages that
+// would cross a partition. Clients are never partitioned, as in Maelstrom.
+type network struct {
+  mu         sync.Mutex
+  rng        *rand.Rand
+  maxLatency time.Duration
+  nodes      map[string]*node
+  side       map[string]bool
+  ids        []string
+  sent       int
+}
+
+// Start count nodes, each set up by register, and a client, and send each
+// node its init message. The network is stopped when the test ends.
+func newNetwork(t *testing.T, count int, register func(*node), maxLatency time.Duration) (*network, *node) {
+  t.Helper()
+  nw := &network{
+    rng:        rand.New(rand.NewSource(*seed)),
+    maxLatency: maxLatency,
+    nodes:      map[string]*node{},
+  }
+  for i := 0; i < count; i++ {
+    nw.ids = append(nw.ids, fmt.Sprintf("n%d", i))
+  }
+  for _, id := range nw.ids {
+    n := newNode(nw.route)
+    register(n)
+    nw.nodes[id] = n
+  }
+  client := newNode(nw.route)
+  client.id = "c1"
+  nw.nodes[client.id] = client
+  t.Cleanup(nw.stop)
+
+  for _, id := range nw.ids {
+    m, err := client.rpc(id, map[string]any{"type": "init", "node_id": id, "node_ids": nw.ids}, time.Second)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if typ := m.header().Type; typ != "init_ok" {
+      t.Fatalf("%s answered init with %s", id, typ)
+    }
+  }
+  return nw, client
+}
+
+func isClient(id string) bool {
+  return id != "" && id[0] == 'c'
+}
+
+func (nw *network) route(m message) {
+  nw.mu.Lock()
+  delay := time.Duration(nw.rng.Int63n(int64(nw.maxLatency) + 1))
+  between := !isClient(m.Src) && !isClient(m.Dest)
+  if between {
+    nw.sent++
+  }
+  dropped := between && nw.side != nil && nw.side[m.Src] != nw.side[m.Dest]
+  dest := nw.nodes[m.Dest]
+  nw.mu.Unlock()
+  if dropped || dest == nil {
+    return
+  }
+  time.AfterFunc(delay, func() { dest.deliver(m) })
+}
+
+// Split the nodes into two random halves that cannot reach each other.
+func (nw *network) partition() {
+  nw.mu.Lock()
+  defer nw.mu.Unlock()
+  nw.side = map[string]bool{}
+  for _, id := range nw.ids {
+    nw.side[id] = nw.rng.Intn(2) == 0
+  }
+}
+
+func (nw *network) heal() {
+  nw.mu.Lock()
+  nw.side = nil
+  nw.mu.Unlock()
+}
+
+// Messages sent between nodes so far.
+func (nw *network) interNode() int {
+  nw.mu.Lock()
+  defer nw.mu.Unlock()
+  return nw.sent
+}
+
+func (nw *network) stop() {
+  for _, n := range nw.nodes {
+    n.stop()
+  }
+}
+
+func TestEcho(t *testing.T) {
+  nw, client := newNetwork(t, 3, registerEcho, 5*time.Millisecond)
+  for i := 0; i < 30; i++ {
+    text := fmt.Sprintf("Please echo %d", i)
+    dest := nw.ids[i%len(nw.ids)]
+    m, err := client.rpc(dest, map[string]any{"type": "echo", "echo": text}, time.Second)
+    if err != nil {
+      t.Fatal(err)
+    }
+    var reply struct {
+      Type string `json:"type"`
+      Echo string `json:"echo"`
+    }
+    json.Unmarshal(m.Body, &reply)
+    if reply.Type != "echo_ok" || reply.Echo != text {
+      t.Fatalf("%s answered %q with %s", dest, text, m.Body)
+    }
+  }
+}
+
+func TestUniqueIDs(t *testing.T) {
+  nw, client := newNetwork(t, 5, registerUniqueIDs, 5*time.Millisecond)
+  // IDs must stay unique while the nodes cannot talk to each other.
+  nw.partition()
+  const requests = 2000
+  ids := make([]string, requests)
+  errs := make([]error, requests)
+  var wg sync.WaitGroup
+  for i := 0; i < requests; i++ {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      m, err := client.rpc(nw.ids[i%len(nw.ids)], map[string]any{"type": "generate"}, 5*time.Second)
+      if err != nil {
+        errs[i] = err
+        return
+      }
+      var reply struct {
+        ID string `json:"id"`
+      }
+      json.Unmarshal(m.Body, &reply)
+      ids[i] = reply.ID
+    }()
+  }
+  wg.Wait()
+  seen := map[string]bool{}
+  for i, id := range ids {
+    if errs[i] != nil {
+      t.Fatal(errs[i])
+    }
+    if id == "" || seen[id] {
+      t.Fatalf("ID %q generated twice or empty", id)
+    }
+    seen[id] = true
+  }
+}
+
+// A square grid like Maelstrom's default topology: each node neighbors
+// the nodes beside and above or below it.
+func gridTopology(ids []string) map[string][]string {
+  side := 1
+  for side*side < len(ids) {
+    side++
+  }
+  topology := map[string][]string{}
+  for i, id := range ids {
+    for _, j := range []int{i - side, i + side, i - 1, i + 1} {
+      if j < 0 || j >= len(ids) || (j == i-1 || j == i+1) && j/side != i/side {
+        continue
+      }
+      topology[id] = append(topology[id], ids[j])
+    }
+  }
+  return topology
+}
+
+// Values broadcast while the network is partitioned reach every node once
+// it heals. This takes a second or more, so -short skips it.
+func TestBroadcast(t *testing.T) {
+  if testing.Short() {
+    t.Skip("waits for the network to converge")
+  }
+  register := func(n *node) { registerBroadcast(n, 100*time.Millisecond) }
+  nw, client := newNetwork(t, 25, register, 10*time.Millisecond)
+  topology := gridTopology(nw.ids)
+  for _, id := range nw.ids {
+    if _, err := client.rpc(id, map[string]any{"type": "topology", "topology": topology}, time.Second); err != nil {
+      t.Fatal(err)
+    }
+  }
+
+  // Broadcast while partitioned, then heal and wait for every node to
+  // converge on every value.
+  nw.partition()
+  const broadcasts = 200
+  before := nw.interNode()
+  rng := rand.New(rand.NewSource(*seed))
+  var wg sync.WaitGroup
+  errs := make(chan error, broadcasts)
+  for v := 0; v < broadcasts; v++ {
+    dest := nw.ids[rng.Intn(len(nw.ids))]
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      m, err := client.rpc(dest, map[string]any{"type": "broadcast", "message": v}, time.Second)
+      if err == nil && m.header().Type != "broadcast_ok" {
+        err = fmt.Errorf("%s answered broadcast with %s", dest, m.Body)
+      }
+      if err != nil {
+        errs <- err
+      }
+    }()
+  }
+  wg.Wait()
+  close(errs)
+  if err := <-errs; err != nil {
+    t.Fatal(err)
+  }
+  time.Sleep(500 * time.Millisecond)
+  nw.heal()
+
+  start := time.Now()
+  for _, id := range nw.ids {
+    for {
+      m, err := client.rpc(id, map[string]any{"type": "read"}, time.Second)
+      if err != nil {
+        t.Fatal(err)
+      }
+      var reply struct {
+        Messages []int `json:"messages"`
+      }
+      json.Unmarshal(m.Body, &reply)
+      if len(reply.Messages) == broadcasts {
+        break
+      }
+      if time.Since(start) > 20*time.Second {
+        t.Fatalf("%s has %d of %d values after healing", id, len(reply.Messages), broadcasts)
+      }
+      time.Sleep(50 * time.Millisecond)
+    }
+  }
+  perOp := float64(nw.interNode()-before) / broadcasts
+  t.Logf("%d broadcasts to 25 nodes converged %v after healing, %.1f messages per broadcast",
+    broadcasts, time.Since(start).Round(time.Millisecond), perOp)
+}
@@ -0,0 +1,28 @@
+NUMBER = 1
+TITLE = "Two Sum"
+DIFFICULTY = "Easy"
+TAGS = ["array", "hash-table"]
+METHOD = "twoSum"
+
+
+class Solution:
+    def twoSum(self, nums: list[int], target: int) -> list[int]:
+        """One pass, remembering where each value was seen."""
+        seen = {}
+        for i, n in enumerate(nums):
+            if target - n in seen:
+                return [seen[target - n], i]
+            seen[n] = i
+        return []
+
+
+CASES = [
+    (([2, 7, 11, 15], 9), [0, 1]),
+    (([3, 2, 4], 6), [1, 2]),
+    (([3, 3], 6), [0, 1]),
+    (([-1, -2, -3, -4, -5], -8), [2, 4]),
+]
+
+
+def same(result: list[int], expected: list[int]) -> bool:
+    return sorted(result) == sorted(expected)
@@ -0,0 +1,29 @@
+NUMBER = 3
+TITLE = "Longest Substring Without Repeating Characters"
+DIFFICULTY = "Medium"
+TAGS = ["string", "hash-table", "sliding-window"]
+METHOD = "lengthOfLongestSubstring"
+
+
+class Solution:
+    def lengthOfLongestSubstring(self, s: str) -> int:
+        """Slide a window, jumping its start past the previous occurrence of
+        a repeated character."""
+        last = {}
+        start = best = 0
+        for i, c in enumerate(s):
+            if last.get(c, -1) >= start:
+                start = last[c] + 1
+            last[c] = i
+            best = max(best, i - start + 1)
+        return best
+
+
+CASES = [
+    (("abcabcbb",), 3),
+    (("bbbbb",), 1),
+    (("pwwkew",), 3),
+    (("",), 0),
+    (("abba",), 2),
+    (("dvdf",), 3),
+]
@@ -0,0 +1,43 @@
+NUMBER = 4
+TITLE = "Median of Two Sorted Arrays"
+DIFFICULTY = "Hard"
+TAGS = ["array", "binary-search", "divide-and-conquer"]
+METHOD = "findMedianSortedArrays"
+
+
+class Solution:
+    def findMedianSortedArrays(self, nums1: list[int], nums2: list[int]) -> float:
+        """Binary search for a split of the shorter array such that
+        everything left of both splits is no greater than everything right
+        of them, in O(log(min(m, n)))."""
+        if len(nums1) > len(nums2):
+            nums1, nums2 = nums2, nums1
+        m, n = len(nums1), len(nums2)
+        half = (m + n + 1) // 2
+        lo, hi = 0, m
+        while lo <= hi:
+            i = (lo + hi) // 2
+            j = half - i
+            left1 = nums1[i - 1] if i > 0 else float("-inf")
+            right1 = nums1[i] if i < m else float("inf")
+            left2 = nums2[j - 1] if j > 0 else float("-inf")
+            right2 = nums2[j] if j < n else float("inf")
+            if left1 > right2:
+                hi = i - 1
+            elif left2 > right1:
+                lo = i + 1
+            else:
+                left = max(left1, left2)
+                if (m + n) % 2:
+                    return float(left)
+                return (left + min(right1, right2)) / 2
+        return 0.0
+
+
+CASES = [
+    (([1, 3], [2]), 2.0),
+    (([1, 2], [3, 4]), 2.5),
+    (([], [1]), 1.0),
+    (([2], []), 2.0),
+    (([1, 2, 3, 4, 5], [6, 7, 8, 9, 10, 11]), 6.0),
+]
@@ -0,0 +1,44 @@
+NUMBER = 15
+TITLE = "3Sum"
+DIFFICULTY = "Medium"
+TAGS = ["array", "two-pointers", "sorting"]
+METHOD = "threeSum"
+
+
+class Solution:
+    def threeSum(self, nums: list[int]) -> list[list[int]]:
+        """Sort, fix the smallest element, then close in from both ends,
+        skipping duplicates at every level."""
+        nums.sort()
+        triplets = []
+        for i in range(len(nums) - 2):
+            if nums[i] > 0:
+                break
+            if i > 0 and nums[i] == nums[i - 1]:
+                continue
+            lo, hi = i + 1, len(nums) - 1
+            while lo < hi:
+                total = nums[i] + nums[lo] + nums[hi]
+                if total < 0:
+                    lo += 1
+                elif total > 0:
+                    hi -= 1
+                else:
+                    triplets.append([nums[i], nums[lo], nums[hi]])
+                    while lo < hi and nums[lo] == nums[lo + 1]:
+                        lo += 1
+                    lo, hi = lo + 1, hi - 1
+        return triplets
+
+
+CASES = [
+    (([-1, 0, 1, 2, -1, -4],), [[-1, -1, 2], [-1, 0, 1]]),
+    (([0, 1, 1],), []),
+    (([0, 0, 0],), [[0, 0, 0]]),
+    (([0, 0, 0, 0],), [[0, 0, 0]]),
+    (([-2, 0, 1, 1, 2],), [[-2, 0, 2], [-2, 1, 1]]),
+]
+
+
+def same(result: list[list[int]], expected: list[list[int]]) -> bool:
+    return sorted(map(sorted, result)) == sorted(map(sorted, expected))
@@ -0,0 +1,29 @@
+NUMBER = 20
+TITLE = "Valid Parentheses"
+DIFFICULTY = "Easy"
+TAGS = ["string", "stack"]
+METHOD = "isValid"
+
+
+class Solution:
+    def isValid(self, s: str) -> bool:
+        pairs = {")": "(", "]": "[", "}": "{"}
+        stack = []
+        for c in s:
+            if c in pairs:
+                if not stack or stack.pop() != pairs[c]:
+                    return False
+            else:
+                stack.append(c)
+        return not stack
+
+
+CASES = [
+    (("()",), True),
+    (("()[]{}",), True),
+    (("(]",), False),
+    (("([)]",), False),
+    (("{[]}",), True),
+    (("(",), False),
+    ((")",), False),
+]
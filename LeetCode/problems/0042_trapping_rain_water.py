@@ -0,0 +1,31 @@
+NUMBER = 42
+TITLE = "Trapping Rain Water"
+DIFFICULTY = "Hard"
+TAGS = ["array", "two-pointers", "dynamic-programming", "stack"]
+METHOD = "trap"
+
+
+class Solution:
+    def trap(self, height: list[int]) -> int:
+        """Two pointers: the lower side's water level is bounded by its own
+        running maximum, since the other side is known to be higher."""
+        lo, hi = 0, len(height) - 1
+        left_max = right_max = water = 0
+        while lo < hi:
+            if height[lo] < height[hi]:
+                left_max = max(left_max, height[lo])
+                water += left_max - height[lo]
+                lo += 1
+            else:
+                right_max = max(right_max, height[hi])
+                water += right_max - height[hi]
+                hi -= 1
+        return water
+
+
+CASES = [
+    (([0, 1, 0, 2, 1, 0, 1, 3, 2, 1, 2, 1],), 6),
+    (([4, 2, 0, 3, 2, 5],), 9),
+    (([],), 0),
+    (([5, 4, 3],), 0),
+]
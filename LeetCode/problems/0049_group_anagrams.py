@@ -0,0 +1,27 @@
+from collections import defaultdict
+
+NUMBER = 49
+TITLE = "Group Anagrams"
+DIFFICULTY = "Medium"
+TAGS = ["array", "hash-table", "string", "sorting"]
+METHOD = "groupAnagrams"
+
+
+class Solution:
+    def groupAnagrams(self, strs: list[str]) -> list[list[str]]:
+        groups = defaultdict(list)
+        for s in strs:
+            groups["".join(sorted(s))].append(s)
+        return list(groups.values())
+
+
+CASES = [
+    ((["eat", "tea", "tan", "ate", "nat", "bat"],),
+     [["bat"], ["nat", "tan"], ["ate", "eat", "tea"]]),
+    (([""],), [[""]]),
+    ((["a"],), [["a"]]),
+]
+
+
+def same(result: list[list[str]], expected: list[list[str]]) -> bool:
+    return sorted(map(sorted, result)) == sorted(map(sorted, expected))
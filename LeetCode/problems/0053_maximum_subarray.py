@@ -0,0 +1,23 @@
+NUMBER = 53
+TITLE = "Maximum Subarray"
+DIFFICULTY = "Medium"
+TAGS = ["array", "divide-and-conquer", "dynamic-programming"]
+METHOD = "maxSubArray"
+
+
+class Solution:
+    def maxSubArray(self, nums: list[int]) -> int:
+        """Kadane's algorithm: extend the current run or start afresh."""
+        best = current = nums[0]
+        for n in nums[1:]:
+            current = max(n, current + n)
+            best = max(best, current)
+        return best
+
+
+CASES = [
+    (([-2, 1, -3, 4, -1, 2, 1, -5, 4],), 6),
+    (([1],), 1),
+    (([5, 4, -1, 7, 8],), 23),
+    (([-3, -1, -2],), -1),
+]
@@ -0,0 +1,23 @@
+NUMBER = 70
+TITLE = "Climbing Stairs"
+DIFFICULTY = "Easy"
+TAGS = ["math", "dynamic-programming", "memoization"]
+METHOD = "climbStairs"
+
+
+class Solution:
+    def climbStairs(self, n: int) -> int:
+        """The ways to reach step n are the Fibonacci numbers."""
+        a, b = 1, 1
+        for _ in range(n):
+            a, b = b, a + b
+        return a
+
+
+CASES = [
+    ((1,), 1),
+    ((2,), 2),
+    ((3,), 3),
+    ((5,), 8),
+    ((45,), 1836311903),
+]
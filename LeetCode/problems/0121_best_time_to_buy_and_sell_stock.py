@@ -0,0 +1,22 @@
+NUMBER = 121
+TITLE = "Best Time to Buy and Sell Stock"
+DIFFICULTY = "Easy"
+TAGS = ["array", "dynamic-programming"]
+METHOD = "maxProfit"
+
+
+class Solution:
+    def maxProfit(self, prices: list[int]) -> int:
+        lowest = float("inf")
+        best = 0
+        for price in prices:
+            lowest = min(lowest, price)
+            best = max(best, price - lowest)
+        return best
+
+
+CASES = [
+    (([7, 1, 5, 3, 6, 4],), 5),
+    (([7, 6, 4, 3, 1],), 0),
+    (([2, 4, 1],), 2),
+]
@@ -0,0 +1,41 @@
+from collections import deque
+
+NUMBER = 200
+TITLE = "Number of Islands"
+DIFFICULTY = "Medium"
+TAGS = ["array", "breadth-first-search", "depth-first-search", "matrix", "union-find"]
+METHOD = "numIslands"
+
+
+class Solution:
+    def numIslands(self, grid: list[list[str]]) -> int:
+        """Flood fill each unvisited piece of land, sinking it as we go."""
+        rows, cols = len(grid), len(grid[0])
+        islands = 0
+        for r in range(rows):
+            for c in range(cols):
+                if grid[r][c] != "1":
+                    continue
+                islands += 1
+                grid[r][c] = "0"
+                queue = deque([(r, c)])
+                while queue:
+                    y, x = queue.popleft()
+                    for ny, nx in ((y + 1, x), (y - 1, x), (y, x + 1), (y, x - 1)):
+                        if 0 <= ny < rows and 0 <= nx < cols and grid[ny][nx] == "1":
+                            grid[ny][nx] = "0"
+                            queue.append((ny, nx))
+        return islands
+
+
+CASES = [
+    (([["1", "1", "1", "1", "0"],
+       ["1", "1", "0", "1", "0"],
+       ["1", "1", "0", "0", "0"],
+       ["0", "0", "0", "0", "0"]],), 1),
+    (([["1", "1", "0", "0", "0"],
+       ["1", "1", "0", "0", "0"],
+       ["0", "0", "1", "0", "0"],
+       ["0", "0", "0", "1", "1"]],), 3),
+    (([["0"]],), 0),
+]
@@ -0,0 +1,25 @@
+NUMBER = 322
+TITLE = "Coin Change"
+DIFFICULTY = "Medium"
+TAGS = ["array", "dynamic-programming", "breadth-first-search"]
+METHOD = "coinChange"
+
+
+class Solution:
+    def coinChange(self, coins: list[int], amount: int) -> int:
+        """Fewest coins for every amount up to the target, bottom up."""
+        unreachable = amount + 1
+        fewest = [0] + [unreachable] * amount
+        for a in range(1, amount + 1):
+            for coin in coins:
+                if coin <= a:
+                    fewest[a] = min(fewest[a], fewest[a - coin] + 1)
+        return fewest[amount] if fewest[amount] != unreachable else -1
+
+
+CASES = [
+    (([1, 2, 5], 11), 3),
+    (([2], 3), -1),
+    (([1], 0), 0),
+    (([186, 419, 83, 408], 6249), 20),
+]
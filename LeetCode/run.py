@@ -0,0 +1,100 @@
+#!/usr/bin/env python
+"""
+List and check LeetCode solutions.
+
+Each problem lives in problems/NNNN_slug.py and defines:
+
+    NUMBER, TITLE, DIFFICULTY  the problem's number, title and difficulty
+    TAGS                       a list of topic tags
+    Solution                   the class with LeetCode's method signature
+    METHOD                     the name of the method to call
+    CASES                      a list of (arguments, expected) pairs
+
+and optionally same(result, expected) for problems that accept answers in
+any order.
+
+    ./run.py list [--tag TAG] [--difficulty LEVEL]
+    ./run.py test [NUMBER ...] [--tag TAG] [--difficulty LEVEL]
+"""
+
+import argparse
+import copy
+import importlib.util
+import os
+import sys
+
+PROBLEMS = os.path.join(os.path.dirname(os.path.abspath(__file__)), "problems")
+
+
+def load_problems() -> list:
+    """Import every problem module, sorted by problem number."""
+    problems = []
+    for name in sorted(os.listdir(PROBLEMS)):
+        if not name.endswith(".py") or name.startswith("_"):
+            continue
+        spec = importlib.util.spec_from_file_location(
+                name[:-3], os.path.join(PROBLEMS, name))
+        module = importlib.util.module_from_spec(spec)
+        spec.loader.exec_module(module)
+        problems.append(module)
+    return sorted(problems, key=lambda p: p.NUMBER)
+
+
+def select(problems: list, args) -> list:
+    """Filter problems by number, tag and difficulty."""
+    if args.numbers:
+        problems = [p for p in problems if p.NUMBER in args.numbers]
+    if args.tag:
+        problems = [p for p in problems if args.tag.lower() in p.TAGS]
+    if args.difficulty:
+        problems = [p for p in problems
+                    if p.DIFFICULTY.lower() == args.difficulty.lower()]
+    return problems
+
+
+def run_cases(problem) -> list:
+    """Run a problem's cases, returning a description of each failure."""
+    failures = []
+    same = getattr(problem, "same", lambda a, b: a == b)
+    for i, (arguments, expected) in enumerate(problem.CASES, 1):
+        # Solutions may modify their inputs in place, as LeetCode allows.
+        arguments = copy.deepcopy(arguments)
+        method = getattr(problem.Solution(), problem.METHOD)
+        try:
+            result = method(*arguments)
+        except Exception as err:
+            failures.append(f"case {i}: raised {err!r}")
+            continue
+        if not same(result, expected):
+            failures.append(f"case {i}: got {result!r}, want {expected!r}")
+    return failures
+
+
+def main() -> int:
+    parser = argparse.ArgumentParser(description="List and check LeetCode solutions.")
+    parser.add_argument("command", choices=["list", "test"])
+    parser.add_argument("numbers", nargs="*", type=int, help="problem numbers")
+    parser.add_argument("--tag", help="only problems with this tag")
+    parser.add_argument("--difficulty", help="only Easy, Medium or Hard problems")
+    args = parser.parse_args()
+
+    problems = select(load_problems(), args)
+    if args.command == "list":
+        for p in problems:
+            print(f"{p.NUMBER:>4}  {p.DIFFICULTY:<6}  {p.TITLE:<47} {', '.join(p.TAGS)}")
+        return 0
+
+    failed = 0
+    for p in problems:
+        failures = run_cases(p)
+        status = "FAIL" if failures else "ok"
+        print(f"{status:<4}  {p.NUMBER:>4}. {p.TITLE} ({len(p.CASES)} cases)")
+        for failure in failures:
+            print("        " + failure)
+        failed += bool(failures)
+    print(f"\n{len(problems) - failed} passed, {failed} failed")
+    return 1 if failed else 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -0,0 +1,12 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Sum of all the multiples of 3 or 5 below 1000."""
+    return sum(n for n in range(1000) if n % 3 == 0 or n % 5 == 0)
+
+
+if __name__ == "__main__":
+    check(solve(), "e1edf9d1967ca96767dcc2b2d6df69f4")
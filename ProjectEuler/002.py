@@ -0,0 +1,17 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Sum of the even Fibonacci numbers not exceeding four million."""
+    total, a, b = 0, 1, 2
+    while a <= 4_000_000:
+        if a % 2 == 0:
+            total += a
+        a, b = b, a + b
+    return total
+
+
+if __name__ == "__main__":
+    check(solve(), "4194eb91842c8e7e6df099ca73c38f28")
@@ -0,0 +1,12 @@
+#!/usr/bin/env python
+
+from euler import check, factorize
+
+
+def solve() -> int:
+    """Largest prime factor of 600851475143."""
+    return max(factorize(600851475143))
+
+
+if __name__ == "__main__":
+    check(solve(), "94c4dd41f9dddce696557d3717d98d82")
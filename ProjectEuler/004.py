@@ -0,0 +1,22 @@
+#!/usr/bin/env python
+
+from euler import check, is_palindrome
+
+
+def solve() -> int:
+    """Largest palindrome made from the product of two 3-digit numbers."""
+    best = 0
+    for a in range(999, 99, -1):
+        if a * 999 <= best:
+            break
+        for b in range(999, a - 1, -1):
+            product = a * b
+            if product <= best:
+                break
+            if is_palindrome(product):
+                best = product
+    return best
+
+
+if __name__ == "__main__":
+    check(solve(), "d4cfc27d16ea72a96b83d9bdef6ce2ec")
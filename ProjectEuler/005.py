@@ -0,0 +1,14 @@
+#!/usr/bin/env python
+
+from functools import reduce
+
+from euler import check, lcm
+
+
+def solve() -> int:
+    """Smallest number evenly divisible by all of 1 to 20."""
+    return reduce(lcm, range(1, 21))
+
+
+if __name__ == "__main__":
+    check(solve(), "bc0d0a22a7a46212135ed0ba77d22f3a")
@@ -0,0 +1,13 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Square of the sum minus the sum of the squares of 1 to 100."""
+    numbers = range(1, 101)
+    return sum(numbers) ** 2 - sum(n * n for n in numbers)
+
+
+if __name__ == "__main__":
+    check(solve(), "867380888952c39a131fe1d832246ecc")
@@ -0,0 +1,13 @@
+#!/usr/bin/env python
+
+from euler import check, primes_below
+
+
+def solve() -> int:
+    """The 10001st prime. By the prime number theorem it is below
+    n(ln n + ln ln n) for n >= 6, which bounds the sieve."""
+    return primes_below(120_000)[10_000]
+
+
+if __name__ == "__main__":
+    check(solve(), "8c32ab09ec0210af60d392e9b2009560")
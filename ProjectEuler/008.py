@@ -0,0 +1,21 @@
+#!/usr/bin/env python
+
+from math import prod
+
+from euler import check, open_input
+
+
+def read_puzzle_input() -> str:
+    """The 1000-digit number from the problem page, split over lines."""
+    with open_input("008.in") as file:
+        return "".join(file.read().split())
+
+
+def solve(number: str) -> int:
+    """Greatest product of thirteen adjacent digits."""
+    return max(prod(map(int, number[i:i + 13]))
+               for i in range(len(number) - 12))
+
+
+if __name__ == "__main__":
+    check(solve(read_puzzle_input()), "0f53ea7949d32ef24f9186207600403c")
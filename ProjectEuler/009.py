@@ -0,0 +1,20 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Product abc of the Pythagorean triplet with a + b + c = 1000.
+    Substituting c = 1000 - a - b into a^2 + b^2 = c^2 gives b directly."""
+    for a in range(1, 333):
+        numerator = 1000 * (500 - a)
+        if numerator % (1000 - a) == 0:
+            b = numerator // (1000 - a)
+            c = 1000 - a - b
+            if a < b < c:
+                return a * b * c
+    return 0
+
+
+if __name__ == "__main__":
+    check(solve(), "24eaa9820350012ff678de47cb85b639")
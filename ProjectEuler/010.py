@@ -0,0 +1,12 @@
+#!/usr/bin/env python
+
+from euler import check, primes_below
+
+
+def solve() -> int:
+    """Sum of all the primes below two million."""
+    return sum(primes_below(2_000_000))
+
+
+if __name__ == "__main__":
+    check(solve(), "d915b2a9ac8749a6b837404815f1ae25")
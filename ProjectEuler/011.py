@@ -0,0 +1,30 @@
+#!/usr/bin/env python
+
+from euler import check, open_input
+
+
+def read_puzzle_input() -> list[list[int]]:
+    """The 20x20 grid from the problem page."""
+    with open_input("011.in") as file:
+        return [list(map(int, line.split())) for line in file if line.strip()]
+
+
+def solve(grid: list[list[int]]) -> int:
+    """Greatest product of four adjacent numbers in any direction."""
+    size = len(grid)
+    best = 0
+    for y in range(size):
+        for x in range(size):
+            for dx, dy in ((1, 0), (0, 1), (1, 1), (1, -1)):
+                end_x, end_y = x + 3 * dx, y + 3 * dy
+                if not (0 <= end_x < size and 0 <= end_y < size):
+                    continue
+                product = 1
+                for i in range(4):
+                    product *= grid[y + i * dy][x + i * dx]
+                best = max(best, product)
+    return best
+
+
+if __name__ == "__main__":
+    check(solve(read_puzzle_input()), "678f5d2e1eaa42f04fa53411b4f441ac")
@@ -0,0 +1,22 @@
+#!/usr/bin/env python
+
+from euler import check, divisor_count
+
+
+def solve() -> int:
+    """First triangle number with over five hundred divisors. n and n + 1
+    are coprime, so the divisor count of n(n + 1)/2 is the product of the
+    counts of its two coprime halves."""
+    n = 1
+    while True:
+        if n % 2 == 0:
+            count = divisor_count(n // 2) * divisor_count(n + 1)
+        else:
+            count = divisor_count(n) * divisor_count((n + 1) // 2)
+        if count > 500:
+            return n * (n + 1) // 2
+        n += 1
+
+
+if __name__ == "__main__":
+    check(solve(), "8091de7d285989bbfa9a2f9f3bdcc7c0")
@@ -0,0 +1,18 @@
+#!/usr/bin/env python
+
+from euler import check, open_input
+
+
+def read_puzzle_input() -> list[int]:
+    """The one hundred 50-digit numbers from the problem page."""
+    with open_input("013.in") as file:
+        return [int(line) for line in file if line.strip()]
+
+
+def solve(numbers: list[int]) -> int:
+    """First ten digits of the sum."""
+    return int(str(sum(numbers))[:10])
+
+
+if __name__ == "__main__":
+    check(solve(read_puzzle_input()), "361113f19fd302adc31268f8283a4f2d")
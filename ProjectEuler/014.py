@@ -0,0 +1,26 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Starting number under one million with the longest Collatz chain,
+    caching chain lengths for the numbers below the limit."""
+    limit = 1_000_000
+    lengths = [0] * limit
+    lengths[1] = 1
+    best, best_start = 1, 1
+    for start in range(2, limit):
+        n, steps = start, 0
+        while n >= limit or lengths[n] == 0:
+            n = n // 2 if n % 2 == 0 else 3 * n + 1
+            steps += 1
+        length = steps + lengths[n]
+        lengths[start] = length
+        if length > best:
+            best, best_start = length, start
+    return best_start
+
+
+if __name__ == "__main__":
+    check(solve(), "5052c3765262bb2c6be537abd60b305e")
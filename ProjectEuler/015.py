@@ -0,0 +1,13 @@
+#!/usr/bin/env python
+
+from euler import check, comb
+
+
+def solve() -> int:
+    """Lattice paths through a 20x20 grid: choose which 20 of the 40 moves
+    go right."""
+    return comb(40, 20)
+
+
+if __name__ == "__main__":
+    check(solve(), "928f3957168ac592c4215dcd04e0b678")
@@ -0,0 +1,12 @@
+#!/usr/bin/env python
+
+from euler import check, digits
+
+
+def solve() -> int:
+    """Sum of the digits of 2^1000."""
+    return sum(digits(2 ** 1000))
+
+
+if __name__ == "__main__":
+    check(solve(), "6a5889bb0190d0211a991f47bb19a777")
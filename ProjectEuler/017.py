@@ -0,0 +1,31 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+ONES = ["", "one", "two", "three", "four", "five", "six", "seven", "eight",
+        "nine", "ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen",
+        "sixteen", "seventeen", "eighteen", "nineteen"]
+TENS = ["", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy",
+        "eighty", "ninety"]
+
+
+def words(n: int) -> str:
+    """British English for 1 <= n <= 1000, without spaces or hyphens."""
+    if n == 1000:
+        return "onethousand"
+    if n >= 100:
+        rest = n % 100
+        return ONES[n // 100] + "hundred" + ("and" + words(rest) if rest else "")
+    if n >= 20:
+        return TENS[n // 10] + ONES[n % 10]
+    return ONES[n]
+
+
+def solve() -> int:
+    """Letters used writing out the numbers 1 to 1000 in words."""
+    return sum(len(words(n)) for n in range(1, 1001))
+
+
+if __name__ == "__main__":
+    check(solve(), "6a979d4a9cf85135408529edc8a133d0")
@@ -0,0 +1,23 @@
+#!/usr/bin/env python
+
+from euler import check, open_input
+
+
+def read_puzzle_input() -> list[list[int]]:
+    """The triangle from the problem page, one row per line."""
+    with open_input("018.in") as file:
+        return [list(map(int, line.split())) for line in file if line.strip()]
+
+
+def solve(triangle: list[list[int]]) -> int:
+    """Maximum path sum from top to bottom, collapsing rows from the bottom
+    up so each cell holds the best sum below it. Problem 67 is the same with
+    a larger triangle."""
+    best = triangle[-1][:]
+    for row in reversed(triangle[:-1]):
+        best = [value + max(best[i], best[i + 1]) for i, value in enumerate(row)]
+    return best[0]
+
+
+if __name__ == "__main__":
+    check(solve(read_puzzle_input()), "708f3cf8100d5e71834b1db77dfa15d6")
@@ -0,0 +1,16 @@
+#!/usr/bin/env python
+
+from datetime import date
+
+from euler import check
+
+
+def solve() -> int:
+    """Sundays that fell on the first of the month during the twentieth
+    century."""
+    return sum(date(year, month, 1).weekday() == 6
+               for year in range(1901, 2001) for month in range(1, 13))
+
+
+if __name__ == "__main__":
+    check(solve(), "a4a042cf4fd6bfb47701cbc8a1653ada")
@@ -0,0 +1,12 @@
+#!/usr/bin/env python
+
+from euler import check, digits, factorial
+
+
+def solve() -> int:
+    """Sum of the digits of 100!."""
+    return sum(digits(factorial(100)))
+
+
+if __name__ == "__main__":
+    check(solve(), "443cb001c138b2561a0d90720d6ce111")
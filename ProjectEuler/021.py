@@ -0,0 +1,14 @@
+#!/usr/bin/env python
+
+from euler import check, divisor_sums
+
+
+def solve() -> int:
+    """Sum of the amicable numbers under 10000."""
+    sums = divisor_sums(10_000)
+    return sum(a for a in range(2, 10_000)
+               if (b := sums[a]) != a and b < 10_000 and sums[b] == a)
+
+
+if __name__ == "__main__":
+    check(solve(), "51e04cd4e55e7e415bf24de9e1b0f3ff")
@@ -0,0 +1,22 @@
+#!/usr/bin/env python
+
+from euler import check, open_input
+
+
+def read_puzzle_input() -> list[str]:
+    """names.txt from the problem page: quoted, comma-separated names."""
+    with open_input("022.in") as file:
+        return [name.strip('"') for name in file.read().strip().split(",")]
+
+
+def score(name: str) -> int:
+    return sum(ord(c) - ord("A") + 1 for c in name)
+
+
+def solve(names: list[str]) -> int:
+    """Total of the alphabetical position times the letter score."""
+    return sum(i * score(name) for i, name in enumerate(sorted(names), 1))
+
+
+if __name__ == "__main__":
+    check(solve(read_puzzle_input()), "f2c9c91cb025746f781fa4db8be3983f")
@@ -0,0 +1,22 @@
+#!/usr/bin/env python
+
+from euler import check, divisor_sums
+
+
+def solve() -> int:
+    """Sum of the positive integers that are not the sum of two abundant
+    numbers. Every integer above 28123 is such a sum."""
+    limit = 28_124
+    sums = divisor_sums(limit)
+    abundant = [n for n in range(12, limit) if sums[n] > n]
+    expressible = [False] * limit
+    for i, a in enumerate(abundant):
+        for b in abundant[i:]:
+            if a + b >= limit:
+                break
+            expressible[a + b] = True
+    return sum(n for n in range(limit) if not expressible[n])
+
+
+if __name__ == "__main__":
+    check(solve(), "2c8258c0604152962f7787571511cf28")
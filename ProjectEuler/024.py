@@ -0,0 +1,12 @@
+#!/usr/bin/env python
+
+from euler import check, nth_permutation
+
+
+def solve() -> int:
+    """Millionth lexicographic permutation of the digits 0 to 9."""
+    return int("".join(map(str, nth_permutation(list(range(10)), 999_999))))
+
+
+if __name__ == "__main__":
+    check(solve(), "7f155b45cb3f0a6e518d59ec348bff84")
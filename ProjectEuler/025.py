@@ -0,0 +1,16 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Index of the first Fibonacci number with 1000 digits."""
+    a, b, index = 1, 1, 2
+    while b < 10 ** 999:
+        a, b = b, a + b
+        index += 1
+    return index
+
+
+if __name__ == "__main__":
+    check(solve(), "a376802c0811f1b9088828288eb0d3f0")
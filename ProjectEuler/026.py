@@ -0,0 +1,24 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def cycle_length(d: int) -> int:
+    """Length of the recurring cycle of 1/d, found by long division until
+    a remainder repeats."""
+    seen = {}
+    remainder, position = 1, 0
+    while remainder and remainder not in seen:
+        seen[remainder] = position
+        remainder = remainder * 10 % d
+        position += 1
+    return position - seen[remainder] if remainder else 0
+
+
+def solve() -> int:
+    """d < 1000 for which 1/d has the longest recurring cycle."""
+    return max(range(2, 1000), key=cycle_length)
+
+
+if __name__ == "__main__":
+    check(solve(), "6aab1270668d8cac7cef2566a1c5f569")
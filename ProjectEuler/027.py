@@ -0,0 +1,21 @@
+#!/usr/bin/env python
+
+from euler import check, is_prime, primes_below
+
+
+def solve() -> int:
+    """Product of a and b for the quadratic n^2 + an + b producing the most
+    consecutive primes from n = 0. With n = 0, b must itself be prime."""
+    best, product = 0, 0
+    for b in primes_below(1001):
+        for a in range(-999, 1000):
+            n = 0
+            while is_prime(n * n + a * n + b):
+                n += 1
+            if n > best:
+                best, product = n, a * b
+    return product
+
+
+if __name__ == "__main__":
+    check(solve(), "69d9e3218fd7abb6ff453ea96505183d")
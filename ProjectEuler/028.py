@@ -0,0 +1,13 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Sum of the diagonals of a 1001x1001 number spiral. The ring of side
+    s has corners s^2, s^2 - (s-1), s^2 - 2(s-1) and s^2 - 3(s-1)."""
+    return 1 + sum(4 * s * s - 6 * (s - 1) for s in range(3, 1002, 2))
+
+
+if __name__ == "__main__":
+    check(solve(), "0d53425bd7c5bf9919df3718c8e49fa6")
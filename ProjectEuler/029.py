@@ -0,0 +1,12 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Distinct terms of a^b for 2 <= a, b <= 100."""
+    return len({a ** b for a in range(2, 101) for b in range(2, 101)})
+
+
+if __name__ == "__main__":
+    check(solve(), "6f0ca67289d79eb35d19decbc0a08453")
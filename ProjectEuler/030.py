@@ -0,0 +1,15 @@
+#!/usr/bin/env python
+
+from euler import check, digits
+
+
+def solve() -> int:
+    """Sum of the numbers that equal the sum of fifth powers of their
+    digits. Seven digits can sum to at most 7 * 9^5, which has only six, so
+    that bounds the search."""
+    return sum(n for n in range(10, 6 * 9 ** 5 + 1)
+               if n == sum(d ** 5 for d in digits(n)))
+
+
+if __name__ == "__main__":
+    check(solve(), "27a1779a8a8c323a307ac8a70bc4489d")
@@ -0,0 +1,17 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Ways to make 200p from the eight UK coins, counted with the usual
+    coin-change table."""
+    ways = [1] + [0] * 200
+    for coin in (1, 2, 5, 10, 20, 50, 100, 200):
+        for amount in range(coin, 201):
+            ways[amount] += ways[amount - coin]
+    return ways[200]
+
+
+if __name__ == "__main__":
+    check(solve(), "142dfe4a33d624d2b830a9257e96726d")
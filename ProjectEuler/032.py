@@ -0,0 +1,18 @@
+#!/usr/bin/env python
+
+from euler import check, is_pandigital
+
+
+def solve() -> int:
+    """Sum of the products in 1-9 pandigital multiplicand/multiplier/product
+    identities. The digit counts force 1 x 4 or 2 x 3 digit factors."""
+    products = set()
+    for a in range(1, 100):
+        for b in range(1000 if a < 10 else 100, 10000 if a < 10 else 1000):
+            if is_pandigital(f"{a}{b}{a * b}"):
+                products.add(a * b)
+    return sum(products)
+
+
+if __name__ == "__main__":
+    check(solve(), "100f6e37d0b0564490a2ee27eff0660d")
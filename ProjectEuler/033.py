@@ -0,0 +1,22 @@
+#!/usr/bin/env python
+
+from fractions import Fraction
+
+from euler import check
+
+
+def solve() -> int:
+    """Denominator of the product of the four curious fractions like 49/98,
+    where wrongly cancelling the shared digit gives the right value."""
+    product = Fraction(1)
+    for num in range(10, 100):
+        for den in range(num + 1, 100):
+            n1, n2 = divmod(num, 10)
+            d1, d2 = divmod(den, 10)
+            if n2 == d1 and d2 != 0 and Fraction(num, den) == Fraction(n1, d2):
+                product *= Fraction(num, den)
+    return product.denominator
+
+
+if __name__ == "__main__":
+    check(solve(), "f899139df5e1059396431415e770c6dd")
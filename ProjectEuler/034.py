@@ -0,0 +1,15 @@
+#!/usr/bin/env python
+
+from euler import check, digits, factorial
+
+
+def solve() -> int:
+    """Sum of the numbers equal to the sum of the factorials of their
+    digits. 8 * 9! has only seven digits, so 7 * 9! is an upper bound."""
+    fact = [factorial(d) for d in range(10)]
+    return sum(n for n in range(10, 7 * fact[9] + 1)
+               if n == sum(fact[d] for d in digits(n)))
+
+
+if __name__ == "__main__":
+    check(solve(), "60803ea798a0c0dfb7f36397d8d4d772")
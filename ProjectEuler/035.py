@@ -0,0 +1,18 @@
+#!/usr/bin/env python
+
+from euler import check, sieve
+
+
+def solve() -> int:
+    """Circular primes below one million."""
+    is_prime = sieve(999_999)
+    count = 0
+    for n in range(2, 1_000_000):
+        s = str(n)
+        if all(is_prime[int(s[i:] + s[:i])] for i in range(len(s))):
+            count += 1
+    return count
+
+
+if __name__ == "__main__":
+    check(solve(), "b53b3a3d6ab90ce0268229151c9bde11")
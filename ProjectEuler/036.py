@@ -0,0 +1,14 @@
+#!/usr/bin/env python
+
+from euler import check, is_palindrome
+
+
+def solve() -> int:
+    """Sum of numbers below one million palindromic in base 10 and base 2.
+    Binary palindromes cannot end in 0, so only odd numbers qualify."""
+    return sum(n for n in range(1, 1_000_000, 2)
+               if is_palindrome(n) and is_palindrome(f"{n:b}"))
+
+
+if __name__ == "__main__":
+    check(solve(), "0e175dc2f28833885f62e7345addff03")
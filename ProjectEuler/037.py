@@ -0,0 +1,26 @@
+#!/usr/bin/env python
+
+from euler import check, is_prime
+
+
+def solve() -> int:
+    """Sum of the eleven primes that stay prime when truncated from either
+    side. They are grown digit by digit from the right-truncatable ones."""
+    total = 0
+    layer = [2, 3, 5, 7]
+    while layer:
+        grown = []
+        for p in layer:
+            for d in (1, 3, 7, 9):
+                candidate = p * 10 + d
+                if is_prime(candidate):
+                    grown.append(candidate)
+                    s = str(candidate)
+                    if all(is_prime(int(s[i:])) for i in range(1, len(s))):
+                        total += candidate
+        layer = grown
+    return total
+
+
+if __name__ == "__main__":
+    check(solve(), "cace46c61b00de1b60874936a093981d")
@@ -0,0 +1,20 @@
+#!/usr/bin/env python
+
+from euler import check, is_pandigital
+
+
+def solve() -> int:
+    """Largest 1-9 pandigital formed by concatenating n with 2n, 3n, ..."""
+    best = 0
+    for n in range(1, 10_000):
+        s, k = "", 1
+        while len(s) < 9:
+            s += str(n * k)
+            k += 1
+        if k > 2 and is_pandigital(s):
+            best = max(best, int(s))
+    return best
+
+
+if __name__ == "__main__":
+    check(solve(), "f2a29ede8dc9fae7926dc7a4357ac25e")
@@ -0,0 +1,19 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Perimeter p <= 1000 with the most right-angle triangle solutions."""
+    counts = [0] * 1001
+    for a in range(1, 500):
+        for b in range(a, 500):
+            c2 = a * a + b * b
+            c = int(c2 ** 0.5)
+            if c * c == c2 and a + b + c <= 1000:
+                counts[a + b + c] += 1
+    return counts.index(max(counts))
+
+
+if __name__ == "__main__":
+    check(solve(), "fa83a11a198d5a7f0bf77a1987bcd006")
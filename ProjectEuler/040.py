@@ -0,0 +1,15 @@
+#!/usr/bin/env python
+
+from math import prod
+
+from euler import check
+
+
+def solve() -> int:
+    """Product of digits d1, d10, ... d1000000 of Champernowne's constant."""
+    fraction = "".join(str(n) for n in range(1, 200_000))
+    return prod(int(fraction[10 ** i - 1]) for i in range(7))
+
+
+if __name__ == "__main__":
+    check(solve(), "6f3ef77ac0e3619e98159e9b6febf557")
@@ -0,0 +1,19 @@
+#!/usr/bin/env python
+
+from itertools import permutations
+
+from euler import check, is_prime
+
+
+def solve() -> int:
+    """Largest n-digit pandigital prime. 8 and 9 digit pandigitals have
+    digit sums divisible by 3, so the search starts at 7 digits."""
+    for n in (7, 4):
+        for digits in permutations("7654321"[7 - n:]):
+            if is_prime(int("".join(digits))):
+                return int("".join(digits))
+    return 0
+
+
+if __name__ == "__main__":
+    check(solve(), "d0a1bd6ab4229b2d0754be8923431404")
@@ -0,0 +1,25 @@
+#!/usr/bin/env python
+
+from euler import check, isqrt, open_input
+
+
+def read_puzzle_input() -> list[str]:
+    """words.txt from the problem page: quoted, comma-separated words."""
+    with open_input("042.in") as file:
+        return [word.strip('"') for word in file.read().strip().split(",")]
+
+
+def is_triangle(t: int) -> bool:
+    # t = n(n + 1)/2  =>  8t + 1 is an odd square
+    r = isqrt(8 * t + 1)
+    return r * r == 8 * t + 1
+
+
+def solve(words: list[str]) -> int:
+    """Number of words whose letter values sum to a triangle number."""
+    return sum(is_triangle(sum(ord(c) - ord("A") + 1 for c in word))
+               for word in words)
+
+
+if __name__ == "__main__":
+    check(solve(read_puzzle_input()), "82aa4b0af34c2313a562076992e50aa3")
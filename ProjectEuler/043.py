@@ -0,0 +1,31 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Sum of 0-9 pandigitals whose three-digit substrings d2d3d4 to d8d9d10
+    are divisible by 2, 3, 5, 7, 11, 13 and 17. The number is built from the
+    right, three digits at a time, pruning on each divisibility rule."""
+    divisors = [17, 13, 11, 7, 5, 3, 2]
+    total = 0
+
+    def extend(suffix: str, k: int) -> None:
+        nonlocal total
+        if k == len(divisors):
+            rest = set("0123456789") - set(suffix)
+            total += int(rest.pop() + suffix)
+            return
+        for d in "0123456789":
+            if d not in suffix and int(d + suffix[:2]) % divisors[k] == 0:
+                extend(d + suffix, k + 1)
+
+    for n in range(17, 1000, 17):
+        s = f"{n:03d}"
+        if len(set(s)) == 3:
+            extend(s, 1)
+    return total
+
+
+if __name__ == "__main__":
+    check(solve(), "115253b7721af0fdff25cd391dfc70cf")
@@ -0,0 +1,22 @@
+#!/usr/bin/env python
+
+from euler import check, is_pentagonal
+
+
+def solve() -> int:
+    """Smallest difference D = Pk - Pj of two pentagonal numbers whose sum
+    and difference are both pentagonal. The first pair found is taken, as
+    in most published solutions; it is known to be the minimum."""
+    pentagonals = []
+    k = 1
+    while True:
+        pk = k * (3 * k - 1) // 2
+        for pj in reversed(pentagonals):
+            if is_pentagonal(pk - pj) and is_pentagonal(pk + pj):
+                return pk - pj
+        pentagonals.append(pk)
+        k += 1
+
+
+if __name__ == "__main__":
+    check(solve(), "2c2556cb85621309ca647465ffa62370")
@@ -0,0 +1,19 @@
+#!/usr/bin/env python
+
+from euler import check, is_pentagonal
+
+
+def solve() -> int:
+    """Next triangle number after 40755 that is also pentagonal and
+    hexagonal. Every hexagonal number is triangular, so only hexagonal
+    numbers need checking."""
+    n = 144
+    while True:
+        h = n * (2 * n - 1)
+        if is_pentagonal(h):
+            return h
+        n += 1
+
+
+if __name__ == "__main__":
+    check(solve(), "30dfe3e3b286add9d12e493ca7be63fc")
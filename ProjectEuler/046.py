@@ -0,0 +1,17 @@
+#!/usr/bin/env python
+
+from euler import check, is_prime, isqrt
+
+
+def solve() -> int:
+    """Smallest odd composite that is not a prime plus twice a square."""
+    n = 9
+    while True:
+        if not is_prime(n) and not any(is_prime(n - 2 * k * k)
+                                       for k in range(1, isqrt(n // 2) + 1)):
+            return n
+        n += 2
+
+
+if __name__ == "__main__":
+    check(solve(), "89abe98de6071178edb1b28901a8f459")
@@ -0,0 +1,24 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """First of four consecutive integers each with four distinct prime
+    factors, counting factors with a sieve."""
+    limit = 200_000
+    factors = [0] * limit
+    for p in range(2, limit):
+        if factors[p] == 0:
+            for multiple in range(p, limit, p):
+                factors[multiple] += 1
+    run = 0
+    for n in range(2, limit):
+        run = run + 1 if factors[n] == 4 else 0
+        if run == 4:
+            return n - 3
+    return 0
+
+
+if __name__ == "__main__":
+    check(solve(), "748f517ecdc29106e2738f88aa7530f4")
@@ -0,0 +1,13 @@
+#!/usr/bin/env python
+
+from euler import check
+
+
+def solve() -> int:
+    """Last ten digits of 1^1 + 2^2 + ... + 1000^1000."""
+    modulus = 10 ** 10
+    return sum(pow(n, n, modulus) for n in range(1, 1001)) % modulus
+
+
+if __name__ == "__main__":
+    check(solve(), "0829124724747ae1c65da8cae5263346")
@@ -0,0 +1,22 @@
+#!/usr/bin/env python
+
+from euler import check, sieve
+
+
+def solve() -> int:
+    """The 12-digit number formed by the other arithmetic sequence of three
+    4-digit primes that are permutations of each other (1487, 4817, 8147
+    is the known one)."""
+    is_prime = sieve(9999)
+    for a in range(1000, 10_000):
+        if a == 1487 or not is_prime[a]:
+            continue
+        for step in range(2, (10_000 - a) // 2, 2):
+            b, c = a + step, a + 2 * step
+            if is_prime[b] and is_prime[c] and sorted(str(a)) == sorted(str(b)) == sorted(str(c)):
+                return int(f"{a}{b}{c}")
+    return 0
+
+
+if __name__ == "__main__":
+    check(solve(), "0b99933d3e2a9addccbb663d46cbb592")
@@ -0,0 +1,27 @@
+#!/usr/bin/env python
+
+from euler import check, primes_below, sieve
+
+
+def solve() -> int:
+    """Prime below one million that is the sum of the most consecutive
+    primes, using prefix sums so each run's sum is a subtraction."""
+    limit = 1_000_000
+    primes = primes_below(limit)
+    is_prime = sieve(limit)
+    prefix = [0]
+    for p in primes:
+        prefix.append(prefix[-1] + p)
+    best_length, best = 0, 0
+    for i in range(len(prefix)):
+        for j in range(i + best_length + 1, len(prefix)):
+            total = prefix[j] - prefix[i]
+            if total >= limit:
+                break
+            if is_prime[total]:
+                best_length, best = j - i, total
+    return best
+
+
+if __name__ == "__main__":
+    check(solve(), "73229bab6c5dc1c7cf7a4fa123caf6bc")
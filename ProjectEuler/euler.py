@@ -0,0 +1,142 @@
+"""
+Number theory and combinatorics helpers shared by the Project Euler
+solutions, plus the answer check each script runs.
+
+Project Euler asks that answers are not published, so each solution keeps
+only the MD5 digest of its answer and check() compares against that.
+"""
+
+import hashlib
+import os
+import sys
+from math import comb, factorial, gcd, isqrt
+
+
+def check(answer: int, digest: str) -> None:
+    """Print the answer and whether it matches the recorded digest, exiting
+    with status 1 when it does not."""
+    ok = hashlib.md5(str(answer).encode()).hexdigest() == digest
+    print("Answer:", answer, "(verified)" if ok else "(WRONG)")
+    if not ok:
+        sys.exit(1)
+
+
+def open_input(name: str):
+    """Open a problem's data file, NNN.in beside the solutions. These are not
+    committed (see README.md); when one is missing, say where it comes from
+    and exit with status 2."""
+    path = os.path.join(os.path.dirname(os.path.abspath(__file__)), name)
+    try:
+        return open(path, "r")
+    except FileNotFoundError:
+        problem = int(name.split(".")[0])
+        print(f"{name} not found: save the data from "
+              f"https://projecteuler.net/problem={problem} as {path}",
+              file=sys.stderr)
+        sys.exit(2)
+
+
+def sieve(limit: int) -> list[bool]:
+    """Sieve of Eratosthenes: is_prime[n] for 0 <= n <= limit."""
+    is_prime = [True] * (limit + 1)
+    is_prime[0] = False
+    if limit >= 1:
+        is_prime[1] = False
+    for i in range(2, isqrt(limit) + 1):
+        if is_prime[i]:
+            is_prime[i * i::i] = [False] * len(range(i * i, limit + 1, i))
+    return is_prime
+
+
+def primes_below(limit: int) -> list[int]:
+    """All primes less than limit."""
+    return [n for n, p in enumerate(sieve(limit - 1)) if p] if limit > 2 else []
+
+
+def is_prime(n: int) -> bool:
+    """Trial division by 6k +/- 1, fine for the sizes these problems use."""
+    if n < 4:
+        return n > 1
+    if n % 2 == 0 or n % 3 == 0:
+        return False
+    for i in range(5, isqrt(n) + 1, 6):
+        if n % i == 0 or n % (i + 2) == 0:
+            return False
+    return True
+
+
+def factorize(n: int) -> dict[int, int]:
+    """Prime factorisation as {prime: exponent}."""
+    factors = {}
+    d = 2
+    while d * d <= n:
+        while n % d == 0:
+            factors[d] = factors.get(d, 0) + 1
+            n //= d
+        d += 1 if d == 2 else 2
+    if n > 1:
+        factors[n] = factors.get(n, 0) + 1
+    return factors
+
+
+def divisor_count(n: int) -> int:
+    """Number of divisors, from the exponents of the factorisation."""
+    count = 1
+    for e in factorize(n).values():
+        count *= e + 1
+    return count
+
+
+def divisor_sums(limit: int) -> list[int]:
+    """Sum of proper divisors for every n below limit, sieve style."""
+    sums = [0] * limit
+    for d in range(1, limit // 2 + 1):
+        for multiple in range(2 * d, limit, d):
+            sums[multiple] += d
+    return sums
+
+
+def lcm(a: int, b: int) -> int:
+    return a // gcd(a, b) * b
+
+
+def digits(n: int) -> list[int]:
+    return [int(c) for c in str(n)]
+
+
+def is_palindrome(s) -> bool:
+    s = str(s)
+    return s == s[::-1]
+
+
+def is_pandigital(s: str, n: int = 9) -> bool:
+    """True if s uses each of the digits 1..n exactly once."""
+    return len(s) == n and set(s) == set("123456789"[:n])
+
+
+def triangle(n: int) -> int:
+    return n * (n + 1) // 2
+
+
+def is_pentagonal(x: int) -> bool:
+    # x = n(3n - 1)/2  =>  n = (1 + sqrt(1 + 24x)) / 6
+    r = isqrt(1 + 24 * x)
+    return r * r == 1 + 24 * x and (1 + r) % 6 == 0
+
+
+def nth_permutation(items: list, k: int) -> list:
+    """The k-th (from 0) lexicographic permutation, via the factorial base."""
+    items = sorted(items)
+    result = []
+    for i in range(len(items) - 1, -1, -1):
+        index, k = divmod(k, factorial(i))
+        result.append(items.pop(index))
+    return result
+
+
+__all__ = [
+    "check", "comb", "digits", "divisor_count", "divisor_sums", "factorial",
+    "factorize", "gcd", "is_palindrome", "is_pandigital", "is_pentagonal",
+    "is_prime", "isqrt", "lcm", "nth_permutation", "open_input",
+    "primes_below", "sieve", "triangle",
+]
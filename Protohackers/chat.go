@@ -0,0 +1,112 @@
+package main
+
+import (
+  "bufio"
+  "net"
+  "sort"
+  "strings"
+  "sync"
+)
+
+const (
+  maxNameLength = 16
+  maxLineLength = 1000
+)
+
+// The room is the state shared by every budget chat connection: the joined
+// users and a way to write to each.
+type room struct {
+  mu    sync.Mutex
+  users map[*chatUser]bool
+}
+
+type chatUser struct {
+  name string
+  mu   sync.Mutex
+  w    *bufio.Writer
+}
+
+func (u *chatUser) send(line string) {
+  u.mu.Lock()
+  defer u.mu.Unlock()
+  u.w.WriteString(line + "\n")
+  u.w.Flush()
+}
+
+func validName(name string) bool {
+  if name == "" || len(name) > maxNameLength {
+    return false
+  }
+  for _, c := range name {
+    if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+      return false
+    }
+  }
+  return true
+}
+
+// Send a line to everyone in the room except from. The member list is
+// copied so slow clients do not hold the room lock.
+func (r *room) broadcast(from *chatUser, line string) {
+  r.mu.Lock()
+  members := make([]*chatUser, 0, len(r.users))
+  for u := range r.users {
+    if u != from {
+      members = append(members, u)
+    }
+  }
+  r.mu.Unlock()
+  for _, u := range members {
+    u.send(line)
+  }
+}
+
+// Add a user, returning who was already there.
+func (r *room) join(u *chatUser) []string {
+  r.mu.Lock()
+  var names []string
+  for other := range r.users {
+    names = append(names, other.name)
+  }
+  r.users[u] = true
+  r.mu.Unlock()
+  sort.Strings(names)
+  return names
+}
+
+func (r *room) leave(u *chatUser) {
+  r.mu.Lock()
+  delete(r.users, u)
+  r.mu.Unlock()
+}
+
+// Problem 3, Budget Chat: ask for a name, announce arrivals and departures
+// with "*" lines, and relay each message as "[name] text" to everyone else.
+// Connections that never give a valid name never join the room.
+func budgetChat() func(net.Conn) {
+  r := &room{users: map[*chatUser]bool{}}
+  return func(conn net.Conn) {
+    u := &chatUser{w: bufio.NewWriter(conn)}
+    s := bufio.NewScanner(conn)
+    s.Buffer(make([]byte, 0, 1024), maxLineLength+2)
+    u.send("Welcome to budgetchat! What shall I call you?")
+    if !s.Scan() {
+      return
+    }
+    u.name = strings.TrimRight(s.Text(), "\r")
+    if !validName(u.name) {
+      u.send("* Names are 1 to 16 letters and digits.")
+      return
+    }
+    present := r.join(u)
+    defer func() {
+      r.leave(u)
+      r.broadcast(u, "* "+u.name+" has left the room")
+    }()
+    u.send("* The room contains: " + strings.Join(present, ", "))
+    r.broadcast(u, "* "+u.name+" has entered the room")
+    for s.Scan() {
+      r.broadcast(u, "["+u.name+"] "+strings.TrimRight(s.Text(), "\r"))
+    }
+  }
+}
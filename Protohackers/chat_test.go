@@ -0,0 +1,68 @@
+package main
+
+import (
+  "bufio"
+  "fmt"
+  "math/rand"
+  "net"
+  "strings"
+  "time"
+)
+
+// Two users join; each must see the other's arrival, their message and the
+// first one's departure. Names carry the client id so concurrent checks
+// in the same room do not confuse each other.
+func checkChat(addr string, rng *rand.Rand) error {
+  type session struct {
+    conn net.Conn
+    r    *bufio.Reader
+  }
+  open := func(name string) (*session, error) {
+    conn, err := net.Dial("tcp", addr)
+    if err != nil {
+      return nil, err
+    }
+    conn.SetDeadline(time.Now().Add(10 * time.Second))
+    s := &session{conn, bufio.NewReader(conn)}
+    s.r.ReadString('\n')
+    fmt.Fprintln(conn, name)
+    if line, err := s.r.ReadString('\n'); err != nil || !strings.HasPrefix(line, "* The room contains:") {
+      conn.Close()
+      return nil, fmt.Errorf("chat: %s got %q on joining", name, line)
+    }
+    return s, nil
+  }
+  // Skip lines about other clients until one matching want arrives.
+  await := func(s *session, want string) error {
+    for {
+      line, err := s.r.ReadString('\n')
+      if err != nil {
+        return fmt.Errorf("chat: waiting for %q: %v", want, err)
+      }
+      if strings.TrimSuffix(line, "\n") == want {
+        return nil
+      }
+    }
+  }
+  id := rng.Intn(1000000)
+  alice, bob := fmt.Sprintf("alice%d", id), fmt.Sprintf("bob%d", id)
+  a, err := open(alice)
+  if err != nil {
+    return err
+  }
+  defer a.conn.Close()
+  b, err := open(bob)
+  if err != nil {
+    return err
+  }
+  defer b.conn.Close()
+  if err := await(a, "* "+bob+" has entered the room"); err != nil {
+    return err
+  }
+  fmt.Fprintln(b.conn, "hello from", bob)
+  if err := await(a, "["+bob+"] hello from "+bob); err != nil {
+    return err
+  }
+  a.conn.Close()
+  return await(b, "* "+alice+" has left the room")
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+  "io"
+  "net"
+)
+
+// Problem 0, Smoke Test: echo everything back until the client closes its
+// side, then close ours.
+func echo() func(net.Conn) {
+  return func(conn net.Conn) {
+    io.Copy(conn, conn)
+  }
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+  "bytes"
+  "fmt"
+  "io"
+  "math/rand"
+  "net"
+)
+
+// Send random binary data, half-close, and expect it all back.
+func checkEcho(addr string, rng *rand.Rand) error {
+  conn, err := net.Dial("tcp", addr)
+  if err != nil {
+    return err
+  }
+  defer conn.Close()
+  data := make([]byte, 1+rng.Intn(100000))
+  rng.Read(data)
+  go func() {
+    conn.Write(data)
+    conn.(*net.TCPConn).CloseWrite()
+  }()
+  got, err := io.ReadAll(conn)
+  if err != nil {
+    return err
+  }
+  if !bytes.Equal(got, data) {
+    return fmt.Errorf("echo: sent %d bytes, got back %d different ones", len(data), len(got))
+  }
+  return nil
+}
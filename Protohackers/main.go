@@ -0,0 +1,68 @@
+package main
+
+import (
+  "flag"
+  "fmt"
+  "net"
+  "os"
+  "sort"
+  "strings"
+)
+
+// Each Protohackers problem is a handler factory, called once per server so
+// shared state such as the chat room starts fresh. The tests play client
+// sessions against each.
+type problem struct {
+  number  int
+  handler func() func(net.Conn)
+}
+
+var problems = map[string]problem{
+  "echo":  {0, echo},
+  "prime": {1, primeTime},
+  "means": {2, means},
+  "chat":  {3, budgetChat},
+}
+
+// The problems' names in order of their numbers.
+func sortedNames() []string {
+  var list []string
+  for name := range problems {
+    list = append(list, name)
+  }
+  sort.Slice(list, func(i, j int) bool { return problems[list[i]].number < problems[list[j]].number })
+  return list
+}
+
+func names() string {
+  return strings.Join(sortedNames(), ", ")
+}
+
+func main() {
+  addr := flag.String("addr", ":9000", "address to listen on")
+  flag.Usage = func() {
+    fmt.Fprintf(os.Stderr, "usage: protohackers [flags] problem\nproblems: %s\n", names())
+    flag.PrintDefaults()
+  }
+  flag.Parse()
+  if flag.NArg() != 1 {
+    flag.Usage()
+    os.Exit(2)
+  }
+  p, ok := problems[flag.Arg(0)]
+  if !ok {
+    fmt.Fprintf(os.Stderr, "protohackers: unknown problem %q (have %s)\n", flag.Arg(0), names())
+    os.Exit(2)
+  }
+
+  s, err := listen(*addr, p.handler())
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "protohackers:", err)
+    os.Exit(1)
+  }
+  fmt.Printf("problem %d (%s) listening on %s\n", p.number, flag.Arg(0), s.addr())
+  if err := s.serve(); err != nil {
+    fmt.Fprintln(os.Stderr, "protohackers:", err)
+    os.Exit(1)
+  }
+}
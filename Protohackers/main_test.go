@@ -0,0 +1,91 @@
+package main
+
+import (
+  "flag"
+  "math/rand"
+  "sync"
+  "testing"
+  "time"
+)
+
+// The check for each problem plays one client session against a running
+// server.
+var checks = map[string]func(addr string, rng *rand.Rand) error{
+  "echo":  checkEcho,
+  "prime": checkPrime,
+  "means": checkMeans,
+  "chat":  checkChat,
+}
+
+var (
+  clients = flag.Int("clients", 50, "concurrent clients per problem in TestLoad")
+  rounds  = flag.Int("rounds", 10, "sessions per client in TestLoad")
+  target  = flag.String("target", "", "load test this server instead of one started in-process; pick its problem with -run TestLoad/NAME")
+)
+
+// Start a server for the problem that lives as long as the test.
+func start(t *testing.T, p problem) string {
+  s, err := listen("127.0.0.1:0", p.handler())
+  if err != nil {
+    t.Skip("no loopback:", err)
+  }
+  go s.serve()
+  t.Cleanup(s.close)
+  return s.addr()
+}
+
+// Every problem has a check, and passes it once.
+func TestProblems(t *testing.T) {
+  for _, name := range sortedNames() {
+    t.Run(name, func(t *testing.T) {
+      check := checks[name]
+      if check == nil {
+        t.Fatal("no check")
+      }
+      if err := check(start(t, problems[name]), rand.New(rand.NewSource(1))); err != nil {
+        t.Error(err)
+      }
+    })
+  }
+}
+
+// Many clients at once, each running several sessions, against a server
+// started in-process or the one -target names.
+func TestLoad(t *testing.T) {
+  if testing.Short() {
+    t.Skip("a load test")
+  }
+  for _, name := range sortedNames() {
+    t.Run(name, func(t *testing.T) {
+      addr := *target
+      if addr == "" {
+        addr = start(t, problems[name])
+      }
+      var wg sync.WaitGroup
+      var mu sync.Mutex
+      failed := 0
+      began := time.Now()
+      for c := range *clients {
+        wg.Add(1)
+        go func(seed int64) {
+          defer wg.Done()
+          rng := rand.New(rand.NewSource(seed))
+          for range *rounds {
+            if err := checks[name](addr, rng); err != nil {
+              mu.Lock()
+              failed++
+              if failed <= 5 {
+                t.Error(err)
+              }
+              mu.Unlock()
+            }
+          }
+        }(int64(c) + 1)
+      }
+      wg.Wait()
+      total := *clients * *rounds
+      t.Logf("%d sessions from %d clients in %v: %d failed",
+        total, *clients, time.Since(began).Round(time.Millisecond), failed)
+    })
+  }
+}
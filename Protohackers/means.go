@@ -0,0 +1,55 @@
+package main
+
+import (
+  "bufio"
+  "encoding/binary"
+  "io"
+  "net"
+)
+
+// Problem 2, Means to an End: each 9-byte message is a type byte and two
+// big-endian int32s. 'I' inserts a (timestamp, price) pair into this
+// connection's own history; 'Q' asks for the mean price between two
+// timestamps inclusive, answered as an int32.
+func means() func(net.Conn) {
+  return func(conn net.Conn) {
+    prices := map[int32]int32{}
+    r := bufio.NewReader(conn)
+    msg := make([]byte, 9)
+    reply := make([]byte, 4)
+    for {
+      if _, err := io.ReadFull(r, msg); err != nil {
+        return
+      }
+      a := int32(binary.BigEndian.Uint32(msg[1:5]))
+      b := int32(binary.BigEndian.Uint32(msg[5:9]))
+      switch msg[0] {
+      case 'I':
+        prices[a] = b
+      case 'Q':
+        binary.BigEndian.PutUint32(reply, uint32(mean(prices, a, b)))
+        if _, err := conn.Write(reply); err != nil {
+          return
+        }
+      default:
+        return
+      }
+    }
+  }
+}
+
+// The mean of the prices in [from, to], or 0 if there are none. The sum is
+// kept in 64 bits since many int32 prices overflow 32.
+func mean(prices map[int32]int32, from, to int32) int32 {
+  var sum, count int64
+  for t, p := range prices {
+    if t >= from && t <= to {
+      sum += int64(p)
+      count++
+    }
+  }
+  if count == 0 {
+    return 0
+  }
+  return int32(sum / count)
+}
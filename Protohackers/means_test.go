@@ -0,0 +1,55 @@
+package main
+
+import (
+  "encoding/binary"
+  "fmt"
+  "io"
+  "math/rand"
+  "net"
+)
+
+// A 9-byte message as a client sends one.
+func meansMessage(kind byte, a, b int32) []byte {
+  msg := []byte{kind, 0, 0, 0, 0, 0, 0, 0, 0}
+  binary.BigEndian.PutUint32(msg[1:], uint32(a))
+  binary.BigEndian.PutUint32(msg[5:], uint32(b))
+  return msg
+}
+
+// Insert random prices, then check a few queries, including an empty and
+// an inverted range.
+func checkMeans(addr string, rng *rand.Rand) error {
+  conn, err := net.Dial("tcp", addr)
+  if err != nil {
+    return err
+  }
+  defer conn.Close()
+  prices := map[int32]int32{}
+  var out []byte
+  for i := 0; i < 1000; i++ {
+    t, p := int32(rng.Intn(100000)), int32(rng.Intn(2000)-1000)
+    if _, ok := prices[t]; ok {
+      continue
+    }
+    prices[t] = p
+    out = append(out, meansMessage('I', t, p)...)
+  }
+  queries := [][2]int32{{0, 100000}, {1000, 5000}, {200000, 300000}, {5000, 1000}}
+  for _, q := range queries {
+    out = append(out, meansMessage('Q', q[0], q[1])...)
+  }
+  if _, err := conn.Write(out); err != nil {
+    return err
+  }
+  reply := make([]byte, 4)
+  for _, q := range queries {
+    if _, err := io.ReadFull(conn, reply); err != nil {
+      return err
+    }
+    got := int32(binary.BigEndian.Uint32(reply))
+    if want := mean(prices, q[0], q[1]); got != want {
+      return fmt.Errorf("means: query %v answered %d, want %d", q, got, want)
+    }
+  }
+  return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+  "bufio"
+  "encoding/json"
+  "fmt"
+  "math/big"
+  "net"
+  "strings"
+)
+
+type primeRequest struct {
+  Method *string          `json:"method"`
+  Number json.RawMessage `json:"number"`
+}
+
+type primeResponse struct {
+  Method string `json:"method"`
+  Prime  bool   `json:"prime"`
+}
+
+// Parse one request line. A request is well-formed only if it is a JSON
+// object with method "isPrime" and a numeric number; extra fields are
+// allowed.
+func parsePrimeRequest(line []byte) (*big.Int, bool, error) {
+  dec := json.NewDecoder(strings.NewReader(string(line)))
+  var req primeRequest
+  if err := dec.Decode(&req); err != nil {
+    return nil, false, err
+  }
+  if dec.More() {
+    return nil, false, fmt.Errorf("trailing data")
+  }
+  if req.Method == nil || *req.Method != "isPrime" {
+    return nil, false, fmt.Errorf("not an isPrime request")
+  }
+  // The number must be a JSON number, not a string holding one. It may be
+  // arbitrarily large, or not an integer at all, which is never prime.
+  var num json.Number
+  if len(req.Number) == 0 || req.Number[0] == '"' || json.Unmarshal(req.Number, &num) != nil || num == "" {
+    return nil, false, fmt.Errorf("number is not a number")
+  }
+  n, ok := new(big.Int).SetString(num.String(), 10)
+  return n, ok, nil
+}
+
+func isPrime(n *big.Int, integer bool) bool {
+  return integer && n.Sign() > 0 && n.ProbablyPrime(20)
+}
+
+// Problem 1, Prime Time: answer newline-delimited isPrime requests. A
+// malformed request gets a malformed response and the connection is closed.
+func primeTime() func(net.Conn) {
+  return func(conn net.Conn) {
+    r := bufio.NewReader(conn)
+    w := bufio.NewWriter(conn)
+    for {
+      line, err := r.ReadBytes('\n')
+      if err != nil {
+        return
+      }
+      n, integer, err := parsePrimeRequest(line)
+      if err != nil {
+        w.WriteString("{\"error\":\"malformed request\"}\n")
+        w.Flush()
+        return
+      }
+      data, _ := json.Marshal(primeResponse{Method: "isPrime", Prime: isPrime(n, integer)})
+      w.Write(append(data, '\n'))
+      // Only flush once the client has no more requests waiting, so
+      // pipelined requests are answered in one write.
+      if r.Buffered() == 0 {
+        if err := w.Flush(); err != nil {
+          return
+        }
+      }
+    }
+  }
+}
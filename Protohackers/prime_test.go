@@ -0,0 +1,59 @@
+package main
+
+import (
+  "bufio"
+  "encoding/json"
+  "fmt"
+  "math/rand"
+  "net"
+  "strings"
+)
+
+// Send a batch of pipelined requests, checking each answer against trial
+// division, then a malformed one that must end the session.
+func checkPrime(addr string, rng *rand.Rand) error {
+  conn, err := net.Dial("tcp", addr)
+  if err != nil {
+    return err
+  }
+  defer conn.Close()
+  var nums []int
+  var b strings.Builder
+  for i := 0; i < 100; i++ {
+    n := rng.Intn(100000) - 10
+    nums = append(nums, n)
+    fmt.Fprintf(&b, "{\"method\":\"isPrime\",\"number\":%d}\n", n)
+  }
+  b.WriteString("{\"method\":\"isPrime\",\"number\":\"7\"}\n")
+  go conn.Write([]byte(b.String()))
+
+  r := bufio.NewReader(conn)
+  for _, n := range nums {
+    line, err := r.ReadBytes('\n')
+    if err != nil {
+      return err
+    }
+    var resp primeResponse
+    if err := json.Unmarshal(line, &resp); err != nil || resp.Method != "isPrime" {
+      return fmt.Errorf("prime: bad response %q", line)
+    }
+    want := n > 1
+    for d := 2; d*d <= n; d++ {
+      if n%d == 0 {
+        want = false
+        break
+      }
+    }
+    if resp.Prime != want {
+      return fmt.Errorf("prime: %d answered %v", n, resp.Prime)
+    }
+  }
+  line, _ := r.ReadBytes('\n')
+  if !strings.Contains(string(line), "error") {
+    return fmt.Errorf("prime: malformed request answered %q", line)
+  }
+  if _, err := r.ReadByte(); err == nil {
+    return fmt.Errorf("prime: connection left open after a malformed request")
+  }
+  return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+  "net"
+  "sync"
+)
+
+// A server accepts TCP connections and runs a handler for each in its own
+// goroutine. Any per-connection state lives in the handler's locals; state
+// shared between connections is captured when the handler is made.
+type server struct {
+  ln     net.Listener
+  handle func(net.Conn)
+
+  mu     sync.Mutex
+  conns  map[net.Conn]bool
+  closed bool
+  wg     sync.WaitGroup
+}
+
+func listen(addr string, handle func(net.Conn)) (*server, error) {
+  ln, err := net.Listen("tcp", addr)
+  if err != nil {
+    return nil, err
+  }
+  return &server{ln: ln, handle: handle, conns: map[net.Conn]bool{}}, nil
+}
+
+func (s *server) addr() string {
+  return s.ln.Addr().String()
+}
+
+// Accept connections until the server is closed. The handler need not close
+// its connection; that happens when it returns.
+func (s *server) serve() error {
+  for {
+    conn, err := s.ln.Accept()
+    if err != nil {
+      s.mu.Lock()
+      closed := s.closed
+      s.mu.Unlock()
+      if closed {
+        return nil
+      }
+      return err
+    }
+    s.mu.Lock()
+    s.conns[conn] = true
+    s.mu.Unlock()
+    s.wg.Add(1)
+    go func() {
+      defer s.wg.Done()
+      defer func() {
+        conn.Close()
+        s.mu.Lock()
+        delete(s.conns, conn)
+        s.mu.Unlock()
+      }()
+      s.handle(conn)
+    }()
+  }
+}
+
+// Stop accepting, close every open connection and wait for the handlers to
+// finish.
+func (s *server) close() {
+  s.mu.Lock()
+  s.closed = true
+  s.ln.Close()
+  for conn := range s.conns {
+    conn.Close()
+  }
+  s.mu.Unlock()
+  s.wg.Wait()
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+  "bufio"
+  "fmt"
+  "io"
+  "maps"
+  "os"
+  "slices"
+  "strconv"
+  "strings"
+)
+
+// The debugger runs the machine an instruction at a time, stopping it at
+// breakpoints, on chosen opcodes, or when a watched register or memory
+// word changes, and keeps a trace of the instructions last executed. It
+// is driven from the prompt, by !debug or -debug, or by a check calling
+// run directly.
+
+var opNames = [...]string{"halt", "set", "push", "pop", "eq", "gt", "jmp", "jt", "jf", "add", "mult", "mod",
+  "and", "or", "not", "rmem", "wmem", "call", "ret", "out", "in", "noop"}
+
+// How many executed instructions the trace keeps.
+const traceSize = 10000
+
+type traceEntry struct {
+  pc    uint16
+  words [4]uint16 // the instruction and its operands
+  reg   [numRegs]uint16
+}
+
+type debugger struct {
+  m        *vm
+  breaks   map[uint16]bool // addresses
+  opBreaks map[uint16]bool // opcodes
+  // Watched registers and memory words, named as operands are: an address
+  // below regBase, or a register from regBase up, with their last values.
+  watches map[uint16]uint16
+  trace   []traceEntry // a ring, next written at steps % traceSize
+  steps   int          // instructions executed
+}
+
+func newDebugger(m *vm) *debugger {
+  return &debugger{m: m, breaks: map[uint16]bool{}, opBreaks: map[uint16]bool{}, watches: map[uint16]uint16{}}
+}
+
+type stopKind int
+
+const (
+  stopHalt   stopKind = iota
+  stopStep            // the number of steps asked for were run
+  stopBreak           // at a breakpoint address
+  stopOpcode          // at an instruction with a breakpoint on its opcode
+  stopWatch           // after an instruction changed a watched location
+)
+
+// Why the machine stopped, and a line saying so.
+type stop struct {
+  kind stopKind
+  msg  string
+}
+
+// Run up to limit instructions, or without limit if it is 0. The first
+// instruction is run even if it has a breakpoint, so that continuing
+// from one moves on. Errors are the machine's, including errInterrupt
+// from an "in" that a command interrupted.
+func (d *debugger) run(limit int) (stop, error) {
+  m := d.m
+  for n := 0; limit == 0 || n < limit; n++ {
+    // The pc can be anything a jump or ret left there, or a restored
+    // state held; step would say so, but the breakpoints look first.
+    if m.pc >= memSize {
+      return stop{}, fmt.Errorf("invalid address %d", m.pc)
+    }
+    op := m.mem[m.pc]
+    if n > 0 {
+      if d.breaks[m.pc] {
+        return stop{stopBreak, fmt.Sprintf("breakpoint at %d", m.pc)}, nil
+      }
+      if d.opBreaks[op] {
+        return stop{stopOpcode, fmt.Sprintf("%s at %d", opName(op), m.pc)}, nil
+      }
+    }
+    e := traceEntry{pc: m.pc, reg: m.reg}
+    for i := range e.words {
+      e.words[i] = m.mem[(int(m.pc)+i)%memSize]
+    }
+    if err := m.step(); err != nil {
+      if err == errHalted {
+        d.record(e)
+        return stop{stopHalt, fmt.Sprintf("halted at %d", e.pc)}, nil
+      }
+      return stop{}, err
+    }
+    d.record(e)
+    var changed []string
+    for loc, old := range d.watches {
+      if v := d.peek(loc); v != old {
+        d.watches[loc] = v
+        changed = append(changed, fmt.Sprintf("%s %d -> %d", locName(loc), old, v))
+      }
+    }
+    if changed != nil {
+      return stop{stopWatch, fmt.Sprintf("%s changed by %s at %d", strings.Join(changed, ", "), disasm(e.words[:]), e.pc)}, nil
+    }
+  }
+  return stop{stopStep, ""}, nil
+}
+
+func (d *debugger) record(e traceEntry) {
+  if len(d.trace) < traceSize {
+    d.trace = append(d.trace, e)
+  } else {
+    d.trace[d.steps%traceSize] = e
+  }
+  d.steps++
+}
+
+// The last n entries of the trace, oldest first.
+func (d *debugger) lastTrace(n int) []traceEntry {
+  n = min(n, len(d.trace))
+  out := make([]traceEntry, 0, n)
+  for i := d.steps - n; i < d.steps; i++ {
+    out = append(out, d.trace[i%traceSize])
+  }
+  return out
+}
+
+func (d *debugger) writeTrace(path string, n int) error {
+  f, err := os.Create(path)
+  if err != nil {
+    return err
+  }
+  w := bufio.NewWriter(f)
+  for _, e := range d.lastTrace(n) {
+    fmt.Fprintln(w, e)
+  }
+  if err := w.Flush(); err != nil {
+    f.Close()
+    return err
+  }
+  return f.Close()
+}
+
+func (d *debugger) watch(loc uint16) {
+  d.watches[loc] = d.peek(loc)
+}
+
+func (d *debugger) peek(loc uint16) uint16 {
+  if loc >= regBase {
+    return d.m.reg[loc-regBase]
+  }
+  return d.m.mem[loc]
+}
+
+func locName(loc uint16) string {
+  if loc >= regBase {
+    return fmt.Sprintf("r%d", loc-regBase)
+  }
+  return fmt.Sprintf("[%d]", loc)
+}
+
+func opName(op uint16) string {
+  if int(op) < len(opNames) {
+    return opNames[op]
+  }
+  return fmt.Sprintf("?%d", op)
+}
+
+// Disassemble the instruction at the start of words, which holds at least
+// its operands.
+func disasm(words []uint16) string {
+  op := words[0]
+  if int(op) >= len(arity) {
+    return fmt.Sprintf("data %d", op)
+  }
+  parts := []string{opNames[op]}
+  for _, v := range words[1 : 1+arity[op]] {
+    switch {
+    case v >= regBase && v <= maxValue:
+      parts = append(parts, fmt.Sprintf("r%d", v-regBase))
+    case op == opOut && v >= ' ' && v < 127:
+      parts = append(parts, strconv.QuoteRune(rune(v)))
+    default:
+      parts = append(parts, strconv.Itoa(int(v)))
+    }
+  }
+  return strings.Join(parts, " ")
+}
+
+// The instruction at addr, disassembled, and its length in words.
+func (d *debugger) disasmAt(addr uint16) (string, int) {
+  var words [4]uint16
+  for i := range words {
+    words[i] = d.m.mem[(int(addr)+i)%memSize]
+  }
+  n := 1
+  if int(words[0]) < len(arity) {
+    n += arity[words[0]]
+  }
+  return disasm(words[:]), n
+}
+
+func (e traceEntry) String() string {
+  regs := make([]string, numRegs)
+  for i, r := range e.reg {
+    regs[i] = strconv.Itoa(int(r))
+  }
+  return fmt.Sprintf("%5d  %-22s  %s", e.pc, disasm(e.words[:]), strings.Join(regs, " "))
+}
+
+const debugHelp = `debugger commands:
+  s, step [N]           run N instructions (1)
+  c, continue           run to the next stop, reading input as the game does
+  b, break ADDR|OP      stop before the instruction at ADDR, or any OP (by name)
+  d, delete ADDR|OP|all remove breakpoints
+  w, watch rN|ADDR      stop after an instruction changes a register or word
+  unwatch rN|ADDR|all   remove watchpoints
+  r, regs               show pc, registers, stack and stops
+  x ADDR [N]            show N memory words (8)
+  dis [ADDR [N]]        disassemble N instructions (10) from ADDR (pc)
+  set rN|ADDR VALUE     change a register or word
+  t, trace [N] [FILE]   show or write the last N instructions run (20)
+  q, quit               exit
+addresses and values are decimal, or hex with 0x`
+
+// Parse a register "rN" or a memory address into the operand naming it.
+func parseLoc(s string) (uint16, error) {
+  if strings.HasPrefix(s, "r") {
+    n, err := strconv.Atoi(s[1:])
+    if err != nil || n < 0 || n >= numRegs {
+      return 0, fmt.Errorf("no register %s", s)
+    }
+    return regBase + uint16(n), nil
+  }
+  return parseAddr(s)
+}
+
+func parseAddr(s string) (uint16, error) {
+  n, err := strconv.ParseUint(s, 0, 15)
+  if err != nil {
+    return 0, fmt.Errorf("bad address %s", s)
+  }
+  return uint16(n), nil
+}
+
+func parseOp(s string) (uint16, bool) {
+  for i, name := range opNames {
+    if name == s {
+      return uint16(i), true
+    }
+  }
+  return 0, false
+}
+
+// Read and run debugger commands until one resumes the machine: continue,
+// which returns nil, or quit, io.EOF. Stepping is done here; its errors
+// other than an interrupt end the session.
+func (s *session) debug(d *debugger) error {
+  m, out := d.m, s.m.out
+  where := func() {
+    if m.pc >= memSize {
+      fmt.Fprintf(out, "%5d  invalid address\n", m.pc)
+      return
+    }
+    text, _ := d.disasmAt(m.pc)
+    fmt.Fprintf(out, "%5d  %s\n", m.pc, text)
+  }
+  where()
+  for {
+    fmt.Fprint(out, "(debug) ")
+    line, err := s.next()
+    if err != nil {
+      fmt.Fprintln(out)
+      return err
+    }
+    f := strings.Fields(line)
+    if len(f) == 0 {
+      continue
+    }
+    arg := func(i int, def int) int {
+      if i < len(f) {
+        if n, err := strconv.ParseUint(f[i], 0, 16); err == nil {
+          return int(n)
+        }
+      }
+      return def
+    }
+    switch f[0] {
+    case "s", "step":
+      st, err := d.run(arg(1, 1))
+      switch {
+      case err == errInterrupt:
+        s.interrupted()
+        s.debugging = false
+      case err != nil:
+        return err
+      case st.kind == stopHalt:
+        fmt.Fprintln(out, st.msg)
+        return io.EOF
+      case st.msg != "":
+        fmt.Fprintln(out, st.msg)
+      }
+      where()
+    case "c", "continue":
+      return nil
+    case "b", "break", "d", "delete":
+      set := f[0] == "b" || f[0] == "break"
+      if len(f) != 2 {
+        fmt.Fprintln(out, "usage: break|delete ADDR|OP")
+        continue
+      }
+      if f[1] == "all" && !set {
+        clear(d.breaks)
+        clear(d.opBreaks)
+      } else if op, ok := parseOp(f[1]); ok {
+        d.opBreaks[op] = set
+      } else if addr, err := parseAddr(f[1]); err == nil {
+        d.breaks[addr] = set
+      } else {
+        fmt.Fprintln(out, err)
+      }
+    case "w", "watch", "unwatch":
+      if len(f) != 2 {
+        fmt.Fprintln(out, "usage: watch|unwatch rN|ADDR")
+        continue
+      }
+      if f[1] == "all" && f[0] == "unwatch" {
+        clear(d.watches)
+      } else if loc, err := parseLoc(f[1]); err != nil {
+        fmt.Fprintln(out, err)
+      } else if f[0] == "unwatch" {
+        delete(d.watches, loc)
+      } else {
+        d.watch(loc)
+      }
+    case "r", "regs":
+      fmt.Fprintf(out, "pc %d, %d instructions run\n", m.pc, d.steps)
+      for i, r := range m.reg {
+        fmt.Fprintf(out, "r%d %-6d", i, r)
+      }
+      fmt.Fprintf(out, "\nstack (%d) %v\n", len(m.stack), m.stack[max(0, len(m.stack)-16):])
+      var ops, watches []string
+      for _, op := range keys(d.opBreaks) {
+        ops = append(ops, opName(op))
+      }
+      for _, loc := range slices.Sorted(maps.Keys(d.watches)) {
+        watches = append(watches, locName(loc))
+      }
+      fmt.Fprintf(out, "breaks %v, on %v, watching %v\n", keys(d.breaks), ops, watches)
+    case "x":
+      addr := arg(1, int(m.pc))
+      for i := 0; i < arg(2, 8); i++ {
+        a := (addr + i) % memSize
+        fmt.Fprintf(out, "[%d] %d\n", a, m.mem[a])
+      }
+    case "dis":
+      addr := arg(1, int(m.pc))
+      for i := 0; i < arg(2, 10); i++ {
+        text, n := d.disasmAt(uint16(addr))
+        fmt.Fprintf(out, "%5d  %s\n", addr, text)
+        addr = (addr + n) % memSize
+      }
+    case "set":
+      if len(f) != 3 {
+        fmt.Fprintln(out, "usage: set rN|ADDR VALUE")
+        continue
+      }
+      loc, err1 := parseLoc(f[1])
+      v, err2 := strconv.ParseUint(f[2], 0, 16)
+      switch {
+      case err1 != nil:
+        fmt.Fprintln(out, err1)
+      case err2 != nil || v > maxValue:
+        fmt.Fprintln(out, "bad value", f[2])
+      case loc >= regBase:
+        m.reg[loc-regBase] = uint16(v)
+      default:
+        m.mem[loc] = uint16(v)
+      }
+    case "t", "trace":
+      // A count, a file, or both; a file with no count gets all of it.
+      n, file := -1, ""
+      for i := 1; i < len(f); i++ {
+        if k := arg(i, -1); k >= 0 {
+          n = k
+        } else {
+          file = f[i]
+        }
+      }
+      if n < 0 {
+        n = 20
+        if file != "" {
+          n = traceSize
+        }
+      }
+      if file == "" {
+        for _, e := range d.lastTrace(n) {
+          fmt.Fprintln(out, e)
+        }
+        continue
+      }
+      if err := d.writeTrace(file, n); err != nil {
+        fmt.Fprintln(out, err)
+      } else {
+        fmt.Fprintln(out, "wrote", file)
+      }
+    case "q", "quit":
+      return io.EOF
+    default:
+      fmt.Fprintln(out, debugHelp)
+    }
+  }
+}
+
+// The keys set in a map of breakpoints, in order.
+func keys(m map[uint16]bool) []uint16 {
+  var ks []uint16
+  for k, on := range m {
+    if on {
+      ks = append(ks, k)
+    }
+  }
+  slices.Sort(ks)
+  return ks
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "fmt"
+  "io"
+  "strings"
+  "testing"
+)
+
+// Drive a counting loop with the debugger: breakpoints by address and by
+// opcode, a watchpoint on memory, single steps, and the trace.
+func TestDebugger(t *testing.T) {
+  program := []uint16{
+    opSet, r0, 0, // 0
+    opAdd, r0, r0, 1, // 3
+    opWmem, 100, r0, // 7
+    opEq, r1, r0, 3, // 10
+    opJf, r1, 3, // 14
+    opOut, 'A', // 17
+    opHalt, // 19
+  }
+  var out bytes.Buffer
+  m := &vm{out: &out}
+  m.load(image(program))
+  d := newDebugger(m)
+  expect := func(what string, limit int, kind stopKind, pc, reg0 uint16) {
+    t.Helper()
+    st, err := d.run(limit)
+    if err != nil {
+      t.Fatalf("%s: %v", what, err)
+    }
+    if st.kind != kind || m.pc != pc || m.reg[0] != reg0 {
+      t.Fatalf("%s: stopped (%d %q) at %d with r0=%d, want kind %d at %d with r0=%d",
+        what, st.kind, st.msg, m.pc, m.reg[0], kind, pc, reg0)
+    }
+  }
+
+  expect("step", 2, stopStep, 7, 1)
+  d.breaks[10] = true
+  expect("breakpoint", 0, stopBreak, 10, 1)
+  expect("continuing from a breakpoint", 0, stopBreak, 10, 2)
+  d.breaks[10] = false
+  d.watch(100)
+  expect("watchpoint", 0, stopWatch, 10, 3)
+  delete(d.watches, 100)
+  d.opBreaks[opOut] = true
+  expect("opcode breakpoint", 0, stopOpcode, 17, 3)
+  expect("halt", 0, stopHalt, 19, 3)
+  if out.String() != "A" {
+    t.Errorf("printed %q", out.String())
+  }
+
+  var pcs []uint16
+  for _, e := range d.lastTrace(4) {
+    pcs = append(pcs, e.pc)
+  }
+  if fmt.Sprint(pcs) != "[10 14 17 19]" || d.steps != 15 {
+    t.Errorf("trace ends %v after %d steps, want [10 14 17 19] after 15", pcs, d.steps)
+  }
+  if text := disasm(program[3:]); text != "add r0 r0 1" {
+    t.Errorf("disassembled %q", text)
+  }
+}
+
+
+// A jump past the end of memory stops the debugger with an error, on the
+// jump's own step or on the first of a later run, rather than a panic.
+func TestDebuggerInvalidAddress(t *testing.T) {
+  m := &vm{out: io.Discard}
+  m.load(image([]uint16{opJmp, r0}))
+  m.reg[0] = memSize + 10
+  d := newDebugger(m)
+  d.breaks[0] = true
+  if _, err := d.run(1); err != nil {
+    t.Fatalf("the jump itself failed: %v", err)
+  }
+  for _, limit := range []int{1, 0} {
+    if _, err := d.run(limit); err == nil || !strings.Contains(err.Error(), "invalid address 32778") {
+      t.Errorf("run(%d): got %v, want invalid address 32778", limit, err)
+    }
+  }
+}
+
+// Scripted commands that look at memory near its end, with the pc past
+// it, print what they can instead of panicking.
+func TestDebugSession(t *testing.T) {
+  var out bytes.Buffer
+  m := &vm{out: &out, pc: memSize + 1}
+  m.mem[memSize-1] = opNoop
+  s := &session{m: m, stdin: bufio.NewReader(strings.NewReader("")),
+    script: []string{"r", "x 32766 3", "dis 32767 2", "step"}}
+  err := s.debug(newDebugger(m))
+  if err == nil || !strings.Contains(err.Error(), "invalid address") {
+    t.Errorf("step past memory: got %v", err)
+  }
+  for _, want := range []string{"32769  invalid address", "pc 32769", "[32767] 21", "[0] 0", "32767  noop"} {
+    if !strings.Contains(out.String(), want) {
+      t.Errorf("output lacks %q:\n%s", want, out.String())
+    }
+  }
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "strconv"
+  "strings"
+)
+
+const help = `commands, typed where the game reads input:
+  !save FILE       save the machine state
+  !load FILE       restore a saved state
+  !reg N VALUE     set register N (0-7)
+  !debug           stop the machine in the debugger
+  !quit            exit`
+
+// The interactive session: lines come from the script first, then stdin.
+// Lines starting with "!" are commands and never reach the machine.
+type session struct {
+  m         *vm
+  script    []string
+  stdin     *bufio.Reader
+  flush     func() error // called before blocking on stdin
+  echo      bool
+  pending   string
+  debugging bool // !debug was typed
+}
+
+// The next line from the script, or from stdin once it runs out. The
+// debugger's commands are read this way too, so a script can drive it.
+func (s *session) next() (string, error) {
+  if len(s.script) > 0 {
+    line := s.script[0]
+    s.script = s.script[1:]
+    if s.echo {
+      fmt.Fprintln(s.m.out, line)
+    }
+    return line, nil
+  }
+  if s.flush != nil {
+    s.flush()
+  }
+  text, err := s.stdin.ReadString('\n')
+  if err != nil && text == "" {
+    return "", err
+  }
+  return strings.TrimRight(text, "\r\n"), nil
+}
+
+func (s *session) readLine() (string, error) {
+  for {
+    line, err := s.next()
+    if err != nil {
+      return "", err
+    }
+    if !strings.HasPrefix(line, "!") {
+      return line, nil
+    }
+    if err := s.command(line); err != nil {
+      if err == errInterrupt || err == io.EOF {
+        return "", err
+      }
+      fmt.Fprintln(s.m.out, err)
+    }
+  }
+}
+
+// Run a command. Loading a state cannot happen in the middle of the "in"
+// instruction that is reading, so it is left pending and the machine is
+// interrupted to perform it; so is entering the debugger, which leaves
+// the "in" to run again.
+func (s *session) command(line string) error {
+  fields := strings.Fields(line)
+  switch {
+  case fields[0] == "!save" && len(fields) == 2:
+    if err := s.m.save(fields[1]); err != nil {
+      return err
+    }
+    fmt.Fprintln(s.m.out, "saved", fields[1])
+  case fields[0] == "!load" && len(fields) == 2:
+    s.pending = fields[1]
+    return errInterrupt
+  case fields[0] == "!reg" && len(fields) == 3:
+    n, err1 := strconv.Atoi(fields[1])
+    v, err2 := strconv.ParseUint(fields[2], 10, 15)
+    if err1 != nil || err2 != nil || n < 0 || n >= numRegs {
+      return fmt.Errorf("usage: !reg N VALUE")
+    }
+    s.m.reg[n] = uint16(v)
+  case fields[0] == "!debug":
+    s.debugging = true
+    return errInterrupt
+  case fields[0] == "!quit":
+    return io.EOF
+  default:
+    fmt.Fprintln(s.m.out, help)
+  }
+  return nil
+}
+
+// Carry out a load a command interrupted the machine for.
+func (s *session) interrupted() {
+  if s.pending == "" {
+    return
+  }
+  if err := s.m.restore(s.pending); err != nil {
+    fmt.Fprintln(s.m.out, err)
+  } else {
+    fmt.Fprintln(s.m.out, "loaded", s.pending)
+  }
+  s.pending = ""
+}
+
+func main() {
+  script := flag.String("script", "", "file of input lines to type before reading stdin")
+  state := flag.String("load", "", "start from a saved state instead of the program")
+  debug := flag.Bool("debug", false, "start in the debugger")
+  flag.Usage = func() {
+    fmt.Fprintln(os.Stderr, "usage: synacor [flags] challenge.bin\n\n"+help+"\n\n"+debugHelp+"\n\nflags:")
+    flag.PrintDefaults()
+  }
+  flag.Parse()
+  if flag.NArg() != 1 && *state == "" {
+    flag.Usage()
+    os.Exit(2)
+  }
+  fail := func(err error) {
+    fmt.Fprintln(os.Stderr, "synacor:", err)
+    os.Exit(1)
+  }
+
+  out := bufio.NewWriter(os.Stdout)
+  m := &vm{out: out}
+  s := &session{m: m, stdin: bufio.NewReader(os.Stdin), flush: out.Flush, echo: true, debugging: *debug}
+  m.readLine = s.readLine
+  if *state != "" {
+    if err := m.restore(*state); err != nil {
+      fail(err)
+    }
+  } else {
+    image, err := os.ReadFile(flag.Arg(0))
+    if err != nil {
+      fail(err)
+    }
+    if err := m.load(image); err != nil {
+      fail(err)
+    }
+  }
+  if *script != "" {
+    data, err := os.ReadFile(*script)
+    if err != nil {
+      fail(err)
+    }
+    s.script = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+  }
+
+  // The machine runs under the debugger throughout, so breakpoints set
+  // once stop it whenever they are reached.
+  d := newDebugger(m)
+  for {
+    if s.debugging {
+      s.debugging = false
+      if err := s.debug(d); err != nil {
+        out.Flush()
+        if err != io.EOF {
+          fail(err)
+        }
+        return
+      }
+    }
+    st, err := d.run(0)
+    if err == errInterrupt {
+      s.interrupted()
+      continue
+    }
+    if err == nil && st.kind != stopHalt {
+      fmt.Fprintln(out, st.msg)
+      s.debugging = true
+      continue
+    }
+    out.Flush()
+    if err != nil && err != io.EOF {
+      fail(err)
+    }
+    return
+  }
+}
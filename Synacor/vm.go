@@ -0,0 +1,343 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "encoding/binary"
+  "errors"
+  "fmt"
+  "io"
+  "math"
+  "os"
+)
+
+// The architecture: 15-bit addresses into 16-bit words, eight registers
+// and an unbounded stack. Values 0..32767 are literals and 32768..32775
+// name the registers; anything larger is invalid.
+const (
+  memSize  = 1 << 15
+  modulus  = 1 << 15
+  regBase  = 32768
+  numRegs  = 8
+  maxValue = regBase + numRegs - 1
+)
+
+const (
+  opHalt = iota
+  opSet
+  opPush
+  opPop
+  opEq
+  opGt
+  opJmp
+  opJt
+  opJf
+  opAdd
+  opMult
+  opMod
+  opAnd
+  opOr
+  opNot
+  opRmem
+  opWmem
+  opCall
+  opRet
+  opOut
+  opIn
+  opNoop
+)
+
+// Operand counts, indexed by opcode.
+var arity = [...]int{0, 2, 1, 1, 3, 3, 1, 2, 2, 3, 3, 3, 3, 3, 2, 2, 2, 1, 0, 1, 1, 0}
+
+var errHalted = errors.New("halted")
+
+// Returned by readLine to stop the machine at an "in" instruction without
+// consuming it, so the caller can replace the machine state and resume.
+var errInterrupt = errors.New("interrupted")
+
+type vm struct {
+  mem   [memSize]uint16
+  reg   [numRegs]uint16
+  stack []uint16
+  pc    uint16
+
+  // Characters typed but not yet read by "in". When it runs dry, readLine
+  // is asked for another line, which lets the caller intercept commands.
+  input    []byte
+  readLine func() (string, error)
+  out      io.Writer
+}
+
+// Load a program image of little-endian 16-bit words at address 0.
+func (m *vm) load(image []byte) error {
+  if len(image)%2 != 0 || len(image)/2 > memSize {
+    return fmt.Errorf("program image of %d bytes is not a valid size", len(image))
+  }
+  for i := 0; i < len(image)/2; i++ {
+    m.mem[i] = binary.LittleEndian.Uint16(image[2*i:])
+  }
+  return nil
+}
+
+// The value of an operand: a literal, or the contents of a register.
+func (m *vm) value(v uint16) (uint16, error) {
+  switch {
+  case v < regBase:
+    return v, nil
+  case v <= maxValue:
+    return m.reg[v-regBase], nil
+  }
+  return 0, fmt.Errorf("invalid operand %d at %d", v, m.pc)
+}
+
+// The register an operand names as a destination.
+func (m *vm) register(v uint16) (*uint16, error) {
+  if v < regBase || v > maxValue {
+    return nil, fmt.Errorf("operand %d at %d is not a register", v, m.pc)
+  }
+  return &m.reg[v-regBase], nil
+}
+
+// Execute one instruction. Returns errHalted after halt, or when ret finds
+// the stack empty, which the spec also treats as a halt. Running off the
+// end of memory, or jumping past it, is an invalid address.
+func (m *vm) step() error {
+  if m.pc >= memSize {
+    return fmt.Errorf("invalid address %d", m.pc)
+  }
+  op := m.mem[m.pc]
+  if int(op) >= len(arity) {
+    return fmt.Errorf("invalid opcode %d at %d", op, m.pc)
+  }
+  if int(m.pc)+arity[op] >= memSize {
+    return fmt.Errorf("invalid address %d: operands of %d run off the end of memory", memSize, m.pc)
+  }
+  // Raw operands, and their values for the ones that are read.
+  var raw, val [3]uint16
+  for i := 0; i < arity[op]; i++ {
+    raw[i] = m.mem[int(m.pc)+1+i]
+    val[i], _ = m.value(raw[i])
+  }
+  next := m.pc + 1 + uint16(arity[op])
+
+  // Operands that are read must be valid; the destination operand of
+  // instructions that write one must be a register.
+  check := func(dest bool, reads ...int) (*uint16, error) {
+    for _, i := range reads {
+      if _, err := m.value(raw[i]); err != nil {
+        return nil, err
+      }
+    }
+    if dest {
+      return m.register(raw[0])
+    }
+    return nil, nil
+  }
+  arith := func(f func(b, c uint16) uint16) error {
+    a, err := check(true, 1, 2)
+    if err != nil {
+      return err
+    }
+    *a = f(val[1], val[2]) % modulus
+    return nil
+  }
+  var err error
+  switch op {
+  case opHalt:
+    return errHalted
+  case opSet:
+    var a *uint16
+    if a, err = check(true, 1); err == nil {
+      *a = val[1]
+    }
+  case opPush:
+    if _, err = check(false, 0); err == nil {
+      m.stack = append(m.stack, val[0])
+    }
+  case opPop:
+    var a *uint16
+    if a, err = check(true); err == nil {
+      if len(m.stack) == 0 {
+        return fmt.Errorf("pop from empty stack at %d", m.pc)
+      }
+      *a = m.stack[len(m.stack)-1]
+      m.stack = m.stack[:len(m.stack)-1]
+    }
+  case opEq:
+    err = arith(func(b, c uint16) uint16 { return bool16(b == c) })
+  case opGt:
+    err = arith(func(b, c uint16) uint16 { return bool16(b > c) })
+  case opJmp:
+    if _, err = check(false, 0); err == nil {
+      next = val[0]
+    }
+  case opJt, opJf:
+    if _, err = check(false, 0, 1); err == nil && (val[0] != 0) == (op == opJt) {
+      next = val[1]
+    }
+  case opAdd:
+    err = arith(func(b, c uint16) uint16 { return b + c })
+  case opMult:
+    err = arith(func(b, c uint16) uint16 { return uint16(uint32(b) * uint32(c) % modulus) })
+  case opMod:
+    if val[2] == 0 {
+      return fmt.Errorf("mod by zero at %d", m.pc)
+    }
+    err = arith(func(b, c uint16) uint16 { return b % c })
+  case opAnd:
+    err = arith(func(b, c uint16) uint16 { return b & c })
+  case opOr:
+    err = arith(func(b, c uint16) uint16 { return b | c })
+  case opNot:
+    var a *uint16
+    if a, err = check(true, 1); err == nil {
+      *a = ^val[1] % modulus
+    }
+  case opRmem:
+    var a *uint16
+    if a, err = check(true, 1); err == nil {
+      if val[1] >= memSize {
+        return fmt.Errorf("invalid address %d at %d", val[1], m.pc)
+      }
+      *a = m.mem[val[1]]
+    }
+  case opWmem:
+    if _, err = check(false, 0, 1); err == nil {
+      if val[0] >= memSize {
+        return fmt.Errorf("invalid address %d at %d", val[0], m.pc)
+      }
+      m.mem[val[0]] = val[1]
+    }
+  case opCall:
+    if _, err = check(false, 0); err == nil {
+      m.stack = append(m.stack, next)
+      next = val[0]
+    }
+  case opRet:
+    if len(m.stack) == 0 {
+      return errHalted
+    }
+    next = m.stack[len(m.stack)-1]
+    m.stack = m.stack[:len(m.stack)-1]
+  case opOut:
+    if _, err = check(false, 0); err == nil {
+      _, err = m.out.Write([]byte{byte(val[0])})
+    }
+  case opIn:
+    var a *uint16
+    if a, err = check(true); err == nil {
+      for len(m.input) == 0 {
+        line, rerr := m.readLine()
+        if rerr != nil {
+          return rerr
+        }
+        m.input = append(m.input, line+"\n"...)
+      }
+      *a = uint16(m.input[0])
+      m.input = m.input[1:]
+    }
+  case opNoop:
+  }
+  if err != nil {
+    return err
+  }
+  m.pc = next
+  return nil
+}
+
+func bool16(b bool) uint16 {
+  if b {
+    return 1
+  }
+  return 0
+}
+
+// Run until halt or an error; a halt is not an error.
+func (m *vm) run() error {
+  for {
+    if err := m.step(); err != nil {
+      if err == errHalted {
+        return nil
+      }
+      return err
+    }
+  }
+}
+
+// The saved state is every word of the machine in order, little-endian:
+// pc, the registers, the stack depth and stack, the pending input length
+// and input, then all of memory. The stack is unbounded, so the two
+// lengths take two words each, low word first.
+func (m *vm) save(path string) error {
+  if uint64(len(m.stack)) > math.MaxUint32 || uint64(len(m.input)) > math.MaxUint32 {
+    return errors.New("machine too large to save")
+  }
+  f, err := os.Create(path)
+  if err != nil {
+    return err
+  }
+  w := bufio.NewWriter(f)
+  words := []uint16{m.pc}
+  words = append(words, m.reg[:]...)
+  words = append(words, uint16(len(m.stack)), uint16(len(m.stack)>>16))
+  words = append(words, m.stack...)
+  words = append(words, uint16(len(m.input)), uint16(len(m.input)>>16))
+  for _, c := range m.input {
+    words = append(words, uint16(c))
+  }
+  binary.Write(w, binary.LittleEndian, words)
+  binary.Write(w, binary.LittleEndian, m.mem[:])
+  if err := w.Flush(); err != nil {
+    f.Close()
+    return err
+  }
+  return f.Close()
+}
+
+func (m *vm) restore(path string) error {
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return err
+  }
+  words := make([]uint16, len(data)/2)
+  binary.Read(bytes.NewReader(data), binary.LittleEndian, words)
+  bad := fmt.Errorf("%s is not a saved state", path)
+  take := func(n int) ([]uint16, error) {
+    if n > len(words) {
+      return nil, bad
+    }
+    t := words[:n]
+    words = words[n:]
+    return t, nil
+  }
+  length := func(w []uint16) int { return int(w[0]) | int(w[1])<<16 }
+  head, err := take(1 + numRegs + 2)
+  if err != nil {
+    return err
+  }
+  stack, err := take(length(head[1+numRegs:]))
+  if err != nil {
+    return err
+  }
+  n, err := take(2)
+  if err != nil {
+    return err
+  }
+  input, err := take(length(n))
+  if err != nil {
+    return err
+  }
+  if len(words) != memSize {
+    return bad
+  }
+  m.pc = head[0]
+  copy(m.reg[:], head[1:1+numRegs])
+  m.stack = append([]uint16(nil), stack...)
+  m.input = m.input[:0]
+  for _, c := range input {
+    m.input = append(m.input, byte(c))
+  }
+  copy(m.mem[:], words)
+  return nil
+}
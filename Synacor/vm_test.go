@@ -0,0 +1,194 @@
+package main
+
+import (
+  "bytes"
+  "io"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+const (
+  r0 = regBase + iota
+  r1
+)
+
+// An opcode test runs a small program from a fresh machine and compares
+// the output, registers and memory afterwards.
+type opcodeTest struct {
+  name    string
+  program []uint16
+  regs    map[int]uint16
+  input   string
+  out     string
+  want    map[int]uint16
+  mem     map[uint16]uint16
+  fails   bool
+}
+
+var opcodeTests = []opcodeTest{
+  {name: "spec example", program: []uint16{opAdd, r0, r1, 4, opOut, r0, opHalt},
+    regs: map[int]uint16{1: 61}, out: "A"},
+  {name: "set", program: []uint16{opSet, r0, 123, opSet, r1, r0}, want: map[int]uint16{0: 123, 1: 123}},
+  {name: "push and pop", program: []uint16{opPush, 5, opPush, 7, opPop, r0, opPop, r1},
+    want: map[int]uint16{0: 7, 1: 5}},
+  {name: "pop from empty stack", program: []uint16{opPop, r0}, fails: true},
+  {name: "eq", program: []uint16{opEq, r0, 3, 3, opEq, r1, 3, 4}, want: map[int]uint16{0: 1, 1: 0}},
+  {name: "gt", program: []uint16{opGt, r0, 4, 3, opGt, r1, 3, 3}, want: map[int]uint16{0: 1, 1: 0}},
+  {name: "jmp", program: []uint16{opJmp, 3, opHalt, opOut, 'A'}, out: "A"},
+  {name: "jt taken", program: []uint16{opJt, 1, 4, opHalt, opOut, 'B'}, out: "B"},
+  {name: "jt not taken", program: []uint16{opJt, 0, 5, opOut, 'C', opHalt}, out: "C"},
+  {name: "jf taken", program: []uint16{opJf, 0, 4, opHalt, opOut, 'D'}, out: "D"},
+  {name: "jf not taken", program: []uint16{opJf, r0, 5, opOut, 'E', opHalt}, regs: map[int]uint16{0: 9}, out: "E"},
+  {name: "add wraps", program: []uint16{opAdd, r0, 32767, 2}, want: map[int]uint16{0: 1}},
+  {name: "mult wraps", program: []uint16{opMult, r0, 32767, 32767}, want: map[int]uint16{0: 1}},
+  {name: "mod", program: []uint16{opMod, r0, 10, 3}, want: map[int]uint16{0: 1}},
+  {name: "mod by zero", program: []uint16{opMod, r0, 10, 0}, fails: true},
+  {name: "and", program: []uint16{opAnd, r0, 12, 10}, want: map[int]uint16{0: 8}},
+  {name: "or", program: []uint16{opOr, r0, 12, 10}, want: map[int]uint16{0: 14}},
+  {name: "not is 15-bit", program: []uint16{opNot, r0, 0, opNot, r1, 32767}, want: map[int]uint16{0: 32767, 1: 0}},
+  {name: "rmem", program: []uint16{opRmem, r0, 4, opHalt, 42}, want: map[int]uint16{0: 42}},
+  {name: "wmem through a register", program: []uint16{opWmem, r0, 77}, regs: map[int]uint16{0: 100},
+    mem: map[uint16]uint16{100: 77}},
+  {name: "call and ret", program: []uint16{opCall, 5, opOut, 'B', opHalt, opOut, 'A', opRet}, out: "AB"},
+  {name: "ret on empty stack halts", program: []uint16{opRet, opOut, 'X'}},
+  {name: "in", program: []uint16{opIn, r0, opIn, r1}, input: "hi", want: map[int]uint16{0: 'h', 1: 'i'}},
+  {name: "noop", program: []uint16{opNoop, opOut, 'A'}, out: "A"},
+  {name: "invalid opcode", program: []uint16{22}, fails: true},
+  {name: "invalid operand", program: []uint16{opAdd, r0, maxValue + 1, 1}, fails: true},
+  {name: "literal destination", program: []uint16{opSet, 5, 1}, fails: true},
+  {name: "jmp past memory", program: []uint16{opJmp, r0}, regs: map[int]uint16{0: memSize}, fails: true},
+  {name: "ret past memory", program: []uint16{opPush, r0, opRet}, regs: map[int]uint16{0: 65535}, fails: true},
+  {name: "rmem past memory", program: []uint16{opRmem, r0, r1}, regs: map[int]uint16{1: memSize + 5}, fails: true},
+  {name: "wmem past memory", program: []uint16{opWmem, r0, 1}, regs: map[int]uint16{0: memSize}, fails: true},
+}
+
+func image(program []uint16) []byte {
+  data := make([]byte, 2*len(program))
+  for i, w := range program {
+    data[2*i], data[2*i+1] = byte(w), byte(w>>8)
+  }
+  return data
+}
+
+func TestOpcodes(t *testing.T) {
+  for _, c := range opcodeTests {
+    t.Run(c.name, func(t *testing.T) {
+      var out bytes.Buffer
+      m := &vm{out: &out, input: []byte(c.input)}
+      m.readLine = func() (string, error) { return "", io.EOF }
+      if err := m.load(image(c.program)); err != nil {
+        t.Fatal(err)
+      }
+      for r, v := range c.regs {
+        m.reg[r] = v
+      }
+      err := m.run()
+      if c.fails {
+        if err == nil {
+          t.Error("ran without error")
+        }
+        return
+      }
+      if err != nil {
+        t.Fatal(err)
+      }
+      if out.String() != c.out {
+        t.Errorf("output %q, want %q", out.String(), c.out)
+      }
+      for r, v := range c.want {
+        if m.reg[r] != v {
+          t.Errorf("register %d is %d, want %d", r, m.reg[r], v)
+        }
+      }
+      for addr, v := range c.mem {
+        if m.mem[addr] != v {
+          t.Errorf("memory %d is %d, want %d", addr, m.mem[addr], v)
+        }
+      }
+    })
+  }
+}
+
+// Running off the end of memory stops with an error rather than a panic,
+// whether the last instruction falls through or its operands would lie
+// past the end.
+func TestEndOfMemory(t *testing.T) {
+  for _, c := range []struct {
+    name string
+    at   uint16
+    prog []uint16
+  }{
+    {"fall through", memSize - 1, []uint16{opNoop}},
+    {"operands", memSize - 2, []uint16{opAdd, r0}},
+  } {
+    m := &vm{out: io.Discard}
+    copy(m.mem[c.at:], c.prog)
+    m.pc = c.at
+    err := m.run()
+    if err == nil || !strings.Contains(err.Error(), "invalid address") {
+      t.Errorf("%s: got %v, want an invalid address", c.name, err)
+    }
+  }
+}
+
+// Interrupt a program at its first "in", save, restore into a new machine
+// and check it carries on exactly where the first one stopped.
+func TestSaveState(t *testing.T) {
+  program := []uint16{opSet, r0, 7, opPush, 9, opIn, r1, opOut, r1, opPop, r0, opOut, r0}
+  m := &vm{out: io.Discard}
+  m.readLine = func() (string, error) { return "", errInterrupt }
+  m.load(image(program))
+  m.input = []byte{}
+  if err := m.run(); err != errInterrupt {
+    t.Fatalf("expected an interrupt, got %v", err)
+  }
+  path := filepath.Join(t.TempDir(), "state")
+  if err := m.save(path); err != nil {
+    t.Fatal(err)
+  }
+  var out bytes.Buffer
+  resumed := &vm{out: &out}
+  resumed.readLine = func() (string, error) { return "Z", nil }
+  if err := resumed.restore(path); err != nil {
+    t.Fatal(err)
+  }
+  if err := resumed.run(); err != nil {
+    t.Fatal(err)
+  }
+  if out.String() != "Z\t" || resumed.reg[0] != 9 {
+    t.Errorf("resumed machine printed %q with r0=%d", out.String(), resumed.reg[0])
+  }
+}
+
+// A stack deeper than a word can count survives a save whole.
+func TestSaveDeepStack(t *testing.T) {
+  m := &vm{out: io.Discard, pc: 3}
+  for i := range 70000 {
+    m.stack = append(m.stack, uint16(i))
+  }
+  m.input = []byte("look\n")
+  path := filepath.Join(t.TempDir(), "state")
+  if err := m.save(path); err != nil {
+    t.Fatal(err)
+  }
+  var r vm
+  if err := r.restore(path); err != nil {
+    t.Fatal(err)
+  }
+  if len(r.stack) != 70000 || r.stack[69999] != 69999%65536 || string(r.input) != "look\n" || r.pc != 3 {
+    t.Errorf("restored %d stack words and input %q at %d", len(r.stack), r.input, r.pc)
+  }
+}
+
+func TestRestoreTruncated(t *testing.T) {
+  m := &vm{out: io.Discard}
+  path := filepath.Join(t.TempDir(), "state")
+  m.save(path)
+  data, _ := os.ReadFile(path)
+  os.WriteFile(path, data[:len(data)-2], 0o644)
+  if err := m.restore(path); err == nil || !strings.Contains(err.Error(), "not a saved state") {
+    t.Errorf("got %v, want not a saved state", err)
+  }
+}
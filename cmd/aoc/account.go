@@ -0,0 +1,160 @@
+package main
+
+import (
+  "encoding/json"
+  "errors"
+  "fmt"
+  "os"
+  "path/filepath"
+  "regexp"
+  "strconv"
+  "strings"
+)
+
+// Several adventofcode.com accounts, a personal one and a work one say,
+// each with its own session token, and so its own puzzle inputs and
+// answers. The account with no name is the usual one: its inputs are the
+// NN.in files beside the solutions and its answers the comments in them.
+// A named account, chosen with -account or AOC_ACCOUNT, keeps everything
+// of its own under .aoc/accounts/NAME: its inputs and snapshots in
+// EVENT/EDITION, as NN.in and snapshots/NN.NAME.txt, its answers in
+// answers.json, and the history, status, solves and submissions that
+// otherwise live in .aoc.
+var account = os.Getenv("AOC_ACCOUNT")
+
+var accountName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+func checkAccount(name string) error {
+  if name != "" && !accountName.MatchString(name) {
+    return fmt.Errorf("bad account name %q; use lower case letters, digits and dashes", name)
+  }
+  return nil
+}
+
+// The directory of a named account's own files.
+func accountDir(root, name string) string {
+  return filepath.Join(root, ".aoc", "accounts", name)
+}
+
+// The variable that overrides a named account's stored session token,
+// AOC_SESSION_WORK for the account "work", as AOC_SESSION does the usual
+// one's.
+func sessionEnv() string {
+  if account == "" {
+    return "AOC_SESSION"
+  }
+  return "AOC_SESSION_" + strings.ToUpper(strings.ReplaceAll(account, "-", "_"))
+}
+
+// A named account's answers, by puzzle id and part.
+type answerFile map[string]map[int]string
+
+func answersPath(root string) string {
+  return filepath.Join(stateDir(root), "answers.json")
+}
+
+func loadAnswers(root string) (answerFile, error) {
+  data, err := os.ReadFile(answersPath(root))
+  if errors.Is(err, os.ErrNotExist) {
+    return answerFile{}, nil
+  }
+  if err != nil {
+    return nil, err
+  }
+  af := answerFile{}
+  return af, json.Unmarshal(data, &af)
+}
+
+func (af answerFile) save(root string) error {
+  path := answersPath(root)
+  if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+    return err
+  }
+  data, err := json.MarshalIndent(af, "", "  ")
+  if err != nil {
+    return err
+  }
+  if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+    return err
+  }
+  return os.Rename(path+".tmp", path)
+}
+
+// Record a right answer for the account in use: in answers.json for a
+// named one, or for the usual one left to be written as a comment on the
+// line that prints it, which is what says so.
+func recordAnswer(root, id string, part int, answer string) (string, error) {
+  if account == "" {
+    return fmt.Sprintf("record it on the line that prints part %d: # %s", part, answer), nil
+  }
+  af, err := loadAnswers(root)
+  if err != nil {
+    return "", err
+  }
+  if af[id] == nil {
+    af[id] = map[int]string{}
+  }
+  af[id][part] = answer
+  if err := af.save(root); err != nil {
+    return "", err
+  }
+  return fmt.Sprintf("recorded for account %s in %s", account, answersPath(root)), nil
+}
+
+// Give the solutions the named account's answers in place of the ones in
+// their comments, which are the usual account's.
+func useAccountAnswers(root string, sols []solution) error {
+  if account == "" {
+    return nil
+  }
+  af, err := loadAnswers(root)
+  if err != nil {
+    return fmt.Errorf("%s: %v", answersPath(root), err)
+  }
+  for i := range sols {
+    sols[i].expected = map[int]string{}
+    for p, a := range af[sols[i].id()] {
+      sols[i].expected[p] = a
+    }
+  }
+  return nil
+}
+
+// aoc answers: list a named account's answers, or with -part and -answer
+// record one got some other way than aoc submit.
+func answers(sols []solution, o *options) int {
+  if account == "" {
+    fmt.Fprintln(os.Stderr, "aoc: the usual account's answers are the comments in the solutions; use -account")
+    return 2
+  }
+  if o.part != 0 || o.answer != "" {
+    if len(sols) == 0 || o.day == 0 || o.part == 0 || o.answer == "" {
+      fmt.Fprintln(os.Stderr, "aoc: recording an answer needs a puzzle, -part and -answer")
+      return 2
+    }
+    msg, err := recordAnswer(o.root, sols[0].id(), o.part, strings.TrimSpace(o.answer))
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+    fmt.Println(msg)
+    return 0
+  }
+  seen := map[string]bool{}
+  for _, s := range sols {
+    if seen[s.id()] {
+      continue
+    }
+    seen[s.id()] = true
+    var parts []string
+    for p := 1; p <= s.edition.event.parts; p++ {
+      a, ok := s.expected[p]
+      if !ok {
+        a = "-"
+      }
+      parts = append(parts, strconv.Itoa(p)+": "+a)
+    }
+    fmt.Printf("%-28s %s\n", s.id(), strings.Join(parts, "  "))
+  }
+  return 0
+}
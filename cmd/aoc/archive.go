@@ -0,0 +1,357 @@
+package main
+
+import (
+  "archive/tar"
+  "bufio"
+  "bytes"
+  "compress/gzip"
+  "crypto/rand"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "io/fs"
+  "os"
+  "path/filepath"
+  "sort"
+  "strings"
+  "time"
+)
+
+// Puzzle inputs may not be published, so they are never committed, and a
+// new machine starts without them. An archive carries them, and the
+// answers that live outside the solutions, from one machine to another:
+// every NN.in of every edition, a named account's inputs, and the
+// answers.json and submissions.json files in .aoc.
+//
+// The archive is a gzipped tar, with a manifest of each file's SHA-256
+// first, sealed with AES-256-GCM under a key derived from a passphrase as
+// the session token's file store does. The cipher finds any change to
+// the archive; the manifest, checked on unpacking, any file that does not
+// come out as it went in.
+const archiveMagic = "aoc-archive 1\n"
+
+// The archive's header, in the clear and authenticated with the contents.
+type archiveHeader struct {
+  Created    time.Time `json:"created"`
+  Files      int       `json:"files"`
+  Iterations int       `json:"iterations"`
+  Salt       []byte    `json:"salt"`
+  Nonce      []byte    `json:"nonce"`
+}
+
+const archiveManifest = "MANIFEST.json"
+
+// The files to archive, as slash-separated paths relative to root.
+func archiveFiles(root string) ([]string, error) {
+  var files []string
+  for _, e := range events {
+    eds, err := e.editions(root)
+    if err != nil {
+      continue
+    }
+    for _, ed := range eds {
+      matches, err := filepath.Glob(filepath.Join(ed.dir, "[0-9][0-9].in"))
+      if err != nil {
+        return nil, err
+      }
+      for _, m := range matches {
+        rel, _ := filepath.Rel(root, m)
+        files = append(files, filepath.ToSlash(rel))
+      }
+    }
+  }
+  state := filepath.Join(root, ".aoc")
+  err := filepath.WalkDir(state, func(path string, d fs.DirEntry, err error) error {
+    if errors.Is(err, fs.ErrNotExist) && path == state {
+      return filepath.SkipDir
+    }
+    if err != nil || d.IsDir() {
+      return err
+    }
+    rel, _ := filepath.Rel(root, path)
+    rel = filepath.ToSlash(rel)
+    switch {
+    case d.Name() == "answers.json", d.Name() == "submissions.json",
+      strings.HasPrefix(rel, ".aoc/accounts/") && solutionInput(d.Name()):
+      files = append(files, rel)
+    }
+    return nil
+  })
+  sort.Strings(files)
+  return files, err
+}
+
+func solutionInput(name string) bool {
+  return len(name) == 5 && strings.HasSuffix(name, ".in") && name[0] >= '0' && name[0] <= '9' && name[1] >= '0' && name[1] <= '9'
+}
+
+func packArchive(root, out string) (int, error) {
+  files, err := archiveFiles(root)
+  if err != nil {
+    return 0, err
+  }
+  if len(files) == 0 {
+    return 0, errors.New("no inputs or answers to archive")
+  }
+  var contents bytes.Buffer
+  gz := gzip.NewWriter(&contents)
+  tw := tar.NewWriter(gz)
+  add := func(name string, data []byte, mtime time.Time) error {
+    if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: mtime}); err != nil {
+      return err
+    }
+    _, err := tw.Write(data)
+    return err
+  }
+  manifest := map[string]string{}
+  data := map[string][]byte{}
+  for _, f := range files {
+    b, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(f)))
+    if err != nil {
+      return 0, err
+    }
+    sum := sha256.Sum256(b)
+    manifest[f], data[f] = hex.EncodeToString(sum[:]), b
+  }
+  m, _ := json.MarshalIndent(manifest, "", "  ")
+  now := time.Now()
+  if err := add(archiveManifest, m, now); err != nil {
+    return 0, err
+  }
+  for _, f := range files {
+    mtime := now
+    if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(f))); err == nil {
+      mtime = info.ModTime()
+    }
+    if err := add(f, data[f], mtime); err != nil {
+      return 0, err
+    }
+  }
+  if err := tw.Close(); err != nil {
+    return 0, err
+  }
+  if err := gz.Close(); err != nil {
+    return 0, err
+  }
+
+  passphrase, err := newPassphrase("passphrase to encrypt the archive with: ")
+  if err != nil {
+    return 0, err
+  }
+  h := archiveHeader{Created: now.UTC(), Files: len(files), Iterations: tokenIterations, Salt: make([]byte, 16)}
+  rand.Read(h.Salt)
+  aead, err := tokenCipher(passphrase, h.Salt, h.Iterations)
+  if err != nil {
+    return 0, err
+  }
+  h.Nonce = make([]byte, aead.NonceSize())
+  rand.Read(h.Nonce)
+  header, _ := json.Marshal(h)
+  sealed := aead.Seal(nil, h.Nonce, contents.Bytes(), header)
+
+  var b bytes.Buffer
+  b.WriteString(archiveMagic)
+  b.Write(header)
+  b.WriteByte('\n')
+  b.Write(sealed)
+  return len(files), os.WriteFile(out, b.Bytes(), 0o600)
+}
+
+// Open an archive: check and decrypt it, then read every file, checking
+// each against the manifest.
+func openArchive(path string) (archiveHeader, map[string][]byte, error) {
+  var h archiveHeader
+  raw, err := os.ReadFile(path)
+  if err != nil {
+    return h, nil, err
+  }
+  if !bytes.HasPrefix(raw, []byte(archiveMagic)) {
+    return h, nil, fmt.Errorf("%s is not an aoc archive", path)
+  }
+  raw = raw[len(archiveMagic):]
+  i := bytes.IndexByte(raw, '\n')
+  if i < 0 || json.Unmarshal(raw[:i], &h) != nil {
+    return h, nil, fmt.Errorf("%s: damaged header", path)
+  }
+  header, sealed := raw[:i], raw[i+1:]
+  passphrase, err := readSecret("passphrase for the archive: ", "AOC_PASSPHRASE")
+  if err != nil {
+    return h, nil, err
+  }
+  aead, err := tokenCipher(passphrase, h.Salt, h.Iterations)
+  if err != nil {
+    return h, nil, err
+  }
+  contents, err := aead.Open(nil, h.Nonce, sealed, header)
+  if err != nil {
+    return h, nil, errors.New("wrong passphrase, or the archive is damaged")
+  }
+
+  gz, err := gzip.NewReader(bytes.NewReader(contents))
+  if err != nil {
+    return h, nil, err
+  }
+  tr := tar.NewReader(gz)
+  var manifest map[string]string
+  files := map[string][]byte{}
+  for {
+    hdr, err := tr.Next()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return h, nil, err
+    }
+    data, err := io.ReadAll(tr)
+    if err != nil {
+      return h, nil, err
+    }
+    if hdr.Name == archiveManifest {
+      if err := json.Unmarshal(data, &manifest); err != nil {
+        return h, nil, fmt.Errorf("manifest: %v", err)
+      }
+      continue
+    }
+    if !filepath.IsLocal(filepath.FromSlash(hdr.Name)) {
+      return h, nil, fmt.Errorf("archive holds a path outside the repository: %s", hdr.Name)
+    }
+    files[hdr.Name] = data
+  }
+  if manifest == nil {
+    return h, nil, errors.New("archive has no manifest")
+  }
+  for name, want := range manifest {
+    data, ok := files[name]
+    if !ok {
+      return h, nil, fmt.Errorf("%s is in the manifest but not the archive", name)
+    }
+    sum := sha256.Sum256(data)
+    if hex.EncodeToString(sum[:]) != want {
+      return h, nil, fmt.Errorf("%s does not match its checksum", name)
+    }
+  }
+  if len(files) != len(manifest) {
+    return h, nil, errors.New("archive holds files not in its manifest")
+  }
+  return h, files, nil
+}
+
+// Restore an archive's files under root. A file already there with other
+// contents is left alone unless force is set, so nothing newer is lost.
+func unpackArchive(root, path string, force, dryRun bool) error {
+  h, files, err := openArchive(path)
+  if err != nil {
+    return err
+  }
+  names := make([]string, 0, len(files))
+  for name := range files {
+    names = append(names, name)
+  }
+  sort.Strings(names)
+  var written, same, kept int
+  for _, name := range names {
+    dest := filepath.Join(root, filepath.FromSlash(name))
+    if old, err := os.ReadFile(dest); err == nil {
+      if bytes.Equal(old, files[name]) {
+        same++
+        continue
+      }
+      if !force {
+        fmt.Printf("kept   %s, which differs from the archive's; -force replaces it\n", name)
+        kept++
+        continue
+      }
+    }
+    written++
+    if dryRun {
+      fmt.Printf("would write %s\n", name)
+      continue
+    }
+    if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+      return err
+    }
+    if err := os.WriteFile(dest, files[name], 0o644); err != nil {
+      return err
+    }
+    fmt.Printf("wrote  %s\n", name)
+  }
+  verb := "restored"
+  if dryRun {
+    verb = "would restore"
+  }
+  fmt.Printf("archive of %s verified: %d files; %s %d, %d already there, %d kept\n",
+    h.Created.Local().Format("2006-01-02 15:04"), len(files), verb, written, same, kept)
+  return nil
+}
+
+// aoc archive pack|unpack|list: carry inputs and answers between machines.
+func archive(args []string) int {
+  fs := flag.NewFlagSet("aoc archive", flag.ExitOnError)
+  root := fs.String("root", "", "repository root (default: found from the working directory)")
+  out := fs.String("o", "aoc-archive.enc", "archive to write, for pack")
+  force := fs.Bool("force", false, "replace files that differ from the archive's, for unpack")
+  dryRun := fs.Bool("n", false, "only say what unpack would write")
+  fs.Usage = func() {
+    fmt.Fprintln(os.Stderr, "usage: aoc archive pack [-o FILE] | unpack [-force] [-n] FILE | list FILE")
+    fs.PrintDefaults()
+  }
+  if len(args) == 0 {
+    fs.Usage()
+    return 2
+  }
+  action := args[0]
+  fs.Parse(args[1:])
+  if *root == "" {
+    r, ok := findRoot(".")
+    if !ok {
+      fmt.Fprintln(os.Stderr, "aoc: not inside the repository; use -root")
+      return 2
+    }
+    *root = r
+  }
+
+  var err error
+  switch action {
+  case "pack":
+    var n int
+    if n, err = packArchive(*root, *out); err == nil {
+      fmt.Printf("packed %d files into %s; keep it private\n", n, *out)
+    }
+  case "unpack", "list":
+    if fs.NArg() != 1 {
+      fs.Usage()
+      return 2
+    }
+    if action == "unpack" {
+      err = unpackArchive(*root, fs.Arg(0), *force, *dryRun)
+      break
+    }
+    var h archiveHeader
+    var files map[string][]byte
+    if h, files, err = openArchive(fs.Arg(0)); err == nil {
+      names := make([]string, 0, len(files))
+      for name := range files {
+        names = append(names, name)
+      }
+      sort.Strings(names)
+      w := bufio.NewWriter(os.Stdout)
+      for _, name := range names {
+        fmt.Fprintf(w, "%8d  %s\n", len(files[name]), name)
+      }
+      fmt.Fprintf(w, "%d files, made %s, all verified\n", len(files), h.Created.Local().Format("2006-01-02 15:04"))
+      w.Flush()
+    }
+  default:
+    fs.Usage()
+    return 2
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  return 0
+}
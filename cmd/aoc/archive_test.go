@@ -0,0 +1,142 @@
+package main
+
+import (
+  "bufio"
+  "bytes"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// Answer prompts with the lines of input, as if typed, rather than from
+// AOC_PASSPHRASE.
+func typed(t *testing.T, input string) {
+  t.Setenv("AOC_PASSPHRASE", "")
+  os.Unsetenv("AOC_PASSPHRASE")
+  r := stdinReader
+  stdinReader = bufio.NewReader(strings.NewReader(input))
+  t.Cleanup(func() { stdinReader = r })
+}
+
+// A repository with an input and an answers file, and an archive of it.
+func packed(t *testing.T) (root, archive string) {
+  root = t.TempDir()
+  for name, data := range map[string]string{
+    "AdventOfCode/2024/01.in":  "3 4\n4 3\n",
+    ".aoc/answers.json":        `{"2024/01": ["11", "31"]}`,
+    "AdventOfCode/2024/01.py": "print('not archived')\n",
+  } {
+    path := filepath.Join(root, filepath.FromSlash(name))
+    os.MkdirAll(filepath.Dir(path), 0o755)
+    if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+      t.Fatal(err)
+    }
+  }
+  archive = filepath.Join(t.TempDir(), "archive.enc")
+  t.Setenv("AOC_PASSPHRASE", "correct horse")
+  n, err := packArchive(root, archive)
+  if err != nil || n != 2 {
+    t.Fatalf("packed %d files: %v", n, err)
+  }
+  return root, archive
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+  root, archive := packed(t)
+  h, files, err := openArchive(archive)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if h.Files != 2 || len(files) != 2 {
+    t.Fatalf("header says %d files, archive holds %v", h.Files, files)
+  }
+  for name, data := range files {
+    want, _ := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+    if !bytes.Equal(data, want) {
+      t.Errorf("%s: got %q, want %q", name, data, want)
+    }
+  }
+
+  // Unpacking into a fresh repository writes the files back.
+  fresh := t.TempDir()
+  if err := unpackArchive(fresh, archive, false, false); err != nil {
+    t.Fatal(err)
+  }
+  got, err := os.ReadFile(filepath.Join(fresh, "AdventOfCode", "2024", "01.in"))
+  if err != nil || string(got) != "3 4\n4 3\n" {
+    t.Errorf("unpacked %q, %v", got, err)
+  }
+}
+
+func TestArchiveWrongPassphrase(t *testing.T) {
+  _, archive := packed(t)
+  t.Setenv("AOC_PASSPHRASE", "correct horse battery")
+  if _, _, err := openArchive(archive); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+    t.Errorf("got %v, want a wrong passphrase error", err)
+  }
+}
+
+// Any change to the archive is found: to the ciphertext, to the header it
+// authenticates, or to the key derivation count that is read before
+// anything can be.
+func TestArchiveTampered(t *testing.T) {
+  _, archive := packed(t)
+  raw, err := os.ReadFile(archive)
+  if err != nil {
+    t.Fatal(err)
+  }
+  for _, c := range []struct {
+    name   string
+    change func([]byte) []byte
+    err    string
+  }{
+    {"ciphertext", func(b []byte) []byte {
+      b[len(b)-20] ^= 1
+      return b
+    }, "wrong passphrase, or the archive is damaged"},
+    {"header", func(b []byte) []byte {
+      return bytes.Replace(b, []byte(`"files":2`), []byte(`"files":3`), 1)
+    }, "wrong passphrase, or the archive is damaged"},
+    {"iterations", func(b []byte) []byte {
+      return bytes.Replace(b, []byte(`"iterations":600000`), []byte(`"iterations":600000000000`), 1)
+    }, "implausible key derivation count"},
+    {"weak iterations", func(b []byte) []byte {
+      return bytes.Replace(b, []byte(`"iterations":600000`), []byte(`"iterations":1`), 1)
+    }, "implausible key derivation count"},
+    {"magic", func(b []byte) []byte {
+      return b[1:]
+    }, "is not an aoc archive"},
+  } {
+    changed := c.change(append([]byte{}, raw...))
+    if bytes.Equal(changed, raw) {
+      t.Fatalf("%s: the change did nothing", c.name)
+    }
+    path := filepath.Join(t.TempDir(), "archive.enc")
+    os.WriteFile(path, changed, 0o600)
+    if _, _, err := openArchive(path); err == nil || !strings.Contains(err.Error(), c.err) {
+      t.Errorf("%s: got %v, want %q", c.name, err, c.err)
+    }
+  }
+}
+
+// A new passphrase is typed twice, and a mistyped one seals nothing.
+func TestArchiveConfirmsPassphrase(t *testing.T) {
+  root, _ := packed(t)
+  archive := filepath.Join(t.TempDir(), "new.enc")
+  typed(t, "correct horse\ncorrect hose\n")
+  if _, err := packArchive(root, archive); err == nil || err.Error() != "the passphrases do not match" {
+    t.Errorf("got %v, want a mismatch", err)
+  }
+  if _, err := os.Stat(archive); err == nil {
+    t.Error("wrote an archive under a mistyped passphrase")
+  }
+
+  typed(t, "correct horse\ncorrect horse\ncorrect horse\n")
+  if _, err := packArchive(root, archive); err != nil {
+    t.Fatal(err)
+  }
+  if _, _, err := openArchive(archive); err != nil {
+    t.Error(err)
+  }
+}
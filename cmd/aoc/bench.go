@@ -0,0 +1,263 @@
+package main
+
+import (
+  "encoding/json"
+  "errors"
+  "fmt"
+  "math"
+  "os"
+  "path/filepath"
+  "sort"
+  "time"
+)
+
+// Benchmarks run each solution several times and keep every run's time as
+// a sample. A set of samples saved under a name, say before an
+// optimization, is compared with a later set the way benchstat does: a
+// change counts only when a Mann-Whitney U test says the two sets of
+// samples are unlikely to come from the same distribution, so noise is not
+// mistaken for a speedup. Solutions are timed whole, so a day's parts are
+// measured together.
+type benchFile struct {
+  Time    time.Time                  `json:"time"`
+  Commit  string                     `json:"commit,omitempty"`
+  Samples map[string][]time.Duration `json:"samples"`
+}
+
+// The significance level below which a difference is reported.
+const benchAlpha = 0.05
+
+func benchPath(root, name string) string {
+  return filepath.Join(stateDir(root), "bench", name+".json")
+}
+
+func loadBench(root, name string) (*benchFile, error) {
+  data, err := os.ReadFile(benchPath(root, name))
+  if errors.Is(err, os.ErrNotExist) {
+    return nil, fmt.Errorf("no benchmark saved as %q; save one with aoc bench -save %s", name, name)
+  }
+  if err != nil {
+    return nil, err
+  }
+  var bf benchFile
+  return &bf, json.Unmarshal(data, &bf)
+}
+
+func (bf *benchFile) save(root, name string) error {
+  path := benchPath(root, name)
+  if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+    return err
+  }
+  data, err := json.MarshalIndent(bf, "", "  ")
+  if err != nil {
+    return err
+  }
+  return os.WriteFile(path, data, 0o644)
+}
+
+// A summary of samples: the median, and the spread of those left after
+// dropping outliers beyond Tukey's fences, as a fraction of the median.
+type benchStats struct {
+  n      int
+  median time.Duration
+  spread float64
+}
+
+func summarize(samples []time.Duration) benchStats {
+  s := append([]time.Duration(nil), samples...)
+  sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+  st := benchStats{n: len(s), median: quantile(s, 0.5)}
+  if len(s) == 0 || st.median == 0 {
+    return st
+  }
+  q1, q3 := quantile(s, 0.25), quantile(s, 0.75)
+  lo, hi := q1-(q3-q1)*3/2, q3+(q3-q1)*3/2
+  var kept []time.Duration
+  for _, d := range s {
+    if d >= lo && d <= hi {
+      kept = append(kept, d)
+    }
+  }
+  for _, d := range kept {
+    st.spread = max(st.spread, math.Abs(float64(d-st.median))/float64(st.median))
+  }
+  return st
+}
+
+// The q quantile of sorted samples, interpolating between neighbours.
+func quantile(sorted []time.Duration, q float64) time.Duration {
+  if len(sorted) == 0 {
+    return 0
+  }
+  pos := q * float64(len(sorted)-1)
+  i := int(pos)
+  if i+1 >= len(sorted) {
+    return sorted[i]
+  }
+  return sorted[i] + time.Duration((pos-float64(i))*float64(sorted[i+1]-sorted[i]))
+}
+
+func (st benchStats) String() string {
+  return fmt.Sprintf("%v ± %2.0f%%", st.median.Round(10*time.Microsecond), st.spread*100)
+}
+
+// The two-sided p-value of the Mann-Whitney U test of whether x and y come
+// from the same distribution, by the normal approximation with a
+// correction for ties and for continuity. That is close enough from about
+// eight samples a side; fewer rarely show anything significant.
+func mannWhitney(x, y []time.Duration) float64 {
+  n1, n2 := len(x), len(y)
+  if n1 == 0 || n2 == 0 {
+    return 1
+  }
+  type sample struct {
+    d     time.Duration
+    fromX bool
+  }
+  all := make([]sample, 0, n1+n2)
+  for _, d := range x {
+    all = append(all, sample{d, true})
+  }
+  for _, d := range y {
+    all = append(all, sample{d, false})
+  }
+  sort.Slice(all, func(i, j int) bool { return all[i].d < all[j].d })
+
+  // Rank the samples from 1, ties taking the mean of their ranks.
+  var rankX, ties float64
+  for i := 0; i < len(all); {
+    j := i
+    for j < len(all) && all[j].d == all[i].d {
+      j++
+    }
+    rank := float64(i+j+1) / 2
+    for k := i; k < j; k++ {
+      if all[k].fromX {
+        rankX += rank
+      }
+    }
+    t := float64(j - i)
+    ties += t*t*t - t
+    i = j
+  }
+  n := float64(n1 + n2)
+  u := rankX - float64(n1*(n1+1))/2
+  mean := float64(n1*n2) / 2
+  variance := float64(n1*n2) / 12 * (n + 1 - ties/(n*(n-1)))
+  if variance <= 0 {
+    return 1
+  }
+  z := max(math.Abs(u-mean)-0.5, 0) / math.Sqrt(variance)
+  return math.Erfc(z / math.Sqrt2)
+}
+
+// Benchmark the solutions o.repeat times each, after a run to warm the
+// caches that is not counted. With -compare the samples are compared with
+// those saved under that name; with -save they are saved under theirs.
+func bench(sols []solution, o *options) int {
+  var base *benchFile
+  if o.against != "" {
+    var err error
+    if base, err = loadBench(o.root, o.against); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+  }
+  runs := max(o.repeat, 1)
+  cur := &benchFile{Time: time.Now(), Commit: headCommit(o.root), Samples: map[string][]time.Duration{}}
+  failed := false
+  table := o.output == "table"
+  var rows []resultRow
+
+  if base != nil && table {
+    fmt.Printf("%-30s %-20s %-20s %s\n", "", o.against, "now", "delta")
+  }
+  for _, s := range sols {
+    key := s.key()
+    var samples []time.Duration
+    var err error
+    for n := 0; n <= runs; n++ {
+      r := run(s, o.timeout)
+      if r.err != nil {
+        err = r.err
+        break
+      }
+      if n > 0 {
+        samples = append(samples, r.elapsed)
+      }
+    }
+    switch {
+    case err == errNoInput:
+      continue
+    case err != nil:
+      if table {
+        fmt.Printf("%-30s FAIL %v\n", key, err)
+      } else {
+        fmt.Fprintf(os.Stderr, "aoc: %s: %v\n", key, err)
+      }
+      failed = true
+      continue
+    }
+    cur.Samples[key] = samples
+    st := summarize(samples)
+    row := solutionRow(s)
+    row.Time = cur.Time
+    row.Bench = &benchRow{Runs: st.n, Median: st.median, Spread: st.spread}
+    rows = append(rows, row)
+    var old []time.Duration
+    ok := false
+    if base != nil {
+      old, ok = base.Samples[key]
+    }
+    switch {
+    case !ok && base == nil:
+      if table {
+        fmt.Printf("%-30s %-20s n=%d\n", key, st, st.n)
+      }
+      continue
+    case !ok:
+      if table {
+        fmt.Printf("%-30s %-20s %-20s (not in %s)\n", key, "", st, o.against)
+      }
+      continue
+    }
+    was := summarize(old)
+    p := mannWhitney(old, samples)
+    row.Bench.Baseline, row.Bench.P = was.median, &p
+    delta := "~"
+    if p < benchAlpha {
+      change := float64(st.median)/float64(was.median) - 1
+      row.Bench.Delta = &change
+      delta = fmt.Sprintf("%+.2f%%", change*100)
+    }
+    if table {
+      fmt.Printf("%-30s %-20s %-20s %-8s (p=%.3f n=%d+%d)\n", key, was, st, delta, p, was.n, st.n)
+    }
+  }
+  if !table {
+    if err := writeRows(os.Stdout, o.output, benchColumns, rows); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+  }
+  if base != nil && table {
+    fmt.Printf("\n~ means no significant difference at p < %.2f; %s was saved %s", benchAlpha, o.against, base.Time.Local().Format("2006-01-02 15:04"))
+    if base.Commit != "" {
+      fmt.Printf(" at %s", base.Commit)
+    }
+    fmt.Println()
+  }
+  if o.save != "" {
+    if err := cur.save(o.root, o.save); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+    if table {
+      fmt.Printf("saved %d benchmarks as %s\n", len(cur.Samples), o.save)
+    }
+  }
+  if failed {
+    return 1
+  }
+  return 0
+}
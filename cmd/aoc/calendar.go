@@ -0,0 +1,178 @@
+package main
+
+import (
+  "fmt"
+  "os"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// One day of the calendar, summed over its implementations: the parts any
+// of them last verified as right, the fastest run that got no part wrong,
+// and the worst of how the rest went.
+type calendarDay struct {
+  solutions int
+  stars     int
+  fastest   time.Duration
+  status    string
+}
+
+// How bad each last outcome is, so one broken implementation shows.
+var outcomeRank = map[string]int{
+  outcomeOK: 1, outcomeUnverified: 2, outcomeTimeout: 3, outcomeWrong: 4, outcomeFailed: 5,
+}
+
+func calendarDays(sols []solution, st statusFile) map[int]*calendarDay {
+  days := map[int]*calendarDay{}
+  for _, s := range sols {
+    d := days[s.day]
+    if d == nil {
+      d = &calendarDay{}
+      days[s.day] = d
+    }
+    d.solutions++
+    rec := st[s.key()]
+    if rec == nil {
+      continue
+    }
+    stars := 0
+    for _, p := range rec.Parts {
+      if p == partOK {
+        stars++
+      }
+    }
+    d.stars = max(d.stars, stars)
+    if (rec.Outcome == outcomeOK || rec.Outcome == outcomeUnverified) && (d.fastest == 0 || rec.Elapsed < d.fastest) {
+      d.fastest = rec.Elapsed
+    }
+    if outcomeRank[rec.Outcome] > outcomeRank[d.status] {
+      d.status = rec.Outcome
+    }
+  }
+  return days
+}
+
+// The days of an edition: Advent of Code had 25 until 2025 and 12 since;
+// other events have as many as there are solutions for.
+func editionDays(ed edition, days map[int]*calendarDay) int {
+  if ed.event.name == "aoc" {
+    if year, _ := strconv.Atoi(ed.name); year < 2025 {
+      return 25
+    }
+    return 12
+  }
+  n := 0
+  for d := range days {
+    n = max(n, d)
+  }
+  return n
+}
+
+// The two lines of a day's cell, each cellWidth wide.
+const cellWidth = 10
+
+func (d *calendarDay) cell(day, parts int) (string, string) {
+  top := fmt.Sprintf("%2d ", day)
+  if d == nil {
+    return top + strings.Repeat(" ", cellWidth-3), strings.Repeat(" ", cellWidth)
+  }
+  stars := strings.Repeat("*", d.stars) + strings.Repeat(".", parts-d.stars)
+  color := colorDim
+  switch {
+  case d.stars == parts:
+    color = colorYellow
+  case d.stars > 0:
+    color = colorGreen
+  }
+  top += paint(color, fmt.Sprintf("%-*s", cellWidth-3, stars))
+
+  var bottom string
+  switch d.status {
+  case "":
+    bottom = paint(colorDim, fmt.Sprintf("%-*s", cellWidth, "   not run"))
+  case outcomeOK, outcomeUnverified:
+    bottom = fmt.Sprintf("%-*s", cellWidth, "   "+d.fastest.Round(time.Millisecond).String())
+  case outcomeTimeout:
+    bottom = paint(colorMagenta, fmt.Sprintf("%-*s", cellWidth, "   timeout"))
+  default:
+    bottom = paint(colorRed, fmt.Sprintf("%-*s", cellWidth, "   "+d.status))
+  }
+  return top, bottom
+}
+
+// Show an edition as a calendar of the days' stars, status and fastest
+// runtimes, from the last verify. Advent of Code editions are laid
+// out on December's weeks; others in rows of seven.
+func calendar(sols []solution, o *options) int {
+  var ed edition
+  if o.edition == "" {
+    // The latest Advent of Code by default.
+    for _, s := range sols {
+      if s.edition.event.name == "aoc" {
+        ed = s.edition
+      }
+    }
+  } else if len(sols) > 0 {
+    ed = sols[0].edition
+  }
+  if ed.dir == "" {
+    fmt.Fprintln(os.Stderr, "aoc: no solutions to show; use -e and -y")
+    return 1
+  }
+  var edSols []solution
+  for _, s := range sols {
+    if s.edition.dir == ed.dir {
+      edSols = append(edSols, s)
+    }
+  }
+  st, err := loadStatus(o.root)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  days := calendarDays(edSols, st)
+  n := editionDays(ed, days)
+  parts := ed.event.parts
+
+  fmt.Printf("%s %s\n\n", ed.event.title, ed.name)
+  offset := 0
+  if year, err := strconv.Atoi(ed.name); err == nil && ed.event.name == "aoc" {
+    offset = int(time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC).Weekday())
+    var header []string
+    for _, wd := range []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"} {
+      header = append(header, fmt.Sprintf("%-*s", cellWidth, wd))
+    }
+    fmt.Println(strings.TrimRight(strings.Join(header, " "), " "))
+  }
+  for week := 0; week*7-offset < n; week++ {
+    var top, bottom []string
+    for wd := 0; wd < 7; wd++ {
+      day := week*7 + wd - offset + 1
+      if day < 1 || day > n {
+        top, bottom = append(top, strings.Repeat(" ", cellWidth)), append(bottom, strings.Repeat(" ", cellWidth))
+        continue
+      }
+      t, b := days[day].cell(day, parts)
+      top, bottom = append(top, t), append(bottom, b)
+    }
+    fmt.Println(strings.TrimRight(strings.Join(top, " "), " "))
+    fmt.Println(strings.TrimRight(strings.Join(bottom, " "), " "))
+  }
+
+  stars, solved, broken := 0, 0, 0
+  var total time.Duration
+  for _, d := range days {
+    stars += d.stars
+    if d.stars == parts {
+      solved++
+    }
+    if d.status == outcomeWrong || d.status == outcomeFailed || d.status == outcomeTimeout {
+      broken++
+    }
+    total += d.fastest
+  }
+  fmt.Printf("\n%d of %d stars, %d of %d days solved, %d with a broken implementation; %v in all\n",
+    stars, n*parts, solved, n, broken, total.Round(time.Millisecond))
+  return 0
+}
@@ -0,0 +1,28 @@
+package main
+
+import "os"
+
+// Terminal colors for command output. They are used only when standard
+// output is a terminal and NO_COLOR is not set (https://no-color.org).
+var useColor = func() bool {
+  info, err := os.Stdout.Stat()
+  return err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == ""
+}()
+
+// SGR codes for paint.
+const (
+  colorRed     = "31"
+  colorGreen   = "32"
+  colorYellow  = "33"
+  colorMagenta = "35"
+  colorDim     = "2"
+)
+
+// Color s, which should already be padded, since the escapes take up no
+// room on screen but count in a width.
+func paint(color, s string) string {
+  if !useColor {
+    return s
+  }
+  return "\x1b[" + color + "m" + s + "\x1b[0m"
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+  "fmt"
+  "os"
+  "strings"
+  "time"
+)
+
+// Compare runs every implementation of each puzzle on the same input,
+// checks that they agree on each part's answer, and prints each one's time
+// as a multiple of the fastest. Puzzles with a single implementation are
+// left out. Each implementation runs o.repeat times and keeps its fastest
+// run, which steadies the ratios for quick solutions.
+func compare(sols []solution, o *options) int {
+  var order []string
+  byPuzzle := map[string][]solution{}
+  for _, s := range sols {
+    if byPuzzle[s.id()] == nil {
+      order = append(order, s.id())
+    }
+    byPuzzle[s.id()] = append(byPuzzle[s.id()], s)
+  }
+
+  compared, failed := 0, false
+  for _, id := range order {
+    impls := byPuzzle[id]
+    if len(impls) < 2 {
+      continue
+    }
+    if _, err := os.Stat(impls[0].input()); err != nil {
+      fmt.Printf("%s: skip (no input)\n\n", id)
+      continue
+    }
+    compared++
+    fmt.Printf("%s (%d implementations)\n", id, len(impls))
+
+    results := make([]result, len(impls))
+    for i, s := range impls {
+      for n := 0; n < max(o.repeat, 1); n++ {
+        r := run(s, o.timeout)
+        if err := recordRun(o.root, r); err != nil {
+          fmt.Fprintln(os.Stderr, "aoc:", err)
+        }
+        if n == 0 || r.err == nil && r.elapsed < results[i].elapsed {
+          results[i] = r
+        }
+        if r.err != nil {
+          break
+        }
+      }
+    }
+
+    var fastest time.Duration
+    for _, r := range results {
+      if r.err == nil && (fastest == 0 || r.elapsed < fastest) {
+        fastest = r.elapsed
+      }
+    }
+    for _, r := range results {
+      if r.err != nil {
+        fmt.Printf("  %-16s FAIL  %v\n", r.sol.name(), r.err)
+        failed = true
+        continue
+      }
+      ratio := float64(r.elapsed) / float64(max(fastest, 1))
+      fmt.Printf("  %-16s %-10s %8v  %7.2fx\n", r.sol.name(), strings.Join(r.check(), " "),
+        r.elapsed.Round(time.Millisecond), ratio)
+    }
+    if diffs := disagreements(results, impls[0].edition.event.parts); len(diffs) > 0 {
+      failed = true
+      for _, d := range diffs {
+        fmt.Println("  DIFFER " + d)
+      }
+    } else {
+      fmt.Println("  answers agree")
+    }
+    fmt.Println()
+  }
+
+  if compared == 0 {
+    fmt.Println("no puzzles with more than one implementation to compare")
+  }
+  if failed {
+    return 1
+  }
+  return 0
+}
+
+// Describe each part on which the implementations that ran printed
+// different answers. An implementation that printed nothing for a part,
+// such as one written for part 1 only, does not count against the others.
+func disagreements(results []result, parts int) []string {
+  var diffs []string
+  for p := 1; p <= parts; p++ {
+    var got []string
+    seen := map[string]bool{}
+    for _, r := range results {
+      a, ok := r.answers[p]
+      if r.err != nil || !ok {
+        continue
+      }
+      seen[a] = true
+      got = append(got, fmt.Sprintf("%s %s", r.sol.name(), a))
+    }
+    if len(seen) > 1 {
+      diffs = append(diffs, fmt.Sprintf("part %d: %s", p, strings.Join(got, ", ")))
+    }
+  }
+  return diffs
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+  "flag"
+  "fmt"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+)
+
+// Completion scripts hand the words typed so far to "aoc __complete",
+// which answers with the candidates for the last one, one per line: a
+// command, a flag of that command, or a value for the flag before it, with
+// years, days and strategies found in the repository.
+var completionScripts = map[string]string{
+  "bash": `_aoc() {
+  local IFS=$'\n'
+  COMPREPLY=($(aoc __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _aoc aoc
+`,
+  "zsh": `#compdef aoc
+_aoc() {
+  local -a candidates
+  candidates=(${(f)"$(aoc __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+  compadd -a candidates
+}
+compdef _aoc aoc
+`,
+  "fish": `function __aoc_complete
+  set -l words (commandline -opc)
+  set -e words[1]
+  aoc __complete $words (commandline -ct) 2>/dev/null
+end
+complete -c aoc -f -a '(__aoc_complete)'
+`,
+}
+
+func completion(args []string) int {
+  if len(args) != 1 || completionScripts[args[0]] == "" {
+    fmt.Fprintln(os.Stderr, "usage: aoc completion bash|zsh|fish")
+    return 2
+  }
+  fmt.Print(completionScripts[args[0]])
+  return 0
+}
+
+// The candidates for the last of words, the command line after "aoc".
+func complete(words []string) []string {
+  if len(words) == 0 {
+    words = []string{""}
+  }
+  cur := words[len(words)-1]
+  if len(words) == 1 {
+    return withPrefix(commands, cur)
+  }
+  fs, _ := newFlagSet(words[0])
+  if prev := words[len(words)-2]; strings.HasPrefix(prev, "-") && !strings.Contains(prev, "=") {
+    if f := fs.Lookup(strings.TrimLeft(prev, "-")); f != nil && !isBoolFlag(f) {
+      return withPrefix(flagValues(f.Name, words[:len(words)-2]), cur)
+    }
+  }
+  if !strings.HasPrefix(cur, "-") {
+    return nil
+  }
+  var names []string
+  fs.VisitAll(func(f *flag.Flag) {
+    names = append(names, "-"+f.Name)
+  })
+  return withPrefix(names, cur)
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+  b, ok := f.Value.(interface{ IsBoolFlag() bool })
+  return ok && b.IsBoolFlag()
+}
+
+func withPrefix(candidates []string, prefix string) []string {
+  var out []string
+  for _, c := range candidates {
+    if strings.HasPrefix(c, prefix) {
+      out = append(out, c)
+    }
+  }
+  return out
+}
+
+// The values worth offering for a flag, narrowed by the event, edition
+// and language flags already given in words.
+func flagValues(name string, words []string) []string {
+  var f filter
+  for i := 1; i < len(words); i++ {
+    flagName, value, hasValue := strings.Cut(strings.TrimLeft(words[i], "-"), "=")
+    if !hasValue && i+1 < len(words) {
+      value = words[i+1]
+    }
+    switch flagName {
+    case "e":
+      f.event = value
+    case "y":
+      f.edition = value
+    case "lang":
+      f.lang = value
+    }
+  }
+  if f.event == "" && f.edition != "" {
+    f.event = "aoc"
+  }
+  switch name {
+  case "e":
+    return strings.Split(eventNames(), ", ")
+  case "lang":
+    var langs []string
+    for l := range languages {
+      langs = append(langs, l)
+    }
+    sort.Strings(langs)
+    return langs
+  case "output":
+    return outputFormats
+  case "y", "d", "algo":
+  default:
+    return nil
+  }
+
+  root, ok := findRoot(".")
+  if !ok {
+    return nil
+  }
+  var values []string
+  seen := map[string]bool{}
+  add := func(v string) {
+    if !seen[v] {
+      seen[v] = true
+      values = append(values, v)
+    }
+  }
+  if name == "y" {
+    if f.event == "" {
+      f.event = "aoc"
+    }
+    e, _ := findEvent(f.event)
+    eds, _ := e.editions(root)
+    for _, ed := range eds {
+      add(ed.name)
+    }
+    return values
+  }
+  sols, _ := discover(root, f)
+  if name == "algo" {
+    add("all")
+  }
+  for _, s := range sols {
+    switch name {
+    case "d":
+      add(strconv.Itoa(s.day))
+    case "algo":
+      for _, a := range s.algos {
+        add(a)
+      }
+    }
+  }
+  if name == "d" {
+    sort.Slice(values, func(i, j int) bool { return numericLess(values[i], values[j]) })
+  }
+  return values
+}
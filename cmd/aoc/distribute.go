@@ -0,0 +1,473 @@
+package main
+
+import (
+  "crypto/hmac"
+  "crypto/rand"
+  "crypto/sha256"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "math/big"
+  "net"
+  "os"
+  "path/filepath"
+  "regexp"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Experimental: a heavy puzzle's search split across machines. The
+// coordinator, aoc distribute, splits a solution into shards and hands
+// them out to workers, aoc work -join, which connect over TCP, run a shard
+// and send back its answers; the coordinator merges them.
+//
+// A solution opts in by saying how to merge each part's shard answers:
+//
+//   # shards: 2=sum
+//
+// and reading AOC_SHARD, "k/n", to try only its share of candidates, say
+// those i with i % n == k. Merges are sum, min, max, or same, the default,
+// for parts every shard works out in full and must agree on. A shard may
+// leave out a part it has nothing to say about.
+//
+// Workers get the solution's source and input, not the repository, so a
+// shardable solution must stand alone. A worker runs whatever source it
+// is sent, so the coordinator listens on localhost unless -addr says
+// otherwise, and both ends must share a secret, from AOC_SHARD_SECRET or
+// typed at a prompt, that signs every message between them.
+var shardsLine = regexp.MustCompile(`(?m)(?:#|//)\s*shards:\s*(.*?)\s*$`)
+
+var shardMerges = map[string]bool{"sum": true, "min": true, "max": true, "same": true}
+
+func shardMergesOf(path string) (map[int]string, error) {
+  src, err := os.ReadFile(path)
+  if err != nil {
+    return nil, err
+  }
+  m := shardsLine.FindSubmatch(src)
+  if m == nil {
+    return nil, fmt.Errorf("%s has no \"shards:\" comment saying how to merge its shards", filepath.Base(path))
+  }
+  merges := map[int]string{}
+  for _, field := range strings.Fields(string(m[1])) {
+    part, merge, _ := strings.Cut(field, "=")
+    p, err := strconv.Atoi(part)
+    if err != nil || !shardMerges[merge] {
+      return nil, fmt.Errorf("bad shard merge %q; want PART=sum, min, max or same", field)
+    }
+    merges[p] = merge
+  }
+  return merges, nil
+}
+
+// A shard of work sent to a worker, and what comes back.
+type shardJob struct {
+  Shard   int           `json:"shard"`
+  Shards  int           `json:"shards"`
+  Event   string        `json:"event"`
+  Edition string        `json:"edition"`
+  Day     int           `json:"day"`
+  Lang    string        `json:"lang"`
+  Variant string        `json:"variant,omitempty"`
+  Algo    string        `json:"algo,omitempty"`
+  Seed    int64         `json:"seed"`
+  Source  []byte        `json:"source"`
+  Input   []byte        `json:"input"`
+  Timeout time.Duration `json:"timeout"`
+}
+
+type shardResult struct {
+  Shard   int            `json:"shard"`
+  Worker  string         `json:"worker"`
+  Answers map[int]string `json:"answers"`
+  Elapsed time.Duration  `json:"elapsed"`
+  Error   string         `json:"error,omitempty"`
+}
+
+// The secret shared by the coordinator and its workers.
+func shardSecret() ([]byte, error) {
+  secret, err := readSecret("secret shared with the workers: ", "AOC_SHARD_SECRET")
+  if err != nil {
+    return nil, err
+  }
+  if secret == "" {
+    return nil, errors.New("the shared secret may not be empty")
+  }
+  return []byte(secret), nil
+}
+
+// One end of a connection between the coordinator and a worker. Each
+// message after the hellos carries an HMAC-SHA256, under the shared
+// secret, of its kind, the connection's nonce and its body. The nonce is
+// both ends' hellos together, so a message recorded on one connection is
+// no good on another, whichever end is fooled.
+type shardConn struct {
+  enc   *json.Encoder
+  dec   *json.Decoder
+  key   []byte
+  nonce []byte
+}
+
+type shardHello struct {
+  Nonce []byte `json:"nonce"`
+}
+
+type signedMessage struct {
+  Body json.RawMessage `json:"body"`
+  MAC  []byte          `json:"mac"`
+}
+
+var errBadMAC = errors.New("message not signed with the shared secret")
+
+const nonceSize = 16
+
+// Exchange hellos over conn: the worker's goes first. The other end is
+// only known to hold the secret once its first signed message checks out.
+func newShardConn(conn net.Conn, key []byte, worker bool) (*shardConn, error) {
+  c := &shardConn{enc: json.NewEncoder(conn), dec: json.NewDecoder(conn), key: key}
+  mine := make([]byte, nonceSize)
+  rand.Read(mine)
+  var theirs shardHello
+  if worker {
+    if err := c.enc.Encode(shardHello{mine}); err != nil {
+      return nil, err
+    }
+  }
+  if err := c.dec.Decode(&theirs); err != nil {
+    return nil, err
+  }
+  if len(theirs.Nonce) != nonceSize {
+    return nil, errors.New("bad hello")
+  }
+  if worker {
+    c.nonce = append(mine, theirs.Nonce...)
+  } else {
+    if err := c.enc.Encode(shardHello{mine}); err != nil {
+      return nil, err
+    }
+    c.nonce = append(theirs.Nonce, mine...)
+  }
+  return c, nil
+}
+
+func (c *shardConn) mac(kind string, body []byte) []byte {
+  h := hmac.New(sha256.New, c.key)
+  h.Write([]byte(kind))
+  h.Write([]byte{0})
+  h.Write(c.nonce)
+  h.Write(body)
+  return h.Sum(nil)
+}
+
+func (c *shardConn) send(kind string, v any) error {
+  body, err := json.Marshal(v)
+  if err != nil {
+    return err
+  }
+  return c.enc.Encode(signedMessage{body, c.mac(kind, body)})
+}
+
+func (c *shardConn) recv(kind string, v any) error {
+  var m signedMessage
+  if err := c.dec.Decode(&m); err != nil {
+    return err
+  }
+  if !hmac.Equal(m.MAC, c.mac(kind, m.Body)) {
+    return errBadMAC
+  }
+  return json.Unmarshal(m.Body, v)
+}
+
+// Run one shard in a scratch edition directory holding just the solution
+// and its input, the way a worker sees it.
+func runShard(job shardJob, worker string) shardResult {
+  res := shardResult{Shard: job.Shard, Worker: worker}
+  e, ok := findEvent(job.Event)
+  if !ok || languages[job.Lang] == nil || languages[job.Lang].plugin {
+    res.Error = fmt.Sprintf("cannot run %s solutions of %s", job.Lang, job.Event)
+    return res
+  }
+  dir, err := os.MkdirTemp("", "aoc-shard-")
+  if err != nil {
+    res.Error = err.Error()
+    return res
+  }
+  defer os.RemoveAll(dir)
+  sol := solution{
+    edition: edition{e, job.Edition, dir, ""}, day: job.Day, lang: job.Lang, variant: job.Variant,
+    algo: job.Algo, seed: job.Seed, shard: fmt.Sprintf("%d/%d", job.Shard, job.Shards),
+  }
+  name := fmt.Sprintf("%02d.%s", job.Day, sol.name())
+  if job.Algo != "" {
+    name = strings.TrimSuffix(name, "@"+job.Algo)
+  }
+  sol.path = filepath.Join(dir, name)
+  if err := os.WriteFile(sol.path, job.Source, 0o644); err != nil {
+    res.Error = err.Error()
+    return res
+  }
+  if err := os.WriteFile(sol.input(), job.Input, 0o644); err != nil {
+    res.Error = err.Error()
+    return res
+  }
+  r := run(sol, job.Timeout)
+  res.Answers, res.Elapsed = r.answers, r.elapsed
+  if r.err != nil {
+    res.Error = r.err.Error()
+  }
+  return res
+}
+
+// Merge the shards' answers part by part.
+func mergeShards(results []shardResult, merges map[int]string, parts int) (map[int]string, error) {
+  merged := map[int]string{}
+  for p := 1; p <= parts; p++ {
+    merge := merges[p]
+    if merge == "" {
+      merge = "same"
+    }
+    var acc *big.Int
+    for _, r := range results {
+      a, ok := r.Answers[p]
+      if !ok {
+        continue
+      }
+      if merge == "same" {
+        if prev, seen := merged[p]; seen && prev != a {
+          return nil, fmt.Errorf("part %d: shards disagree, %s and %s", p, prev, a)
+        }
+        merged[p] = a
+        continue
+      }
+      n, ok := new(big.Int).SetString(a, 10)
+      if !ok {
+        return nil, fmt.Errorf("part %d: shard %d answered %q, which is not a number to %s", p, r.Shard, a, merge)
+      }
+      switch {
+      case acc == nil:
+        acc = n
+      case merge == "sum":
+        acc.Add(acc, n)
+      case merge == "min" && n.Cmp(acc) < 0, merge == "max" && n.Cmp(acc) > 0:
+        acc = n
+      }
+    }
+    if acc != nil {
+      merged[p] = acc.String()
+    }
+  }
+  return merged, nil
+}
+
+// The coordinator's queue of shards. A shard whose worker goes away goes
+// back on the queue for another.
+type shardQueue struct {
+  jobs    chan shardJob
+  results chan shardResult
+  done    chan struct{}
+  key     []byte
+}
+
+// How long a worker has to say hello and prove it holds the secret.
+const handshakeTimeout = 10 * time.Second
+
+// Serve one remote worker until the queue is done or the worker fails.
+// It is sent no job until it has shown it holds the secret.
+func (q *shardQueue) serveWorker(conn net.Conn) {
+  defer conn.Close()
+  name := conn.RemoteAddr().String()
+  conn.SetDeadline(time.Now().Add(handshakeTimeout))
+  c, err := newShardConn(conn, q.key, false)
+  if err == nil {
+    err = c.recv("ready", &struct{}{})
+  }
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "aoc: turning away %s: %v\n", name, err)
+    return
+  }
+  conn.SetDeadline(time.Time{})
+  fmt.Printf("worker %s joined\n", name)
+  for {
+    var job shardJob
+    select {
+    case job = <-q.jobs:
+    case <-q.done:
+      return
+    }
+    var res shardResult
+    err := c.send("job", job)
+    if err == nil {
+      err = c.recv("result", &res)
+    }
+    if err != nil || res.Worker == "" {
+      fmt.Fprintf(os.Stderr, "aoc: worker %s went away (%v); shard %d goes back on the queue\n", name, err, job.Shard)
+      q.jobs <- job
+      return
+    }
+    q.results <- res
+  }
+}
+
+// Split one solution into shards, run them on the workers that join and
+// on local ones, and print the merged answers like verify would.
+func distribute(sols []solution, o *options) int {
+  if len(sols) != 1 {
+    fmt.Fprintf(os.Stderr, "aoc: distribute needs exactly one solution, and %d match; use -y, -d and -lang\n", len(sols))
+    return 2
+  }
+  sol := sols[0]
+  merges, err := shardMergesOf(sol.path)
+  if err == nil && languages[sol.lang].plugin {
+    err = errors.New("plugins cannot be distributed")
+  }
+  var source, input []byte
+  if err == nil {
+    source, err = os.ReadFile(sol.path)
+  }
+  if err == nil {
+    input, err = os.ReadFile(sol.input())
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  key, err := shardSecret()
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  ln, err := net.Listen("tcp", o.addr)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  defer ln.Close()
+
+  n := max(o.shards, 1)
+  q := &shardQueue{make(chan shardJob, n), make(chan shardResult, n), make(chan struct{}), key}
+  for k := 0; k < n; k++ {
+    q.jobs <- shardJob{
+      Shard: k, Shards: n, Event: sol.edition.event.name, Edition: sol.edition.name, Day: sol.day,
+      Lang: sol.lang, Variant: sol.variant, Algo: sol.algo, Seed: sol.seed,
+      Source: source, Input: input, Timeout: o.timeout,
+    }
+  }
+  fmt.Printf("%s.%s in %d shards; workers join with: aoc work -join %s\n", sol.id(), sol.name(), n, ln.Addr())
+  go func() {
+    for {
+      conn, err := ln.Accept()
+      if err != nil {
+        return
+      }
+      go q.serveWorker(conn)
+    }
+  }()
+  for i := 0; i < o.local; i++ {
+    go func() {
+      for {
+        select {
+        case job := <-q.jobs:
+          q.results <- runShard(job, "local")
+        case <-q.done:
+          return
+        }
+      }
+    }()
+  }
+
+  start := time.Now()
+  results := make([]shardResult, 0, n)
+  failed := false
+  for len(results) < n {
+    r := <-q.results
+    results = append(results, r)
+    status := fmt.Sprintf("%v", r.Elapsed.Round(time.Millisecond))
+    if r.Error != "" {
+      status, failed = "FAIL "+r.Error, true
+    }
+    fmt.Printf("  shard %d/%d on %s: %s\n", r.Shard, n, r.Worker, status)
+  }
+  close(q.done)
+  sort.Slice(results, func(i, j int) bool { return results[i].Shard < results[j].Shard })
+
+  res := result{sol: sol, elapsed: time.Since(start)}
+  if failed {
+    res.err = errors.New("a shard failed")
+  } else if res.answers, err = mergeShards(results, merges, sol.edition.event.parts); err != nil {
+    res.err = err
+  }
+  for p := 1; p <= sol.edition.event.parts; p++ {
+    if a, ok := res.answers[p]; ok {
+      res.output += fmt.Sprintf("Part %d: %s\n", p, a)
+    }
+  }
+  if err := recordRun(o.root, res); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+  }
+  fmt.Print("\n" + res.output)
+  if res.err != nil {
+    fmt.Println("error:", res.err)
+    return 1
+  }
+  fmt.Printf("%s (%v)\n", strings.Join(res.check(), " "), res.elapsed.Round(time.Millisecond))
+  if res.outcome() == outcomeWrong {
+    return 1
+  }
+  return 0
+}
+
+// Join a coordinator and run the shards it sends, reconnecting when it
+// goes away, until interrupted.
+func work(o *options) int {
+  if o.join == "" {
+    fmt.Fprintln(os.Stderr, "aoc: work needs -join host:port")
+    return 2
+  }
+  key, err := shardSecret()
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  host, _ := os.Hostname()
+  for {
+    conn, err := net.Dial("tcp", o.join)
+    if err != nil {
+      time.Sleep(2 * time.Second)
+      continue
+    }
+    if err := workFor(conn, key, host); err != nil && err != io.EOF {
+      fmt.Fprintf(os.Stderr, "aoc: %s: %v\n", o.join, err)
+    }
+    conn.Close()
+    fmt.Printf("left %s\n", o.join)
+    // Wait for the next coordinator rather than spinning on this one as
+    // it shuts down.
+    time.Sleep(2 * time.Second)
+  }
+}
+
+// Run the shards sent over one connection until it ends. A job that is
+// not signed with the secret ends it unrun.
+func workFor(conn net.Conn, key []byte, host string) error {
+  c, err := newShardConn(conn, key, true)
+  if err != nil {
+    return err
+  }
+  if err := c.send("ready", struct{}{}); err != nil {
+    return err
+  }
+  fmt.Printf("joined %s\n", conn.RemoteAddr())
+  for {
+    var job shardJob
+    if err := c.recv("job", &job); err != nil {
+      return err
+    }
+    fmt.Printf("  %s/%s/%02d shard %d/%d\n", job.Event, job.Edition, job.Day, job.Shard, job.Shards)
+    if err := c.send("result", runShard(job, host)); err != nil {
+      return err
+    }
+  }
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+  "encoding/json"
+  "errors"
+  "net"
+  "testing"
+)
+
+// A coordinator's and a worker's end of a fresh connection, after the
+// hellos, each with its own key.
+func shardPair(t *testing.T, coordKey, workerKey string) (coord, worker *shardConn) {
+  t.Helper()
+  a, b := net.Pipe()
+  t.Cleanup(func() { a.Close(); b.Close() })
+  done := make(chan error)
+  go func() {
+    var err error
+    worker, err = newShardConn(b, []byte(workerKey), true)
+    done <- err
+  }()
+  coord, err := newShardConn(a, []byte(coordKey), false)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := <-done; err != nil {
+    t.Fatal(err)
+  }
+  return coord, worker
+}
+
+func TestShardConn(t *testing.T) {
+  coord, worker := shardPair(t, "s3cret", "s3cret")
+  go coord.send("job", shardJob{Shard: 2, Shards: 4, Lang: "py"})
+  var job shardJob
+  if err := worker.recv("job", &job); err != nil || job.Shard != 2 || job.Lang != "py" {
+    t.Fatalf("got %+v, %v", job, err)
+  }
+
+  // A message of one kind does not pass for another.
+  go worker.send("result", shardJob{Shard: 3})
+  if err := coord.recv("job", &job); !errors.Is(err, errBadMAC) {
+    t.Errorf("a result read as a job: %v", err)
+  }
+}
+
+func TestShardConnWrongSecret(t *testing.T) {
+  coord, worker := shardPair(t, "s3cret", "guess")
+  go coord.send("job", shardJob{Shard: 1})
+  var job shardJob
+  if err := worker.recv("job", &job); !errors.Is(err, errBadMAC) {
+    t.Errorf("a job under another secret was accepted: %v", err)
+  }
+}
+
+// A job recorded on one connection, or changed on the way, is refused.
+func TestShardConnTampered(t *testing.T) {
+  for _, c := range []struct {
+    name  string
+    forge func(m signedMessage, coord *shardConn) signedMessage
+  }{
+    {"replayed", func(m signedMessage, _ *shardConn) signedMessage { return m }},
+    {"changed", func(m signedMessage, coord *shardConn) signedMessage {
+      body, _ := json.Marshal(shardJob{Shard: 9, Source: []byte("print('not from the coordinator')")})
+      return signedMessage{body, m.MAC}
+    }},
+  } {
+    t.Run(c.name, func(t *testing.T) {
+      old, _ := shardPair(t, "s3cret", "s3cret")
+      body, _ := json.Marshal(shardJob{Shard: 1})
+      recorded := signedMessage{body, old.mac("job", body)}
+
+      coord, worker := shardPair(t, "s3cret", "s3cret")
+      go coord.enc.Encode(c.forge(recorded, coord))
+      var job shardJob
+      if err := worker.recv("job", &job); !errors.Is(err, errBadMAC) {
+        t.Errorf("got %+v, %v; want it refused", job, err)
+      }
+    })
+  }
+}
+
+// A worker without the secret is turned away before it sees a job, which
+// stays on the queue for another.
+func TestServeWorkerWrongSecret(t *testing.T) {
+  q := &shardQueue{make(chan shardJob, 1), make(chan shardResult, 1), make(chan struct{}), []byte("s3cret")}
+  q.jobs <- shardJob{Shard: 0, Source: []byte("print('hi')")}
+  a, b := net.Pipe()
+  served := make(chan struct{})
+  go func() {
+    q.serveWorker(a)
+    close(served)
+  }()
+  err := workFor(b, []byte("guess"), "intruder")
+  <-served
+  if err == nil {
+    t.Error("the worker ran without the secret")
+  }
+  if len(q.jobs) != 1 {
+    t.Error("the job was handed out")
+  }
+}
+
+func TestServeWorker(t *testing.T) {
+  q := &shardQueue{make(chan shardJob, 1), make(chan shardResult, 1), make(chan struct{}), []byte("s3cret")}
+  q.jobs <- shardJob{Shard: 0, Shards: 1, Event: "nope"}
+  a, b := net.Pipe()
+  go q.serveWorker(a)
+  go workFor(b, []byte("s3cret"), "box")
+  res := <-q.results
+  close(q.done)
+  if res.Worker != "box" || res.Error == "" {
+    t.Errorf("got %+v, want box's failure to run an unknown event", res)
+  }
+}
+
+func TestMergeShards(t *testing.T) {
+  results := []shardResult{
+    {Shard: 0, Answers: map[int]string{1: "10", 2: "7"}},
+    {Shard: 1, Answers: map[int]string{1: "32", 2: "7"}},
+    {Shard: 2, Answers: map[int]string{1: "99999999999999999999"}},
+  }
+  got, err := mergeShards(results, map[int]string{1: "sum"}, 2)
+  if err != nil || got[1] != "100000000000000000041" || got[2] != "7" {
+    t.Errorf("got %v, %v", got, err)
+  }
+  got, _ = mergeShards(results, map[int]string{1: "min"}, 1)
+  if got[1] != "10" {
+    t.Errorf("min: got %v", got)
+  }
+  results[1].Answers[2] = "8"
+  if _, err := mergeShards(results, nil, 2); err == nil {
+    t.Error("shards that disagree were merged")
+  }
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+  "os"
+  "path/filepath"
+  "regexp"
+  "sort"
+  "strings"
+)
+
+// An event is one family of puzzles and how its solutions are laid out.
+// Every event keeps one directory per edition (a year, or a story) holding
+// NN.<ext> solutions that read NN.in and print "Part N: answer" lines; what
+// differs is where the editions live, how they are named and how many
+// parts each puzzle has.
+type event struct {
+  name  string
+  title string
+  dir   string
+  parts int
+  // Matches the edition directories; the first group is the edition's
+  // short name, as used by -y.
+  edition *regexp.Regexp
+}
+
+var events = []event{
+  {"aoc", "Advent of Code", "AdventOfCode", 2, regexp.MustCompile(`^(\d{4})$`)},
+  {"ec", "Everybody Codes", "EverybodyCodes", 3, regexp.MustCompile(`^(\d{4})-.+$`)},
+  {"ec-story", "Everybody Codes stories", "EverybodyCodes/Stories", 3, regexp.MustCompile(`^(\d+)-.+$`)},
+  {"codyssi", "Codyssi", "Codyssi", 3, regexp.MustCompile(`^(\d{4})$`)},
+}
+
+func findEvent(name string) (event, bool) {
+  for _, e := range events {
+    if e.name == name {
+      return e, true
+    }
+  }
+  return event{}, false
+}
+
+// An edition directory of an event, such as AdventOfCode/2024 or
+// EverybodyCodes/2025-The_Song_of_Ducks_and_Dragons.
+type edition struct {
+  event event
+  name  string
+  dir   string
+  // Where a named account keeps its inputs and snapshots for the
+  // edition, when one is in use; see account.go.
+  own string
+}
+
+func (e event) editions(root string) ([]edition, error) {
+  entries, err := os.ReadDir(filepath.Join(root, e.dir))
+  if err != nil {
+    return nil, err
+  }
+  var eds []edition
+  for _, entry := range entries {
+    m := e.edition.FindStringSubmatch(entry.Name())
+    if entry.IsDir() && m != nil {
+      ed := edition{e, m[1], filepath.Join(root, e.dir, entry.Name()), ""}
+      if account != "" {
+        ed.own = filepath.Join(accountDir(root, account), e.name, ed.name)
+      }
+      eds = append(eds, ed)
+    }
+  }
+  sort.Slice(eds, func(i, j int) bool {
+    return numericLess(eds[i].name, eds[j].name)
+  })
+  return eds, nil
+}
+
+// Compare names that are numbers by value, so story 10 follows story 9.
+func numericLess(a, b string) bool {
+  if len(a) != len(b) {
+    return len(a) < len(b)
+  }
+  return a < b
+}
+
+// Find the repository root: the nearest directory at or above dir that
+// holds the Advent of Code track.
+func findRoot(dir string) (string, bool) {
+  dir, _ = filepath.Abs(dir)
+  for {
+    if info, err := os.Stat(filepath.Join(dir, "AdventOfCode")); err == nil && info.IsDir() {
+      return dir, true
+    }
+    parent := filepath.Dir(dir)
+    if parent == dir {
+      return "", false
+    }
+    dir = parent
+  }
+}
+
+func eventNames() string {
+  var names []string
+  for _, e := range events {
+    names = append(names, e.name)
+  }
+  return strings.Join(names, ", ")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "strconv"
+  "strings"
+)
+
+// With -explain, run asks solutions to trace the steps they take, such as
+// the choices a search makes or a state machine's transitions, as lines
+// on standard error that the runner prints in order after the output:
+//
+//   aoc-trace: {"event": "don't", "counted": "mul(2,4)&mul[3,7]!^", "sum": 8}
+//
+// Each is a JSON object whose "event" names the step; the other fields are
+// shown in the order written. The runner sets AOC_EXPLAIN for a solution
+// to trace, and only when the input is at most explainLimit bytes, since
+// the trace of a full input is too long to read.
+const explainLimit = 16 << 10
+
+// One traced step, with its fields in order.
+type traceStep struct {
+  event  string
+  fields []string
+}
+
+// Parse the JSON of a trace line, keeping the order of its fields, which
+// decoding into a map would lose.
+func parseTrace(data []byte) traceStep {
+  var step traceStep
+  dec := json.NewDecoder(bytes.NewReader(data))
+  if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+    return traceStep{event: "?", fields: []string{strings.TrimSpace(string(data))}}
+  }
+  for dec.More() {
+    key, err := dec.Token()
+    if err != nil {
+      break
+    }
+    var value json.RawMessage
+    if err := dec.Decode(&value); err != nil {
+      break
+    }
+    if key == "event" {
+      json.Unmarshal(value, &step.event)
+      continue
+    }
+    // Strings show bare unless spaces or the like would make them
+    // ambiguous.
+    var s string
+    if json.Unmarshal(value, &s) != nil {
+      s = string(value)
+    } else if s == "" || strings.ContainsAny(s, " \t\r\n\"") {
+      s = strconv.Quote(s)
+    }
+    step.fields = append(step.fields, fmt.Sprintf("%v=%s", key, s))
+  }
+  return step
+}
+
+// The trace as numbered lines, events aligned.
+func formatTrace(steps []traceStep) string {
+  width := 0
+  for _, s := range steps {
+    width = max(width, len(s.event))
+  }
+  var b strings.Builder
+  fmt.Fprintf(&b, "trace, %d steps:\n", len(steps))
+  for i, s := range steps {
+    fmt.Fprintf(&b, "%6d  %-*s  %s\n", i+1, width, s.event, strings.Join(s.fields, " "))
+  }
+  return b.String()
+}
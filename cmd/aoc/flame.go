@@ -0,0 +1,108 @@
+package main
+
+import (
+  "fmt"
+  "hash/fnv"
+  "html"
+  "sort"
+  "strings"
+)
+
+// Flame graph layout, in pixels.
+const (
+  flameWidth     = 1200
+  flameRowHeight = 16
+  flameCharWidth = 7
+  flameMargin    = 10
+)
+
+type flameNode struct {
+  name     string
+  value    int64
+  children map[string]*flameNode
+}
+
+func (n *flameNode) child(name string) *flameNode {
+  c := n.children[name]
+  if c == nil {
+    c = &flameNode{name: name, children: map[string]*flameNode{}}
+    n.children[name] = c
+  }
+  return c
+}
+
+func (n *flameNode) depth() int {
+  d := 0
+  for _, c := range n.children {
+    d = max(d, c.depth())
+  }
+  return d + 1
+}
+
+// A warm colour that depends only on the name, so a function keeps its
+// colour between graphs.
+func flameColor(name string) string {
+  h := fnv.New32a()
+  h.Write([]byte(name))
+  v := h.Sum32()
+  return fmt.Sprintf("rgb(%d,%d,%d)", 205+v%50, 80+(v>>8)%130, 40+(v>>16)%50)
+}
+
+// Render folded stacks as a standalone SVG flame graph: callers at the
+// bottom, each frame as wide as its share of the total, children sorted
+// by name as in Brendan Gregg's flamegraph.pl.
+func flameGraph(folded map[string]int64, title, unit string) []byte {
+  root := &flameNode{name: "all", children: map[string]*flameNode{}}
+  for stack, w := range folded {
+    root.value += w
+    n := root
+    for _, frame := range strings.Split(stack, ";") {
+      n = n.child(frame)
+      n.value += w
+    }
+  }
+  depth := root.depth()
+  height := depth*flameRowHeight + 3*flameMargin + flameRowHeight
+  scale := float64(flameWidth-2*flameMargin) / float64(max(root.value, 1))
+
+  var b strings.Builder
+  fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="12">`+"\n",
+    flameWidth, height)
+  fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#f8f8f0"/>`+"\n")
+  fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-size="15">%s</text>`+"\n",
+    flameWidth/2, flameMargin+flameRowHeight, html.EscapeString(title))
+
+  var draw func(n *flameNode, x float64, level int)
+  draw = func(n *flameNode, x float64, level int) {
+    w := float64(n.value) * scale
+    if w < 0.5 {
+      return
+    }
+    y := height - flameMargin - (level+1)*flameRowHeight
+    label := fmt.Sprintf("%s (%d %s, %.2f%%)", n.name, n.value, unit, float64(n.value)/float64(root.value)*100)
+    fmt.Fprintf(&b, `<g><title>%s</title><rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" rx="2"/>`,
+      html.EscapeString(label), x, y, w, flameRowHeight-1, flameColor(n.name))
+    if chars := int(w-6) / flameCharWidth; chars >= 3 {
+      text := n.name
+      if len(text) > chars {
+        text = text[:chars-2] + ".."
+      }
+      fmt.Fprintf(&b, `<text x="%.1f" y="%d">%s</text>`, x+3, y+flameRowHeight-4, html.EscapeString(text))
+    }
+    b.WriteString("</g>\n")
+
+    names := make([]string, 0, len(n.children))
+    for name := range n.children {
+      names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+      c := n.children[name]
+      draw(c, x, level+1)
+      x += float64(c.value) * scale
+    }
+  }
+  draw(root, flameMargin, 0)
+  b.WriteString("</svg>\n")
+  return []byte(b.String())
+}
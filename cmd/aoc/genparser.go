@@ -0,0 +1,422 @@
+package main
+
+import (
+  "fmt"
+  "io"
+  "os"
+  "regexp"
+  "strings"
+)
+
+// The shapes of input that gen-parser recognizes, each with the Python
+// expression and the Go function body that parse a piece of text in that
+// shape. Python expressions read the variable text; Go bodies the
+// parameter text.
+type inputShape struct {
+  name   string
+  py     string
+  goType string
+  goBody string
+  // Needs re in Python, or the ints helper in Go.
+  numbers bool
+}
+
+var (
+  shapeInt = inputShape{
+    name: "one integer per line",
+    py:   `[int(line) for line in text.splitlines()]`, goType: "[]int",
+    goBody: `  var out []int
+  for _, line := range strings.Split(text, "\n") {
+    n, err := strconv.Atoi(strings.TrimSpace(line))
+    if err != nil {
+      panic(err)
+    }
+    out = append(out, n)
+  }
+  return out`,
+  }
+  shapeInts = inputShape{
+    name: "lines of integers",
+    py:   `[[int(n) for n in re.findall(r"-?\d+", line)] for line in text.splitlines()]`, goType: "[][]int",
+    goBody: `  var out [][]int
+  for _, line := range strings.Split(text, "\n") {
+    out = append(out, ints(line))
+  }
+  return out`,
+    numbers: true,
+  }
+  shapeLineInts = inputShape{
+    name: "a line of integers",
+    py:   `[int(n) for n in re.findall(r"-?\d+", text)]`, goType: "[]int",
+    goBody:  `  return ints(text)`,
+    numbers: true,
+  }
+  shapeDigits = inputShape{
+    name: "a grid of digits",
+    py:   `[[int(c) for c in line] for line in text.splitlines()]`, goType: "[][]int",
+    goBody: `  var out [][]int
+  for _, line := range strings.Split(text, "\n") {
+    row := make([]int, len(line))
+    for i, c := range line {
+      row[i] = int(c - '0')
+    }
+    out = append(out, row)
+  }
+  return out`,
+  }
+  shapeGrid = inputShape{
+    name: "a grid of characters",
+    py:   `text.splitlines()`, goType: "[]string",
+    goBody: `  return strings.Split(text, "\n")`,
+  }
+  // Kept as a list, since keys such as test values may repeat.
+  shapeIntRecords = inputShape{
+    name: "integer keys with integer values",
+    py: `[(int(key), [int(n) for n in re.findall(r"-?\d+", values)])
+        for key, values in (line.split(":", 1) for line in text.splitlines())]`,
+    goType:  "[][]int",
+    goBody:  shapeInts.goBody,
+    numbers: true,
+  }
+  shapeRecords = inputShape{
+    name: "key: values records",
+    py: `{key: values.strip()
+        for key, values in (line.split(":", 1) for line in text.splitlines())}`,
+    goType: "map[string]string",
+    goBody: `  out := map[string]string{}
+  for _, line := range strings.Split(text, "\n") {
+    key, values, _ := strings.Cut(line, ":")
+    out[key] = strings.TrimSpace(values)
+  }
+  return out`,
+  }
+  shapeNumbered = inputShape{
+    name: "lines of text with numbers in",
+    py:   `[tuple(int(n) for n in re.findall(r"-?\d+", line)) for line in text.splitlines()]`, goType: "[][]int",
+    goBody:  shapeInts.goBody,
+    numbers: true,
+  }
+  shapeWords = inputShape{
+    name: "a comma-separated list",
+    py:   `[word.strip() for word in text.split(",")]`, goType: "[]string",
+    goBody: `  words := strings.Split(text, ",")
+  for i := range words {
+    words[i] = strings.TrimSpace(words[i])
+  }
+  return words`,
+  }
+  shapeLine = inputShape{
+    name: "a single line",
+    py:   `text.strip()`, goType: "string",
+    goBody: `  return strings.TrimSpace(text)`,
+  }
+  shapeLines = inputShape{
+    name: "lines of text",
+    py:   `text.splitlines()`, goType: "[]string",
+    goBody: `  return strings.Split(text, "\n")`,
+  }
+)
+
+var (
+  numberPattern = regexp.MustCompile(`-?\d+`)
+  letterPattern = regexp.MustCompile(`[A-Za-z]`)
+  intLine       = regexp.MustCompile(`^\s*-?\d+\s*$`)
+  digitLine     = regexp.MustCompile(`^\d+$`)
+  recordLine    = regexp.MustCompile(`^[^:]+:\s*\S`)
+)
+
+// Guess the shape of a piece of input, which has no blank lines.
+func guessShape(lines []string) inputShape {
+  all := func(match func(string) bool) bool {
+    for _, line := range lines {
+      if !match(line) {
+        return false
+      }
+    }
+    return true
+  }
+  numbersOnly := func(line string) bool {
+    return numberPattern.MatchString(line) && !letterPattern.MatchString(line)
+  }
+  sameWidth := all(func(line string) bool { return len(line) == len(lines[0]) })
+
+  if len(lines) == 1 {
+    line := lines[0]
+    switch {
+    case numbersOnly(line) && len(numberPattern.FindAllString(line, -1)) > 1:
+      return shapeLineInts
+    case strings.Contains(line, ",") && !numberPattern.MatchString(line):
+      return shapeWords
+    }
+    return shapeLine
+  }
+  switch {
+  case sameWidth && len(lines[0]) > 1 && all(digitLine.MatchString):
+    return shapeDigits
+  case all(intLine.MatchString):
+    return shapeInt
+  case all(numbersOnly) && !all(recordLine.MatchString):
+    return shapeInts
+  case all(recordLine.MatchString) && all(func(line string) bool {
+    return intLine.MatchString(strings.SplitN(line, ":", 2)[0]) && numbersOnly(line)
+  }):
+    return shapeIntRecords
+  case all(recordLine.MatchString) && !all(numberPattern.MatchString):
+    return shapeRecords
+  case sameWidth && all(func(line string) bool { return !strings.ContainsAny(line, " \t") }):
+    return shapeGrid
+  case all(numberPattern.MatchString):
+    return shapeNumbered
+  }
+  return shapeLines
+}
+
+// The template of a line with its numbers replaced by N, when every line
+// follows the same one, for documenting lines of text with numbers in.
+func lineTemplate(lines []string) string {
+  template := numberPattern.ReplaceAllString(lines[0], "N")
+  for _, line := range lines[1:] {
+    if numberPattern.ReplaceAllString(line, "N") != template {
+      return ""
+    }
+  }
+  return template
+}
+
+// A piece of the input and its guessed shape.
+type inputSection struct {
+  lines []string
+  shape inputShape
+}
+
+func (s inputSection) describe() string {
+  d := s.shape.name
+  if s.shape.name == shapeNumbered.name {
+    if t := lineTemplate(s.lines); t != "" {
+      d += fmt.Sprintf(` like "%s"`, t)
+    }
+  }
+  return d
+}
+
+// Split an input into its blank-line sections and guess each one's shape.
+// Returns the sections and whether they all share one shape, as with a
+// list of blocks rather than distinct parts.
+func analyzeInput(text string) ([]inputSection, bool) {
+  text = strings.Trim(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+  var sections []inputSection
+  for _, chunk := range strings.Split(text, "\n\n") {
+    lines := strings.Split(strings.Trim(chunk, "\n"), "\n")
+    sections = append(sections, inputSection{lines, guessShape(lines)})
+  }
+  uniform := len(sections) > 2
+  for _, s := range sections[1:] {
+    if s.shape.name != sections[0].shape.name {
+      uniform = false
+    }
+  }
+  return sections, uniform
+}
+
+// The first few lines of a section, indented for a comment or docstring.
+func sample(lines []string, indent string) string {
+  var b strings.Builder
+  for i, line := range lines {
+    if i == 3 {
+      b.WriteString(indent + "...\n")
+      break
+    }
+    b.WriteString(indent + line + "\n")
+  }
+  return b.String()
+}
+
+func genPython(day int, sections []inputSection, uniform bool) string {
+  var b strings.Builder
+  numbers := false
+  for _, s := range sections {
+    numbers = numbers || s.shape.numbers
+  }
+  if numbers {
+    b.WriteString("import re\n\n\n")
+  }
+  indent := func(expr, prefix string) string {
+    return strings.ReplaceAll(expr, "\n", "\n"+prefix)
+  }
+
+  switch {
+  case len(sections) == 1:
+    s := sections[0]
+    fmt.Fprintf(&b, "def read_puzzle_input() -> %s:\n", pyType(s.shape))
+    fmt.Fprintf(&b, "    \"\"\"\n    Read the puzzle input, %s, which looks like:\n\n%s    \"\"\"\n", s.describe(), sample(s.lines, "        "))
+    fmt.Fprintf(&b, "    with open(\"%02d.in\", \"r\") as file:\n        text = file.read().strip(\"\\n\")\n", day)
+    fmt.Fprintf(&b, "    return %s\n", indent(s.shape.py, "    "))
+  case uniform:
+    s := sections[0]
+    fmt.Fprintf(&b, "def parse_block(text: str) -> %s:\n", pyType(s.shape))
+    fmt.Fprintf(&b, "    \"\"\"\n    Parse one block, %s, such as:\n\n%s    \"\"\"\n", s.describe(), sample(s.lines, "        "))
+    fmt.Fprintf(&b, "    return %s\n\n\n", indent(s.shape.py, "    "))
+    b.WriteString("def read_puzzle_input() -> list:\n")
+    b.WriteString("    \"\"\"\n    Read the puzzle input, blocks separated by blank lines.\n    \"\"\"\n")
+    fmt.Fprintf(&b, "    with open(\"%02d.in\", \"r\") as file:\n", day)
+    b.WriteString("        return [parse_block(text) for text in file.read().strip(\"\\n\").split(\"\\n\\n\")]\n")
+  default:
+    b.WriteString("def read_puzzle_input() -> tuple:\n")
+    fmt.Fprintf(&b, "    \"\"\"\n    Read the puzzle input, %d sections separated by blank lines:\n\n", len(sections))
+    for i, s := range sections {
+      if i > 0 {
+        b.WriteString("\n")
+      }
+      fmt.Fprintf(&b, "    %d. %s, such as\n\n%s", i+1, s.describe(), sample(s.lines, "        "))
+    }
+    b.WriteString("    \"\"\"\n")
+    fmt.Fprintf(&b, "    with open(\"%02d.in\", \"r\") as file:\n", day)
+    b.WriteString("        sections = file.read().strip(\"\\n\").split(\"\\n\\n\")\n")
+    var names []string
+    for i, s := range sections {
+      name := fmt.Sprintf("section_%d", i+1)
+      names = append(names, name)
+      fmt.Fprintf(&b, "    text = sections[%d]\n    %s = %s\n", i, name, indent(s.shape.py, "    "))
+    }
+    fmt.Fprintf(&b, "    return %s\n", strings.Join(names, ", "))
+  }
+  return b.String()
+}
+
+func pyType(shape inputShape) string {
+  switch {
+  case strings.HasPrefix(shape.goType, "map"):
+    return "dict"
+  case shape.goType == "string":
+    return "str"
+  }
+  return "list"
+}
+
+func genGo(sections []inputSection, uniform bool) string {
+  var b strings.Builder
+  numbers := false
+  for _, s := range sections {
+    numbers = numbers || s.shape.numbers
+  }
+  if uniform {
+    sections = sections[:1]
+  }
+  for i, s := range sections {
+    name := fmt.Sprintf("parseSection%d", i+1)
+    if len(sections) == 1 && !uniform {
+      name = "parse"
+    } else if uniform {
+      name = "parseBlock"
+    }
+    fmt.Fprintf(&b, "// Parse %s, such as:\n//\n%s", s.describe(), sample(s.lines, "//   "))
+    fmt.Fprintf(&b, "func %s(text string) %s {\n%s\n}\n\n", name, s.shape.goType, s.shape.goBody)
+  }
+  if numbers {
+    b.WriteString(`var numberPattern = regexp.MustCompile(` + "`-?\\d+`" + `)
+
+// Every integer in s, in order.
+func ints(s string) []int {
+  var out []int
+  for _, field := range numberPattern.FindAllString(s, -1) {
+    n, err := strconv.Atoi(field)
+    if err != nil {
+      panic(err)
+    }
+    out = append(out, n)
+  }
+  return out
+}
+
+`)
+  }
+
+  b.WriteString("// Read the puzzle input, whose path aoc passes in AOC_INPUT.\n")
+  read := `  data, err := os.ReadFile(os.Getenv("AOC_INPUT"))
+  if err != nil {
+    panic(err)
+  }
+  text := strings.Trim(string(data), "\n")
+`
+  switch {
+  case uniform:
+    fmt.Fprintf(&b, "func readPuzzleInput() []%s {\n%s", sections[0].shape.goType, read)
+    fmt.Fprintf(&b, "  var blocks []%s\n  for _, block := range strings.Split(text, \"\\n\\n\") {\n", sections[0].shape.goType)
+    b.WriteString("    blocks = append(blocks, parseBlock(block))\n  }\n  return blocks\n}\n")
+  case len(sections) == 1:
+    fmt.Fprintf(&b, "func readPuzzleInput() %s {\n%s  return parse(text)\n}\n", sections[0].shape.goType, read)
+  default:
+    var types, calls []string
+    for i, s := range sections {
+      types = append(types, s.shape.goType)
+      calls = append(calls, fmt.Sprintf("parseSection%d(sections[%d])", i+1, i))
+    }
+    fmt.Fprintf(&b, "func readPuzzleInput() (%s) {\n%s", strings.Join(types, ", "), read)
+    b.WriteString("  sections := strings.Split(text, \"\\n\\n\")\n")
+    fmt.Fprintf(&b, "  return %s\n}\n", strings.Join(calls, ",\n    "))
+  }
+  return b.String()
+}
+
+// Print a starter parse function for a puzzle's input, guessed from the
+// input itself: NN.in by default, or the example in o.genInput, where "-"
+// reads it from standard input so an example can be pasted in.
+func genParser(o *options) int {
+  if o.day == 0 && o.genInput == "" {
+    fmt.Fprintln(os.Stderr, "aoc: gen-parser needs -d, or an example with -in")
+    return 2
+  }
+  var data []byte
+  var err error
+  switch o.genInput {
+  case "":
+    ed, ok := findEdition(o.root, o.event, o.edition)
+    if !ok {
+      fmt.Fprintln(os.Stderr, "aoc: gen-parser needs -y, or an example with -in")
+      return 2
+    }
+    data, err = os.ReadFile(solution{edition: ed, day: o.day}.input())
+  case "-":
+    data, err = io.ReadAll(os.Stdin)
+  default:
+    data, err = os.ReadFile(o.genInput)
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  if strings.TrimSpace(string(data)) == "" {
+    fmt.Fprintln(os.Stderr, "aoc: the input is empty")
+    return 1
+  }
+
+  sections, uniform := analyzeInput(string(data))
+  switch o.lang {
+  case "", "py":
+    fmt.Print(genPython(max(o.day, 1), sections, uniform))
+  case "go":
+    fmt.Print(genGo(sections, uniform))
+  default:
+    fmt.Fprintf(os.Stderr, "aoc: gen-parser writes py or go, not %s\n", o.lang)
+    return 2
+  }
+  return 0
+}
+
+// The edition of an event with the given name, such as 2024.
+func findEdition(root, eventName, name string) (edition, bool) {
+  e, ok := findEvent(eventName)
+  if !ok || name == "" {
+    return edition{}, false
+  }
+  eds, err := e.editions(root)
+  if err != nil {
+    return edition{}, false
+  }
+  for _, ed := range eds {
+    if ed.name == name {
+      return ed, true
+    }
+  }
+  return edition{}, false
+}
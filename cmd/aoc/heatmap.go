@@ -0,0 +1,290 @@
+package main
+
+import (
+  "bufio"
+  "fmt"
+  "image"
+  "image/color"
+  "image/png"
+  "math"
+  "os"
+  "sort"
+  "strings"
+  "time"
+)
+
+// The runtime heatmap: a row per edition and a column per day, each cell
+// colored by how long the day's fastest implementation takes, the median
+// of its runs in the history that got no part wrong. Times are on a log
+// scale from heatLow to heatHigh, green to red, so the days worth
+// optimizing stand out.
+const (
+  heatLow  = time.Millisecond
+  heatHigh = 10 * time.Second
+)
+
+type heatRow struct {
+  ed   edition
+  days []time.Duration // by day, from 1; zero for days never run
+}
+
+// The median runtime of the successful runs in entries, or zero.
+func medianRuntime(entries []historyEntry) time.Duration {
+  var times []time.Duration
+  for _, e := range entries {
+    if e.Outcome == outcomeOK || e.Outcome == outcomeUnverified {
+      times = append(times, e.Elapsed)
+    }
+  }
+  if len(times) == 0 {
+    return 0
+  }
+  sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+  return times[len(times)/2]
+}
+
+func heatRows(sols []solution, hist map[string][]historyEntry) []heatRow {
+  var rows []heatRow
+  index := map[string]int{}
+  for _, s := range sols {
+    i, ok := index[s.edition.dir]
+    if !ok {
+      i = len(rows)
+      index[s.edition.dir] = i
+      rows = append(rows, heatRow{ed: s.edition})
+    }
+    for len(rows[i].days) <= s.day {
+      rows[i].days = append(rows[i].days, 0)
+    }
+    if t := medianRuntime(hist[s.key()]); t > 0 && (rows[i].days[s.day] == 0 || t < rows[i].days[s.day]) {
+      rows[i].days[s.day] = t
+    }
+  }
+  // Editions never run are left out.
+  var ran []heatRow
+  for _, r := range rows {
+    for _, t := range r.days {
+      if t > 0 {
+        for len(r.days) <= editionDays(r.ed, nil) {
+          r.days = append(r.days, 0)
+        }
+        ran = append(ran, r)
+        break
+      }
+    }
+  }
+  return ran
+}
+
+// Where a runtime falls on the scale, from 0 to 1.
+func heatLevel(t time.Duration) float64 {
+  x := math.Log(float64(t)/float64(heatLow)) / math.Log(float64(heatHigh)/float64(heatLow))
+  return min(max(x, 0), 1)
+}
+
+// The color of a level: green through yellow to red.
+func heatColor(x float64) color.RGBA {
+  stops := []color.RGBA{{0x2e, 0xa0, 0x43, 0xff}, {0xf0, 0xc0, 0x20, 0xff}, {0xd0, 0x30, 0x30, 0xff}}
+  x *= float64(len(stops) - 1)
+  i := min(int(x), len(stops)-2)
+  f := x - float64(i)
+  mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*f + 0.5) }
+  a, b := stops[i], stops[i+1]
+  return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xff}
+}
+
+// A cell in the terminal: a colored block, or a shade of grey without
+// color.
+func heatCell(t time.Duration) string {
+  if t == 0 {
+    return paint(colorDim, " ·")
+  }
+  x := heatLevel(t)
+  if !useColor {
+    shades := []string{"..", "░░", "▒▒", "▓▓", "██"}
+    return shades[int(math.Round(x*float64(len(shades)-1)))]
+  }
+  c := heatColor(x)
+  return paint(fmt.Sprintf("38;2;%d;%d;%d", c.R, c.G, c.B), "██")
+}
+
+// Show the heatmap of the editions of the solutions, and the slowest days,
+// and with -png write it as an image too.
+func heatmap(sols []solution, o *options) int {
+  hist, err := readHistory(o.root, nil)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  rows := heatRows(sols, hist)
+  if len(rows) == 0 {
+    fmt.Fprintln(os.Stderr, "aoc: no runs in the history to show; run or verify some solutions first")
+    return 1
+  }
+  width := 0
+  for _, r := range rows {
+    width = max(width, len(r.days)-1)
+  }
+
+  label := 0
+  for _, r := range rows {
+    label = max(label, len(r.ed.event.name+" "+r.ed.name))
+  }
+  var header strings.Builder
+  fmt.Fprintf(&header, "%-*s", label, "")
+  for d := 1; d <= width; d++ {
+    fmt.Fprintf(&header, " %2d", d)
+  }
+  fmt.Println(header.String())
+  type slow struct {
+    name string
+    t    time.Duration
+  }
+  var slowest []slow
+  for _, r := range rows {
+    fmt.Printf("%-*s", label, r.ed.event.name+" "+r.ed.name)
+    for d := 1; d < len(r.days); d++ {
+      fmt.Print(" " + heatCell(r.days[d]))
+      if r.days[d] > 0 {
+        slowest = append(slowest, slow{fmt.Sprintf("%s/%s/%02d", r.ed.event.name, r.ed.name, d), r.days[d]})
+      }
+    }
+    fmt.Println()
+  }
+
+  var legend []string
+  for t := heatLow; t <= heatHigh; t *= 10 {
+    legend = append(legend, heatCell(t)+" "+t.String())
+  }
+  fmt.Printf("\n%s   %s no runs\n", strings.Join(legend, "  "), paint(colorDim, "·"))
+
+  sort.Slice(slowest, func(i, j int) bool { return slowest[i].t > slowest[j].t })
+  if len(slowest) > 0 {
+    fmt.Println("\nslowest:")
+    for _, s := range slowest[:min(5, len(slowest))] {
+      fmt.Printf("  %-20s %v\n", s.name, s.t.Round(time.Millisecond))
+    }
+  }
+
+  if o.pngOut != "" {
+    if err := writeHeatmapPNG(o.pngOut, rows, width); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+    fmt.Printf("\nwrote %s\n", o.pngOut)
+  }
+  return 0
+}
+
+// The PNG has the same layout, with editions and days labelled in a small
+// bitmap font and the scale drawn beneath.
+const (
+  heatCellSize = 20
+  heatGap      = 2
+  heatMargin   = 8
+  heatScale    = 2 // pixels per font dot
+)
+
+// Glyphs 3 dots wide and 5 high, a row of dots to a byte; enough for the
+// labels, which are years, story numbers, days and milliseconds.
+var heatFont = map[rune][5]byte{
+  '0': {0b111, 0b101, 0b101, 0b101, 0b111},
+  '1': {0b010, 0b110, 0b010, 0b010, 0b111},
+  '2': {0b111, 0b001, 0b111, 0b100, 0b111},
+  '3': {0b111, 0b001, 0b111, 0b001, 0b111},
+  '4': {0b101, 0b101, 0b111, 0b001, 0b001},
+  '5': {0b111, 0b100, 0b111, 0b001, 0b111},
+  '6': {0b111, 0b100, 0b111, 0b101, 0b111},
+  '7': {0b111, 0b001, 0b010, 0b010, 0b010},
+  '8': {0b111, 0b101, 0b111, 0b101, 0b111},
+  '9': {0b111, 0b101, 0b111, 0b001, 0b111},
+  '-': {0b000, 0b000, 0b111, 0b000, 0b000},
+  'm': {0b000, 0b000, 0b111, 0b111, 0b101},
+  's': {0b000, 0b111, 0b110, 0b011, 0b111},
+}
+
+func drawText(img *image.RGBA, x, y int, s string, c color.Color) {
+  for _, r := range s {
+    glyph, ok := heatFont[r]
+    if ok {
+      for row, bits := range glyph {
+        for col := 0; col < 3; col++ {
+          if bits&(0b100>>col) != 0 {
+            fillRect(img, x+col*heatScale, y+row*heatScale, heatScale, heatScale, c)
+          }
+        }
+      }
+    }
+    x += 4 * heatScale
+  }
+}
+
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+  for i := x; i < x+w; i++ {
+    for j := y; j < y+h; j++ {
+      img.Set(i, j, c)
+    }
+  }
+}
+
+func writeHeatmapPNG(path string, rows []heatRow, width int) error {
+  labelW := 0
+  for _, r := range rows {
+    labelW = max(labelW, len(r.ed.name)*4*heatScale)
+  }
+  step := heatCellSize + heatGap
+  left, top := heatMargin+labelW+heatMargin, heatMargin+5*heatScale+heatMargin
+  scaleH := heatCellSize / 2
+  w := left + width*step + heatMargin
+  h := top + len(rows)*step + heatMargin + scaleH + heatMargin + 5*heatScale + heatMargin
+
+  img := image.NewRGBA(image.Rect(0, 0, w, h))
+  fillRect(img, 0, 0, w, h, color.White)
+  ink := color.RGBA{0x40, 0x40, 0x40, 0xff}
+  empty := color.RGBA{0xee, 0xee, 0xee, 0xff}
+  for d := 1; d <= width; d++ {
+    label := fmt.Sprint(d)
+    drawText(img, left+(d-1)*step+(heatCellSize-len(label)*4*heatScale+heatScale)/2, heatMargin, label, ink)
+  }
+  for i, r := range rows {
+    y := top + i*step
+    drawText(img, heatMargin, y+(heatCellSize-5*heatScale)/2, r.ed.name, ink)
+    for d := 1; d < len(r.days); d++ {
+      c := empty
+      if r.days[d] > 0 {
+        c = heatColor(heatLevel(r.days[d]))
+      }
+      fillRect(img, left+(d-1)*step, y, heatCellSize, heatCellSize, c)
+    }
+  }
+  // The scale, labelled with its powers of ten in milliseconds, the unit
+  // given on the first.
+  y := top + len(rows)*step + heatMargin
+  span := width*step - heatGap
+  for x := 0; x < span; x++ {
+    fillRect(img, left+x, y, 1, scaleH, heatColor(float64(x)/float64(span-1)))
+  }
+  for t := heatLow; t <= heatHigh; t *= 10 {
+    x := left + int(heatLevel(t)*float64(span-1))
+    label := fmt.Sprint(t.Milliseconds())
+    if t == heatLow {
+      label += "ms"
+    }
+    drawText(img, min(x, left+span-len(label)*4*heatScale), y+scaleH+heatMargin/2, label, ink)
+  }
+
+  f, err := os.Create(path)
+  if err != nil {
+    return err
+  }
+  w2 := bufio.NewWriter(f)
+  if err := png.Encode(w2, img); err != nil {
+    f.Close()
+    return err
+  }
+  if err := w2.Flush(); err != nil {
+    f.Close()
+    return err
+  }
+  return f.Close()
+}
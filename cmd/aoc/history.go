@@ -0,0 +1,220 @@
+package main
+
+import (
+  "bufio"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "sort"
+  "strings"
+  "sync"
+  "time"
+)
+
+// Every run is appended to .aoc/history.jsonl, one JSON object per line,
+// so the file only grows and a crash mid-write loses at most one line.
+type historyEntry struct {
+  Time    time.Time      `json:"time"`
+  Key     string         `json:"key"`
+  Outcome string         `json:"outcome"`
+  Parts   map[int]string `json:"parts,omitempty"`
+  Answers map[int]string `json:"answers,omitempty"`
+  Elapsed time.Duration  `json:"elapsed"`
+  MaxRSS  int64          `json:"max_rss,omitempty"`
+  Seed    int64          `json:"seed,omitempty"`
+  // The code version: the repository commit, and a hash of the solution
+  // file, which changes with uncommitted edits too.
+  Commit string `json:"commit,omitempty"`
+  Source string `json:"source,omitempty"`
+  Error  string `json:"error,omitempty"`
+}
+
+func historyPath(root string) string {
+  return filepath.Join(stateDir(root), "history.jsonl")
+}
+
+var (
+  commitOnce sync.Once
+  commit     string
+)
+
+// The short hash of HEAD, or empty outside a git checkout.
+func headCommit(root string) string {
+  commitOnce.Do(func() {
+    out, err := exec.Command("git", "-C", root, "rev-parse", "--short", "HEAD").Output()
+    if err == nil {
+      commit = strings.TrimSpace(string(out))
+    }
+  })
+  return commit
+}
+
+func sourceHash(path string) string {
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return ""
+  }
+  sum := sha256.Sum256(data)
+  return hex.EncodeToString(sum[:6])
+}
+
+func appendHistory(root string, r result) error {
+  if r.err == errNoInput {
+    return nil
+  }
+  e := historyEntry{
+    Time: time.Now(), Key: r.sol.key(), Outcome: r.outcome(),
+    Answers: r.answers, Elapsed: r.elapsed, MaxRSS: r.maxRSS, Seed: r.sol.seed,
+    Commit: headCommit(root), Source: sourceHash(r.sol.path),
+  }
+  if r.err != nil {
+    e.Error = r.err.Error()
+  } else {
+    e.Parts = map[int]string{}
+    for i, p := range r.check() {
+      e.Parts[i+1] = p
+    }
+  }
+  data, err := json.Marshal(e)
+  if err != nil {
+    return err
+  }
+  if err := os.MkdirAll(stateDir(root), 0o755); err != nil {
+    return err
+  }
+  f, err := os.OpenFile(historyPath(root), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+  if err != nil {
+    return err
+  }
+  if _, err := f.Write(append(data, '\n')); err != nil {
+    f.Close()
+    return err
+  }
+  return f.Close()
+}
+
+// Read the history of the given solutions, or of all with nil keys, oldest
+// first, grouped by key. Lines that do not parse, such as one cut short by
+// a crash, are skipped.
+func readHistory(root string, keys map[string]bool) (map[string][]historyEntry, error) {
+  f, err := os.Open(historyPath(root))
+  if errors.Is(err, os.ErrNotExist) {
+    return map[string][]historyEntry{}, nil
+  }
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+  hist := map[string][]historyEntry{}
+  s := bufio.NewScanner(f)
+  s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+  for s.Scan() {
+    var e historyEntry
+    if json.Unmarshal(s.Bytes(), &e) != nil || keys != nil && !keys[e.Key] {
+      continue
+    }
+    hist[e.Key] = append(hist[e.Key], e)
+  }
+  for _, entries := range hist {
+    sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+  }
+  return hist, s.Err()
+}
+
+func formatBytes(n int64) string {
+  if n == 0 {
+    return "-"
+  }
+  return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
+// A sparkline of run times, one block per run.
+func sparkline(entries []historyEntry) string {
+  const blocks = "▁▂▃▄▅▆▇█"
+  lo, hi := entries[0].Elapsed, entries[0].Elapsed
+  for _, e := range entries {
+    lo, hi = min(lo, e.Elapsed), max(hi, e.Elapsed)
+  }
+  var b strings.Builder
+  for _, e := range entries {
+    i := 0
+    if hi > lo {
+      i = int(float64(e.Elapsed-lo) / float64(hi-lo) * 7)
+    }
+    b.WriteString(string([]rune(blocks)[i]))
+  }
+  return b.String()
+}
+
+// Print the last limit runs of each solution with the change in run time
+// from the run before, then a trend line over its whole history.
+func history(sols []solution, o *options) int {
+  keys := map[string]bool{}
+  for _, s := range sols {
+    keys[s.key()] = true
+  }
+  hist, err := readHistory(o.root, keys)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  var rows []resultRow
+  for _, s := range sols {
+    entries := hist[s.key()]
+    if len(entries) == 0 {
+      continue
+    }
+    first := max(0, len(entries)-o.limit)
+    if o.limit <= 0 {
+      first = 0
+    }
+    if o.output != "table" {
+      for _, e := range entries[first:] {
+        rows = append(rows, historyRow(s, e))
+      }
+      continue
+    }
+    fmt.Printf("%s.%s\n", s.id(), s.name())
+    for i := first; i < len(entries); i++ {
+      e := entries[i]
+      delta := ""
+      if i > 0 && entries[i-1].Elapsed > 0 {
+        change := float64(e.Elapsed-entries[i-1].Elapsed) / float64(entries[i-1].Elapsed) * 100
+        delta = fmt.Sprintf("%+.0f%%", change)
+      }
+      var parts []string
+      for p := 1; p <= s.edition.event.parts; p++ {
+        parts = append(parts, orDash(e.Parts[p]))
+      }
+      fmt.Printf("  %s  %-7s %s  %-10s %-8s %10v %6s %9s\n",
+        e.Time.Local().Format("2006-01-02 15:04"), orDash(e.Commit), orDash(e.Source),
+        e.Outcome, strings.Join(parts, " "), e.Elapsed.Round(time.Millisecond), delta, formatBytes(e.MaxRSS))
+    }
+    times := make([]time.Duration, len(entries))
+    for i, e := range entries {
+      times[i] = e.Elapsed
+    }
+    sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+    fmt.Printf("  %d runs, best %v, median %v  %s\n\n", len(entries),
+      times[0].Round(time.Millisecond), times[len(times)/2].Round(time.Millisecond), sparkline(entries))
+  }
+  if o.output != "table" {
+    if err := writeRows(os.Stdout, o.output, historyColumns, rows); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+  }
+  return 0
+}
+
+func orDash(s string) string {
+  if s == "" {
+    return "-"
+  }
+  return s
+}
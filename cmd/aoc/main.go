@@ -0,0 +1,476 @@
+package main
+
+import (
+  "errors"
+  "flag"
+  "fmt"
+  "os"
+  "strings"
+  "time"
+)
+
+const usage = `usage: aoc <command> [flags]
+
+Runs and verifies puzzle solutions across the event tracks (%s).
+
+commands:
+  list     list solutions and how many answers each has recorded
+  run      run solutions and print their output
+  verify   run solutions and compare answers with the recorded ones
+  serve    browse results, timings and outputs in a web UI, with
+           Prometheus metrics on /metrics
+  history  show past runs and how their times changed
+  solves   show how long each puzzle took to solve, from getting its
+           input to each part's first correct answer
+  calendar show an edition's days as a calendar of stars, status and
+           runtimes from the last verify
+  heatmap  show each day's runtime from the history as a heatmap across
+           editions, with -png to write it as an image
+  compare  run every implementation of a puzzle on the same input,
+           check they agree and compare their times
+  bench    time solutions over several runs, -save the samples under a
+           name and -compare later runs with them for significant changes
+  snapshot run solutions and compare their whole output with the
+           snapshots kept beside them, with -update to accept changes
+  scrub    write a shareable copy of a puzzle's input with its numbers
+           and names randomized
+  gen-parser
+           print a starter parse function guessed from a puzzle's input
+           or an example, in Python or with -lang go in Go
+  repl     explore a puzzle's input in Python with grid, graph, interval
+           and number helpers
+  distribute
+           split a solution into shards for workers on other machines
+           to run, and merge their answers (experimental); both ends
+           need the same secret, in AOC_SHARD_SECRET or typed
+  work     join a distribute coordinator with -join host:port and run
+           the shards it sends
+  profile  profile one solution and show where its time goes, with
+           -flame for a flame graph
+  submit   send a part's answer to adventofcode.com, keeping track of
+           wrong answers, too high and too low bounds, and waits
+  answers  list a named account's answers, or record one with -part
+           and -answer
+  token    store the adventofcode.com session token in the OS keychain,
+           or encrypted in a file: aoc token set|show|clear|status
+  archive  pack the inputs and the answers kept outside the solutions
+           into an encrypted archive, and unpack it on another machine:
+           aoc archive pack|unpack|list
+  completion
+           print a bash, zsh or fish completion script, as in
+           source <(aoc completion bash)
+
+Answers are recorded as the comment on the line that prints each part.
+Alternative implementations carry a variant name: 03.bruteforce.py sits
+beside 03.py and reads the same 03.in.
+A Go solution may keep its code in a package of its own beside it, as
+01.go imports day01 by its path in the repository's module, so that other
+programs can import it too. The mains carry "//go:build ignore", which
+keeps an edition's several mains out of "go build ./...".
+A solution with several strategies lists them in an "algos:" comment,
+default first, and reads the one -algo selects from AOC_ALGO. Solutions
+that use randomness seed it from AOC_SEED, which -seed sets and which
+otherwise stays the same from run to run of a puzzle. Long runs show
+progress that solutions report as "aoc-progress: DONE/TOTAL" lines on
+standard error, and with run -explain trace their steps the same way.
+An NN.cmd file registers an external command, given the input on
+standard input, so solutions in other languages run like the rest.
+Solvers outside the repository attach as plugins, listed in AOC_PLUGINS
+and speaking a JSON protocol over standard input and output.
+Run "aoc <command> -h" for a command's flags.
+
+Every run is recorded in .aoc/history.jsonl, with its output kept in
+.aoc/output and the first time each part came out right in
+.aoc/solves.json. verify also remembers each part's status in .aoc/status.json;
+with -webhook, or AOC_WEBHOOK set, it posts newly correct parts and
+regressions to a Discord or Slack incoming webhook. Network requests are
+retried with backoff when they fail; -offline, or AOC_OFFLINE set, makes
+none at all.
+
+Each adventofcode.com account has its own inputs and answers. -account
+NAME, or AOC_ACCOUNT, uses a named account's session token and keeps its
+inputs, answers, snapshots and state in .aoc/accounts/NAME, inputs as
+EVENT/EDITION/NN.in; without it the usual account's are used.
+`
+
+// Flags shared by every command.
+type options struct {
+  filter
+  root    string
+  algo    string
+  timeout time.Duration
+  webhook string
+  addr    string
+  limit   int
+  shards  int
+  local   int
+  join    string
+  repeat  int
+  seed    int64
+  update  bool
+  explain bool
+  part    int
+  answer  string
+  save    string
+  against string
+  output  string
+
+  scrubOut string
+  genInput string
+
+  flame    bool
+  flameOut string
+  pprofWeb bool
+
+  pngOut string
+}
+
+// The commands, in the order usage lists them.
+var commands = []string{
+  "list", "run", "verify", "serve", "history", "solves", "calendar", "heatmap", "compare", "bench", "snapshot", "scrub",
+  "gen-parser", "repl", "distribute", "work", "profile", "submit", "answers", "token", "archive", "completion",
+}
+
+// The flags of a command, bound to the options they set.
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+  fs := flag.NewFlagSet("aoc "+name, flag.ExitOnError)
+  o := &options{}
+  fs.StringVar(&o.event, "e", "", "event: "+eventNames()+" (default: all, or aoc with -y or -d)")
+  fs.StringVar(&o.edition, "y", "", "edition: a year, or a story number for ec-story")
+  fs.IntVar(&o.day, "d", 0, "day or quest")
+  fs.StringVar(&o.lang, "lang", "", "only solutions in this language (py, go, js, cmd, plugin)")
+  fs.StringVar(&o.root, "root", "", "repository root (default: found from the working directory)")
+  fs.DurationVar(&o.timeout, "timeout", time.Minute, "time limit per solution")
+  fs.StringVar(&o.algo, "algo", "", "strategy for solutions that declare several, or all to run each")
+  fs.StringVar(&o.webhook, "webhook", os.Getenv("AOC_WEBHOOK"), "Discord or Slack webhook URL for verify to notify")
+  fs.BoolVar(&offline, "offline", offline, "make no network requests (default: AOC_OFFLINE set)")
+  fs.StringVar(&account, "account", account, "named account whose inputs, answers and session to use (default: AOC_ACCOUNT, or the usual one)")
+  if name == "serve" {
+    fs.StringVar(&o.addr, "addr", "localhost:8080", "address to serve on")
+  }
+  if name == "distribute" {
+    fs.StringVar(&o.addr, "addr", "localhost:7700", "address to listen for workers on; :7700 for other machines")
+    fs.IntVar(&o.shards, "shards", 16, "number of shards to split the work into")
+    fs.IntVar(&o.local, "local", 1, "shards to run at once on this machine, besides the workers")
+  }
+  if name == "work" {
+    fs.StringVar(&o.join, "join", "", "coordinator to join, host:port")
+  }
+  if name == "history" {
+    fs.IntVar(&o.limit, "n", 10, "runs to show per solution, or 0 for all")
+  }
+  if name == "compare" {
+    fs.IntVar(&o.repeat, "n", 1, "runs per implementation, keeping the fastest")
+  }
+  if name == "run" || name == "verify" || name == "bench" || name == "history" {
+    fs.StringVar(&o.output, "output", "table", "output format: table, json, csv or tsv")
+  }
+  if name == "heatmap" {
+    fs.StringVar(&o.pngOut, "png", "", "also write the heatmap to this PNG file")
+  }
+  if name == "bench" {
+    fs.IntVar(&o.repeat, "n", 10, "timed runs per solution")
+    fs.StringVar(&o.save, "save", "", "save the samples under this name")
+    fs.StringVar(&o.against, "compare", "", "compare with the samples saved under this name")
+  }
+  if name == "run" {
+    fs.BoolVar(&o.explain, "explain", false, "print the steps solutions trace, when the input is small")
+  }
+  if name == "submit" || name == "answers" {
+    fs.IntVar(&o.part, "part", 0, "part to answer")
+    fs.StringVar(&o.answer, "answer", "", "answer to send (default: the one the solution prints)")
+  }
+  if name == "snapshot" {
+    fs.BoolVar(&o.update, "update", false, "write every snapshot from this run instead of comparing")
+  }
+  if name == "scrub" {
+    fs.Int64Var(&o.seed, "seed", 0, "random seed (default: time based)")
+  } else {
+    fs.Int64Var(&o.seed, "seed", 0, "random seed given to solutions as AOC_SEED (default: one per puzzle)")
+  }
+  if name == "scrub" {
+    fs.StringVar(&o.scrubOut, "o", "", "file to write (default: standard output)")
+  }
+  if name == "gen-parser" {
+    fs.StringVar(&o.genInput, "in", "", "example input to read, or - for standard input (default: the puzzle's input)")
+  }
+  if name == "profile" {
+    fs.BoolVar(&o.flame, "flame", false, "write an SVG flame graph")
+    fs.StringVar(&o.flameOut, "o", "", "flame graph file (default: in .aoc/profiles)")
+    fs.BoolVar(&o.pprofWeb, "web", false, "open a Go solution's profile in the pprof web UI")
+  }
+  return fs, o
+}
+
+func parseFlags(name string, args []string) (*options, error) {
+  fs, o := newFlagSet(name)
+  fs.Parse(args)
+  if fs.NArg() > 0 {
+    return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+  }
+  if err := checkAccount(account); err != nil {
+    return nil, err
+  }
+  if o.output == "" {
+    o.output = "table"
+  }
+  if err := checkOutput(o.output); err != nil {
+    return nil, err
+  }
+  // Years and days mean Advent of Code unless another event is named.
+  if o.event == "" && (o.edition != "" || o.day != 0) {
+    o.event = "aoc"
+  }
+  if o.event != "" {
+    if _, ok := findEvent(o.event); !ok {
+      return nil, fmt.Errorf("unknown event %q (have %s)", o.event, eventNames())
+    }
+  }
+  // Workers need nothing from the repository.
+  if o.root == "" && name != "work" {
+    root, ok := findRoot(".")
+    if !ok {
+      return nil, fmt.Errorf("not inside the repository; use -root")
+    }
+    o.root = root
+  }
+  return o, nil
+}
+
+func list(sols []solution) {
+  for _, s := range sols {
+    algos := ""
+    if len(s.algos) > 0 && s.algo == "" {
+      algos = "; algos " + strings.Join(s.algos, ", ")
+    }
+    fmt.Printf("%-28s %-3s %d/%d answers recorded%s\n",
+      s.id(), s.name(), len(s.expected), s.edition.event.parts, algos)
+  }
+}
+
+func runAll(sols []solution, o *options) int {
+  failed := 0
+  var rows []resultRow
+  for _, s := range sols {
+    if o.output != "table" {
+      r := run(s, o.timeout)
+      if err := recordRun(o.root, r); err != nil {
+        fmt.Fprintln(os.Stderr, "aoc:", err)
+      }
+      if r.err != nil {
+        failed++
+      }
+      rows = append(rows, resultRowOf(r))
+      continue
+    }
+    fmt.Printf("== %s.%s\n", s.id(), s.name())
+    if o.explain {
+      if info, err := os.Stat(s.input()); err == nil && info.Size() > explainLimit {
+        fmt.Printf("(input of %d KB is too big to explain; the limit is %d KB)\n", info.Size()>>10, explainLimit>>10)
+      } else {
+        s.explain = true
+      }
+    }
+    r := run(s, o.timeout)
+    if err := recordRun(o.root, r); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+    }
+    fmt.Print(r.output)
+    if len(r.trace) > 0 {
+      fmt.Print(formatTrace(r.trace))
+    } else if s.explain && r.err != errNoInput {
+      fmt.Println("(no steps traced; the solution does not support -explain)")
+    }
+    if r.err != nil {
+      fmt.Println("error:", r.err)
+      failed++
+    } else {
+      fmt.Printf("(%v)\n", r.elapsed.Round(time.Millisecond))
+    }
+  }
+  if o.output != "table" {
+    if err := writeRows(os.Stdout, o.output, runColumns, rows); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+  }
+  if failed > 0 {
+    return 1
+  }
+  return 0
+}
+
+// Verify prints one line per solution with the status of each part, then
+// the details of wrong answers and a summary. It records the status of
+// each part and reports what changed since the last verify.
+func verify(sols []solution, o *options) int {
+  previous, err := loadStatus(o.root)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc: reading status:", err)
+    previous = statusFile{}
+  }
+  var ch changes
+  counts := map[string]int{}
+  var rows []resultRow
+  for _, s := range sols {
+    r := run(s, o.timeout)
+    previous.update(r, &ch)
+    if err := recordRun(o.root, r); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+    }
+    outcome := r.outcome()
+    counts[outcome]++
+    if o.output != "table" {
+      rows = append(rows, resultRowOf(r))
+      continue
+    }
+    var line string
+    switch outcome {
+    case outcomeSkipped:
+      line = "skip  (no input)"
+    case outcomeFailed:
+      line = "FAIL  " + r.err.Error()
+    case outcomeTimeout:
+      // Not a failure as such: say how far it got, and whether its best
+      // answers so far are already the right ones.
+      line = "TIME  " + r.err.Error()
+      var te *timeoutError
+      errors.As(r.err, &te)
+      for p := 1; p <= s.edition.event.parts; p++ {
+        if a, ok := te.partial[p]; ok && s.expected[p] != "" {
+          verdict := "not yet the recorded answer"
+          if a == s.expected[p] {
+            verdict = "matches the recorded answer"
+          }
+          line += fmt.Sprintf("\n      part %d: best so far %s %s", p, a, verdict)
+        }
+      }
+    default:
+      label := map[string]string{outcomeOK: "ok", outcomeWrong: "WRONG", outcomeUnverified: "part"}[outcome]
+      status := r.check()
+      line = fmt.Sprintf("%-5s %s  %v", label, strings.Join(status, " "), r.elapsed.Round(time.Millisecond))
+      for p, st := range status {
+        if st == partWrong {
+          line += fmt.Sprintf("\n      part %d: got %s, want %s", p+1, r.answers[p+1], s.expected[p+1])
+        }
+      }
+    }
+    fmt.Printf("%-28s %-3s %s\n", s.id(), s.name(), line)
+  }
+  if o.output != "table" {
+    if err := writeRows(os.Stdout, o.output, runColumns, rows); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+    }
+  } else {
+    fmt.Printf("\n%d ok, %d wrong, %d unverified, %d failed, %d timed out, %d skipped\n",
+      counts["ok"], counts["wrong"], counts["unverified"], counts["failed"], counts["timeout"], counts["skipped"])
+  }
+
+  if err := previous.save(o.root); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc: saving status:", err)
+  }
+  if msg := ch.message(); msg != "" {
+    if o.output == "table" {
+      fmt.Print("\n" + msg)
+    }
+    if o.webhook != "" {
+      if err := postWebhook(o.webhook, msg); isOffline(err) {
+        fmt.Fprintln(os.Stderr, "aoc: offline, so the webhook was not notified")
+      } else if err != nil {
+        fmt.Fprintln(os.Stderr, "aoc: webhook:", err)
+      }
+    }
+  }
+  if counts["wrong"] > 0 || counts["failed"] > 0 || counts["timeout"] > 0 {
+    return 1
+  }
+  return 0
+}
+
+func main() {
+  if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "help" {
+    fmt.Fprintf(os.Stderr, usage, eventNames())
+    os.Exit(2)
+  }
+  command := os.Args[1]
+  switch command {
+  case "completion":
+    os.Exit(completion(os.Args[2:]))
+  case "archive":
+    os.Exit(archive(os.Args[2:]))
+  case "token":
+    os.Exit(token(os.Args[2:]))
+  case "__complete":
+    for _, c := range complete(os.Args[2:]) {
+      fmt.Println(c)
+    }
+    os.Exit(0)
+  }
+  o, err := parseFlags(command, os.Args[2:])
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    os.Exit(2)
+  }
+  if command == "work" {
+    os.Exit(work(o))
+  }
+  sols, err := discover(o.root, o.filter)
+  if err == nil {
+    sols, err = selectAlgo(sols, o.algo)
+  }
+  seedSolutions(sols, o.seed)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    os.Exit(1)
+  }
+
+  switch command {
+  case "run", "verify", "compare", "snapshot":
+    showProgress = newProgressBar()
+  }
+  switch command {
+  case "list":
+    list(sols)
+  case "run":
+    os.Exit(runAll(sols, o))
+  case "verify":
+    os.Exit(verify(sols, o))
+  case "profile":
+    os.Exit(profile(sols, o))
+  case "history":
+    os.Exit(history(sols, o))
+  case "solves":
+    os.Exit(solves(sols, o))
+  case "submit":
+    os.Exit(submit(sols, o))
+  case "answers":
+    os.Exit(answers(sols, o))
+  case "calendar":
+    os.Exit(calendar(sols, o))
+  case "heatmap":
+    os.Exit(heatmap(sols, o))
+  case "bench":
+    os.Exit(bench(sols, o))
+  case "compare":
+    os.Exit(compare(sols, o))
+  case "snapshot":
+    os.Exit(snapshot(sols, o))
+  case "scrub":
+    os.Exit(scrub(sols, o))
+  case "gen-parser":
+    os.Exit(genParser(o))
+  case "repl":
+    os.Exit(repl(o))
+  case "distribute":
+    os.Exit(distribute(sols, o))
+  case "serve":
+    fmt.Printf("serving %d solutions on http://%s\n", len(sols), o.addr)
+    if err := serve(o, sols); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      os.Exit(1)
+    }
+  default:
+    fmt.Fprintf(os.Stderr, "aoc: unknown command %q\n", command)
+    os.Exit(2)
+  }
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+  "fmt"
+  "io"
+  "math"
+  "net/http"
+  "sort"
+  "strconv"
+  "strings"
+  "sync"
+)
+
+// A small metrics registry that writes the Prometheus text format, enough
+// for counters and histograms with labels.
+type metric interface {
+  write(w io.Writer)
+}
+
+type registry struct {
+  metrics []metric
+}
+
+func (r *registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+  w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+  for _, m := range r.metrics {
+    m.write(w)
+  }
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Format {a="x",b="y"}, with extra appended as a last pair if given.
+func formatLabels(names, values []string, extra ...string) string {
+  if len(names) == 0 && len(extra) == 0 {
+    return ""
+  }
+  var pairs []string
+  for i, n := range names {
+    pairs = append(pairs, n+`="`+labelEscaper.Replace(values[i])+`"`)
+  }
+  if len(extra) == 2 {
+    pairs = append(pairs, extra[0]+`="`+extra[1]+`"`)
+  }
+  return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+  if math.IsInf(f, 1) {
+    return "+Inf"
+  }
+  return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Series are keyed by their label values joined with a byte that cannot
+// appear in them.
+const labelSep = "\xff"
+
+type counterVec struct {
+  name, help string
+  labels     []string
+  mu         sync.Mutex
+  values     map[string]float64
+}
+
+func (r *registry) counter(name, help string, labels ...string) *counterVec {
+  c := &counterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+  r.metrics = append(r.metrics, c)
+  return c
+}
+
+func (c *counterVec) inc(values ...string) {
+  c.mu.Lock()
+  c.values[strings.Join(values, labelSep)]++
+  c.mu.Unlock()
+}
+
+func (c *counterVec) write(w io.Writer) {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+  for _, key := range sortedKeys(c.values) {
+    fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, splitKey(key, c.labels)), formatFloat(c.values[key]))
+  }
+}
+
+type histogram struct {
+  counts []uint64
+  sum    float64
+  count  uint64
+}
+
+type histogramVec struct {
+  name, help string
+  labels     []string
+  buckets    []float64
+  mu         sync.Mutex
+  series     map[string]*histogram
+}
+
+func (r *registry) histogram(name, help string, buckets []float64, labels ...string) *histogramVec {
+  h := &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: map[string]*histogram{}}
+  r.metrics = append(r.metrics, h)
+  return h
+}
+
+func (h *histogramVec) observe(v float64, values ...string) {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  key := strings.Join(values, labelSep)
+  s := h.series[key]
+  if s == nil {
+    s = &histogram{counts: make([]uint64, len(h.buckets))}
+    h.series[key] = s
+  }
+  for i, le := range h.buckets {
+    if v <= le {
+      s.counts[i]++
+    }
+  }
+  s.sum += v
+  s.count++
+}
+
+func (h *histogramVec) write(w io.Writer) {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+  for _, key := range sortedKeys(h.series) {
+    s, values := h.series[key], splitKey(key, h.labels)
+    for i, le := range h.buckets {
+      fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, "le", formatFloat(le)), s.counts[i])
+    }
+    fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, "le", "+Inf"), s.count)
+    fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, values), formatFloat(s.sum))
+    fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, values), s.count)
+  }
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+  keys := make([]string, 0, len(m))
+  for k := range m {
+    keys = append(keys, k)
+  }
+  sort.Strings(keys)
+  return keys
+}
+
+func splitKey(key string, labels []string) []string {
+  if len(labels) == 0 {
+    return nil
+  }
+  return strings.Split(key, labelSep)
+}
+
+// The solve server's metrics.
+type serverMetrics struct {
+  registry *registry
+  runs     *counterVec
+  errors   *counterVec
+  duration *histogramVec
+  requests *counterVec
+}
+
+func newServerMetrics() *serverMetrics {
+  r := &registry{}
+  puzzle := []string{"event", "edition", "day", "lang", "variant"}
+  return &serverMetrics{
+    registry: r,
+    runs: r.counter("aoc_solve_runs_total", "Solution runs by outcome.",
+      append(puzzle, "outcome")...),
+    errors: r.counter("aoc_solve_errors_total", "Solution runs that failed to complete.", puzzle...),
+    duration: r.histogram("aoc_solve_duration_seconds", "Wall time of solution runs.",
+      []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}, puzzle...),
+    requests: r.counter("aoc_http_requests_total", "API requests by path and status code.", "path", "code"),
+  }
+}
+
+func (m *serverMetrics) observeRun(r result) {
+  s := r.sol
+  labels := []string{s.edition.event.name, s.edition.name, fmt.Sprintf("%02d", s.day), s.lang, s.variant}
+  outcome := r.outcome()
+  m.runs.inc(append(labels, outcome)...)
+  if outcome == outcomeFailed || outcome == outcomeTimeout {
+    m.errors.inc(labels...)
+  }
+  if outcome != outcomeSkipped {
+    m.duration.observe(r.elapsed.Seconds(), labels...)
+  }
+}
+
+// Count a handler's requests by status code.
+type statusRecorder struct {
+  http.ResponseWriter
+  code int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+  s.code = code
+  s.ResponseWriter.WriteHeader(code)
+}
+
+func (m *serverMetrics) instrument(path string, h http.HandlerFunc) http.HandlerFunc {
+  return func(w http.ResponseWriter, r *http.Request) {
+    rec := &statusRecorder{w, http.StatusOK}
+    h(rec, r)
+    m.requests.inc(path, strconv.Itoa(rec.code))
+  }
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+  "errors"
+  "fmt"
+  "io"
+  "math/rand"
+  "net"
+  "net/http"
+  "os"
+  "strconv"
+  "time"
+)
+
+// Everything that talks to the internet goes through fetch, which gives it
+// the same timeouts and retries, and which -offline, or AOC_OFFLINE, turns
+// into a quick, clear failure so a command falls back on what it has.
+var offline = os.Getenv("AOC_OFFLINE") != ""
+
+type offlineError struct{ what string }
+
+func (e *offlineError) Error() string {
+  return "offline: not " + e.what
+}
+
+// How hard fetch tries: each attempt may take attemptTimeout, and a failed
+// one is retried up to retries times, waiting retryBase, then twice that
+// and so on, with some jitter, or as long as the server's Retry-After.
+const (
+  attemptTimeout = 10 * time.Second
+  retries        = 3
+  retryBase      = time.Second
+  retryMax       = 30 * time.Second
+)
+
+var httpClient = &http.Client{Timeout: attemptTimeout}
+
+// Send the request made by newRequest, anew for each attempt since a body
+// can only be read once, and return the response to the first attempt that
+// gets one that is not a server error or a 429. what describes the request
+// for messages, as in "posting to the webhook".
+func fetch(what string, newRequest func() (*http.Request, error)) (*http.Response, error) {
+  if offline {
+    return nil, &offlineError{what}
+  }
+  var lastErr error
+  for attempt := 0; ; attempt++ {
+    req, err := newRequest()
+    if err != nil {
+      return nil, err
+    }
+    resp, err := httpClient.Do(req)
+    wait := retryBase << attempt
+    switch {
+    case err != nil:
+      lastErr = err
+    case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+      lastErr = fmt.Errorf("server answered %s", resp.Status)
+      if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+        wait = time.Duration(s) * time.Second
+      }
+      io.Copy(io.Discard, resp.Body)
+      resp.Body.Close()
+    default:
+      return resp, nil
+    }
+    if attempt == retries {
+      return nil, fmt.Errorf("%s: giving up after %d attempts: %w", what, attempt+1, lastErr)
+    }
+    wait = min(wait+time.Duration(rand.Int63n(int64(wait)/4+1)), retryMax)
+    fmt.Fprintf(os.Stderr, "aoc: %s: %v; retrying in %v\n", what, lastErr, wait.Round(100*time.Millisecond))
+    time.Sleep(wait)
+  }
+}
+
+// Send req once, for a request that must not be repeated: a retried
+// answer could be counted as a second guess, with its wait. -offline
+// stops it as it does fetch.
+func fetchOnce(what string, req *http.Request) (*http.Response, error) {
+  if offline {
+    return nil, &offlineError{what}
+  }
+  resp, err := httpClient.Do(req)
+  if err != nil {
+    return nil, fmt.Errorf("%s: %w", what, err)
+  }
+  return resp, nil
+}
+
+// Whether err is a request running out of time, after which the server
+// may or may not have acted on it.
+func isTimeout(err error) bool {
+  var ne net.Error
+  return errors.As(err, &ne) && ne.Timeout()
+}
+
+func isOffline(err error) bool {
+  var oe *offlineError
+  return errors.As(err, &oe)
+}
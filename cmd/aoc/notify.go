@@ -0,0 +1,75 @@
+package main
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "strings"
+)
+
+// Discord caps a message at 2000 characters; Slack allows more, but a
+// notification that long is not read anyway.
+const maxNotification = 1900
+
+// Post text to a Discord or Slack incoming webhook. The two differ only
+// in the name of the field that carries the text.
+func postWebhook(url, text string) error {
+  if len(text) > maxNotification {
+    text = text[:maxNotification] + "\n…"
+  }
+  field := "text"
+  if strings.Contains(url, "discord.com/") || strings.Contains(url, "discordapp.com/") {
+    field = "content"
+  }
+  body, _ := json.Marshal(map[string]string{field: text})
+  resp, err := fetch("posting to the webhook", func() (*http.Request, error) {
+    req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+    if err == nil {
+      req.Header.Set("Content-Type", "application/json")
+    }
+    return req, err
+  })
+  if err != nil {
+    return err
+  }
+  resp.Body.Close()
+  if resp.StatusCode/100 != 2 {
+    return fmt.Errorf("webhook answered %s", resp.Status)
+  }
+  return nil
+}
+
+// Changes since the previous verify worth telling people about: parts that
+// are now correct, and parts that were correct and no longer are.
+type changes struct {
+  solved      []string
+  regressions []string
+}
+
+func (c *changes) record(s solution, part int, before, after string) {
+  name := fmt.Sprintf("%s part %d (%s)", s.id(), part, s.name())
+  switch {
+  case after == partOK && before != partOK:
+    c.solved = append(c.solved, name)
+  case before == partOK && after != partOK:
+    c.regressions = append(c.regressions, fmt.Sprintf("%s: now %s", name, after))
+  }
+}
+
+func (c *changes) message() string {
+  var b strings.Builder
+  if len(c.solved) > 0 {
+    b.WriteString("⭐ Newly correct:\n")
+    for _, s := range c.solved {
+      b.WriteString("• " + s + "\n")
+    }
+  }
+  if len(c.regressions) > 0 {
+    b.WriteString("⚠️ Regressions:\n")
+    for _, s := range c.regressions {
+      b.WriteString("• " + s + "\n")
+    }
+  }
+  return b.String()
+}
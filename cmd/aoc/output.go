@@ -0,0 +1,210 @@
+package main
+
+import (
+  "encoding/csv"
+  "encoding/json"
+  "fmt"
+  "io"
+  "strconv"
+  "time"
+)
+
+// Output formats for run, verify, bench and history: the usual table for
+// reading, or every row as JSON, CSV or TSV for a spreadsheet or a script.
+// The last three are rendered from the same rows, made from the results
+// and history entries the tables are printed from.
+var outputFormats = []string{"table", "json", "csv", "tsv"}
+
+func checkOutput(format string) error {
+  for _, f := range outputFormats {
+    if f == format {
+      return nil
+    }
+  }
+  return fmt.Errorf("unknown output format %q (have table, json, csv, tsv)", format)
+}
+
+// One row of results: a run, as run and verify make, a past run from the
+// history, or a benchmark. Fields a command has nothing for are left
+// empty.
+type resultRow struct {
+  Time     time.Time      `json:"time"`
+  Event    string         `json:"event"`
+  Edition  string         `json:"edition"`
+  Day      int            `json:"day"`
+  Solution string         `json:"solution"`
+  Outcome  string         `json:"outcome,omitempty"`
+  Parts    map[int]string `json:"parts,omitempty"`
+  Answers  map[int]string `json:"answers,omitempty"`
+  Elapsed  time.Duration  `json:"elapsed,omitempty"`
+  MaxRSS   int64          `json:"max_rss,omitempty"`
+  Commit   string         `json:"commit,omitempty"`
+  Source   string         `json:"source,omitempty"`
+  Error    string         `json:"error,omitempty"`
+  Bench    *benchRow      `json:"bench,omitempty"`
+
+  parts int // the event's number of parts
+}
+
+type benchRow struct {
+  Runs     int           `json:"runs"`
+  Median   time.Duration `json:"median"`
+  Spread   float64       `json:"spread"`
+  Baseline time.Duration `json:"baseline,omitempty"`
+  // The change from the baseline, as a fraction, when significant, and
+  // the test's p-value whenever there was a baseline.
+  Delta *float64 `json:"delta,omitempty"`
+  P     *float64 `json:"p,omitempty"`
+}
+
+func solutionRow(s solution) resultRow {
+  return resultRow{
+    Event: s.edition.event.name, Edition: s.edition.name, Day: s.day, Solution: s.name(),
+    parts: s.edition.event.parts,
+  }
+}
+
+// The row of a run just made.
+func resultRowOf(r result) resultRow {
+  row := solutionRow(r.sol)
+  row.Time = time.Now()
+  row.Outcome, row.Answers, row.Elapsed, row.MaxRSS = r.outcome(), r.answers, r.elapsed, r.maxRSS
+  if r.err != nil {
+    row.Error = r.err.Error()
+  } else {
+    row.Parts = map[int]string{}
+    for i, p := range r.check() {
+      row.Parts[i+1] = p
+    }
+  }
+  return row
+}
+
+// The row of a past run of s.
+func historyRow(s solution, e historyEntry) resultRow {
+  row := solutionRow(s)
+  row.Time, row.Outcome, row.Parts, row.Answers = e.Time, e.Outcome, e.Parts, e.Answers
+  row.Elapsed, row.MaxRSS, row.Commit, row.Source, row.Error = e.Elapsed, e.MaxRSS, e.Commit, e.Source, e.Error
+  return row
+}
+
+// The columns of CSV and TSV output, by name. Parts and answers take a
+// column each per part, after the others named "parts" and "answers".
+var resultColumns = map[string]func(resultRow) string{
+  "time":       func(r resultRow) string { return r.Time.Format(time.RFC3339) },
+  "event":      func(r resultRow) string { return r.Event },
+  "edition":    func(r resultRow) string { return r.Edition },
+  "day":        func(r resultRow) string { return strconv.Itoa(r.Day) },
+  "solution":   func(r resultRow) string { return r.Solution },
+  "outcome":    func(r resultRow) string { return r.Outcome },
+  "elapsed_ms": func(r resultRow) string { return millis(r.Elapsed) },
+  "max_rss":    func(r resultRow) string { return intOrEmpty(r.MaxRSS) },
+  "commit":     func(r resultRow) string { return r.Commit },
+  "source":     func(r resultRow) string { return r.Source },
+  "error":      func(r resultRow) string { return r.Error },
+  "runs": func(r resultRow) string {
+    return benchField(r, func(b *benchRow) string { return strconv.Itoa(b.Runs) })
+  },
+  "median_ms": func(r resultRow) string {
+    return benchField(r, func(b *benchRow) string { return millis(b.Median) })
+  },
+  "spread_pct": func(r resultRow) string {
+    return benchField(r, func(b *benchRow) string { return strconv.FormatFloat(b.Spread*100, 'f', 1, 64) })
+  },
+  "baseline_ms": func(r resultRow) string {
+    return benchField(r, func(b *benchRow) string { return millis(b.Baseline) })
+  },
+  "delta_pct": func(r resultRow) string {
+    return benchField(r, func(b *benchRow) string { return floatOrEmpty(b.Delta, 100, 2) })
+  },
+  "p": func(r resultRow) string {
+    return benchField(r, func(b *benchRow) string { return floatOrEmpty(b.P, 1, 4) })
+  },
+}
+
+// The columns each command exports.
+var (
+  runColumns     = []string{"time", "event", "edition", "day", "solution", "outcome", "parts", "answers", "elapsed_ms", "max_rss", "error"}
+  historyColumns = []string{"time", "event", "edition", "day", "solution", "outcome", "parts", "answers", "elapsed_ms", "max_rss", "commit", "source", "error"}
+  benchColumns   = []string{"event", "edition", "day", "solution", "runs", "median_ms", "spread_pct", "baseline_ms", "delta_pct", "p"}
+)
+
+func millis(d time.Duration) string {
+  if d == 0 {
+    return ""
+  }
+  return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+func intOrEmpty(n int64) string {
+  if n == 0 {
+    return ""
+  }
+  return strconv.FormatInt(n, 10)
+}
+
+func floatOrEmpty(f *float64, scale float64, prec int) string {
+  if f == nil {
+    return ""
+  }
+  return strconv.FormatFloat(*f*scale, 'f', prec, 64)
+}
+
+func benchField(r resultRow, f func(*benchRow) string) string {
+  if r.Bench == nil {
+    return ""
+  }
+  return f(r.Bench)
+}
+
+// Write rows as JSON, CSV or TSV with the given columns.
+func writeRows(w io.Writer, format string, columns []string, rows []resultRow) error {
+  if format == "json" {
+    if rows == nil {
+      rows = []resultRow{}
+    }
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    return enc.Encode(rows)
+  }
+  parts := 0
+  for _, r := range rows {
+    parts = max(parts, r.parts)
+  }
+  cw := csv.NewWriter(w)
+  if format == "tsv" {
+    cw.Comma = '\t'
+  }
+  var header []string
+  for _, c := range columns {
+    switch c {
+    case "parts", "answers":
+      for p := 1; p <= parts; p++ {
+        header = append(header, fmt.Sprintf("%s%d", map[string]string{"parts": "part", "answers": "answer"}[c], p))
+      }
+    default:
+      header = append(header, c)
+    }
+  }
+  cw.Write(header)
+  for _, r := range rows {
+    var record []string
+    for _, c := range columns {
+      switch c {
+      case "parts", "answers":
+        values := r.Parts
+        if c == "answers" {
+          values = r.Answers
+        }
+        for p := 1; p <= parts; p++ {
+          record = append(record, values[p])
+        }
+      default:
+        record = append(record, resultColumns[c](r))
+      }
+    }
+    cw.Write(record)
+  }
+  cw.Flush()
+  return cw.Error()
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+  "bufio"
+  "context"
+  "encoding/json"
+  "fmt"
+  "io"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "regexp"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Plugins are solvers that live outside the repository, in any language,
+// and attach to the runner over a JSON protocol on standard input and
+// output instead of being built in. AOC_PLUGINS lists their executables,
+// separated like PATH.
+//
+// Each message is one JSON object on one line. The runner opens with a
+// hello and the plugin answers with its name and the puzzles it solves,
+// optionally with their expected answers:
+//
+//   > {"type":"hello","protocol":1}
+//   < {"type":"hello","protocol":1,"name":"rust","puzzles":[
+//       {"event":"aoc","edition":"2024","day":2,"expected":{"1":"341"}}]}
+//
+// To solve, the runner sends the puzzle with its input, and the plugin
+// replies with the answers by part, the time it spent solving, any log
+// output, and an error if it failed. A puzzle may list "algos" in the
+// hello, and the solve request then names the one selected as "algo". The
+// request's "seed" is for any randomness, so runs repeat:
+//
+//   > {"type":"solve","event":"aoc","edition":"2024","day":2,"input":"7 6 4 2 1\n..."}
+//   < {"type":"answer","answers":{"1":"341","2":"404"},"elapsed_ns":1830000}
+//
+// The runner then closes the plugin's standard input, and the plugin
+// should exit. Each run starts a fresh process, so a plugin that hangs or
+// crashes costs one run. A plugin's puzzles without expected answers take
+// those recorded by the repository's own solutions of the same puzzle.
+const pluginProtocol = 1
+
+type pluginMessage struct {
+  Type     string            `json:"type"`
+  Protocol int               `json:"protocol,omitempty"`
+  Name     string            `json:"name,omitempty"`
+  Puzzles  []pluginPuzzle    `json:"puzzles,omitempty"`
+  Event    string            `json:"event,omitempty"`
+  Edition  string            `json:"edition,omitempty"`
+  Day      int               `json:"day,omitempty"`
+  Input    string            `json:"input,omitempty"`
+  Algo     string            `json:"algo,omitempty"`
+  Seed     int64             `json:"seed,omitempty"`
+  Answers  map[string]string `json:"answers,omitempty"`
+  Elapsed  int64             `json:"elapsed_ns,omitempty"`
+  Output   string            `json:"output,omitempty"`
+  Error    string            `json:"error,omitempty"`
+}
+
+type pluginPuzzle struct {
+  Event    string            `json:"event"`
+  Edition  string            `json:"edition"`
+  Day      int               `json:"day"`
+  Expected map[string]string `json:"expected,omitempty"`
+  Algos    []string          `json:"algos,omitempty"`
+}
+
+// How long a plugin has to say hello.
+const pluginHandshakeTimeout = 10 * time.Second
+
+// A running plugin process.
+type pluginConn struct {
+  cmd   *exec.Cmd
+  stdin io.WriteCloser
+  in    *json.Encoder
+  out   *bufio.Scanner
+}
+
+func startPlugin(ctx context.Context, path string) (*pluginConn, pluginMessage, error) {
+  cmd := exec.CommandContext(ctx, path)
+  stdin, err := cmd.StdinPipe()
+  if err != nil {
+    return nil, pluginMessage{}, err
+  }
+  stdout, err := cmd.StdoutPipe()
+  if err != nil {
+    return nil, pluginMessage{}, err
+  }
+  cmd.Stderr = os.Stderr
+  if err := cmd.Start(); err != nil {
+    return nil, pluginMessage{}, err
+  }
+  p := &pluginConn{cmd: cmd, stdin: stdin, in: json.NewEncoder(stdin), out: bufio.NewScanner(stdout)}
+  p.out.Buffer(nil, 1<<26)
+  hello, err := p.call(pluginMessage{Type: "hello", Protocol: pluginProtocol}, "hello")
+  if err == nil && hello.Protocol != pluginProtocol {
+    err = fmt.Errorf("speaks protocol %d, not %d", hello.Protocol, pluginProtocol)
+  }
+  if err != nil {
+    p.close()
+    return nil, pluginMessage{}, err
+  }
+  return p, hello, nil
+}
+
+// Send a message and read the reply, which must be of the given type.
+func (p *pluginConn) call(msg pluginMessage, want string) (pluginMessage, error) {
+  var reply pluginMessage
+  if err := p.in.Encode(msg); err != nil {
+    return reply, err
+  }
+  if !p.out.Scan() {
+    if err := p.out.Err(); err != nil {
+      return reply, err
+    }
+    return reply, fmt.Errorf("exited without replying to %s", msg.Type)
+  }
+  if err := json.Unmarshal(p.out.Bytes(), &reply); err != nil {
+    return reply, fmt.Errorf("bad reply to %s: %v", msg.Type, err)
+  }
+  if reply.Type != want {
+    return reply, fmt.Errorf("replied %q to %s, not %q", reply.Type, msg.Type, want)
+  }
+  return reply, nil
+}
+
+func (p *pluginConn) close() error {
+  p.stdin.Close()
+  return p.cmd.Wait()
+}
+
+func pluginPaths() []string {
+  var paths []string
+  for _, path := range filepath.SplitList(os.Getenv("AOC_PLUGINS")) {
+    if path != "" {
+      paths = append(paths, path)
+    }
+  }
+  return paths
+}
+
+var pluginName = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Ask each plugin which puzzles it solves, and add those the filter
+// selects to sols as solutions in the "plugin" language, with the
+// plugin's name as their variant. A plugin that fails its handshake is
+// reported and left out.
+func discoverPlugins(root string, f filter, sols []solution) ([]solution, error) {
+  paths := pluginPaths()
+  if len(paths) == 0 || f.lang != "" && f.lang != "plugin" {
+    return sols, nil
+  }
+  recorded := map[string]map[int]string{}
+  for _, s := range sols {
+    if recorded[s.id()] == nil && len(s.expected) > 0 {
+      recorded[s.id()] = s.expected
+    }
+  }
+  // Editions by event and name, and their place in discovery order.
+  editions := map[string]edition{}
+  order := map[string]int{}
+  for _, e := range events {
+    eds, err := e.editions(root)
+    if err != nil {
+      return nil, err
+    }
+    for _, ed := range eds {
+      editions[e.name+"/"+ed.name] = ed
+      order[ed.dir] = len(order)
+    }
+  }
+
+  for _, path := range paths {
+    path, _ = filepath.Abs(path)
+    ctx, cancel := context.WithTimeout(context.Background(), pluginHandshakeTimeout)
+    p, hello, err := startPlugin(ctx, path)
+    if err == nil {
+      p.close()
+    }
+    cancel()
+    if err != nil {
+      fmt.Fprintf(os.Stderr, "aoc: plugin %s: %v\n", path, err)
+      continue
+    }
+    name := strings.Trim(pluginName.ReplaceAllString(strings.ToLower(hello.Name), "-"), "-")
+    if name == "" {
+      name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+    }
+    for _, pz := range hello.Puzzles {
+      if f.event != "" && f.event != pz.Event || f.edition != "" && f.edition != pz.Edition || f.day != 0 && f.day != pz.Day {
+        continue
+      }
+      ed, ok := editions[pz.Event+"/"+pz.Edition]
+      if !ok {
+        fmt.Fprintf(os.Stderr, "aoc: plugin %s: no %s edition %s in the repository\n", name, pz.Event, pz.Edition)
+        continue
+      }
+      sol := solution{
+        edition: ed, day: pz.Day, lang: "plugin", variant: name, path: path,
+        expected: map[int]string{}, algos: pz.Algos,
+      }
+      for part, answer := range pz.Expected {
+        if n, err := strconv.Atoi(part); err == nil {
+          sol.expected[n] = answer
+        }
+      }
+      if len(sol.expected) == 0 && recorded[sol.id()] != nil {
+        sol.expected = recorded[sol.id()]
+      }
+      sols = append(sols, sol)
+    }
+  }
+  sort.SliceStable(sols, func(i, j int) bool {
+    a, b := order[sols[i].edition.dir], order[sols[j].edition.dir]
+    return a < b || a == b && sols[i].day < sols[j].day
+  })
+  return sols, nil
+}
+
+// Solve a puzzle with a plugin. The plugin's own timing is used when it
+// reports one, since it leaves out the process start-up. The answers are
+// written out as "Part N: answer" lines after the plugin's log output, so
+// the result reads like any other solution's.
+func runPlugin(sol solution, input string, timeout time.Duration) result {
+  res := result{sol: sol}
+  data, err := os.ReadFile(input)
+  if err != nil {
+    res.err = err
+    return res
+  }
+  ctx, cancel := context.WithTimeout(context.Background(), timeout)
+  defer cancel()
+  start := time.Now()
+  p, _, err := startPlugin(ctx, sol.path)
+  if err != nil {
+    res.err = err
+    return res
+  }
+  reply, err := p.call(pluginMessage{
+    Type: "solve", Event: sol.edition.event.name, Edition: sol.edition.name,
+    Day: sol.day, Input: string(data), Algo: sol.algo, Seed: sol.seed,
+  }, "answer")
+  res.elapsed = time.Since(start)
+  p.close()
+  res.maxRSS = maxRSS(p.cmd.ProcessState)
+  switch {
+  case ctx.Err() == context.DeadlineExceeded:
+    res.err = &timeoutError{after: timeout}
+    return res
+  case err != nil:
+    res.err = err
+    return res
+  case reply.Error != "":
+    res.err = fmt.Errorf("plugin: %s", reply.Error)
+  }
+  if reply.Elapsed > 0 {
+    res.elapsed = time.Duration(reply.Elapsed)
+  }
+
+  var out strings.Builder
+  out.WriteString(reply.Output)
+  if reply.Output != "" && !strings.HasSuffix(reply.Output, "\n") {
+    out.WriteString("\n")
+  }
+  parts := sortedKeys(reply.Answers)
+  sort.SliceStable(parts, func(i, j int) bool { return numericLess(parts[i], parts[j]) })
+  for _, part := range parts {
+    fmt.Fprintf(&out, "Part %s: %s\n", part, reply.Answers[part])
+  }
+  res.output = out.String()
+  res.answers = parseAnswers(res.output)
+  return res
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// Without process groups only the solution itself is killed at a timeout.
+func runGroup(cmd *exec.Cmd) error {
+  cmd.WaitDelay = waitDelay
+  return cmd.Run()
+}
@@ -0,0 +1,61 @@
+//go:build unix
+
+package main
+
+import (
+  "os"
+  "os/exec"
+  "os/signal"
+  "sync"
+  "syscall"
+)
+
+// A solution runs in a process group of its own, so that a timeout kills
+// everything it started: the program a shell line runs, a Python
+// multiprocessing pool, anything left behind in the background. Being its
+// own group also keeps it from the terminal's Ctrl-C, so aoc kills the
+// groups still running when it is interrupted, before it exits.
+var groups = struct {
+  sync.Mutex
+  running map[int]bool
+  once    sync.Once
+}{running: map[int]bool{}}
+
+// Run cmd as the leader of a new process group, killing the whole group
+// when its context ends.
+func runGroup(cmd *exec.Cmd) error {
+  groups.once.Do(killGroupsOnSignal)
+  cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+  cmd.Cancel = func() error {
+    return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+  }
+  cmd.WaitDelay = waitDelay
+  groups.Lock()
+  err := cmd.Start()
+  if err == nil {
+    groups.running[cmd.Process.Pid] = true
+  }
+  groups.Unlock()
+  if err != nil {
+    return err
+  }
+  defer func() {
+    groups.Lock()
+    delete(groups.running, cmd.Process.Pid)
+    groups.Unlock()
+  }()
+  return cmd.Wait()
+}
+
+func killGroupsOnSignal() {
+  c := make(chan os.Signal, 1)
+  signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+  go func() {
+    sig := <-c
+    groups.Lock()
+    for pid := range groups.running {
+      syscall.Kill(-pid, syscall.SIGKILL)
+    }
+    os.Exit(128 + int(sig.(syscall.Signal)))
+  }()
+}
@@ -0,0 +1,69 @@
+//go:build unix
+
+package main
+
+import (
+  "context"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "syscall"
+  "testing"
+  "time"
+)
+
+// A timeout kills what the solution started as well as the solution, and
+// does not wait on a grandchild holding the output open.
+func TestRunGroupTimeout(t *testing.T) {
+  pidFile := filepath.Join(t.TempDir(), "pid")
+  ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+  defer cancel()
+  cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 30 & echo $! > "+pidFile+"; wait")
+  var out strings.Builder
+  cmd.Stdout = &out
+  start := time.Now()
+  if err := runGroup(cmd); err == nil {
+    t.Fatal("ran to the end")
+  }
+  if took := time.Since(start); took > waitDelay {
+    t.Errorf("took %v to stop", took)
+  }
+  b, err := os.ReadFile(pidFile)
+  if err != nil {
+    t.Fatal(err)
+  }
+  pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+  for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+    if !alive(pid) {
+      break
+    }
+    if time.Now().After(deadline) {
+      syscall.Kill(pid, syscall.SIGKILL)
+      t.Fatalf("the background sleep %d outlived the timeout", pid)
+    }
+  }
+}
+
+func TestRunGroup(t *testing.T) {
+  cmd := exec.CommandContext(context.Background(), "sh", "-c", "echo Part 1: 42")
+  var out strings.Builder
+  cmd.Stdout = &out
+  if err := runGroup(cmd); err != nil || out.String() != "Part 1: 42\n" {
+    t.Errorf("got %q, %v", out.String(), err)
+  }
+  if len(groups.running) != 0 {
+    t.Errorf("still tracking %v", groups.running)
+  }
+}
+
+// Whether pid is a live process: a killed one may linger as a zombie
+// until whatever it was left to reaps it.
+func alive(pid int) bool {
+  if syscall.Kill(pid, 0) == syscall.ESRCH {
+    return false
+  }
+  stat, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+  return err == nil && !strings.HasPrefix(strings.TrimSpace(string(stat)), "Z")
+}
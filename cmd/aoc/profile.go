@@ -0,0 +1,386 @@
+package main
+
+import (
+  "bufio"
+  _ "embed"
+  "encoding/json"
+  "fmt"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "regexp"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+//go:embed profilers/sample.py
+var pythonSampler []byte
+
+// A profiler records where a solution spends its time as folded stacks:
+// each key is a call stack, outermost first and separated by ";", and each
+// value its weight. Python is sampled every millisecond, so weights are
+// samples; Go and JavaScript weights are microseconds.
+type profiler struct {
+  folded map[string]int64
+  unit   string
+  // Where the raw profile was left, for handing to pprof.
+  raw string
+}
+
+// Go solutions are profiled by renaming their main and building them with
+// a main that wraps it in pprof.StartCPUProfile and StopCPUProfile.
+const goProfileMain = `package main
+
+import (
+  "os"
+  "runtime/pprof"
+)
+
+func main() {
+  f, err := os.Create(os.Getenv("AOC_CPUPROFILE"))
+  if err == nil {
+    pprof.StartCPUProfile(f)
+  }
+  aocProfiledMain()
+  pprof.StopCPUProfile()
+  f.Close()
+}
+`
+
+var goMainFunc = regexp.MustCompile(`(?m)^func main\(\)`)
+
+// A solution's build constraint, "//go:build ignore", which keeps the
+// several mains of an edition out of "go build ./..." but would keep the
+// copy out of its own build.
+var goBuildLine = regexp.MustCompile(`(?m)^//go:build .*\n`)
+
+// The go.mod of the module a solution belongs to, the nearest above dir,
+// with its module path and the directory holding it.
+func goModule(dir string) (gomod, path, root string, err error) {
+  for d := dir; ; d = filepath.Dir(d) {
+    data, err := os.ReadFile(filepath.Join(d, "go.mod"))
+    if err == nil {
+      for _, line := range strings.Split(string(data), "\n") {
+        if f := strings.Fields(line); len(f) == 2 && f[0] == "module" {
+          return string(data), f[1], d, nil
+        }
+      }
+      return "", "", "", fmt.Errorf("%s has no module line", filepath.Join(d, "go.mod"))
+    }
+    if filepath.Dir(d) == d {
+      return "", "", "", fmt.Errorf("%s is not inside a Go module", dir)
+    }
+  }
+}
+
+func (p *profiler) source(sol solution, scratch, path string) (string, error) {
+  if sol.lang != "go" {
+    return path, nil
+  }
+  src, err := os.ReadFile(path)
+  if err != nil {
+    return "", err
+  }
+  if !goMainFunc.Match(src) {
+    return "", fmt.Errorf("%s has no func main to wrap", sol.path)
+  }
+  dir := filepath.Join(scratch, "src")
+  if err := os.Mkdir(dir, 0o755); err != nil {
+    return "", err
+  }
+  // The copy is built outside the repository, as a module of its own that
+  // finds the repository's packages, which the solution may import, where
+  // they are.
+  gomod, mod, root, err := goModule(filepath.Dir(path))
+  if err != nil {
+    return "", err
+  }
+  gomod = strings.Replace(gomod, "module "+mod, "module aocprofile", 1) +
+    fmt.Sprintf("\nrequire %s v0.0.0\n\nreplace %s => %s\n", mod, mod, root)
+  if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o644); err != nil {
+    return "", err
+  }
+  src = goBuildLine.ReplaceAll(src, nil)
+  src = goMainFunc.ReplaceAll(src, []byte("func aocProfiledMain()"))
+  if err := os.WriteFile(filepath.Join(dir, "main.go"), src, 0o644); err != nil {
+    return "", err
+  }
+  return dir, os.WriteFile(filepath.Join(dir, "aoc_profile.go"), []byte(goProfileMain), 0o644)
+}
+
+func (p *profiler) command(sol solution, scratch string, argv []string) ([]string, []string) {
+  switch sol.lang {
+  case "py":
+    sampler := filepath.Join(scratch, "sample.py")
+    os.WriteFile(sampler, pythonSampler, 0o644)
+    return append([]string{argv[0], sampler, filepath.Join(scratch, "folded")}, argv[1:]...), nil
+  case "go":
+    return argv, []string{"AOC_CPUPROFILE=" + filepath.Join(scratch, "cpu.pprof")}
+  case "js":
+    return append([]string{argv[0], "--cpu-prof", "--cpu-prof-dir=" + filepath.Join(scratch, "prof")}, argv[1:]...), nil
+  }
+  return argv, nil
+}
+
+// Collect the profile, copying the raw data out of the scratch directory
+// into dir so pprof can still read it afterwards.
+func (p *profiler) collect(sol solution, scratch string, argv []string) error {
+  var err error
+  switch sol.lang {
+  case "py":
+    p.unit = "samples"
+    p.folded, err = readFolded(filepath.Join(scratch, "folded"))
+  case "go":
+    p.unit = "µs"
+    p.folded, err = foldPprof(argv[0], filepath.Join(scratch, "cpu.pprof"))
+    if err == nil && p.raw != "" {
+      err = copyProfile(argv[0], filepath.Join(scratch, "cpu.pprof"), p.raw)
+    }
+  case "js":
+    p.unit = "µs"
+    var matches []string
+    matches, err = filepath.Glob(filepath.Join(scratch, "prof", "*.cpuprofile"))
+    if err == nil && len(matches) == 0 {
+      err = fmt.Errorf("node wrote no profile")
+    }
+    if err == nil {
+      p.folded, err = foldCPUProfile(matches[0])
+    }
+  default:
+    err = fmt.Errorf("cannot profile %s solutions", sol.lang)
+  }
+  if err == nil && len(p.folded) == 0 {
+    err = fmt.Errorf("the profile is empty; the run may have been too short to sample")
+  }
+  return err
+}
+
+func readFolded(path string) (map[string]int64, error) {
+  f, err := os.Open(path)
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+  folded := map[string]int64{}
+  s := bufio.NewScanner(f)
+  s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+  for s.Scan() {
+    line := s.Text()
+    i := strings.LastIndexByte(line, ' ')
+    if i < 0 {
+      continue
+    }
+    n, err := strconv.ParseInt(line[i+1:], 10, 64)
+    if err != nil {
+      return nil, fmt.Errorf("bad folded line %q", line)
+    }
+    folded[line[:i]] += n
+  }
+  return folded, s.Err()
+}
+
+func writeFolded(path string, folded map[string]int64) error {
+  var b strings.Builder
+  for _, stack := range sortedKeys(folded) {
+    fmt.Fprintf(&b, "%s %d\n", stack, folded[stack])
+  }
+  return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// Fold a Go CPU profile using the stacks that pprof -traces prints: each
+// sample's weight and leaf function on one line, its callers on the lines
+// below, and a rule of dashes between samples.
+func foldPprof(bin, prof string) (map[string]int64, error) {
+  if _, err := os.Stat(prof); err != nil {
+    return nil, fmt.Errorf("no profile written; the solution may have exited through os.Exit")
+  }
+  out, err := exec.Command("go", "tool", "pprof", "-traces", bin, prof).Output()
+  if err != nil {
+    return nil, fmt.Errorf("go tool pprof: %v", err)
+  }
+  folded := map[string]int64{}
+  var stack []string
+  var weight int64
+  flush := func() {
+    if len(stack) == 0 {
+      return
+    }
+    for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+      stack[i], stack[j] = stack[j], stack[i]
+    }
+    folded[strings.Join(stack, ";")] += weight
+    stack = nil
+  }
+  for _, line := range strings.Split(string(out), "\n") {
+    if strings.HasPrefix(line, "-----") {
+      flush()
+      continue
+    }
+    fields := strings.Fields(line)
+    if len(fields) == 0 || strings.HasSuffix(fields[0], ":") {
+      continue
+    }
+    if len(stack) == 0 && len(fields) >= 2 {
+      d, err := time.ParseDuration(fields[0])
+      if err != nil {
+        continue
+      }
+      weight = d.Microseconds()
+      fields = fields[1:]
+    }
+    stack = append(stack, strings.Join(fields, " "))
+  }
+  flush()
+  return folded, nil
+}
+
+func copyProfile(bin, prof, dest string) error {
+  if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+    return err
+  }
+  for _, pair := range [][2]string{{prof, dest}, {bin, dest + ".bin"}} {
+    data, err := os.ReadFile(pair[0])
+    if err != nil {
+      return err
+    }
+    if err := os.WriteFile(pair[1], data, 0o755); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// Fold a V8 .cpuprofile: a tree of call frames, the node sampled at each
+// tick, and the time since the previous tick.
+func foldCPUProfile(path string) (map[string]int64, error) {
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return nil, err
+  }
+  var prof struct {
+    Nodes []struct {
+      ID        int `json:"id"`
+      CallFrame struct {
+        FunctionName string `json:"functionName"`
+        URL          string `json:"url"`
+        LineNumber   int    `json:"lineNumber"`
+      } `json:"callFrame"`
+      Children []int `json:"children"`
+    } `json:"nodes"`
+    Samples    []int   `json:"samples"`
+    TimeDeltas []int64 `json:"timeDeltas"`
+  }
+  if err := json.Unmarshal(data, &prof); err != nil {
+    return nil, err
+  }
+  names, parent := map[int]string{}, map[int]int{}
+  for _, n := range prof.Nodes {
+    name := n.CallFrame.FunctionName
+    if name == "" {
+      name = "(anonymous)"
+    }
+    if n.CallFrame.URL != "" {
+      name += fmt.Sprintf(" (%s:%d)", filepath.Base(n.CallFrame.URL), n.CallFrame.LineNumber+1)
+    }
+    names[n.ID] = name
+    for _, c := range n.Children {
+      parent[c] = n.ID
+    }
+  }
+  folded := map[string]int64{}
+  for i, id := range prof.Samples {
+    if names[id] == "(idle)" || i+1 >= len(prof.TimeDeltas) {
+      continue
+    }
+    var stack []string
+    for n, ok := id, true; ok; n, ok = parent[n] {
+      if names[n] != "(root)" {
+        stack = append([]string{names[n]}, stack...)
+      }
+    }
+    // A sample's time is the delta before the next one.
+    folded[strings.Join(stack, ";")] += prof.TimeDeltas[i+1]
+  }
+  return folded, nil
+}
+
+// The functions with the most self time, the leaves of the stacks.
+func topFunctions(folded map[string]int64, n int) ([]string, map[string]int64, int64) {
+  self := map[string]int64{}
+  var total int64
+  for stack, w := range folded {
+    leaf := stack[strings.LastIndexByte(stack, ';')+1:]
+    self[leaf] += w
+    total += w
+  }
+  names := sortedKeys(self)
+  sort.SliceStable(names, func(i, j int) bool { return self[names[i]] > self[names[j]] })
+  return names[:min(n, len(names))], self, total
+}
+
+// Profile one solution: print its hottest functions, and with -flame write
+// a flame graph. With -web, Go profiles open in pprof's web UI instead.
+func profile(sols []solution, o *options) int {
+  if len(sols) != 1 {
+    fmt.Fprintf(os.Stderr, "aoc: profile needs exactly one solution, and %d match; use -y, -d and -lang\n", len(sols))
+    return 2
+  }
+  sol := sols[0]
+  base := filepath.Join(stateDir(o.root), "profiles", strings.ReplaceAll(sol.key(), "/", "_"))
+  prof := &profiler{}
+  if sol.lang == "go" {
+    prof.raw = base + ".pprof"
+  }
+  r := runProfiled(sol, o.timeout, prof)
+  if err := recordRun(o.root, r); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+  }
+  if r.err != nil {
+    fmt.Fprintf(os.Stderr, "aoc: %s: %v\n", sol.key(), r.err)
+    return 1
+  }
+  fmt.Print(r.output)
+  fmt.Printf("(%v)\n\n", r.elapsed.Round(time.Millisecond))
+
+  names, self, total := topFunctions(prof.folded, 15)
+  fmt.Printf("%10s %6s  %s\n", "self "+prof.unit, "", "function")
+  for _, name := range names {
+    fmt.Printf("%10d %5.1f%%  %s\n", self[name], float64(self[name])/float64(total)*100, name)
+  }
+
+  if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  if err := writeFolded(base+".folded", prof.folded); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  if o.flame {
+    out := o.flameOut
+    if out == "" {
+      out = base + ".svg"
+    }
+    title := fmt.Sprintf("%s.%s, %v", sol.id(), sol.name(), r.elapsed.Round(time.Millisecond))
+    if err := os.WriteFile(out, flameGraph(prof.folded, title, prof.unit), 0o644); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+    fmt.Println("\nflame graph:", out)
+  }
+  if o.pprofWeb {
+    if prof.raw == "" {
+      fmt.Fprintln(os.Stderr, "aoc: -web needs a Go solution; pprof does not read other profiles")
+      return 1
+    }
+    cmd := exec.Command("go", "tool", "pprof", "-http=localhost:0", prof.raw+".bin", prof.raw)
+    cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+    if err := cmd.Run(); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+  }
+  return 0
+}
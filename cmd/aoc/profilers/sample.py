@@ -0,0 +1,54 @@
+"""
+A sampling profiler for Python solutions: run a script and, on every tick
+of the profiling timer, record the interpreter's stack. Writes folded stacks
+("outer;inner count" lines) for a flame graph.
+
+    python3 sample.py OUTPUT SCRIPT
+"""
+
+import collections
+import os
+import runpy
+import signal
+import sys
+
+INTERVAL = 0.001
+
+counts: collections.Counter = collections.Counter()
+here = os.path.abspath(__file__)
+
+
+def frame_name(frame) -> str:
+    code = frame.f_code
+    return f"{code.co_name} ({os.path.basename(code.co_filename)}:{code.co_firstlineno})"
+
+
+def sample(signum, frame) -> None:
+    stack = []
+    while frame is not None:
+        # Leave out this profiler and the runpy machinery beneath the script.
+        name = frame.f_code.co_filename
+        if name != here and not name.endswith(("runpy.py", "<frozen runpy>")):
+            stack.append(frame_name(frame))
+        frame = frame.f_back
+    if stack:
+        counts[";".join(reversed(stack))] += 1
+
+
+def main() -> None:
+    output, script = sys.argv[1], sys.argv[2]
+    sys.argv = sys.argv[2:]
+    sys.path.insert(0, os.path.dirname(os.path.abspath(script)))
+    signal.signal(signal.SIGPROF, sample)
+    signal.setitimer(signal.ITIMER_PROF, INTERVAL, INTERVAL)
+    try:
+        runpy.run_path(script, run_name="__main__")
+    finally:
+        signal.setitimer(signal.ITIMER_PROF, 0, 0)
+        with open(output, "w") as file:
+            for stack, n in counts.items():
+                file.write(f"{stack} {n}\n")
+
+
+if __name__ == "__main__":
+    main()
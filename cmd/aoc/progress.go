@@ -0,0 +1,150 @@
+package main
+
+import (
+  "bytes"
+  _ "embed"
+  "fmt"
+  "os"
+  "regexp"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Long-running solutions report their progress on standard error, as lines
+// the runner takes out of the error output and shows as a progress bar, or
+// as a log line now and then when standard error is not a terminal:
+//
+//   aoc-progress: 4200/10000
+//
+// The total may be "?" when it is not known. A search can also report the
+// best answer it has found so far for a part, which the runner shows if
+// the run times out:
+//
+//   aoc-partial: 2 1048
+//
+// The runner sets AOC_PROGRESS so a solution need only report when run by
+// it; Python solutions can import report and partial from aoc_progress,
+// which the runner puts on their path (see progress/aoc_progress.py).
+//
+// Steps traced for -explain arrive the same way; see explain.go.
+var (
+  progressLine = regexp.MustCompile(`^aoc-progress: (\d+)/(\d+|\?)\s*$`)
+  partialLine  = regexp.MustCompile(`^aoc-partial: (\d+) (.*?)\s*$`)
+  traceLine    = regexp.MustCompile(`^aoc-trace: (.*?)\s*$`)
+)
+
+//go:embed progress/aoc_progress.py
+var pythonProgress []byte
+
+// How a command shows progress; nil for none, as when serving.
+var showProgress *progressBar
+
+type progressBar struct {
+  tty   bool
+  last  time.Time
+  drawn bool
+}
+
+func newProgressBar() *progressBar {
+  info, err := os.Stderr.Stat()
+  return &progressBar{tty: err == nil && info.Mode()&os.ModeCharDevice != 0}
+}
+
+func (p *progressBar) report(sol solution, done, total int64, elapsed time.Duration) {
+  // Logs start once a run has gone on for an interval, so quick runs
+  // stay quiet.
+  interval := 10 * time.Second
+  if p.tty {
+    interval = 100 * time.Millisecond
+  } else if p.last.IsZero() {
+    p.last = time.Now().Add(-elapsed)
+  }
+  if time.Since(p.last) < interval {
+    return
+  }
+  p.last = time.Now()
+  status := fmt.Sprintf("%d", done)
+  frac := -1.0
+  if total > 0 {
+    frac = min(float64(done)/float64(total), 1)
+    status = fmt.Sprintf("%5.1f%% (%d/%d)", frac*100, done, total)
+  }
+  name := sol.id() + "." + sol.name()
+  if !p.tty {
+    fmt.Fprintf(os.Stderr, "  %s: %s after %v\n", name, status, elapsed.Round(time.Second))
+    return
+  }
+  bar := ""
+  if frac >= 0 {
+    const width = 30
+    filled := int(frac * width)
+    bar = "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "] "
+  }
+  fmt.Fprintf(os.Stderr, "\r%s %s%s %v\x1b[K", name, bar, status, elapsed.Round(100*time.Millisecond))
+  p.drawn = true
+}
+
+// Clear the bar when a run ends, so the results print on a clean line.
+func (p *progressBar) clear() {
+  if p.drawn {
+    fmt.Fprint(os.Stderr, "\r\x1b[K")
+    p.drawn = false
+  }
+  p.last = time.Time{}
+}
+
+// A progressWriter is a solution's standard error: it keeps the latest
+// progress, partial answers and traced steps, showing progress on the bar
+// if there is one, and passes everything else to the error output kept
+// for the result.
+type progressWriter struct {
+  sol    solution
+  bar    *progressBar
+  start  time.Time
+  line   []byte
+  stderr *bytes.Buffer
+
+  done, total int64
+  partial     map[int]string
+  trace       []traceStep
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+  w.line = append(w.line, p...)
+  for {
+    i := bytes.IndexByte(w.line, '\n')
+    if i < 0 {
+      break
+    }
+    line := w.line[:i+1]
+    if m := progressLine.FindSubmatch(line); m != nil {
+      w.done, _ = strconv.ParseInt(string(m[1]), 10, 64)
+      w.total, _ = strconv.ParseInt(string(m[2]), 10, 64)
+      if w.bar != nil {
+        w.bar.report(w.sol, w.done, w.total, time.Since(w.start))
+      }
+    } else if m := partialLine.FindSubmatch(line); m != nil {
+      if w.partial == nil {
+        w.partial = map[int]string{}
+      }
+      part, _ := strconv.Atoi(string(m[1]))
+      w.partial[part] = string(m[2])
+    } else if m := traceLine.FindSubmatch(line); m != nil {
+      w.trace = append(w.trace, parseTrace(m[1]))
+    } else {
+      w.stderr.Write(line)
+    }
+    w.line = w.line[i+1:]
+  }
+  return len(p), nil
+}
+
+// Flush any unterminated last line and clear the bar.
+func (w *progressWriter) close() {
+  w.stderr.Write(w.line)
+  w.line = nil
+  if w.bar != nil {
+    w.bar.clear()
+  }
+}
@@ -0,0 +1,51 @@
+"""
+Progress reporting for Python solutions run by aoc, and tracing for
+aoc run -explain. The runner puts this module on the path; a solution run
+on its own can fall back to a no-op:
+
+    try:
+        from aoc_progress import partial, report, trace
+    except ImportError:
+        def report(done, total=None): pass
+        def partial(part, answer): pass
+        def trace(event, **fields): pass
+"""
+
+import json
+import os
+import sys
+import time
+
+INTERVAL = 0.1
+
+_enabled = bool(os.environ.get("AOC_PROGRESS"))
+_explain = bool(os.environ.get("AOC_EXPLAIN"))
+_last = 0.0
+
+
+def report(done, total=None) -> None:
+    """Report done units of work out of total, at most ten times a second."""
+    global _last
+    if not _enabled:
+        return
+    now = time.monotonic()
+    if now - _last < INTERVAL and done != total:
+        return
+    _last = now
+    print(f"aoc-progress: {done}/{total if total is not None else '?'}",
+          file=sys.stderr, flush=True)
+
+
+def partial(part: int, answer) -> None:
+    """Report the best answer to a part found so far, which the runner
+    shows if the solution runs out of time."""
+    if _enabled:
+        print(f"aoc-partial: {part} {answer}", file=sys.stderr, flush=True)
+
+
+def trace(event: str, **fields) -> None:
+    """Trace a step, such as a choice made or a change of state, for
+    aoc run -explain to print. Does nothing unless asked to explain."""
+    if _explain:
+        line = json.dumps({"event": event, **fields}, default=str)
+        print(f"aoc-trace: {line}", file=sys.stderr, flush=True)
@@ -0,0 +1,204 @@
+package main
+
+import (
+  "fmt"
+  "hash/fnv"
+  "os"
+  "path/filepath"
+  "regexp"
+  "strconv"
+  "strings"
+)
+
+// A solution is one source file for one puzzle. A puzzle may have several
+// implementations in one language, told apart by a variant name in the
+// file name: 03.py and 03.bruteforce.py.
+type solution struct {
+  edition edition
+  day     int
+  lang    string
+  variant string
+  path    string
+  // Expected answers by part, from the comments on the lines that print
+  // them. Parts with no recorded answer are missing.
+  expected map[int]string
+  // The strategies the solution can be told to use, the first being its
+  // default, and the one this run selects, if any.
+  algos []string
+  algo  string
+  // The seed for any randomness, given to the solution as AOC_SEED.
+  seed int64
+  // The share of the work to do, "k/n", when distributed.
+  shard string
+  // Whether to ask the solution to trace its steps, for -explain.
+  explain bool
+}
+
+func (s solution) id() string {
+  return fmt.Sprintf("%s/%s/%02d", s.edition.event.name, s.edition.name, s.day)
+}
+
+// The variant, language and selected strategy, e.g. "py",
+// "bruteforce.py" or "py@dp".
+func (s solution) name() string {
+  name := s.lang
+  if s.variant != "" {
+    name = s.variant + "." + name
+  }
+  if s.algo != "" {
+    name += "@" + s.algo
+  }
+  return name
+}
+
+// The puzzle input the solution reads, which is NN.in in its edition's
+// directory, or in a named account's own directory for the edition.
+func (s solution) input() string {
+  dir := s.edition.dir
+  if s.edition.own != "" {
+    dir = s.edition.own
+  }
+  return filepath.Join(dir, fmt.Sprintf("%02d.in", s.day))
+}
+
+var solutionFile = regexp.MustCompile(`^(\d{2})(?:\.([a-z][\w-]*))?\.(\w+)$`)
+
+// A line that prints a part's answer, with the answer in a trailing
+// comment, e.g. print("Part 1:", part_one(data))  # 232
+var answerLine = regexp.MustCompile(`Part (\d+)\b.*(?:#|//)\s*(.*?)\s*$`)
+
+// Some comments label the answer. Others are not answers at all: an
+// "answer" placeholder, or a "< N" bound noted while still solving.
+var (
+  answerLabel = regexp.MustCompile(`^Expected(?: output)?:\s*`)
+  notAnswer   = regexp.MustCompile(`^(?:answer|[<>].*)$`)
+)
+
+// A solution with more than one strategy lists them in a comment, default
+// first, and reads the one selected from AOC_ALGO:
+//
+//   # algos: dp, recursive
+var algosLine = regexp.MustCompile(`(?m)(?:#|//)\s*algos:\s*(.*?)\s*$`)
+
+// Read a solution's recorded answers and declared strategies.
+func annotations(path string) (map[int]string, []string, error) {
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return nil, nil, err
+  }
+  var algos []string
+  if m := algosLine.FindSubmatch(data); m != nil {
+    algos = strings.FieldsFunc(string(m[1]), func(r rune) bool { return r == ',' || r == ' ' })
+  }
+  expected := map[int]string{}
+  for _, line := range strings.Split(string(data), "\n") {
+    m := answerLine.FindStringSubmatch(line)
+    if m == nil {
+      continue
+    }
+    part, _ := strconv.Atoi(m[1])
+    answer := answerLabel.ReplaceAllString(m[2], "")
+    if answer != "" && !notAnswer.MatchString(answer) {
+      expected[part] = answer
+    }
+  }
+  return expected, algos, nil
+}
+
+// Select a strategy for the solutions that declare one. "all" runs every
+// strategy of each such solution as a solution of its own, so verify
+// checks them all and compare times them against each other; a name keeps
+// only the solutions that have that strategy. An empty name runs every
+// solution with its default.
+func selectAlgo(sols []solution, algo string) ([]solution, error) {
+  if algo == "" {
+    return sols, nil
+  }
+  var out []solution
+  for _, s := range sols {
+    for _, a := range s.algos {
+      if algo == "all" || algo == a {
+        s.algo = a
+        out = append(out, s)
+      }
+    }
+    if algo == "all" && len(s.algos) == 0 {
+      out = append(out, s)
+    }
+  }
+  if len(out) == 0 {
+    return nil, fmt.Errorf("no solution has a strategy called %q", algo)
+  }
+  return out, nil
+}
+
+// Give every solution the seed, or by default one of its own derived from
+// the puzzle, so runs that use randomness repeat. Python's string hashing,
+// which decides the order of sets of strings, is seeded with it too.
+func seedSolutions(sols []solution, seed int64) {
+  for i := range sols {
+    sols[i].seed = seed
+    if seed == 0 {
+      h := fnv.New32a()
+      h.Write([]byte(sols[i].id()))
+      sols[i].seed = int64(h.Sum32()%(1<<31-1)) + 1
+    }
+  }
+}
+
+// A filter selects solutions by event, edition, day and language; empty
+// fields match everything.
+type filter struct {
+  event   string
+  edition string
+  day     int
+  lang    string
+}
+
+// Discover every solution under root that the filter selects, in event,
+// edition and file name order.
+func discover(root string, f filter) ([]solution, error) {
+  var sols []solution
+  for _, e := range events {
+    if f.event != "" && f.event != e.name {
+      continue
+    }
+    eds, err := e.editions(root)
+    if err != nil {
+      return nil, err
+    }
+    for _, ed := range eds {
+      if f.edition != "" && f.edition != ed.name {
+        continue
+      }
+      entries, err := os.ReadDir(ed.dir)
+      if err != nil {
+        return nil, err
+      }
+      for _, entry := range entries {
+        m := solutionFile.FindStringSubmatch(entry.Name())
+        if m == nil || languages[m[3]] == nil {
+          continue
+        }
+        day, _ := strconv.Atoi(m[1])
+        if f.day != 0 && f.day != day || f.lang != "" && f.lang != m[3] {
+          continue
+        }
+        path := filepath.Join(ed.dir, entry.Name())
+        expected, algos, err := annotations(path)
+        if err != nil {
+          return nil, err
+        }
+        sols = append(sols, solution{
+          edition: ed, day: day, lang: m[3], variant: m[2], path: path,
+          expected: expected, algos: algos,
+        })
+      }
+    }
+  }
+  sols, err := discoverPlugins(root, f, sols)
+  if err != nil {
+    return nil, err
+  }
+  return sols, useAccountAnswers(root, sols)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+  _ "embed"
+  "fmt"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "strings"
+)
+
+//go:embed repl/helpers.py
+var replHelpers []byte
+
+// The script an interactive Python session runs first: it loads the
+// helpers and the input, parses the input with the guessed parser, and
+// prints a summary.
+const replStartup = `import sys
+sys.path.insert(0, %q)
+import helpers
+from helpers import *
+with open(%q, "r") as file:
+    text = file.read()
+lines = text.splitlines()
+helpers.text, helpers.lines = text, lines
+
+%s
+try:
+    data = read_puzzle_input()
+except Exception as err:
+    data = None
+    print("the guessed parser failed:", err)
+print(%q)
+summary()
+`
+
+// Open an interactive Python session on one puzzle's input, with helpers
+// for grids, graphs, intervals and number theory (see repl/helpers.py).
+func repl(o *options) int {
+  ed, ok := findEdition(o.root, o.event, o.edition)
+  if !ok || o.day == 0 {
+    fmt.Fprintln(os.Stderr, "aoc: repl needs a puzzle; use -y and -d")
+    return 2
+  }
+  input := solution{edition: ed, day: o.day}.input()
+  data, err := os.ReadFile(input)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  scratch, err := os.MkdirTemp("", "aoc-repl-")
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  defer os.RemoveAll(scratch)
+  if err := os.WriteFile(filepath.Join(scratch, "helpers.py"), replHelpers, 0o644); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+
+  parser, banner := "def read_puzzle_input():\n    return None\n", "no parser guessed; data is None"
+  if strings.TrimSpace(string(data)) != "" {
+    sections, uniform := analyzeInput(string(data))
+    parser = genPython(o.day, sections, uniform)
+    banner = "data = read_puzzle_input(), as guessed by gen-parser; help(helpers) lists the helpers"
+  }
+  startup := filepath.Join(scratch, "startup.py")
+  script := fmt.Sprintf(replStartup, scratch, filepath.Base(input), parser, banner)
+  if err := os.WriteFile(startup, []byte(script), 0o644); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+
+  cmd := exec.Command("python3", "-i", startup)
+  cmd.Dir = ed.dir
+  cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+  if err := cmd.Run(); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  return 0
+}
@@ -0,0 +1,117 @@
+"""
+Helpers for poking at a puzzle's input in aoc repl. The runner loads this
+module, then the input: text and lines hold it raw, and data holds it as
+parsed by the parse function gen-parser guesses, when there is one.
+
+    summary()            line count, widths, characters and number range
+    ints(s)              every integer in a string
+    grid(lines)          {(x, y): char} for a grid of characters
+    find(g, chars)       the positions in a grid holding any of chars
+    neighbors(p, diag)   the 4, or with diag 8, positions around p
+    bfs(start, step)     distances from start, step(p) giving next positions
+    dijkstra(start, step)
+                         distances where step(p) gives (next, cost) pairs
+    merge(intervals)     overlapping or touching (lo, hi) ranges merged
+    crt(remainders, moduli)
+                         the Chinese remainder theorem
+"""
+
+import heapq
+import re
+from collections import Counter, defaultdict, deque
+from functools import cache, reduce
+from itertools import combinations, pairwise, permutations, product
+from math import gcd, lcm, prod
+
+text = ""
+lines: list[str] = []
+
+
+def ints(s: str) -> list[int]:
+    return [int(n) for n in re.findall(r"-?\d+", s)]
+
+
+def grid(rows: list[str]) -> dict[tuple[int, int], str]:
+    return {(x, y): c for y, row in enumerate(rows) for x, c in enumerate(row)}
+
+
+def find(g: dict, chars: str) -> list[tuple[int, int]]:
+    return [p for p, c in g.items() if c in chars]
+
+
+DIRECTIONS = [(1, 0), (0, 1), (-1, 0), (0, -1)]
+DIAGONALS = [(1, 1), (-1, 1), (-1, -1), (1, -1)]
+
+
+def neighbors(p: tuple[int, int], diag: bool = False) -> list[tuple[int, int]]:
+    x, y = p
+    return [(x + dx, y + dy) for dx, dy in DIRECTIONS + (DIAGONALS if diag else [])]
+
+
+def bfs(start, step) -> dict:
+    dist = {start: 0}
+    queue = deque([start])
+    while queue:
+        p = queue.popleft()
+        for q in step(p):
+            if q not in dist:
+                dist[q] = dist[p] + 1
+                queue.append(q)
+    return dist
+
+
+def dijkstra(start, step) -> dict:
+    dist = {start: 0}
+    heap = [(0, 0, start)]
+    tie = 0
+    while heap:
+        d, _, p = heapq.heappop(heap)
+        if d > dist[p]:
+            continue
+        for q, cost in step(p):
+            if q not in dist or d + cost < dist[q]:
+                dist[q] = d + cost
+                tie += 1
+                heapq.heappush(heap, (d + cost, tie, q))
+    return dist
+
+
+def merge(intervals) -> list[tuple[int, int]]:
+    merged: list[tuple[int, int]] = []
+    for lo, hi in sorted(intervals):
+        if merged and lo <= merged[-1][1] + 1:
+            merged[-1] = (merged[-1][0], max(merged[-1][1], hi))
+        else:
+            merged.append((lo, hi))
+    return merged
+
+
+def crt(remainders: list[int], moduli: list[int]) -> int:
+    x, m = 0, 1
+    for r, n in zip(remainders, moduli):
+        g = gcd(m, n)
+        if (r - x) % g:
+            raise ValueError("no solution")
+        # Step x by multiples of m until it is r modulo n.
+        x += m * ((r - x) // g * pow(m // g, -1, n // g) % (n // g))
+        m = lcm(m, n)
+    return x % m
+
+
+def summary() -> None:
+    widths = Counter(len(line) for line in lines)
+    numbers = ints(text)
+    print(f"{len(lines)} lines, {len(text)} characters")
+    if len(widths) == 1:
+        print(f"every line {next(iter(widths))} wide")
+    else:
+        print(f"widths {min(widths)} to {max(widths)}, most often {widths.most_common(1)[0][0]}")
+    chars = Counter(text.replace("\n", ""))
+    print("characters:", " ".join(f"{c!r}x{n}" for c, n in chars.most_common(20)),
+          "..." if len(chars) > 20 else "")
+    if numbers:
+        print(f"{len(numbers)} numbers from {min(numbers)} to {max(numbers)},"
+              f" {len(set(numbers))} distinct")
+    blank = text.count("\n\n")
+    if blank:
+        print(f"{blank + 1} sections separated by blank lines")
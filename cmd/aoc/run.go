@@ -0,0 +1,341 @@
+package main
+
+import (
+  "bytes"
+  "context"
+  "errors"
+  "fmt"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "regexp"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// A language says how to run a solution file. Solutions run from their own
+// directory, where Python ones read NN.in; every solution is also given
+// the input's absolute path in AOC_INPUT, which the Go and JavaScript ones
+// read, finding the input under the repository root themselves only when
+// run by hand. Compiled solutions are built first so the build is not
+// timed.
+//
+// Anything else runs as an external command: an NN.cmd file holds a shell
+// command, run from the file's directory with the input on standard input,
+// that prints "Part N: answer" lines like the other solutions. Its answers
+// are recorded on lines of their own:
+//
+//   # 2024 day 2 in Rust
+//   cargo run --release -q --manifest-path rust/Cargo.toml --bin day02
+//   Part 1  # 341
+//   Part 2  # 404
+type language struct {
+  name     string
+  command  []string
+  build    []string
+  env      []string
+  stdin    bool
+  external bool
+  // Plugins speak the protocol in plugin.go rather than running as a
+  // command.
+  plugin bool
+}
+
+var languages = map[string]*language{
+  "py":     {name: "Python", command: []string{"python3"}},
+  "go":     {name: "Go", build: []string{"go", "build", "-o"}},
+  "js":     {name: "JavaScript", command: []string{"node"}},
+  "cmd":    {name: "command", stdin: true, external: true},
+  "plugin": {name: "plugin", plugin: true},
+}
+
+// The command of an NN.cmd file: its first line that is neither blank, a
+// comment, nor a recorded answer.
+func externalCommand(path string) (string, error) {
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return "", err
+  }
+  for _, line := range strings.Split(string(data), "\n") {
+    line = strings.TrimSpace(line)
+    if line != "" && !strings.HasPrefix(line, "#") && !answerLine.MatchString(line) {
+      return line, nil
+    }
+  }
+  return "", fmt.Errorf("%s has no command", filepath.Base(path))
+}
+
+var errNoInput = errors.New("no input")
+
+// How long a run's output is waited for once it has timed out, or once
+// the solution has exited while something it started still holds its
+// output open.
+const waitDelay = 2 * time.Second
+
+// A run that ran out of time, with how far it had got by its last progress
+// report and the best answers it had reported so far.
+type timeoutError struct {
+  after       time.Duration
+  done, total int64
+  partial     map[int]string
+}
+
+func (e *timeoutError) Error() string {
+  msg := fmt.Sprintf("timed out after %v", e.after)
+  switch {
+  case e.total > 0:
+    msg += fmt.Sprintf(" at %.1f%%", float64(e.done)/float64(e.total)*100)
+  case e.done > 0:
+    msg += fmt.Sprintf(" after %d steps", e.done)
+  }
+  if len(e.partial) > 0 {
+    parts := make([]int, 0, len(e.partial))
+    for p := range e.partial {
+      parts = append(parts, p)
+    }
+    sort.Ints(parts)
+    var best []string
+    for _, p := range parts {
+      best = append(best, fmt.Sprintf("part %d %s", p, e.partial[p]))
+    }
+    msg += ", best so far " + strings.Join(best, ", ")
+  }
+  return msg
+}
+
+// The outcome of running one solution.
+type result struct {
+  sol     solution
+  answers map[int]string
+  output  string
+  elapsed time.Duration
+  // Peak resident memory of the solution's process in bytes, where the
+  // platform reports it.
+  maxRSS int64
+  // The steps the solution traced, when it was asked to explain itself.
+  trace []traceStep
+  err   error
+}
+
+var partLine = regexp.MustCompile(`^Part (\d+):?\s*(.*?)\s*$`)
+
+// Collect the "Part N: answer" lines of a solution's output.
+func parseAnswers(output string) map[int]string {
+  answers := map[int]string{}
+  for _, line := range strings.Split(output, "\n") {
+    if m := partLine.FindStringSubmatch(line); m != nil {
+      part, _ := strconv.Atoi(m[1])
+      answers[part] = m[2]
+    }
+  }
+  return answers
+}
+
+func run(sol solution, timeout time.Duration) result {
+  return runProfiled(sol, timeout, nil)
+}
+
+// Run a solution, under a profiler if prof is not nil. The profiler may
+// replace the source that is built and the command that runs, and collects
+// its data before the scratch directory is removed.
+func runProfiled(sol solution, timeout time.Duration, prof *profiler) result {
+  res := result{sol: sol}
+  input, _ := filepath.Abs(sol.input())
+  if _, err := os.Stat(input); err != nil {
+    res.err = errNoInput
+    return res
+  }
+  lang := languages[sol.lang]
+  if lang.plugin {
+    return runPlugin(sol, input, timeout)
+  }
+  path, _ := filepath.Abs(sol.path)
+  scratch, err := os.MkdirTemp("", "aoc-")
+  if err != nil {
+    res.err = err
+    return res
+  }
+  defer os.RemoveAll(scratch)
+
+  // Python solutions open NN.in by name, so they run where the input is;
+  // that is their own directory unless a named account's input is used.
+  // Other solutions, commands especially, run from their own directory.
+  dir := filepath.Dir(path)
+  if sol.lang == "py" {
+    dir = filepath.Dir(input)
+  }
+  env := []string{"AOC_INPUT=" + input, fmt.Sprintf("AOC_SEED=%d", sol.seed)}
+  if sol.seed >= 0 && sol.seed < 1<<32 {
+    env = append(env, fmt.Sprintf("PYTHONHASHSEED=%d", sol.seed))
+  }
+  if sol.algo != "" {
+    env = append(env, "AOC_ALGO="+sol.algo)
+  }
+  if sol.shard != "" {
+    env = append(env, "AOC_SHARD="+sol.shard)
+  }
+  if sol.explain {
+    env = append(env, "AOC_EXPLAIN=1")
+  }
+  if sol.lang == "py" {
+    lib := filepath.Join(scratch, "lib")
+    if err := os.Mkdir(lib, 0o755); err != nil {
+      res.err = err
+      return res
+    }
+    if err := os.WriteFile(filepath.Join(lib, "aoc_progress.py"), pythonProgress, 0o644); err != nil {
+      res.err = err
+      return res
+    }
+    pythonPath := lib
+    if p := os.Getenv("PYTHONPATH"); p != "" {
+      pythonPath += string(filepath.ListSeparator) + p
+    }
+    env = append(env, "PYTHONPATH="+pythonPath)
+  }
+  if prof != nil {
+    if path, err = prof.source(sol, scratch, path); err != nil {
+      res.err = err
+      return res
+    }
+  }
+  argv := append(append([]string{}, lang.command...), path)
+  if lang.external {
+    line, err := externalCommand(path)
+    if err != nil {
+      res.err = err
+      return res
+    }
+    argv = []string{"sh", "-c", line}
+  }
+  if lang.build != nil {
+    bin := filepath.Join(scratch, "solution")
+    // The build runs beside the solution, so that Go finds the module it
+    // is in. A directory (a profiled Go solution) builds as a package.
+    build := exec.Command(lang.build[0], append(lang.build[1:], bin, path)...)
+    build.Dir = filepath.Dir(path)
+    if info, err := os.Stat(path); err == nil && info.IsDir() {
+      build = exec.Command(lang.build[0], append(lang.build[1:], bin, ".")...)
+      build.Dir = path
+    }
+    build.Env = append(os.Environ(), lang.env...)
+    if out, err := build.CombinedOutput(); err != nil {
+      res.err = fmt.Errorf("build: %v: %s", err, lastLine(string(out)))
+      return res
+    }
+    argv = []string{bin}
+  }
+  if prof != nil {
+    var profEnv []string
+    argv, profEnv = prof.command(sol, scratch, argv)
+    env = append(env, profEnv...)
+  }
+
+  ctx, cancel := context.WithTimeout(context.Background(), timeout)
+  defer cancel()
+  cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+  cmd.Dir = dir
+  cmd.Env = append(append(os.Environ(), lang.env...), env...)
+  var stdout, stderr bytes.Buffer
+  cmd.Stdout, cmd.Stderr = &stdout, &stderr
+  pw := &progressWriter{sol: sol, bar: showProgress, start: time.Now(), stderr: &stderr}
+  cmd.Stderr = pw
+  cmd.Env = append(cmd.Env, "AOC_PROGRESS=1")
+  if lang.stdin {
+    f, err := os.Open(input)
+    if err != nil {
+      res.err = err
+      return res
+    }
+    defer f.Close()
+    cmd.Stdin = f
+  }
+
+  start := time.Now()
+  err = runGroup(cmd)
+  res.elapsed = time.Since(start)
+  pw.close()
+  res.maxRSS = maxRSS(cmd.ProcessState)
+  res.output = stdout.String()
+  res.answers = parseAnswers(res.output)
+  res.trace = pw.trace
+  switch {
+  case ctx.Err() == context.DeadlineExceeded:
+    res.err = &timeoutError{timeout, pw.done, pw.total, pw.partial}
+  case err != nil:
+    res.err = fmt.Errorf("%v: %s", err, lastLine(stderr.String()))
+  }
+  if prof != nil && res.err == nil {
+    res.err = prof.collect(sol, scratch, argv)
+  }
+  return res
+}
+
+func lastLine(s string) string {
+  lines := strings.Split(strings.TrimSpace(s), "\n")
+  return lines[len(lines)-1]
+}
+
+// Verification of one part.
+const (
+  partOK         = "ok"
+  partWrong      = "wrong"
+  partUnverified = "?"
+  partMissing    = "-"
+)
+
+// Compare a result's answers with the recorded ones, part by part.
+func (r result) check() []string {
+  parts := r.sol.edition.event.parts
+  status := make([]string, parts)
+  for p := 1; p <= parts; p++ {
+    got, printed := r.answers[p]
+    want, recorded := r.sol.expected[p]
+    switch {
+    case !printed:
+      status[p-1] = partMissing
+    case !recorded:
+      status[p-1] = partUnverified
+    case got == want:
+      status[p-1] = partOK
+    default:
+      status[p-1] = partWrong
+    }
+  }
+  return status
+}
+
+// Outcomes of a whole run, as counted in the verify summary.
+const (
+  outcomeOK         = "ok"
+  outcomeWrong      = "wrong"
+  outcomeUnverified = "unverified"
+  outcomeFailed     = "failed"
+  outcomeTimeout    = "timeout"
+  outcomeSkipped    = "skipped"
+)
+
+func (r result) outcome() string {
+  switch {
+  case r.err == errNoInput:
+    return outcomeSkipped
+  case errors.As(r.err, new(*timeoutError)):
+    return outcomeTimeout
+  case r.err != nil:
+    return outcomeFailed
+  }
+  outcome := outcomeOK
+  for i, p := range r.check() {
+    // A part that is neither printed nor recorded does not exist, as with
+    // the last day of an Advent of Code.
+    _, recorded := r.sol.expected[i+1]
+    if p == partWrong {
+      outcome = outcomeWrong
+    } else if (p == partUnverified || p == partMissing && recorded) && outcome == outcomeOK {
+      outcome = outcomeUnverified
+    }
+  }
+  return outcome
+}
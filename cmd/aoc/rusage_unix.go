@@ -0,0 +1,25 @@
+//go:build unix
+
+package main
+
+import (
+  "os"
+  "runtime"
+  "syscall"
+)
+
+// Peak resident memory of a finished process. Linux and the BSDs report
+// it in kilobytes, macOS in bytes.
+func maxRSS(ps *os.ProcessState) int64 {
+  if ps == nil {
+    return 0
+  }
+  ru, ok := ps.SysUsage().(*syscall.Rusage)
+  if !ok {
+    return 0
+  }
+  if runtime.GOOS == "darwin" {
+    return int64(ru.Maxrss)
+  }
+  return int64(ru.Maxrss) * 1024
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+  "fmt"
+  "math/rand"
+  "os"
+  "regexp"
+  "sort"
+  "strings"
+  "time"
+)
+
+// A scrubber rewrites one kind of token in a puzzle input so it can be
+// shared without giving away the original. Its setting comes from the
+// scrub directive of the day's solution, or from scrubDefaults.
+type scrubber func(input, setting string, rng *rand.Rand) (string, error)
+
+var scrubbers = map[string]scrubber{
+  "numbers": scrubNumbers,
+  "words":   scrubWords,
+}
+
+// Numbers keep their order by default, which is the property most puzzles
+// depend on. Words are kept, since inputs often contain keywords the
+// solution parses; a day that names things turns renaming on.
+var scrubDefaults = map[string]string{
+  "numbers": "order",
+  "words":   "keep",
+}
+
+// A solution sets its input's strategies with a comment such as
+//
+//   # scrub: numbers=shuffle words=rename:Button,Prize
+var scrubDirective = regexp.MustCompile(`(?m)(?:#|//)\s*scrub:\s*(.*?)\s*$`)
+
+func scrubSettings(sols []solution) (map[string]string, error) {
+  settings := map[string]string{}
+  for name, setting := range scrubDefaults {
+    settings[name] = setting
+  }
+  for _, s := range sols {
+    if languages[s.lang].plugin {
+      continue
+    }
+    src, err := os.ReadFile(s.path)
+    if err != nil {
+      return nil, err
+    }
+    m := scrubDirective.FindSubmatch(src)
+    if m == nil {
+      continue
+    }
+    for _, field := range strings.Fields(string(m[1])) {
+      name, setting, _ := strings.Cut(field, "=")
+      if scrubbers[name] == nil {
+        return nil, fmt.Errorf("%s: unknown scrub strategy %q", s.path, name)
+      }
+      settings[name] = setting
+    }
+    break
+  }
+  return settings, nil
+}
+
+// Numbers are rewritten consistently, so equal numbers stay equal, and
+// keep their digit count. "order" also keeps every comparison between
+// them; "shuffle" keeps only equality; "keep" leaves them alone. Zero, and
+// numbers written with leading zeros, are left as they are.
+func scrubNumbers(input, setting string, rng *rand.Rand) (string, error) {
+  if setting == "keep" {
+    return input, nil
+  }
+  if setting != "order" && setting != "shuffle" {
+    return "", fmt.Errorf("numbers: unknown setting %q (have order, shuffle, keep)", setting)
+  }
+  number := regexp.MustCompile(`\d+`)
+  byLength := map[int][]string{}
+  seen := map[string]bool{}
+  for _, n := range number.FindAllString(input, -1) {
+    if seen[n] || n[0] == '0' {
+      continue
+    }
+    seen[n] = true
+    byLength[len(n)] = append(byLength[len(n)], n)
+  }
+  mapping := map[string]string{}
+  for length, nums := range byLength {
+    sort.Strings(nums)
+    repl := randomNumbers(length, len(nums), rng)
+    if repl == nil {
+      continue
+    }
+    if setting == "shuffle" {
+      rng.Shuffle(len(repl), func(i, j int) { repl[i], repl[j] = repl[j], repl[i] })
+    }
+    for i, n := range nums {
+      mapping[n] = repl[i]
+    }
+  }
+  return number.ReplaceAllStringFunc(input, func(n string) string {
+    if r, ok := mapping[n]; ok {
+      return r
+    }
+    return n
+  }), nil
+}
+
+// Return count distinct random numbers of the given digit count in
+// increasing order, or nil if there are not enough to go round.
+func randomNumbers(length, count int, rng *rand.Rand) []string {
+  if length < 19 {
+    low, high := 1, 10
+    for i := 1; i < length; i++ {
+      low, high = low*10, high*10
+    }
+    if count > high-low {
+      return nil
+    }
+  }
+  seen := map[string]bool{}
+  var out []string
+  for len(out) < count {
+    b := make([]byte, length)
+    b[0] = byte('1' + rng.Intn(9))
+    for i := 1; i < length; i++ {
+      b[i] = byte('0' + rng.Intn(10))
+    }
+    if !seen[string(b)] {
+      seen[string(b)] = true
+      out = append(out, string(b))
+    }
+  }
+  sort.Strings(out)
+  return out
+}
+
+// Words of two or more letters are renamed consistently to random letters
+// of the same length and case, except for a comma-separated list after
+// "rename:". "keep" leaves them alone.
+func scrubWords(input, setting string, rng *rand.Rand) (string, error) {
+  if setting == "keep" {
+    return input, nil
+  }
+  mode, keepList, _ := strings.Cut(setting, ":")
+  if mode != "rename" {
+    return "", fmt.Errorf("words: unknown setting %q (have rename, rename:KEEP,..., keep)", setting)
+  }
+  keep := map[string]bool{}
+  for _, w := range strings.Split(keepList, ",") {
+    keep[w] = true
+  }
+  mapping := map[string]string{}
+  used := map[string]bool{}
+  word := regexp.MustCompile(`[A-Za-z]{2,}`)
+  return word.ReplaceAllStringFunc(input, func(w string) string {
+    if keep[w] {
+      return w
+    }
+    if r, ok := mapping[w]; ok {
+      return r
+    }
+    for {
+      b := []byte(w)
+      for i, c := range b {
+        if c >= 'a' {
+          b[i] = byte('a' + rng.Intn(26))
+        } else {
+          b[i] = byte('A' + rng.Intn(26))
+        }
+      }
+      if r := string(b); !used[r] && !keep[r] {
+        used[r] = true
+        mapping[w] = r
+        return r
+      }
+    }
+  }), nil
+}
+
+// Scrub the input of one puzzle and write it to o.scrubOut, or standard
+// output. The settings and seed go to standard error, so a scrub can be
+// repeated.
+func scrub(sols []solution, o *options) int {
+  ids := map[string]bool{}
+  for _, s := range sols {
+    ids[s.id()] = true
+  }
+  if len(ids) != 1 {
+    fmt.Fprintf(os.Stderr, "aoc: scrub needs exactly one puzzle, and %d match; use -y and -d\n", len(ids))
+    return 2
+  }
+  input, err := os.ReadFile(sols[0].input())
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  settings, err := scrubSettings(sols)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  if o.seed == 0 {
+    o.seed = time.Now().UnixNano()
+  }
+  rng := rand.New(rand.NewSource(o.seed))
+
+  out := string(input)
+  var used []string
+  for _, name := range sortedKeys(settings) {
+    if out, err = scrubbers[name](out, settings[name], rng); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+    used = append(used, name+"="+settings[name])
+  }
+  fmt.Fprintf(os.Stderr, "scrubbed %s with %s, -seed %d\n", sols[0].id(), strings.Join(used, " "), o.seed)
+
+  if o.scrubOut == "" {
+    fmt.Print(out)
+    return 0
+  }
+  if err := os.WriteFile(o.scrubOut, []byte(out), 0o644); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  return 0
+}
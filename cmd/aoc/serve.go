@@ -0,0 +1,147 @@
+package main
+
+import (
+  "embed"
+  "encoding/json"
+  "errors"
+  "io/fs"
+  "net/http"
+  "os"
+  "sync"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// The JSON view of a solution for the web UI.
+type solutionInfo struct {
+  Key      string  `json:"key"`
+  ID       string  `json:"id"`
+  Event    string  `json:"event"`
+  Title    string  `json:"title"`
+  Edition  string  `json:"edition"`
+  Day      int     `json:"day"`
+  Lang     string  `json:"lang"`
+  Variant  string  `json:"variant,omitempty"`
+  Parts    int     `json:"parts"`
+  Recorded int     `json:"recorded"`
+  HasInput bool    `json:"hasInput"`
+  Last     *record `json:"last"`
+}
+
+// The server answers the web UI's JSON requests. Runs go one at a time:
+// they share the status file, and timings mean more without contention.
+type server struct {
+  o       *options
+  mu      sync.Mutex
+  sols    map[string]solution
+  list    []solution
+  metrics *serverMetrics
+}
+
+func newServer(o *options, sols []solution) *server {
+  s := &server{o: o, sols: map[string]solution{}, list: sols, metrics: newServerMetrics()}
+  for _, sol := range sols {
+    s.sols[sol.key()] = sol
+  }
+  return s
+}
+
+func (s *server) info(sol solution, st statusFile) solutionInfo {
+  _, err := os.Stat(sol.input())
+  e := sol.edition.event
+  return solutionInfo{
+    Key: sol.key(), ID: sol.id(), Event: e.name, Title: e.title,
+    Edition: sol.edition.name, Day: sol.day, Lang: sol.lang, Variant: sol.variant, Parts: e.parts,
+    Recorded: len(sol.expected), HasInput: err == nil, Last: st[sol.key()],
+  }
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+  w.Header().Set("Content-Type", "application/json")
+  json.NewEncoder(w).Encode(v)
+}
+
+func (s *server) handleSolutions(w http.ResponseWriter, r *http.Request) {
+  s.mu.Lock()
+  st, err := loadStatus(s.o.root)
+  s.mu.Unlock()
+  if err != nil {
+    http.Error(w, err.Error(), http.StatusInternalServerError)
+    return
+  }
+  infos := make([]solutionInfo, 0, len(s.list))
+  for _, sol := range s.list {
+    infos = append(infos, s.info(sol, st))
+  }
+  writeJSON(w, infos)
+}
+
+func (s *server) lookup(w http.ResponseWriter, r *http.Request) (solution, bool) {
+  sol, ok := s.sols[r.URL.Query().Get("key")]
+  if !ok {
+    http.Error(w, "unknown solution", http.StatusNotFound)
+  }
+  return sol, ok
+}
+
+// The last output of a solution, which for some puzzles is a picture.
+func (s *server) handleOutput(w http.ResponseWriter, r *http.Request) {
+  sol, ok := s.lookup(w, r)
+  if !ok {
+    return
+  }
+  data, err := os.ReadFile(outputPath(s.o.root, sol))
+  if errors.Is(err, os.ErrNotExist) {
+    http.Error(w, "not run yet", http.StatusNotFound)
+    return
+  }
+  if err != nil {
+    http.Error(w, err.Error(), http.StatusInternalServerError)
+    return
+  }
+  w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+  w.Write(data)
+}
+
+// Run and verify one solution, recording the result as verify does.
+func (s *server) handleRun(w http.ResponseWriter, r *http.Request) {
+  if r.Method != http.MethodPost {
+    http.Error(w, "POST only", http.StatusMethodNotAllowed)
+    return
+  }
+  sol, ok := s.lookup(w, r)
+  if !ok {
+    return
+  }
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  res := run(sol, s.o.timeout)
+  s.metrics.observeRun(res)
+  st, err := loadStatus(s.o.root)
+  if err == nil {
+    var ch changes
+    st.update(res, &ch)
+    err = st.save(s.o.root)
+  }
+  if err == nil {
+    err = recordRun(s.o.root, res)
+  }
+  if err != nil {
+    http.Error(w, err.Error(), http.StatusInternalServerError)
+    return
+  }
+  writeJSON(w, s.info(sol, st))
+}
+
+func serve(o *options, sols []solution) error {
+  s := newServer(o, sols)
+  static, _ := fs.Sub(webFiles, "web")
+  mux := http.NewServeMux()
+  mux.Handle("/", http.FileServer(http.FS(static)))
+  mux.HandleFunc("/api/solutions", s.metrics.instrument("/api/solutions", s.handleSolutions))
+  mux.HandleFunc("/api/output", s.metrics.instrument("/api/output", s.handleOutput))
+  mux.HandleFunc("/api/run", s.metrics.instrument("/api/run", s.handleRun))
+  mux.Handle("/metrics", s.metrics.registry)
+  return http.ListenAndServe(o.addr, mux)
+}
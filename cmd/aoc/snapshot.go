@@ -0,0 +1,73 @@
+package main
+
+import (
+  "errors"
+  "fmt"
+  "os"
+  "path/filepath"
+
+  "github.com/nixternal/CodingChallenges/pkg/testx"
+)
+
+// Snapshots hold a solution's whole standard output, labels and all, so a
+// change to how it prints is caught as well as a wrong answer. They live
+// beside the solutions, in snapshots/NN.NAME.txt, and are committed; the
+// inputs they were made from are not. A package's own tests keep theirs
+// the same way with testx.Snapshot. A named account's snapshots are of
+// its own inputs, so they live with them instead.
+func snapshotPath(s solution) string {
+  dir := s.edition.dir
+  if s.edition.own != "" {
+    dir = s.edition.own
+  }
+  return filepath.Join(dir, "snapshots", fmt.Sprintf("%02d.%s.txt", s.day, s.name()))
+}
+
+// Run each solution and compare its output with its snapshot. A solution
+// without one gets one made; with -update every snapshot is made afresh
+// from this run.
+func snapshot(sols []solution, o *options) int {
+  counts := map[string]int{}
+  for _, s := range sols {
+    r := run(s, o.timeout)
+    if err := recordRun(o.root, r); err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+    }
+    path := snapshotPath(s)
+    want, err := os.ReadFile(path)
+    var line string
+    switch {
+    case r.err == errNoInput:
+      line, counts["skipped"] = "skip  (no input)", counts["skipped"]+1
+    case r.err != nil:
+      line, counts["failed"] = "FAIL  "+r.err.Error(), counts["failed"]+1
+    case err == nil && string(want) == r.output:
+      line, counts["ok"] = "ok", counts["ok"]+1
+    case err == nil && !o.update:
+      line, counts["changed"] = "DIFF  "+testx.Diff(r.output, string(want)), counts["changed"]+1
+    case err != nil && !errors.Is(err, os.ErrNotExist):
+      line, counts["failed"] = "FAIL  "+err.Error(), counts["failed"]+1
+    default:
+      line = "new"
+      if err == nil {
+        line = "updated"
+      }
+      if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+        fmt.Fprintln(os.Stderr, "aoc:", err)
+        return 1
+      }
+      if err := os.WriteFile(path, []byte(r.output), 0o644); err != nil {
+        fmt.Fprintln(os.Stderr, "aoc:", err)
+        return 1
+      }
+      counts["written"]++
+    }
+    fmt.Printf("%-28s %-3s %s\n", s.id(), s.name(), line)
+  }
+  fmt.Printf("\n%d ok, %d changed, %d written, %d failed, %d skipped\n",
+    counts["ok"], counts["changed"], counts["written"], counts["failed"], counts["skipped"])
+  if counts["changed"] > 0 || counts["failed"] > 0 {
+    return 1
+  }
+  return 0
+}
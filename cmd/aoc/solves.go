@@ -0,0 +1,199 @@
+package main
+
+import (
+  "encoding/json"
+  "errors"
+  "fmt"
+  "os"
+  "path/filepath"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// When each puzzle was started and solved, for the time taken to solve
+// it: when its input arrived, which is the input file's modification time
+// as the best record of its download, and when each part was first
+// verified correct by any implementation. Kept in .aoc/solves.json by
+// puzzle.
+type solveRecord struct {
+  Input time.Time         `json:"input"`
+  Parts map[int]time.Time `json:"parts,omitempty"`
+}
+
+type solveFile map[string]*solveRecord
+
+func solvesPath(root string) string {
+  return filepath.Join(stateDir(root), "solves.json")
+}
+
+func loadSolves(root string) (solveFile, error) {
+  data, err := os.ReadFile(solvesPath(root))
+  if errors.Is(err, os.ErrNotExist) {
+    return solveFile{}, nil
+  }
+  if err != nil {
+    return nil, err
+  }
+  sf := solveFile{}
+  return sf, json.Unmarshal(data, &sf)
+}
+
+func (sf solveFile) save(root string) error {
+  data, err := json.MarshalIndent(sf, "", "  ")
+  if err != nil {
+    return err
+  }
+  path := solvesPath(root)
+  if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+    return err
+  }
+  return os.Rename(path+".tmp", path)
+}
+
+// Note the parts a run first got right. A puzzle seen for the first time
+// takes its earlier correct runs from the history, so puzzles solved
+// before solve times were kept still show when.
+func noteSolve(root string, r result) error {
+  if r.err == errNoInput {
+    return nil
+  }
+  sf, err := loadSolves(root)
+  if err != nil {
+    return err
+  }
+  id := r.sol.id()
+  rec := sf[id]
+  changed := false
+  if rec == nil {
+    rec = &solveRecord{Input: time.Now(), Parts: map[int]time.Time{}}
+    if info, err := os.Stat(r.sol.input()); err == nil {
+      rec.Input = info.ModTime()
+    }
+    hist, err := readHistory(root, nil)
+    if err != nil {
+      return err
+    }
+    for key, entries := range hist {
+      if !strings.HasPrefix(key, id+".") {
+        continue
+      }
+      for _, e := range entries {
+        for p, st := range e.Parts {
+          if st == partOK && (rec.Parts[p].IsZero() || e.Time.Before(rec.Parts[p])) {
+            rec.Parts[p] = e.Time
+          }
+        }
+      }
+    }
+    sf[id], changed = rec, true
+  }
+  if rec.Parts == nil {
+    // A record with no parts yet was saved without any.
+    rec.Parts = map[int]time.Time{}
+  }
+  if r.err == nil {
+    for i, st := range r.check() {
+      if st == partOK && rec.Parts[i+1].IsZero() {
+        rec.Parts[i+1], changed = time.Now(), true
+      }
+    }
+  }
+  if !changed {
+    return nil
+  }
+  return sf.save(root)
+}
+
+// When an Advent of Code puzzle unlocked: midnight in US Eastern time,
+// which in December is 05:00 UTC. Other events have no fixed schedule.
+func unlockTime(ed edition, day int) (time.Time, bool) {
+  year, err := strconv.Atoi(ed.name)
+  if ed.event.name != "aoc" || err != nil {
+    return time.Time{}, false
+  }
+  return time.Date(year, time.December, day, 5, 0, 0, 0, time.UTC), true
+}
+
+// A span of time to the minute, in its two largest units.
+func formatSpan(d time.Duration) string {
+  if d < 0 {
+    return "-"
+  }
+  d = d.Round(time.Minute)
+  days, hours, minutes := d/(24*time.Hour), d%(24*time.Hour)/time.Hour, d%time.Hour/time.Minute
+  switch {
+  case days > 0:
+    return fmt.Sprintf("%dd%02dh", days, hours)
+  case hours > 0:
+    return fmt.Sprintf("%dh%02dm", hours, minutes)
+  }
+  return fmt.Sprintf("%dm", minutes)
+}
+
+// Print the time to solve each puzzle: from getting its input to each
+// part's first correct answer, and for Advent of Code from the unlock to
+// the last part solved.
+func solves(sols []solution, o *options) int {
+  sf, err := loadSolves(o.root)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  var puzzles []solution
+  seen := map[string]bool{}
+  for _, s := range sols {
+    if sf[s.id()] != nil && !seen[s.id()] {
+      seen[s.id()] = true
+      puzzles = append(puzzles, s)
+    }
+  }
+  if len(puzzles) == 0 {
+    fmt.Println("no solve times yet; they are noted as solutions run")
+    return 0
+  }
+  sort.SliceStable(puzzles, func(i, j int) bool { return puzzles[i].id() < puzzles[j].id() })
+
+  fmt.Printf("%-24s %-16s", "puzzle", "input")
+  parts := 0
+  for _, s := range puzzles {
+    parts = max(parts, s.edition.event.parts)
+  }
+  for p := 1; p <= parts; p++ {
+    fmt.Printf(" %8s", fmt.Sprintf("part %d", p))
+  }
+  fmt.Printf(" %11s\n", "from unlock")
+  var spans []time.Duration
+  for _, s := range puzzles {
+    rec := sf[s.id()]
+    fmt.Printf("%-24s %-16s", s.id(), rec.Input.Local().Format("2006-01-02 15:04"))
+    var last time.Time
+    for p := 1; p <= parts; p++ {
+      t, ok := rec.Parts[p]
+      if !ok {
+        fmt.Printf(" %8s", "-")
+        continue
+      }
+      fmt.Printf(" %8s", formatSpan(t.Sub(rec.Input)))
+      if t.After(last) {
+        last = t
+      }
+    }
+    if unlock, ok := unlockTime(s.edition, s.day); ok && len(rec.Parts) > 0 {
+      fmt.Printf(" %11s", formatSpan(last.Sub(unlock)))
+    } else {
+      fmt.Printf(" %11s", "-")
+    }
+    fmt.Println()
+    if len(rec.Parts) > 0 && !last.Before(rec.Input) {
+      spans = append(spans, last.Sub(rec.Input))
+    }
+  }
+  if len(spans) > 0 {
+    sort.Slice(spans, func(i, j int) bool { return spans[i] < spans[j] })
+    fmt.Printf("\n%d puzzles solved; median %s from input to the last part solved, fastest %s\n",
+      len(spans), formatSpan(spans[len(spans)/2]), formatSpan(spans[0]))
+  }
+  return 0
+}
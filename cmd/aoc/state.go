@@ -0,0 +1,124 @@
+package main
+
+import (
+  "encoding/json"
+  "errors"
+  "os"
+  "path/filepath"
+  "strings"
+  "time"
+)
+
+// Local state lives in .aoc at the repository root, which is not
+// committed: it describes this machine's inputs and runs. A named
+// account's lives in its own directory below.
+func stateDir(root string) string {
+  if account != "" {
+    return accountDir(root, account)
+  }
+  return filepath.Join(root, ".aoc")
+}
+
+// The last verified status of every solution, so verify can tell what
+// changed since the previous run and serve can show it.
+type statusFile map[string]*record
+
+type record struct {
+  Parts   map[int]string `json:"parts"`
+  Outcome string         `json:"outcome"`
+  Elapsed time.Duration  `json:"elapsed"`
+  Checked time.Time      `json:"checked"`
+  Error   string         `json:"error,omitempty"`
+}
+
+func (s solution) key() string {
+  return s.id() + "." + s.name()
+}
+
+// Record a result, noting changes since the previous record. A missing
+// input says nothing about the solution, so it changes nothing; a failed
+// or timed out run is a regression for every part.
+func (st statusFile) update(r result, ch *changes) {
+  if r.err == errNoInput {
+    return
+  }
+  rec := &record{Parts: map[int]string{}, Outcome: r.outcome(), Elapsed: r.elapsed, Checked: time.Now()}
+  status := make([]string, r.sol.edition.event.parts)
+  failure := outcomeFailed
+  if r.err == nil {
+    status = r.check()
+  } else {
+    rec.Error = r.err.Error()
+    failure = rec.Outcome
+  }
+  var before map[int]string
+  if prev := st[r.sol.key()]; prev != nil {
+    before = prev.Parts
+  }
+  for i, p := range status {
+    if p == "" {
+      p = failure
+    }
+    rec.Parts[i+1] = p
+    ch.record(r.sol, i+1, before[i+1], p)
+  }
+  st[r.sol.key()] = rec
+}
+
+func loadStatus(root string) (statusFile, error) {
+  data, err := os.ReadFile(filepath.Join(stateDir(root), "status.json"))
+  if errors.Is(err, os.ErrNotExist) {
+    return statusFile{}, nil
+  }
+  if err != nil {
+    return nil, err
+  }
+  st := statusFile{}
+  return st, json.Unmarshal(data, &st)
+}
+
+// Write through a temporary file so an interrupted run cannot leave the
+// file half written.
+func (st statusFile) save(root string) error {
+  if err := os.MkdirAll(stateDir(root), 0o755); err != nil {
+    return err
+  }
+  data, err := json.MarshalIndent(st, "", "  ")
+  if err != nil {
+    return err
+  }
+  path := filepath.Join(stateDir(root), "status.json")
+  if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+    return err
+  }
+  return os.Rename(path+".tmp", path)
+}
+
+// The output of the last run of each solution is kept too, since for some
+// puzzles the picture it prints is the interesting part.
+func outputPath(root string, s solution) string {
+  return filepath.Join(stateDir(root), "output", strings.ReplaceAll(s.key(), "/", "_")+".txt")
+}
+
+func saveOutput(root string, r result) error {
+  if r.err == errNoInput {
+    return nil
+  }
+  path := outputPath(root, r.sol)
+  if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+    return err
+  }
+  return os.WriteFile(path, []byte(r.output), 0o644)
+}
+
+// Keep a run's output, note any parts newly solved, and add it to the
+// history.
+func recordRun(root string, r result) error {
+  if err := saveOutput(root, r); err != nil {
+    return err
+  }
+  if err := noteSolve(root, r); err != nil {
+    return err
+  }
+  return appendHistory(root, r)
+}
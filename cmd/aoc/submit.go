@@ -0,0 +1,289 @@
+package main
+
+import (
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "math/big"
+  "net/http"
+  "net/url"
+  "os"
+  "path/filepath"
+  "regexp"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// The site answers, AOC_SITE overriding it for a mirror or a test server.
+func aocSite() string {
+  if site := os.Getenv("AOC_SITE"); site != "" {
+    return strings.TrimSuffix(site, "/")
+  }
+  return "https://adventofcode.com"
+}
+
+// What is known about one part's answer from submitting it: the answer
+// once right, the wrong ones, bounds from "too high" and "too low", and
+// until when the site wants no more answers.
+type submission struct {
+  Correct string    `json:"correct,omitempty"`
+  Wrong   []string  `json:"wrong,omitempty"`
+  Low     string    `json:"low,omitempty"`  // the highest answer that was too low
+  High    string    `json:"high,omitempty"` // the lowest answer that was too high
+  Wait    time.Time `json:"wait,omitempty"`
+}
+
+// Submissions by puzzle id and part, as "aoc/2024/01 part 2".
+type submissionFile map[string]*submission
+
+func submissionsPath(root string) string {
+  return filepath.Join(stateDir(root), "submissions.json")
+}
+
+func loadSubmissions(root string) (submissionFile, error) {
+  data, err := os.ReadFile(submissionsPath(root))
+  if errors.Is(err, os.ErrNotExist) {
+    return submissionFile{}, nil
+  }
+  if err != nil {
+    return nil, err
+  }
+  sf := submissionFile{}
+  return sf, json.Unmarshal(data, &sf)
+}
+
+func (sf submissionFile) save(root string) error {
+  if err := os.MkdirAll(stateDir(root), 0o755); err != nil {
+    return err
+  }
+  data, err := json.MarshalIndent(sf, "", "  ")
+  if err != nil {
+    return err
+  }
+  path := submissionsPath(root)
+  if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+    return err
+  }
+  return os.Rename(path+".tmp", path)
+}
+
+// Why an answer should not be sent, if it should not: the answer is
+// already known right or wrong, it lies outside the bounds found so far,
+// or the site is still making us wait.
+func (s *submission) refuse(answer string, now time.Time) string {
+  switch {
+  case s.Correct != "" && s.Correct == answer:
+    return "already accepted as the right answer"
+  case s.Correct != "":
+    return fmt.Sprintf("the right answer is already known, and is %s", s.Correct)
+  }
+  for _, w := range s.Wrong {
+    if w == answer {
+      return "already submitted and wrong"
+    }
+  }
+  if n, ok := new(big.Int).SetString(answer, 10); ok {
+    if low, ok := new(big.Int).SetString(s.Low, 10); ok && n.Cmp(low) <= 0 {
+      return fmt.Sprintf("too low: %s was already too low", s.Low)
+    }
+    if high, ok := new(big.Int).SetString(s.High, 10); ok && n.Cmp(high) >= 0 {
+      return fmt.Sprintf("too high: %s was already too high", s.High)
+    }
+  }
+  if now.Before(s.Wait) {
+    return fmt.Sprintf("the site asked to wait until %s, %v from now", s.Wait.Local().Format("15:04:05"), s.Wait.Sub(now).Round(time.Second))
+  }
+  return ""
+}
+
+// The site's verdicts, found in the text of its answer page.
+const (
+  verdictRight   = "right"
+  verdictWrong   = "wrong"
+  verdictTooHigh = "too high"
+  verdictTooLow  = "too low"
+  verdictWait    = "wait"
+  verdictDone    = "already solved"
+  verdictUnknown = "unknown"
+)
+
+var (
+  htmlTag    = regexp.MustCompile(`<[^>]*>`)
+  mainText   = regexp.MustCompile(`(?s)<main>(.*)</main>`)
+  leftToWait = regexp.MustCompile(`You have (?:(\d+)m )?(\d+)s left to wait`)
+  waitAgain  = regexp.MustCompile(`(?:[Pp]lease wait|wait) (one|\d+) minutes? before trying again`)
+)
+
+// Read the answer page: the verdict, how long to wait before the next
+// answer, and the page's message for showing.
+func parseVerdict(page string) (verdict string, wait time.Duration, message string) {
+  if m := mainText.FindStringSubmatch(page); m != nil {
+    page = m[1]
+  }
+  message = strings.Join(strings.Fields(htmlTag.ReplaceAllString(page, " ")), " ")
+  if i := strings.Index(message, "[Return to"); i >= 0 {
+    message = strings.TrimSpace(message[:i])
+  }
+  if m := leftToWait.FindStringSubmatch(message); m != nil {
+    minutes, _ := strconv.Atoi(m[1])
+    seconds, _ := strconv.Atoi(m[2])
+    wait = time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+  } else if m := waitAgain.FindStringSubmatch(message); m != nil {
+    minutes := 1
+    if m[1] != "one" {
+      minutes, _ = strconv.Atoi(m[1])
+    }
+    wait = time.Duration(minutes) * time.Minute
+  }
+  switch {
+  case strings.Contains(message, "That's the right answer"):
+    verdict = verdictRight
+  case strings.Contains(message, "You gave an answer too recently"):
+    verdict = verdictWait
+  case strings.Contains(message, "your answer is too high"):
+    verdict = verdictTooHigh
+  case strings.Contains(message, "your answer is too low"):
+    verdict = verdictTooLow
+  case strings.Contains(message, "That's not the right answer"):
+    verdict = verdictWrong
+  case strings.Contains(message, "You don't seem to be solving the right level"):
+    verdict = verdictDone
+  default:
+    verdict = verdictUnknown
+  }
+  return verdict, wait, message
+}
+
+// Record a verdict on an answer.
+func (s *submission) record(answer, verdict string, wait time.Duration, now time.Time) {
+  if wait > 0 {
+    s.Wait = now.Add(wait)
+  }
+  n, numeric := new(big.Int).SetString(answer, 10)
+  switch verdict {
+  case verdictRight:
+    s.Correct = answer
+  case verdictTooHigh:
+    if high, ok := new(big.Int).SetString(s.High, 10); numeric && (!ok || n.Cmp(high) < 0) {
+      s.High = answer
+    }
+    s.Wrong = append(s.Wrong, answer)
+  case verdictTooLow:
+    if low, ok := new(big.Int).SetString(s.Low, 10); numeric && (!ok || n.Cmp(low) > 0) {
+      s.Low = answer
+    }
+    s.Wrong = append(s.Wrong, answer)
+  case verdictWrong:
+    s.Wrong = append(s.Wrong, answer)
+  }
+}
+
+// Post an answer and return the page the site answers with. It goes
+// exactly once: after a timeout or a server error the answer may have
+// been counted, so the user is told to look rather than have it sent
+// again.
+func postAnswer(edition string, day, part int, answer, session string) (string, error) {
+  form := url.Values{"level": {strconv.Itoa(part)}, "answer": {answer}}.Encode()
+  req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s/day/%d/answer", aocSite(), edition, day), strings.NewReader(form))
+  if err != nil {
+    return "", err
+  }
+  req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+  req.Header.Set("User-Agent", "github.com/nixternal/CodingChallenges cmd/aoc")
+  req.AddCookie(&http.Cookie{Name: "session", Value: session})
+  check := fmt.Sprintf("check %s/%s/day/%d before submitting again", aocSite(), edition, day)
+  resp, err := fetchOnce("submitting the answer", req)
+  if isTimeout(err) {
+    return "", fmt.Errorf("submitting the answer timed out; it may still have been counted, so %s", check)
+  } else if err != nil {
+    return "", err
+  }
+  page, err := io.ReadAll(resp.Body)
+  resp.Body.Close()
+  switch {
+  case err != nil:
+    return "", err
+  case resp.StatusCode >= 500:
+    return "", fmt.Errorf("the site answered %s; the answer may still have been counted, so %s", resp.Status, check)
+  case resp.StatusCode != http.StatusOK:
+    return "", fmt.Errorf("the site answered %s; is the session token still valid?", resp.Status)
+  }
+  return string(page), nil
+}
+
+// Submit an answer to an Advent of Code part: the one given with -answer,
+// or else the one the puzzle's solution prints. Answers the site would
+// only turn away are not sent.
+func submit(sols []solution, o *options) int {
+  if o.event != "aoc" || o.edition == "" || o.day == 0 || o.part == 0 {
+    fmt.Fprintln(os.Stderr, "aoc: submit needs an Advent of Code part; use -y, -d and -part")
+    return 2
+  }
+  answer := strings.TrimSpace(o.answer)
+  if answer == "" {
+    if len(sols) == 0 {
+      fmt.Fprintln(os.Stderr, "aoc: no solution to run for an answer; give one with -answer")
+      return 2
+    }
+    // Any implementation will do; the first is the usual one.
+    r := run(sols[0], o.timeout)
+    if r.err != nil || r.answers[o.part] == "" {
+      fmt.Fprintf(os.Stderr, "aoc: %s.%s gave no answer to part %d: %v\n", sols[0].id(), sols[0].name(), o.part, r.err)
+      return 1
+    }
+    answer = r.answers[o.part]
+    fmt.Printf("%s.%s answers %s\n", sols[0].id(), sols[0].name(), answer)
+  }
+
+  subs, err := loadSubmissions(o.root)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  key := fmt.Sprintf("aoc/%s/%02d part %d", o.edition, o.day, o.part)
+  sub := subs[key]
+  if sub == nil {
+    sub = &submission{}
+    subs[key] = sub
+  }
+  if why := sub.refuse(answer, time.Now()); why != "" {
+    fmt.Printf("not submitting %s: %s\n", answer, why)
+    return 1
+  }
+  session, err := sessionToken()
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+
+  page, err := postAnswer(o.edition, o.day, o.part, answer, session)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  verdict, wait, message := parseVerdict(page)
+  now := time.Now()
+  sub.record(answer, verdict, wait, now)
+  if err := subs.save(o.root); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+  }
+  fmt.Println(message)
+  if verdict == verdictRight {
+    msg, err := recordAnswer(o.root, fmt.Sprintf("aoc/%s/%02d", o.edition, o.day), o.part, answer)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "aoc:", err)
+      return 1
+    }
+    fmt.Println("\n" + msg)
+    return 0
+  }
+  if wait > 0 {
+    fmt.Printf("\nthe next answer can go in at %s\n", now.Add(wait).Local().Format("15:04:05"))
+  }
+  if verdict == verdictDone {
+    return 0
+  }
+  return 1
+}
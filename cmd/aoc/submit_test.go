@@ -0,0 +1,95 @@
+package main
+
+import (
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "sync/atomic"
+  "testing"
+  "time"
+)
+
+// A site that answers each post with handler, counting the posts.
+func fakeSite(t *testing.T, handler http.HandlerFunc) *atomic.Int32 {
+  var posts atomic.Int32
+  ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    posts.Add(1)
+    if r.Method != http.MethodPost || r.URL.Path != "/2024/day/3/answer" {
+      t.Errorf("got %s %s", r.Method, r.URL.Path)
+    }
+    if c, err := r.Cookie("session"); err != nil || c.Value != "tok" {
+      t.Errorf("session cookie %v, %v", c, err)
+    }
+    handler(w, r)
+  }))
+  t.Cleanup(ts.Close)
+  t.Setenv("AOC_SITE", ts.URL)
+  client := httpClient
+  httpClient = &http.Client{Timeout: 100 * time.Millisecond}
+  t.Cleanup(func() { httpClient = client })
+  return &posts
+}
+
+func TestPostAnswer(t *testing.T) {
+  posts := fakeSite(t, func(w http.ResponseWriter, r *http.Request) {
+    if r.FormValue("level") != "2" || r.FormValue("answer") != "48" {
+      t.Errorf("form %v", r.Form)
+    }
+    w.Write([]byte("<main><p>That's the right answer!</p></main>"))
+  })
+  page, err := postAnswer("2024", 3, 2, "48", "tok")
+  if err != nil || !strings.Contains(page, "right answer") || posts.Load() != 1 {
+    t.Errorf("got %q, %v after %d posts", page, err, posts.Load())
+  }
+}
+
+// An answer is never sent twice, since a retry could count as a second
+// guess; a failure says whether it may have been counted.
+func TestPostAnswerOnce(t *testing.T) {
+  for _, c := range []struct {
+    name    string
+    handler http.HandlerFunc
+    err     string
+  }{
+    {"server error", func(w http.ResponseWriter, r *http.Request) {
+      http.Error(w, "oops", http.StatusBadGateway)
+    }, "502 Bad Gateway; the answer may still have been counted, so check http"},
+    {"too many requests", func(w http.ResponseWriter, r *http.Request) {
+      w.Header().Set("Retry-After", "1")
+      http.Error(w, "slow down", http.StatusTooManyRequests)
+    }, "is the session token still valid?"},
+    {"timeout", func(w http.ResponseWriter, r *http.Request) {
+      time.Sleep(300 * time.Millisecond)
+    }, "timed out; it may still have been counted, so check http"},
+  } {
+    t.Run(c.name, func(t *testing.T) {
+      posts := fakeSite(t, c.handler)
+      _, err := postAnswer("2024", 3, 2, "48", "tok")
+      if err == nil || !strings.Contains(err.Error(), c.err) {
+        t.Errorf("got %v, want %q", err, c.err)
+      }
+      if n := posts.Load(); n != 1 {
+        t.Errorf("posted %d times", n)
+      }
+    })
+  }
+}
+
+func TestParseVerdict(t *testing.T) {
+  for _, c := range []struct {
+    page, verdict string
+    wait          time.Duration
+  }{
+    {"<main><article><p>That's the right answer! You are one gold star closer.</p></article></main>", verdictRight, 0},
+    {"<main><p>That's not the right answer; your answer is too high. Please wait one minute before trying again.</p></main>", verdictTooHigh, time.Minute},
+    {"<main><p>That's not the right answer; your answer is too low. please wait 5 minutes before trying again.</p></main>", verdictTooLow, 5 * time.Minute},
+    {"<main><p>You gave an answer too recently. You have 1m 5s left to wait.</p></main>", verdictWait, 65 * time.Second},
+    {"<main><p>You don't seem to be solving the right level. [Return to Day 3]</p></main>", verdictDone, 0},
+    {"<html>something else</html>", verdictUnknown, 0},
+  } {
+    verdict, wait, _ := parseVerdict(c.page)
+    if verdict != c.verdict || wait != c.wait {
+      t.Errorf("%q: got %s, %v; want %s, %v", c.page, verdict, wait, c.verdict, c.wait)
+    }
+  }
+}
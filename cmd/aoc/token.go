@@ -0,0 +1,399 @@
+package main
+
+import (
+  "bufio"
+  "crypto/aes"
+  "crypto/cipher"
+  "crypto/pbkdf2"
+  "crypto/rand"
+  "crypto/sha256"
+  "encoding/json"
+  "errors"
+  "flag"
+  "fmt"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "runtime"
+  "strings"
+)
+
+// The adventofcode.com session token, for anything that talks to the site,
+// is kept out of plain text: in the OS keychain where there is one (the
+// macOS keychain through security(1), or the Secret Service through
+// secret-tool(1) on Linux and the BSDs), and otherwise in a file encrypted
+// with a passphrase. AOC_SESSION still overrides both, for CI. Each named
+// account (see account.go) has a token of its own, stored beside the usual
+// one and overridden by its own variable, AOC_SESSION_NAME.
+type tokenStore interface {
+  name() string
+  available() bool
+  // Whether a token is stored, found without asking for a passphrase.
+  has() bool
+  get() (string, error)
+  set(token string) error
+  clear() error
+}
+
+var errNoToken = errors.New("no session token stored; run aoc token set")
+
+const keyringService = "adventofcode.com"
+
+// The keyring's name for the account in use's token.
+func keyringAccount() string {
+  if account == "" {
+    return "aoc-session"
+  }
+  return "aoc-session-" + account
+}
+
+type keyringStore struct{}
+
+func (keyringStore) name() string { return "keyring" }
+
+func (keyringStore) available() bool {
+  tool := "secret-tool"
+  switch runtime.GOOS {
+  case "darwin":
+    tool = "security"
+  case "windows":
+    // Credential Manager has no command to read a secret back.
+    return false
+  }
+  _, err := exec.LookPath(tool)
+  return err == nil
+}
+
+func (s keyringStore) has() bool {
+  _, err := s.get()
+  return err == nil
+}
+
+func (keyringStore) get() (string, error) {
+  var cmd *exec.Cmd
+  if runtime.GOOS == "darwin" {
+    cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount(), "-w")
+  } else {
+    cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount())
+  }
+  out, err := cmd.Output()
+  token := strings.TrimSpace(string(out))
+  if err != nil || token == "" {
+    return "", errNoToken
+  }
+  return token, nil
+}
+
+func (keyringStore) set(token string) error {
+  var cmd *exec.Cmd
+  if runtime.GOOS == "darwin" {
+    // security takes the secret as an argument, briefly visible to ps;
+    // it has no other way short of its interactive prompt.
+    cmd = exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", keyringAccount(), "-w", token)
+  } else {
+    cmd = exec.Command("secret-tool", "store", "--label=Advent of Code session", "service", keyringService, "account", keyringAccount())
+    cmd.Stdin = strings.NewReader(token)
+  }
+  if out, err := cmd.CombinedOutput(); err != nil {
+    return fmt.Errorf("keyring: %v: %s", err, lastLine(string(out)))
+  }
+  return nil
+}
+
+func (keyringStore) clear() error {
+  cmd := exec.Command("secret-tool", "clear", "service", keyringService, "account", keyringAccount())
+  if runtime.GOOS == "darwin" {
+    cmd = exec.Command("security", "delete-generic-password", "-s", keyringService, "-a", keyringAccount())
+  }
+  cmd.Run()
+  return nil
+}
+
+// The fallback: the token sealed with AES-256-GCM under a key derived from
+// a passphrase, in the user's config directory.
+type fileStore struct{}
+
+func (fileStore) name() string { return "file" }
+
+func (fileStore) available() bool { return true }
+
+func (fileStore) path() (string, error) {
+  dir, err := os.UserConfigDir()
+  if err != nil {
+    return "", err
+  }
+  name := "session.enc"
+  if account != "" {
+    name = "session." + account + ".enc"
+  }
+  return filepath.Join(dir, "aoc", name), nil
+}
+
+type sealedToken struct {
+  Iterations int    `json:"iterations"`
+  Salt       []byte `json:"salt"`
+  Nonce      []byte `json:"nonce"`
+  Sealed     []byte `json:"sealed"`
+}
+
+const tokenIterations = 600000
+
+// A sealed file's iteration count is read before anything in it can be
+// authenticated, so only counts within a factor of ten of the one used to
+// seal are accepted: a doctored file can neither weaken the key nor tie
+// the machine up deriving it.
+const (
+  minIterations = tokenIterations / 10
+  maxIterations = tokenIterations * 10
+)
+
+func tokenCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+  if iterations < minIterations || iterations > maxIterations {
+    return nil, fmt.Errorf("implausible key derivation count %d; the file is damaged", iterations)
+  }
+  key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+  if err != nil {
+    return nil, err
+  }
+  block, err := aes.NewCipher(key)
+  if err != nil {
+    return nil, err
+  }
+  return cipher.NewGCM(block)
+}
+
+func (s fileStore) has() bool {
+  path, err := s.path()
+  if err == nil {
+    _, err = os.Stat(path)
+  }
+  return err == nil
+}
+
+func (s fileStore) get() (string, error) {
+  path, err := s.path()
+  if err != nil {
+    return "", err
+  }
+  data, err := os.ReadFile(path)
+  if errors.Is(err, os.ErrNotExist) {
+    return "", errNoToken
+  }
+  if err != nil {
+    return "", err
+  }
+  var st sealedToken
+  if err := json.Unmarshal(data, &st); err != nil {
+    return "", fmt.Errorf("%s: %v", path, err)
+  }
+  passphrase, err := readSecret("passphrase for the session token: ", "AOC_PASSPHRASE")
+  if err != nil {
+    return "", err
+  }
+  aead, err := tokenCipher(passphrase, st.Salt, st.Iterations)
+  if err != nil {
+    return "", err
+  }
+  token, err := aead.Open(nil, st.Nonce, st.Sealed, nil)
+  if err != nil {
+    return "", errors.New("wrong passphrase, or the token file is damaged")
+  }
+  return string(token), nil
+}
+
+func (s fileStore) set(token string) error {
+  path, err := s.path()
+  if err != nil {
+    return err
+  }
+  passphrase, err := newPassphrase("passphrase to encrypt it with: ")
+  if err != nil {
+    return err
+  }
+  st := sealedToken{Iterations: tokenIterations, Salt: make([]byte, 16)}
+  rand.Read(st.Salt)
+  aead, err := tokenCipher(passphrase, st.Salt, st.Iterations)
+  if err != nil {
+    return err
+  }
+  st.Nonce = make([]byte, aead.NonceSize())
+  rand.Read(st.Nonce)
+  st.Sealed = aead.Seal(nil, st.Nonce, []byte(token), nil)
+  data, _ := json.MarshalIndent(st, "", "  ")
+  if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+    return err
+  }
+  return os.WriteFile(path, data, 0o600)
+}
+
+func (s fileStore) clear() error {
+  path, err := s.path()
+  if err != nil {
+    return err
+  }
+  if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+    return err
+  }
+  return nil
+}
+
+// The stores in the order they are tried.
+var tokenStores = []tokenStore{keyringStore{}, fileStore{}}
+
+// The account in use's session token, from AOC_SESSION (or the named
+// account's own variable) or the first store that has one.
+func sessionToken() (string, error) {
+  if token := os.Getenv(sessionEnv()); token != "" {
+    return token, nil
+  }
+  for _, s := range tokenStores {
+    if !s.available() {
+      continue
+    }
+    token, err := s.get()
+    if err != errNoToken {
+      return token, err
+    }
+  }
+  return "", errNoToken
+}
+
+// Read a line from the terminal without echoing it, or from standard input
+// when it is not a terminal. The variable env, if set, answers without
+// asking, for scripts.
+func readSecret(prompt, env string) (string, error) {
+  if p, ok := os.LookupEnv(env); ok && env != "" {
+    return p, nil
+  }
+  info, err := os.Stdin.Stat()
+  tty := err == nil && info.Mode()&os.ModeCharDevice != 0
+  if tty {
+    fmt.Fprint(os.Stderr, prompt)
+    stty := func(arg string) {
+      cmd := exec.Command("stty", arg)
+      cmd.Stdin = os.Stdin
+      cmd.Run()
+    }
+    stty("-echo")
+    defer func() {
+      stty("echo")
+      fmt.Fprintln(os.Stderr)
+    }()
+  }
+  line, err := stdinReader.ReadString('\n')
+  if err != nil && line == "" {
+    return "", fmt.Errorf("reading the %s: %v", strings.TrimSuffix(prompt, ": "), err)
+  }
+  return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Ask for a new passphrase twice, since a mistyped one would lock away
+// what it seals for good. With AOC_PASSPHRASE set, that answers both.
+func newPassphrase(prompt string) (string, error) {
+  passphrase, err := readSecret(prompt, "AOC_PASSPHRASE")
+  if err != nil {
+    return "", err
+  }
+  if passphrase == "" {
+    return "", errors.New("the passphrase may not be empty")
+  }
+  again, err := readSecret("repeat the passphrase: ", "AOC_PASSPHRASE")
+  if err != nil {
+    return "", err
+  }
+  if again != passphrase {
+    return "", errors.New("the passphrases do not match")
+  }
+  return passphrase, nil
+}
+
+// One reader for all prompts, so a token and passphrase piped in together
+// are read line by line.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// aoc token set|show|clear|status: manage the stored session token.
+func token(args []string) int {
+  fs := flag.NewFlagSet("aoc token", flag.ExitOnError)
+  storeName := fs.String("store", "", "where to keep the token: keyring or file (default: keyring if there is one)")
+  fs.StringVar(&account, "account", account, "named account whose token this is (default: AOC_ACCOUNT, or the usual one)")
+  fs.Usage = func() {
+    fmt.Fprintln(os.Stderr, "usage: aoc token set|show|clear|status [-store keyring|file] [-account NAME]")
+    fs.PrintDefaults()
+  }
+  if len(args) == 0 {
+    fs.Usage()
+    return 2
+  }
+  action := args[0]
+  fs.Parse(args[1:])
+  if err := checkAccount(account); err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 2
+  }
+
+  var store tokenStore
+  for _, s := range tokenStores {
+    if (*storeName == "" || *storeName == s.name()) && s.available() {
+      store = s
+      break
+    }
+  }
+  if store == nil {
+    fmt.Fprintf(os.Stderr, "aoc: no %s store on this system\n", *storeName)
+    return 1
+  }
+
+  var err error
+  switch action {
+  case "set":
+    var t string
+    t, err = readSecret("session token (the session cookie of adventofcode.com): ", "")
+    if err == nil && strings.TrimSpace(t) == "" {
+      err = errors.New("no token given")
+    }
+    if err == nil {
+      err = store.set(strings.TrimSpace(t))
+    }
+    if err == nil {
+      fmt.Printf("token stored in the %s store\n", store.name())
+    }
+  case "show":
+    var t string
+    if t, err = sessionToken(); err == nil {
+      fmt.Println(t)
+    }
+  case "clear":
+    for _, s := range tokenStores {
+      if s.available() && (*storeName == "" || *storeName == s.name()) {
+        if e := s.clear(); e != nil {
+          err = e
+        }
+      }
+    }
+  case "status":
+    if account != "" {
+      fmt.Printf("account %s\n", account)
+    }
+    if os.Getenv(sessionEnv()) != "" {
+      fmt.Printf("%s is set and overrides the stores\n", sessionEnv())
+    }
+    for _, s := range tokenStores {
+      status := "not available"
+      switch {
+      case s.available() && s.has():
+        status = "holds a token"
+      case s.available():
+        status = "empty"
+      }
+      fmt.Printf("%-8s %s\n", s.name(), status)
+    }
+  default:
+    fs.Usage()
+    return 2
+  }
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "aoc:", err)
+    return 1
+  }
+  return 0
+}
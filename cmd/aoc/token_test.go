@@ -0,0 +1,116 @@
+package main
+
+import (
+  "encoding/json"
+  "os"
+  "strings"
+  "testing"
+)
+
+// A file store writing under a temporary config directory.
+func tempFileStore(t *testing.T) fileStore {
+  t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+  t.Setenv("HOME", t.TempDir())
+  a := account
+  account = ""
+  t.Cleanup(func() { account = a })
+  return fileStore{}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+  s := tempFileStore(t)
+  if s.has() {
+    t.Fatal("a fresh store has a token")
+  }
+  if _, err := s.get(); err != errNoToken {
+    t.Fatalf("got %v, want %v", err, errNoToken)
+  }
+  t.Setenv("AOC_PASSPHRASE", "correct horse")
+  if err := s.set("53616c7465645f5f"); err != nil {
+    t.Fatal(err)
+  }
+  path, _ := s.path()
+  info, err := os.Stat(path)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if info.Mode().Perm() != 0o600 {
+    t.Errorf("token file has mode %v", info.Mode().Perm())
+  }
+  data, _ := os.ReadFile(path)
+  if strings.Contains(string(data), "53616c7465645f5f") {
+    t.Error("the token is stored in the clear")
+  }
+  got, err := s.get()
+  if err != nil || got != "53616c7465645f5f" {
+    t.Errorf("got %q, %v", got, err)
+  }
+
+  // Each account has a file of its own.
+  account = "work"
+  if s.has() {
+    t.Error("the work account sees the usual account's token")
+  }
+  account = ""
+
+  if err := s.clear(); err != nil || s.has() {
+    t.Errorf("clear left the token (%v)", err)
+  }
+}
+
+func TestFileStoreWrongPassphrase(t *testing.T) {
+  s := tempFileStore(t)
+  t.Setenv("AOC_PASSPHRASE", "correct horse")
+  if err := s.set("token"); err != nil {
+    t.Fatal(err)
+  }
+  t.Setenv("AOC_PASSPHRASE", "correct horse battery")
+  if got, err := s.get(); err == nil || err.Error() != "wrong passphrase, or the token file is damaged" {
+    t.Errorf("got %q, %v; want a wrong passphrase error", got, err)
+  }
+}
+
+// A sealed file whose key derivation count was tampered with is refused
+// before the key is derived.
+func TestFileStoreImplausibleIterations(t *testing.T) {
+  s := tempFileStore(t)
+  t.Setenv("AOC_PASSPHRASE", "correct horse")
+  if err := s.set("token"); err != nil {
+    t.Fatal(err)
+  }
+  path, _ := s.path()
+  data, _ := os.ReadFile(path)
+  for _, n := range []int{0, 1, minIterations - 1, maxIterations + 1, 1 << 40} {
+    var st sealedToken
+    json.Unmarshal(data, &st)
+    st.Iterations = n
+    changed, _ := json.Marshal(st)
+    os.WriteFile(path, changed, 0o600)
+    if _, err := s.get(); err == nil || !strings.Contains(err.Error(), "implausible key derivation count") {
+      t.Errorf("%d iterations: got %v", n, err)
+    }
+  }
+}
+
+func TestFileStoreConfirmsPassphrase(t *testing.T) {
+  for _, c := range []struct{ name, input, err string }{
+    {"mismatch", "correct horse\ncorrect hose\n", "the passphrases do not match"},
+    {"empty", "\n\n", "the passphrase may not be empty"},
+    {"match", "correct horse\ncorrect horse\n", ""},
+  } {
+    t.Run(c.name, func(t *testing.T) {
+      s := tempFileStore(t)
+      typed(t, c.input)
+      err := s.set("token")
+      switch {
+      case c.err == "" && err != nil:
+        t.Fatal(err)
+      case c.err != "" && (err == nil || err.Error() != c.err):
+        t.Fatalf("got %v, want %q", err, c.err)
+      }
+      if s.has() != (c.err == "") {
+        t.Errorf("token stored: %v", s.has())
+      }
+    })
+  }
+}
@@ -0,0 +1,148 @@
+// The results page: a table of every solution's last verification, a bar
+// chart of run times, and the output of the selected solution.
+
+let solutions = [];
+
+const $ = (id) => document.getElementById(id);
+
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    if (k.startsWith("on")) node.addEventListener(k.slice(2), v);
+    else node.setAttribute(k, v);
+  }
+  node.append(...children);
+  return node;
+}
+
+// Durations arrive from Go in nanoseconds.
+function formatTime(ns) {
+  const ms = ns / 1e6;
+  return ms < 1000 ? `${ms.toFixed(0)} ms` : `${(ms / 1000).toFixed(2)} s`;
+}
+
+// The language, with the variant name for alternative implementations.
+function implName(s) {
+  return s.variant ? `${s.variant}.${s.lang}` : s.lang;
+}
+
+function selected() {
+  const event = $("event").value;
+  const edition = $("edition").value;
+  return solutions.filter((s) => s.event === event && (edition === "" || s.edition === edition));
+}
+
+function fillSelect(select, values, labels = values) {
+  const current = select.value;
+  select.replaceChildren(...values.map((v, i) => el("option", { value: v }, labels[i])));
+  if (values.includes(current)) select.value = current;
+}
+
+function updateEditions() {
+  const editions = [...new Set(solutions.filter((s) => s.event === $("event").value).map((s) => s.edition))];
+  fillSelect($("edition"), ["", ...editions], ["all", ...editions]);
+}
+
+function partCells(s) {
+  const cells = [];
+  for (let p = 1; p <= s.parts; p++) {
+    const status = s.last ? s.last.parts[p] : undefined;
+    const cls = { ok: "ok", wrong: "wrong", failed: "failed", timeout: "failed", "?": "unverified", "-": "missing" }[status] || "none";
+    cells.push(el("span", { class: `part ${cls}`, title: `part ${p}` }, status === undefined ? "·" : status));
+  }
+  return cells;
+}
+
+function row(s) {
+  const run = el("button", { onclick: () => runSolution(s, run) }, "run");
+  if (!s.hasInput) {
+    run.disabled = true;
+    run.title = "no input";
+  }
+  const show = el("button", { onclick: () => showOutput(s) }, "output");
+  return el("tr", {},
+    el("td", {}, s.id),
+    el("td", {}, implName(s)),
+    el("td", {}, ...partCells(s)),
+    el("td", { class: "num" }, s.last ? formatTime(s.last.elapsed) : ""),
+    el("td", {}, s.last ? new Date(s.last.checked).toLocaleString() : ""),
+    el("td", {}, run, " ", show));
+}
+
+// Horizontal bars on a log scale, since run times span milliseconds to
+// minutes.
+function drawChart(list) {
+  const timed = list.filter((s) => s.last && s.last.elapsed > 0);
+  if (timed.length === 0) {
+    $("chart").textContent = "No timings yet: run aoc verify, or a solution here.";
+    return;
+  }
+  const ns = "http://www.w3.org/2000/svg";
+  const barHeight = 14, labelWidth = 150, width = 500;
+  const svg = document.createElementNS(ns, "svg");
+  svg.setAttribute("width", labelWidth + width + 80);
+  svg.setAttribute("height", timed.length * (barHeight + 3));
+  const max = Math.log10(Math.max(...timed.map((s) => s.last.elapsed / 1e6)) + 1);
+  timed.forEach((s, i) => {
+    const ms = s.last.elapsed / 1e6;
+    const y = i * (barHeight + 3);
+    const add = (tag, attrs, text) => {
+      const node = document.createElementNS(ns, tag);
+      for (const [k, v] of Object.entries(attrs)) node.setAttribute(k, v);
+      if (text) node.textContent = text;
+      svg.append(node);
+    };
+    add("text", { x: 0, y: y + barHeight - 3 }, `${s.id}.${implName(s)}`);
+    const w = Math.max(1, (Math.log10(ms + 1) / max) * width);
+    const color = s.last.outcome === "ok" ? "#2e7d32" : s.last.outcome === "unverified" ? "#777" : "#c62828";
+    add("rect", { x: labelWidth, y, width: w, height: barHeight, fill: color });
+    add("text", { x: labelWidth + w + 4, y: y + barHeight - 3 }, formatTime(s.last.elapsed));
+  });
+  $("chart").replaceChildren(svg);
+}
+
+function render() {
+  const list = selected();
+  $("results").tBodies[0].replaceChildren(...list.map(row));
+  const counts = {};
+  for (const s of list) {
+    const outcome = s.last ? s.last.outcome : "not run";
+    counts[outcome] = (counts[outcome] || 0) + 1;
+  }
+  $("summary").textContent = Object.entries(counts).map(([k, v]) => `${v} ${k}`).join(", ");
+  drawChart(list);
+}
+
+async function showOutput(s) {
+  $("output-title").textContent = `Output of ${s.id}.${implName(s)}`;
+  const resp = await fetch(`api/output?key=${encodeURIComponent(s.key)}`);
+  $("output").textContent = await resp.text();
+}
+
+async function runSolution(s, button) {
+  button.disabled = true;
+  button.textContent = "running";
+  try {
+    const resp = await fetch(`api/run?key=${encodeURIComponent(s.key)}`, { method: "POST" });
+    if (!resp.ok) throw new Error(await resp.text());
+    Object.assign(s, await resp.json());
+    render();
+    showOutput(s);
+  } catch (err) {
+    $("output").textContent = `Run failed: ${err.message}`;
+    button.disabled = false;
+    button.textContent = "run";
+  }
+}
+
+async function load() {
+  solutions = await (await fetch("api/solutions")).json();
+  const events = [...new Map(solutions.map((s) => [s.event, s.title])).entries()];
+  fillSelect($("event"), events.map((e) => e[0]), events.map((e) => e[1]));
+  updateEditions();
+  render();
+}
+
+$("event").addEventListener("change", () => { updateEditions(); render(); });
+$("edition").addEventListener("change", render);
+load();
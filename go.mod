@@ -0,0 +1,3 @@
+module github.com/nixternal/CodingChallenges
+
+go 1.24
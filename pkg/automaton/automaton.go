@@ -0,0 +1,346 @@
+// Package automaton runs cellular automata: a rule deciding each cell's
+// next state from its own and its neighbors', over a neighborhood of any
+// shape, on a grid that is dense or sparse and whose edges are bounded,
+// wrap around, or (sparse only) do not exist.
+//
+// Conway's Game of Life and the other B/S rules are one kind of rule, and
+// the lumber collection of 2018 day 18 another; a rule with its own states
+// is a type with Next and States methods.
+package automaton
+
+import (
+  "errors"
+  "fmt"
+  "strconv"
+  "strings"
+
+  "github.com/nixternal/CodingChallenges/pkg/grid"
+)
+
+// Cells hold a small state number; 0 is dead or empty. A rule sees a cell's
+// state and how many of its neighbors are in each state.
+const MaxStates = 8
+
+type Counts [MaxStates]int
+
+type Rule interface {
+  Next(state uint8, n *Counts) uint8
+  States() int
+}
+
+// A LifeRule is a Life-like rule in B/S notation, e.g. B3/S23 for Conway's
+// Game of Life.
+type LifeRule struct {
+  birth, survive [64]bool
+}
+
+// ParseLifeRule parses "B3/S23" (either order, case-insensitive) or the
+// older "23/3" survival/birth form.
+func ParseLifeRule(s string) (*LifeRule, error) {
+  r := &LifeRule{}
+  parts := strings.Split(strings.ToUpper(s), "/")
+  if len(parts) != 2 {
+    return nil, fmt.Errorf("rule %q: want B.../S...", s)
+  }
+  if !strings.HasPrefix(parts[0], "B") && !strings.HasPrefix(parts[0], "S") {
+    // The old notation puts survival first and has no letters.
+    parts = []string{"S" + parts[0], "B" + parts[1]}
+  }
+  for _, part := range parts {
+    if part == "" {
+      return nil, fmt.Errorf("rule %q: empty part", s)
+    }
+    set := &r.birth
+    if part[0] == 'S' {
+      set = &r.survive
+    } else if part[0] != 'B' {
+      return nil, fmt.Errorf("rule %q: parts must start with B or S", s)
+    }
+    for _, c := range part[1:] {
+      n, err := strconv.Atoi(string(c))
+      if err != nil {
+        return nil, fmt.Errorf("rule %q: bad neighbor count %q", s, c)
+      }
+      set[n] = true
+    }
+  }
+  return r, nil
+}
+
+func (r *LifeRule) Next(state uint8, n *Counts) uint8 {
+  alive := n[1]
+  if state != 0 && alive < len(r.survive) && r.survive[alive] || state == 0 && alive < len(r.birth) && r.birth[alive] {
+    return 1
+  }
+  return 0
+}
+
+func (r *LifeRule) States() int { return 2 }
+
+// Lumber is the three-state forest from Advent of Code 2018 day 18
+// ("Settlers of The North Pole"): open ground grows trees, trees become
+// lumberyards, and lumberyards need both neighbors to last.
+type Lumber struct{}
+
+// The states of a Lumber cell.
+const (
+  Open = iota
+  Trees
+  Lumberyard
+)
+
+func (Lumber) Next(state uint8, n *Counts) uint8 {
+  switch state {
+  case Open:
+    if n[Trees] >= 3 {
+      return Trees
+    }
+  case Trees:
+    if n[Lumberyard] >= 3 {
+      return Lumberyard
+    }
+  case Lumberyard:
+    if n[Lumberyard] == 0 || n[Trees] == 0 {
+      return Open
+    }
+  }
+  return state
+}
+
+func (Lumber) States() int { return 3 }
+
+// A Neighborhood is the set of offsets counted as neighbors.
+type Neighborhood []grid.Point
+
+// Moore is the square of cells within radius steps, diagonals included.
+func Moore(radius int) Neighborhood {
+  var n Neighborhood
+  for dy := -radius; dy <= radius; dy++ {
+    for dx := -radius; dx <= radius; dx++ {
+      if dx != 0 || dy != 0 {
+        n = append(n, grid.Point{X: dx, Y: dy})
+      }
+    }
+  }
+  return n
+}
+
+// VonNeumann is the diamond of cells within radius orthogonal steps.
+func VonNeumann(radius int) Neighborhood {
+  var n Neighborhood
+  for _, p := range Moore(radius) {
+    if p.Manhattan(grid.Point{}) <= radius {
+      n = append(n, p)
+    }
+  }
+  return n
+}
+
+// ParseNeighborhood parses a neighborhood name with an optional radius,
+// like "moore" or "vonneumann:2".
+func ParseNeighborhood(s string) (Neighborhood, error) {
+  name, radius := s, 1
+  if i := strings.IndexByte(s, ':'); i >= 0 {
+    r, err := strconv.Atoi(s[i+1:])
+    if err != nil || r < 1 {
+      return nil, fmt.Errorf("bad radius in %q", s)
+    }
+    name, radius = s[:i], r
+  }
+  switch strings.ToLower(name) {
+  case "moore":
+    return Moore(radius), nil
+  case "vonneumann", "von-neumann", "vn":
+    return VonNeumann(radius), nil
+  }
+  return nil, fmt.Errorf("unknown neighborhood %q", name)
+}
+
+// Edges is how a grid treats cells past its edge: as empty, or as the
+// cells on the opposite side.
+type Edges int
+
+const (
+  Bounded Edges = iota
+  Toroidal
+  Unbounded // sparse grids only
+)
+
+// A Grid is one generation of the automaton. Backends differ in storage,
+// not behaviour, so either can run any rule.
+type Grid interface {
+  Get(x, y int) uint8
+  Set(x, y int, s uint8)
+  Step(r Rule, nb Neighborhood) Grid
+  Population() int
+  // The bounding box of the cells a renderer should show.
+  Bounds() (min, max grid.Point)
+}
+
+// A Dense grid stores every cell of a fixed rectangle.
+type Dense struct {
+  w, h  int
+  edges Edges
+  cells []uint8
+}
+
+func NewDense(w, h int, e Edges) *Dense {
+  return &Dense{w: w, h: h, edges: e, cells: make([]uint8, w*h)}
+}
+
+// Map (x, y) into the grid, reporting false for cells off a bounded edge.
+func (g *Dense) index(x, y int) (int, bool) {
+  if g.edges == Toroidal {
+    x, y = (x%g.w+g.w)%g.w, (y%g.h+g.h)%g.h
+  } else if x < 0 || y < 0 || x >= g.w || y >= g.h {
+    return 0, false
+  }
+  return y*g.w + x, true
+}
+
+func (g *Dense) Get(x, y int) uint8 {
+  if i, ok := g.index(x, y); ok {
+    return g.cells[i]
+  }
+  return 0
+}
+
+func (g *Dense) Set(x, y int, s uint8) {
+  if i, ok := g.index(x, y); ok {
+    g.cells[i] = s
+  }
+}
+
+func (g *Dense) Step(r Rule, nb Neighborhood) Grid {
+  next := NewDense(g.w, g.h, g.edges)
+  var n Counts
+  for y := 0; y < g.h; y++ {
+    for x := 0; x < g.w; x++ {
+      n = Counts{}
+      for _, d := range nb {
+        n[g.Get(x+d.X, y+d.Y)]++
+      }
+      next.cells[y*g.w+x] = r.Next(g.cells[y*g.w+x], &n)
+    }
+  }
+  return next
+}
+
+func (g *Dense) Population() int {
+  total := 0
+  for _, c := range g.cells {
+    if c != 0 {
+      total++
+    }
+  }
+  return total
+}
+
+func (g *Dense) Bounds() (grid.Point, grid.Point) {
+  return grid.Point{}, grid.Point{X: g.w - 1, Y: g.h - 1}
+}
+
+// A Sparse grid stores only non-empty cells, so patterns can grow without
+// limit and large empty areas cost nothing. It needs a rule under which an
+// empty cell with no live neighbors stays empty; see CheckSparse.
+type Sparse struct {
+  cells map[grid.Point]uint8
+  edges Edges
+  w, h  int // size when not unbounded
+}
+
+func NewSparse(w, h int, e Edges) *Sparse {
+  return &Sparse{cells: map[grid.Point]uint8{}, edges: e, w: w, h: h}
+}
+
+func (g *Sparse) wrap(p grid.Point) (grid.Point, bool) {
+  switch g.edges {
+  case Toroidal:
+    return grid.Point{X: (p.X%g.w + g.w) % g.w, Y: (p.Y%g.h + g.h) % g.h}, true
+  case Bounded:
+    return p, p.X >= 0 && p.Y >= 0 && p.X < g.w && p.Y < g.h
+  }
+  return p, true
+}
+
+func (g *Sparse) Get(x, y int) uint8 {
+  p, ok := g.wrap(grid.Point{X: x, Y: y})
+  if !ok {
+    return 0
+  }
+  return g.cells[p]
+}
+
+func (g *Sparse) Set(x, y int, s uint8) {
+  p, ok := g.wrap(grid.Point{X: x, Y: y})
+  if !ok {
+    return
+  }
+  if s == 0 {
+    delete(g.cells, p)
+  } else {
+    g.cells[p] = s
+  }
+}
+
+// Only occupied cells and their neighbors can change, so those are the
+// only candidates examined.
+func (g *Sparse) Step(r Rule, nb Neighborhood) Grid {
+  next := NewSparse(g.w, g.h, g.edges)
+  candidates := map[grid.Point]bool{}
+  for p := range g.cells {
+    candidates[p] = true
+    for _, d := range nb {
+      // Neighborhoods are symmetric, so the cells that see p are p - d.
+      if q, ok := g.wrap(p.Sub(d)); ok {
+        candidates[q] = true
+      }
+    }
+  }
+  var n Counts
+  for p := range candidates {
+    n = Counts{}
+    for _, d := range nb {
+      n[g.Get(p.X+d.X, p.Y+d.Y)]++
+    }
+    if s := r.Next(g.cells[p], &n); s != 0 {
+      next.cells[p] = s
+    }
+  }
+  return next
+}
+
+func (g *Sparse) Population() int {
+  return len(g.cells)
+}
+
+func (g *Sparse) Bounds() (grid.Point, grid.Point) {
+  if g.edges != Unbounded {
+    return grid.Point{}, grid.Point{X: g.w - 1, Y: g.h - 1}
+  }
+  if len(g.cells) == 0 {
+    return grid.Point{}, grid.Point{}
+  }
+  first := true
+  var lo, hi grid.Point
+  for p := range g.cells {
+    if first {
+      lo, hi, first = p, p, false
+      continue
+    }
+    lo = grid.Point{X: min(lo.X, p.X), Y: min(lo.Y, p.Y)}
+    hi = grid.Point{X: max(hi.X, p.X), Y: max(hi.Y, p.Y)}
+  }
+  return lo, hi
+}
+
+// CheckSparse reports whether a rule leaves empty space empty, which
+// sparse grids rely on.
+func CheckSparse(r Rule, nb Neighborhood) error {
+  var n Counts
+  n[0] = len(nb)
+  if r.Next(0, &n) != 0 {
+    return errors.New("the sparse backend cannot run a rule in which empty cells with no neighbors come alive")
+  }
+  return nil
+}
@@ -0,0 +1,183 @@
+package automaton
+
+import (
+  "maps"
+  "strings"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/grid"
+)
+
+// Set the cells of a picture onto g with its top left at (x, y), each
+// character standing for the state at its index in states.
+func draw(g Grid, x, y int, picture, states string) Grid {
+  for dy, line := range strings.Split(strings.TrimSpace(picture), "\n") {
+    for dx, c := range strings.TrimSpace(line) {
+      g.Set(x+dx, y+dy, uint8(strings.IndexRune(states, c)))
+    }
+  }
+  return g
+}
+
+// The non-empty cells of g within its bounds.
+func cells(g Grid) map[grid.Point]uint8 {
+  lo, hi := g.Bounds()
+  m := map[grid.Point]uint8{}
+  for y := lo.Y; y <= hi.Y; y++ {
+    for x := lo.X; x <= hi.X; x++ {
+      if s := g.Get(x, y); s != 0 {
+        m[grid.Point{X: x, Y: y}] = s
+      }
+    }
+  }
+  return m
+}
+
+func shifted(m map[grid.Point]uint8, d grid.Point) map[grid.Point]uint8 {
+  out := map[grid.Point]uint8{}
+  for p, s := range m {
+    out[p.Add(d)] = s
+  }
+  return out
+}
+
+// Every backend and edge it supports, on a grid big enough for the
+// patterns to move about in.
+func backends() map[string]func() Grid {
+  return map[string]func() Grid{
+    "dense bounded":    func() Grid { return NewDense(12, 12, Bounded) },
+    "dense toroidal":   func() Grid { return NewDense(12, 12, Toroidal) },
+    "sparse bounded":   func() Grid { return NewSparse(12, 12, Bounded) },
+    "sparse toroidal":  func() Grid { return NewSparse(12, 12, Toroidal) },
+    "sparse unbounded": func() Grid { return NewSparse(0, 0, Unbounded) },
+  }
+}
+
+// Oscillators come back after their period, and not before; spaceships
+// come back moved.
+func TestPeriods(t *testing.T) {
+  life, err := ParseLifeRule("B3/S23")
+  if err != nil {
+    t.Fatal(err)
+  }
+  for _, c := range []struct {
+    name, picture string
+    period        int
+    moves         grid.Point
+  }{
+    {"blinker", "OOO", 2, grid.Point{}},
+    {"toad", ".OOO\nOOO.", 2, grid.Point{}},
+    {"block", "OO\nOO", 1, grid.Point{}},
+    {"glider", ".O.\n..O\nOOO", 4, grid.Point{X: 1, Y: 1}},
+    {"lwss", ".O..O\nO....\nO...O\nOOOO.", 4, grid.Point{X: -2}},
+  } {
+    for name, empty := range backends() {
+      g := draw(empty(), 4, 4, c.picture, ".O")
+      start := cells(g)
+      for gen := 1; gen <= c.period; gen++ {
+        g = g.Step(life, Moore(1))
+        if gen < c.period && maps.Equal(cells(g), start) {
+          t.Errorf("%s on %s: back at generation %d", c.name, name, gen)
+        }
+      }
+      if got, want := cells(g), shifted(start, c.moves); !maps.Equal(got, want) {
+        t.Errorf("%s on %s: after %d generations got %v, want %v", c.name, name, c.period, got, want)
+      }
+    }
+  }
+}
+
+// A glider on a torus comes back to where it started after going all the
+// way round.
+func TestToroidalWrap(t *testing.T) {
+  life, _ := ParseLifeRule("B3/S23")
+  for _, empty := range []Grid{NewDense(8, 8, Toroidal), NewSparse(8, 8, Toroidal)} {
+    g := draw(empty, 0, 0, ".O.\n..O\nOOO", ".O")
+    start := cells(g)
+    for range 8 * 4 {
+      g = g.Step(life, Moore(1))
+    }
+    if got := cells(g); !maps.Equal(got, start) {
+      t.Errorf("%T: got %v, want %v", g, got, start)
+    }
+  }
+}
+
+// The example from 2018 day 18: after ten minutes, 37 acres are wooded
+// and 31 are lumberyards.
+func TestLumber(t *testing.T) {
+  area := `
+    .#.#...|#.
+    .....#|##|
+    .|..|...#.
+    ..|#.....#
+    #.#|||#|#|
+    ...#.||...
+    .|....|...
+    ||...#|.#|
+    |.||||..|.
+    ...#.|..|.`
+  g := draw(NewDense(10, 10, Bounded), 0, 0, area, ".|#")
+  for range 10 {
+    g = g.Step(Lumber{}, Moore(1))
+  }
+  var n Counts
+  for _, s := range cells(g) {
+    n[s]++
+  }
+  if n[Trees] != 37 || n[Lumberyard] != 31 {
+    t.Errorf("got %d wooded and %d lumberyards, want 37 and 31", n[Trees], n[Lumberyard])
+  }
+}
+
+func TestParseLifeRule(t *testing.T) {
+  highLife, _ := ParseLifeRule("B36/S23")
+  for _, s := range []string{"b36/s23", "S23/B36", "23/36"} {
+    r, err := ParseLifeRule(s)
+    if err != nil || *r != *highLife {
+      t.Errorf("%s: got %v, %v, want B36/S23", s, r, err)
+    }
+  }
+  for _, s := range []string{"", "B3", "B3/S2/S3", "B3/", "B3/X23", "B3a/S23"} {
+    if _, err := ParseLifeRule(s); err == nil {
+      t.Errorf("%q parsed", s)
+    }
+  }
+}
+
+func TestNeighborhoods(t *testing.T) {
+  for _, c := range []struct {
+    name string
+    size int
+  }{
+    {"moore", 8},
+    {"moore:2", 24},
+    {"vn", 4},
+    {"VonNeumann:2", 12},
+  } {
+    nb, err := ParseNeighborhood(c.name)
+    if err != nil || len(nb) != c.size {
+      t.Errorf("%s: got %d cells, %v, want %d", c.name, len(nb), err, c.size)
+    }
+  }
+  for _, name := range []string{"hex", "moore:0", "moore:x"} {
+    if _, err := ParseNeighborhood(name); err == nil {
+      t.Errorf("%s parsed", name)
+    }
+  }
+}
+
+// Sparse grids cannot run rules that fill empty space.
+func TestCheckSparse(t *testing.T) {
+  life, _ := ParseLifeRule("B3/S23")
+  filling, _ := ParseLifeRule("B0/S23")
+  if err := CheckSparse(life, Moore(1)); err != nil {
+    t.Errorf("life: %v", err)
+  }
+  if err := CheckSparse(Lumber{}, Moore(1)); err != nil {
+    t.Errorf("lumber: %v", err)
+  }
+  if err := CheckSparse(filling, Moore(1)); err == nil {
+    t.Error("B0 passed")
+  }
+}
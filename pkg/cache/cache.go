@@ -0,0 +1,218 @@
+// Package cache holds the memoization, counting and map helpers that
+// solutions share, built to be used from many goroutines at once, as in a
+// parallel brute force.
+//
+// Everything here is safe for concurrent use. Maps are split into shards,
+// each with its own lock, so goroutines working on different keys rarely
+// contend; counters are atomic once their key exists.
+package cache
+
+import (
+  "hash/maphash"
+  "sync"
+  "sync/atomic"
+)
+
+const shardCount = 64
+
+type shard[K comparable, V any] struct {
+  mu sync.RWMutex
+  m  map[K]V
+}
+
+// Map is a sharded map safe for concurrent use.
+type Map[K comparable, V any] struct {
+  seed   maphash.Seed
+  shards [shardCount]shard[K, V]
+}
+
+func NewMap[K comparable, V any]() *Map[K, V] {
+  m := &Map[K, V]{seed: maphash.MakeSeed()}
+  for i := range m.shards {
+    m.shards[i].m = map[K]V{}
+  }
+  return m
+}
+
+func (m *Map[K, V]) shard(k K) *shard[K, V] {
+  return &m.shards[maphash.Comparable(m.seed, k)%shardCount]
+}
+
+func (m *Map[K, V]) Load(k K) (V, bool) {
+  s := m.shard(k)
+  s.mu.RLock()
+  v, ok := s.m[k]
+  s.mu.RUnlock()
+  return v, ok
+}
+
+func (m *Map[K, V]) Store(k K, v V) {
+  s := m.shard(k)
+  s.mu.Lock()
+  s.m[k] = v
+  s.mu.Unlock()
+}
+
+// LoadOrStore returns the existing value for k if there is one, and
+// otherwise stores and returns v. The bool reports whether v was loaded.
+func (m *Map[K, V]) LoadOrStore(k K, v V) (V, bool) {
+  s := m.shard(k)
+  s.mu.RLock()
+  old, ok := s.m[k]
+  s.mu.RUnlock()
+  if ok {
+    return old, true
+  }
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  if old, ok := s.m[k]; ok {
+    return old, true
+  }
+  s.m[k] = v
+  return v, false
+}
+
+func (m *Map[K, V]) Delete(k K) {
+  s := m.shard(k)
+  s.mu.Lock()
+  delete(s.m, k)
+  s.mu.Unlock()
+}
+
+// Len counts the entries. With concurrent writers the count is only a
+// snapshot, since shards are counted one at a time.
+func (m *Map[K, V]) Len() int {
+  n := 0
+  for i := range m.shards {
+    s := &m.shards[i]
+    s.mu.RLock()
+    n += len(s.m)
+    s.mu.RUnlock()
+  }
+  return n
+}
+
+// Range calls f for each entry until it returns false. Each shard is
+// copied before f sees it, so f may use the map itself, but entries
+// changed during the walk may or may not be seen.
+func (m *Map[K, V]) Range(f func(K, V) bool) {
+  for i := range m.shards {
+    s := &m.shards[i]
+    s.mu.RLock()
+    keys := make([]K, 0, len(s.m))
+    vals := make([]V, 0, len(s.m))
+    for k, v := range s.m {
+      keys = append(keys, k)
+      vals = append(vals, v)
+    }
+    s.mu.RUnlock()
+    for j := range keys {
+      if !f(keys[j], vals[j]) {
+        return
+      }
+    }
+  }
+}
+
+// Memo caches the results of a function. Each key is computed once: other
+// goroutines asking for a key that is being computed wait for it rather
+// than computing it again. The function may itself call Get for other
+// keys, which is how recursive memoization works, but a key that depends
+// on itself deadlocks. If the function panics, the panic goes on up and
+// the key is forgotten, so the goroutines waiting on it compute it again
+// rather than take a zero value.
+type Memo[K comparable, V any] struct {
+  fn      func(K) V
+  entries *Map[K, *memoEntry[V]]
+}
+
+type memoEntry[V any] struct {
+  done chan struct{}
+  ok   bool // false if the function panicked
+  v    V
+}
+
+func NewMemo[K comparable, V any](fn func(K) V) *Memo[K, V] {
+  return &Memo[K, V]{fn: fn, entries: NewMap[K, *memoEntry[V]]()}
+}
+
+// Get returns fn(k), computing it if no one has yet. A key already there
+// costs a lookup and nothing more; only a miss makes an entry.
+func (m *Memo[K, V]) Get(k K) V {
+  for {
+    e, ok := m.entries.Load(k)
+    if !ok {
+      var loaded bool
+      e, loaded = m.entries.LoadOrStore(k, &memoEntry[V]{done: make(chan struct{})})
+      if !loaded {
+        m.compute(k, e)
+      }
+    }
+    <-e.done
+    if e.ok {
+      return e.v
+    }
+  }
+}
+
+// Compute the entry for k, removing it again if the function panics.
+func (m *Memo[K, V]) compute(k K, e *memoEntry[V]) {
+  defer func() {
+    if !e.ok {
+      m.entries.Delete(k)
+    }
+    close(e.done)
+  }()
+  e.v = m.fn(k)
+  e.ok = true
+}
+
+// Len is the number of keys computed or being computed.
+func (m *Memo[K, V]) Len() int {
+  return m.entries.Len()
+}
+
+// Counter counts occurrences of keys.
+type Counter[K comparable] struct {
+  counts *Map[K, *atomic.Int64]
+}
+
+func NewCounter[K comparable]() *Counter[K] {
+  return &Counter[K]{counts: NewMap[K, *atomic.Int64]()}
+}
+
+// Add adds n to the count for k and returns the new count.
+func (c *Counter[K]) Add(k K, n int64) int64 {
+  v, ok := c.counts.Load(k)
+  if !ok {
+    v, _ = c.counts.LoadOrStore(k, new(atomic.Int64))
+  }
+  return v.Add(n)
+}
+
+func (c *Counter[K]) Get(k K) int64 {
+  if v, ok := c.counts.Load(k); ok {
+    return v.Load()
+  }
+  return 0
+}
+
+// Snapshot copies the counts into a plain map.
+func (c *Counter[K]) Snapshot() map[K]int64 {
+  out := map[K]int64{}
+  c.counts.Range(func(k K, v *atomic.Int64) bool {
+    out[k] = v.Load()
+    return true
+  })
+  return out
+}
+
+// Total sums every count.
+func (c *Counter[K]) Total() int64 {
+  var total int64
+  c.counts.Range(func(_ K, v *atomic.Int64) bool {
+    total += v.Load()
+    return true
+  })
+  return total
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+  "sync"
+  "sync/atomic"
+  "testing"
+  "time"
+)
+
+func TestMemo(t *testing.T) {
+  var calls atomic.Int64
+  var fib *Memo[int, int]
+  fib = NewMemo(func(n int) int {
+    calls.Add(1)
+    if n < 2 {
+      return n
+    }
+    return fib.Get(n-1) + fib.Get(n-2)
+  })
+  var wg sync.WaitGroup
+  for range 8 {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      if got := fib.Get(80); got != 23416728348467685 {
+        t.Errorf("fib(80) = %d", got)
+      }
+    }()
+  }
+  wg.Wait()
+  if calls.Load() != 81 || fib.Len() != 81 {
+    t.Errorf("%d calls for %d keys, want 81 of each", calls.Load(), fib.Len())
+  }
+}
+
+// Getting a key already computed allocates nothing.
+func TestMemoHitAllocs(t *testing.T) {
+  m := NewMemo(func(k int) [4]int { return [4]int{k} })
+  m.Get(1)
+  if allocs := testing.AllocsPerRun(100, func() { m.Get(1) }); allocs != 0 {
+    t.Errorf("a hit allocates %.1f times", allocs)
+  }
+}
+
+// A panic reaches the caller and leaves the key to be computed again,
+// both by later callers and by those that were waiting for it.
+func TestMemoPanic(t *testing.T) {
+  var calls atomic.Int64
+  started, release := make(chan struct{}), make(chan struct{})
+  m := NewMemo(func(k string) int {
+    if calls.Add(1) == 1 {
+      close(started)
+      <-release
+      panic("first call fails")
+    }
+    return len(k)
+  })
+
+  panicked := make(chan any)
+  go func() {
+    defer func() { panicked <- recover() }()
+    m.Get("abc")
+  }()
+  <-started
+  waiter := make(chan int)
+  go func() { waiter <- m.Get("abc") }()
+  time.Sleep(10 * time.Millisecond) // let the waiter block on the entry
+  close(release)
+
+  if p := <-panicked; p != "first call fails" {
+    t.Fatalf("recovered %v, want the function's panic", p)
+  }
+  if got := <-waiter; got != 3 {
+    t.Errorf("waiter got %d, want 3", got)
+  }
+  if got := m.Get("abc"); got != 3 {
+    t.Errorf("Get after the panic = %d, want 3", got)
+  }
+  if calls.Load() != 2 {
+    t.Errorf("%d calls, want 2", calls.Load())
+  }
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+  _ "embed"
+  "flag"
+  "fmt"
+  "os"
+  "path/filepath"
+  "text/template"
+)
+
+// The template is a complete single-file solution with the fast I/O code
+// inlined, because judges such as Codeforces take one file per submission.
+//
+//go:embed template.go.tmpl
+var source string
+
+// Write a new solution skeleton for each named problem, e.g.
+// "cpgen -multi 1850A" creates 1850A/main.go.
+func main() {
+  multi := flag.Bool("multi", false, "read a test case count first and solve each case")
+  force := flag.Bool("f", false, "overwrite existing files")
+  flag.Parse()
+  if flag.NArg() == 0 {
+    fmt.Fprintln(os.Stderr, "usage: cpgen [-multi] [-f] problem...")
+    os.Exit(2)
+  }
+  tmpl := template.Must(template.New("solution").Parse(source))
+  for _, name := range flag.Args() {
+    path := filepath.Join(name, "main.go")
+    if _, err := os.Stat(path); err == nil && !*force {
+      fmt.Fprintf(os.Stderr, "cpgen: %s exists; use -f to overwrite\n", path)
+      os.Exit(1)
+    }
+    if err := os.MkdirAll(name, 0o755); err != nil {
+      fmt.Fprintln(os.Stderr, "cpgen:", err)
+      os.Exit(1)
+    }
+    f, err := os.Create(path)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "cpgen:", err)
+      os.Exit(1)
+    }
+    err = tmpl.Execute(f, struct {
+      Name  string
+      Multi bool
+    }{filepath.Base(name), *multi})
+    if cerr := f.Close(); err == nil {
+      err = cerr
+    }
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "cpgen:", err)
+      os.Exit(1)
+    }
+    fmt.Println("created", path)
+  }
+}
@@ -0,0 +1,292 @@
+// Package cpio is fast input and output for competitive programming, where
+// inputs run to millions of numbers and fmt.Scan is far too slow.
+//
+// The Reader parses straight out of its buffer without allocating; values
+// returned as []byte point into that buffer and are only valid until the
+// next read. The Writer formats numbers with strconv.Append* into its own
+// buffer. Remember to Flush the Writer before exiting.
+package cpio
+
+import (
+  "io"
+  "strconv"
+)
+
+const bufferSize = 1 << 16
+
+// Reader reads whitespace-separated tokens and lines.
+type Reader struct {
+  r   io.Reader
+  buf []byte
+  pos int
+  end int
+  err error
+}
+
+func NewReader(r io.Reader) *Reader {
+  return &Reader{r: r, buf: make([]byte, bufferSize)}
+}
+
+// Refill the buffer, keeping the unread bytes from keep onwards. The buffer
+// doubles when keep is at its start and it is already full, which only
+// happens for a token or line longer than the buffer.
+func (r *Reader) fill(keep int) bool {
+  if r.err != nil {
+    return false
+  }
+  if keep == 0 && r.end == len(r.buf) {
+    bigger := make([]byte, 2*len(r.buf))
+    copy(bigger, r.buf[:r.end])
+    r.buf = bigger
+  } else if keep > 0 {
+    copy(r.buf, r.buf[keep:r.end])
+    r.pos -= keep
+    r.end -= keep
+  }
+  n, err := r.r.Read(r.buf[r.end:])
+  r.end += n
+  if err != nil {
+    r.err = err
+  }
+  return n > 0 || err == nil
+}
+
+func isSpace(c byte) bool {
+  return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\v' || c == '\f'
+}
+
+// Skip whitespace, reporting false at end of input.
+func (r *Reader) skipSpace() bool {
+  for {
+    for r.pos < r.end {
+      if !isSpace(r.buf[r.pos]) {
+        return true
+      }
+      r.pos++
+    }
+    if !r.fill(r.pos) {
+      return false
+    }
+  }
+}
+
+// Token returns the next whitespace-separated token, or nil at end of
+// input. The slice is only valid until the next call.
+func (r *Reader) Token() []byte {
+  if !r.skipSpace() {
+    return nil
+  }
+  start := r.pos
+  for {
+    for r.pos < r.end {
+      if isSpace(r.buf[r.pos]) {
+        return r.buf[start:r.pos]
+      }
+      r.pos++
+    }
+    // The token reaches the end of the buffer; move it to the front.
+    offset := r.pos - start
+    if !r.fill(start) {
+      return r.buf[r.pos-offset : r.pos]
+    }
+    start = r.pos - offset
+  }
+}
+
+// ReadInt parses the next token as a decimal integer. It panics on
+// malformed input, a value that does not fit an int, or end of input,
+// which for a judged problem is a bug.
+func (r *Reader) ReadInt() int {
+  return int(r.readInt(strconv.IntSize))
+}
+
+// Parse the next token as a decimal integer of the given bit size.
+func (r *Reader) readInt(bits int) int64 {
+  tok := r.Token()
+  if len(tok) == 0 {
+    panic("cpio: unexpected end of input")
+  }
+  neg := tok[0] == '-'
+  if neg || tok[0] == '+' {
+    tok = tok[1:]
+  }
+  if len(tok) == 0 {
+    panic("cpio: bad integer")
+  }
+  // Accumulate the magnitude, which for the most negative value is one
+  // more than the largest positive one.
+  limit := uint64(1)<<(bits-1) - 1
+  if neg {
+    limit++
+  }
+  var n uint64
+  for _, c := range tok {
+    if c < '0' || c > '9' {
+      panic("cpio: bad integer")
+    }
+    d := uint64(c - '0')
+    if n > (limit-d)/10 {
+      panic("cpio: integer out of range")
+    }
+    n = n*10 + d
+  }
+  if neg {
+    return -int64(n)
+  }
+  return int64(n)
+}
+
+// ReadInts reads n integers, appending them to dst so a slice can be reused
+// across test cases.
+func (r *Reader) ReadInts(dst []int, n int) []int {
+  for i := 0; i < n; i++ {
+    dst = append(dst, r.ReadInt())
+  }
+  return dst
+}
+
+// ReadInt64 is ReadInt for 64-bit values on any platform.
+func (r *Reader) ReadInt64() int64 {
+  return r.readInt(64)
+}
+
+// ReadFloat parses the next token as a float64.
+func (r *Reader) ReadFloat() float64 {
+  f, err := strconv.ParseFloat(string(r.Token()), 64)
+  if err != nil {
+    panic("cpio: " + err.Error())
+  }
+  return f
+}
+
+// ReadString returns the next token as a string; this one allocates.
+func (r *Reader) ReadString() string {
+  return string(r.Token())
+}
+
+// ReadLine returns the rest of the current line without its line ending,
+// and false at end of input. The slice is only valid until the next call.
+func (r *Reader) ReadLine() ([]byte, bool) {
+  start := r.pos
+  for {
+    for r.pos < r.end {
+      if r.buf[r.pos] == '\n' {
+        line := r.buf[start:r.pos]
+        r.pos++
+        if len(line) > 0 && line[len(line)-1] == '\r' {
+          line = line[:len(line)-1]
+        }
+        return line, true
+      }
+      r.pos++
+    }
+    offset := r.pos - start
+    if !r.fill(start) {
+      line := r.buf[r.pos-offset : r.pos]
+      if len(line) > 0 && line[len(line)-1] == '\r' {
+        line = line[:len(line)-1]
+      }
+      return line, offset > 0
+    }
+    start = r.pos - offset
+  }
+}
+
+// Writer buffers output, formatting numbers without fmt.
+type Writer struct {
+  w   io.Writer
+  buf []byte
+  err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+  return &Writer{w: w, buf: make([]byte, 0, bufferSize)}
+}
+
+func (w *Writer) flushIfFull() {
+  if len(w.buf) >= bufferSize {
+    w.Flush()
+  }
+}
+
+// Flush writes out the buffer, returning the first write error seen.
+func (w *Writer) Flush() error {
+  if w.err == nil && len(w.buf) > 0 {
+    _, w.err = w.w.Write(w.buf)
+  }
+  w.buf = w.buf[:0]
+  return w.err
+}
+
+func (w *Writer) WriteInt(n int) {
+  w.buf = strconv.AppendInt(w.buf, int64(n), 10)
+  w.flushIfFull()
+}
+
+func (w *Writer) WriteInt64(n int64) {
+  w.buf = strconv.AppendInt(w.buf, n, 10)
+  w.flushIfFull()
+}
+
+// WriteFloat writes f with prec digits after the decimal point.
+func (w *Writer) WriteFloat(f float64, prec int) {
+  w.buf = strconv.AppendFloat(w.buf, f, 'f', prec, 64)
+  w.flushIfFull()
+}
+
+func (w *Writer) WriteByte(c byte) error {
+  w.buf = append(w.buf, c)
+  w.flushIfFull()
+  return w.err
+}
+
+func (w *Writer) WriteString(s string) (int, error) {
+  w.buf = append(w.buf, s...)
+  w.flushIfFull()
+  return len(s), w.err
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+  w.buf = append(w.buf, p...)
+  w.flushIfFull()
+  return len(p), w.err
+}
+
+// WriteInts writes the numbers separated by single spaces and ends the line.
+func (w *Writer) WriteInts(nums []int) {
+  for i, n := range nums {
+    if i > 0 {
+      w.buf = append(w.buf, ' ')
+    }
+    w.buf = strconv.AppendInt(w.buf, int64(n), 10)
+    w.flushIfFull()
+  }
+  w.buf = append(w.buf, '\n')
+  w.flushIfFull()
+}
+
+// Println writes the int, string or []byte arguments separated by spaces,
+// then a newline. Other types are not supported, to keep fmt out.
+func (w *Writer) Println(args ...any) {
+  for i, arg := range args {
+    if i > 0 {
+      w.buf = append(w.buf, ' ')
+    }
+    switch v := arg.(type) {
+    case int:
+      w.buf = strconv.AppendInt(w.buf, int64(v), 10)
+    case int64:
+      w.buf = strconv.AppendInt(w.buf, v, 10)
+    case string:
+      w.buf = append(w.buf, v...)
+    case []byte:
+      w.buf = append(w.buf, v...)
+    case bool:
+      w.buf = strconv.AppendBool(w.buf, v)
+    default:
+      panic("cpio: Println does not support this type")
+    }
+  }
+  w.buf = append(w.buf, '\n')
+  w.flushIfFull()
+}
@@ -0,0 +1,204 @@
+package cpio
+
+import (
+  "bufio"
+  "bytes"
+  "fmt"
+  "math"
+  "strconv"
+  "strings"
+  "testing"
+)
+
+func TestReadInt(t *testing.T) {
+  largest, smallest := strconv.Itoa(math.MaxInt), strconv.Itoa(math.MinInt)
+  r := NewReader(strings.NewReader("0 42 -7 +13 007 \n\t-0 " + largest + " " + smallest))
+  for _, want := range []int{0, 42, -7, 13, 7, 0, math.MaxInt, math.MinInt} {
+    if got := r.ReadInt(); got != want {
+      t.Errorf("got %d, want %d", got, want)
+    }
+  }
+}
+
+func TestReadInt64(t *testing.T) {
+  r := NewReader(strings.NewReader("9223372036854775807 -9223372036854775808"))
+  if got := r.ReadInt64(); got != math.MaxInt64 {
+    t.Errorf("got %d, want %d", got, int64(math.MaxInt64))
+  }
+  if got := r.ReadInt64(); got != math.MinInt64 {
+    t.Errorf("got %d, want %d", got, int64(math.MinInt64))
+  }
+}
+
+// Malformed tokens panic rather than read as some other number.
+func TestReadIntPanics(t *testing.T) {
+  tooBig := strconv.FormatUint(math.MaxInt+1, 10)
+  for _, c := range []struct{ input, want string }{
+    {"", "cpio: unexpected end of input"},
+    {"   \n", "cpio: unexpected end of input"},
+    {"-", "cpio: bad integer"},
+    {"+", "cpio: bad integer"},
+    {"--1", "cpio: bad integer"},
+    {"1x", "cpio: bad integer"},
+    {"1.5", "cpio: bad integer"},
+    {tooBig, "cpio: integer out of range"},
+    {"-" + strconv.FormatUint(math.MaxInt+2, 10), "cpio: integer out of range"},
+    {"99999999999999999999999", "cpio: integer out of range"},
+  } {
+    got := func() (msg any) {
+      defer func() { msg = recover() }()
+      NewReader(strings.NewReader(c.input)).ReadInt()
+      return nil
+    }()
+    if got != c.want {
+      t.Errorf("%q: got panic %v, want %q", c.input, got, c.want)
+    }
+  }
+}
+
+// Tokens that straddle a buffer refill, and one longer than the buffer,
+// come back whole.
+func TestTokenAcrossRefills(t *testing.T) {
+  var input strings.Builder
+  var want []int
+  for i := 0; input.Len() < 3*bufferSize; i++ {
+    n := i * 7919
+    fmt.Fprintf(&input, "%d ", n)
+    want = append(want, n)
+  }
+  long := strings.Repeat("z", 2*bufferSize+1)
+  input.WriteString(long)
+  r := NewReader(&oneByteAtATime{strings.NewReader(input.String())})
+  for _, n := range want {
+    if got := r.ReadInt(); got != n {
+      t.Fatalf("got %d, want %d", got, n)
+    }
+  }
+  if got := r.ReadString(); got != long {
+    t.Errorf("long token has length %d, want %d", len(got), len(long))
+  }
+  if tok := r.Token(); tok != nil {
+    t.Errorf("got %q after the last token", tok)
+  }
+}
+
+type oneByteAtATime struct{ r *strings.Reader }
+
+func (o *oneByteAtATime) Read(p []byte) (int, error) {
+  if len(p) > 1 {
+    p = p[:1]
+  }
+  return o.r.Read(p)
+}
+
+func TestReadLine(t *testing.T) {
+  r := NewReader(strings.NewReader("one two\r\n\nlast"))
+  for _, want := range []string{"one two", "", "last"} {
+    line, ok := r.ReadLine()
+    if !ok || string(line) != want {
+      t.Errorf("got %q, %v, want %q", line, ok, want)
+    }
+  }
+  if line, ok := r.ReadLine(); ok {
+    t.Errorf("got %q at end of input", line)
+  }
+}
+
+func TestWriter(t *testing.T) {
+  var out bytes.Buffer
+  w := NewWriter(&out)
+  w.WriteInts([]int{1, -2, 3})
+  w.Println("x", 4, int64(-5), []byte("y"), true)
+  w.WriteFloat(math.Pi, 3)
+  w.WriteByte('\n')
+  if err := w.Flush(); err != nil {
+    t.Fatal(err)
+  }
+  want := "1 -2 3\nx 4 -5 y true\n3.142\n"
+  if out.String() != want {
+    t.Errorf("got %q, want %q", out.String(), want)
+  }
+}
+
+// Reading numbers allocates nothing once the buffer has grown to fit.
+func TestReadIntAllocs(t *testing.T) {
+  input := numbers(1000)
+  r := NewReader(bytes.NewReader(input))
+  allocs := testing.AllocsPerRun(100, func() {
+    r.ReadInt()
+    r.ReadInt64()
+  })
+  if allocs != 0 {
+    t.Errorf("ReadInt allocates %.2f times per call", allocs)
+  }
+}
+
+// n numbers of mixed sign and size, one per line.
+func numbers(n int) []byte {
+  var b []byte
+  x := uint64(1)
+  for i := 0; i < n; i++ {
+    x = x*6364136223846793005 + 1442695040888963407
+    v := int64(x>>1) >> (x % 60)
+    b = strconv.AppendInt(b, v, 10)
+    b = append(b, '\n')
+  }
+  return b
+}
+
+// An endless input, so that a benchmark can keep one reader.
+type repeat struct {
+  data []byte
+  pos  int
+}
+
+func (r *repeat) Read(p []byte) (int, error) {
+  n := copy(p, r.data[r.pos:])
+  r.pos = (r.pos + n) % len(r.data)
+  return n, nil
+}
+
+func BenchmarkReadInt(b *testing.B) {
+  const n = 1 << 16
+  input := numbers(n)
+  r := NewReader(&repeat{data: input})
+  b.SetBytes(int64(len(input)))
+  b.ReportAllocs()
+  for b.Loop() {
+    for i := 0; i < n; i++ {
+      r.ReadInt()
+    }
+  }
+}
+
+// What ReadInt replaces.
+func BenchmarkScanner(b *testing.B) {
+  const n = 1 << 16
+  input := numbers(n)
+  s := bufio.NewScanner(&repeat{data: input})
+  s.Split(bufio.ScanWords)
+  b.SetBytes(int64(len(input)))
+  b.ReportAllocs()
+  for b.Loop() {
+    for i := 0; i < n; i++ {
+      s.Scan()
+      if _, err := strconv.Atoi(s.Text()); err != nil {
+        b.Fatal(err)
+      }
+    }
+  }
+}
+
+func BenchmarkWriteInt(b *testing.B) {
+  var out bytes.Buffer
+  w := NewWriter(&out)
+  b.ReportAllocs()
+  for b.Loop() {
+    out.Reset()
+    for i := -1 << 15; i < 1<<15; i++ {
+      w.WriteInt(i * 7919)
+      w.WriteByte('\n')
+    }
+    w.Flush()
+  }
+}
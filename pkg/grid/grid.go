@@ -0,0 +1,77 @@
+// Package grid holds points on the plane, grids of cells indexed by them,
+// and prefix sums over grids of numbers, for the many puzzles played out
+// on a map.
+//
+// X grows to the right and Y downward, as a puzzle's input is read, so
+// row order is Y then X and Up is a negative Y.
+package grid
+
+import "fmt"
+
+// Point is a position on the plane, or a step across it.
+type Point struct {
+  X, Y int
+}
+
+// The four steps to a neighbouring cell, clockwise from Up, and the eight
+// that include the diagonals.
+var (
+  Up    = Point{0, -1}
+  Right = Point{1, 0}
+  Down  = Point{0, 1}
+  Left  = Point{-1, 0}
+
+  Dirs4 = []Point{Up, Right, Down, Left}
+  Dirs8 = []Point{Up, {1, -1}, Right, {1, 1}, Down, {-1, 1}, Left, {-1, -1}}
+)
+
+func (p Point) String() string {
+  return fmt.Sprintf("(%d,%d)", p.X, p.Y)
+}
+
+func (p Point) Add(q Point) Point { return Point{p.X + q.X, p.Y + q.Y} }
+func (p Point) Sub(q Point) Point { return Point{p.X - q.X, p.Y - q.Y} }
+func (p Point) Mul(k int) Point   { return Point{p.X * k, p.Y * k} }
+
+// Manhattan is the taxicab distance from p to q.
+func (p Point) Manhattan(q Point) int {
+  return abs(p.X-q.X) + abs(p.Y-q.Y)
+}
+
+// Less orders points by row, then by column.
+func (p Point) Less(q Point) bool {
+  if p.Y != q.Y {
+    return p.Y < q.Y
+  }
+  return p.X < q.X
+}
+
+func abs(n int) int {
+  if n < 0 {
+    return -n
+  }
+  return n
+}
+
+// Rect is the rectangle from Min to Max, both included.
+type Rect struct {
+  Min, Max Point
+}
+
+func (r Rect) String() string {
+  return fmt.Sprintf("%v-%v", r.Min, r.Max)
+}
+
+func (r Rect) Width() int  { return r.Max.X - r.Min.X + 1 }
+func (r Rect) Height() int { return r.Max.Y - r.Min.Y + 1 }
+
+func (r Rect) Contains(p Point) bool {
+  return r.Min.X <= p.X && p.X <= r.Max.X && r.Min.Y <= p.Y && p.Y <= r.Max.Y
+}
+
+// Grow returns the smallest rectangle holding both r and p.
+func (r Rect) Grow(p Point) Rect {
+  r.Min.X, r.Min.Y = min(r.Min.X, p.X), min(r.Min.Y, p.Y)
+  r.Max.X, r.Max.Y = max(r.Max.X, p.X), max(r.Max.Y, p.Y)
+  return r
+}
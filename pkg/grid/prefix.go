@@ -0,0 +1,79 @@
+package grid
+
+// Prefix sums answer the sum of any run or rectangle of numbers in
+// constant time, after a single pass to build them: the largest total
+// power of any square on 2018's fuel cell grid is a few million lookups,
+// not a few billion additions.
+
+// PrefixSums returns the running totals of xs, with a 0 first, so that
+// the sum of xs[i:j] is p[j] - p[i].
+func PrefixSums(xs []int) []int {
+  p := make([]int, len(xs)+1)
+  for i, x := range xs {
+    p[i+1] = p[i] + x
+  }
+  return p
+}
+
+// RangeSum is the sum of xs[i:j], given p = PrefixSums(xs).
+func RangeSum(p []int, i, j int) int {
+  return p[j] - p[i]
+}
+
+// SumTable is a summed-area table over a w by h grid of numbers with its
+// top left at (0, 0).
+type SumTable struct {
+  w, h int
+  // s[y*(w+1)+x] is the sum of the cells above and left of (x, y),
+  // with a row and column of zeros first so no edge needs a check.
+  s []int
+}
+
+// NewSumTable builds the table of rows of numbers, which must all be the
+// same length.
+func NewSumTable(rows [][]int) *SumTable {
+  w := 0
+  if len(rows) > 0 {
+    w = len(rows[0])
+  }
+  return NewSumTableFunc(w, len(rows), func(p Point) int {
+    return rows[p.Y][p.X]
+  })
+}
+
+// NewSumTableFunc builds the table of a w by h grid whose cells are
+// given by cell, for a grid computed rather than read, like 2018's power
+// levels.
+func NewSumTableFunc(w, h int, cell func(Point) int) *SumTable {
+  t := &SumTable{w: w, h: h, s: make([]int, (w+1)*(h+1))}
+  for y := 0; y < h; y++ {
+    row := 0
+    for x := 0; x < w; x++ {
+      row += cell(Point{x, y})
+      t.s[(y+1)*(w+1)+x+1] = t.s[y*(w+1)+x+1] + row
+    }
+  }
+  return t
+}
+
+// Bounds is the rectangle of the grid the table covers.
+func (t *SumTable) Bounds() Rect {
+  return Rect{Point{0, 0}, Point{t.w - 1, t.h - 1}}
+}
+
+// Sum is the total of the cells in r, both corners included. The part of
+// r outside the grid counts as zero.
+func (t *SumTable) Sum(r Rect) int {
+  x0, y0 := max(r.Min.X, 0), max(r.Min.Y, 0)
+  x1, y1 := min(r.Max.X, t.w-1)+1, min(r.Max.Y, t.h-1)+1
+  if x0 >= x1 || y0 >= y1 {
+    return 0
+  }
+  w := t.w + 1
+  return t.s[y1*w+x1] - t.s[y0*w+x1] - t.s[y1*w+x0] + t.s[y0*w+x0]
+}
+
+// Square is the total of the size by size square with its top left at p.
+func (t *SumTable) Square(p Point, size int) int {
+  return t.Sum(Rect{p, p.Add(Point{size - 1, size - 1})})
+}
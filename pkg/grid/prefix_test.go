@@ -0,0 +1,64 @@
+package grid
+
+import (
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/prop"
+)
+
+// Any rectangle's sum from the table matches adding its cells one by one,
+// including rectangles partly or wholly off the grid.
+func TestSumTable(t *testing.T) {
+  g := prop.PairOf(prop.Grid(12, 12, prop.Int(-50, 50)),
+    prop.PairOf(prop.PairOf(prop.Int(-3, 15), prop.Int(-3, 15)), prop.PairOf(prop.Int(-3, 15), prop.Int(-3, 15))))
+  prop.Check(t, prop.Config{}, g, func(p prop.Pair[[][]int, prop.Pair[prop.Pair[int, int], prop.Pair[int, int]]]) bool {
+    rows := p.A
+    r := Rect{Point{p.B.A.A, p.B.A.B}, Point{p.B.B.A, p.B.B.B}}
+    want := 0
+    for y := r.Min.Y; y <= r.Max.Y; y++ {
+      for x := r.Min.X; x <= r.Max.X; x++ {
+        if y >= 0 && y < len(rows) && x >= 0 && x < len(rows[y]) {
+          want += rows[y][x]
+        }
+      }
+    }
+    return NewSumTable(rows).Sum(r) == want
+  })
+}
+
+func TestPrefixSums(t *testing.T) {
+  g := prop.PairOf(prop.SliceOf(prop.Int(-100, 100), 30), prop.PairOf(prop.Int(0, 30), prop.Int(0, 30)))
+  prop.Check(t, prop.Config{}, g, func(p prop.Pair[[]int, prop.Pair[int, int]]) bool {
+    xs := p.A
+    i, j := min(p.B.A, len(xs)), min(p.B.B, len(xs))
+    if i > j {
+      i, j = j, i
+    }
+    want := 0
+    for _, x := range xs[i:j] {
+      want += x
+    }
+    return RangeSum(PrefixSums(xs), i, j) == want
+  })
+}
+
+// The 3x3 square of most power on 2018 day 11's grid with serial 18.
+func TestPowerGrid(t *testing.T) {
+  const serial = 18
+  table := NewSumTableFunc(300, 300, func(p Point) int {
+    x, y := p.X+1, p.Y+1
+    id := x + 10
+    return (id*y+serial)*id/100%10 - 5
+  })
+  best, at := 0, Point{}
+  for y := 0; y+3 <= 300; y++ {
+    for x := 0; x+3 <= 300; x++ {
+      if s := table.Square(Point{x, y}, 3); s > best {
+        best, at = s, Point{x + 1, y + 1}
+      }
+    }
+  }
+  if best != 29 || at != (Point{33, 45}) {
+    t.Errorf("got %d at %v, want 29 at (33,45)", best, at)
+  }
+}
@@ -0,0 +1,160 @@
+package grid
+
+import (
+  "iter"
+  "maps"
+  "slices"
+  "strings"
+)
+
+// Sparse is a grid stored as a map from point to cell, for a plane with
+// no edges: a universe that expands, a floor that goes on forever, or
+// cells that only ever fill a few scattered points. Only the cells set are
+// stored, and the bounds follow them, so a grid can be printed or walked
+// without knowing its extent up front.
+//
+// The zero value is not ready for use; make one with NewSparse.
+type Sparse[T any] struct {
+  cells  map[Point]T
+  bounds Rect
+  // Deleting a cell on the edge may shrink the bounds, which are then
+  // found again when next asked for.
+  stale bool
+}
+
+func NewSparse[T any]() *Sparse[T] {
+  return &Sparse[T]{cells: map[Point]T{}}
+}
+
+// ParseSparse makes a grid from lines of a map, one cell per byte, with
+// the top left at (0, 0). A cell for which keep returns false, like the
+// '.' of empty space, is not stored.
+func ParseSparse(lines []string, keep func(byte) bool) *Sparse[byte] {
+  g := NewSparse[byte]()
+  for y, line := range lines {
+    for x := 0; x < len(line); x++ {
+      if keep == nil || keep(line[x]) {
+        g.Set(Point{x, y}, line[x])
+      }
+    }
+  }
+  return g
+}
+
+// At returns the cell at p, and whether one is set.
+func (g *Sparse[T]) At(p Point) (T, bool) {
+  v, ok := g.cells[p]
+  return v, ok
+}
+
+// Get returns the cell at p, or the zero value if none is set.
+func (g *Sparse[T]) Get(p Point) T {
+  return g.cells[p]
+}
+
+func (g *Sparse[T]) Has(p Point) bool {
+  _, ok := g.cells[p]
+  return ok
+}
+
+func (g *Sparse[T]) Set(p Point, v T) {
+  switch {
+  case len(g.cells) == 0:
+    g.bounds, g.stale = Rect{p, p}, false
+  case !g.stale:
+    g.bounds = g.bounds.Grow(p)
+  }
+  g.cells[p] = v
+}
+
+func (g *Sparse[T]) Delete(p Point) {
+  if _, ok := g.cells[p]; !ok {
+    return
+  }
+  delete(g.cells, p)
+  b := g.bounds
+  if p.X == b.Min.X || p.X == b.Max.X || p.Y == b.Min.Y || p.Y == b.Max.Y {
+    g.stale = true
+  }
+}
+
+// Len is the number of cells set.
+func (g *Sparse[T]) Len() int {
+  return len(g.cells)
+}
+
+// Bounds is the smallest rectangle holding every cell set, and false if
+// there are none.
+func (g *Sparse[T]) Bounds() (Rect, bool) {
+  if len(g.cells) == 0 {
+    return Rect{}, false
+  }
+  if g.stale {
+    first := true
+    for p := range g.cells {
+      if first {
+        g.bounds, first = Rect{p, p}, false
+      } else {
+        g.bounds = g.bounds.Grow(p)
+      }
+    }
+    g.stale = false
+  }
+  return g.bounds, true
+}
+
+// All yields every cell set, in row order. The points are sorted when
+// the loop starts, so cells may be set or deleted during it; the loop
+// sees the grid as it was then, apart from the values of deleted cells.
+func (g *Sparse[T]) All() iter.Seq2[Point, T] {
+  return func(yield func(Point, T) bool) {
+    points := make([]Point, 0, len(g.cells))
+    for p := range g.cells {
+      points = append(points, p)
+    }
+    slices.SortFunc(points, func(a, b Point) int {
+      switch {
+      case a.Less(b):
+        return -1
+      case b.Less(a):
+        return 1
+      }
+      return 0
+    })
+    for _, p := range points {
+      v, ok := g.cells[p]
+      if !ok {
+        continue
+      }
+      if !yield(p, v) {
+        return
+      }
+    }
+  }
+}
+
+// Clone returns a copy of g, for a step that reads one generation while
+// writing the next.
+func (g *Sparse[T]) Clone() *Sparse[T] {
+  c := *g
+  c.cells = maps.Clone(g.cells)
+  return &c
+}
+
+// Render draws the grid within its bounds, a line per row, with cell
+// giving each position's character; ok says whether a cell is set there.
+func (g *Sparse[T]) Render(cell func(v T, ok bool) rune) string {
+  b, ok := g.Bounds()
+  if !ok {
+    return ""
+  }
+  var s strings.Builder
+  for y := b.Min.Y; y <= b.Max.Y; y++ {
+    for x := b.Min.X; x <= b.Max.X; x++ {
+      v, ok := g.cells[Point{x, y}]
+      s.WriteRune(cell(v, ok))
+    }
+    s.WriteByte('\n')
+  }
+  return s.String()
+}
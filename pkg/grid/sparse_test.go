@@ -0,0 +1,80 @@
+package grid
+
+import (
+  "math/rand"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/prop"
+)
+
+// edit sets or deletes one point, a step of the sequences below.
+type edit struct {
+  p   Point
+  del bool
+}
+
+var edits = prop.SliceOf(prop.Gen[edit]{
+  Generate: func(r *rand.Rand, size int) edit {
+    return edit{Point{r.Intn(11) - 5, r.Intn(11) - 5}, r.Intn(3) == 0}
+  },
+}, 40)
+
+// After any run of sets and deletes, the grid holds what a plain map
+// would, and its bounds are the smallest rectangle around those points,
+// however often a delete on the edge made them stale.
+func TestSparse(t *testing.T) {
+  prop.Check(t, prop.Config{}, edits, func(es []edit) bool {
+    g, want := NewSparse[int](), map[Point]int{}
+    for i, e := range es {
+      if e.del {
+        g.Delete(e.p)
+        delete(want, e.p)
+      } else {
+        g.Set(e.p, i)
+        want[e.p] = i
+      }
+      if !sameBounds(g, want) {
+        return false
+      }
+    }
+    if g.Len() != len(want) {
+      return false
+    }
+    for p, v := range g.All() {
+      if w, ok := want[p]; !ok || w != v {
+        return false
+      }
+    }
+    return true
+  })
+}
+
+func sameBounds(g *Sparse[int], want map[Point]int) bool {
+  b, ok := g.Bounds()
+  if len(want) == 0 {
+    return !ok
+  }
+  first, r := true, Rect{}
+  for p := range want {
+    if first {
+      r, first = Rect{p, p}, false
+    } else {
+      r = r.Grow(p)
+    }
+  }
+  return ok && b == r
+}
+
+func TestSparseRender(t *testing.T) {
+  g := ParseSparse([]string{"#..", "..#", ".#."}, func(c byte) bool { return c == '#' })
+  g.Delete(Point{0, 0})
+  got := g.Render(func(v byte, ok bool) rune {
+    if ok {
+      return rune(v)
+    }
+    return '.'
+  })
+  if want := ".#\n#.\n"; got != want {
+    t.Errorf("got %q, want %q", got, want)
+  }
+}
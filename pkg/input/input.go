@@ -0,0 +1,46 @@
+// Package input reads puzzle inputs: lazily, line by line as they are
+// read, or whole, as bytes or mapped into memory when they are very large,
+// then split into lines and parsed into integers without going through
+// strings, since on inputs of a million lines strings.Fields and
+// strconv.Atoi cost more than solving the puzzle.
+//
+// Lines and fields returned as []byte, rather than string, point into the
+// data they came from.
+package input
+
+import (
+  "bytes"
+  "os"
+)
+
+// ReadFile reads a whole input.
+func ReadFile(path string) ([]byte, error) {
+  return os.ReadFile(path)
+}
+
+// SplitLines splits data into lines without their line endings, "\n" or
+// "\r\n". A final line ending does not start another, empty line.
+func SplitLines(data []byte) [][]byte {
+  lines := make([][]byte, 0, bytes.Count(data, []byte{'\n'})+1)
+  for len(data) > 0 {
+    line, rest, _ := bytes.Cut(data, []byte{'\n'})
+    lines = append(lines, bytes.TrimSuffix(line, []byte{'\r'}))
+    data = rest
+  }
+  return lines
+}
+
+// IntLines parses the integers on each line, as Ints does, for the many
+// puzzles whose input is rows of numbers. The rows are cut from one
+// growing slice rather than allocated one by one.
+func IntLines(data []byte) [][]int {
+  lines := SplitLines(data)
+  rows := make([][]int, len(lines))
+  all := make([]int, 0, len(data)/4)
+  for i, line := range lines {
+    start := len(all)
+    all = Ints(all, line)
+    rows[i] = all[start:len(all):len(all)]
+  }
+  return rows
+}
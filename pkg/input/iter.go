@@ -0,0 +1,64 @@
+package input
+
+import (
+  "fmt"
+  "io"
+  "iter"
+  "strings"
+)
+
+// Lines yields the lines of r one at a time, without their line endings,
+// so a large input is processed as it is read:
+//
+//   for line, err := range input.Lines(f) {
+//     if err != nil {
+//       return err
+//     }
+//     ...
+//   }
+//
+// Lines may be up to DefaultMaxLine long. A read error, or a longer line,
+// is yielded once with an empty line, and ends the sequence.
+func Lines(r io.Reader) iter.Seq2[string, error] {
+  return func(yield func(string, error) bool) {
+    s := NewScanner(r, 0)
+    for s.Scan() {
+      if !yield(strings.TrimSuffix(s.Text(), "\r"), nil) {
+        return
+      }
+    }
+    if err := s.Err(); err != nil {
+      yield("", fmt.Errorf("input: %w", err))
+    }
+  }
+}
+
+// Records yields each line of r split into fields by sep, or around runs
+// of whitespace if sep is empty, skipping blank lines. Errors are yielded
+// as Lines yields them:
+//
+//   for rec, err := range input.Records(f, ",") {
+//     ...
+//   }
+func Records(r io.Reader, sep string) iter.Seq2[[]string, error] {
+  return func(yield func([]string, error) bool) {
+    for line, err := range Lines(r) {
+      if err != nil {
+        yield(nil, err)
+        return
+      }
+      if strings.TrimSpace(line) == "" {
+        continue
+      }
+      var rec []string
+      if sep == "" {
+        rec = strings.Fields(line)
+      } else {
+        rec = strings.Split(line, sep)
+      }
+      if !yield(rec, nil) {
+        return
+      }
+    }
+  }
+}
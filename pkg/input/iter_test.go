@@ -0,0 +1,108 @@
+package input
+
+import (
+  "errors"
+  "io"
+  "slices"
+  "strings"
+  "testing"
+  "testing/iotest"
+)
+
+var errDisk = errors.New("disk on fire")
+
+// An input that reads as data and then fails.
+func failing(data string) io.Reader {
+  return io.MultiReader(strings.NewReader(data), iotest.ErrReader(errDisk))
+}
+
+func TestLines(t *testing.T) {
+  for _, c := range []struct {
+    name, in string
+    want     []string
+  }{
+    {"empty", "", nil},
+    {"final newline", "a\nb\n", []string{"a", "b"}},
+    {"no final newline", "a\nb", []string{"a", "b"}},
+    {"crlf", "a\r\nb\r\n", []string{"a", "b"}},
+    {"blank lines", "\n\na\n", []string{"", "", "a"}},
+  } {
+    var got []string
+    for line, err := range Lines(iotest.OneByteReader(strings.NewReader(c.in))) {
+      if err != nil {
+        t.Fatalf("%s: %v", c.name, err)
+      }
+      got = append(got, line)
+    }
+    if !slices.Equal(got, c.want) {
+      t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+    }
+  }
+}
+
+// A read error comes after the lines read before it, once, and ends the
+// sequence.
+func TestLinesError(t *testing.T) {
+  var got []string
+  var errs []error
+  for line, err := range Lines(failing("a\nb\n")) {
+    if err != nil {
+      errs = append(errs, err)
+      continue
+    }
+    got = append(got, line)
+  }
+  if !slices.Equal(got, []string{"a", "b"}) {
+    t.Errorf("got lines %q before the error", got)
+  }
+  if len(errs) != 1 || !errors.Is(errs[0], errDisk) {
+    t.Errorf("got errors %v, want %v once", errs, errDisk)
+  }
+}
+
+// Stopping early stops reading.
+func TestLinesBreak(t *testing.T) {
+  for line, err := range Lines(failing("a\nb\n")) {
+    if err != nil {
+      t.Fatal(err)
+    }
+    if line == "a" {
+      break
+    }
+  }
+}
+
+func TestRecords(t *testing.T) {
+  for _, c := range []struct {
+    name, in, sep string
+    want          [][]string
+  }{
+    {"whitespace", "1 2\n\n  3\t4  \n", "", [][]string{{"1", "2"}, {"3", "4"}}},
+    {"comma", "a,b\n \nc,,d\n", ",", [][]string{{"a", "b"}, {"c", "", "d"}}},
+    {"crlf", "a b\r\n", "", [][]string{{"a", "b"}}},
+  } {
+    var got [][]string
+    for rec, err := range Records(strings.NewReader(c.in), c.sep) {
+      if err != nil {
+        t.Fatalf("%s: %v", c.name, err)
+      }
+      got = append(got, rec)
+    }
+    if !slices.EqualFunc(got, c.want, slices.Equal) {
+      t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+    }
+  }
+
+  n := 0
+  for rec, err := range Records(failing("a b\n"), "") {
+    switch n++; {
+    case n == 1 && (err != nil || !slices.Equal(rec, []string{"a", "b"})):
+      t.Errorf("got %q, %v first", rec, err)
+    case n == 2 && (rec != nil || !errors.Is(err, errDisk)):
+      t.Errorf("got %q, %v, want %v", rec, err, errDisk)
+    }
+  }
+  if n != 2 {
+    t.Errorf("got %d records and errors, want 2", n)
+  }
+}
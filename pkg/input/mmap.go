@@ -0,0 +1,53 @@
+package input
+
+import "os"
+
+// A Mapped input is a file's contents mapped into memory where the
+// platform allows, so a very large input is scanned where it lies instead
+// of being copied onto the heap first. Elsewhere, and for empty files, it
+// is read as ReadFile would.
+//
+// The bytes are read-only, writing to them faults, and they are only valid
+// until Close.
+type Mapped struct {
+  data   []byte
+  mapped bool
+}
+
+// Map opens an input for reading through Bytes.
+func Map(path string) (*Mapped, error) {
+  f, err := os.Open(path)
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+  info, err := f.Stat()
+  if err != nil {
+    return nil, err
+  }
+  if size := info.Size(); size > 0 && int64(int(size)) == size {
+    if data, err := mmap(f, int(size)); err == nil {
+      return &Mapped{data: data, mapped: true}, nil
+    }
+  }
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return nil, err
+  }
+  return &Mapped{data: data}, nil
+}
+
+func (m *Mapped) Bytes() []byte {
+  return m.data
+}
+
+// Close unmaps the input.
+func (m *Mapped) Close() error {
+  data := m.data
+  m.data = nil
+  if m.mapped {
+    m.mapped = false
+    return munmap(data)
+  }
+  return nil
+}
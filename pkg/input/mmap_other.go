@@ -0,0 +1,17 @@
+//go:build !unix
+
+package input
+
+import (
+  "errors"
+  "os"
+)
+
+// Without mmap, Map falls back to reading the file.
+func mmap(f *os.File, size int) ([]byte, error) {
+  return nil, errors.ErrUnsupported
+}
+
+func munmap(data []byte) error {
+  return nil
+}
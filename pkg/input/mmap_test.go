@@ -0,0 +1,56 @@
+package input
+
+import (
+  "errors"
+  "io/fs"
+  "os"
+  "path/filepath"
+  "slices"
+  "testing"
+)
+
+func TestMap(t *testing.T) {
+  dir := t.TempDir()
+  for _, c := range []struct {
+    name, data string
+    want       [][]int
+  }{
+    {"empty", "", [][]int{}},
+    {"rows", "1 2 3\n-4,5\n", [][]int{{1, 2, 3}, {-4, 5}}},
+    {"no final newline", "7\r\n8", [][]int{{7}, {8}}},
+    // Malformed rows parse to whatever integers they hold, or none.
+    {"malformed", "1 2\nx y\n\n3-\n-\n99999999999999999999 6\n", [][]int{{1, 2}, {}, {}, {3}, {}, {6}}},
+  } {
+    path := filepath.Join(dir, c.name)
+    if err := os.WriteFile(path, []byte(c.data), 0o644); err != nil {
+      t.Fatal(err)
+    }
+    m, err := Map(path)
+    if err != nil {
+      t.Fatalf("%s: %v", c.name, err)
+    }
+    if got := string(m.Bytes()); got != c.data {
+      t.Errorf("%s: mapped %q, want %q", c.name, got, c.data)
+    }
+    got := IntLines(m.Bytes())
+    if !slices.EqualFunc(got, c.want, slices.Equal) {
+      t.Errorf("%s: got rows %v, want %v", c.name, got, c.want)
+    }
+    if err := m.Close(); err != nil {
+      t.Errorf("%s: close: %v", c.name, err)
+    }
+    if m.Bytes() != nil {
+      t.Errorf("%s: bytes still there after Close", c.name)
+    }
+    // A second Close does not unmap twice.
+    if err := m.Close(); err != nil {
+      t.Errorf("%s: second close: %v", c.name, err)
+    }
+  }
+}
+
+func TestMapMissing(t *testing.T) {
+  if _, err := Map(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, fs.ErrNotExist) {
+    t.Errorf("got %v, want a not-exist error", err)
+  }
+}
@@ -0,0 +1,89 @@
+package input
+
+import "errors"
+
+var (
+  ErrSyntax = errors.New("input: invalid integer")
+  ErrRange  = errors.New("input: integer out of range")
+)
+
+// ParseInt parses a decimal integer with an optional sign, like
+// strconv.Atoi but on bytes, so no string is made for it, and with plain
+// errors that cost nothing to return.
+func ParseInt(b []byte) (int, error) {
+  neg := len(b) > 0 && b[0] == '-'
+  if len(b) > 0 && (b[0] == '-' || b[0] == '+') {
+    b = b[1:]
+  }
+  if len(b) == 0 {
+    return 0, ErrSyntax
+  }
+  // Accumulate unsigned so the lowest int, which has no positive
+  // counterpart, parses too.
+  limit := uint(^uint(0) >> 1)
+  if neg {
+    limit++
+  }
+  var n uint
+  for _, c := range b {
+    if c < '0' || c > '9' {
+      return 0, ErrSyntax
+    }
+    d := uint(c - '0')
+    if n > (limit-d)/10 {
+      return 0, ErrRange
+    }
+    n = n*10 + d
+  }
+  if neg {
+    return -int(n), nil
+  }
+  return int(n), nil
+}
+
+func isSpace(c byte) bool {
+  return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\v' || c == '\f'
+}
+
+// Fields splits b around runs of whitespace, appending the fields to dst so
+// the slice can be reused from line to line. The fields point into b.
+func Fields(dst [][]byte, b []byte) [][]byte {
+  for i := 0; i < len(b); {
+    for i < len(b) && isSpace(b[i]) {
+      i++
+    }
+    start := i
+    for i < len(b) && !isSpace(b[i]) {
+      i++
+    }
+    if i > start {
+      dst = append(dst, b[start:i])
+    }
+  }
+  return dst
+}
+
+// Ints appends every integer in b to dst, skipping whatever lies between
+// them, so "p=0,4 v=3,-3" gives 0, 4, 3 and -3. A minus sign counts
+// only right before a digit, and not after a letter or digit, so ranges
+// like "3-5" give 3 and 5. Integers too big for an int are skipped.
+func Ints(dst []int, b []byte) []int {
+  for i := 0; i < len(b); {
+    c := b[i]
+    if !(c >= '0' && c <= '9') && !(c == '-' && i+1 < len(b) && b[i+1] >= '0' && b[i+1] <= '9' && !(i > 0 && isWord(b[i-1]))) {
+      i++
+      continue
+    }
+    start := i
+    for i++; i < len(b) && b[i] >= '0' && b[i] <= '9'; i++ {
+    }
+    if n, err := ParseInt(b[start:i]); err == nil {
+      dst = append(dst, n)
+    }
+  }
+  return dst
+}
+
+func isWord(c byte) bool {
+  return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
@@ -0,0 +1,95 @@
+package input
+
+import (
+  "errors"
+  "math"
+  "slices"
+  "strconv"
+  "testing"
+)
+
+func TestParseInt(t *testing.T) {
+  for _, c := range []struct {
+    in   string
+    want int
+    err  error
+  }{
+    {"0", 0, nil},
+    {"42", 42, nil},
+    {"+42", 42, nil},
+    {"-42", -42, nil},
+    {"-0", 0, nil},
+    {"007", 7, nil},
+    {strconv.Itoa(math.MaxInt), math.MaxInt, nil},
+    {strconv.Itoa(math.MinInt), math.MinInt, nil},
+    {"9223372036854775808", 0, ErrRange},
+    {"-9223372036854775809", 0, ErrRange},
+    {"99999999999999999999999", 0, ErrRange},
+    {"", 0, ErrSyntax},
+    {"-", 0, ErrSyntax},
+    {"+", 0, ErrSyntax},
+    {"--1", 0, ErrSyntax},
+    {"+-1", 0, ErrSyntax},
+    {" 1", 0, ErrSyntax},
+    {"1 ", 0, ErrSyntax},
+    {"1a", 0, ErrSyntax},
+    {"0x10", 0, ErrSyntax},
+  } {
+    got, err := ParseInt([]byte(c.in))
+    if !errors.Is(err, c.err) || got != c.want {
+      t.Errorf("%q: got %d, %v, want %d, %v", c.in, got, err, c.want, c.err)
+    }
+    // Where strconv accepts the input it agrees.
+    if want, serr := strconv.Atoi(c.in); serr == nil && (err != nil || got != want) {
+      t.Errorf("%q: got %d, %v, strconv gives %d", c.in, got, err, want)
+    }
+  }
+}
+
+func TestFields(t *testing.T) {
+  for _, c := range []struct {
+    in   string
+    want []string
+  }{
+    {"", nil},
+    {" \t\r\n", nil},
+    {"a", []string{"a"}},
+    {"  3   4  ", []string{"3", "4"}},
+    {"a\tb\vc\fd\r\n", []string{"a", "b", "c", "d"}},
+    {"-1 +2 -", []string{"-1", "+2", "-"}},
+    // Separators in a row make no empty field between them.
+    {"a  \t\t b", []string{"a", "b"}},
+  } {
+    var got []string
+    for _, f := range Fields(nil, []byte(c.in)) {
+      got = append(got, string(f))
+    }
+    if !slices.Equal(got, c.want) {
+      t.Errorf("%q: got %q, want %q", c.in, got, c.want)
+    }
+  }
+
+  // Fields appends, so a slice can be reused from line to line.
+  dst := Fields(nil, []byte("a b"))
+  if got := Fields(dst[:1], []byte("c")); len(got) != 2 || string(got[1]) != "c" {
+    t.Errorf("appending gave %q", got)
+  }
+}
+
+func TestInts(t *testing.T) {
+  for _, c := range []struct {
+    in   string
+    want []int
+  }{
+    {"", nil},
+    {"no numbers", nil},
+    {"p=0,4 v=3,-3", []int{0, 4, 3, -3}},
+    {"3-5", []int{3, 5}},
+    {"x-1 -2", []int{1, -2}},
+    {"--7", []int{-7}},
+  } {
+    if got := Ints(nil, []byte(c.in)); !slices.Equal(got, c.want) {
+      t.Errorf("%q: got %v, want %v", c.in, got, c.want)
+    }
+  }
+}
@@ -0,0 +1,56 @@
+package input
+
+import (
+  "errors"
+  "os"
+  "path/filepath"
+)
+
+// The repository root is the directory holding AdventOfCode, as aoc finds
+// it.
+const rootMarker = "AdventOfCode"
+
+// Root finds the repository root: AOC_ROOT if set, otherwise the nearest
+// directory above the working directory, or above the running program,
+// that holds AdventOfCode.
+func Root() (string, error) {
+  if root := os.Getenv("AOC_ROOT"); root != "" {
+    return root, nil
+  }
+  var starts []string
+  if wd, err := os.Getwd(); err == nil {
+    starts = append(starts, wd)
+  }
+  if exe, err := os.Executable(); err == nil {
+    starts = append(starts, filepath.Dir(exe))
+  }
+  for _, dir := range starts {
+    for {
+      if info, err := os.Stat(filepath.Join(dir, rootMarker)); err == nil && info.IsDir() {
+        return dir, nil
+      }
+      parent := filepath.Dir(dir)
+      if parent == dir {
+        break
+      }
+      dir = parent
+    }
+  }
+  return "", errors.New("input: not inside the repository; set AOC_ROOT")
+}
+
+// Path resolves a puzzle's input, given relative to the repository root
+// with forward slashes whatever the platform, as in
+// Path("AdventOfCode/2024/01.in"). Under aoc, which passes the input in
+// AOC_INPUT, it is that file instead, so a solution reads whatever input
+// it is run on.
+func Path(rel string) (string, error) {
+  if p := os.Getenv("AOC_INPUT"); p != "" {
+    return p, nil
+  }
+  root, err := Root()
+  if err != nil {
+    return "", err
+  }
+  return filepath.Join(root, filepath.FromSlash(rel)), nil
+}
@@ -0,0 +1,24 @@
+package input
+
+import (
+  "bufio"
+  "io"
+)
+
+// DefaultMaxLine is the longest line a Scanner from NewScanner accepts
+// unless told otherwise. bufio.Scanner's own limit, 64KB, is smaller than
+// some puzzle inputs that are a single line.
+const DefaultMaxLine = 256 << 20
+
+// NewScanner returns a line scanner over r that grows its buffer for lines
+// up to maxLine bytes, or DefaultMaxLine if maxLine is 0. Longer lines stop
+// the scan with bufio.ErrTooLong.
+func NewScanner(r io.Reader, maxLine int) *bufio.Scanner {
+  if maxLine <= 0 {
+    maxLine = DefaultMaxLine
+  }
+  s := bufio.NewScanner(r)
+  // The buffer holds the line ending too.
+  s.Buffer(make([]byte, 0, min(64*1024, maxLine+1)), maxLine+1)
+  return s
+}
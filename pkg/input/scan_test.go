@@ -0,0 +1,60 @@
+package input
+
+import (
+  "bufio"
+  "errors"
+  "strings"
+  "testing"
+)
+
+// A line of 2MB, past bufio.Scanner's own 64KB limit, scans whole, and one
+// byte over the limit given stops the scan with bufio.ErrTooLong.
+func TestScannerLongLines(t *testing.T) {
+  long := strings.Repeat("x", 2<<20)
+  for _, c := range []struct {
+    name    string
+    maxLine int
+    in      string
+    want    []string
+    err     error
+  }{
+    {"default limit", 0, long + "\nend\n", []string{long, "end"}, nil},
+    {"at the limit", 2 << 20, long + "\nend", []string{long, "end"}, nil},
+    {"over the limit", 2 << 20, "start\n" + long + "y\nend\n", []string{"start"}, bufio.ErrTooLong},
+    {"small limit", 4, "abc\nabcde\n", []string{"abc"}, bufio.ErrTooLong},
+  } {
+    s := NewScanner(strings.NewReader(c.in), c.maxLine)
+    var got []string
+    for s.Scan() {
+      got = append(got, s.Text())
+    }
+    if !errors.Is(s.Err(), c.err) {
+      t.Errorf("%s: got error %v, want %v", c.name, s.Err(), c.err)
+    }
+    if len(got) != len(c.want) {
+      t.Errorf("%s: got %d lines, want %d", c.name, len(got), len(c.want))
+      continue
+    }
+    for i := range got {
+      if got[i] != c.want[i] {
+        t.Errorf("%s: line %d is %d bytes, want %d", c.name, i+1, len(got[i]), len(c.want[i]))
+      }
+    }
+  }
+}
+
+// Lines reads with the default limit, so a multi-megabyte line comes
+// through it too.
+func TestLinesLong(t *testing.T) {
+  long := strings.Repeat("7", 3<<20)
+  var got []string
+  for line, err := range Lines(strings.NewReader(long + "\r\n")) {
+    if err != nil {
+      t.Fatal(err)
+    }
+    got = append(got, line)
+  }
+  if len(got) != 1 || got[0] != long {
+    t.Errorf("got %d lines, want the one %d-byte line", len(got), len(long))
+  }
+}
@@ -0,0 +1,129 @@
+// Package modular is arithmetic modulo m, for puzzles whose numbers only
+// matter up to a modulus, like the worry levels of 2022's monkeys kept
+// modulo the product of their divisors.
+//
+// A Mod carries its modulus and is always reduced, so no "% m" can be
+// forgotten, and products are taken at 128 bits so they never overflow
+// for any modulus up to the largest int64.
+package modular
+
+import (
+  "fmt"
+  "math/bits"
+)
+
+// Mod is a value modulo m, in [0, m). Values with different moduli do not
+// mix; combining them panics. The fields are unexported so a Mod can only
+// be made reduced, through New.
+type Mod struct {
+  v, m int64
+}
+
+// New reduces v modulo m, which must be positive.
+func New(v, m int64) Mod {
+  if m <= 0 {
+    panic(fmt.Sprintf("modular: modulus %d is not positive", m))
+  }
+  v %= m
+  if v < 0 {
+    v += m
+  }
+  return Mod{v, m}
+}
+
+// Value is a's value, in [0, Modulus()).
+func (a Mod) Value() int64 { return a.v }
+
+// Modulus is the modulus a was made with.
+func (a Mod) Modulus() int64 { return a.m }
+
+func (a Mod) String() string {
+  return fmt.Sprintf("%d (mod %d)", a.v, a.m)
+}
+
+func (a Mod) check(b Mod) {
+  if a.m != b.m {
+    panic(fmt.Sprintf("modular: mixing moduli %d and %d", a.m, b.m))
+  }
+}
+
+func (a Mod) Add(b Mod) Mod {
+  a.check(b)
+  // Both are below m <= MaxInt64, so the sum fits in a uint64.
+  s := uint64(a.v) + uint64(b.v)
+  if s >= uint64(a.m) {
+    s -= uint64(a.m)
+  }
+  return Mod{int64(s), a.m}
+}
+
+func (a Mod) Sub(b Mod) Mod {
+  a.check(b)
+  d := a.v - b.v
+  if d < 0 {
+    d += a.m
+  }
+  return Mod{d, a.m}
+}
+
+func (a Mod) Neg() Mod {
+  if a.v == 0 {
+    return a
+  }
+  return Mod{a.m - a.v, a.m}
+}
+
+func (a Mod) Mul(b Mod) Mod {
+  a.check(b)
+  hi, lo := bits.Mul64(uint64(a.v), uint64(b.v))
+  _, r := bits.Div64(hi, lo, uint64(a.m))
+  return Mod{int64(r), a.m}
+}
+
+// AddInt, SubInt and MulInt take a plain int, reducing it first.
+func (a Mod) AddInt(n int64) Mod { return a.Add(New(n, a.m)) }
+func (a Mod) SubInt(n int64) Mod { return a.Sub(New(n, a.m)) }
+func (a Mod) MulInt(n int64) Mod { return a.Mul(New(n, a.m)) }
+
+// Pow raises a to the power e, which must not be negative, by squaring.
+func (a Mod) Pow(e int64) Mod {
+  if e < 0 {
+    panic(fmt.Sprintf("modular: negative exponent %d", e))
+  }
+  r := New(1, a.m)
+  for ; e > 0; e >>= 1 {
+    if e&1 == 1 {
+      r = r.Mul(a)
+    }
+    a = a.Mul(a)
+  }
+  return r
+}
+
+// Inverse returns b with a*b = 1, and false if there is none because a and
+// m share a factor.
+func (a Mod) Inverse() (Mod, bool) {
+  // The extended Euclidean algorithm, tracking only the coefficient of
+  // a. Coefficients stay within m in magnitude, so nothing overflows.
+  r0, r1 := a.m, a.v
+  t0, t1 := int64(0), int64(1)
+  for r1 != 0 {
+    q := r0 / r1
+    r0, r1 = r1, r0-q*r1
+    t0, t1 = t1, t0-q*t1
+  }
+  if r0 != 1 {
+    return Mod{}, false
+  }
+  return New(t0, a.m), true
+}
+
+// Div returns a/b, a times the inverse of b, and false if b has none.
+func (a Mod) Div(b Mod) (Mod, bool) {
+  a.check(b)
+  inv, ok := b.Inverse()
+  if !ok {
+    return Mod{}, false
+  }
+  return a.Mul(inv), true
+}
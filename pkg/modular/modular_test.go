@@ -0,0 +1,107 @@
+package modular
+
+import (
+  "math"
+  "math/big"
+  "math/rand/v2"
+  "testing"
+)
+
+// Moduli to try: the edges, a large prime, and random ones of every size
+// up to the largest int64.
+func moduli(r *rand.Rand, n int) []int64 {
+  ms := []int64{1, 2, 3, 1<<31 - 1, 1<<61 - 1, math.MaxInt64 - 1, math.MaxInt64}
+  for range n {
+    ms = append(ms, r.Int64N(math.MaxInt64>>r.IntN(63))+1)
+  }
+  return ms
+}
+
+// An operand near anything interesting: 0, the ends of the int64 range,
+// either side of the modulus, or anywhere.
+func operand(r *rand.Rand, m int64) int64 {
+  switch r.IntN(6) {
+  case 0:
+    return 0
+  case 1:
+    return math.MinInt64
+  case 2:
+    return math.MaxInt64
+  case 3:
+    return m - 1
+  case 4:
+    return -m + r.Int64N(3) - 1
+  }
+  return r.Int64() - r.Int64()
+}
+
+// Every operation agrees with math/big.
+func TestAgainstBig(t *testing.T) {
+  r := rand.New(rand.NewPCG(1, 2))
+  n := 20000
+  if testing.Short() {
+    n = 1000
+  }
+  reduce := func(x, m *big.Int) *big.Int { return new(big.Int).Mod(x, m) }
+  for _, m := range moduli(r, n) {
+    x, y := operand(r, m), operand(r, m)
+    e := r.Int64N(1 << 20)
+    bm, bx, by := big.NewInt(m), big.NewInt(x), big.NewInt(y)
+    a, b := New(x, m), New(y, m)
+
+    check := func(op string, got Mod, want *big.Int) {
+      t.Helper()
+      if got.Modulus() != m || got.Value() != want.Int64() {
+        t.Fatalf("%d %s %d (mod %d): got %v, want %v", x, op, y, m, got, want)
+      }
+    }
+    check("new", a, reduce(bx, bm))
+    check("+", a.Add(b), reduce(new(big.Int).Add(bx, by), bm))
+    check("-", a.Sub(b), reduce(new(big.Int).Sub(bx, by), bm))
+    check("neg", a.Neg(), reduce(new(big.Int).Neg(bx), bm))
+    check("*", a.Mul(b), reduce(new(big.Int).Mul(bx, by), bm))
+    check("+int", a.AddInt(y), reduce(new(big.Int).Add(bx, by), bm))
+    check("-int", a.SubInt(y), reduce(new(big.Int).Sub(bx, by), bm))
+    check("*int", a.MulInt(y), reduce(new(big.Int).Mul(bx, by), bm))
+    check("pow", a.Pow(e), new(big.Int).Exp(bx, big.NewInt(e), bm))
+
+    inv, ok := a.Inverse()
+    want := new(big.Int).ModInverse(reduce(bx, bm), bm)
+    // big has 0 as the inverse of everything mod 1; so does Inverse.
+    if ok != (want != nil) {
+      t.Fatalf("inverse of %d (mod %d): got %v, %v, want %v", x, m, inv, ok, want)
+    }
+    if ok {
+      check("inverse", inv, want)
+      q, ok := b.Div(a)
+      if !ok {
+        t.Fatalf("%d / %d (mod %d) has no answer", y, x, m)
+      }
+      check("/", q, reduce(new(big.Int).Mul(by, want), bm))
+    }
+  }
+}
+
+func TestString(t *testing.T) {
+  if got := New(-1, 7).String(); got != "6 (mod 7)" {
+    t.Errorf("got %q", got)
+  }
+}
+
+func TestPanics(t *testing.T) {
+  for name, f := range map[string]func(){
+    "zero modulus":     func() { New(1, 0) },
+    "negative modulus": func() { New(1, -5) },
+    "mixed moduli":     func() { New(1, 5).Add(New(1, 7)) },
+    "negative power":   func() { New(2, 5).Pow(-1) },
+  } {
+    func() {
+      defer func() {
+        if recover() == nil {
+          t.Errorf("%s did not panic", name)
+        }
+      }()
+      f()
+    }()
+  }
+}
@@ -0,0 +1,197 @@
+// Package parallel runs brute-force searches across all CPUs: try every
+// index in a range, or every item in a slice, and either stop at the first
+// hit or collect every result.
+//
+// Results do not depend on scheduling. Find returns the hit with the
+// lowest index, not whichever a worker happened to see first, and Map keeps
+// its results in index order. Work is handed out in chunks of consecutive
+// indices, in increasing order, so once a hit is found only the indices
+// below it still need trying.
+//
+// A panic in the function being run is recovered and returned as a
+// *PanicError, which stops the search, and a cancelled context stops it
+// with the context's error.
+package parallel
+
+import (
+  "context"
+  "fmt"
+  "runtime"
+  "runtime/debug"
+  "sync"
+  "sync/atomic"
+)
+
+// Options tune a search; the zero value suits most.
+type Options struct {
+  // Workers is the number of goroutines, by default GOMAXPROCS.
+  Workers int
+  // Chunk is how many consecutive indices a worker takes at a time. The
+  // default makes about 64 chunks per worker, which keeps them busy to
+  // the end without contending for work.
+  Chunk int
+}
+
+func (o Options) split(n int) (workers, chunk int) {
+  workers = o.Workers
+  if workers <= 0 {
+    workers = runtime.GOMAXPROCS(0)
+  }
+  chunk = o.Chunk
+  if chunk <= 0 {
+    chunk = max(n/(workers*64), 1)
+  }
+  return min(workers, max((n+chunk-1)/chunk, 1)), chunk
+}
+
+// PanicError is a panic recovered from the function run at Index.
+type PanicError struct {
+  Index int
+  Value any
+  Stack []byte
+}
+
+func (e *PanicError) Error() string {
+  return fmt.Sprintf("parallel: panic at index %d: %v\n%s", e.Index, e.Value, e.Stack)
+}
+
+// Run body for every index in [lo, hi), in parallel. When body returns
+// true, indices above that one are no longer needed: they stop being
+// handed out, while those below still run. Returns the lowest index at
+// which body stopped the search or panicked, or hi.
+func run(ctx context.Context, o Options, lo, hi int, body func(i int) bool) (int, error) {
+  if hi <= lo {
+    return hi, ctx.Err()
+  }
+  workers, chunk := o.split(hi - lo)
+  var next atomic.Int64
+  var stop atomic.Int64
+  stop.Store(int64(hi))
+  lower := func(i int) {
+    for {
+      cur := stop.Load()
+      if int64(i) >= cur || stop.CompareAndSwap(cur, int64(i)) {
+        return
+      }
+    }
+  }
+
+  var mu sync.Mutex
+  var panicked *PanicError
+  call := func(i int) (hit bool) {
+    defer func() {
+      if v := recover(); v != nil {
+        mu.Lock()
+        if panicked == nil || i < panicked.Index {
+          panicked = &PanicError{i, v, debug.Stack()}
+        }
+        mu.Unlock()
+        hit = true
+      }
+    }()
+    return body(i)
+  }
+
+  var wg sync.WaitGroup
+  for w := 0; w < workers; w++ {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      for ctx.Err() == nil {
+        start := lo + int(next.Add(1)-1)*chunk
+        if start >= hi || int64(start) >= stop.Load() {
+          return
+        }
+        for i := start; i < min(start+chunk, hi); i++ {
+          if int64(i) >= stop.Load() {
+            break
+          }
+          if call(i) {
+            lower(i)
+          }
+        }
+      }
+    }()
+  }
+  wg.Wait()
+
+  end := int(stop.Load())
+  if panicked != nil && panicked.Index == end {
+    return end, panicked
+  }
+  if end == hi {
+    // Cancellation only matters if it cut the search short.
+    if err := ctx.Err(); err != nil && int(next.Load())*chunk < hi-lo {
+      return end, err
+    }
+  }
+  return end, nil
+}
+
+// Find tries every index in [lo, hi) and returns the lowest one for which
+// test reports a hit, with the value it returned. ok is false if there was
+// no hit.
+func Find[T any](ctx context.Context, o Options, lo, hi int, test func(i int) (T, bool)) (index int, value T, ok bool, err error) {
+  var mu sync.Mutex
+  hits := map[int]T{}
+  end, err := run(ctx, o, lo, hi, func(i int) bool {
+    v, hit := test(i)
+    if hit {
+      mu.Lock()
+      hits[i] = v
+      mu.Unlock()
+    }
+    return hit
+  })
+  if err != nil {
+    return 0, value, false, err
+  }
+  if v, hit := hits[end]; hit {
+    return end, v, true, nil
+  }
+  return 0, value, false, nil
+}
+
+// FindIn is Find over the items of a slice, returning the index of the
+// first item, in slice order, that is a hit.
+func FindIn[E, T any](ctx context.Context, o Options, items []E, test func(E) (T, bool)) (int, T, bool, error) {
+  return Find(ctx, o, 0, len(items), func(i int) (T, bool) { return test(items[i]) })
+}
+
+// Map calls f for every index in [lo, hi) and returns the results in
+// index order.
+func Map[T any](ctx context.Context, o Options, lo, hi int, f func(i int) T) ([]T, error) {
+  out := make([]T, max(hi-lo, 0))
+  if _, err := run(ctx, o, lo, hi, func(i int) bool {
+    out[i-lo] = f(i)
+    return false
+  }); err != nil {
+    return nil, err
+  }
+  return out, nil
+}
+
+// MapIn is Map over the items of a slice.
+func MapIn[E, T any](ctx context.Context, o Options, items []E, f func(E) T) ([]T, error) {
+  return Map(ctx, o, 0, len(items), func(i int) T { return f(items[i]) })
+}
+
+// Count returns how many indices in [lo, hi) satisfy pred.
+func Count(ctx context.Context, o Options, lo, hi int, pred func(i int) bool) (int, error) {
+  var n atomic.Int64
+  if _, err := run(ctx, o, lo, hi, func(i int) bool {
+    if pred(i) {
+      n.Add(1)
+    }
+    return false
+  }); err != nil {
+    return 0, err
+  }
+  return int(n.Load()), nil
+}
+
+// CountIn is Count over the items of a slice, such as the candidate
+// obstacle positions of a grid.
+func CountIn[E any](ctx context.Context, o Options, items []E, pred func(E) bool) (int, error) {
+  return Count(ctx, o, 0, len(items), func(i int) bool { return pred(items[i]) })
+}
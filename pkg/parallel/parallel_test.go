@@ -0,0 +1,137 @@
+package parallel
+
+import (
+  "context"
+  "errors"
+  "slices"
+  "sync"
+  "testing"
+)
+
+// A body where each index in waits waits until every index in after has
+// finished, so work completes out of index order however it is scheduled.
+func outOfOrder(waits []int, after ...int) (wait func(i int), done func(i int)) {
+  var wg sync.WaitGroup
+  wg.Add(len(after))
+  var once sync.Map
+  wait = func(i int) {
+    if slices.Contains(waits, i) {
+      wg.Wait()
+    }
+  }
+  done = func(i int) {
+    if _, dup := once.LoadOrStore(i, true); !dup && slices.Contains(after, i) {
+      wg.Done()
+    }
+  }
+  return wait, done
+}
+
+// One worker per index, so an index that waits holds up none of the
+// others.
+var spread = Options{Workers: 8, Chunk: 1}
+
+func TestMapOrder(t *testing.T) {
+  wait, done := outOfOrder([]int{0, 1, 2}, 5, 6, 7)
+  got, err := Map(context.Background(), spread, 0, 8, func(i int) int {
+    wait(i)
+    defer done(i)
+    return i * i
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if want := []int{0, 1, 4, 9, 16, 25, 36, 49}; !slices.Equal(got, want) {
+    t.Errorf("got %v, want %v", got, want)
+  }
+
+  items := []string{"a", "bb", "ccc"}
+  lens, err := MapIn(context.Background(), Options{}, items, func(s string) int { return len(s) })
+  if err != nil || !slices.Equal(lens, []int{1, 2, 3}) {
+    t.Errorf("MapIn gave %v, %v", lens, err)
+  }
+}
+
+// The lowest hit wins even when a higher one is found first.
+func TestFindLowest(t *testing.T) {
+  wait, done := outOfOrder([]int{2}, 6)
+  i, v, ok, err := Find(context.Background(), spread, 0, 8, func(i int) (string, bool) {
+    wait(i)
+    defer done(i)
+    return string(rune('a' + i)), i == 2 || i == 6
+  })
+  if err != nil || !ok || i != 2 || v != "c" {
+    t.Errorf("got %d, %q, %v, %v, want 2, \"c\", true, nil", i, v, ok, err)
+  }
+
+  for _, o := range []Options{{}, {Workers: 1}, {Workers: 3, Chunk: 7}} {
+    i, _, ok, err := Find(context.Background(), o, 10, 1000, func(i int) (int, bool) { return i, i%97 == 0 })
+    if err != nil || !ok || i != 97 {
+      t.Errorf("%+v: got %d, %v, %v, want 97", o, i, ok, err)
+    }
+    if _, _, ok, err := Find(context.Background(), o, 0, 100, func(i int) (int, bool) { return i, false }); ok || err != nil {
+      t.Errorf("%+v: found a hit in nothing: %v", o, err)
+    }
+  }
+
+  if i, _, ok, _ := FindIn(context.Background(), Options{}, []string{"x", "yes", "no", "yes"}, func(s string) (int, bool) { return 0, s == "yes" }); !ok || i != 1 {
+    t.Errorf("FindIn gave %d, %v, want 1", i, ok)
+  }
+}
+
+func TestCount(t *testing.T) {
+  n, err := Count(context.Background(), Options{Workers: 3}, 0, 1000, func(i int) bool { return i%3 == 0 })
+  if err != nil || n != 334 {
+    t.Errorf("got %d, %v, want 334", n, err)
+  }
+  if n, err := Count(context.Background(), Options{}, 5, 5, func(int) bool { return true }); err != nil || n != 0 {
+    t.Errorf("an empty range counted %d, %v", n, err)
+  }
+}
+
+// A panicking body stops the search with a *PanicError for the lowest index
+// that panicked, unless a hit below it made it irrelevant.
+func TestPanic(t *testing.T) {
+  wait, done := outOfOrder([]int{3}, 6)
+  _, err := Map(context.Background(), spread, 0, 8, func(i int) int {
+    wait(i)
+    defer done(i)
+    if i == 3 || i == 6 {
+      panic("boom")
+    }
+    return i
+  })
+  var pe *PanicError
+  if !errors.As(err, &pe) || pe.Index != 3 || pe.Value != "boom" || len(pe.Stack) == 0 {
+    t.Errorf("got %v, want a panic at index 3", err)
+  }
+
+  find := func(hit, panics int) (int, bool, error) {
+    i, _, ok, err := Find(context.Background(), spread, 0, 8, func(i int) (int, bool) {
+      if i == panics {
+        panic(i)
+      }
+      return i, i == hit
+    })
+    return i, ok, err
+  }
+  if i, ok, err := find(2, 5); err != nil || !ok || i != 2 {
+    t.Errorf("hit below the panic: got %d, %v, %v, want 2", i, ok, err)
+  }
+  if _, ok, err := find(5, 2); ok || !errors.As(err, &pe) || pe.Index != 2 {
+    t.Errorf("panic below the hit: got %v, %v, want a panic at index 2", ok, err)
+  }
+}
+
+func TestCancel(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  _, err := Count(ctx, Options{Workers: 2, Chunk: 1}, 0, 1000, func(i int) bool {
+    if i == 10 {
+      cancel()
+    }
+    return false
+  })
+  if !errors.Is(err, context.Canceled) {
+    t.Errorf("got %v, want context.Canceled", err)
+  }
+}
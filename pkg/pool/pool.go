@@ -0,0 +1,109 @@
+// Package pool cuts allocation in hot loops: typed pools for values that
+// are expensive to make, pools of slices by capacity, and arenas that hand
+// out values from large chunks and free them all at once.
+//
+// Pools are safe for concurrent use, as sync.Pool is. An Arena is not; give
+// each goroutine its own.
+package pool
+
+import (
+  "math/bits"
+  "sync"
+)
+
+// Pool is a typed sync.Pool. Reset, if set, clears a value as it is put
+// back, so Get always returns a clean one.
+type Pool[T any] struct {
+  pool  sync.Pool
+  reset func(*T)
+}
+
+func New[T any](reset func(*T)) *Pool[T] {
+  p := &Pool[T]{reset: reset}
+  p.pool.New = func() any { return new(T) }
+  return p
+}
+
+func (p *Pool[T]) Get() *T {
+  return p.pool.Get().(*T)
+}
+
+func (p *Pool[T]) Put(v *T) {
+  if p.reset != nil {
+    p.reset(v)
+  }
+  p.pool.Put(v)
+}
+
+// Slices pools slices in power-of-two capacity classes, so a slice put
+// back after growing serves any later request up to its capacity.
+type Slices[T any] struct {
+  classes [64]sync.Pool
+}
+
+// Get returns an empty slice with room for at least n elements.
+func (s *Slices[T]) Get(n int) []T {
+  class := bits.Len(uint(max(n, 1) - 1))
+  if v := s.classes[class].Get(); v != nil {
+    return (*v.(*[]T))[:0]
+  }
+  return make([]T, 0, 1<<class)
+}
+
+// Put returns a slice for reuse; the caller must not use it afterwards.
+func (s *Slices[T]) Put(b []T) {
+  if cap(b) == 0 {
+    return
+  }
+  // File it under the largest class it can fully serve.
+  class := bits.Len(uint(cap(b))) - 1
+  b = b[:0]
+  s.classes[class].Put(&b)
+}
+
+// Arena hands out zeroed values from chunks, such as the nodes of a search
+// frontier, and Reset makes them all available again at once. Pointers
+// from before a Reset must not be used after it.
+type Arena[T any] struct {
+  chunks [][]T
+  chunk  int // index of the chunk being filled
+  used   int // values handed out from it
+  size   int
+}
+
+// NewArena makes an arena allocating chunkSize values at a time.
+func NewArena[T any](chunkSize int) *Arena[T] {
+  return &Arena[T]{size: max(chunkSize, 1)}
+}
+
+func (a *Arena[T]) Alloc() *T {
+  if a.chunk == len(a.chunks) {
+    a.chunks = append(a.chunks, make([]T, a.size))
+  }
+  c := a.chunks[a.chunk]
+  v := &c[a.used]
+  if a.used++; a.used == len(c) {
+    a.chunk, a.used = a.chunk+1, 0
+  }
+  return v
+}
+
+// Reset zeroes the values handed out and makes the memory reusable.
+func (a *Arena[T]) Reset() {
+  var zero T
+  for i := 0; i <= a.chunk && i < len(a.chunks); i++ {
+    c := a.chunks[i]
+    if i == a.chunk {
+      c = c[:a.used]
+    }
+    for j := range c {
+      c[j] = zero
+    }
+  }
+  a.chunk, a.used = 0, 0
+}
+
+// Len is the number of values handed out since the last Reset.
+func (a *Arena[T]) Len() int {
+  return a.chunk*a.size + a.used
+}
@@ -0,0 +1,133 @@
+package pool
+
+import "testing"
+
+func TestSlices(t *testing.T) {
+  var s Slices[int]
+  b := s.Get(5)
+  if len(b) != 0 || cap(b) < 5 {
+    t.Fatalf("Get(5) gave len %d cap %d", len(b), cap(b))
+  }
+  b = append(b, 1, 2, 3)
+  s.Put(b)
+  // The pool may drop what it holds at any time, so only check that what
+  // comes back is empty and big enough.
+  for _, n := range []int{0, 1, 3, 8, 9, 1000} {
+    if b := s.Get(n); len(b) != 0 || cap(b) < n {
+      t.Errorf("Get(%d) gave len %d cap %d", n, len(b), cap(b))
+    }
+  }
+}
+
+func TestPoolReset(t *testing.T) {
+  p := New(func(v *[]int) { *v = (*v)[:0] })
+  v := p.Get()
+  *v = append(*v, 1, 2)
+  p.Put(v)
+  if v := p.Get(); len(*v) != 0 {
+    t.Errorf("Get after Put gave %v", *v)
+  }
+}
+
+func TestArena(t *testing.T) {
+  a := NewArena[node](3)
+  var got []*node
+  for i := 0; i < 7; i++ {
+    n := a.Alloc()
+    if n.x != 0 || n.parent != nil {
+      t.Fatalf("value %d is not zeroed: %+v", i, *n)
+    }
+    n.x = i + 1
+    got = append(got, n)
+  }
+  for i, n := range got {
+    if n.x != i+1 {
+      t.Errorf("value %d was overwritten: %d", i, n.x)
+    }
+  }
+  if a.Len() != 7 {
+    t.Errorf("Len is %d, want 7", a.Len())
+  }
+  a.Reset()
+  if a.Len() != 0 {
+    t.Errorf("Len after Reset is %d", a.Len())
+  }
+  for i := 0; i < 7; i++ {
+    if n := a.Alloc(); n.x != 0 {
+      t.Fatalf("value %d is not zeroed after Reset: %+v", i, *n)
+    }
+  }
+}
+
+// A search node, as a BFS frontier holds.
+type node struct {
+  x, y, dist int
+  parent     *node
+}
+
+// Breadth-first search of an open size by size grid, making each node with
+// alloc, as a puzzle's search does once per state.
+func bfs(size int, alloc func() *node) int {
+  seen := make([]bool, size*size)
+  frontier := []*node{alloc()}
+  seen[0] = true
+  visited := 0
+  for len(frontier) > 0 {
+    n := frontier[0]
+    frontier = frontier[1:]
+    visited++
+    for _, d := range [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}} {
+      x, y := n.x+d[0], n.y+d[1]
+      if x < 0 || y < 0 || x >= size || y >= size || seen[y*size+x] {
+        continue
+      }
+      seen[y*size+x] = true
+      next := alloc()
+      next.x, next.y, next.dist, next.parent = x, y, n.dist+1, n
+      frontier = append(frontier, next)
+    }
+  }
+  return visited
+}
+
+// The frontier's nodes from new, then from an arena reset between
+// searches.
+func BenchmarkFrontier(b *testing.B) {
+  b.Run("new", func(b *testing.B) {
+    b.ReportAllocs()
+    for b.Loop() {
+      bfs(64, func() *node { return new(node) })
+    }
+  })
+  b.Run("arena", func(b *testing.B) {
+    b.ReportAllocs()
+    a := NewArena[node](1024)
+    for b.Loop() {
+      bfs(64, a.Alloc)
+      a.Reset()
+    }
+  })
+}
+
+var sink []int
+
+// Buffers of varying size from make, then from Slices.
+func BenchmarkBuffers(b *testing.B) {
+  sizes := []int{10, 100, 1000, 50, 500}
+  b.Run("make", func(b *testing.B) {
+    b.ReportAllocs()
+    for i := 0; b.Loop(); i++ {
+      buf := make([]int, 0, sizes[i%len(sizes)])
+      sink = append(buf, i)
+    }
+  })
+  b.Run("slices", func(b *testing.B) {
+    b.ReportAllocs()
+    var s Slices[int]
+    for i := 0; b.Loop(); i++ {
+      buf := append(s.Get(sizes[i%len(sizes)]), i)
+      sink = buf
+      s.Put(buf)
+    }
+  })
+}
@@ -0,0 +1,287 @@
+// Package prop is property testing: check that something holds for many
+// random values, and when it does not, shrink the value that broke it to a
+// small one before reporting it.
+//
+// A Gen makes random values and, for shrinking, simpler versions of a
+// value. Values start small and grow over the trials, so the simple cases
+// come first. Runs repeat: the seed is AOC_SEED when set, else 1, and a
+// failure reports it.
+//
+//   prop.Check(t, prop.Config{}, prop.SliceOf(prop.IntervalIn(0, 100), 20),
+//     func(ivs []prop.Interval) bool { return isSorted(merge(ivs)) })
+package prop
+
+import (
+  "fmt"
+  "math/rand"
+  "os"
+  "strconv"
+)
+
+// T is the part of testing.TB that Check uses.
+type T interface {
+  Helper()
+  Errorf(format string, args ...any)
+}
+
+// Gen generates values of type V.
+type Gen[V any] struct {
+  // Generate makes a value no bigger than size, which runs from 1 up to
+  // Config.MaxSize over the trials.
+  Generate func(r *rand.Rand, size int) V
+  // Shrink returns simpler values to try in place of v, simplest first.
+  // It may be nil for values that do not shrink.
+  Shrink func(v V) []V
+}
+
+// Config tunes Check; the zero value suits most.
+type Config struct {
+  // Trials is the number of values tried, by default 1000.
+  Trials int
+  // MaxSize bounds the values' size, by default 100.
+  MaxSize int
+  // Seed seeds the values, by default AOC_SEED or 1.
+  Seed int64
+}
+
+// Check tries prop on values from g, reporting through t the simplest
+// value it could shrink a failure to. It returns whether prop held.
+func Check[V any](t T, c Config, g Gen[V], prop func(V) bool) bool {
+  t.Helper()
+  if c.Trials <= 0 {
+    c.Trials = 1000
+  }
+  if c.MaxSize <= 0 {
+    c.MaxSize = 100
+  }
+  if c.Seed == 0 {
+    c.Seed, _ = strconv.ParseInt(os.Getenv("AOC_SEED"), 10, 64)
+  }
+  if c.Seed == 0 {
+    c.Seed = 1
+  }
+  r := rand.New(rand.NewSource(c.Seed))
+  for trial := 0; trial < c.Trials; trial++ {
+    v := g.Generate(r, 1+trial*c.MaxSize/c.Trials)
+    if holds(prop, v) {
+      continue
+    }
+    original := v
+    steps := shrink(g, prop, &v)
+    t.Errorf("property failed on trial %d (seed %d) for\n  %v\nshrunk in %d steps from\n  %v",
+      trial+1, c.Seed, v, steps, original)
+    return false
+  }
+  return true
+}
+
+// Whether prop holds for v; a panic counts as not.
+func holds[V any](prop func(V) bool, v V) (ok bool) {
+  defer func() {
+    if recover() != nil {
+      ok = false
+    }
+  }()
+  return prop(v)
+}
+
+// The most shrinking steps Check takes, which bounds the time spent on a
+// Shrink that keeps finding new failures without getting simpler.
+const maxShrinks = 1000
+
+// Replace *v by ever simpler values that still fail, taking the first
+// failing candidate each time until none fail, and return the steps taken.
+func shrink[V any](g Gen[V], prop func(V) bool, v *V) int {
+  if g.Shrink == nil {
+    return 0
+  }
+  steps := 0
+  for steps < maxShrinks {
+    found := false
+    for _, c := range g.Shrink(*v) {
+      if !holds(prop, c) {
+        *v, found = c, true
+        steps++
+        break
+      }
+    }
+    if !found {
+      break
+    }
+  }
+  return steps
+}
+
+// Int generates ints in [lo, hi], small ones first when the range holds
+// zero, and shrinks them towards zero, or towards the end nearer it.
+func Int(lo, hi int) Gen[int] {
+  target := min(max(0, lo), hi)
+  return Gen[int]{
+    Generate: func(r *rand.Rand, size int) int {
+      a, b := max(lo, target-size), min(hi, target+size)
+      return a + r.Intn(b-a+1)
+    },
+    Shrink: func(v int) []int {
+      return shrinkInt(v, target)
+    },
+  }
+}
+
+// Values between v and target, from target out: target, then halfway,
+// and so on, then the neighbour of v.
+func shrinkInt(v, target int) []int {
+  var out []int
+  for d := v - target; d != 0; d /= 2 {
+    out = append(out, v-d)
+  }
+  return out
+}
+
+// SliceOf generates slices of up to maxLen elements from g. It shrinks by
+// trying the empty slice, dropping halves, quarters and so on down to
+// single elements, then by shrinking single elements.
+func SliceOf[V any](g Gen[V], maxLen int) Gen[[]V] {
+  return Gen[[]V]{
+    Generate: func(r *rand.Rand, size int) []V {
+      s := make([]V, r.Intn(min(maxLen, size)+1))
+      for i := range s {
+        s[i] = g.Generate(r, size)
+      }
+      return s
+    },
+    Shrink: func(s []V) [][]V {
+      var out [][]V
+      if len(s) > 0 {
+        out = append(out, s[:0])
+      }
+      for n := len(s) / 2; n > 0; n /= 2 {
+        for i := 0; i+n <= len(s); i += n {
+          out = append(out, without(s, i, i+n))
+        }
+      }
+      if g.Shrink != nil {
+        for i := range s {
+          for _, c := range g.Shrink(s[i]) {
+            t := append([]V(nil), s...)
+            t[i] = c
+            out = append(out, t)
+          }
+        }
+      }
+      return out
+    },
+  }
+}
+
+// A copy of s without s[i:j].
+func without[V any](s []V, i, j int) []V {
+  return append(append(make([]V, 0, len(s)-(j-i)), s[:i]...), s[j:]...)
+}
+
+// Grid generates rectangular grids of up to rows by cols cells from g.
+// It shrinks by dropping the last row or column, then by shrinking cells.
+func Grid[V any](rows, cols int, g Gen[V]) Gen[[][]V] {
+  return Gen[[][]V]{
+    Generate: func(r *rand.Rand, size int) [][]V {
+      h, w := 1+r.Intn(min(rows, size)), 1+r.Intn(min(cols, size))
+      grid := make([][]V, h)
+      for y := range grid {
+        grid[y] = make([]V, w)
+        for x := range grid[y] {
+          grid[y][x] = g.Generate(r, size)
+        }
+      }
+      return grid
+    },
+    Shrink: func(grid [][]V) [][][]V {
+      var out [][][]V
+      if len(grid) > 1 {
+        out = append(out, grid[:len(grid)-1])
+      }
+      if len(grid) > 0 && len(grid[0]) > 1 {
+        narrower := make([][]V, len(grid))
+        for y, row := range grid {
+          narrower[y] = row[:len(row)-1]
+        }
+        out = append(out, narrower)
+      }
+      if g.Shrink != nil {
+        for y, row := range grid {
+          for x := range row {
+            for _, c := range g.Shrink(row[x]) {
+              t := make([][]V, len(grid))
+              copy(t, grid)
+              t[y] = append([]V(nil), row...)
+              t[y][x] = c
+              out = append(out, t)
+            }
+          }
+        }
+      }
+      return out
+    },
+  }
+}
+
+// An Interval is the closed range of ints Lo through Hi.
+type Interval struct {
+  Lo, Hi int
+}
+
+func (iv Interval) String() string {
+  return fmt.Sprintf("%d..%d", iv.Lo, iv.Hi)
+}
+
+// IntervalIn generates intervals within [lo, hi], shrinking them towards
+// single points and towards zero.
+func IntervalIn(lo, hi int) Gen[Interval] {
+  ends := Int(lo, hi)
+  return Gen[Interval]{
+    Generate: func(r *rand.Rand, size int) Interval {
+      start := ends.Generate(r, size)
+      return Interval{start, min(hi, start+r.Intn(size+1))}
+    },
+    Shrink: func(iv Interval) []Interval {
+      var out []Interval
+      for _, end := range shrinkInt(iv.Hi, iv.Lo) {
+        out = append(out, Interval{iv.Lo, end})
+      }
+      for _, start := range ends.Shrink(iv.Lo) {
+        if end := start + iv.Hi - iv.Lo; end <= hi {
+          out = append(out, Interval{start, end})
+        }
+      }
+      return out
+    },
+  }
+}
+
+// A Pair is two values generated together, for a property of more than
+// one, such as a report and the index to change in it.
+type Pair[A, B any] struct {
+  A A
+  B B
+}
+
+// PairOf generates pairs from a and b, shrinking one side at a time.
+func PairOf[A, B any](a Gen[A], b Gen[B]) Gen[Pair[A, B]] {
+  return Gen[Pair[A, B]]{
+    Generate: func(r *rand.Rand, size int) Pair[A, B] {
+      return Pair[A, B]{a.Generate(r, size), b.Generate(r, size)}
+    },
+    Shrink: func(p Pair[A, B]) []Pair[A, B] {
+      var out []Pair[A, B]
+      if a.Shrink != nil {
+        for _, v := range a.Shrink(p.A) {
+          out = append(out, Pair[A, B]{v, p.B})
+        }
+      }
+      if b.Shrink != nil {
+        for _, v := range b.Shrink(p.B) {
+          out = append(out, Pair[A, B]{p.A, v})
+        }
+      }
+      return out
+    },
+  }
+}
@@ -0,0 +1,115 @@
+package prop
+
+import (
+  "fmt"
+  "slices"
+  "strings"
+  "testing"
+)
+
+// A T that records failures instead of failing the test.
+type recorder struct {
+  errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+  r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestHolds(t *testing.T) {
+  var r recorder
+  if !Check(&r, Config{}, SliceOf(Int(-50, 50), 10), func(s []int) bool {
+    return len(slices.Sorted(slices.Values(s))) == len(s)
+  }) || len(r.errors) > 0 {
+    t.Errorf("a property that holds failed: %v", r.errors)
+  }
+}
+
+// Failures shrink to the smallest counterexample there is, for properties
+// where shrinking one step at a time can find it.
+func TestShrinks(t *testing.T) {
+  for _, c := range []struct {
+    name string
+    run  func(T) bool
+    want string
+  }{
+    {"int", func(t T) bool {
+      return Check(t, Config{}, Int(-1000, 1000), func(n int) bool { return n < 37 })
+    }, "\n  37\n"},
+    {"int towards the nearer end", func(t T) bool {
+      return Check(t, Config{}, Int(10, 1000), func(n int) bool { return n%7 != 0 })
+    }, "\n  14\n"},
+    {"slice", func(t T) bool {
+      return Check(t, Config{}, SliceOf(Int(0, 100), 20), func(s []int) bool {
+        return !slices.ContainsFunc(s, func(v int) bool { return v >= 10 })
+      })
+    }, "\n  [10]\n"},
+    {"grid", func(t T) bool {
+      return Check(t, Config{}, Grid(5, 5, Int(0, 9)), func(g [][]int) bool {
+        return len(g) < 2 || len(g[0]) < 3
+      })
+    }, "\n  [[0 0 0] [0 0 0]]\n"},
+    {"interval", func(t T) bool {
+      return Check(t, Config{}, IntervalIn(0, 100), func(iv Interval) bool { return iv.Hi-iv.Lo < 5 })
+    }, "\n  0..5\n"},
+    {"pair", func(t T) bool {
+      return Check(t, Config{}, PairOf(Int(0, 100), Int(0, 100)), func(p Pair[int, int]) bool {
+        return p.A < 5 || p.B < 7
+      })
+    }, "\n  {5 7}\n"},
+    {"panic", func(t T) bool {
+      return Check(t, Config{}, Int(0, 100), func(n int) bool { return 100/(n-3) != 0 || true })
+    }, "\n  3\n"},
+  } {
+    var r recorder
+    if c.run(&r) || len(r.errors) != 1 {
+      t.Errorf("%s: a failing property held: %v", c.name, r.errors)
+      continue
+    }
+    if !strings.Contains(r.errors[0], c.want) {
+      t.Errorf("%s: did not shrink to %q:\n%s", c.name, c.want, r.errors[0])
+    }
+  }
+}
+
+// The same seed gives the same values; a failure can be repeated.
+func TestSeed(t *testing.T) {
+  values := func(seed int64) []int {
+    var seen []int
+    Check(t, Config{Seed: seed, Trials: 50}, Int(-100, 100), func(n int) bool {
+      seen = append(seen, n)
+      return true
+    })
+    return seen
+  }
+  if !slices.Equal(values(7), values(7)) {
+    t.Error("one seed gave different values")
+  }
+  if slices.Equal(values(7), values(8)) {
+    t.Error("two seeds gave the same values")
+  }
+}
+
+func TestGeneratorsStayInRange(t *testing.T) {
+  Check(t, Config{}, IntervalIn(-5, 40), func(iv Interval) bool {
+    return -5 <= iv.Lo && iv.Lo <= iv.Hi && iv.Hi <= 40
+  })
+  Check(t, Config{}, Grid(4, 6, Int(1, 3)), func(g [][]int) bool {
+    if len(g) < 1 || len(g) > 4 {
+      return false
+    }
+    for _, row := range g {
+      if len(row) != len(g[0]) || len(row) < 1 || len(row) > 6 {
+        return false
+      }
+      for _, v := range row {
+        if v < 1 || v > 3 {
+          return false
+        }
+      }
+    }
+    return true
+  })
+}
@@ -0,0 +1,66 @@
+package testx
+
+import (
+  "errors"
+  "fmt"
+  "os"
+  "path/filepath"
+  "strings"
+)
+
+// Snapshot checks got against the file testdata/NAME.txt beside the test,
+// as "aoc snapshot" checks a solution's whole output: a change to how
+// something prints is caught as well as a wrong answer. A missing file is
+// written from got, and with AOC_UPDATE=1 in the environment every file
+// is written afresh instead of compared; either way the new file is for
+// committing.
+func Snapshot(t T, name, got string) bool {
+  t.Helper()
+  path := filepath.Join("testdata", name+".txt")
+  want, err := os.ReadFile(path)
+  switch {
+  case err == nil && os.Getenv("AOC_UPDATE") != "1":
+    if string(want) != got {
+      t.Errorf("%s: %s", path, Diff(got, string(want)))
+      return false
+    }
+    return true
+  case err != nil && !errors.Is(err, os.ErrNotExist):
+    t.Errorf("%v", err)
+    return false
+  }
+  if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+    t.Errorf("%v", err)
+    return false
+  }
+  if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+    t.Errorf("%v", err)
+    return false
+  }
+  return true
+}
+
+// Diff says where two outputs first differ, as a few lines of each, the
+// wanted lines marked - and the lines got marked +.
+func Diff(got, want string) string {
+  g := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+  w := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+  i := 0
+  for i < len(g) && i < len(w) && g[i] == w[i] {
+    i++
+  }
+  if i == len(g) && i == len(w) {
+    return "differs only in the final newline"
+  }
+  var b strings.Builder
+  fmt.Fprintf(&b, "first difference at line %d", i+1)
+  for _, side := range []struct {
+    label string
+    lines []string
+  }{{"-", w}, {"+", g}} {
+    for j := i; j < min(i+3, len(side.lines)); j++ {
+      fmt.Fprintf(&b, "\n      %s %s", side.label, side.lines[j])
+    }
+  }
+  return b.String()
+}
@@ -0,0 +1,47 @@
+package testx
+
+import (
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+func TestSnapshot(t *testing.T) {
+  t.Chdir(t.TempDir())
+  var r recorder
+  if !Snapshot(&r, "out", "a\nb\n") {
+    t.Fatalf("first snapshot failed: %q", r.msgs)
+  }
+  if b, err := os.ReadFile(filepath.Join("testdata", "out.txt")); err != nil || string(b) != "a\nb\n" {
+    t.Fatalf("snapshot written as %q, %v", b, err)
+  }
+  if !Snapshot(&r, "out", "a\nb\n") || len(r.msgs) != 0 {
+    t.Errorf("same output failed: %q", r.msgs)
+  }
+  if Snapshot(&r, "out", "a\nc\n") || len(r.msgs) != 1 || !strings.Contains(r.msgs[0], "first difference at line 2") {
+    t.Errorf("changed output passed or was misreported: %q", r.msgs)
+  }
+
+  t.Setenv("AOC_UPDATE", "1")
+  r.msgs = nil
+  if !Snapshot(&r, "out", "a\nc\n") {
+    t.Fatalf("update failed: %q", r.msgs)
+  }
+  t.Setenv("AOC_UPDATE", "")
+  if !Snapshot(&r, "out", "a\nc\n") {
+    t.Errorf("updated snapshot not kept: %q", r.msgs)
+  }
+}
+
+func TestDiff(t *testing.T) {
+  for _, c := range []struct{ got, want, diff string }{
+    {"a\nb", "a\nb\n", "differs only in the final newline"},
+    {"a\nx\ny\n", "a\nb\n", "first difference at line 2\n      - b\n      + x\n      + y"},
+    {"a\n", "a\nb\n", "first difference at line 2\n      - b"},
+  } {
+    if d := Diff(c.got, c.want); d != c.diff {
+      t.Errorf("Diff(%q, %q) = %q, want %q", c.got, c.want, d, c.diff)
+    }
+  }
+}
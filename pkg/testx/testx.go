@@ -0,0 +1,131 @@
+// Package testx is a handful of assertions that keep solution tests terse:
+// a failure says what was expected, what came instead, and for slices and
+// grids where they differ.
+//
+// The assertions take a T, which *testing.T and *testing.B satisfy, and
+// report with Errorf, so a test goes on to check the rest.
+package testx
+
+import (
+  "fmt"
+  "reflect"
+  "strings"
+)
+
+// T is the part of testing.TB the assertions use.
+type T interface {
+  Helper()
+  Errorf(format string, args ...any)
+}
+
+// Equal checks got == want, comparing with reflect.DeepEqual.
+func Equal[V any](t T, got, want V) bool {
+  t.Helper()
+  if !reflect.DeepEqual(got, want) {
+    t.Errorf("got %#v, want %#v", got, want)
+    return false
+  }
+  return true
+}
+
+// NoError checks that err is nil.
+func NoError(t T, err error) bool {
+  t.Helper()
+  if err != nil {
+    t.Errorf("unexpected error: %v", err)
+    return false
+  }
+  return true
+}
+
+// The most differences a failure lists before it stops counting them out.
+const maxDiffs = 10
+
+// SlicesEqual checks two slices element by element, listing the indices
+// where they differ and any elements one has past the end of the other.
+func SlicesEqual[E comparable](t T, got, want []E) bool {
+  t.Helper()
+  var diffs []string
+  for i := 0; i < max(len(got), len(want)); i++ {
+    switch {
+    case i >= len(got):
+      diffs = append(diffs, fmt.Sprintf("  [%d] missing, want %#v", i, want[i]))
+    case i >= len(want):
+      diffs = append(diffs, fmt.Sprintf("  [%d] got %#v, want nothing", i, got[i]))
+    case got[i] != want[i]:
+      diffs = append(diffs, fmt.Sprintf("  [%d] got %#v, want %#v", i, got[i], want[i]))
+    }
+  }
+  if len(diffs) == 0 {
+    return true
+  }
+  t.Errorf("slices differ (got %d elements, want %d):\n%s", len(got), len(want), limitDiffs(diffs))
+  return false
+}
+
+// GridEqual checks two grids, such as puzzle maps parsed into [][]byte,
+// and on failure shows both side by side with the differing rows marked.
+// Bytes and runes print as characters.
+func GridEqual[E comparable](t T, got, want [][]E) bool {
+  t.Helper()
+  rows := max(len(got), len(want))
+  var b strings.Builder
+  differ := 0
+  width := 0
+  for _, row := range got {
+    width = max(width, len(formatRow(row)))
+  }
+  for y := 0; y < rows; y++ {
+    var g, w []E
+    if y < len(got) {
+      g = got[y]
+    }
+    if y < len(want) {
+      w = want[y]
+    }
+    mark := " "
+    if y >= len(got) || y >= len(want) || !rowEqual(g, w) {
+      mark = ">"
+      differ++
+    }
+    fmt.Fprintf(&b, "%s %3d  %-*s  %s\n", mark, y, width, formatRow(g), formatRow(w))
+  }
+  if differ == 0 {
+    return true
+  }
+  t.Errorf("grids differ in %d of %d rows (got, want):\n%s", differ, rows, strings.TrimSuffix(b.String(), "\n"))
+  return false
+}
+
+func rowEqual[E comparable](a, b []E) bool {
+  if len(a) != len(b) {
+    return false
+  }
+  for i := range a {
+    if a[i] != b[i] {
+      return false
+    }
+  }
+  return true
+}
+
+func formatRow[E comparable](row []E) string {
+  switch r := any(row).(type) {
+  case []byte:
+    return string(r)
+  case []rune:
+    return string(r)
+  }
+  cells := make([]string, len(row))
+  for i, c := range row {
+    cells[i] = fmt.Sprint(c)
+  }
+  return strings.Join(cells, " ")
+}
+
+func limitDiffs(diffs []string) string {
+  if len(diffs) > maxDiffs {
+    diffs = append(diffs[:maxDiffs], fmt.Sprintf("  and %d more", len(diffs)-maxDiffs))
+  }
+  return strings.Join(diffs, "\n")
+}
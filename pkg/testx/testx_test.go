@@ -0,0 +1,70 @@
+package testx
+
+import (
+  "fmt"
+  "strings"
+  "testing"
+)
+
+// recorder is a T that keeps what the assertions report.
+type recorder struct {
+  msgs []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+  r.msgs = append(r.msgs, fmt.Sprintf(format, args...))
+}
+
+func TestEqual(t *testing.T) {
+  var r recorder
+  if !Equal(&r, []int{1, 2}, []int{1, 2}) || len(r.msgs) != 0 {
+    t.Errorf("equal slices failed: %q", r.msgs)
+  }
+  if Equal(&r, 3, 4) || len(r.msgs) != 1 || r.msgs[0] != "got 3, want 4" {
+    t.Errorf("got %q, want one failure", r.msgs)
+  }
+}
+
+func TestNoError(t *testing.T) {
+  var r recorder
+  if !NoError(&r, nil) || NoError(&r, fmt.Errorf("boom")) {
+    t.Error("NoError reported the wrong result")
+  }
+  if len(r.msgs) != 1 || !strings.Contains(r.msgs[0], "boom") {
+    t.Errorf("got %q", r.msgs)
+  }
+}
+
+func TestSlicesEqual(t *testing.T) {
+  var r recorder
+  if SlicesEqual(&r, []int{1, 9, 3}, []int{1, 2, 3, 4}) {
+    t.Fatal("different slices passed")
+  }
+  want := "slices differ (got 3 elements, want 4):\n  [1] got 9, want 2\n  [3] missing, want 4"
+  if len(r.msgs) != 1 || r.msgs[0] != want {
+    t.Errorf("got %q, want %q", r.msgs, want)
+  }
+
+  r.msgs = nil
+  SlicesEqual(&r, make([]int, 15), nil)
+  if !strings.HasSuffix(r.msgs[0], "  [9] got 0, want nothing\n  and 5 more") {
+    t.Errorf("long diff not cut short: %q", r.msgs[0])
+  }
+}
+
+func TestGridEqual(t *testing.T) {
+  var r recorder
+  got := [][]byte{[]byte("#.#"), []byte("...")}
+  if !GridEqual(&r, got, [][]byte{[]byte("#.#"), []byte("...")}) {
+    t.Fatalf("equal grids failed: %q", r.msgs)
+  }
+  if GridEqual(&r, got, [][]byte{[]byte("#.#"), []byte(".#.")}) {
+    t.Fatal("different grids passed")
+  }
+  want := "grids differ in 1 of 2 rows (got, want):\n    0  #.#  #.#\n>   1  ...  .#."
+  if len(r.msgs) != 1 || r.msgs[0] != want {
+    t.Errorf("got %q, want %q", r.msgs, want)
+  }
+}
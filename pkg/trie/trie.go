@@ -0,0 +1,167 @@
+// Package trie is a prefix tree of strings, for the puzzles that build
+// words out of pieces, like 2024's towels made of stripe patterns, and
+// for spell checkers and word searches.
+//
+// Keys are taken a byte at a time, which suits the ASCII of puzzle inputs;
+// a UTF-8 key works too, split within its characters.
+package trie
+
+import (
+  "iter"
+  "slices"
+)
+
+type node[V any] struct {
+  children map[byte]*node[V]
+  value    V
+  word     bool // a key ends here
+}
+
+// Trie maps strings to values of type V. The zero value is an empty trie
+// ready to use.
+type Trie[V any] struct {
+  root node[V]
+  n    int
+}
+
+func New[V any]() *Trie[V] {
+  return &Trie[V]{}
+}
+
+// Words makes a trie holding each word, with the value true.
+func Words(words ...string) *Trie[bool] {
+  t := New[bool]()
+  for _, w := range words {
+    t.Insert(w, true)
+  }
+  return t
+}
+
+// Insert sets the value for key, replacing any it had.
+func (t *Trie[V]) Insert(key string, v V) {
+  n := &t.root
+  for i := 0; i < len(key); i++ {
+    next := n.children[key[i]]
+    if next == nil {
+      if n.children == nil {
+        n.children = map[byte]*node[V]{}
+      }
+      next = &node[V]{}
+      n.children[key[i]] = next
+    }
+    n = next
+  }
+  if !n.word {
+    t.n++
+  }
+  n.value, n.word = v, true
+}
+
+// The node reached by following prefix, or nil if no key starts with it.
+func (t *Trie[V]) find(prefix string) *node[V] {
+  n := &t.root
+  for i := 0; i < len(prefix) && n != nil; i++ {
+    n = n.children[prefix[i]]
+  }
+  return n
+}
+
+// Get returns the value for key, and whether key is in the trie.
+func (t *Trie[V]) Get(key string) (V, bool) {
+  if n := t.find(key); n != nil && n.word {
+    return n.value, true
+  }
+  var zero V
+  return zero, false
+}
+
+func (t *Trie[V]) Contains(key string) bool {
+  n := t.find(key)
+  return n != nil && n.word
+}
+
+// HasPrefix reports whether any key starts with prefix. Every node but
+// the root lies on the way to a key, so only the root needs the count.
+func (t *Trie[V]) HasPrefix(prefix string) bool {
+  return t.n > 0 && t.find(prefix) != nil
+}
+
+// Len is the number of keys.
+func (t *Trie[V]) Len() int {
+  return t.n
+}
+
+// WithPrefix yields every key starting with prefix, and its value, in
+// byte order.
+func (t *Trie[V]) WithPrefix(prefix string) iter.Seq2[string, V] {
+  return func(yield func(string, V) bool) {
+    if n := t.find(prefix); n != nil {
+      n.all([]byte(prefix), yield)
+    }
+  }
+}
+
+// All yields every key and its value, in byte order.
+func (t *Trie[V]) All() iter.Seq2[string, V] {
+  return t.WithPrefix("")
+}
+
+func (n *node[V]) all(key []byte, yield func(string, V) bool) bool {
+  if n.word && !yield(string(key), n.value) {
+    return false
+  }
+  keys := make([]byte, 0, len(n.children))
+  for b := range n.children {
+    keys = append(keys, b)
+  }
+  slices.Sort(keys)
+  for _, b := range keys {
+    if !n.children[b].all(append(key, b), yield) {
+      return false
+    }
+  }
+  return true
+}
+
+// Prefixes yields the length of each key that s starts with, shortest
+// first, and its value: the pieces that could begin s, found in one pass
+// down the trie rather than by trying every key.
+func (t *Trie[V]) Prefixes(s string) iter.Seq2[int, V] {
+  return func(yield func(int, V) bool) {
+    n := &t.root
+    for i := 0; ; i++ {
+      if n.word && !yield(i, n.value) {
+        return
+      }
+      if i == len(s) {
+        return
+      }
+      if n = n.children[s[i]]; n == nil {
+        return
+      }
+    }
+  }
+}
+
+// Walk follows s down the trie a byte at a time, calling fn at each step
+// with the part of s read so far, its value, and whether it is a key. Walk
+// stops at the end of s, when no key goes on with the next byte, or when
+// fn returns false, and reports how much of s it read, so a word search
+// can tell a dead end, where no word continues, from a prefix that may
+// still become one.
+func (t *Trie[V]) Walk(s string, fn func(prefix string, v V, word bool) bool) int {
+  n := &t.root
+  for i := 0; ; i++ {
+    if !fn(s[:i], n.value, n.word) {
+      return i
+    }
+    if i == len(s) {
+      return i
+    }
+    next := n.children[s[i]]
+    if next == nil {
+      return i
+    }
+    n = next
+  }
+}
@@ -0,0 +1,74 @@
+package trie
+
+import (
+  "math/rand"
+  "slices"
+  "strings"
+  "testing"
+
+  "github.com/nixternal/CodingChallenges/pkg/prop"
+)
+
+// Short words over a small alphabet, so that keys share prefixes often.
+var word = prop.Gen[string]{
+  Generate: func(r *rand.Rand, size int) string {
+    b := make([]byte, r.Intn(5))
+    for i := range b {
+      b[i] = "abc"[r.Intn(3)]
+    }
+    return string(b)
+  },
+}
+
+// WithPrefix, Prefixes and Len agree with scanning the list of words.
+func TestAgainstList(t *testing.T) {
+  g := prop.PairOf(prop.SliceOf(word, 20), word)
+  prop.Check(t, prop.Config{}, g, func(p prop.Pair[[]string, string]) bool {
+    words, s := p.A, p.B
+    tr := Words(words...)
+    uniq := slices.Clone(words)
+    slices.Sort(uniq)
+    uniq = slices.Compact(uniq)
+    if tr.Len() != len(uniq) {
+      return false
+    }
+
+    var want, got []string
+    for _, w := range uniq {
+      if strings.HasPrefix(w, s) {
+        want = append(want, w)
+      }
+    }
+    for k := range tr.WithPrefix(s) {
+      got = append(got, k)
+    }
+    if !slices.Equal(got, want) || tr.HasPrefix(s) != (len(want) > 0) {
+      return false
+    }
+
+    var wantN, gotN []int
+    for i := 0; i <= len(s); i++ {
+      if slices.Contains(uniq, s[:i]) {
+        wantN = append(wantN, i)
+      }
+    }
+    for n := range tr.Prefixes(s) {
+      gotN = append(gotN, n)
+    }
+    return slices.Equal(gotN, wantN) && tr.Contains(s) == slices.Contains(uniq, s)
+  })
+}
+
+func TestWalk(t *testing.T) {
+  tr := Words("ab", "abcd")
+  var words []string
+  n := tr.Walk("abcx", func(prefix string, _ bool, word bool) bool {
+    if word {
+      words = append(words, prefix)
+    }
+    return true
+  })
+  if n != 3 || !slices.Equal(words, []string{"ab"}) {
+    t.Errorf("Walk read %d bytes and found %q, want 3 and [ab]", n, words)
+  }
+}